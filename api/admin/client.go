@@ -28,6 +28,9 @@ type Client interface {
 	SetLoggerLevel(ctx context.Context, loggerName, logLevel, displayLevel string, options ...rpc.Option) error
 	GetLoggerLevel(ctx context.Context, loggerName string, options ...rpc.Option) (map[string]LogAndDisplayLevels, error)
 	GetConfig(ctx context.Context, options ...rpc.Option) (interface{}, error)
+	CreateBackup(ctx context.Context, directory string, options ...rpc.Option) error
+	GetBackupStatus(ctx context.Context, options ...rpc.Option) (*GetBackupStatusReply, error)
+	GetDatabaseStats(ctx context.Context, refresh bool, options ...rpc.Option) (*GetDatabaseStatsReply, error)
 }
 
 // Client implementation for the Pepecoin Platform Info API Endpoint
@@ -138,3 +141,23 @@ func (c *client) GetConfig(ctx context.Context, options ...rpc.Option) (interfac
 	err := c.requester.SendRequest(ctx, "admin.getConfig", struct{}{}, &res, options...)
 	return res, err
 }
+
+func (c *client) CreateBackup(ctx context.Context, directory string, options ...rpc.Option) error {
+	return c.requester.SendRequest(ctx, "admin.createBackup", &CreateBackupArgs{
+		Directory: directory,
+	}, &api.EmptyReply{}, options...)
+}
+
+func (c *client) GetBackupStatus(ctx context.Context, options ...rpc.Option) (*GetBackupStatusReply, error) {
+	res := &GetBackupStatusReply{}
+	err := c.requester.SendRequest(ctx, "admin.getBackupStatus", struct{}{}, res, options...)
+	return res, err
+}
+
+func (c *client) GetDatabaseStats(ctx context.Context, refresh bool, options ...rpc.Option) (*GetDatabaseStatsReply, error) {
+	res := &GetDatabaseStatsReply{}
+	err := c.requester.SendRequest(ctx, "admin.getDatabaseStats", &GetDatabaseStatsArgs{
+		Refresh: refresh,
+	}, res, options...)
+	return res, err
+}
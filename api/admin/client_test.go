@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/memeticofficial/pepecoingo/api"
+	"github.com/memeticofficial/pepecoingo/database/stats"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
 	"github.com/memeticofficial/pepecoingo/utils/rpc"
@@ -66,6 +67,12 @@ func (mc *mockClient) SendRequest(_ context.Context, _ string, _ interface{}, re
 	case *GetLoggerLevelReply:
 		response := mc.response.(*GetLoggerLevelReply)
 		*p = *response
+	case *GetBackupStatusReply:
+		response := mc.response.(*GetBackupStatusReply)
+		*p = *response
+	case *GetDatabaseStatsReply:
+		response := mc.response.(*GetDatabaseStatsReply)
+		*p = *response
 	case *interface{}:
 		response := mc.response.(*interface{})
 		*p = *response
@@ -381,3 +388,60 @@ func TestGetConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateBackup(t *testing.T) {
+	tests := GetSuccessResponseTests()
+
+	for _, test := range tests {
+		mockClient := client{requester: NewMockClient(&api.EmptyReply{}, test.Err)}
+		err := mockClient.CreateBackup(context.Background(), "dir")
+		require.ErrorIs(t, err, test.Err)
+	}
+}
+
+func TestGetBackupStatus(t *testing.T) {
+	t.Run("successful", func(t *testing.T) {
+		expectedReply := &GetBackupStatusReply{
+			Directory:  "dir",
+			InProgress: true,
+			NumKeys:    1,
+		}
+		mockClient := client{requester: NewMockClient(expectedReply, nil)}
+
+		reply, err := mockClient.GetBackupStatus(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expectedReply, reply)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mockClient := client{requester: NewMockClient(&GetBackupStatusReply{}, errTest)}
+
+		_, err := mockClient.GetBackupStatus(context.Background())
+		require.ErrorIs(t, err, errTest)
+	})
+}
+
+func TestGetDatabaseStats(t *testing.T) {
+	t.Run("successful", func(t *testing.T) {
+		expectedReply := &GetDatabaseStatsReply{
+			Stats: stats.Stats{
+				Total: stats.Usage{
+					Keys: 1,
+					Size: 10,
+				},
+			},
+		}
+		mockClient := client{requester: NewMockClient(expectedReply, nil)}
+
+		reply, err := mockClient.GetDatabaseStats(context.Background(), true)
+		require.NoError(t, err)
+		require.Equal(t, expectedReply, reply)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		mockClient := client{requester: NewMockClient(&GetDatabaseStatsReply{}, errTest)}
+
+		_, err := mockClient.GetDatabaseStats(context.Background(), false)
+		require.ErrorIs(t, err, errTest)
+	})
+}
@@ -4,9 +4,11 @@
 package admin
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"path"
+	"sync"
 
 	"github.com/gorilla/rpc/v2"
 
@@ -15,6 +17,10 @@ import (
 	"github.com/memeticofficial/pepecoingo/api"
 	"github.com/memeticofficial/pepecoingo/api/server"
 	"github.com/memeticofficial/pepecoingo/chains"
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/backup"
+	"github.com/memeticofficial/pepecoingo/database/manager"
+	"github.com/memeticofficial/pepecoingo/database/stats"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/engine/common"
 	"github.com/memeticofficial/pepecoingo/utils"
@@ -37,6 +43,10 @@ const (
 var (
 	errAliasTooLong = errors.New("alias length is too long")
 	errNoLogLevel   = errors.New("need to specify either displayLevel or logLevel")
+
+	errNoBackupDirectory = errors.New("need to specify a backup directory")
+	errBackupInProgress  = errors.New("a backup is already in progress")
+	errNoBackup          = errors.New("no backup has been started")
 )
 
 type Config struct {
@@ -48,12 +58,18 @@ type Config struct {
 	HTTPServer   server.PathAdderWithReadLock
 	VMRegistry   registry.VMRegistry
 	VMManager    vms.Manager
+	DBManager    manager.Manager
+	DBStats      stats.Collector
 }
 
 // Admin is the API service for node admin management
 type Admin struct {
 	Config
 	profiler profiler.Profiler
+
+	backupLock sync.Mutex
+	// backupStatus is nil if no backup has been started
+	backupStatus *GetBackupStatusReply
 }
 
 // NewService returns a new admin API service.
@@ -345,3 +361,136 @@ func (a *Admin) LoadVMs(r *http.Request, _ *struct{}, reply *LoadVMsReply) error
 	reply.NewVMs, err = ids.GetRelevantAliases(a.VMManager, loadedVMs)
 	return err
 }
+
+// CreateBackupArgs are the arguments for calling CreateBackup
+type CreateBackupArgs struct {
+	// Directory the backup will be written to. It must either not exist or be
+	// empty.
+	Directory string `json:"directory"`
+}
+
+// CreateBackup starts writing a point-in-time copy of the node's current
+// database into [args.Directory]. Only one backup can be in progress at a time.
+// The progress of the backup can be queried with GetBackupStatus.
+func (a *Admin) CreateBackup(_ *http.Request, args *CreateBackupArgs, _ *api.EmptyReply) error {
+	a.Log.Debug("API called",
+		zap.String("service", "admin"),
+		zap.String("method", "createBackup"),
+		logging.UserString("directory", args.Directory),
+	)
+
+	if len(args.Directory) == 0 {
+		return errNoBackupDirectory
+	}
+
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	if a.backupStatus != nil && a.backupStatus.InProgress {
+		return errBackupInProgress
+	}
+
+	currentDB := a.DBManager.Current()
+	a.backupStatus = &GetBackupStatusReply{
+		Directory:  args.Directory,
+		InProgress: true,
+	}
+	go a.createBackup(backup.Config{
+		Directory:       args.Directory,
+		DatabaseVersion: currentDB.Version,
+		OnProgress: func(progress backup.Progress) {
+			a.backupLock.Lock()
+			defer a.backupLock.Unlock()
+
+			a.backupStatus.NumKeys = json.Uint64(progress.NumKeys)
+			a.backupStatus.NumBytes = json.Uint64(progress.NumBytes)
+		},
+	}, currentDB.Database)
+	return nil
+}
+
+func (a *Admin) createBackup(config backup.Config, db database.Database) {
+	a.Log.Info("starting database backup",
+		zap.String("directory", config.Directory),
+		zap.Stringer("version", config.DatabaseVersion),
+	)
+
+	manifest, err := backup.Create(context.Background(), db, config)
+
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	a.backupStatus.InProgress = false
+	if err != nil {
+		a.Log.Error("database backup failed",
+			zap.String("directory", config.Directory),
+			zap.Error(err),
+		)
+		a.backupStatus.Error = err.Error()
+		return
+	}
+
+	a.Log.Info("finished database backup",
+		zap.String("directory", config.Directory),
+		zap.Uint64("numKeys", manifest.NumKeys),
+		zap.Uint64("numBytes", manifest.NumBytes),
+	)
+	a.backupStatus.Manifest = manifest
+}
+
+// GetBackupStatusReply is the status of the most recently started backup
+type GetBackupStatusReply struct {
+	Directory  string           `json:"directory"`
+	InProgress bool             `json:"inProgress"`
+	NumKeys    json.Uint64      `json:"numKeys"`
+	NumBytes   json.Uint64      `json:"numBytes"`
+	Manifest   *backup.Manifest `json:"manifest,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// GetBackupStatus returns the status of the most recently started backup.
+func (a *Admin) GetBackupStatus(_ *http.Request, _ *struct{}, reply *GetBackupStatusReply) error {
+	a.Log.Debug("API called",
+		zap.String("service", "admin"),
+		zap.String("method", "getBackupStatus"),
+	)
+
+	a.backupLock.Lock()
+	defer a.backupLock.Unlock()
+
+	if a.backupStatus == nil {
+		return errNoBackup
+	}
+	*reply = *a.backupStatus
+	return nil
+}
+
+// GetDatabaseStatsArgs are the arguments for calling GetDatabaseStats
+type GetDatabaseStatsArgs struct {
+	// If true, the stats are collected again rather than returning the most
+	// recently collected stats.
+	Refresh bool `json:"refresh"`
+}
+
+// GetDatabaseStatsReply is the storage used by the database, broken down by
+// chain and by the prefixes of each chain's VM
+type GetDatabaseStatsReply struct {
+	stats.Stats
+}
+
+// GetDatabaseStats returns the approximate storage used by each chain's
+// databases and by each of the prefixes registered by the chain's VM.
+func (a *Admin) GetDatabaseStats(_ *http.Request, args *GetDatabaseStatsArgs, reply *GetDatabaseStatsReply) error {
+	a.Log.Debug("API called",
+		zap.String("service", "admin"),
+		zap.String("method", "getDatabaseStats"),
+		zap.Bool("refresh", args.Refresh),
+	)
+
+	dbStats, err := a.DBStats.Stats(args.Refresh)
+	if err != nil {
+		return err
+	}
+	reply.Stats = *dbStats
+	return nil
+}
@@ -6,13 +6,22 @@ package admin
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"github.com/stretchr/testify/require"
 
+	"github.com/memeticofficial/pepecoingo/api"
+	"github.com/memeticofficial/pepecoingo/database/manager"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/stats"
 	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/json"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
+	"github.com/memeticofficial/pepecoingo/version"
 	"github.com/memeticofficial/pepecoingo/vms"
 	"github.com/memeticofficial/pepecoingo/vms/registry"
 )
@@ -116,3 +125,74 @@ func TestLoadVMsGetAliasesFails(t *testing.T) {
 	err := resources.admin.LoadVMs(&http.Request{}, nil, &reply)
 	require.ErrorIs(t, err, errTest)
 }
+
+func TestCreateBackupAndGetStatus(t *testing.T) {
+	require := require.New(t)
+
+	dbManager := manager.NewMemDB(version.CurrentDatabase)
+	require.NoError(dbManager.Current().Database.Put([]byte("key"), []byte("value")))
+
+	admin := &Admin{Config: Config{
+		Log:       logging.NoLog{},
+		DBManager: dbManager,
+	}}
+
+	err := admin.GetBackupStatus(&http.Request{}, nil, &GetBackupStatusReply{})
+	require.ErrorIs(err, errNoBackup)
+
+	err = admin.CreateBackup(&http.Request{}, &CreateBackupArgs{}, &api.EmptyReply{})
+	require.ErrorIs(err, errNoBackupDirectory)
+
+	dir := t.TempDir()
+	err = admin.CreateBackup(&http.Request{}, &CreateBackupArgs{Directory: dir}, &api.EmptyReply{})
+	require.NoError(err)
+
+	reply := GetBackupStatusReply{}
+	require.Eventually(func() bool {
+		require.NoError(admin.GetBackupStatus(&http.Request{}, nil, &reply))
+		return !reply.InProgress
+	}, 10*time.Second, 10*time.Millisecond)
+
+	require.Empty(reply.Error)
+	require.Equal(dir, reply.Directory)
+	require.Equal(json.Uint64(1), reply.NumKeys)
+	require.NotNil(reply.Manifest)
+	require.Equal(uint64(1), reply.Manifest.NumKeys)
+
+	// The directory is no longer empty, so the backup should fail.
+	err = admin.CreateBackup(&http.Request{}, &CreateBackupArgs{Directory: dir}, &api.EmptyReply{})
+	require.NoError(err)
+	require.Eventually(func() bool {
+		require.NoError(admin.GetBackupStatus(&http.Request{}, nil, &reply))
+		return !reply.InProgress
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NotEmpty(reply.Error)
+}
+
+func TestGetDatabaseStatsRefresh(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	dbStats, err := stats.New(logging.NoLog{}, db, stats.DefaultSampleSize, "", prometheus.NewRegistry())
+	require.NoError(err)
+
+	admin := &Admin{Config: Config{
+		Log:     logging.NoLog{},
+		DBStats: dbStats,
+	}}
+
+	require.NoError(db.Put([]byte("key"), []byte("value")))
+
+	reply := GetDatabaseStatsReply{}
+	require.NoError(admin.GetDatabaseStats(&http.Request{}, &GetDatabaseStatsArgs{}, &reply))
+	require.Equal(json.Uint64(1), reply.Total.Keys)
+
+	// The most recently collected stats are returned unless a refresh is
+	// requested.
+	require.NoError(db.Put([]byte("key2"), []byte("value")))
+	require.NoError(admin.GetDatabaseStats(&http.Request{}, &GetDatabaseStatsArgs{}, &reply))
+	require.Equal(json.Uint64(1), reply.Total.Keys)
+
+	require.NoError(admin.GetDatabaseStats(&http.Request{}, &GetDatabaseStatsArgs{Refresh: true}, &reply))
+	require.Equal(json.Uint64(2), reply.Total.Keys)
+}
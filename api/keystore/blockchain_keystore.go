@@ -23,6 +23,10 @@ type BlockchainKeystore interface {
 	// values. This Database will not perform any encrypting or decrypting of
 	// values and is not recommended to be used when implementing a VM.
 	GetRawDatabase(username, password string) (database.Database, error)
+
+	// Get the underlying database that is able to read and write encrypted
+	// values, and the config that its values are encrypted with.
+	GetRawDatabaseWithConfig(username, password string) (database.Database, *encdb.Config, error)
 }
 
 type blockchainKeystore struct {
@@ -49,3 +53,13 @@ func (bks *blockchainKeystore) GetRawDatabase(username, password string) (databa
 
 	return bks.ks.GetRawDatabase(bks.blockchainID, username, password)
 }
+
+func (bks *blockchainKeystore) GetRawDatabaseWithConfig(username, password string) (database.Database, *encdb.Config, error) {
+	bks.ks.log.Warn("deprecated keystore called",
+		zap.String("method", "getRawDatabaseWithConfig"),
+		logging.UserString("username", username),
+		zap.Stringer("blockchainID", bks.blockchainID),
+	)
+
+	return bks.ks.getDatabase(bks.blockchainID, username, password)
+}
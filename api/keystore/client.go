@@ -27,6 +27,8 @@ type Client interface {
 	ImportUser(ctx context.Context, importTo api.UserPass, exportedUser []byte, options ...rpc.Option) error
 	// Delete the given user
 	DeleteUser(context.Context, api.UserPass, ...rpc.Option) error
+	// Replace the password of the given user with [newPassword]
+	ChangePassword(ctx context.Context, user api.UserPass, newPassword string, options ...rpc.Option) error
 }
 
 // Client implementation for Pepecoin Keystore API Endpoint
@@ -79,3 +81,10 @@ func (c *client) ImportUser(ctx context.Context, user api.UserPass, account []by
 func (c *client) DeleteUser(ctx context.Context, user api.UserPass, options ...rpc.Option) error {
 	return c.requester.SendRequest(ctx, "keystore.deleteUser", &user, &api.EmptyReply{}, options...)
 }
+
+func (c *client) ChangePassword(ctx context.Context, user api.UserPass, newPassword string, options ...rpc.Option) error {
+	return c.requester.SendRequest(ctx, "keystore.changePassword", &ChangePasswordArgs{
+		UserPass:    user,
+		NewPassword: newPassword,
+	}, &api.EmptyReply{}, options...)
+}
@@ -5,6 +5,8 @@ package gkeystore
 
 import (
 	"context"
+	"errors"
+	"math"
 
 	"github.com/memeticofficial/pepecoingo/api/keystore"
 	"github.com/memeticofficial/pepecoingo/database"
@@ -16,42 +18,74 @@ import (
 	rpcdbpb "github.com/memeticofficial/pepecoingo/proto/pb/rpcdb"
 )
 
-var _ keystore.BlockchainKeystore = (*Client)(nil)
+var (
+	_ keystore.BlockchainKeystore = (*Client)(nil)
+
+	errMissingEncryptionConfig = errors.New("missing encryption config")
+	errInvalidArgon2Threads    = errors.New("invalid argon2 threads")
+)
 
 // Client is a snow.Keystore that talks over RPC.
 type Client struct {
 	client keystorepb.KeystoreClient
+	keys   *encdb.KeyCache
 }
 
 // NewClient returns a keystore instance connected to a remote keystore instance
 func NewClient(client keystorepb.KeystoreClient) *Client {
 	return &Client{
 		client: client,
+		keys:   encdb.NewKeyCache(keystore.KeyCacheSize),
 	}
 }
 
 func (c *Client) GetDatabase(username, password string) (*encdb.Database, error) {
-	bcDB, err := c.GetRawDatabase(username, password)
+	bcDB, config, err := c.GetRawDatabaseWithConfig(username, password)
 	if err != nil {
 		return nil, err
 	}
-	return encdb.New([]byte(password), bcDB)
+	key, err := c.keys.Get([]byte(password), *config)
+	if err != nil {
+		return nil, err
+	}
+	return encdb.NewWithKey(bcDB, key), nil
 }
 
 func (c *Client) GetRawDatabase(username, password string) (database.Database, error) {
+	bcDB, _, err := c.GetRawDatabaseWithConfig(username, password)
+	return bcDB, err
+}
+
+func (c *Client) GetRawDatabaseWithConfig(username, password string) (database.Database, *encdb.Config, error) {
 	resp, err := c.client.GetDatabase(context.Background(), &keystorepb.GetDatabaseRequest{
 		Username: username,
 		Password: password,
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	pbConfig := resp.EncryptionConfig
+	if pbConfig == nil {
+		return nil, nil, errMissingEncryptionConfig
+	}
+	if pbConfig.Argon2Threads > math.MaxUint8 {
+		return nil, nil, errInvalidArgon2Threads
+	}
+	config := &encdb.Config{
+		Argon2: encdb.Argon2Params{
+			Time:    pbConfig.Argon2Time,
+			Memory:  pbConfig.Argon2Memory,
+			Threads: uint8(pbConfig.Argon2Threads),
+		},
+		Salt: pbConfig.Salt,
 	}
 
 	clientConn, err := grpcutils.Dial(resp.ServerAddr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	dbClient := rpcdb.NewClient(rpcdbpb.NewDatabaseClient(clientConn))
-	return dbClient, err
+	return dbClient, config, nil
 }
@@ -34,7 +34,7 @@ func (s *Server) GetDatabase(
 	_ context.Context,
 	req *keystorepb.GetDatabaseRequest,
 ) (*keystorepb.GetDatabaseResponse, error) {
-	db, err := s.ks.GetRawDatabase(req.Username, req.Password)
+	db, config, err := s.ks.GetRawDatabaseWithConfig(req.Username, req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +53,15 @@ func (s *Server) GetDatabase(
 	// start the db server
 	go grpcutils.Serve(serverListener, server)
 
-	return &keystorepb.GetDatabaseResponse{ServerAddr: serverListener.Addr().String()}, nil
+	return &keystorepb.GetDatabaseResponse{
+		ServerAddr: serverListener.Addr().String(),
+		EncryptionConfig: &keystorepb.EncryptionConfig{
+			Argon2Time:    config.Argon2.Time,
+			Argon2Memory:  config.Argon2.Memory,
+			Argon2Threads: uint32(config.Argon2.Threads),
+			Salt:          config.Salt,
+		},
+	}, nil
 }
 
 type dbCloser struct {
@@ -25,14 +25,20 @@ import (
 const (
 	// maxUserLen is the maximum allowed length of a username
 	maxUserLen = 1024
+
+	// KeyCacheSize is the number of derived encryption keys that are cached
+	KeyCacheSize = 64
 )
 
 var (
 	errEmptyUsername = errors.New("empty username")
 	errUserMaxLength = fmt.Errorf("username exceeds maximum length of %d chars", maxUserLen)
 
-	usersPrefix = []byte("users")
-	bcsPrefix   = []byte("bcs")
+	errMixedEncryptionConfigs = errors.New("imported values are encrypted with different configs")
+
+	usersPrefix      = []byte("users")
+	bcsPrefix        = []byte("bcs")
+	encryptionPrefix = []byte("encryption")
 
 	_ Keystore = (*keystore)(nil)
 )
@@ -73,6 +79,12 @@ type Keystore interface {
 	// with encrypted database values.
 	ExportUser(username, pw string) ([]byte, error)
 
+	// ChangePassword atomically replaces the password of [username] and
+	// re-encrypts all of the user's data with a key derived from [newPW].
+	// The databases previously returned for [username] are closed, so they
+	// can't write values encrypted with the old key.
+	ChangePassword(username, oldPW, newPW string) error
+
 	// Get the password that is used by [username]. If [username] doesn't exist,
 	// no error is returned and a nil password hash is returned.
 	getPassword(username string) (*password.Hash, error)
@@ -97,16 +109,33 @@ type keystore struct {
 	// Value: The hash of that user's password
 	usernameToPassword map[string]*password.Hash
 
+	// Key: username
+	// Value: The config that the user's data is encrypted with
+	usernameToConfig map[string]*encdb.Config
+
+	// Key: username
+	// Value: The database that every database returned for the user is built
+	// on. It is closed, which closes all of them, when the user's data is
+	// re-encrypted or deleted.
+	usernameToDB map[string]*prefixdb.Database
+
+	// keys caches the keys derived from users' passwords
+	keys *encdb.KeyCache
+
 	// Used to persist users and their data
 	userDB database.Database
 	bcDB   database.Database
-	//           BaseDB
-	//          /      \
-	//    UserDB        BlockchainDB
-	//                 /      |     \
-	//               Usr     Usr    Usr
-	//             /  |  \
-	//          BID  BID  BID
+	// Used to persist the config that each user's data is encrypted with.
+	// Users without a config were created by a legacy version of the
+	// keystore, so their data is re-encrypted the next time it is unlocked.
+	encryptionDB database.Database
+	//               BaseDB
+	//          /      |       \
+	//    UserDB  EncryptionDB  BlockchainDB
+	//                         /      |     \
+	//                       Usr     Usr    Usr
+	//                     /  |  \
+	//                  BID  BID  BID
 }
 
 func New(log logging.Logger, dbManager manager.Manager) Keystore {
@@ -114,8 +143,12 @@ func New(log logging.Logger, dbManager manager.Manager) Keystore {
 	return &keystore{
 		log:                log,
 		usernameToPassword: make(map[string]*password.Hash),
+		usernameToConfig:   make(map[string]*encdb.Config),
+		usernameToDB:       make(map[string]*prefixdb.Database),
+		keys:               encdb.NewKeyCache(KeyCacheSize),
 		userDB:             prefixdb.New(usersPrefix, currentDB.Database),
 		bcDB:               prefixdb.New(bcsPrefix, currentDB.Database),
+		encryptionDB:       prefixdb.New(encryptionPrefix, currentDB.Database),
 	}
 }
 
@@ -138,16 +171,28 @@ func (ks *keystore) NewBlockchainKeyStore(blockchainID ids.ID) BlockchainKeystor
 }
 
 func (ks *keystore) GetDatabase(bID ids.ID, username, password string) (*encdb.Database, error) {
-	bcDB, err := ks.GetRawDatabase(bID, username, password)
+	bcDB, config, err := ks.getDatabase(bID, username, password)
 	if err != nil {
 		return nil, err
 	}
-	return encdb.New([]byte(password), bcDB)
+	key, err := ks.keys.Get([]byte(password), *config)
+	if err != nil {
+		return nil, err
+	}
+	return encdb.NewWithKey(bcDB, key), nil
 }
 
 func (ks *keystore) GetRawDatabase(bID ids.ID, username, pw string) (database.Database, error) {
+	bcDB, _, err := ks.getDatabase(bID, username, pw)
+	return bcDB, err
+}
+
+// getDatabase returns the database of [username] for [bID] and the config
+// that the user's data is encrypted with. If the user's data was encrypted by
+// a legacy version of the keystore, it is re-encrypted first.
+func (ks *keystore) getDatabase(bID ids.ID, username, pw string) (database.Database, *encdb.Config, error) {
 	if username == "" {
-		return nil, errEmptyUsername
+		return nil, nil, errEmptyUsername
 	}
 
 	ks.lock.Lock()
@@ -155,15 +200,42 @@ func (ks *keystore) GetRawDatabase(bID ids.ID, username, pw string) (database.Da
 
 	passwordHash, err := ks.getPassword(username)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if passwordHash == nil || !passwordHash.Check(pw) {
-		return nil, fmt.Errorf("incorrect password for user %q", username)
+		return nil, nil, fmt.Errorf("incorrect password for user %q", username)
 	}
 
-	userDB := prefixdb.New([]byte(username), ks.bcDB)
+	config, err := ks.getEncryptionConfig(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config == nil {
+		config, err = ks.upgradeUser(username, pw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't upgrade encryption of user %q: %w", username, err)
+		}
+	}
+
+	userDB, ok := ks.usernameToDB[username]
+	if !ok {
+		userDB = prefixdb.New([]byte(username), ks.bcDB)
+		ks.usernameToDB[username] = userDB
+	}
 	bcDB := prefixdb.NewNested(bID[:], userDB)
-	return bcDB, nil
+	return bcDB, config, nil
+}
+
+// closeUserDB closes every database that was returned for [username].
+//
+// Assumes [ks.lock] is held.
+func (ks *keystore) closeUserDB(username string) error {
+	userDB, ok := ks.usernameToDB[username]
+	if !ok {
+		return nil
+	}
+	delete(ks.usernameToDB, username)
+	return userDB.Close()
 }
 
 func (ks *keystore) CreateUser(username, pw string) error {
@@ -199,11 +271,29 @@ func (ks *keystore) CreateUser(username, pw string) error {
 		return err
 	}
 
-	if err := ks.userDB.Put([]byte(username), passwordBytes); err != nil {
+	config, err := encdb.NewConfig()
+	if err != nil {
+		return err
+	}
+	configBytes, err := c.Marshal(codecVersion, &config)
+	if err != nil {
+		return err
+	}
+
+	userBatch := ks.userDB.NewBatch()
+	if err := userBatch.Put([]byte(username), passwordBytes); err != nil {
+		return err
+	}
+	encryptionBatch := ks.encryptionDB.NewBatch()
+	if err := encryptionBatch.Put([]byte(username), configBytes); err != nil {
 		return err
 	}
-	ks.usernameToPassword[username] = passwordHash
 
+	if err := atomic.WriteAll(userBatch, encryptionBatch); err != nil {
+		return err
+	}
+	ks.usernameToPassword[username] = passwordHash
+	ks.usernameToConfig[username] = &config
 	return nil
 }
 
@@ -229,11 +319,19 @@ func (ks *keystore) DeleteUser(username, pw string) error {
 		return fmt.Errorf("incorrect password for user %q", username)
 	}
 
+	if err := ks.closeUserDB(username); err != nil {
+		return err
+	}
+
 	userNameBytes := []byte(username)
 	userBatch := ks.userDB.NewBatch()
 	if err := userBatch.Delete(userNameBytes); err != nil {
 		return err
 	}
+	encryptionBatch := ks.encryptionDB.NewBatch()
+	if err := encryptionBatch.Delete(userNameBytes); err != nil {
+		return err
+	}
 
 	userDataDB := prefixdb.New(userNameBytes, ks.bcDB)
 	dataBatch := userDataDB.NewBatch()
@@ -251,12 +349,13 @@ func (ks *keystore) DeleteUser(username, pw string) error {
 		return err
 	}
 
-	if err := atomic.WriteAll(dataBatch, userBatch); err != nil {
+	if err := atomic.WriteAll(dataBatch, userBatch, encryptionBatch); err != nil {
 		return err
 	}
 
 	// delete from users map.
 	delete(ks.usernameToPassword, username)
+	delete(ks.usernameToConfig, username)
 	return nil
 }
 
@@ -311,18 +410,52 @@ func (ks *keystore) ImportUser(username, pw string, userBytes []byte) error {
 		return err
 	}
 
+	// The imported data is only readable with the config that it was
+	// encrypted with, so that config is recorded as the user's config.
+	var config *encdb.Config
 	userDataDB := prefixdb.New([]byte(username), ks.bcDB)
 	dataBatch := userDataDB.NewBatch()
 	for _, kvp := range userData.Data {
+		valueConfig, err := encdb.ValueConfig(kvp.Value)
+		if err != nil {
+			return fmt.Errorf("couldn't parse imported value: %w", err)
+		}
+		switch {
+		case valueConfig == nil:
+		case config == nil:
+			config = valueConfig
+		case !config.Equal(valueConfig):
+			return errMixedEncryptionConfigs
+		}
+
 		if err := dataBatch.Put(kvp.Key, kvp.Value); err != nil {
 			return fmt.Errorf("error on database put: %w", err)
 		}
 	}
 
-	if err := atomic.WriteAll(dataBatch, userBatch); err != nil {
+	batches := []database.Batch{userBatch}
+	if config != nil {
+		if err := config.Verify(); err != nil {
+			return fmt.Errorf("invalid imported encryption config: %w", err)
+		}
+		configBytes, err := c.Marshal(codecVersion, config)
+		if err != nil {
+			return err
+		}
+		encryptionBatch := ks.encryptionDB.NewBatch()
+		if err := encryptionBatch.Put([]byte(username), configBytes); err != nil {
+			return err
+		}
+		batches = append(batches, encryptionBatch)
+	}
+
+	if err := atomic.WriteAll(dataBatch, batches...); err != nil {
 		return err
 	}
 	ks.usernameToPassword[username] = &userData.Hash
+	if config != nil {
+		ks.usernameToConfig[username] = config
+	}
 	return nil
 }
 
@@ -364,6 +497,139 @@ func (ks *keystore) ExportUser(username, pw string) ([]byte, error) {
 	return c.Marshal(codecVersion, &userData)
 }
 
+func (ks *keystore) ChangePassword(username, oldPW, newPW string) error {
+	if username == "" {
+		return errEmptyUsername
+	}
+	if len(username) > maxUserLen {
+		return errUserMaxLength
+	}
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	passwordHash, err := ks.getPassword(username)
+	switch {
+	case err != nil:
+		return err
+	case passwordHash == nil:
+		return fmt.Errorf("user doesn't exist: %s", username)
+	case !passwordHash.Check(oldPW):
+		return fmt.Errorf("incorrect password for user %q", username)
+	}
+
+	if err := password.IsValid(newPW, password.OK); err != nil {
+		return err
+	}
+
+	newPasswordHash := &password.Hash{}
+	if err := newPasswordHash.Set(newPW); err != nil {
+		return err
+	}
+	passwordBytes, err := c.Marshal(codecVersion, newPasswordHash)
+	if err != nil {
+		return err
+	}
+	userBatch := ks.userDB.NewBatch()
+	if err := userBatch.Put([]byte(username), passwordBytes); err != nil {
+		return err
+	}
+
+	oldConfig, err := ks.getEncryptionConfig(username)
+	if err != nil {
+		return err
+	}
+	// Writes made through the user's open databases would be encrypted with
+	// the old key, so they are closed before the data is re-encrypted.
+	if err := ks.closeUserDB(username); err != nil {
+		return err
+	}
+	config, err := ks.reencrypt(username, oldPW, newPW, oldConfig, userBatch)
+	if err != nil {
+		return err
+	}
+	ks.usernameToPassword[username] = newPasswordHash
+	ks.usernameToConfig[username] = config
+	return nil
+}
+
+// upgradeUser re-encrypts the data of [username], which was written by a
+// legacy version of the keystore, and records the config that it is now
+// encrypted with.
+//
+// Assumes [ks.lock] is held.
+func (ks *keystore) upgradeUser(username, pw string) (*encdb.Config, error) {
+	config, err := ks.reencrypt(username, pw, pw, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ks.log.Info("upgraded keystore user encryption",
+		logging.UserString("username", username),
+	)
+	ks.usernameToConfig[username] = config
+	return config, nil
+}
+
+// reencrypt decrypts all of the data of [username] with a key derived from
+// [oldPW] using [oldConfig] and encrypts it with a key derived from [newPW]
+// using a newly generated config. If [oldConfig] is nil, the data must have
+// been written by a legacy version of the keystore. The re-encrypted data, the
+// new config, and [batches] are written atomically.
+//
+// Assumes [ks.lock] is held.
+func (ks *keystore) reencrypt(
+	username string,
+	oldPW string,
+	newPW string,
+	oldConfig *encdb.Config,
+	batches ...database.Batch,
+) (*encdb.Config, error) {
+	config, err := encdb.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	configBytes, err := c.Marshal(codecVersion, &config)
+	if err != nil {
+		return nil, err
+	}
+	encryptionBatch := ks.encryptionDB.NewBatch()
+	if err := encryptionBatch.Put([]byte(username), configBytes); err != nil {
+		return nil, err
+	}
+
+	userDataDB := prefixdb.New([]byte(username), ks.bcDB)
+	if oldConfig == nil {
+		// Legacy values don't depend on the config.
+		oldConfig = &config
+	}
+	oldKey, err := ks.keys.Get([]byte(oldPW), *oldConfig)
+	if err != nil {
+		return nil, err
+	}
+	newKey, err := ks.keys.Get([]byte(newPW), config)
+	if err != nil {
+		return nil, err
+	}
+	oldDB := encdb.NewWithKey(userDataDB, oldKey)
+	newDB := encdb.NewWithKey(userDataDB, newKey)
+
+	dataBatch := newDB.NewBatch()
+	it := oldDB.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		if err := dataBatch.Put(it.Key(), it.Value()); err != nil {
+			return nil, err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	batches = append(batches, encryptionBatch)
+	return &config, atomic.WriteAll(dataBatch, batches...)
+}
+
 func (ks *keystore) getPassword(username string) (*password.Hash, error) {
 	// If the user is already in memory, return it
 	passwordHash, exists := ks.usernameToPassword[username]
@@ -386,3 +652,26 @@ func (ks *keystore) getPassword(username string) (*password.Hash, error) {
 	_, err = c.Unmarshal(userBytes, passwordHash)
 	return passwordHash, err
 }
+
+// getEncryptionConfig returns the config that the data of [username] is
+// encrypted with. If the user doesn't have a config, nil is returned.
+func (ks *keystore) getEncryptionConfig(username string) (*encdb.Config, error) {
+	if config, exists := ks.usernameToConfig[username]; exists {
+		return config, nil
+	}
+
+	configBytes, err := ks.encryptionDB.Get([]byte(username))
+	if err == database.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &encdb.Config{}
+	if _, err := c.Unmarshal(configBytes, config); err != nil {
+		return nil, err
+	}
+	ks.usernameToConfig[username] = config
+	return config, nil
+}
@@ -116,6 +116,23 @@ func (s *service) ExportUser(_ *http.Request, args *ExportUserArgs, reply *Expor
 	return nil
 }
 
+type ChangePasswordArgs struct {
+	// The username and current password of the user
+	api.UserPass
+	// The password that will replace the current password
+	NewPassword string `json:"newPassword"`
+}
+
+func (s *service) ChangePassword(_ *http.Request, args *ChangePasswordArgs, _ *api.EmptyReply) error {
+	s.ks.log.Warn("deprecated API called",
+		zap.String("service", "keystore"),
+		zap.String("method", "changePassword"),
+		logging.UserString("username", args.Username),
+	)
+
+	return s.ks.ChangePassword(args.Username, args.Password, args.NewPassword)
+}
+
 // CreateTestKeystore returns a new keystore that can be utilized for testing
 func CreateTestKeystore() (Keystore, error) {
 	dbManager, err := manager.NewManagerFromDBs([]*manager.VersionedDatabase{
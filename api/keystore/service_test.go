@@ -10,9 +10,17 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
 	"github.com/memeticofficial/pepecoingo/api"
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/encdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/formatting"
+	"github.com/memeticofficial/pepecoingo/utils/hashing"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
 )
 
 // strongPassword defines a password used for the following tests that
@@ -413,3 +421,177 @@ func TestServiceDeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceChangePassword(t *testing.T) {
+	require := require.New(t)
+
+	ks, err := CreateTestKeystore()
+	require.NoError(err)
+	s := service{ks: ks.(*keystore)}
+
+	oldUser := api.UserPass{
+		Username: "bob",
+		Password: strongPassword,
+	}
+	require.NoError(s.CreateUser(nil, &oldUser, &api.EmptyReply{}))
+
+	chainID1 := ids.GenerateTestID()
+	chainID2 := ids.GenerateTestID()
+	for _, chainID := range []ids.ID{chainID1, chainID2} {
+		db, err := ks.GetDatabase(chainID, oldUser.Username, oldUser.Password)
+		require.NoError(err)
+		require.NoError(db.Put(chainID[:], []byte("world")))
+	}
+	oldConfig := s.ks.usernameToConfig[oldUser.Username]
+
+	// The current password must be provided.
+	err = s.ChangePassword(nil, &ChangePasswordArgs{
+		UserPass: api.UserPass{
+			Username: oldUser.Username,
+			Password: "wrong password",
+		},
+		NewPassword: strongPassword + "new",
+	}, &api.EmptyReply{})
+	require.Error(err) //nolint:forbidigo // error is not exported
+
+	// The new password must be strong enough.
+	err = s.ChangePassword(nil, &ChangePasswordArgs{
+		UserPass:    oldUser,
+		NewPassword: "weak",
+	}, &api.EmptyReply{})
+	require.Error(err) //nolint:forbidigo // error is not exported
+
+	newUser := api.UserPass{
+		Username: oldUser.Username,
+		Password: strongPassword + "new",
+	}
+	require.NoError(s.ChangePassword(nil, &ChangePasswordArgs{
+		UserPass:    oldUser,
+		NewPassword: newUser.Password,
+	}, &api.EmptyReply{}))
+	require.NotEqual(oldConfig, s.ks.usernameToConfig[oldUser.Username])
+
+	_, err = ks.GetDatabase(chainID1, oldUser.Username, oldUser.Password)
+	require.Error(err) //nolint:forbidigo // error is not exported
+
+	for _, chainID := range []ids.ID{chainID1, chainID2} {
+		db, err := ks.GetDatabase(chainID, newUser.Username, newUser.Password)
+		require.NoError(err)
+		val, err := db.Get(chainID[:])
+		require.NoError(err)
+		require.Equal([]byte("world"), val)
+	}
+
+	// The data can no longer be decrypted with the old password.
+	rawDB, err := ks.GetRawDatabase(chainID1, newUser.Username, newUser.Password)
+	require.NoError(err)
+	config, err := ks.(*keystore).getEncryptionConfig(newUser.Username)
+	require.NoError(err)
+	oldDB, err := encdb.NewWithConfig([]byte(oldUser.Password), rawDB, *config)
+	require.NoError(err)
+	_, err = oldDB.Get(chainID1[:])
+	require.Error(err) //nolint:forbidigo // chacha20poly1305 does not export the error
+}
+
+func TestServiceChangePasswordOpenDatabase(t *testing.T) {
+	require := require.New(t)
+
+	ks, err := CreateTestKeystore()
+	require.NoError(err)
+	s := service{ks: ks.(*keystore)}
+
+	oldUser := api.UserPass{
+		Username: "bob",
+		Password: strongPassword,
+	}
+	require.NoError(s.CreateUser(nil, &oldUser, &api.EmptyReply{}))
+
+	chainID := ids.GenerateTestID()
+	db, err := ks.GetDatabase(chainID, oldUser.Username, oldUser.Password)
+	require.NoError(err)
+	rawDB, err := ks.GetRawDatabase(chainID, oldUser.Username, oldUser.Password)
+	require.NoError(err)
+	require.NoError(db.Put([]byte("hello"), []byte("world")))
+
+	newUser := api.UserPass{
+		Username: oldUser.Username,
+		Password: strongPassword + "new",
+	}
+	require.NoError(s.ChangePassword(nil, &ChangePasswordArgs{
+		UserPass:    oldUser,
+		NewPassword: newUser.Password,
+	}, &api.EmptyReply{}))
+
+	// The open databases use the old key, so they can no longer be used.
+	err = db.Put([]byte("hello"), []byte("moon"))
+	require.ErrorIs(err, database.ErrClosed)
+	_, err = db.Get([]byte("hello"))
+	require.ErrorIs(err, database.ErrClosed)
+	err = rawDB.Put([]byte("hello"), []byte("moon"))
+	require.ErrorIs(err, database.ErrClosed)
+
+	// A database opened with the new password reads the re-encrypted value.
+	db, err = ks.GetDatabase(chainID, newUser.Username, newUser.Password)
+	require.NoError(err)
+	val, err := db.Get([]byte("hello"))
+	require.NoError(err)
+	require.Equal([]byte("world"), val)
+}
+
+func TestServiceUpgradeLegacyUser(t *testing.T) {
+	require := require.New(t)
+
+	ks, err := CreateTestKeystore()
+	require.NoError(err)
+	s := service{ks: ks.(*keystore)}
+
+	user := api.UserPass{
+		Username: "bob",
+		Password: strongPassword,
+	}
+	require.NoError(s.CreateUser(nil, &user, &api.EmptyReply{}))
+
+	// Users created by legacy versions of the keystore don't have an
+	// encryption config.
+	chainID := ids.GenerateTestID()
+	rawDB, err := ks.GetRawDatabase(chainID, user.Username, user.Password)
+	require.NoError(err)
+	putLegacyValue(t, rawDB, user.Password, []byte("hello"), []byte("world"))
+
+	require.NoError(s.ks.encryptionDB.Delete([]byte(user.Username)))
+	delete(s.ks.usernameToConfig, user.Username)
+
+	// Unlocking the user re-encrypts the user's data with a new config.
+	db, err := ks.GetDatabase(chainID, user.Username, user.Password)
+	require.NoError(err)
+	val, err := db.Get([]byte("hello"))
+	require.NoError(err)
+	require.Equal([]byte("world"), val)
+
+	config, err := s.ks.getEncryptionConfig(user.Username)
+	require.NoError(err)
+	require.NotNil(config)
+
+	has, err := s.ks.encryptionDB.Has([]byte(user.Username))
+	require.NoError(err)
+	require.True(has)
+}
+
+// putLegacyValue writes [value] to [db] encrypted the way that legacy versions
+// of the keystore encrypted values.
+func putLegacyValue(t *testing.T, db database.KeyValueWriter, pw string, key, value []byte) {
+	require := require.New(t)
+
+	aead, err := chacha20poly1305.NewX(hashing.ComputeHash256([]byte(pw)))
+	require.NoError(err)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	_, err = rand.Read(nonce)
+	require.NoError(err)
+
+	p := wrappers.Packer{MaxSize: 1024}
+	p.PackShort(0) // legacy codec version
+	p.PackBytes(aead.Seal(nil, nonce, value, nil))
+	p.PackBytes(nonce)
+	require.NoError(p.Err)
+	require.NoError(db.Put(key, p.Bytes))
+}
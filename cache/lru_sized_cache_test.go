@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+func TestSizedLRU(t *testing.T) {
+	cache := NewSizedLRU[ids.ID, int](1, func(ids.ID, int) int { return 1 })
+
+	TestBasic(t, cache)
+}
+
+func TestSizedLRUEviction(t *testing.T) {
+	cache := NewSizedLRU[ids.ID, int](2, func(ids.ID, int) int { return 1 })
+
+	TestEviction(t, cache)
+}
+
+func TestSizedLRUSize(t *testing.T) {
+	require := require.New(t)
+
+	// Each entry is as large as its value.
+	cache := NewSizedLRU[ids.ID, int](10, func(_ ids.ID, v int) int { return v })
+
+	id1 := ids.ID{1}
+	id2 := ids.ID{2}
+	id3 := ids.ID{3}
+
+	cache.Put(id1, 4)
+	cache.Put(id2, 5)
+
+	val, found := cache.Get(id1)
+	require.True(found)
+	require.Equal(4, val)
+
+	// Inserting [id3] requires evicting the least recently used entry, [id2].
+	cache.Put(id3, 6)
+
+	_, found = cache.Get(id2)
+	require.False(found)
+
+	val, found = cache.Get(id1)
+	require.True(found)
+	require.Equal(4, val)
+
+	val, found = cache.Get(id3)
+	require.True(found)
+	require.Equal(6, val)
+
+	// Replacing an entry only accounts for the size of the new value.
+	cache.Put(id3, 5)
+
+	val, found = cache.Get(id1)
+	require.True(found)
+	require.Equal(4, val)
+
+	// Evicting an entry frees its space.
+	cache.Evict(id1)
+	cache.Put(id2, 5)
+
+	val, found = cache.Get(id2)
+	require.True(found)
+	require.Equal(5, val)
+
+	val, found = cache.Get(id3)
+	require.True(found)
+	require.Equal(5, val)
+
+	// An entry that can never fit clears the cache.
+	cache.Put(id3, 11)
+
+	_, found = cache.Get(id2)
+	require.False(found)
+	_, found = cache.Get(id3)
+	require.False(found)
+}
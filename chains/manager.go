@@ -24,6 +24,8 @@ import (
 	"github.com/memeticofficial/pepecoingo/api/server"
 	"github.com/memeticofficial/pepecoingo/chains/atomic"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/database/rpcdb"
+	"github.com/memeticofficial/pepecoingo/database/stats"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/message"
 	"github.com/memeticofficial/pepecoingo/network"
@@ -160,9 +162,27 @@ type chain struct {
 // ChainConfig is configuration settings for the current execution.
 // [Config] is the user-provided config blob for the chain.
 // [Upgrade] is a chain-specific blob for coordinating upgrades.
+// [Database] configures the database provided to the chain's VM.
 type ChainConfig struct {
-	Config  []byte
-	Upgrade []byte
+	Config   []byte
+	Upgrade  []byte
+	Database DatabaseConfig
+}
+
+// DatabaseConfig is the node-level configuration of the database provided to
+// a chain's VM.
+type DatabaseConfig struct {
+	// CacheSize is the maximum number of bytes of reads from the VM's database
+	// to cache. If 0, reads aren't cached.
+	CacheSize int `json:"cacheSize"`
+	// RPC configures how a VM that runs in a separate process reads from its
+	// database. Fields that aren't set use their defaults.
+	RPC rpcdb.ClientConfig `json:"rpc"`
+}
+
+// rpcDatabaseVM is implemented by VMs that access their database over RPC.
+type rpcDatabaseVM interface {
+	SetDatabaseClientConfig(config rpcdb.ClientConfig)
 }
 
 type ManagerConfig struct {
@@ -179,6 +199,7 @@ type ManagerConfig struct {
 	TxAcceptorGroup             snow.AcceptorGroup
 	VertexAcceptorGroup         snow.AcceptorGroup
 	DBManager                   dbManager.Manager
+	DatabaseStats               stats.ChainRegisterer      // Reports the storage used by each chain
 	MsgCreator                  message.OutboundMsgBuilder // message creator, shared with network
 	Router                      router.Router              // Routes incoming messages to the appropriate chain
 	Net                         network.Network            // Sends consensus messages to other validators
@@ -500,6 +521,14 @@ func (m *manager) buildChain(chainParams ChainParameters, sb subnets.Subnet) (*c
 	}
 	// TODO: Shutdown VM if an error occurs
 
+	if rpcVM, ok := vm.(rpcDatabaseVM); ok {
+		chainConfig, err := m.getChainConfig(chainParams.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error while getting chain config: %w", err)
+		}
+		rpcVM.SetDatabaseClientConfig(chainConfig.Database.RPC)
+	}
+
 	fxs := make([]*common.Fx, len(chainParams.FxIDs))
 	for i, fxID := range chainParams.FxIDs {
 		// Get a factory for the fx we want to use on our chain
@@ -538,7 +567,10 @@ func (m *manager) buildChain(chainParams ChainParameters, sb subnets.Subnet) (*c
 
 	bootstrapWeight := beacons.Weight()
 
-	var chain *chain
+	var (
+		chain           *chain
+		chainDBPrefixes [][]byte
+	)
 	switch vm := vm.(type) {
 	case vertex.LinearizableVMWithEngine:
 		chain, err = m.createPepecoinChain(
@@ -554,6 +586,12 @@ func (m *manager) buildChain(chainParams ChainParameters, sb subnets.Subnet) (*c
 		if err != nil {
 			return nil, fmt.Errorf("error while creating new pepecoin vm %w", err)
 		}
+		chainDBPrefixes = [][]byte{
+			vertexDBPrefix,
+			vertexBootstrappingDBPrefix,
+			txBootstrappingDBPrefix,
+			blockBootstrappingDBPrefix,
+		}
 	case block.ChainVM:
 		chain, err = m.createSnowmanChain(
 			ctx,
@@ -568,6 +606,9 @@ func (m *manager) buildChain(chainParams ChainParameters, sb subnets.Subnet) (*c
 		if err != nil {
 			return nil, fmt.Errorf("error while creating new snowman vm %w", err)
 		}
+		chainDBPrefixes = [][]byte{
+			bootstrappingDB,
+		}
 	default:
 		return nil, errUnknownVMType
 	}
@@ -577,6 +618,18 @@ func (m *manager) buildChain(chainParams ChainParameters, sb subnets.Subnet) (*c
 		return nil, err
 	}
 
+	// Register the chain's databases so that their storage usage is reported
+	dbPrefixes := map[string][]byte{
+		stats.VMDatabase: prefixdb.MakePrefix(chainParams.ID[:], vmDBPrefix),
+		// Every VM is wrapped by the proposervm, which stores its state in
+		// the VM's database.
+		string(proposervm.DBPrefix): prefixdb.MakePrefix(chainParams.ID[:], vmDBPrefix, proposervm.DBPrefix),
+	}
+	for _, prefix := range chainDBPrefixes {
+		dbPrefixes[string(prefix)] = prefixdb.MakePrefix(chainParams.ID[:], prefix)
+	}
+	m.DatabaseStats.RegisterChain(chainParams.ID, primaryAlias, chainParams.VMID, dbPrefixes)
+
 	return chain, nil
 }
 
@@ -603,12 +656,25 @@ func (m *manager) createPepecoinChain(
 		State: snow.Initializing,
 	})
 
+	chainConfig, err := m.getChainConfig(ctx.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching chain config: %w", err)
+	}
+
 	meterDBManager, err := m.DBManager.NewMeterDBManager("db", ctx.Registerer)
 	if err != nil {
 		return nil, err
 	}
 	prefixDBManager := meterDBManager.NewPrefixDBManager(ctx.ChainID[:])
 	vmDBManager := prefixDBManager.NewPrefixDBManager(vmDBPrefix)
+	if chainConfig.Database.CacheSize > 0 {
+		// The cache is beneath the prefixes, so the VM's keys are the same
+		// whether or not its reads are cached.
+		vmDBManager, err = meterDBManager.NewCacheDBManager(chainConfig.Database.CacheSize, "vm_db_cache", ctx.Registerer, ctx.ChainID[:], vmDBPrefix)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	db := prefixDBManager.Current()
 	vertexDB := prefixdb.New(vertexDBPrefix, db.Database)
@@ -689,11 +755,6 @@ func (m *manager) createPepecoinChain(
 		return nil, fmt.Errorf("problem initializing event dispatcher: %w", err)
 	}
 
-	chainConfig, err := m.getChainConfig(ctx.ChainID)
-	if err != nil {
-		return nil, fmt.Errorf("error while fetching chain config: %w", err)
-	}
-
 	if m.MeterVMEnabled {
 		vm = metervm.NewVertexVM(vm)
 	}
@@ -994,12 +1055,25 @@ func (m *manager) createSnowmanChain(
 		State: snow.Initializing,
 	})
 
+	chainConfig, err := m.getChainConfig(ctx.ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("error while fetching chain config: %w", err)
+	}
+
 	meterDBManager, err := m.DBManager.NewMeterDBManager("db", ctx.Registerer)
 	if err != nil {
 		return nil, err
 	}
 	prefixDBManager := meterDBManager.NewPrefixDBManager(ctx.ChainID[:])
 	vmDBManager := prefixDBManager.NewPrefixDBManager(vmDBPrefix)
+	if chainConfig.Database.CacheSize > 0 {
+		// The cache is beneath the prefixes, so the VM's keys are the same
+		// whether or not its reads are cached.
+		vmDBManager, err = meterDBManager.NewCacheDBManager(chainConfig.Database.CacheSize, "vm_db_cache", ctx.Registerer, ctx.ChainID[:], vmDBPrefix)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	db := prefixDBManager.Current()
 	bootstrappingDB := prefixdb.New(bootstrappingDB, db.Database)
@@ -1090,11 +1164,6 @@ func (m *manager) createSnowmanChain(
 	}
 
 	// Initialize the ProposerVM and the vm wrapped inside it
-	chainConfig, err := m.getChainConfig(ctx.ChainID)
-	if err != nil {
-		return nil, fmt.Errorf("error while fetching chain config: %w", err)
-	}
-
 	minBlockDelay := proposervm.DefaultMinBlockDelay
 	if subnetCfg, ok := m.SubnetConfigs[ctx.SubnetID]; ok {
 		minBlockDelay = subnetCfg.ProposerMinBlockDelay
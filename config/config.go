@@ -57,6 +57,7 @@ import (
 const (
 	chainConfigFileName  = "config"
 	chainUpgradeFileName = "upgrade"
+	chainDBFileName      = "database"
 	subnetConfigFileExt  = ".json"
 	ipResolutionTimeout  = 30 * time.Second
 )
@@ -903,6 +904,16 @@ func getTrackedSubnets(v *viper.Viper) (set.Set[ids.ID], error) {
 	return trackedSubnetIDs, nil
 }
 
+// GetDatabaseConfig returns the database config described by [v] without
+// parsing the rest of the node config.
+func GetDatabaseConfig(v *viper.Viper) (node.DatabaseConfig, error) {
+	networkID, err := constants.NetworkID(v.GetString(NetworkNameKey))
+	if err != nil {
+		return node.DatabaseConfig{}, err
+	}
+	return getDatabaseConfig(v, networkID)
+}
+
 func getDatabaseConfig(v *viper.Viper, networkID uint32) (node.DatabaseConfig, error) {
 	var (
 		configBytes []byte
@@ -928,7 +939,8 @@ func getDatabaseConfig(v *viper.Viper, networkID uint32) (node.DatabaseConfig, e
 			GetExpandedArg(v, DBPathKey),
 			constants.NetworkName(networkID),
 		),
-		Config: configBytes,
+		Config:         configBytes,
+		StatsFrequency: v.GetDuration(DBStatsFrequencyKey),
 	}, nil
 }
 
@@ -1076,9 +1088,23 @@ func readChainConfigPath(chainConfigPath string) (map[string]chains.ChainConfig,
 			return chainConfigMap, err
 		}
 
+		// chainconfigdir/chainId/database.*
+		dbData, err := storage.ReadFileWithName(chainDir, chainDBFileName)
+		if err != nil {
+			return chainConfigMap, err
+		}
+
+		var dbConfig chains.DatabaseConfig
+		if len(dbData) > 0 {
+			if err := json.Unmarshal(dbData, &dbConfig); err != nil {
+				return chainConfigMap, fmt.Errorf("%w on %s: %s", errUnmarshalling, chainDir, err)
+			}
+		}
+
 		chainConfigMap[dirInfo.Name()] = chains.ChainConfig{
-			Config:  configData,
-			Upgrade: upgradeData,
+			Config:   configData,
+			Upgrade:  upgradeData,
+			Database: dbConfig,
 		}
 	}
 	return chainConfigMap, nil
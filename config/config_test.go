@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/memeticofficial/pepecoingo/chains"
+	"github.com/memeticofficial/pepecoingo/database/rpcdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/consensus/snowball"
 	"github.com/memeticofficial/pepecoingo/subnets"
@@ -25,9 +26,10 @@ import (
 
 func TestGetChainConfigsFromFiles(t *testing.T) {
 	tests := map[string]struct {
-		configs  map[string]string
-		upgrades map[string]string
-		expected map[string]chains.ChainConfig
+		configs   map[string]string
+		upgrades  map[string]string
+		databases map[string]string
+		expected  map[string]chains.ChainConfig
 	}{
 		"no chain configs": {
 			configs:  map[string]string{},
@@ -58,6 +60,40 @@ func TestGetChainConfigsFromFiles(t *testing.T) {
 				m["C"] = chains.ChainConfig{Config: []byte("hello"), Upgrade: []byte("upgradess")}
 				m["X"] = chains.ChainConfig{Config: []byte("world"), Upgrade: []byte(nil)}
 
+				return m
+			}(),
+		},
+		"database config": {
+			configs:   map[string]string{"C": "hello", "X": "world"},
+			databases: map[string]string{"X": `{"cacheSize": 1024}`},
+			expected: func() map[string]chains.ChainConfig {
+				m := map[string]chains.ChainConfig{}
+				m["C"] = chains.ChainConfig{Config: []byte("hello"), Upgrade: []byte(nil)}
+				m["X"] = chains.ChainConfig{
+					Config:   []byte("world"),
+					Upgrade:  []byte(nil),
+					Database: chains.DatabaseConfig{CacheSize: 1024},
+				}
+
+				return m
+			}(),
+		},
+		"database rpc config": {
+			configs:   map[string]string{"X": "world"},
+			databases: map[string]string{"X": `{"rpc": {"iteratorBatchSize": 4096, "iteratorPrefetch": 8}}`},
+			expected: func() map[string]chains.ChainConfig {
+				m := map[string]chains.ChainConfig{}
+				m["X"] = chains.ChainConfig{
+					Config:  []byte("world"),
+					Upgrade: []byte(nil),
+					Database: chains.DatabaseConfig{
+						RPC: rpcdb.ClientConfig{
+							IteratorBatchSize: 4096,
+							IteratorPrefetch:  8,
+						},
+					},
+				}
+
 				return m
 			}(),
 		},
@@ -79,6 +115,10 @@ func TestGetChainConfigsFromFiles(t *testing.T) {
 				chainDir := filepath.Join(chainsDir, key)
 				setupFile(t, chainDir, chainUpgradeFileName+".ex", value)
 			}
+			for key, value := range test.databases {
+				chainDir := filepath.Join(chainsDir, key)
+				setupFile(t, chainDir, chainDBFileName+".json", value)
+			}
 
 			v := setupViper(configFile)
 
@@ -15,6 +15,7 @@ import (
 
 	"github.com/memeticofficial/pepecoingo/database/leveldb"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/pebbledb"
 	"github.com/memeticofficial/pepecoingo/genesis"
 	"github.com/memeticofficial/pepecoingo/trace"
 	"github.com/memeticofficial/pepecoingo/utils/compression"
@@ -99,10 +100,11 @@ func addNodeFlags(fs *pflag.FlagSet) {
 	fs.Uint64(AddSubnetDelegatorFeeKey, genesis.LocalParams.AddSubnetDelegatorFee, "Transaction fee, in nAVAX, for transactions that add new subnet delegators")
 
 	// Database
-	fs.String(DBTypeKey, leveldb.Name, fmt.Sprintf("Database type to use. Should be one of {%s, %s}", leveldb.Name, memdb.Name))
+	fs.String(DBTypeKey, leveldb.Name, fmt.Sprintf("Database type to use. Should be one of {%s, %s, %s}", leveldb.Name, memdb.Name, pebbledb.Name))
 	fs.String(DBPathKey, defaultDBDir, "Path to database directory")
 	fs.String(DBConfigFileKey, "", fmt.Sprintf("Path to database config file. Ignored if %s is specified", DBConfigContentKey))
 	fs.String(DBConfigContentKey, "", "Specifies base64 encoded database config content")
+	fs.Duration(DBStatsFrequencyKey, 10*time.Minute, "Frequency to collect the storage usage of each chain's database. If 0, the usage is only collected when requested through the admin API")
 
 	// Logging
 	fs.String(LogsDirKey, defaultLogDir, "Logging directory for Pepecoin")
@@ -0,0 +1,248 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package backup creates and restores point-in-time copies of a database.
+//
+// A backup is a directory containing a sequence of data files and a manifest.
+// Each data file is a sequence of records of the form:
+//
+//	uvarint(len(key)) | key | uvarint(len(value)) | value
+//
+// with keys in ascending order across all the files. The manifest records the
+// SHA-256 checksum of every data file so that a restore can detect corruption.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/perms"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+	"github.com/memeticofficial/pepecoingo/version"
+)
+
+const (
+	// DefaultMaxFileSize is the default size at which a new data file will be
+	// started.
+	DefaultMaxFileSize = 256 * units.MiB
+
+	// progressFrequency is the number of keys processed between progress
+	// reports.
+	progressFrequency = 100_000
+
+	dataFileNameFormat = "data-%06d.bin"
+)
+
+var (
+	errDirectoryNotEmpty    = errors.New("backup directory is not empty")
+	errMissingManifest      = errors.New("missing manifest")
+	errUnknownFormatVersion = errors.New("unknown backup format version")
+)
+
+// Config describes how a backup should be created.
+type Config struct {
+	// Directory the backup will be written to. It must either not exist or
+	// be empty.
+	Directory string
+	// DatabaseVersion is the version of the database being backed up.
+	DatabaseVersion *version.Semantic
+	// MaxFileSize is the size at which a new data file will be started. If
+	// <= 0, DefaultMaxFileSize is used.
+	MaxFileSize int
+	// OnProgress, if non-nil, is called periodically with the progress of the
+	// backup.
+	OnProgress func(Progress)
+}
+
+// Create writes a point-in-time copy of [db] into [config.Directory].
+//
+// The consistency of the backup relies on iterators providing a snapshot of the
+// database, so writes that occur after Create is called are not included in the
+// backup.
+func Create(ctx context.Context, db database.Iteratee, config Config) (*Manifest, error) {
+	if err := createEmptyDir(config.Directory); err != nil {
+		return nil, err
+	}
+	maxFileSize := config.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+
+	manifest := &Manifest{
+		FormatVersion:   FormatVersion,
+		DatabaseVersion: config.DatabaseVersion.String(),
+		Timestamp:       time.Now().UTC(),
+	}
+
+	it := db.NewIterator()
+	defer it.Release()
+
+	var w *fileWriter
+	for it.Next() {
+		if w == nil {
+			var err error
+			w, err = newFileWriter(config.Directory, len(manifest.Files))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		key := it.Key()
+		value := it.Value()
+		if err := w.write(key, value); err != nil {
+			_ = w.close()
+			return nil, err
+		}
+
+		manifest.NumKeys++
+		manifest.NumBytes += uint64(len(key) + len(value))
+
+		if w.size >= uint64(maxFileSize) {
+			file, err := w.finish()
+			if err != nil {
+				return nil, err
+			}
+			manifest.Files = append(manifest.Files, file)
+			w = nil
+		}
+
+		if manifest.NumKeys%progressFrequency == 0 {
+			if err := ctx.Err(); err != nil {
+				if w != nil {
+					_ = w.close()
+				}
+				return nil, err
+			}
+			reportProgress(config.OnProgress, manifest.NumKeys, manifest.NumBytes)
+		}
+	}
+	if err := it.Error(); err != nil {
+		if w != nil {
+			_ = w.close()
+		}
+		return nil, err
+	}
+	if w != nil {
+		file, err := w.finish()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	if err := writeManifest(config.Directory, manifest); err != nil {
+		return nil, err
+	}
+	reportProgress(config.OnProgress, manifest.NumKeys, manifest.NumBytes)
+	return manifest, nil
+}
+
+func createEmptyDir(dir string) error {
+	if err := os.MkdirAll(dir, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) != 0 {
+		return fmt.Errorf("%w: %s", errDirectoryNotEmpty, dir)
+	}
+	return nil
+}
+
+func reportProgress(onProgress func(Progress), numKeys, numBytes uint64) {
+	if onProgress == nil {
+		return
+	}
+	onProgress(Progress{
+		NumKeys:  numKeys,
+		NumBytes: numBytes,
+	})
+}
+
+// fileWriter writes records into a single data file while tracking its
+// checksum.
+type fileWriter struct {
+	name    string
+	file    *os.File
+	buf     *bufio.Writer
+	hasher  hash.Hash
+	numKeys uint64
+	size    uint64
+	lenBuf  [binary.MaxVarintLen64]byte
+}
+
+func newFileWriter(dir string, index int) (*fileWriter, error) {
+	name := fmt.Sprintf(dataFileNameFormat, index)
+	file, err := perms.Create(filepath.Join(dir, name), perms.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	return &fileWriter{
+		name:   name,
+		file:   file,
+		buf:    bufio.NewWriter(io.MultiWriter(file, hasher)),
+		hasher: hasher,
+	}, nil
+}
+
+func (w *fileWriter) write(key, value []byte) error {
+	if err := w.writeBytes(key); err != nil {
+		return err
+	}
+	if err := w.writeBytes(value); err != nil {
+		return err
+	}
+	w.numKeys++
+	return nil
+}
+
+func (w *fileWriter) writeBytes(b []byte) error {
+	n := binary.PutUvarint(w.lenBuf[:], uint64(len(b)))
+	if _, err := w.buf.Write(w.lenBuf[:n]); err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(b); err != nil {
+		return err
+	}
+	w.size += uint64(n + len(b))
+	return nil
+}
+
+// finish flushes the file to disk and returns its description.
+func (w *fileWriter) finish() (File, error) {
+	if err := w.buf.Flush(); err != nil {
+		_ = w.close()
+		return File{}, err
+	}
+	if err := w.file.Sync(); err != nil {
+		_ = w.close()
+		return File{}, err
+	}
+	if err := w.close(); err != nil {
+		return File{}, err
+	}
+	return File{
+		Name:     w.name,
+		NumKeys:  w.numKeys,
+		Size:     w.size,
+		Checksum: hex.EncodeToString(w.hasher.Sum(nil)),
+	}, nil
+}
+
+func (w *fileWriter) close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/version"
+)
+
+func populate(t *testing.T, db database.KeyValueWriter, numKeys int) {
+	for i := 0; i < numKeys; i++ {
+		key := []byte(fmt.Sprintf("key-%05d", i))
+		value := []byte(fmt.Sprintf("value-%05d", i))
+		require.NoError(t, db.Put(key, value))
+	}
+}
+
+func requireEqualDBs(t *testing.T, expected, actual database.Iteratee) {
+	require := require.New(t)
+
+	expectedIt := expected.NewIterator()
+	defer expectedIt.Release()
+	actualIt := actual.NewIterator()
+	defer actualIt.Release()
+
+	for expectedIt.Next() {
+		require.True(actualIt.Next())
+		require.Equal(expectedIt.Key(), actualIt.Key())
+		// Empty values may be returned as either nil or an empty slice.
+		require.True(bytes.Equal(expectedIt.Value(), actualIt.Value()))
+	}
+	require.False(actualIt.Next())
+	require.NoError(expectedIt.Error())
+	require.NoError(actualIt.Error())
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	populate(t, prefixdb.New([]byte("chain1"), db), 100)
+	populate(t, prefixdb.New([]byte("chain2"), db), 100)
+	require.NoError(db.Put(nil, nil))
+
+	dir := t.TempDir()
+	var lastProgress Progress
+	manifest, err := Create(context.Background(), db, Config{
+		Directory:       dir,
+		DatabaseVersion: version.CurrentDatabase,
+		MaxFileSize:     1024,
+		OnProgress: func(p Progress) {
+			lastProgress = p
+		},
+	})
+	require.NoError(err)
+	require.Equal(uint64(201), manifest.NumKeys)
+	require.Equal(manifest.NumKeys, lastProgress.NumKeys)
+	require.Equal(manifest.NumBytes, lastProgress.NumBytes)
+	require.Greater(len(manifest.Files), 1)
+	require.Equal(version.CurrentDatabase.String(), manifest.DatabaseVersion)
+
+	readManifest, err := ReadManifest(dir)
+	require.NoError(err)
+	require.Equal(manifest.Files, readManifest.Files)
+
+	_, err = Verify(context.Background(), dir)
+	require.NoError(err)
+
+	restoredDB := memdb.New()
+	_, err = Restore(context.Background(), dir, restoredDB, nil)
+	require.NoError(err)
+	requireEqualDBs(t, db, restoredDB)
+}
+
+func TestCreateEmptyDatabase(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	manifest, err := Create(context.Background(), memdb.New(), Config{
+		Directory:       dir,
+		DatabaseVersion: version.CurrentDatabase,
+	})
+	require.NoError(err)
+	require.Zero(manifest.NumKeys)
+	require.Empty(manifest.Files)
+
+	restoredDB := memdb.New()
+	_, err = Restore(context.Background(), dir, restoredDB, nil)
+	require.NoError(err)
+	requireEqualDBs(t, memdb.New(), restoredDB)
+}
+
+func TestCreateNonEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), nil, 0o600))
+
+	_, err := Create(context.Background(), memdb.New(), Config{
+		Directory:       dir,
+		DatabaseVersion: version.CurrentDatabase,
+	})
+	require.ErrorIs(t, err, errDirectoryNotEmpty)
+}
+
+func TestRestoreNonEmptyDatabase(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	_, err := Create(context.Background(), memdb.New(), Config{
+		Directory:       dir,
+		DatabaseVersion: version.CurrentDatabase,
+	})
+	require.NoError(err)
+
+	db := memdb.New()
+	populate(t, db, 1)
+	_, err = Restore(context.Background(), dir, db, nil)
+	require.ErrorIs(err, errDatabaseNotEmpty)
+}
+
+func TestRestoreMissingManifest(t *testing.T) {
+	_, err := Restore(context.Background(), t.TempDir(), memdb.New(), nil)
+	require.ErrorIs(t, err, errMissingManifest)
+}
+
+func TestRestoreCorruptedFile(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	populate(t, db, 10)
+
+	dir := t.TempDir()
+	manifest, err := Create(context.Background(), db, Config{
+		Directory:       dir,
+		DatabaseVersion: version.CurrentDatabase,
+	})
+	require.NoError(err)
+	require.Len(manifest.Files, 1)
+
+	path := filepath.Join(dir, manifest.Files[0].Name)
+	require.NoError(os.Chmod(path, 0o600))
+	fileBytes, err := os.ReadFile(path)
+	require.NoError(err)
+	// Flip a bit in the last value so that the records still parse.
+	fileBytes[len(fileBytes)-1] ^= 1
+	require.NoError(os.WriteFile(path, fileBytes, 0o600))
+
+	_, err = Verify(context.Background(), dir)
+	require.ErrorIs(err, errChecksumMismatch)
+
+	// Truncate the file so that the last record can't be parsed.
+	require.NoError(os.WriteFile(path, fileBytes[:len(fileBytes)-1], 0o600))
+
+	_, err = Verify(context.Background(), dir)
+	require.ErrorIs(err, errCorruptedFile)
+}
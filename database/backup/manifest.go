@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/memeticofficial/pepecoingo/utils/perms"
+)
+
+const (
+	// ManifestFileName is the name of the file, inside of a backup directory,
+	// that describes the backup. The manifest is written last, so a backup
+	// directory without a manifest is incomplete.
+	ManifestFileName = "manifest.json"
+
+	// FormatVersion is the version of the on-disk backup format.
+	FormatVersion = 0
+)
+
+// Manifest describes a completed backup.
+type Manifest struct {
+	// FormatVersion is the version of the backup format.
+	FormatVersion uint32 `json:"formatVersion"`
+	// DatabaseVersion is the version of the database that was backed up.
+	DatabaseVersion string `json:"databaseVersion"`
+	// Timestamp is the time the backup was started.
+	Timestamp time.Time `json:"timestamp"`
+	// NumKeys is the total number of key/value pairs in the backup.
+	NumKeys uint64 `json:"numKeys"`
+	// NumBytes is the total number of key and value bytes in the backup.
+	NumBytes uint64 `json:"numBytes"`
+	// Files are the data files of the backup, in key order.
+	Files []File `json:"files"`
+}
+
+// File describes a single data file of a backup.
+type File struct {
+	// Name of the file relative to the backup directory.
+	Name string `json:"name"`
+	// NumKeys is the number of key/value pairs in this file.
+	NumKeys uint64 `json:"numKeys"`
+	// Size is the number of bytes in this file.
+	Size uint64 `json:"size"`
+	// Checksum is the hex encoded SHA-256 hash of this file.
+	Checksum string `json:"checksum"`
+}
+
+// Progress reports how much of a backup or restore has been processed.
+type Progress struct {
+	NumKeys  uint64 `json:"numKeys"`
+	NumBytes uint64 `json:"numBytes"`
+}
+
+// ReadManifest returns the manifest of the backup in [dir].
+func ReadManifest(dir string) (*Manifest, error) {
+	manifestBytes, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errMissingManifest, err)
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(manifestBytes, manifest); err != nil {
+		return nil, fmt.Errorf("couldn't parse manifest: %w", err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", errUnknownFormatVersion, manifest.FormatVersion)
+	}
+	return manifest, nil
+}
+
+func writeManifest(dir string, manifest *Manifest) error {
+	manifestBytes, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return perms.WriteFile(filepath.Join(dir, ManifestFileName), manifestBytes, perms.ReadOnly)
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+)
+
+// restoreBatchSize is the number of bytes to buffer in a batch before writing
+// it to the database during a restore.
+const restoreBatchSize = 4 * units.MiB
+
+var (
+	errDatabaseNotEmpty = errors.New("database is not empty")
+	errCorruptedFile    = errors.New("corrupted backup file")
+	errChecksumMismatch = errors.New("checksum mismatch")
+	errCountMismatch    = errors.New("count mismatch")
+)
+
+// Verify checks that every data file of the backup in [dir] matches the
+// manifest, without writing anything.
+func Verify(ctx context.Context, dir string) (*Manifest, error) {
+	return apply(ctx, dir, nil, nil)
+}
+
+// Restore writes the contents of the backup in [dir] into [db], which must be
+// empty.
+//
+// Every data file is verified against its checksum as it is read. If an error
+// is returned, [db] may contain a partial restore and should be discarded.
+func Restore(ctx context.Context, dir string, db database.Database, onProgress func(Progress)) (*Manifest, error) {
+	it := db.NewIterator()
+	hasKeys := it.Next()
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return nil, err
+	}
+	if hasKeys {
+		return nil, errDatabaseNotEmpty
+	}
+	return apply(ctx, dir, db, onProgress)
+}
+
+// apply reads the backup in [dir], verifying it against its manifest. If [db]
+// is non-nil, the key/value pairs are written into it.
+func apply(ctx context.Context, dir string, db database.Database, onProgress func(Progress)) (*Manifest, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		batch    database.Batch
+		progress Progress
+	)
+	if db != nil {
+		batch = db.NewBatch()
+	}
+	for _, file := range manifest.Files {
+		if err := applyFile(ctx, dir, file, batch, &progress, onProgress); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", file.Name, err)
+		}
+	}
+	if batch != nil {
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+	}
+
+	if progress.NumKeys != manifest.NumKeys || progress.NumBytes != manifest.NumBytes {
+		return nil, fmt.Errorf("%w: expected %d keys and %d bytes but read %d keys and %d bytes",
+			errCountMismatch,
+			manifest.NumKeys,
+			manifest.NumBytes,
+			progress.NumKeys,
+			progress.NumBytes,
+		)
+	}
+	reportProgress(onProgress, progress.NumKeys, progress.NumBytes)
+	return manifest, nil
+}
+
+func applyFile(
+	ctx context.Context,
+	dir string,
+	file File,
+	batch database.Batch,
+	progress *Progress,
+	onProgress func(Progress),
+) error {
+	f, err := os.Open(filepath.Join(dir, file.Name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	r := &fileReader{
+		reader:    bufio.NewReader(io.TeeReader(f, hasher)),
+		remaining: file.Size,
+	}
+
+	var numKeys uint64
+	for r.remaining > 0 {
+		key, err := r.readBytes()
+		if err != nil {
+			return err
+		}
+		value, err := r.readBytes()
+		if err != nil {
+			return err
+		}
+		numKeys++
+
+		if batch != nil {
+			if err := batch.Put(key, value); err != nil {
+				return err
+			}
+			if batch.Size() >= restoreBatchSize {
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				batch.Reset()
+			}
+		}
+
+		progress.NumKeys++
+		progress.NumBytes += uint64(len(key) + len(value))
+		if progress.NumKeys%progressFrequency == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			reportProgress(onProgress, progress.NumKeys, progress.NumBytes)
+		}
+	}
+
+	// Make sure the file doesn't contain any trailing data.
+	if _, err := r.reader.ReadByte(); err != io.EOF {
+		return fmt.Errorf("%w: file is larger than %d bytes", errCorruptedFile, file.Size)
+	}
+	if numKeys != file.NumKeys {
+		return fmt.Errorf("%w: expected %d keys but read %d", errCountMismatch, file.NumKeys, numKeys)
+	}
+	if checksum := hex.EncodeToString(hasher.Sum(nil)); checksum != file.Checksum {
+		return fmt.Errorf("%w: expected %s but got %s", errChecksumMismatch, file.Checksum, checksum)
+	}
+	return nil
+}
+
+// fileReader reads records from a data file, refusing to read past the size
+// reported by the manifest.
+type fileReader struct {
+	reader    *bufio.Reader
+	remaining uint64
+}
+
+func (r *fileReader) readBytes() ([]byte, error) {
+	length, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCorruptedFile, err)
+	}
+	lengthSize := uint64(uvarintSize(length))
+	if lengthSize > r.remaining || length > r.remaining-lengthSize {
+		return nil, fmt.Errorf("%w: record exceeds file size", errCorruptedFile)
+	}
+	r.remaining -= lengthSize + length
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, b); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCorruptedFile, err)
+	}
+	return b, nil
+}
+
+func uvarintSize(x uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], x)
+}
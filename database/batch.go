@@ -7,12 +7,17 @@
 
 package database
 
-import "golang.org/x/exp/slices"
+import (
+	"bytes"
+
+	"golang.org/x/exp/slices"
+)
 
 // Batch is a write-only database that commits changes to its host database
 // when Write is called. A batch cannot be used concurrently.
 type Batch interface {
 	KeyValueWriterDeleter
+	KeyValueRangeDeleter
 
 	// Size retrieves the amount of data queued up for writing, this includes
 	// the keys, values, and deleted keys.
@@ -26,6 +31,9 @@ type Batch interface {
 
 	// Replay replays the batch contents in the same order they were written
 	// to the batch.
+	//
+	// If the batch contains range deletions, [w] must implement
+	// KeyValueRangeDeleter. Otherwise ErrRangeDeleteNotSupported is returned.
 	Replay(w KeyValueWriterDeleter) error
 
 	// Inner returns a Batch writing to the inner database, if one exists. If
@@ -45,6 +53,10 @@ type BatchOp struct {
 	Key    []byte
 	Value  []byte
 	Delete bool
+
+	// If DeleteRange is true, every key in [Key, Limit) is deleted.
+	DeleteRange bool
+	Limit       []byte
 }
 
 type BatchOps struct {
@@ -70,6 +82,16 @@ func (b *BatchOps) Delete(key []byte) error {
 	return nil
 }
 
+func (b *BatchOps) DeleteRange(start, limit []byte) error {
+	b.Ops = append(b.Ops, BatchOp{
+		Key:         slices.Clone(start),
+		DeleteRange: true,
+		Limit:       slices.Clone(limit),
+	})
+	b.size += len(start) + len(limit)
+	return nil
+}
+
 func (b *BatchOps) Size() int {
 	return b.size
 }
@@ -81,13 +103,38 @@ func (b *BatchOps) Reset() {
 
 func (b *BatchOps) Replay(w KeyValueWriterDeleter) error {
 	for _, op := range b.Ops {
-		if op.Delete {
+		switch {
+		case op.DeleteRange:
+			if err := ReplayDeleteRange(w, op.Key, op.Limit); err != nil {
+				return err
+			}
+		case op.Delete:
 			if err := w.Delete(op.Key); err != nil {
 				return err
 			}
-		} else if err := w.Put(op.Key, op.Value); err != nil {
-			return err
+		default:
+			if err := w.Put(op.Key, op.Value); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
+
+// ReplayDeleteRange removes every key in the range [start, limit) from [w]. If
+// [w] doesn't implement KeyValueRangeDeleter, ErrRangeDeleteNotSupported is
+// returned.
+func ReplayDeleteRange(w KeyValueWriterDeleter, start, limit []byte) error {
+	rangeDeleter, ok := w.(KeyValueRangeDeleter)
+	if !ok {
+		return ErrRangeDeleteNotSupported
+	}
+	return rangeDeleter.DeleteRange(start, limit)
+}
+
+// InRange returns true if [key] is in the range [start, limit). A nil [limit]
+// is treated as a key after all keys.
+func InRange(key, start, limit []byte) bool {
+	return bytes.Compare(key, start) >= 0 &&
+		(limit == nil || bytes.Compare(key, limit) < 0)
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cachedb
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/cache"
+	"github.com/memeticofficial/pepecoingo/cache/metercacher"
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+)
+
+// entryOverhead is the approximate number of bytes used to cache an entry in
+// addition to its key and value.
+const entryOverhead = 64
+
+var (
+	_ database.Database = (*Database)(nil)
+	_ database.Batch    = (*batch)(nil)
+)
+
+// entry is the cached result of looking up a key.
+type entry struct {
+	value  []byte
+	exists bool
+}
+
+// Database is a read-through cache in front of a database. The results of Get
+// and Has, including lookups of keys that don't exist, are cached until the
+// key is modified through this Database.
+//
+// Iterators are served directly by the underlying database. Modifications
+// that bypass this Database, including writes to batches returned by
+// batch.Inner(), aren't reflected in the cache.
+type Database struct {
+	database.Database
+
+	// lock is held for reading while a lookup may populate the cache and for
+	// writing while the underlying database is being modified, to ensure that
+	// a stale value is never cached.
+	lock   sync.RWMutex
+	closed bool
+	cache  cache.Cacher[string, entry]
+}
+
+// New returns a new database that caches up to [cacheSize] bytes of the keys
+// and values read from [db].
+func New(
+	namespace string,
+	registerer prometheus.Registerer,
+	db database.Database,
+	cacheSize int,
+) (*Database, error) {
+	cache, err := metercacher.New[string, entry](
+		namespace,
+		registerer,
+		cache.NewSizedLRU[string, entry](cacheSize, entrySize),
+	)
+	return &Database{
+		Database: db,
+		cache:    cache,
+	}, err
+}
+
+// NewPrefixed returns a database equivalent to wrapping [db] with
+// prefixdb.New for each of [prefixes], in order, whose reads are cached by a
+// new cache of up to [cacheSize] bytes.
+//
+// The cache is placed beneath the prefixes rather than on top of them, so the
+// keys written to [db] are the same as if no cache was used, including for
+// any prefixed databases later created on top of the returned database, as
+// long as [db] isn't itself a prefixed database. As every key is cached by the
+// same Database, a single lock guards the cache.
+func NewPrefixed(
+	namespace string,
+	registerer prometheus.Registerer,
+	db database.Database,
+	cacheSize int,
+	prefixes ...[]byte,
+) (database.Database, error) {
+	cacheDB, err := New(namespace, registerer, db, cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	var prefixedDB database.Database = cacheDB
+	for _, prefix := range prefixes {
+		prefixedDB = prefixdb.New(prefix, prefixedDB)
+	}
+	return prefixedDB, nil
+}
+
+func (db *Database) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return false, database.ErrClosed
+	}
+	e, err := db.get(key)
+	return e.exists, err
+}
+
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	e, err := db.get(key)
+	switch {
+	case err != nil:
+		return nil, err
+	case !e.exists:
+		return nil, database.ErrNotFound
+	default:
+		return slices.Clone(e.value), nil
+	}
+}
+
+func (db *Database) Put(key, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	err := db.Database.Put(key, value)
+	db.cache.Evict(string(key))
+	return err
+}
+
+func (db *Database) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	err := db.Database.Delete(key)
+	db.cache.Evict(string(key))
+	return err
+}
+
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	err := db.Database.DeleteRange(start, limit)
+	// The cache can't be searched by range, so it is cleared instead.
+	db.cache.Flush()
+	return err
+}
+
+func (db *Database) NewBatch() database.Batch {
+	return &batch{
+		Batch: db.Database.NewBatch(),
+		db:    db,
+	}
+}
+
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.closed = true
+	db.cache.Flush()
+	return db.Database.Close()
+}
+
+// get returns the result of looking up [key], populating the cache from the
+// underlying database if it isn't already cached.
+//
+// Assumes [db.lock] is held.
+func (db *Database) get(key []byte) (entry, error) {
+	if e, ok := db.cache.Get(string(key)); ok {
+		return e, nil
+	}
+
+	// The value is read even for Has so that a subsequent Get of the same key
+	// is served by the cache.
+	value, err := db.Database.Get(key)
+	var e entry
+	switch err {
+	case nil:
+		e = entry{
+			value:  value,
+			exists: true,
+		}
+	case database.ErrNotFound:
+	default:
+		return entry{}, err
+	}
+	db.cache.Put(string(key), e)
+	return e, nil
+}
+
+func entrySize(key string, e entry) int {
+	return len(key) + len(e.value) + entryOverhead
+}
+
+type batch struct {
+	database.Batch
+	db *Database
+
+	// keys that have been written to this batch since the last reset
+	keys []string
+	// true if a range has been deleted in this batch since the last reset
+	deletedRange bool
+}
+
+func (b *batch) Put(key, value []byte) error {
+	b.keys = append(b.keys, string(key))
+	return b.Batch.Put(key, value)
+}
+
+func (b *batch) Delete(key []byte) error {
+	b.keys = append(b.keys, string(key))
+	return b.Batch.Delete(key)
+}
+
+func (b *batch) DeleteRange(start, limit []byte) error {
+	b.deletedRange = true
+	return b.Batch.DeleteRange(start, limit)
+}
+
+func (b *batch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	err := b.Batch.Write()
+	if b.deletedRange {
+		b.db.cache.Flush()
+		return err
+	}
+	for _, key := range b.keys {
+		b.db.cache.Evict(key)
+	}
+	return err
+}
+
+func (b *batch) Reset() {
+	b.Batch.Reset()
+	b.keys = b.keys[:0]
+	b.deletedRange = false
+}
+
+// Inner returns the underlying batch. Writes to it aren't reflected in the
+// cache.
+func (b *batch) Inner() database.Batch {
+	return b.Batch
+}
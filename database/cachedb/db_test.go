@@ -0,0 +1,200 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cachedb
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+)
+
+const testCacheSize = 1024
+
+func TestInterface(t *testing.T) {
+	for _, test := range database.Tests {
+		db, err := New("", prometheus.NewRegistry(), memdb.New(), testCacheSize)
+		require.NoError(t, err)
+
+		test(t, db)
+	}
+}
+
+func FuzzInterface(f *testing.F) {
+	for _, test := range database.FuzzTests {
+		db, err := New("", prometheus.NewRegistry(), memdb.New(), testCacheSize)
+		require.NoError(f, err)
+
+		test(f, db)
+	}
+}
+
+func TestCache(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := New("", prometheus.NewRegistry(), baseDB, testCacheSize)
+	require.NoError(err)
+
+	key := []byte("key")
+	value := []byte("value")
+
+	// Negative lookups are cached.
+	has, err := db.Has(key)
+	require.NoError(err)
+	require.False(has)
+
+	require.NoError(baseDB.Put(key, value))
+
+	has, err = db.Has(key)
+	require.NoError(err)
+	require.False(has)
+
+	// Writes through the cache invalidate the cached entry.
+	require.NoError(db.Put(key, value))
+
+	got, err := db.Get(key)
+	require.NoError(err)
+	require.Equal(value, got)
+
+	// Positive lookups are cached, and modifying the returned value doesn't
+	// modify the cached value.
+	got[0] = 'x'
+	require.NoError(baseDB.Delete(key))
+
+	got, err = db.Get(key)
+	require.NoError(err)
+	require.Equal(value, got)
+
+	require.NoError(db.Delete(key))
+
+	_, err = db.Get(key)
+	require.Equal(database.ErrNotFound, err)
+}
+
+func TestCacheBatch(t *testing.T) {
+	require := require.New(t)
+
+	db, err := New("", prometheus.NewRegistry(), memdb.New(), testCacheSize)
+	require.NoError(err)
+
+	key1 := []byte("key1")
+	key2 := []byte("key2")
+	value := []byte("value")
+
+	require.NoError(db.Put(key1, value))
+
+	// Populate the cache.
+	has, err := db.Has(key1)
+	require.NoError(err)
+	require.True(has)
+
+	has, err = db.Has(key2)
+	require.NoError(err)
+	require.False(has)
+
+	batch := db.NewBatch()
+	require.NoError(batch.Delete(key1))
+	require.NoError(batch.Put(key2, value))
+
+	// The cache isn't invalidated until the batch is written.
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.True(has)
+
+	require.NoError(batch.Write())
+
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.False(has)
+
+	got, err := db.Get(key2)
+	require.NoError(err)
+	require.Equal(value, got)
+
+	batch.Reset()
+	require.NoError(batch.DeleteRange(nil, nil))
+	require.NoError(batch.Write())
+
+	has, err = db.Has(key2)
+	require.NoError(err)
+	require.False(has)
+}
+
+func TestCacheEviction(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	key1 := []byte("key1")
+	key2 := []byte("key2")
+	value := []byte("value")
+
+	// The cache only has room for one entry.
+	db, err := New("", prometheus.NewRegistry(), baseDB, entrySize(string(key1), entry{value: value}))
+	require.NoError(err)
+
+	require.NoError(baseDB.Put(key1, value))
+	require.NoError(baseDB.Put(key2, value))
+
+	_, err = db.Get(key1)
+	require.NoError(err)
+	_, err = db.Get(key2)
+	require.NoError(err)
+
+	require.NoError(baseDB.Delete(key1))
+	require.NoError(baseDB.Delete(key2))
+
+	// [key2] is still cached.
+	has, err := db.Has(key2)
+	require.NoError(err)
+	require.True(has)
+
+	// [key1] was evicted, so the change is observed.
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.False(has)
+}
+
+func TestNewPrefixedKeyLayout(t *testing.T) {
+	require := require.New(t)
+
+	write := func(db database.Database) {
+		// VMs may prefix their database further.
+		innerDB := prefixdb.New([]byte("inner"), db)
+		require.NoError(innerDB.Put([]byte("key"), []byte("value")))
+		require.NoError(db.Put([]byte("other"), []byte("value")))
+
+		value, err := innerDB.Get([]byte("key"))
+		require.NoError(err)
+		require.Equal([]byte("value"), value)
+	}
+
+	uncachedDB := memdb.New()
+	write(prefixdb.New([]byte("vm"), prefixdb.New([]byte("chain"), uncachedDB)))
+
+	cachedDB := memdb.New()
+	db, err := NewPrefixed("", prometheus.NewRegistry(), cachedDB, testCacheSize, []byte("chain"), []byte("vm"))
+	require.NoError(err)
+	write(db)
+
+	// The keys written must not depend on the cache.
+	require.Equal(rawKeys(t, uncachedDB), rawKeys(t, cachedDB))
+}
+
+func rawKeys(t *testing.T, db database.Iteratee) [][]byte {
+	it := db.NewIterator()
+	defer it.Release()
+
+	var keys [][]byte
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	require.NoError(t, it.Error())
+	return keys
+}
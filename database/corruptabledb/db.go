@@ -12,8 +12,9 @@ import (
 )
 
 var (
-	_ database.Database = (*Database)(nil)
-	_ database.Batch    = (*batch)(nil)
+	_ database.Database      = (*Database)(nil)
+	_ database.SizeEstimator = (*Database)(nil)
+	_ database.Batch         = (*batch)(nil)
 )
 
 // CorruptableDB is a wrapper around Database
@@ -67,6 +68,33 @@ func (db *Database) Delete(key []byte) error {
 	return db.handleError(db.Database.Delete(key))
 }
 
+// DeleteRange removes every key in the range [start, limit) from the database
+func (db *Database) DeleteRange(start, limit []byte) error {
+	if err := db.corrupted(); err != nil {
+		return err
+	}
+	return db.handleError(db.Database.DeleteRange(start, limit))
+}
+
+// EstimateSize returns the approximate number of bytes used on disk to store
+// the keys in the range [start, limit). If the underlying database doesn't
+// support size estimation, database.ErrSizeEstimateNotSupported is returned.
+func (db *Database) EstimateSize(start, limit []byte) (uint64, error) {
+	if err := db.corrupted(); err != nil {
+		return 0, err
+	}
+	return database.EstimateSize(db.Database, start, limit)
+}
+
+// Snapshot returns a read-only view of the current state of the database
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	if err := db.corrupted(); err != nil {
+		return nil, err
+	}
+	s, err := db.Database.Snapshot()
+	return s, db.handleError(err)
+}
+
 func (db *Database) Compact(start []byte, limit []byte) error {
 	return db.handleError(db.Database.Compact(start, limit))
 }
@@ -64,6 +64,10 @@ func TestCorruption(t *testing.T) {
 			_, err := db.HealthCheck(context.Background())
 			return err
 		},
+		"corrupted estimate size": func(db database.Database) error {
+			_, err := database.EstimateSize(db, nil, nil)
+			return err
+		},
 	}
 	baseDB := memdb.New()
 	// wrap this db
@@ -76,3 +80,14 @@ func TestCorruption(t *testing.T) {
 		})
 	}
 }
+
+// TestEstimateSizeNotSupported tests to make sure that an unsupported size
+// estimation doesn't mark the database as corrupted.
+func TestEstimateSizeNotSupported(t *testing.T) {
+	require := require.New(t)
+
+	db := New(memdb.New())
+	_, err := db.EstimateSize(nil, nil)
+	require.ErrorIs(err, database.ErrSizeEstimateNotSupported)
+	require.NoError(db.corrupted())
+}
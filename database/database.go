@@ -48,6 +48,20 @@ type KeyValueDeleter interface {
 	Delete(key []byte) error
 }
 
+// KeyValueRangeDeleter wraps the DeleteRange method of a backing data store.
+type KeyValueRangeDeleter interface {
+	// DeleteRange removes every key in the range [start, limit) from the
+	// key-value data store.
+	//
+	// A nil start is treated as a key before all keys in the DB.
+	// And a nil limit is treated as a key after all keys in the DB.
+	// Therefore if both are nil then it will delete every key in the DB.
+	//
+	// Note: [start] and [limit] are safe to modify and read after calling
+	// DeleteRange.
+	DeleteRange(start, limit []byte) error
+}
+
 // KeyValueReaderWriter allows read/write acccess to a backing data store.
 type KeyValueReaderWriter interface {
 	KeyValueReader
@@ -83,12 +97,52 @@ type Compacter interface {
 	Compact(start []byte, limit []byte) error
 }
 
+// SizeEstimator wraps the EstimateSize method of a backing data store.
+type SizeEstimator interface {
+	// EstimateSize returns the approximate number of bytes used on disk to
+	// store the keys in the range [start, limit). Data that hasn't been
+	// flushed to disk yet may not be accounted for.
+	//
+	// A nil start is treated as a key before all keys in the DB.
+	// And a nil limit is treated as a key after all keys in the DB.
+	// Therefore if both are nil then it will estimate the size of the entire
+	// DB.
+	//
+	// Note: [start] and [limit] are safe to modify and read after calling
+	// EstimateSize.
+	EstimateSize(start, limit []byte) (uint64, error)
+}
+
+// Snapshot is a read-only view of a backing data store at the time that the
+// snapshot was taken. Modifications made to the data store after the snapshot
+// was taken aren't visible through the snapshot.
+type Snapshot interface {
+	KeyValueReader
+	Iteratee
+
+	// Release frees the resources held by the snapshot. After the snapshot is
+	// released, reads from it and from its iterators return ErrClosed.
+	//
+	// Note: It is safe to call Release multiple times.
+	Release()
+}
+
+// Snapshotter wraps the Snapshot method of a backing data store.
+type Snapshotter interface {
+	// Snapshot returns a read-only view of the current state of the data
+	// store. The snapshot must be released once it is no longer needed, and
+	// before the data store is closed.
+	Snapshot() (Snapshot, error)
+}
+
 // Database contains all the methods required to allow handling different
 // key-value data stores backing the database.
 type Database interface {
 	KeyValueReaderWriterDeleter
+	KeyValueRangeDeleter
 	Batcher
 	Iteratee
+	Snapshotter
 	Compacter
 	io.Closer
 	health.Checker
@@ -0,0 +1,134 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package encdb
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/memeticofficial/pepecoingo/utils/hashing"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+)
+
+const (
+	// SaltLen is the number of bytes of the salt generated by NewConfig.
+	SaltLen = 16
+
+	// maxSaltLen and the maximum Argon2 parameters bound the cost of deriving
+	// a key, as configs may be imported from an untrusted source.
+	maxSaltLen       = 64
+	maxArgon2Time    = 4
+	maxArgon2Memory  = 256 * units.KiB // in KiB, so 256 MiB
+	maxArgon2Threads = 16
+)
+
+var (
+	// DefaultArgon2Params are the parameters that values are encrypted with
+	// if no other parameters are specified.
+	DefaultArgon2Params = Argon2Params{
+		Time:    1,
+		Memory:  64 * units.KiB,
+		Threads: 4,
+	}
+
+	errZeroArgon2Time       = errors.New("argon2 time must be positive")
+	errZeroArgon2Threads    = errors.New("argon2 threads must be positive")
+	errArgon2TimeTooHigh    = fmt.Errorf("argon2 time exceeds maximum of %d", maxArgon2Time)
+	errArgon2MemoryTooHigh  = fmt.Errorf("argon2 memory exceeds maximum of %d KiB", maxArgon2Memory)
+	errArgon2ThreadsTooHigh = fmt.Errorf("argon2 threads exceeds maximum of %d", maxArgon2Threads)
+	errEmptySalt            = errors.New("empty salt")
+	errSaltTooLong          = fmt.Errorf("salt exceeds maximum length of %d", maxSaltLen)
+)
+
+// Argon2Params are the parameters that are passed to Argon2id to derive the
+// encryption key from the password.
+type Argon2Params struct {
+	// Number of passes over the memory
+	Time uint32 `serialize:"true" json:"time"`
+	// Amount of memory used, in KiB
+	Memory uint32 `serialize:"true" json:"memory"`
+	// Degree of parallelism
+	Threads uint8 `serialize:"true" json:"threads"`
+}
+
+func (p Argon2Params) Verify() error {
+	switch {
+	case p.Time == 0:
+		return errZeroArgon2Time
+	case p.Time > maxArgon2Time:
+		return errArgon2TimeTooHigh
+	case p.Memory > maxArgon2Memory:
+		return errArgon2MemoryTooHigh
+	case p.Threads == 0:
+		return errZeroArgon2Threads
+	case p.Threads > maxArgon2Threads:
+		return errArgon2ThreadsTooHigh
+	default:
+		return nil
+	}
+}
+
+// Config describes how the key that values are encrypted with is derived from
+// the password. It is stored alongside every encrypted value so that values
+// can be checked against the config that the database was opened with.
+type Config struct {
+	Argon2 Argon2Params `serialize:"true" json:"argon2"`
+	Salt   []byte       `serialize:"true" json:"salt"`
+}
+
+// NewConfig returns a config that uses [DefaultArgon2Params] and a random
+// salt.
+func NewConfig() (Config, error) {
+	salt := make([]byte, SaltLen)
+	_, err := rand.Read(salt)
+	return Config{
+		Argon2: DefaultArgon2Params,
+		Salt:   salt,
+	}, err
+}
+
+func (c *Config) Verify() error {
+	switch {
+	case len(c.Salt) == 0:
+		return errEmptySalt
+	case len(c.Salt) > maxSaltLen:
+		return errSaltTooLong
+	default:
+		return c.Argon2.Verify()
+	}
+}
+
+// Equal returns true if [c] and [other] derive the same key from a password.
+func (c *Config) Equal(other *Config) bool {
+	return c.Argon2 == other.Argon2 && bytes.Equal(c.Salt, other.Salt)
+}
+
+// newCipher derives the key from [password] according to [config].
+func newCipher(password []byte, config *Config) (cipher.AEAD, error) {
+	if err := config.Verify(); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey(
+		password,
+		config.Salt,
+		config.Argon2.Time,
+		config.Argon2.Memory,
+		config.Argon2.Threads,
+		chacha20poly1305.KeySize,
+	)
+	return chacha20poly1305.NewX(key)
+}
+
+// newLegacyCipher derives the key that values written before values included
+// their config were encrypted with.
+func newLegacyCipher(password []byte) (cipher.AEAD, error) {
+	h := hashing.ComputeHash256(password)
+	return chacha20poly1305.NewX(h)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 	"sync"
 
 	"golang.org/x/crypto/chacha20poly1305"
@@ -16,42 +17,83 @@ import (
 	"github.com/memeticofficial/pepecoingo/codec"
 	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
 	"github.com/memeticofficial/pepecoingo/database"
-	"github.com/memeticofficial/pepecoingo/utils/hashing"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
 )
 
 const (
-	codecVersion = 0
+	// legacyCodecVersion is the version of values that don't include the
+	// config that their key was derived with. Their key is the hash of the
+	// password.
+	legacyCodecVersion = 0
+	codecVersion       = 1
 )
 
 var (
 	_ database.Database = (*Database)(nil)
 	_ database.Batch    = (*batch)(nil)
 	_ database.Iterator = (*iterator)(nil)
+	_ database.Snapshot = (*snapshot)(nil)
+
+	errUnknownVersion   = errors.New("unknown encrypted value version")
+	errUnexpectedConfig = errors.New("value was encrypted with an unexpected config")
+
+	valueCodec codec.Manager
 )
 
+func init() {
+	valueCodec = codec.NewDefaultManager()
+	errs := wrappers.Errs{}
+	errs.Add(
+		valueCodec.RegisterCodec(legacyCodecVersion, linearcodec.NewDefault()),
+		valueCodec.RegisterCodec(codecVersion, linearcodec.NewDefault()),
+	)
+	if errs.Errored() {
+		panic(errs.Err)
+	}
+}
+
 // Database encrypts all values that are provided
 type Database struct {
 	lock   sync.RWMutex
 	codec  codec.Manager
-	cipher cipher.AEAD
 	db     database.Database
 	closed bool
+
+	// config that values are encrypted with. Values encrypted with any other
+	// config are rejected, so that reading a value never derives a key with
+	// parameters that weren't chosen by the user.
+	config       Config
+	cipher       cipher.AEAD
+	legacyCipher cipher.AEAD
 }
 
-// New returns a new encrypted database
-func New(password []byte, db database.Database) (*Database, error) {
-	h := hashing.ComputeHash256(password)
-	aead, err := chacha20poly1305.NewX(h)
+// NewWithConfig returns a new encrypted database that encrypts values with a
+// key derived according to [config]. Values that were encrypted by legacy
+// versions of this database can still be read.
+//
+// A new database should use a config returned by NewConfig. As the config
+// includes a random salt, it must be stored by the caller and provided again
+// to reopen the database. The config of an encrypted value is returned by
+// ValueConfig.
+func NewWithConfig(password []byte, db database.Database, config Config) (*Database, error) {
+	key, err := NewKey(password, config)
 	if err != nil {
 		return nil, err
 	}
-	c := linearcodec.NewDefault()
-	manager := codec.NewDefaultManager()
+	return NewWithKey(db, key), nil
+}
+
+// NewWithKey returns a new encrypted database that encrypts values with
+// [key]. Values that were encrypted by legacy versions of this database with
+// the same password can still be read.
+func NewWithKey(db database.Database, key *Key) *Database {
 	return &Database{
-		codec:  manager,
-		cipher: aead,
-		db:     db,
-	}, manager.RegisterCodec(codecVersion, c)
+		codec:        valueCodec,
+		db:           db,
+		config:       key.config,
+		cipher:       key.cipher,
+		legacyCipher: key.legacyCipher,
+	}
 }
 
 func (db *Database) Has(key []byte) (bool, error) {
@@ -103,6 +145,16 @@ func (db *Database) Delete(key []byte) error {
 	return db.db.Delete(key)
 }
 
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	return db.db.DeleteRange(start, limit)
+}
+
 func (db *Database) NewBatch() database.Batch {
 	return &batch{
 		Batch: db.db.NewBatch(),
@@ -137,6 +189,51 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 	}
 }
 
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return &iterator{
+		Iterator: db.db.NewReverseIteratorWithStartAndPrefix(start, prefix),
+		db:       db,
+	}
+}
+
+// Snapshot returns a read-only view of the current state of the database
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	s, err := db.db.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		db:       db,
+		snapshot: s,
+	}, nil
+}
+
 func (db *Database) Compact(start, limit []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -202,6 +299,15 @@ func (b *batch) Delete(key []byte) error {
 	return b.Batch.Delete(key)
 }
 
+func (b *batch) DeleteRange(start, limit []byte) error {
+	b.ops = append(b.ops, database.BatchOp{
+		Key:         slices.Clone(start),
+		DeleteRange: true,
+		Limit:       slices.Clone(limit),
+	})
+	return b.Batch.DeleteRange(start, limit)
+}
+
 func (b *batch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
@@ -226,12 +332,19 @@ func (b *batch) Reset() {
 // Replay replays the batch contents.
 func (b *batch) Replay(w database.KeyValueWriterDeleter) error {
 	for _, op := range b.ops {
-		if op.Delete {
+		switch {
+		case op.DeleteRange:
+			if err := database.ReplayDeleteRange(w, op.Key, op.Limit); err != nil {
+				return err
+			}
+		case op.Delete:
 			if err := w.Delete(op.Key); err != nil {
 				return err
 			}
-		} else if err := w.Put(op.Key, op.Value); err != nil {
-			return err
+		default:
+			if err := w.Put(op.Key, op.Value); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -286,11 +399,120 @@ func (it *iterator) Value() []byte {
 	return it.val
 }
 
+// snapshot decrypts the values read from a snapshot of the underlying
+// database
+type snapshot struct {
+	db       *Database
+	snapshot database.Snapshot
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	if s.db.isClosed() {
+		return false, database.ErrClosed
+	}
+	return s.snapshot.Has(key)
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	if s.db.isClosed() {
+		return nil, database.ErrClosed
+	}
+	encVal, err := s.snapshot.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.decrypt(encVal)
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	if s.db.isClosed() {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return &iterator{
+		Iterator: s.snapshot.NewIteratorWithStartAndPrefix(start, prefix),
+		db:       s.db,
+	}
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	if s.db.isClosed() {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return &iterator{
+		Iterator: s.snapshot.NewReverseIteratorWithStartAndPrefix(start, prefix),
+		db:       s.db,
+	}
+}
+
+func (s *snapshot) Release() {
+	s.snapshot.Release()
+}
+
+// legacyEncryptedValue is the format of values that were written before the
+// key derivation config was stored alongside each value.
+type legacyEncryptedValue struct {
+	Ciphertext []byte `serialize:"true"`
+	Nonce      []byte `serialize:"true"`
+}
+
 type encryptedValue struct {
+	Config     Config `serialize:"true"`
 	Ciphertext []byte `serialize:"true"`
 	Nonce      []byte `serialize:"true"`
 }
 
+// ValueConfig returns the config that [value], as written to the underlying
+// database, was encrypted with. If [value] was encrypted by a legacy version
+// of this database, nil is returned.
+func ValueConfig(value []byte) (*Config, error) {
+	p := wrappers.Packer{Bytes: value}
+	version := p.UnpackShort()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	switch version {
+	case legacyCodecVersion:
+		return nil, nil
+	case codecVersion:
+		val := encryptedValue{}
+		if _, err := valueCodec.Unmarshal(value, &val); err != nil {
+			return nil, err
+		}
+		return &val.Config, nil
+	default:
+		return nil, errUnknownVersion
+	}
+}
+
 func (db *Database) encrypt(plaintext []byte) ([]byte, error) {
 	nonce := make([]byte, chacha20poly1305.NonceSizeX)
 	if _, err := rand.Read(nonce); err != nil {
@@ -298,15 +520,36 @@ func (db *Database) encrypt(plaintext []byte) ([]byte, error) {
 	}
 	ciphertext := db.cipher.Seal(nil, nonce, plaintext, nil)
 	return db.codec.Marshal(codecVersion, &encryptedValue{
+		Config:     db.config,
 		Ciphertext: ciphertext,
 		Nonce:      nonce,
 	})
 }
 
 func (db *Database) decrypt(ciphertext []byte) ([]byte, error) {
-	val := encryptedValue{}
-	if _, err := db.codec.Unmarshal(ciphertext, &val); err != nil {
-		return nil, err
+	p := wrappers.Packer{Bytes: ciphertext}
+	version := p.UnpackShort()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+
+	switch version {
+	case legacyCodecVersion:
+		val := legacyEncryptedValue{}
+		if _, err := db.codec.Unmarshal(ciphertext, &val); err != nil {
+			return nil, err
+		}
+		return db.legacyCipher.Open(nil, val.Nonce, val.Ciphertext, nil)
+	case codecVersion:
+		val := encryptedValue{}
+		if _, err := db.codec.Unmarshal(ciphertext, &val); err != nil {
+			return nil, err
+		}
+		if !val.Config.Equal(&db.config) {
+			return nil, errUnexpectedConfig
+		}
+		return db.cipher.Open(nil, val.Nonce, val.Ciphertext, nil)
+	default:
+		return nil, errUnknownVersion
 	}
-	return db.cipher.Open(nil, val.Nonce, val.Ciphertext, nil)
 }
@@ -4,8 +4,11 @@
 package encdb
 
 import (
+	"crypto/rand"
 	"testing"
 
+	"golang.org/x/crypto/chacha20poly1305"
+
 	"github.com/stretchr/testify/require"
 
 	"github.com/memeticofficial/pepecoingo/database"
@@ -17,10 +20,7 @@ const testPassword = "lol totally a secure password" //nolint:gosec
 func TestInterface(t *testing.T) {
 	for _, test := range database.Tests {
 		unencryptedDB := memdb.New()
-		db, err := New([]byte(testPassword), unencryptedDB)
-		if err != nil {
-			t.Fatal(err)
-		}
+		db := newTestDB(t, unencryptedDB)
 
 		test(t, db)
 	}
@@ -29,24 +29,228 @@ func TestInterface(t *testing.T) {
 func FuzzInterface(f *testing.F) {
 	for _, test := range database.FuzzTests {
 		unencryptedDB := memdb.New()
-		db, err := New([]byte(testPassword), unencryptedDB)
-		if err != nil {
-			require.NoError(f, err)
-		}
+		db := newTestDB(f, unencryptedDB)
 		test(f, db)
 	}
 }
 
+func TestLegacyValue(t *testing.T) {
+	require := require.New(t)
+
+	unencryptedDB := memdb.New()
+	db := newTestDB(t, unencryptedDB)
+
+	// Encrypt a value the way that it was encrypted before the config was
+	// included in the value.
+	key := []byte("hello")
+	value := []byte("world")
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	_, err := rand.Read(nonce)
+	require.NoError(err)
+	legacyValue, err := db.codec.Marshal(legacyCodecVersion, &legacyEncryptedValue{
+		Ciphertext: db.legacyCipher.Seal(nil, nonce, value, nil),
+		Nonce:      nonce,
+	})
+	require.NoError(err)
+	require.NoError(unencryptedDB.Put(key, legacyValue))
+
+	config, err := ValueConfig(legacyValue)
+	require.NoError(err)
+	require.Nil(config)
+
+	gotValue, err := db.Get(key)
+	require.NoError(err)
+	require.Equal(value, gotValue)
+
+	// Values are always written in the current format.
+	require.NoError(db.Put(key, value))
+	encValue, err := unencryptedDB.Get(key)
+	require.NoError(err)
+	val := encryptedValue{}
+	version, err := db.codec.Unmarshal(encValue, &val)
+	require.NoError(err)
+	require.Equal(uint16(codecVersion), version)
+	require.Equal(db.config, val.Config)
+}
+
+func TestDifferentConfigs(t *testing.T) {
+	require := require.New(t)
+
+	unencryptedDB := memdb.New()
+	db1 := newTestDB(t, unencryptedDB)
+	db2, err := NewWithConfig([]byte(testPassword), unencryptedDB, Config{
+		Argon2: Argon2Params{
+			Time:    2,
+			Memory:  16,
+			Threads: 1,
+		},
+		Salt: []byte("salt"),
+	})
+	require.NoError(err)
+
+	key := []byte("hello")
+	value := []byte("world")
+	require.NoError(db1.Put(key, value))
+
+	// [db2] must not derive a key with parameters read from the value.
+	_, err = db2.Get(key)
+	require.ErrorIs(err, errUnexpectedConfig)
+
+	// A database opened with the config of the value can read it.
+	encValue, err := unencryptedDB.Get(key)
+	require.NoError(err)
+	config, err := ValueConfig(encValue)
+	require.NoError(err)
+	require.Equal(&db1.config, config)
+
+	db4, err := NewWithConfig([]byte(testPassword), unencryptedDB, *config)
+	require.NoError(err)
+	gotValue, err := db4.Get(key)
+	require.NoError(err)
+	require.Equal(value, gotValue)
+
+	// Values can't be decrypted with the wrong password.
+	db3, err := NewWithConfig([]byte("wrong password"), unencryptedDB, *config)
+	require.NoError(err)
+	_, err = db3.Get(key)
+	require.Error(err) //nolint:forbidigo // chacha20poly1305 does not export the error
+}
+
+func TestReopen(t *testing.T) {
+	require := require.New(t)
+
+	config, err := NewConfig()
+	require.NoError(err)
+
+	unencryptedDB := memdb.New()
+	db, err := NewWithConfig([]byte(testPassword), unencryptedDB, config)
+	require.NoError(err)
+
+	key := []byte("hello")
+	value := []byte("world")
+	require.NoError(db.Put(key, value))
+
+	// Reopening the database with the same config reads the values.
+	db, err = NewWithConfig([]byte(testPassword), unencryptedDB, config)
+	require.NoError(err)
+	gotValue, err := db.Get(key)
+	require.NoError(err)
+	require.Equal(value, gotValue)
+
+	// A new config derives a different key, so the values aren't read.
+	otherConfig, err := NewConfig()
+	require.NoError(err)
+	db, err = NewWithConfig([]byte(testPassword), unencryptedDB, otherConfig)
+	require.NoError(err)
+	_, err = db.Get(key)
+	require.ErrorIs(err, errUnexpectedConfig)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+	}{
+		{
+			name: "empty salt",
+			config: Config{
+				Argon2: DefaultArgon2Params,
+			},
+			expectedErr: errEmptySalt,
+		},
+		{
+			name: "salt too long",
+			config: Config{
+				Argon2: DefaultArgon2Params,
+				Salt:   make([]byte, maxSaltLen+1),
+			},
+			expectedErr: errSaltTooLong,
+		},
+		{
+			name: "zero time",
+			config: Config{
+				Argon2: Argon2Params{
+					Memory:  DefaultArgon2Params.Memory,
+					Threads: DefaultArgon2Params.Threads,
+				},
+				Salt: []byte("salt"),
+			},
+			expectedErr: errZeroArgon2Time,
+		},
+		{
+			name: "time too high",
+			config: Config{
+				Argon2: Argon2Params{
+					Time:    maxArgon2Time + 1,
+					Memory:  DefaultArgon2Params.Memory,
+					Threads: DefaultArgon2Params.Threads,
+				},
+				Salt: []byte("salt"),
+			},
+			expectedErr: errArgon2TimeTooHigh,
+		},
+		{
+			name: "too much memory",
+			config: Config{
+				Argon2: Argon2Params{
+					Time:    DefaultArgon2Params.Time,
+					Memory:  maxArgon2Memory + 1,
+					Threads: DefaultArgon2Params.Threads,
+				},
+				Salt: []byte("salt"),
+			},
+			expectedErr: errArgon2MemoryTooHigh,
+		},
+		{
+			name: "zero threads",
+			config: Config{
+				Argon2: Argon2Params{
+					Time:   DefaultArgon2Params.Time,
+					Memory: DefaultArgon2Params.Memory,
+				},
+				Salt: []byte("salt"),
+			},
+			expectedErr: errZeroArgon2Threads,
+		},
+		{
+			name: "too many threads",
+			config: Config{
+				Argon2: Argon2Params{
+					Time:    DefaultArgon2Params.Time,
+					Memory:  DefaultArgon2Params.Memory,
+					Threads: maxArgon2Threads + 1,
+				},
+				Salt: []byte("salt"),
+			},
+			expectedErr: errArgon2ThreadsTooHigh,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewWithConfig([]byte(testPassword), memdb.New(), test.config)
+			require.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
 func BenchmarkInterface(b *testing.B) {
 	for _, size := range database.BenchmarkSizes {
 		keys, values := database.SetupBenchmark(b, size[0], size[1], size[2])
 		for _, bench := range database.Benchmarks {
 			unencryptedDB := memdb.New()
-			db, err := New([]byte(testPassword), unencryptedDB)
-			if err != nil {
-				b.Fatal(err)
-			}
+			db := newTestDB(b, unencryptedDB)
 			bench(b, db, "encdb", keys, values)
 		}
 	}
 }
+
+// newTestDB returns a database that encrypts values with [testPassword] and a
+// new config.
+func newTestDB(tb testing.TB, db database.Database) *Database {
+	config, err := NewConfig()
+	require.NoError(tb, err)
+	encDB, err := NewWithConfig([]byte(testPassword), db, config)
+	require.NoError(tb, err)
+	return encDB
+}
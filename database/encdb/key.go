@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package encdb
+
+import (
+	"crypto/cipher"
+
+	"github.com/memeticofficial/pepecoingo/cache"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/hashing"
+)
+
+// Key is the key derived from a password according to a config.
+type Key struct {
+	config       Config
+	cipher       cipher.AEAD
+	legacyCipher cipher.AEAD
+}
+
+// NewKey derives the key from [password] according to [config].
+func NewKey(password []byte, config Config) (*Key, error) {
+	aead, err := newCipher(password, &config)
+	if err != nil {
+		return nil, err
+	}
+	legacyCipher, err := newLegacyCipher(password)
+	return &Key{
+		config:       config,
+		cipher:       aead,
+		legacyCipher: legacyCipher,
+	}, err
+}
+
+// Config returns the config that the key was derived with.
+func (k *Key) Config() Config {
+	return k.config
+}
+
+// keyID identifies the key derived from a password according to a config.
+type keyID struct {
+	password ids.ID
+	params   Argon2Params
+	salt     string
+}
+
+// KeyCache caches derived keys, as deriving a key is intentionally expensive.
+type KeyCache struct {
+	keys cache.Cacher[keyID, *Key]
+}
+
+// NewKeyCache returns a cache that holds up to [size] keys.
+func NewKeyCache(size int) *KeyCache {
+	return &KeyCache{
+		keys: &cache.LRU[keyID, *Key]{Size: size},
+	}
+}
+
+// Get returns the key derived from [password] according to [config], deriving
+// it if it isn't cached.
+func (c *KeyCache) Get(password []byte, config Config) (*Key, error) {
+	id := keyID{
+		password: hashing.ComputeHash256Array(password),
+		params:   config.Argon2,
+		salt:     string(config.Salt),
+	}
+	if key, ok := c.keys.Get(id); ok {
+		return key, nil
+	}
+
+	key, err := NewKey(password, config)
+	if err != nil {
+		return nil, err
+	}
+	c.keys.Put(id, key)
+	return key, nil
+}
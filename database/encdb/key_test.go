@@ -0,0 +1,51 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package encdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+)
+
+func TestKeyCache(t *testing.T) {
+	require := require.New(t)
+
+	config, err := NewConfig()
+	require.NoError(err)
+
+	keys := NewKeyCache(1)
+	key1, err := keys.Get([]byte(testPassword), config)
+	require.NoError(err)
+	require.Equal(config, key1.Config())
+
+	// The key is only derived once.
+	key2, err := keys.Get([]byte(testPassword), config)
+	require.NoError(err)
+	require.Same(key1, key2)
+
+	// Different passwords derive different keys.
+	otherKey, err := keys.Get([]byte("other password"), config)
+	require.NoError(err)
+	require.NotSame(key1, otherKey)
+
+	// Databases created with the key can read each other's values.
+	unencryptedDB := memdb.New()
+	require.NoError(NewWithKey(unencryptedDB, key1).Put([]byte("hello"), []byte("world")))
+
+	db, err := NewWithConfig([]byte(testPassword), unencryptedDB, config)
+	require.NoError(err)
+	value, err := db.Get([]byte("hello"))
+	require.NoError(err)
+	require.Equal([]byte("world"), value)
+
+	_, err = NewWithKey(unencryptedDB, otherKey).Get([]byte("hello"))
+	require.Error(err) //nolint:forbidigo // chacha20poly1305 does not export the error
+
+	// Invalid configs are rejected.
+	_, err = keys.Get([]byte(testPassword), Config{})
+	require.ErrorIs(err, errEmptySalt)
+}
@@ -9,4 +9,7 @@ import "errors"
 var (
 	ErrClosed   = errors.New("closed")
 	ErrNotFound = errors.New("not found")
+
+	ErrRangeDeleteNotSupported  = errors.New("range deletion not supported")
+	ErrSizeEstimateNotSupported = errors.New("size estimation not supported")
 )
@@ -177,3 +177,14 @@ func ClearPrefix(readerDB Iteratee, deleterDB KeyValueDeleter, prefix []byte) er
 	}
 	return iterator.Error()
 }
+
+// EstimateSize returns the approximate number of bytes used on disk by [db] to
+// store the keys in the range [start, limit). If [db] doesn't implement
+// SizeEstimator, ErrSizeEstimateNotSupported is returned.
+func EstimateSize(db Database, start, limit []byte) (uint64, error) {
+	estimator, ok := db.(SizeEstimator)
+	if !ok {
+		return 0, ErrSizeEstimateNotSupported
+	}
+	return estimator.EstimateSize(start, limit)
+}
@@ -61,6 +61,26 @@ type Iteratee interface {
 	// database content with a particular key prefix starting at a specified
 	// key.
 	NewIteratorWithStartAndPrefix(start, prefix []byte) Iterator
+
+	// NewReverseIterator creates an iterator over the entire keyspace
+	// contained within the key-value database in descending key order.
+	NewReverseIterator() Iterator
+
+	// NewReverseIteratorWithStart creates an iterator, in descending key
+	// order, over a subset of database content starting at a particular
+	// initial key. Only keys <= [start] are included. A nil [start] is treated
+	// as a key after all keys in the database.
+	NewReverseIteratorWithStart(start []byte) Iterator
+
+	// NewReverseIteratorWithPrefix creates an iterator, in descending key
+	// order, over a subset of database content with a particular key prefix.
+	NewReverseIteratorWithPrefix(prefix []byte) Iterator
+
+	// NewReverseIteratorWithStartAndPrefix creates an iterator, in descending
+	// key order, over a subset of database content with a particular key
+	// prefix starting at a specified key. Only keys <= [start] are included.
+	// A nil [start] is treated as a key after all keys in the database.
+	NewReverseIteratorWithStartAndPrefix(start, prefix []byte) Iterator
 }
 
 // IteratorError does nothing and returns the provided error
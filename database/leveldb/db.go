@@ -63,12 +63,18 @@ const (
 	// levelDBByteOverhead is the number of bytes of constant overhead that
 	// should be added to a batch size per operation.
 	levelDBByteOverhead = 8
+
+	// deleteRangeChunkSize is the maximum number of keys that are deleted at
+	// once by a batch that only contains range deletions.
+	deleteRangeChunkSize = 1024
 )
 
 var (
-	_ database.Database = (*Database)(nil)
-	_ database.Batch    = (*batch)(nil)
-	_ database.Iterator = (*iter)(nil)
+	_ database.Database      = (*Database)(nil)
+	_ database.SizeEstimator = (*Database)(nil)
+	_ database.Batch         = (*batch)(nil)
+	_ database.Iterator      = (*iter)(nil)
+	_ database.Snapshot      = (*snapshot)(nil)
 
 	ErrInvalidConfig = errors.New("invalid config")
 	ErrCouldNotOpen  = errors.New("could not open")
@@ -294,6 +300,22 @@ func (db *Database) Delete(key []byte) error {
 	return updateError(db.DB.Delete(key, nil))
 }
 
+// DeleteRange removes every key in the range [start, limit).
+//
+// LevelDB doesn't support range deletions, so every key in the range is
+// deleted individually, which takes time proportional to the number of keys in
+// the range. The keys are deleted in chunks of [deleteRangeChunkSize] keys, so
+// the deletion isn't atomic: a concurrent reader may observe a partially
+// deleted range and keys written into the range while it is being deleted may
+// not be removed.
+func (db *Database) DeleteRange(start, limit []byte) error {
+	b := db.NewBatch()
+	if err := b.DeleteRange(start, limit); err != nil {
+		return err
+	}
+	return b.Write()
+}
+
 // NewBatch creates a write/delete-only buffer that is atomically committed to
 // the database when write is called
 func (db *Database) NewBatch() database.Batch {
@@ -330,14 +352,82 @@ func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
 // over the database starting at start and ignoring keys that do not start with
 // the provided prefix
 func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return &iter{
+		db:       db,
+		Iterator: db.DB.NewIterator(iteratorRange(start, prefix), nil),
+	}
+}
+
+// NewReverseIterator creates a reverse lexicographically ordered iterator over
+// the database
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+// NewReverseIteratorWithStart creates a reverse lexicographically ordered
+// iterator over the database starting at the provided key
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+// NewReverseIteratorWithPrefix creates a reverse lexicographically ordered
+// iterator over the database ignoring keys that do not start with the provided
+// prefix
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewReverseIteratorWithStartAndPrefix creates a reverse lexicographically
+// ordered iterator over the database starting at start and ignoring keys that
+// do not start with the provided prefix
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return &iter{
+		db:       db,
+		Iterator: db.DB.NewIterator(reverseIteratorRange(start, prefix), nil),
+		reverse:  true,
+	}
+}
+
+// Snapshot returns a read-only view of the current state of the database
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	if db.closed.Get() {
+		return nil, database.ErrClosed
+	}
+	s, err := db.DB.GetSnapshot()
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &snapshot{
+		db:       db,
+		snapshot: s,
+	}, nil
+}
+
+// iteratorRange returns the range of keys that an iterator starting at [start]
+// and ignoring keys that do not start with [prefix] iterates over
+func iteratorRange(start, prefix []byte) *util.Range {
 	iterRange := util.BytesPrefix(prefix)
 	if bytes.Compare(start, prefix) == 1 {
 		iterRange.Start = start
 	}
-	return &iter{
-		db:       db,
-		Iterator: db.DB.NewIterator(iterRange, nil),
+	return iterRange
+}
+
+// reverseIteratorRange returns the range of keys that a reverse iterator
+// starting at [start] and ignoring keys that do not start with [prefix]
+// iterates over
+func reverseIteratorRange(start, prefix []byte) *util.Range {
+	iterRange := util.BytesPrefix(prefix)
+	if start != nil {
+		// [Limit] is exclusive, so the smallest key larger than [start] is
+		// used to include [start].
+		limit := make([]byte, len(start)+1)
+		copy(limit, start)
+		if iterRange.Limit == nil || bytes.Compare(limit, iterRange.Limit) == -1 {
+			iterRange.Limit = limit
+		}
 	}
+	return iterRange
 }
 
 // This comment is basically copy pasted from the underlying levelDB library:
@@ -355,6 +445,37 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return updateError(db.DB.CompactRange(util.Range{Start: start, Limit: limit}))
 }
 
+// EstimateSize returns the approximate number of bytes used on disk to store
+// the keys in the range [start, limit). Data that is still in the memtable is
+// not accounted for.
+func (db *Database) EstimateSize(start, limit []byte) (uint64, error) {
+	if db.closed.Get() {
+		return 0, database.ErrClosed
+	}
+	if limit == nil {
+		// LevelDB treats a nil limit as a key before all keys, so the limit is
+		// replaced by the smallest key after the last key in the DB.
+		it := db.DB.NewIterator(new(util.Range), nil)
+		if it.Last() {
+			limit = append(slices.Clone(it.Key()), 0)
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return 0, updateError(err)
+		}
+		if limit == nil {
+			// The DB is empty.
+			return 0, nil
+		}
+	}
+
+	sizes, err := db.DB.SizeOf([]util.Range{{Start: start, Limit: limit}})
+	if err != nil {
+		return 0, updateError(err)
+	}
+	return uint64(sizes.Sum()), nil
+}
+
 func (db *Database) Close() error {
 	db.closed.Set(true)
 	db.closeOnce.Do(func() {
@@ -376,6 +497,18 @@ type batch struct {
 	leveldb.Batch
 	db   *Database
 	size int
+
+	// rangeDeletes are the range deletions in the batch, in the order they
+	// were added. LevelDB batches don't support range deletions, so they are
+	// tracked separately.
+	rangeDeletes []rangeDelete
+}
+
+type rangeDelete struct {
+	// index is the number of records in the LevelDB batch that precede this
+	// range deletion.
+	index        int
+	start, limit []byte
 }
 
 // Put the value into the batch for later writing
@@ -392,29 +525,106 @@ func (b *batch) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange deletes every key in the range [start, limit) during writing
+func (b *batch) DeleteRange(start, limit []byte) error {
+	b.rangeDeletes = append(b.rangeDeletes, rangeDelete{
+		index: b.Batch.Len(),
+		start: slices.Clone(start),
+		limit: slices.Clone(limit),
+	})
+	b.size += len(start) + len(limit) + levelDBByteOverhead
+	return nil
+}
+
 // Size retrieves the amount of data queued up for writing.
 func (b *batch) Size() int {
 	return b.size
 }
 
 // Write flushes any accumulated data to disk.
+//
+// If the batch contains range deletions and other operations, the batch is
+// written inside of a LevelDB transaction, which blocks all other writes to the
+// database until every key in the deleted ranges has been found and deleted.
+// If the batch only contains range deletions, there is nothing to keep atomic,
+// so the keys are deleted in chunks without blocking other writers, as
+// described in [Database.DeleteRange].
 func (b *batch) Write() error {
-	return updateError(b.db.DB.Write(&b.Batch, nil))
+	switch {
+	case len(b.rangeDeletes) == 0:
+		return updateError(b.db.DB.Write(&b.Batch, nil))
+	case b.Batch.Len() == 0:
+		for _, rangeDelete := range b.rangeDeletes {
+			if err := b.db.deleteRangeInChunks(rangeDelete.start, rangeDelete.limit); err != nil {
+				return updateError(err)
+			}
+		}
+		return nil
+	}
+
+	// The keys removed by the range deletions must be found and deleted
+	// atomically with the rest of the batch, so the batch is written inside
+	// of a transaction.
+	tr, err := b.db.DB.OpenTransaction()
+	if err != nil {
+		return updateError(err)
+	}
+	if err := b.Replay(&transaction{tr: tr}); err != nil {
+		tr.Discard()
+		return updateError(err)
+	}
+	return updateError(tr.Commit())
+}
+
+// deleteRangeInChunks deletes every key in the range [start, limit), writing
+// at most [deleteRangeChunkSize] deletions at a time.
+func (db *Database) deleteRangeInChunks(start, limit []byte) error {
+	var chunk leveldb.Batch
+	for {
+		it := db.DB.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+		for chunk.Len() < deleteRangeChunkSize && it.Next() {
+			// The next chunk starts at the last deleted key, which will no
+			// longer exist once this chunk has been written.
+			start = slices.Clone(it.Key())
+			chunk.Delete(start)
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+
+		if chunk.Len() == 0 {
+			return nil
+		}
+		if err := db.DB.Write(&chunk, nil); err != nil {
+			return err
+		}
+		if chunk.Len() < deleteRangeChunkSize {
+			return nil
+		}
+		chunk.Reset()
+	}
 }
 
 // Reset resets the batch for reuse.
 func (b *batch) Reset() {
 	b.Batch.Reset()
 	b.size = 0
+	b.rangeDeletes = nil
 }
 
 // Replay the batch contents.
 func (b *batch) Replay(w database.KeyValueWriterDeleter) error {
-	replay := &replayer{writerDeleter: w}
+	replay := &replayer{
+		writerDeleter: w,
+		rangeDeletes:  b.rangeDeletes,
+	}
 	if err := b.Batch.Replay(replay); err != nil {
 		// Never actually returns an error, because Replay just returns nil
 		return err
 	}
+	replay.deleteRanges()
 	return replay.err
 }
 
@@ -426,9 +636,15 @@ func (b *batch) Inner() database.Batch {
 type replayer struct {
 	writerDeleter database.KeyValueWriterDeleter
 	err           error
+
+	// index is the number of records that have been replayed
+	index        int
+	rangeDeletes []rangeDelete
 }
 
 func (r *replayer) Put(key, value []byte) {
+	r.deleteRanges()
+	r.index++
 	if r.err != nil {
 		return
 	}
@@ -436,30 +652,86 @@ func (r *replayer) Put(key, value []byte) {
 }
 
 func (r *replayer) Delete(key []byte) {
+	r.deleteRanges()
+	r.index++
 	if r.err != nil {
 		return
 	}
 	r.err = r.writerDeleter.Delete(key)
 }
 
+// deleteRanges replays the range deletions that precede the next record.
+func (r *replayer) deleteRanges() {
+	for len(r.rangeDeletes) > 0 && r.rangeDeletes[0].index <= r.index {
+		rangeDelete := r.rangeDeletes[0]
+		r.rangeDeletes = r.rangeDeletes[1:]
+		if r.err != nil {
+			continue
+		}
+		r.err = database.ReplayDeleteRange(r.writerDeleter, rangeDelete.start, rangeDelete.limit)
+	}
+}
+
+// transaction exposes a LevelDB transaction as a database writer.
+type transaction struct {
+	tr *leveldb.Transaction
+}
+
+func (t *transaction) Put(key, value []byte) error {
+	return t.tr.Put(key, value, nil)
+}
+
+func (t *transaction) Delete(key []byte) error {
+	return t.tr.Delete(key, nil)
+}
+
+func (t *transaction) DeleteRange(start, limit []byte) error {
+	it := t.tr.NewIterator(&util.Range{Start: start, Limit: limit}, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if err := t.tr.Delete(it.Key(), nil); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
 type iter struct {
 	db *Database
+	// snapshot is the snapshot being iterated over, or nil if the iterator
+	// was created from [db]
+	snapshot *snapshot
 	iterator.Iterator
 
+	// reverse is true if the iterator moves in descending key order
+	reverse     bool
+	initialized bool
+
 	key, val []byte
 	err      error
 }
 
 func (it *iter) Next() bool {
-	// Short-circuit and set an error if the underlying database has been closed.
-	if it.db.closed.Get() {
+	// Short-circuit and set an error if the underlying database has been
+	// closed or the snapshot has been released.
+	if it.db.closed.Get() || (it.snapshot != nil && it.snapshot.released.Get()) {
 		it.key = nil
 		it.val = nil
 		it.err = database.ErrClosed
 		return false
 	}
 
-	hasNext := it.Iterator.Next()
+	var hasNext bool
+	switch {
+	case !it.reverse:
+		hasNext = it.Iterator.Next()
+	case !it.initialized:
+		it.initialized = true
+		hasNext = it.Iterator.Last()
+	default:
+		hasNext = it.Iterator.Prev()
+	}
 	if hasNext {
 		it.key = slices.Clone(it.Iterator.Key())
 		it.val = slices.Clone(it.Iterator.Value())
@@ -485,9 +757,81 @@ func (it *iter) Value() []byte {
 	return it.val
 }
 
+// snapshot is a read-only view of the database at the time that it was taken
+type snapshot struct {
+	db       *Database
+	snapshot *leveldb.Snapshot
+	released utils.Atomic[bool]
+}
+
+// Has returns if the key was set in the database when the snapshot was taken
+func (s *snapshot) Has(key []byte) (bool, error) {
+	if s.released.Get() {
+		return false, database.ErrClosed
+	}
+	has, err := s.snapshot.Has(key, nil)
+	return has, updateError(err)
+}
+
+// Get returns the value the key mapped to in the database when the snapshot
+// was taken
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	if s.released.Get() {
+		return nil, database.ErrClosed
+	}
+	value, err := s.snapshot.Get(key, nil)
+	return value, updateError(err)
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return &iter{
+		db:       s.db,
+		snapshot: s,
+		Iterator: s.snapshot.NewIterator(iteratorRange(start, prefix), nil),
+	}
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return &iter{
+		db:       s.db,
+		snapshot: s,
+		Iterator: s.snapshot.NewIterator(reverseIteratorRange(start, prefix), nil),
+		reverse:  true,
+	}
+}
+
+func (s *snapshot) Release() {
+	s.released.Set(true)
+	s.snapshot.Release()
+}
+
 func updateError(err error) error {
 	switch err {
-	case leveldb.ErrClosed:
+	case leveldb.ErrClosed, leveldb.ErrSnapshotReleased:
 		return database.ErrClosed
 	case leveldb.ErrNotFound:
 		return database.ErrNotFound
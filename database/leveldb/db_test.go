@@ -32,6 +32,50 @@ func TestInterface(t *testing.T) {
 	}
 }
 
+func TestSizeEstimator(t *testing.T) {
+	for _, test := range database.SizeEstimatorTests {
+		folder := t.TempDir()
+		db, err := New(folder, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		test(t, db)
+
+		// The database may have been closed by the test, so we don't care if it
+		// errors here.
+		_ = db.Close()
+	}
+}
+
+func TestDeleteRangeInChunks(t *testing.T) {
+	require := require.New(t)
+
+	db, err := New(t.TempDir(), nil, logging.NoLog{}, "", prometheus.NewRegistry())
+	require.NoError(err)
+	defer db.Close()
+
+	// The range spans multiple chunks, including a partial chunk.
+	const numKeys = 5*deleteRangeChunkSize/2 + 2
+	for i := uint64(0); i < numKeys; i++ {
+		key := database.PackUInt64(i)
+		require.NoError(db.Put(key, key))
+	}
+
+	require.NoError(db.DeleteRange(database.PackUInt64(1), database.PackUInt64(numKeys-1)))
+
+	it := db.NewIterator()
+	defer it.Release()
+
+	var keys [][]byte
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	require.NoError(it.Error())
+	require.Equal([][]byte{
+		database.PackUInt64(0),
+		database.PackUInt64(numKeys - 1),
+	}, keys)
+}
+
 func FuzzInterface(f *testing.F) {
 	for _, test := range database.FuzzTests {
 		folder := f.TempDir()
@@ -13,10 +13,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/cachedb"
 	"github.com/memeticofficial/pepecoingo/database/corruptabledb"
 	"github.com/memeticofficial/pepecoingo/database/leveldb"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/database/meterdb"
+	"github.com/memeticofficial/pepecoingo/database/pebbledb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/utils"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
@@ -64,6 +66,15 @@ type Manager interface {
 	// Note: calling this more than once with the same [namespace] will cause a
 	// conflict error for the [registerer].
 	NewCompleteMeterDBManager(namespace string, registerer prometheus.Registerer) (Manager, error)
+
+	// NewCacheDBManager returns a new database manager with each database
+	// instance prefixed by each of [prefixes], in order. The current database
+	// is also wrapped with a cachedb instance that caches up to [cacheSize]
+	// bytes of reads. The cache is beneath the prefixes, so the keys written
+	// are the same as if the databases were only prefixed.
+	// Note: calling this more than once with the same [namespace] will cause a
+	// conflict error for the [registerer].
+	NewCacheDBManager(cacheSize int, namespace string, registerer prometheus.Registerer, prefixes ...[]byte) (Manager, error)
 }
 
 type manager struct {
@@ -96,6 +107,29 @@ func NewLevelDB(
 	)
 }
 
+// NewPebbleDB creates a database manager of pebbleDBs at [filePath] by
+// creating a database instance from each directory with a version <=
+// [currentVersion]. If [includePreviousVersions], opens previous database
+// versions and includes them in the returned Manager.
+func NewPebbleDB(
+	dbDirPath string,
+	dbConfig []byte,
+	log logging.Logger,
+	currentVersion *version.Semantic,
+	namespace string,
+	reg prometheus.Registerer,
+) (Manager, error) {
+	return new(
+		pebbledb.New,
+		dbDirPath,
+		dbConfig,
+		log,
+		currentVersion,
+		namespace,
+		reg,
+	)
+}
+
 // new creates a database manager at [filePath] by creating a database instance
 // from each directory with a version <= [currentVersion]. If
 // [includePreviousVersions], opens previous database versions and includes them
@@ -303,6 +337,36 @@ func (m *manager) NewCompleteMeterDBManager(namespace string, registerer prometh
 	})
 }
 
+// NewCacheDBManager prefixes each database instance by [prefixes] and caches
+// the reads of the current database instance.
+// Note: calling this more than once with the same [namespace] will cause a conflict error for the [registerer]
+func (m *manager) NewCacheDBManager(cacheSize int, namespace string, registerer prometheus.Registerer, prefixes ...[]byte) (Manager, error) {
+	currentDB := m.Current()
+	currentCacheDB, err := cachedb.NewPrefixed(namespace, registerer, currentDB.Database, cacheSize, prefixes...)
+	if err != nil {
+		return nil, err
+	}
+	newManager := &manager{
+		databases: make([]*VersionedDatabase, len(m.databases)),
+	}
+	for i, vdb := range m.databases[1:] {
+		db := vdb.Database
+		for _, prefix := range prefixes {
+			db = prefixdb.New(prefix, db)
+		}
+		newManager.databases[i+1] = &VersionedDatabase{
+			Database: db,
+			Version:  vdb.Version,
+		}
+	}
+	// Overwrite the current database with the cache DB
+	newManager.databases[0] = &VersionedDatabase{
+		Database: currentCacheDB,
+		Version:  currentDB.Version,
+	}
+	return newManager, nil
+}
+
 // wrapManager returns a new database manager with each managed database wrapped
 // by the [wrap] function. If an error is returned by wrap, the error is
 // returned immediately. If [wrap] never returns an error, then wrapManager is
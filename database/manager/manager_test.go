@@ -15,6 +15,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/database/leveldb"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/database/meterdb"
+	"github.com/memeticofficial/pepecoingo/database/pebbledb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
 	"github.com/memeticofficial/pepecoingo/utils/metric"
@@ -51,6 +52,36 @@ func TestNewSingleLevelDB(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestNewSinglePebbleDB(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+
+	v1 := version.Semantic1_0_0
+
+	dbPath := filepath.Join(dir, v1.String())
+	db, err := pebbledb.New(dbPath, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+	require.NoError(err)
+
+	err = db.Close()
+	require.NoError(err)
+
+	manager, err := NewPebbleDB(dir, nil, logging.NoLog{}, v1, "", prometheus.NewRegistry())
+	require.NoError(err)
+
+	semDB := manager.Current()
+	cmp := semDB.Version.Compare(v1)
+	require.Equal(0, cmp, "incorrect version on current database")
+
+	_, exists := manager.Previous()
+	require.False(exists, "there should be no previous database")
+
+	dbs := manager.GetDatabases()
+	require.Len(dbs, 1)
+
+	err = manager.Close()
+	require.NoError(err)
+}
+
 func TestNewCreatesSingleDB(t *testing.T) {
 	require := require.New(t)
 
@@ -318,6 +349,61 @@ func TestMeterDBManager(t *testing.T) {
 	require.ErrorIs(err, metric.ErrFailedRegistering)
 }
 
+func TestCacheDBManager(t *testing.T) {
+	require := require.New(t)
+
+	registry := prometheus.NewRegistry()
+
+	m := &manager{databases: []*VersionedDatabase{
+		{
+			Database: memdb.New(),
+			Version:  version.Semantic1_0_0,
+		},
+		{
+			Database: memdb.New(),
+			Version: &version.Semantic{
+				Major: 0,
+				Minor: 9,
+				Patch: 0,
+			},
+		},
+	}}
+
+	chainPrefix := []byte("chain")
+	vmPrefix := []byte("vm")
+	manager, err := m.NewCacheDBManager(1024, "", registry, chainPrefix, vmPrefix)
+	require.NoError(err)
+
+	dbs := manager.GetDatabases()
+	require.Len(dbs, 2)
+
+	// Every database is prefixed, whether or not it is cached.
+	key := []byte("key")
+	for i, db := range dbs {
+		require.NoError(db.Database.Put(key, key))
+
+		rawKey := append(prefixdb.MakePrefix(chainPrefix, vmPrefix), key...)
+		has, err := m.databases[i].Database.Has(rawKey)
+		require.NoError(err)
+		require.True(has)
+	}
+
+	// Reads from the current database are cached.
+	has, err := dbs[0].Database.Has(key)
+	require.NoError(err)
+	require.True(has)
+
+	rawKey := append(prefixdb.MakePrefix(chainPrefix, vmPrefix), key...)
+	require.NoError(m.databases[0].Database.Delete(rawKey))
+	has, err = dbs[0].Database.Has(key)
+	require.NoError(err)
+	require.True(has)
+
+	// Confirm that the error from a name conflict is handled correctly
+	_, err = m.NewCacheDBManager(1024, "", registry, chainPrefix, vmPrefix)
+	require.ErrorIs(err, metric.ErrFailedRegistering)
+}
+
 func TestCompleteMeterDBManager(t *testing.T) {
 	require := require.New(t)
 
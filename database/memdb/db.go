@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"github.com/memeticofficial/pepecoingo/database"
@@ -25,6 +26,7 @@ var (
 	_ database.Database = (*Database)(nil)
 	_ database.Batch    = (*batch)(nil)
 	_ database.Iterator = (*iterator)(nil)
+	_ database.Snapshot = (*snapshot)(nil)
 )
 
 // Database is an ephemeral key-value store that implements the Database
@@ -109,6 +111,28 @@ func (db *Database) Delete(key []byte) error {
 	return nil
 }
 
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.db == nil {
+		return database.ErrClosed
+	}
+	db.deleteRange(start, limit)
+	return nil
+}
+
+// Assumes [db.lock] is held.
+func (db *Database) deleteRange(start, limit []byte) {
+	startString := string(start)
+	limitString := string(limit)
+	for key := range db.db {
+		if key >= startString && (limit == nil || key < limitString) {
+			delete(db.db, key)
+		}
+	}
+}
+
 func (db *Database) NewBatch() database.Batch {
 	return &batch{db: db}
 }
@@ -144,6 +168,48 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 		}
 	}
 	slices.Sort(keys) // Keys need to be in sorted order
+	return db.newIterator(keys)
+}
+
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+
+	startString := string(start)
+	prefixString := string(prefix)
+	keys := make([]string, 0, len(db.db))
+	for key := range db.db {
+		if strings.HasPrefix(key, prefixString) && (start == nil || key <= startString) {
+			keys = append(keys, key)
+		}
+	}
+	// Keys need to be in reverse sorted order
+	slices.SortFunc(keys, func(a, b string) bool {
+		return a > b
+	})
+	return db.newIterator(keys)
+}
+
+// Assumes [db.lock] is held.
+func (db *Database) newIterator(keys []string) database.Iterator {
 	values := make([][]byte, 0, len(keys))
 	for _, key := range keys {
 		values = append(values, db.db[key])
@@ -155,6 +221,23 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 	}
 }
 
+// Snapshot returns a copy of the database. Values are never modified in place,
+// so they are shared with the copy rather than being duplicated.
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.db == nil {
+		return nil, database.ErrClosed
+	}
+	copiedDB := &Database{db: maps.Clone(db.db)}
+	return &snapshot{
+		KeyValueReader: copiedDB,
+		Iteratee:       copiedDB,
+		db:             copiedDB,
+	}, nil
+}
+
 func (db *Database) Compact(_, _ []byte) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
@@ -187,9 +270,12 @@ func (b *batch) Write() error {
 	}
 
 	for _, op := range b.Ops {
-		if op.Delete {
+		switch {
+		case op.DeleteRange:
+			b.db.deleteRange(op.Key, op.Limit)
+		case op.Delete:
 			delete(b.db.db, string(op.Key))
-		} else {
+		default:
 			b.db.db[string(op.Key)] = op.Value
 		}
 	}
@@ -254,3 +340,15 @@ func (it *iterator) Release() {
 	it.keys = nil
 	it.values = nil
 }
+
+// snapshot is a read-only copy of a database.
+type snapshot struct {
+	database.KeyValueReader
+	database.Iteratee
+
+	db *Database
+}
+
+func (s *snapshot) Release() {
+	_ = s.db.Close()
+}
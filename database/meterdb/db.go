@@ -79,6 +79,16 @@ func (db *Database) Delete(key []byte) error {
 	return err
 }
 
+func (db *Database) DeleteRange(start, limit []byte) error {
+	startTime := db.clock.Time()
+	err := db.db.DeleteRange(start, limit)
+	end := db.clock.Time()
+	db.writeSize.Observe(float64(len(start) + len(limit)))
+	db.deleteRange.Observe(float64(end.Sub(startTime)))
+	db.deleteRangeSize.Observe(float64(len(start) + len(limit)))
+	return err
+}
+
 func (db *Database) NewBatch() database.Batch {
 	start := db.clock.Time()
 	b := &batch{
@@ -116,6 +126,38 @@ func (db *Database) NewIteratorWithStartAndPrefix(
 	return it
 }
 
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (db *Database) NewReverseIteratorWithStartAndPrefix(
+	start,
+	prefix []byte,
+) database.Iterator {
+	startTime := db.clock.Time()
+	it := &iterator{
+		iterator: db.db.NewReverseIteratorWithStartAndPrefix(start, prefix),
+		db:       db,
+	}
+	end := db.clock.Time()
+	db.newIterator.Observe(float64(end.Sub(startTime)))
+	return it
+}
+
+// Snapshot returns a snapshot of the underlying database. Reads from the
+// snapshot aren't metered.
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	return db.db.Snapshot()
+}
+
 func (db *Database) Compact(start, limit []byte) error {
 	startTime := db.clock.Time()
 	err := db.db.Compact(start, limit)
@@ -163,6 +205,15 @@ func (b *batch) Delete(key []byte) error {
 	return err
 }
 
+func (b *batch) DeleteRange(start, limit []byte) error {
+	startTime := b.db.clock.Time()
+	err := b.batch.DeleteRange(start, limit)
+	end := b.db.clock.Time()
+	b.db.bDeleteRange.Observe(float64(end.Sub(startTime)))
+	b.db.bDeleteRangeSize.Observe(float64(len(start) + len(limit)))
+	return err
+}
+
 func (b *batch) Size() int {
 	start := b.db.clock.Time()
 	size := b.batch.Size()
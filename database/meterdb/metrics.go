@@ -39,6 +39,7 @@ type metrics struct {
 	get, getSize,
 	put, putSize,
 	delete, deleteSize,
+	deleteRange, deleteRangeSize,
 	newBatch,
 	newIterator,
 	compact,
@@ -46,6 +47,7 @@ type metrics struct {
 	healthCheck,
 	bPut, bPutSize,
 	bDelete, bDeleteSize,
+	bDeleteRange, bDeleteRangeSize,
 	bSize,
 	bWrite, bWriteSize,
 	bReset,
@@ -61,36 +63,40 @@ type metrics struct {
 func newMetrics(namespace string, reg prometheus.Registerer) (metrics, error) {
 	errs := wrappers.Errs{}
 	return metrics{
-		readSize:    newSizeMetric(namespace, "read", reg, &errs),
-		writeSize:   newSizeMetric(namespace, "write", reg, &errs),
-		has:         newTimeMetric(namespace, "has", reg, &errs),
-		hasSize:     newSizeMetric(namespace, "has", reg, &errs),
-		get:         newTimeMetric(namespace, "get", reg, &errs),
-		getSize:     newSizeMetric(namespace, "get", reg, &errs),
-		put:         newTimeMetric(namespace, "put", reg, &errs),
-		putSize:     newSizeMetric(namespace, "put", reg, &errs),
-		delete:      newTimeMetric(namespace, "delete", reg, &errs),
-		deleteSize:  newSizeMetric(namespace, "delete", reg, &errs),
-		newBatch:    newTimeMetric(namespace, "new_batch", reg, &errs),
-		newIterator: newTimeMetric(namespace, "new_iterator", reg, &errs),
-		compact:     newTimeMetric(namespace, "compact", reg, &errs),
-		close:       newTimeMetric(namespace, "close", reg, &errs),
-		healthCheck: newTimeMetric(namespace, "health_check", reg, &errs),
-		bPut:        newTimeMetric(namespace, "batch_put", reg, &errs),
-		bPutSize:    newSizeMetric(namespace, "batch_put", reg, &errs),
-		bDelete:     newTimeMetric(namespace, "batch_delete", reg, &errs),
-		bDeleteSize: newSizeMetric(namespace, "batch_delete", reg, &errs),
-		bSize:       newTimeMetric(namespace, "batch_size", reg, &errs),
-		bWrite:      newTimeMetric(namespace, "batch_write", reg, &errs),
-		bWriteSize:  newSizeMetric(namespace, "batch_write", reg, &errs),
-		bReset:      newTimeMetric(namespace, "batch_reset", reg, &errs),
-		bReplay:     newTimeMetric(namespace, "batch_replay", reg, &errs),
-		bInner:      newTimeMetric(namespace, "batch_inner", reg, &errs),
-		iNext:       newTimeMetric(namespace, "iterator_next", reg, &errs),
-		iNextSize:   newSizeMetric(namespace, "iterator_next", reg, &errs),
-		iError:      newTimeMetric(namespace, "iterator_error", reg, &errs),
-		iKey:        newTimeMetric(namespace, "iterator_key", reg, &errs),
-		iValue:      newTimeMetric(namespace, "iterator_value", reg, &errs),
-		iRelease:    newTimeMetric(namespace, "iterator_release", reg, &errs),
+		readSize:         newSizeMetric(namespace, "read", reg, &errs),
+		writeSize:        newSizeMetric(namespace, "write", reg, &errs),
+		has:              newTimeMetric(namespace, "has", reg, &errs),
+		hasSize:          newSizeMetric(namespace, "has", reg, &errs),
+		get:              newTimeMetric(namespace, "get", reg, &errs),
+		getSize:          newSizeMetric(namespace, "get", reg, &errs),
+		put:              newTimeMetric(namespace, "put", reg, &errs),
+		putSize:          newSizeMetric(namespace, "put", reg, &errs),
+		delete:           newTimeMetric(namespace, "delete", reg, &errs),
+		deleteSize:       newSizeMetric(namespace, "delete", reg, &errs),
+		deleteRange:      newTimeMetric(namespace, "delete_range", reg, &errs),
+		deleteRangeSize:  newSizeMetric(namespace, "delete_range", reg, &errs),
+		newBatch:         newTimeMetric(namespace, "new_batch", reg, &errs),
+		newIterator:      newTimeMetric(namespace, "new_iterator", reg, &errs),
+		compact:          newTimeMetric(namespace, "compact", reg, &errs),
+		close:            newTimeMetric(namespace, "close", reg, &errs),
+		healthCheck:      newTimeMetric(namespace, "health_check", reg, &errs),
+		bPut:             newTimeMetric(namespace, "batch_put", reg, &errs),
+		bPutSize:         newSizeMetric(namespace, "batch_put", reg, &errs),
+		bDelete:          newTimeMetric(namespace, "batch_delete", reg, &errs),
+		bDeleteSize:      newSizeMetric(namespace, "batch_delete", reg, &errs),
+		bDeleteRange:     newTimeMetric(namespace, "batch_delete_range", reg, &errs),
+		bDeleteRangeSize: newSizeMetric(namespace, "batch_delete_range", reg, &errs),
+		bSize:            newTimeMetric(namespace, "batch_size", reg, &errs),
+		bWrite:           newTimeMetric(namespace, "batch_write", reg, &errs),
+		bWriteSize:       newSizeMetric(namespace, "batch_write", reg, &errs),
+		bReset:           newTimeMetric(namespace, "batch_reset", reg, &errs),
+		bReplay:          newTimeMetric(namespace, "batch_replay", reg, &errs),
+		bInner:           newTimeMetric(namespace, "batch_inner", reg, &errs),
+		iNext:            newTimeMetric(namespace, "iterator_next", reg, &errs),
+		iNextSize:        newSizeMetric(namespace, "iterator_next", reg, &errs),
+		iError:           newTimeMetric(namespace, "iterator_error", reg, &errs),
+		iKey:             newTimeMetric(namespace, "iterator_key", reg, &errs),
+		iValue:           newTimeMetric(namespace, "iterator_value", reg, &errs),
+		iRelease:         newTimeMetric(namespace, "iterator_release", reg, &errs),
 	}, errs.Err
 }
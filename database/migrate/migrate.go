@@ -0,0 +1,244 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package migrate copies the contents of one database into another, possibly
+// of a different type, and verifies that the copy is complete.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/perms"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+)
+
+const (
+	// DefaultBatchSize is the default number of bytes written to the
+	// destination database per batch.
+	DefaultBatchSize = 4 * units.MiB
+
+	// progressFrequency is the number of keys copied between progress
+	// reports.
+	progressFrequency = 100_000
+)
+
+var errDestinationNotEmpty = errors.New("destination database is not empty")
+
+// Checkpoint records how much of a migration has been completed. Because keys
+// are copied in order, every key <= LastKey has been written to the
+// destination.
+type Checkpoint struct {
+	LastKey  []byte `json:"lastKey"`
+	NumKeys  uint64 `json:"numKeys"`
+	NumBytes uint64 `json:"numBytes"`
+}
+
+// Progress reports how much of a migration has been completed.
+type Progress struct {
+	NumKeys  uint64
+	NumBytes uint64
+	// Duration is the time spent migrating since the migration was (re)started.
+	Duration time.Duration
+	// resumedKeys and resumedBytes are the values from the checkpoint that
+	// the migration was resumed from.
+	resumedKeys  uint64
+	resumedBytes uint64
+}
+
+// KeysPerSecond is the rate keys have been copied since the migration was
+// (re)started.
+func (p Progress) KeysPerSecond() float64 {
+	return rate(p.NumKeys-p.resumedKeys, p.Duration)
+}
+
+// BytesPerSecond is the rate bytes have been copied since the migration was
+// (re)started.
+func (p Progress) BytesPerSecond() float64 {
+	return rate(p.NumBytes-p.resumedBytes, p.Duration)
+}
+
+func rate(n uint64, duration time.Duration) float64 {
+	seconds := duration.Seconds()
+	if seconds == 0 {
+		return 0
+	}
+	return float64(n) / seconds
+}
+
+// Config describes how a migration should be performed.
+type Config struct {
+	// BatchSize is the number of bytes to write to the destination per batch.
+	// If <= 0, DefaultBatchSize is used.
+	BatchSize int
+	// CheckpointFile, if non-empty, is the file the migration's progress is
+	// recorded in after every batch. If the file already exists, the migration
+	// is resumed from it.
+	CheckpointFile string
+	// OnProgress, if non-nil, is called periodically with the progress of the
+	// migration.
+	OnProgress func(Progress)
+}
+
+// Copy writes every key/value pair of [src] into [dst].
+//
+// If [config.CheckpointFile] doesn't exist, [dst] must be empty.
+func Copy(ctx context.Context, src database.Iteratee, dst database.Database, config Config) (Progress, error) {
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	checkpoint, err := readCheckpoint(config.CheckpointFile)
+	if err != nil {
+		return Progress{}, err
+	}
+	if checkpoint == nil {
+		if err := requireEmpty(dst); err != nil {
+			return Progress{}, err
+		}
+		checkpoint = &Checkpoint{}
+	}
+
+	var (
+		startTime = time.Now()
+		progress  = Progress{
+			NumKeys:      checkpoint.NumKeys,
+			NumBytes:     checkpoint.NumBytes,
+			resumedKeys:  checkpoint.NumKeys,
+			resumedBytes: checkpoint.NumBytes,
+		}
+		// If resuming, the first key of the iterator may have already been
+		// written.
+		resumeKey     = checkpoint.LastKey
+		skipResumeKey = checkpoint.NumKeys > 0
+		batch         = dst.NewBatch()
+		// batchHasWrites is tracked separately from the batch size because
+		// empty keys with empty values may not increase the size of the batch.
+		batchHasWrites = false
+		it             = src.NewIteratorWithStart(checkpoint.LastKey)
+	)
+	defer it.Release()
+
+	// writeBatch writes the current batch and then records the checkpoint.
+	// The checkpoint is only updated after the batch has been written, so if
+	// the process is killed, the checkpoint never claims keys that weren't
+	// written.
+	writeBatch := func(lastKey []byte) error {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		batchHasWrites = false
+
+		checkpoint.LastKey = lastKey
+		checkpoint.NumKeys = progress.NumKeys
+		checkpoint.NumBytes = progress.NumBytes
+		return writeCheckpoint(config.CheckpointFile, checkpoint)
+	}
+
+	var lastKey []byte
+	for it.Next() {
+		key := it.Key()
+		if skipResumeKey {
+			skipResumeKey = false
+			if bytes.Equal(key, resumeKey) {
+				continue
+			}
+		}
+		value := it.Value()
+		if err := batch.Put(key, value); err != nil {
+			return progress, err
+		}
+		lastKey = key
+		batchHasWrites = true
+
+		progress.NumKeys++
+		progress.NumBytes += uint64(len(key) + len(value))
+
+		if batch.Size() >= batchSize {
+			if err := writeBatch(lastKey); err != nil {
+				return progress, err
+			}
+		}
+
+		if progress.NumKeys%progressFrequency == 0 {
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+			progress.Duration = time.Since(startTime)
+			reportProgress(config.OnProgress, progress)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return progress, err
+	}
+	if batchHasWrites {
+		if err := writeBatch(lastKey); err != nil {
+			return progress, err
+		}
+	}
+
+	progress.Duration = time.Since(startTime)
+	reportProgress(config.OnProgress, progress)
+	return progress, nil
+}
+
+func requireEmpty(db database.Iteratee) error {
+	it := db.NewIterator()
+	defer it.Release()
+
+	if it.Next() {
+		return errDestinationNotEmpty
+	}
+	return it.Error()
+}
+
+func reportProgress(onProgress func(Progress), progress Progress) {
+	if onProgress != nil {
+		onProgress(progress)
+	}
+}
+
+// readCheckpoint returns the checkpoint in [file]. If [file] is empty or
+// doesn't exist, nil is returned.
+func readCheckpoint(file string) (*Checkpoint, error) {
+	if len(file) == 0 {
+		return nil, nil
+	}
+	checkpointBytes, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &Checkpoint{}
+	if err := json.Unmarshal(checkpointBytes, checkpoint); err != nil {
+		return nil, fmt.Errorf("couldn't parse checkpoint %s: %w", file, err)
+	}
+	return checkpoint, nil
+}
+
+// writeCheckpoint atomically replaces the contents of [file] with
+// [checkpoint]. If [file] is empty, this is a noop.
+func writeCheckpoint(file string, checkpoint *Checkpoint) error {
+	if len(file) == 0 {
+		return nil
+	}
+	checkpointBytes, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmpFile := file + ".tmp"
+	if err := perms.WriteFile(tmpFile, checkpointBytes, perms.ReadWrite); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
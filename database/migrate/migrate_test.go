@@ -0,0 +1,144 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+)
+
+var errTest = errors.New("non-nil error")
+
+func populate(t *testing.T, db database.KeyValueWriter, numKeys int) {
+	for i := 0; i < numKeys; i++ {
+		key := []byte(fmt.Sprintf("key-%05d", i))
+		value := []byte(fmt.Sprintf("value-%05d", i))
+		require.NoError(t, db.Put(key, value))
+	}
+}
+
+func TestCopy(t *testing.T) {
+	require := require.New(t)
+
+	src := memdb.New()
+	populate(t, prefixdb.New([]byte("chain1"), src), 100)
+	populate(t, prefixdb.New([]byte("chain2"), src), 50)
+	require.NoError(src.Put(nil, nil))
+
+	dst := memdb.New()
+	progress, err := Copy(context.Background(), src, dst, Config{
+		BatchSize:      128,
+		CheckpointFile: filepath.Join(t.TempDir(), "checkpoint"),
+	})
+	require.NoError(err)
+	require.Equal(uint64(151), progress.NumKeys)
+
+	summary, err := Verify(context.Background(), src, dst, DefaultPrefixLen)
+	require.NoError(err)
+	require.Len(summary, 3)
+	require.Equal(uint64(151), summary.NumKeys())
+}
+
+func TestCopyNonEmptyDestination(t *testing.T) {
+	dst := memdb.New()
+	populate(t, dst, 1)
+
+	_, err := Copy(context.Background(), memdb.New(), dst, Config{})
+	require.ErrorIs(t, err, errDestinationNotEmpty)
+}
+
+// failingBatchDB fails to write the [failAfter]th batch.
+type failingBatchDB struct {
+	database.Database
+	failAfter int
+}
+
+func (db *failingBatchDB) NewBatch() database.Batch {
+	return &failingBatch{
+		Batch: db.Database.NewBatch(),
+		db:    db,
+	}
+}
+
+type failingBatch struct {
+	database.Batch
+	db *failingBatchDB
+}
+
+func (b *failingBatch) Write() error {
+	if b.db.failAfter == 0 {
+		return errTest
+	}
+	b.db.failAfter--
+	return b.Batch.Write()
+}
+
+func TestCopyResume(t *testing.T) {
+	require := require.New(t)
+
+	src := memdb.New()
+	populate(t, src, 100)
+
+	checkpointFile := filepath.Join(t.TempDir(), "checkpoint")
+	dst := memdb.New()
+	_, err := Copy(context.Background(), src, &failingBatchDB{
+		Database:  dst,
+		failAfter: 3,
+	}, Config{
+		BatchSize:      128,
+		CheckpointFile: checkpointFile,
+	})
+	require.ErrorIs(err, errTest)
+
+	checkpoint, err := readCheckpoint(checkpointFile)
+	require.NoError(err)
+	require.NotNil(checkpoint)
+	require.Positive(checkpoint.NumKeys)
+	require.Less(checkpoint.NumKeys, uint64(100))
+
+	// The destination is no longer empty, so resuming relies on the checkpoint.
+	progress, err := Copy(context.Background(), src, dst, Config{
+		BatchSize:      128,
+		CheckpointFile: checkpointFile,
+	})
+	require.NoError(err)
+	require.Equal(uint64(100), progress.NumKeys)
+
+	_, err = Verify(context.Background(), src, dst, DefaultPrefixLen)
+	require.NoError(err)
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	require := require.New(t)
+
+	expected := memdb.New()
+	populate(t, prefixdb.New([]byte("chain1"), expected), 10)
+	populate(t, prefixdb.New([]byte("chain2"), expected), 10)
+
+	actual := memdb.New()
+	populate(t, prefixdb.New([]byte("chain1"), actual), 10)
+	populate(t, prefixdb.New([]byte("chain2"), actual), 9)
+
+	_, err := Verify(context.Background(), expected, actual, DefaultPrefixLen)
+	require.ErrorIs(err, errMismatch)
+
+	// Modifying a value without changing the number of keys must also be
+	// detected.
+	actual = memdb.New()
+	populate(t, prefixdb.New([]byte("chain1"), actual), 10)
+	populate(t, prefixdb.New([]byte("chain2"), actual), 10)
+	require.NoError(prefixdb.New([]byte("chain2"), actual).Put([]byte("key-00000"), nil))
+
+	_, err = Verify(context.Background(), expected, actual, DefaultPrefixLen)
+	require.ErrorIs(err, errMismatch)
+}
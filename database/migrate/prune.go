@@ -0,0 +1,72 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/memeticofficial/pepecoingo/version"
+)
+
+var (
+	errPruneCurrentVersion = errors.New("refusing to prune the current database version")
+	errPruneNewerVersion   = errors.New("refusing to prune a version that isn't older than the kept version")
+)
+
+// OldVersions returns the paths of the version directories in [dbDir] that
+// are older than [keep]. Entries that aren't directories named after a
+// database version are ignored.
+func OldVersions(dbDir string, keep *version.Semantic) ([]string, error) {
+	entries, err := os.ReadDir(dbDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dbVersion, err := version.Parse(entry.Name())
+		if err != nil {
+			continue
+		}
+		if canPrune(dbVersion, keep) == nil {
+			paths = append(paths, filepath.Join(dbDir, entry.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// PruneVersions removes the version directories in [dbDir] that are older
+// than [keep]. Neither [keep] nor the current database version is ever
+// removed. The paths that were removed are returned.
+func PruneVersions(dbDir string, keep *version.Semantic) ([]string, error) {
+	paths, err := OldVersions(dbDir, keep)
+	if err != nil {
+		return nil, err
+	}
+	for i, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return paths[:i], err
+		}
+	}
+	return paths, nil
+}
+
+// canPrune returns nil if the database at [dbVersion] may be removed when
+// [keep] is being kept.
+func canPrune(dbVersion, keep *version.Semantic) error {
+	switch {
+	case dbVersion.Compare(version.CurrentDatabase) == 0:
+		return fmt.Errorf("%w: %s", errPruneCurrentVersion, dbVersion)
+	case dbVersion.Compare(keep) >= 0:
+		return fmt.Errorf("%w: %s", errPruneNewerVersion, dbVersion)
+	default:
+		return nil
+	}
+}
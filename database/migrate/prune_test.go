@@ -0,0 +1,75 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/utils/perms"
+	"github.com/memeticofficial/pepecoingo/version"
+)
+
+func TestPruneVersions(t *testing.T) {
+	require := require.New(t)
+
+	dbDir := t.TempDir()
+	for _, name := range []string{
+		"v1.0.0",
+		"v1.4.4",
+		version.CurrentDatabase.String(),
+		"v9.9.9",
+		"not-a-version",
+	} {
+		require.NoError(os.Mkdir(filepath.Join(dbDir, name), perms.ReadWriteExecute))
+	}
+	// Files named after versions aren't removed.
+	require.NoError(os.WriteFile(filepath.Join(dbDir, "v1.0.1"), nil, perms.ReadWrite))
+
+	expected := []string{
+		filepath.Join(dbDir, "v1.0.0"),
+		filepath.Join(dbDir, "v1.4.4"),
+	}
+	paths, err := OldVersions(dbDir, version.CurrentDatabase)
+	require.NoError(err)
+	require.Equal(expected, paths)
+
+	paths, err = PruneVersions(dbDir, version.CurrentDatabase)
+	require.NoError(err)
+	require.Equal(expected, paths)
+
+	entries, err := os.ReadDir(dbDir)
+	require.NoError(err)
+	var remaining []string
+	for _, entry := range entries {
+		remaining = append(remaining, entry.Name())
+	}
+	require.ElementsMatch([]string{
+		"not-a-version",
+		"v1.0.1",
+		version.CurrentDatabase.String(),
+		"v9.9.9",
+	}, remaining)
+}
+
+func TestPruneVersionsKeepsCurrentVersion(t *testing.T) {
+	require := require.New(t)
+
+	dbDir := t.TempDir()
+	currentPath := filepath.Join(dbDir, version.CurrentDatabase.String())
+	require.NoError(os.Mkdir(currentPath, perms.ReadWriteExecute))
+
+	// Even when keeping a newer version, the current version isn't removed.
+	keep := &version.Semantic{Major: 9, Minor: 9, Patch: 9}
+	paths, err := PruneVersions(dbDir, keep)
+	require.NoError(err)
+	require.Empty(paths)
+	require.DirExists(currentPath)
+
+	require.ErrorIs(canPrune(version.CurrentDatabase, keep), errPruneCurrentVersion)
+	require.ErrorIs(canPrune(keep, keep), errPruneNewerVersion)
+}
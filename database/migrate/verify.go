@@ -0,0 +1,143 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/hashing"
+)
+
+// DefaultPrefixLen is the default number of leading key bytes used to group
+// keys when verifying a migration. This matches the length of the prefixes
+// added by prefixdb, so every chain is summarized separately.
+const DefaultPrefixLen = hashing.HashLen
+
+var errMismatch = errors.New("databases don't match")
+
+// PrefixSummary describes all of the key/value pairs that share a prefix.
+type PrefixSummary struct {
+	NumKeys  uint64
+	NumBytes uint64
+	// Hash is the SHA-256 hash of the ordered key/value pairs.
+	Hash [sha256.Size]byte
+}
+
+// Summary maps the hex encoded key prefixes of a database to a description of
+// the key/value pairs with that prefix. Keys shorter than the prefix length
+// are summarized under the empty prefix.
+type Summary map[string]PrefixSummary
+
+// NumKeys returns the total number of keys in the summary.
+func (s Summary) NumKeys() uint64 {
+	var numKeys uint64
+	for _, prefix := range s {
+		numKeys += prefix.NumKeys
+	}
+	return numKeys
+}
+
+// Summarize returns a summary of [db] grouped by the first [prefixLen] bytes
+// of each key.
+func Summarize(ctx context.Context, db database.Iteratee, prefixLen int) (Summary, error) {
+	var (
+		summary       = make(Summary)
+		hashers       = make(map[string]hash.Hash)
+		lenBuf        [binary.MaxVarintLen64]byte
+		numKeysPassed uint64
+	)
+
+	it := db.NewIterator()
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		value := it.Value()
+
+		var prefix string
+		if len(key) >= prefixLen {
+			prefix = hex.EncodeToString(key[:prefixLen])
+		}
+
+		hasher, ok := hashers[prefix]
+		if !ok {
+			hasher = sha256.New()
+			hashers[prefix] = hasher
+		}
+		for _, b := range [][]byte{key, value} {
+			n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+			_, _ = hasher.Write(lenBuf[:n])
+			_, _ = hasher.Write(b)
+		}
+
+		prefixSummary := summary[prefix]
+		prefixSummary.NumKeys++
+		prefixSummary.NumBytes += uint64(len(key) + len(value))
+		summary[prefix] = prefixSummary
+
+		numKeysPassed++
+		if numKeysPassed%progressFrequency == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	for prefix, hasher := range hashers {
+		prefixSummary := summary[prefix]
+		copy(prefixSummary.Hash[:], hasher.Sum(nil))
+		summary[prefix] = prefixSummary
+	}
+	return summary, nil
+}
+
+// Verify returns an error if [expected] and [actual] don't contain exactly the
+// same key/value pairs. The error reports every prefix that differs.
+func Verify(ctx context.Context, expected, actual database.Iteratee, prefixLen int) (Summary, error) {
+	expectedSummary, err := Summarize(ctx, expected, prefixLen)
+	if err != nil {
+		return nil, err
+	}
+	actualSummary, err := Summarize(ctx, actual, prefixLen)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := maps.Keys(expectedSummary)
+	for prefix := range actualSummary {
+		if _, ok := expectedSummary[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	slices.Sort(prefixes)
+
+	var mismatched []string
+	for _, prefix := range prefixes {
+		if expectedSummary[prefix] != actualSummary[prefix] {
+			mismatched = append(mismatched, fmt.Sprintf(
+				"prefix %q: expected %d keys but found %d",
+				prefix,
+				expectedSummary[prefix].NumKeys,
+				actualSummary[prefix].NumKeys,
+			))
+		}
+	}
+	if len(mismatched) > 0 {
+		return nil, fmt.Errorf("%w: %v", errMismatch, mismatched)
+	}
+	return expectedSummary, nil
+}
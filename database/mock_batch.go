@@ -50,6 +50,20 @@ func (mr *MockBatchMockRecorder) Delete(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBatch)(nil).Delete), arg0)
 }
 
+// DeleteRange mocks base method.
+func (m *MockBatch) DeleteRange(arg0, arg1 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRange", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRange indicates an expected call of DeleteRange.
+func (mr *MockBatchMockRecorder) DeleteRange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRange", reflect.TypeOf((*MockBatch)(nil).DeleteRange), arg0, arg1)
+}
+
 // Inner mocks base method.
 func (m *MockBatch) Inner() Batch {
 	m.ctrl.T.Helper()
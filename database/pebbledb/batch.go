@@ -0,0 +1,144 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+)
+
+var _ database.Batch = (*batch)(nil)
+
+// batch is a wrapper around a pebble batch to contain sizes.
+type batch struct {
+	batch *pebble.Batch
+	db    *Database
+	size  int
+
+	// written is true if [batch] has been committed. Pebble doesn't allow a
+	// batch to be committed more than once.
+	written bool
+
+	// lastKey is the largest key that has been put into the batch. It is used
+	// to resolve range deletions without a limit.
+	lastKey []byte
+}
+
+// Put the value into the batch for later writing
+func (b *batch) Put(key, value []byte) error {
+	b.size += len(key) + len(value) + pebbleByteOverhead
+	if b.lastKey == nil || pebble.DefaultComparer.Compare(key, b.lastKey) > 0 {
+		b.lastKey = slices.Clone(key)
+	}
+	return b.batch.Set(key, value, pebble.Sync)
+}
+
+// Delete the key during writing
+func (b *batch) Delete(key []byte) error {
+	b.size += len(key) + pebbleByteOverhead
+	return b.batch.Delete(key, pebble.Sync)
+}
+
+// DeleteRange deletes every key in the range [start, limit) during writing.
+//
+// A nil [limit] is resolved to the smallest key after the last key currently
+// in the DB or in the batch. Keys added to the DB after this call that are
+// after every such key will not be deleted.
+func (b *batch) DeleteRange(start, limit []byte) error {
+	b.db.lock.RLock()
+	defer b.db.lock.RUnlock()
+
+	if b.db.closed {
+		return database.ErrClosed
+	}
+
+	limit, err := b.db.resolveLimit(limit, b.lastKey)
+	if err != nil {
+		return err
+	}
+	if pebble.DefaultComparer.Compare(start, limit) >= 0 {
+		// The range is empty, so there is nothing to delete.
+		return nil
+	}
+	b.size += len(start) + len(limit) + pebbleByteOverhead
+	return b.batch.DeleteRange(start, limit, pebble.Sync)
+}
+
+// Size retrieves the amount of data queued up for writing.
+func (b *batch) Size() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	b.db.lock.RLock()
+	defer b.db.lock.RUnlock()
+
+	if b.db.closed {
+		return database.ErrClosed
+	}
+
+	if !b.written {
+		b.written = true
+		return updateError(b.batch.Commit(pebble.Sync))
+	}
+
+	// The batch was already committed, so its contents must be copied into a
+	// new batch to be committed again.
+	newBatch := b.db.pebbleDB.NewBatch()
+	if err := newBatch.Apply(b.batch, nil); err != nil {
+		return err
+	}
+	if err := b.batch.Close(); err != nil {
+		return err
+	}
+	b.batch = newBatch
+	return updateError(b.batch.Commit(pebble.Sync))
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.batch.Reset()
+	b.written = false
+	b.size = 0
+	b.lastKey = nil
+}
+
+// Replay the batch contents.
+func (b *batch) Replay(w database.KeyValueWriterDeleter) error {
+	reader := b.batch.Reader()
+	for {
+		kind, key, value, ok := reader.Next()
+		if !ok {
+			return nil
+		}
+		switch kind {
+		case pebble.InternalKeyKindSet:
+			if err := w.Put(key, value); err != nil {
+				return err
+			}
+		case pebble.InternalKeyKindDelete:
+			if err := w.Delete(key); err != nil {
+				return err
+			}
+		case pebble.InternalKeyKindRangeDelete:
+			// The value of a range deletion is its exclusive end key.
+			if err := database.ReplayDeleteRange(w, key, value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %v", errInvalidOperation, kind)
+		}
+	}
+}
+
+// Inner returns itself
+func (b *batch) Inner() database.Batch {
+	return b
+}
@@ -0,0 +1,638 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.uber.org/zap"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+)
+
+const (
+	// Name is the name of this database for database switches
+	Name = "pebble"
+
+	// pebbleByteOverhead is the number of bytes of constant overhead that
+	// should be added to a batch size per operation.
+	pebbleByteOverhead = 8
+
+	// numLevels is the number of levels in the LSM tree. This matches the
+	// number of levels pebble uses internally.
+	numLevels = 7
+
+	// DefaultMetricUpdateFrequency is the frequency to poll the pebble
+	// metrics.
+	DefaultMetricUpdateFrequency = 10 * time.Second
+)
+
+var (
+	_ database.Database      = (*Database)(nil)
+	_ database.SizeEstimator = (*Database)(nil)
+
+	ErrInvalidConfig = errors.New("invalid config")
+	ErrCouldNotOpen  = errors.New("could not open")
+
+	errInvalidOperation = errors.New("invalid operation")
+)
+
+// Database is a persistent key-value store backed by pebble. Apart from basic
+// data storage functionality it also supports batch writes and iterating over
+// the keyspace in binary-alphabetical order.
+type Database struct {
+	lock     sync.RWMutex
+	pebbleDB *pebble.DB
+	closed   bool
+	// openIterators must be closed before [pebbleDB] is closed, otherwise
+	// pebble will report leaked iterators.
+	openIterators set.Set[*iter]
+	// openSnapshots must be closed before [pebbleDB] is closed, otherwise
+	// pebble will report leaked snapshots.
+	openSnapshots set.Set[*snapshot]
+
+	// metrics is only initialized and used when [MetricUpdateFrequency] is > 0
+	// in the config
+	metrics metrics
+
+	// closeCh is closed when Close() is called.
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	// closeWg is used to wait for all goroutines created by New() to exit.
+	// This avoids racy behavior when Close() is called while the metrics are
+	// being updated.
+	closeWg sync.WaitGroup
+}
+
+type config struct {
+	// CacheSize is the number of bytes to use for the block cache.
+	//
+	// The default value is 512MiB.
+	CacheSize int64 `json:"cacheSize"`
+	// BytesPerSync is the number of bytes written to an sstable between
+	// background syncs.
+	//
+	// The default value is 512KiB.
+	BytesPerSync int `json:"bytesPerSync"`
+	// WALBytesPerSync is the number of bytes written to the WAL between
+	// background syncs. 0 disables background syncing of the WAL.
+	//
+	// The default value is 0.
+	WALBytesPerSync int `json:"walBytesPerSync"`
+	// MemTableStopWritesThreshold is the hard limit on the number of queued
+	// memtables. Writes are stopped when this limit is reached.
+	//
+	// The default value is 8.
+	MemTableStopWritesThreshold int `json:"memTableStopWritesThreshold"`
+	// MemTableSize is the size of a memtable. Larger memtables reduce
+	// compaction pressure at the cost of memory usage.
+	//
+	// The default value is 128MiB.
+	MemTableSize int `json:"memTableSize"`
+	// MaxOpenFiles is a soft limit on the number of open files that can be
+	// used by the database.
+	//
+	// The default value is 4096.
+	MaxOpenFiles int `json:"maxOpenFiles"`
+	// MaxConcurrentCompactions is the maximum number of concurrent
+	// compactions.
+	//
+	// The default value is 1.
+	MaxConcurrentCompactions int `json:"maxConcurrentCompactions"`
+	// L0CompactionThreshold is the amount of L0 read-amplification necessary
+	// to trigger an L0 compaction.
+	//
+	// The default value is 4.
+	L0CompactionThreshold int `json:"l0CompactionThreshold"`
+	// L0StopWritesThreshold is the hard limit on L0 read-amplification.
+	// Writes are stopped when this threshold is reached.
+	//
+	// The default value is 12.
+	L0StopWritesThreshold int `json:"l0StopWritesThreshold"`
+	// LBaseMaxBytes is the maximum number of bytes for LBase.
+	//
+	// The default value is 64MiB.
+	LBaseMaxBytes int64 `json:"lBaseMaxBytes"`
+	// BlockSize is the target uncompressed size in bytes of each table block.
+	//
+	// The default value is 4KiB.
+	BlockSize int `json:"blockSize"`
+	// TargetFileSize is the target file size for L0. Each subsequent level
+	// doubles the target file size.
+	//
+	// The default value is 2MiB.
+	TargetFileSize int64 `json:"targetFileSize"`
+	// FilterBitsPerKey is the number of bits to add to the bloom filter per
+	// key.
+	//
+	// The default value is 10.
+	FilterBitsPerKey int `json:"filterBitsPerKey"`
+	// DisableWAL disables the write ahead log. This should only be used if
+	// loss of recent writes on a crash is acceptable.
+	//
+	// The default is false.
+	DisableWAL bool `json:"disableWAL"`
+	// MetricUpdateFrequency is the frequency to poll the pebble metrics.
+	// If <= 0, the pebble metrics aren't polled.
+	//
+	// The default value is 10s.
+	MetricUpdateFrequency time.Duration `json:"metricUpdateFrequency"`
+}
+
+// New returns a wrapped pebble DB object.
+func New(file string, configBytes []byte, log logging.Logger, namespace string, reg prometheus.Registerer) (database.Database, error) {
+	parsedConfig := config{
+		CacheSize:                   512 * units.MiB,
+		BytesPerSync:                512 * units.KiB,
+		WALBytesPerSync:             0,
+		MemTableStopWritesThreshold: 8,
+		MemTableSize:                128 * units.MiB,
+		MaxOpenFiles:                4096,
+		MaxConcurrentCompactions:    1,
+		L0CompactionThreshold:       4,
+		L0StopWritesThreshold:       12,
+		LBaseMaxBytes:               64 * units.MiB,
+		BlockSize:                   4 * units.KiB,
+		TargetFileSize:              2 * units.MiB,
+		FilterBitsPerKey:            10,
+		MetricUpdateFrequency:       DefaultMetricUpdateFrequency,
+	}
+	if len(configBytes) > 0 {
+		if err := json.Unmarshal(configBytes, &parsedConfig); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+		}
+	}
+
+	log.Info("creating pebble",
+		zap.Reflect("config", parsedConfig),
+	)
+
+	cache := pebble.NewCache(parsedConfig.CacheSize)
+	// The cache is reference counted by the DB, so the reference returned by
+	// NewCache can be released once the DB has been opened.
+	defer cache.Unref()
+
+	opts := &pebble.Options{
+		Cache:                       cache,
+		BytesPerSync:                parsedConfig.BytesPerSync,
+		Comparer:                    pebble.DefaultComparer,
+		DisableWAL:                  parsedConfig.DisableWAL,
+		L0CompactionThreshold:       parsedConfig.L0CompactionThreshold,
+		L0StopWritesThreshold:       parsedConfig.L0StopWritesThreshold,
+		LBaseMaxBytes:               parsedConfig.LBaseMaxBytes,
+		Logger:                      &logger{log: log},
+		MaxOpenFiles:                parsedConfig.MaxOpenFiles,
+		MemTableSize:                parsedConfig.MemTableSize,
+		MemTableStopWritesThreshold: parsedConfig.MemTableStopWritesThreshold,
+		MaxConcurrentCompactions: func() int {
+			return parsedConfig.MaxConcurrentCompactions
+		},
+		WALBytesPerSync: parsedConfig.WALBytesPerSync,
+	}
+	// Disable seek triggered compactions, matching the leveldb defaults.
+	opts.Experimental.ReadSamplingMultiplier = -1
+
+	opts.Levels = make([]pebble.LevelOptions, numLevels)
+	targetFileSize := parsedConfig.TargetFileSize
+	for i := range opts.Levels {
+		level := &opts.Levels[i]
+		level.BlockSize = parsedConfig.BlockSize
+		level.FilterPolicy = bloom.FilterPolicy(parsedConfig.FilterBitsPerKey)
+		level.FilterType = pebble.TableFilter
+		level.TargetFileSize = targetFileSize
+		level.EnsureDefaults()
+
+		targetFileSize *= 2
+	}
+
+	db, err := pebble.Open(file, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCouldNotOpen, err)
+	}
+	wrappedDB := &Database{
+		pebbleDB:      db,
+		openIterators: set.Set[*iter]{},
+		openSnapshots: set.Set[*snapshot]{},
+		closeCh:       make(chan struct{}),
+	}
+	if parsedConfig.MetricUpdateFrequency > 0 {
+		metrics, err := newMetrics(namespace, reg)
+		if err != nil {
+			// Drop any close error to report the original error
+			_ = db.Close()
+			return nil, err
+		}
+		wrappedDB.metrics = metrics
+		wrappedDB.closeWg.Add(1)
+		go func() {
+			t := time.NewTicker(parsedConfig.MetricUpdateFrequency)
+			defer func() {
+				t.Stop()
+				wrappedDB.closeWg.Done()
+			}()
+
+			for {
+				if err := wrappedDB.updateMetrics(); err != nil {
+					log.Warn("failed to update pebble metrics",
+						zap.Error(err),
+					)
+				}
+
+				select {
+				case <-t.C:
+				case <-wrappedDB.closeCh:
+					return
+				}
+			}
+		}()
+	}
+	return wrappedDB, nil
+}
+
+// Has returns if the key is set in the database
+func (db *Database) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return false, database.ErrClosed
+	}
+	return has(db.pebbleDB, key)
+}
+
+// Get returns the value the key maps to in the database
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	return get(db.pebbleDB, key)
+}
+
+// Put sets the value of the provided key to the provided value
+func (db *Database) Put(key []byte, value []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	return updateError(db.pebbleDB.Set(key, value, pebble.Sync))
+}
+
+// Delete removes the key from the database
+func (db *Database) Delete(key []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	return updateError(db.pebbleDB.Delete(key, pebble.Sync))
+}
+
+// DeleteRange removes every key in the range [start, limit).
+//
+// A nil [limit] is resolved to the smallest key after the last key currently
+// in the DB.
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+
+	limit, err := db.resolveLimit(limit, nil)
+	if err != nil {
+		return err
+	}
+	if pebble.DefaultComparer.Compare(start, limit) >= 0 {
+		// The range is empty, so there is nothing to delete.
+		return nil
+	}
+	return updateError(db.pebbleDB.DeleteRange(start, limit, pebble.Sync))
+}
+
+// NewBatch creates a write/delete-only buffer that is atomically committed to
+// the database when write is called
+func (db *Database) NewBatch() database.Batch {
+	return &batch{
+		db:    db,
+		batch: db.pebbleDB.NewBatch(),
+	}
+}
+
+// NewIterator creates a lexicographically ordered iterator over the database
+func (db *Database) NewIterator() database.Iterator {
+	return db.newIterator(&pebble.IterOptions{})
+}
+
+// NewIteratorWithStart creates a lexicographically ordered iterator over the
+// database starting at the provided key
+func (db *Database) NewIteratorWithStart(start []byte) database.Iterator {
+	return db.newIterator(&pebble.IterOptions{
+		LowerBound: start,
+	})
+}
+
+// NewIteratorWithPrefix creates a lexicographically ordered iterator over the
+// database ignoring keys that do not start with the provided prefix
+func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.newIterator(keyRange(nil, prefix))
+}
+
+// NewIteratorWithStartAndPrefix creates a lexicographically ordered iterator
+// over the database starting at start and ignoring keys that do not start with
+// the provided prefix
+func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return db.newIterator(keyRange(start, prefix))
+}
+
+// NewReverseIterator creates a reverse lexicographically ordered iterator over
+// the database
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.newReverseIterator(&pebble.IterOptions{})
+}
+
+// NewReverseIteratorWithStart creates a reverse lexicographically ordered
+// iterator over the database starting at the provided key
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.newReverseIterator(reverseKeyRange(start, nil))
+}
+
+// NewReverseIteratorWithPrefix creates a reverse lexicographically ordered
+// iterator over the database ignoring keys that do not start with the provided
+// prefix
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.newReverseIterator(keyRange(nil, prefix))
+}
+
+// NewReverseIteratorWithStartAndPrefix creates a reverse lexicographically
+// ordered iterator over the database starting at start and ignoring keys that
+// do not start with the provided prefix
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return db.newReverseIterator(reverseKeyRange(start, prefix))
+}
+
+func (db *Database) newIterator(opts *pebble.IterOptions) database.Iterator {
+	return db.newIter(opts, false)
+}
+
+func (db *Database) newReverseIterator(opts *pebble.IterOptions) database.Iterator {
+	return db.newIter(opts, true)
+}
+
+func (db *Database) newIter(opts *pebble.IterOptions, reverse bool) database.Iterator {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+
+	it := &iter{
+		db:      db,
+		iter:    db.pebbleDB.NewIter(opts),
+		reverse: reverse,
+	}
+	db.openIterators.Add(it)
+	return it
+}
+
+// Snapshot returns a read-only view of the current state of the database
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+
+	s := &snapshot{
+		db:       db,
+		snapshot: db.pebbleDB.NewSnapshot(),
+	}
+	db.openSnapshots.Add(s)
+	return s, nil
+}
+
+// Compact the underlying DB for the given key range.
+//
+// A nil start is treated as a key before all keys in the DB.
+// And a nil limit is treated as a key after all keys in the DB.
+// Therefore if both are nil then it will compact entire DB.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+
+	limit, err := db.resolveLimit(limit, nil)
+	if err != nil {
+		return err
+	}
+	if pebble.DefaultComparer.Compare(start, limit) >= 0 {
+		// The range is empty, so there is nothing to compact.
+		return nil
+	}
+	return updateError(db.pebbleDB.Compact(start, limit, true /*=parallelize*/))
+}
+
+// EstimateSize returns the approximate number of bytes used on disk to store
+// the keys in the range [start, limit). Data that is still in the memtable is
+// not accounted for.
+func (db *Database) EstimateSize(start, limit []byte) (uint64, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return 0, database.ErrClosed
+	}
+
+	limit, err := db.resolveLimit(limit, nil)
+	if err != nil {
+		return 0, err
+	}
+	if pebble.DefaultComparer.Compare(start, limit) >= 0 {
+		// The range is empty, so there is nothing stored in it.
+		return 0, nil
+	}
+	size, err := db.pebbleDB.EstimateDiskUsage(start, limit)
+	return size, updateError(err)
+}
+
+// resolveLimit returns [limit] if it is non-nil.
+//
+// Pebble treats a nil limit as a key before all keys, so a nil [limit] is
+// replaced by the smallest key after both the last key in the DB and
+// [lastKey]. If there is no such key, an empty limit is returned.
+//
+// Assumes [db.lock] is held.
+func (db *Database) resolveLimit(limit, lastKey []byte) ([]byte, error) {
+	if limit != nil {
+		return limit, nil
+	}
+
+	it := db.pebbleDB.NewIter(&pebble.IterOptions{})
+	if it.Last() && (lastKey == nil || pebble.DefaultComparer.Compare(it.Key(), lastKey) > 0) {
+		lastKey = it.Key()
+	}
+	limit = []byte{}
+	if lastKey != nil {
+		limit = append(slices.Clone(lastKey), 0)
+	}
+	return limit, updateError(it.Close())
+}
+
+func (db *Database) Close() error {
+	// Stop polling the metrics before [db.lock] is grabbed, as polling grabs
+	// [db.lock].
+	db.closeOnce.Do(func() {
+		close(db.closeCh)
+	})
+	db.closeWg.Wait()
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	db.closed = true
+
+	for it := range db.openIterators {
+		it.release()
+	}
+	db.openIterators.Clear()
+	for s := range db.openSnapshots {
+		s.release()
+	}
+	db.openSnapshots.Clear()
+	return updateError(db.pebbleDB.Close())
+}
+
+func (db *Database) HealthCheck(context.Context) (interface{}, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	return nil, nil
+}
+
+// keyRange returns the iterator bounds of the keys that start with [prefix]
+// and are >= [start].
+func keyRange(start, prefix []byte) *pebble.IterOptions {
+	opts := &pebble.IterOptions{
+		LowerBound: prefix,
+		UpperBound: prefixToUpperBound(prefix),
+	}
+	if pebble.DefaultComparer.Compare(start, prefix) == 1 {
+		opts.LowerBound = start
+	}
+	return opts
+}
+
+// reverseKeyRange returns the iterator bounds of the keys that start with
+// [prefix] and are <= [start]. A nil [start] doesn't restrict the range.
+func reverseKeyRange(start, prefix []byte) *pebble.IterOptions {
+	opts := keyRange(nil, prefix)
+	if start != nil {
+		// [UpperBound] is exclusive, so the smallest key larger than [start] is
+		// used to include [start].
+		upperBound := make([]byte, len(start)+1)
+		copy(upperBound, start)
+		if opts.UpperBound == nil || pebble.DefaultComparer.Compare(upperBound, opts.UpperBound) == -1 {
+			opts.UpperBound = upperBound
+		}
+	}
+	return opts
+}
+
+// prefixToUpperBound returns the smallest key that is larger than every key
+// with the provided prefix. If no such key exists, nil is returned.
+func prefixToUpperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			upperBound := slices.Clone(prefix[:i+1])
+			upperBound[i]++
+			return upperBound
+		}
+	}
+	return nil
+}
+
+// has returns if [key] is set in [reader]
+func has(reader pebble.Reader, key []byte) (bool, error) {
+	_, closer, err := reader.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, updateError(err)
+	}
+	return true, closer.Close()
+}
+
+// get returns the value [key] maps to in [reader]
+func get(reader pebble.Reader, key []byte) ([]byte, error) {
+	data, closer, err := reader.Get(key)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	// [data] is only valid until [closer] is closed.
+	value := slices.Clone(data)
+	return value, closer.Close()
+}
+
+func updateError(err error) error {
+	switch err {
+	case pebble.ErrClosed:
+		return database.ErrClosed
+	case pebble.ErrNotFound:
+		return database.ErrNotFound
+	default:
+		return err
+	}
+}
+
+// logger forwards pebble's internal logs to the node's logger.
+type logger struct {
+	log logging.Logger
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.log.Debug(fmt.Sprintf(format, args...))
+}
+
+// Fatalf is only called by pebble when it is unable to continue, so it must
+// not return.
+func (l *logger) Fatalf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log.Fatal(msg)
+	panic(msg)
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+)
+
+func TestInterface(t *testing.T) {
+	for _, test := range database.Tests {
+		folder := t.TempDir()
+		db, err := New(folder, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		test(t, db)
+
+		// The database may have been closed by the test, so we don't care if it
+		// errors here.
+		_ = db.Close()
+	}
+}
+
+func TestSizeEstimator(t *testing.T) {
+	for _, test := range database.SizeEstimatorTests {
+		folder := t.TempDir()
+		db, err := New(folder, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		test(t, db)
+
+		// The database may have been closed by the test, so we don't care if it
+		// errors here.
+		_ = db.Close()
+	}
+}
+
+func FuzzInterface(f *testing.F) {
+	for _, test := range database.FuzzTests {
+		folder := f.TempDir()
+		db, err := New(folder, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+		require.NoError(f, err)
+
+		test(f, db)
+
+		// The database may have been closed by the test, so we don't care if it
+		// errors here.
+		_ = db.Close()
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := New(t.TempDir(), []byte("{"), logging.NoLog{}, "", prometheus.NewRegistry())
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+
+	reg := prometheus.NewRegistry()
+	db, err := New(t.TempDir(), nil, logging.NoLog{}, "db", reg)
+	require.NoError(err)
+
+	require.NoError(db.Put([]byte("key"), []byte("value")))
+	require.NoError(db.(*Database).updateMetrics())
+
+	families, err := reg.Gather()
+	require.NoError(err)
+	names := make([]string, 0, len(families))
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	require.Contains(names, "db_block_cache_size")
+	require.Contains(names, "db_table_count")
+
+	require.NoError(db.Close())
+	require.ErrorIs(db.(*Database).updateMetrics(), database.ErrClosed)
+
+	// Registering the metrics twice under the same namespace fails.
+	_, err = New(t.TempDir(), nil, logging.NoLog{}, "db", reg)
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	require.ErrorAs(err, &alreadyRegistered)
+}
+
+func TestPrefixToUpperBound(t *testing.T) {
+	tests := []struct {
+		prefix   []byte
+		expected []byte
+	}{
+		{
+			prefix:   nil,
+			expected: nil,
+		},
+		{
+			prefix:   []byte{0x00},
+			expected: []byte{0x01},
+		},
+		{
+			prefix:   []byte{0x01, 0xFF},
+			expected: []byte{0x02},
+		},
+		{
+			prefix:   []byte{0xFF, 0xFF},
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, prefixToUpperBound(test.prefix))
+	}
+}
+
+func BenchmarkInterface(b *testing.B) {
+	for _, size := range database.BenchmarkSizes {
+		keys, values := database.SetupBenchmark(b, size[0], size[1], size[2])
+		for _, bench := range database.Benchmarks {
+			folder := b.TempDir()
+
+			db, err := New(folder, nil, logging.NoLog{}, "", prometheus.NewRegistry())
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			bench(b, db, "pebble", keys, values)
+
+			// The database may have been closed by the test, so we don't care if it
+			// errors here.
+			_ = db.Close()
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"github.com/cockroachdb/pebble"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+)
+
+var _ database.Iterator = (*iter)(nil)
+
+type iter struct {
+	db *Database
+	// snapshot is the snapshot being iterated over, or nil if the iterator
+	// was created from [db]
+	snapshot *snapshot
+	iter     *pebble.Iterator
+
+	// reverse is true if the iterator moves in descending key order
+	reverse     bool
+	initialized bool
+	closed      bool
+	err         error
+
+	key, val []byte
+}
+
+// Must not be called with [db.lock] held.
+func (it *iter) Next() bool {
+	it.db.lock.RLock()
+	defer it.db.lock.RUnlock()
+
+	var hasNext bool
+	switch {
+	case it.db.closed, it.snapshot != nil && it.snapshot.released:
+		it.err = database.ErrClosed
+	case it.closed:
+	case !it.initialized:
+		it.initialized = true
+		if it.reverse {
+			hasNext = it.iter.Last()
+		} else {
+			hasNext = it.iter.First()
+		}
+	case it.reverse:
+		hasNext = it.iter.Prev()
+	default:
+		hasNext = it.iter.Next()
+	}
+
+	if !hasNext {
+		it.key = nil
+		it.val = nil
+		return false
+	}
+
+	it.key = slices.Clone(it.iter.Key())
+	it.val = slices.Clone(it.iter.Value())
+	return true
+}
+
+func (it *iter) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	it.db.lock.RLock()
+	defer it.db.lock.RUnlock()
+
+	if it.closed {
+		return nil
+	}
+	return updateError(it.iter.Error())
+}
+
+func (it *iter) Key() []byte {
+	return it.key
+}
+
+func (it *iter) Value() []byte {
+	return it.val
+}
+
+// Must not be called with [db.lock] held.
+func (it *iter) Release() {
+	it.db.lock.Lock()
+	defer it.db.lock.Unlock()
+
+	it.db.openIterators.Remove(it)
+	it.release()
+}
+
+// Assumes [db.lock] is held.
+func (it *iter) release() {
+	if it.closed {
+		return
+	}
+	it.closed = true
+	_ = it.iter.Close()
+}
@@ -0,0 +1,240 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+var levelLabels = []string{"level"}
+
+type metrics struct {
+	// total number of bytes of cached blocks
+	blockCacheSize prometheus.Gauge
+	// number of cached blocks
+	blockCacheCount prometheus.Gauge
+	// total number of block cache hits
+	blockCacheHits prometheus.Gauge
+	// total number of block cache misses
+	blockCacheMisses prometheus.Gauge
+
+	// total number of compactions performed
+	compactions prometheus.Gauge
+	// estimated number of bytes that need to be compacted to reach a stable
+	// state
+	compactionDebt prometheus.Gauge
+	// number of compactions in progress
+	compactionsInProgress prometheus.Gauge
+	// total number of memtable flushes performed
+	flushes prometheus.Gauge
+
+	// total number of bytes of memtables
+	memTableSize prometheus.Gauge
+	// number of memtables
+	memTableCount prometheus.Gauge
+
+	// number of currently alive snapshots
+	aliveSnapshots prometheus.Gauge
+	// number of currently open sstable iterators
+	tableIterators prometheus.Gauge
+
+	// total number of bytes of live WAL files
+	walSize prometheus.Gauge
+	// total number of bytes written to the WAL
+	walBytesWritten prometheus.Gauge
+
+	// number of tables per level
+	levelTableCount *prometheus.GaugeVec
+	// size of each level
+	levelSize *prometheus.GaugeVec
+	// amount of bytes read while compacting each level
+	levelReads *prometheus.GaugeVec
+	// amount of bytes written while compacting each level
+	levelWrites *prometheus.GaugeVec
+}
+
+func newMetrics(namespace string, reg prometheus.Registerer) (metrics, error) {
+	m := metrics{
+		blockCacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_cache_size",
+			Help:      "total size of cached blocks",
+		}),
+		blockCacheCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_cache_count",
+			Help:      "number of cached blocks",
+		}),
+		blockCacheHits: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_cache_hits",
+			Help:      "cumulative number of block cache hits",
+		}),
+		blockCacheMisses: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "block_cache_misses",
+			Help:      "cumulative number of block cache misses",
+		}),
+
+		compactions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "comps",
+			Help:      "cumulative number of compactions performed",
+		}),
+		compactionDebt: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "comp_debt",
+			Help:      "estimated number of bytes that need to be compacted",
+		}),
+		compactionsInProgress: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "comps_in_progress",
+			Help:      "number of compactions in progress",
+		}),
+		flushes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "flushes",
+			Help:      "cumulative number of memtable flushes performed",
+		}),
+
+		memTableSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mem_table_size",
+			Help:      "total size of the memtables",
+		}),
+		memTableCount: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mem_table_count",
+			Help:      "number of memtables",
+		}),
+
+		aliveSnapshots: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "alive_snapshots",
+			Help:      "number of currently alive snapshots",
+		}),
+		tableIterators: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "table_iterators",
+			Help:      "number of currently open sstable iterators",
+		}),
+
+		walSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "wal_size",
+			Help:      "total size of the live WAL files",
+		}),
+		walBytesWritten: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "wal_bytes_written",
+			Help:      "cumulative amount of bytes written to the WAL",
+		}),
+
+		levelTableCount: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "table_count",
+				Help:      "number of tables allocated by level",
+			},
+			levelLabels,
+		),
+		levelSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "size",
+				Help:      "amount of bytes allocated by level",
+			},
+			levelLabels,
+		),
+		levelReads: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "reads",
+				Help:      "cumulative amount of bytes read during compaction by level",
+			},
+			levelLabels,
+		),
+		levelWrites: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "writes",
+				Help:      "cumulative amount of bytes written during compaction by level",
+			},
+			levelLabels,
+		),
+	}
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		reg.Register(m.blockCacheSize),
+		reg.Register(m.blockCacheCount),
+		reg.Register(m.blockCacheHits),
+		reg.Register(m.blockCacheMisses),
+
+		reg.Register(m.compactions),
+		reg.Register(m.compactionDebt),
+		reg.Register(m.compactionsInProgress),
+		reg.Register(m.flushes),
+
+		reg.Register(m.memTableSize),
+		reg.Register(m.memTableCount),
+
+		reg.Register(m.aliveSnapshots),
+		reg.Register(m.tableIterators),
+
+		reg.Register(m.walSize),
+		reg.Register(m.walBytesWritten),
+
+		reg.Register(m.levelTableCount),
+		reg.Register(m.levelSize),
+		reg.Register(m.levelReads),
+		reg.Register(m.levelWrites),
+	)
+	return m, errs.Err
+}
+
+func (db *Database) updateMetrics() error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+
+	metrics := &db.metrics
+	stats := db.pebbleDB.Metrics()
+
+	metrics.blockCacheSize.Set(float64(stats.BlockCache.Size))
+	metrics.blockCacheCount.Set(float64(stats.BlockCache.Count))
+	metrics.blockCacheHits.Set(float64(stats.BlockCache.Hits))
+	metrics.blockCacheMisses.Set(float64(stats.BlockCache.Misses))
+
+	metrics.compactions.Set(float64(stats.Compact.Count))
+	metrics.compactionDebt.Set(float64(stats.Compact.EstimatedDebt))
+	metrics.compactionsInProgress.Set(float64(stats.Compact.NumInProgress))
+	metrics.flushes.Set(float64(stats.Flush.Count))
+
+	metrics.memTableSize.Set(float64(stats.MemTable.Size))
+	metrics.memTableCount.Set(float64(stats.MemTable.Count))
+
+	metrics.aliveSnapshots.Set(float64(stats.Snapshots.Count))
+	metrics.tableIterators.Set(float64(stats.TableIters))
+
+	metrics.walSize.Set(float64(stats.WAL.Size))
+	metrics.walBytesWritten.Set(float64(stats.WAL.BytesWritten))
+
+	for level, levelStats := range stats.Levels {
+		levelStr := strconv.Itoa(level)
+		metrics.levelTableCount.WithLabelValues(levelStr).Set(float64(levelStats.NumFiles))
+		metrics.levelSize.WithLabelValues(levelStr).Set(float64(levelStats.Size))
+		metrics.levelReads.WithLabelValues(levelStr).Set(float64(levelStats.BytesRead))
+		metrics.levelWrites.WithLabelValues(levelStr).Set(float64(levelStats.BytesCompacted + levelStats.BytesFlushed))
+	}
+	return nil
+}
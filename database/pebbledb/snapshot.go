@@ -0,0 +1,115 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pebbledb
+
+import (
+	"github.com/cockroachdb/pebble"
+
+	"github.com/memeticofficial/pepecoingo/database"
+)
+
+var _ database.Snapshot = (*snapshot)(nil)
+
+// snapshot is a read-only view of the database at the time that it was taken
+type snapshot struct {
+	db       *Database
+	snapshot *pebble.Snapshot
+	// released is protected by [db.lock]
+	released bool
+}
+
+// Has returns if the key was set in the database when the snapshot was taken
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed || s.released {
+		return false, database.ErrClosed
+	}
+	return has(s.snapshot, key)
+}
+
+// Get returns the value the key mapped to in the database when the snapshot
+// was taken
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed || s.released {
+		return nil, database.ErrClosed
+	}
+	return get(s.snapshot, key)
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.newIter(&pebble.IterOptions{}, false)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.newIter(&pebble.IterOptions{
+		LowerBound: start,
+	}, false)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.newIter(keyRange(nil, prefix), false)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return s.newIter(keyRange(start, prefix), false)
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.newIter(&pebble.IterOptions{}, true)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.newIter(reverseKeyRange(start, nil), true)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.newIter(keyRange(nil, prefix), true)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return s.newIter(reverseKeyRange(start, prefix), true)
+}
+
+func (s *snapshot) newIter(opts *pebble.IterOptions, reverse bool) database.Iterator {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	if s.db.closed || s.released {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+
+	it := &iter{
+		db:       s.db,
+		snapshot: s,
+		iter:     s.snapshot.NewIter(opts),
+		reverse:  reverse,
+	}
+	s.db.openIterators.Add(it)
+	return it
+}
+
+// Must not be called with [db.lock] held.
+func (s *snapshot) Release() {
+	s.db.lock.Lock()
+	defer s.db.lock.Unlock()
+
+	s.db.openSnapshots.Remove(s)
+	s.release()
+}
+
+// Assumes [db.lock] is held.
+func (s *snapshot) release() {
+	if s.released {
+		return
+	}
+	s.released = true
+	_ = s.snapshot.Close()
+}
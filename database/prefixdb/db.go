@@ -21,6 +21,7 @@ var (
 	_ database.Database = (*Database)(nil)
 	_ database.Batch    = (*batch)(nil)
 	_ database.Iterator = (*iterator)(nil)
+	_ database.Snapshot = (*snapshot)(nil)
 )
 
 // Database partitions a database into a sub-database by prefixing all keys with
@@ -28,6 +29,9 @@ var (
 type Database struct {
 	// All keys in this db begin with this byte slice
 	dbPrefix []byte
+	// The smallest key after every key that begins with [dbPrefix], or nil if
+	// there is no such key
+	dbLimit []byte
 	// Holds unused []byte
 	bufferPool sync.Pool
 
@@ -50,11 +54,27 @@ func New(prefix []byte, db database.Database) *Database {
 	return NewNested(prefix, db)
 }
 
+// MakePrefix returns the prefix that every key is stored under in a database
+// that isn't a prefixed database after wrapping it, in order, with each of the
+// provided prefixes using New.
+func MakePrefix(prefixes ...[]byte) []byte {
+	var dbPrefix []byte
+	for _, prefix := range prefixes {
+		simplePrefix := make([]byte, len(dbPrefix)+len(prefix))
+		copy(simplePrefix, dbPrefix)
+		copy(simplePrefix[len(dbPrefix):], prefix)
+		dbPrefix = hashing.ComputeHash256(simplePrefix)
+	}
+	return dbPrefix
+}
+
 // NewNested returns a new prefixed database without attempting to compress
 // prefixes.
 func NewNested(prefix []byte, db database.Database) *Database {
+	dbPrefix := hashing.ComputeHash256(prefix)
 	return &Database{
-		dbPrefix: hashing.ComputeHash256(prefix),
+		dbPrefix: dbPrefix,
+		dbLimit:  PrefixToLimit(dbPrefix),
 		db:       db,
 		bufferPool: sync.Pool{
 			New: func() interface{} {
@@ -64,6 +84,12 @@ func NewNested(prefix []byte, db database.Database) *Database {
 	}
 }
 
+// Prefix returns the prefix that every key in this database is stored under
+// in the underlying database.
+func (db *Database) Prefix() []byte {
+	return slices.Clone(db.dbPrefix)
+}
+
 // Assumes that it is OK for the argument to db.db.Has
 // to be modified after db.db.Has returns
 // [key] may be modified after this method returns.
@@ -129,6 +155,26 @@ func (db *Database) Delete(key []byte) error {
 	return err
 }
 
+// Assumes that it is OK for the arguments to db.db.DeleteRange
+// to be modified after db.db.DeleteRange returns.
+// [start] and [limit] may be modified after this method returns.
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return database.ErrClosed
+	}
+	prefixedStart := db.prefix(start)
+	prefixedLimit := db.limit(limit)
+	err := db.db.DeleteRange(prefixedStart, db.limitOrEnd(prefixedLimit))
+	db.bufferPool.Put(prefixedStart)
+	if prefixedLimit != nil {
+		db.bufferPool.Put(prefixedLimit)
+	}
+	return err
+}
+
 func (db *Database) NewBatch() database.Batch {
 	return &batch{
 		Batch: db.db.NewBatch(),
@@ -159,10 +205,62 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 			Err: database.ErrClosed,
 		}
 	}
+	return db.newIterator(db.db, start, prefix)
+}
+
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// Assumes it is safe to modify the arguments to
+// db.db.NewReverseIteratorWithStartAndPrefix after it returns.
+// It is safe to modify [start] and [prefix] after this method returns.
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return db.newReverseIterator(db.db, start, prefix)
+}
+
+// Snapshot returns a read-only view of the current state of the database
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	s, err := db.db.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		db:       db,
+		snapshot: s,
+	}, nil
+}
+
+// newIterator returns an iterator over the keys of this db in [iteratee].
+//
+// Assumes [db.lock] is held.
+func (db *Database) newIterator(iteratee database.Iteratee, start, prefix []byte) database.Iterator {
 	prefixedStart := db.prefix(start)
 	prefixedPrefix := db.prefix(prefix)
 	it := &iterator{
-		Iterator: db.db.NewIteratorWithStartAndPrefix(prefixedStart, prefixedPrefix),
+		Iterator: iteratee.NewIteratorWithStartAndPrefix(prefixedStart, prefixedPrefix),
 		db:       db,
 	}
 	db.bufferPool.Put(prefixedStart)
@@ -170,6 +268,29 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 	return it
 }
 
+// newReverseIterator returns a reverse iterator over the keys of this db in
+// [iteratee].
+//
+// Assumes [db.lock] is held.
+func (db *Database) newReverseIterator(iteratee database.Iteratee, start, prefix []byte) database.Iterator {
+	// A nil [start] means the iteration begins at the end of this db's
+	// keyspace, which is already enforced by the prefix.
+	var prefixedStart []byte
+	if start != nil {
+		prefixedStart = db.prefix(start)
+	}
+	prefixedPrefix := db.prefix(prefix)
+	it := &iterator{
+		Iterator: iteratee.NewReverseIteratorWithStartAndPrefix(prefixedStart, prefixedPrefix),
+		db:       db,
+	}
+	if prefixedStart != nil {
+		db.bufferPool.Put(prefixedStart)
+	}
+	db.bufferPool.Put(prefixedPrefix)
+	return it
+}
+
 func (db *Database) Compact(start, limit []byte) error {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
@@ -229,6 +350,38 @@ func (db *Database) prefix(key []byte) []byte {
 	return prefixedKey
 }
 
+// Return a copy of [limit], prepended with this db's prefix. If [limit] is nil,
+// nil is returned. The returned slice should be put back in the pool when it's
+// done being used.
+func (db *Database) limit(limit []byte) []byte {
+	if limit == nil {
+		return nil
+	}
+	return db.prefix(limit)
+}
+
+// Returns [prefixedLimit], or if it is nil, the smallest key after every key in
+// this db.
+func (db *Database) limitOrEnd(prefixedLimit []byte) []byte {
+	if prefixedLimit == nil {
+		return db.dbLimit
+	}
+	return prefixedLimit
+}
+
+// PrefixToLimit returns the smallest key that is larger than every key with
+// the provided prefix. If no such key exists, nil is returned.
+func PrefixToLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			limit := slices.Clone(prefix[:i+1])
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
+
 // Batch of database operations
 type batch struct {
 	database.Batch
@@ -266,6 +419,20 @@ func (b *batch) Delete(key []byte) error {
 	return b.Batch.Delete(prefixedKey)
 }
 
+// Assumes that it is OK for the arguments to b.Batch.DeleteRange
+// to be modified after b.Batch.DeleteRange returns
+// [start] and [limit] may be modified after this method returns.
+func (b *batch) DeleteRange(start, limit []byte) error {
+	prefixedStart := b.db.prefix(start)
+	prefixedLimit := b.db.limit(limit)
+	b.ops = append(b.ops, database.BatchOp{
+		Key:         prefixedStart,
+		DeleteRange: true,
+		Limit:       prefixedLimit,
+	})
+	return b.Batch.DeleteRange(prefixedStart, b.db.limitOrEnd(prefixedLimit))
+}
+
 // Write flushes any accumulated data to the memory database.
 func (b *batch) Write() error {
 	b.db.lock.RLock()
@@ -285,6 +452,9 @@ func (b *batch) Reset() {
 	// value argument to w.Put.
 	for _, op := range b.ops {
 		b.db.bufferPool.Put(op.Key)
+		if op.Limit != nil {
+			b.db.bufferPool.Put(op.Limit)
+		}
 	}
 
 	// Clear b.writes
@@ -302,11 +472,20 @@ func (b *batch) Reset() {
 func (b *batch) Replay(w database.KeyValueWriterDeleter) error {
 	for _, op := range b.ops {
 		keyWithoutPrefix := op.Key[len(b.db.dbPrefix):]
-		if op.Delete {
+		switch {
+		case op.DeleteRange:
+			var limitWithoutPrefix []byte
+			if op.Limit != nil {
+				limitWithoutPrefix = op.Limit[len(b.db.dbPrefix):]
+			}
+			if err := database.ReplayDeleteRange(w, keyWithoutPrefix, limitWithoutPrefix); err != nil {
+				return err
+			}
+		case op.Delete:
 			if err := w.Delete(keyWithoutPrefix); err != nil {
 				return err
 			}
-		} else {
+		default:
 			if err := w.Put(keyWithoutPrefix, op.Value); err != nil {
 				return err
 			}
@@ -364,3 +543,87 @@ func (it *iterator) Error() error {
 	}
 	return it.Iterator.Error()
 }
+
+// snapshot is a read-only view of the database at the time that it was taken
+type snapshot struct {
+	db       *Database
+	snapshot database.Snapshot
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed {
+		return false, database.ErrClosed
+	}
+	prefixedKey := s.db.prefix(key)
+	has, err := s.snapshot.Has(prefixedKey)
+	s.db.bufferPool.Put(prefixedKey)
+	return has, err
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed {
+		return nil, database.ErrClosed
+	}
+	prefixedKey := s.db.prefix(key)
+	val, err := s.snapshot.Get(prefixedKey)
+	s.db.bufferPool.Put(prefixedKey)
+	return val, err
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return s.db.newIterator(s.snapshot, start, prefix)
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	s.db.lock.RLock()
+	defer s.db.lock.RUnlock()
+
+	if s.db.closed {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return s.db.newReverseIterator(s.snapshot, start, prefix)
+}
+
+func (s *snapshot) Release() {
+	s.snapshot.Release()
+}
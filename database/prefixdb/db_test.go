@@ -6,6 +6,8 @@ package prefixdb
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 )
@@ -22,6 +24,57 @@ func TestInterface(t *testing.T) {
 	}
 }
 
+func TestDeleteRangeIsolated(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db1 := New([]byte("hello"), baseDB)
+	db2 := New([]byte("world"), baseDB)
+
+	key := []byte("key")
+	require.NoError(baseDB.Put(key, key))
+	require.NoError(db1.Put(key, key))
+	require.NoError(db2.Put(key, key))
+
+	require.NoError(db1.DeleteRange(nil, nil))
+
+	batch := db2.NewBatch()
+	require.NoError(batch.DeleteRange(nil, nil))
+	require.NoError(batch.Put(key, key))
+	require.NoError(batch.Write())
+
+	count, err := database.Count(db1)
+	require.NoError(err)
+	require.Zero(count)
+
+	count, err = database.Count(db2)
+	require.NoError(err)
+	require.Equal(1, count)
+
+	count, err = database.Count(baseDB)
+	require.NoError(err)
+	require.Equal(2, count)
+}
+
+func TestMakePrefix(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db := New([]byte("wor"), New([]byte("ld"), New([]byte("hello"), baseDB)))
+	require.Equal(MakePrefix([]byte("hello"), []byte("ld"), []byte("wor")), db.Prefix())
+
+	key := []byte("key")
+	require.NoError(db.Put(key, key))
+
+	it := baseDB.NewIteratorWithPrefix(db.Prefix())
+	defer it.Release()
+
+	require.True(it.Next())
+	require.Equal(append(db.Prefix(), key...), it.Key())
+	require.False(it.Next())
+	require.NoError(it.Error())
+}
+
 func FuzzInterface(f *testing.F) {
 	for _, test := range database.FuzzTests {
 		test(f, New([]byte(""), memdb.New()))
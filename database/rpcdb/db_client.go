@@ -4,35 +4,93 @@
 package rpcdb
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"sync"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/utils"
+	"github.com/memeticofficial/pepecoingo/utils/math"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 
 	rpcdbpb "github.com/memeticofficial/pepecoingo/proto/pb/rpcdb"
 )
 
+// defaultIteratorPrefetch is the default number of batches that are received
+// ahead of an iterator's consumer.
+const defaultIteratorPrefetch = 4
+
 var (
+	errWrongNumberOfValues = errors.New("wrong number of values returned")
+
 	_ database.Database = (*DatabaseClient)(nil)
 	_ database.Batch    = (*batch)(nil)
 	_ database.Iterator = (*iterator)(nil)
+	_ database.Iterator = (*streamIterator)(nil)
+	_ database.Snapshot = (*snapshot)(nil)
 )
 
+// ClientConfig configures how a DatabaseClient iterates over the remote
+// database.
+type ClientConfig struct {
+	// IteratorBatchSize is the maximum number of bytes of keys and values that
+	// the server sends in each batch while streaming an iterator.
+	IteratorBatchSize int `json:"iteratorBatchSize"`
+	// IteratorPrefetch is the number of batches that are received ahead of the
+	// iterator's consumer. Once this many batches are buffered, the server
+	// stops iterating until the consumer catches up.
+	IteratorPrefetch int `json:"iteratorPrefetch"`
+}
+
+// DefaultClientConfig returns the config that NewClient uses.
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		IteratorBatchSize: iterationBatchSize,
+		IteratorPrefetch:  defaultIteratorPrefetch,
+	}
+}
+
 // DatabaseClient is an implementation of database that talks over RPC.
 type DatabaseClient struct {
 	client rpcdbpb.DatabaseClient
+	config ClientConfig
 
 	closed utils.Atomic[bool]
+
+	// Set if the server doesn't support the streaming or batched RPCs, in
+	// which case the original RPCs are used instead.
+	streamingUnsupported utils.Atomic[bool]
+	getValuesUnsupported utils.Atomic[bool]
 }
 
 // NewClient returns a database instance connected to a remote database instance
 func NewClient(client rpcdbpb.DatabaseClient) *DatabaseClient {
-	return &DatabaseClient{client: client}
+	return NewClientWithConfig(client, DefaultClientConfig())
+}
+
+// NewClientWithConfig returns a database instance connected to a remote
+// database instance that iterates according to [config]. Fields of [config]
+// that aren't positive are replaced by their defaults.
+func NewClientWithConfig(client rpcdbpb.DatabaseClient, config ClientConfig) *DatabaseClient {
+	defaultConfig := DefaultClientConfig()
+	if config.IteratorBatchSize <= 0 {
+		config.IteratorBatchSize = defaultConfig.IteratorBatchSize
+	}
+	if config.IteratorPrefetch <= 0 {
+		config.IteratorPrefetch = defaultConfig.IteratorPrefetch
+	}
+	return &DatabaseClient{
+		client: client,
+		config: config,
+	}
 }
 
 // Has attempts to return if the database has a key with the provided value.
@@ -57,6 +115,45 @@ func (db *DatabaseClient) Get(key []byte) ([]byte, error) {
 	return resp.Value, errEnumToError[resp.Err]
 }
 
+// GetValues attempts to return the values that were mapped to each of the
+// provided keys. The error for each key is returned in the corresponding index
+// of the returned errors.
+func (db *DatabaseClient) GetValues(keys [][]byte) ([][]byte, []error) {
+	values := make([][]byte, len(keys))
+	errs := make([]error, len(keys))
+	if len(keys) == 0 {
+		return values, errs
+	}
+
+	if !db.getValuesUnsupported.Get() {
+		resp, err := db.client.GetValues(context.Background(), &rpcdbpb.GetValuesRequest{
+			Keys: keys,
+		})
+		switch {
+		case err == nil && len(resp.Values) != len(keys):
+			err = errWrongNumberOfValues
+		case status.Code(err) == codes.Unimplemented:
+			db.getValuesUnsupported.Set(true)
+		}
+		if !db.getValuesUnsupported.Get() {
+			for i := range keys {
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				values[i] = resp.Values[i].Value
+				errs[i] = errEnumToError[resp.Values[i].Err]
+			}
+			return values, errs
+		}
+	}
+
+	for i, key := range keys {
+		values[i], errs[i] = db.Get(key)
+	}
+	return values, errs
+}
+
 // Put attempts to set the value this key maps to
 func (db *DatabaseClient) Put(key, value []byte) error {
 	resp, err := db.client.Put(context.Background(), &rpcdbpb.PutRequest{
@@ -80,6 +177,24 @@ func (db *DatabaseClient) Delete(key []byte) error {
 	return errEnumToError[resp.Err]
 }
 
+// DeleteRange attempts to remove every mapping from the keys in the range
+// [start, limit)
+func (db *DatabaseClient) DeleteRange(start, limit []byte) error {
+	// An empty limit can't be distinguished from a nil limit over the wire, so
+	// empty ranges are handled locally.
+	if isEmptyRange(start, limit) {
+		return nil
+	}
+	resp, err := db.client.DeleteRange(context.Background(), &rpcdbpb.DeleteRangeRequest{
+		Start: start,
+		Limit: limit,
+	})
+	if err != nil {
+		return err
+	}
+	return errEnumToError[resp.Err]
+}
+
 // NewBatch returns a new batch
 func (db *DatabaseClient) NewBatch() database.Batch {
 	return &batch{db: db}
@@ -99,16 +214,88 @@ func (db *DatabaseClient) NewIteratorWithPrefix(prefix []byte) database.Iterator
 
 // NewIteratorWithStartAndPrefix returns a new empty iterator
 func (db *DatabaseClient) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return db.newIterator(nil, start, prefix, false)
+}
+
+func (db *DatabaseClient) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *DatabaseClient) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *DatabaseClient) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+// NewReverseIteratorWithStartAndPrefix returns a new empty iterator that
+// iterates in descending key order.
+//
+// Note: Servers that predate the has_start field treat an empty [start] as
+// nil.
+func (db *DatabaseClient) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	return db.newIterator(nil, start, prefix, true)
+}
+
+// newIterator streams the iterator from the server if the server supports it.
+// Otherwise, the iterator is fetched one batch at a time. If [s] is non-nil,
+// the iterator reads from the snapshot rather than the database.
+func (db *DatabaseClient) newIterator(s *snapshot, start, prefix []byte, reverse bool) database.Iterator {
+	var snapshotID uint64
+	if s != nil {
+		snapshotID = s.id
+	}
+
+	if !db.streamingUnsupported.Get() {
+		it, err := newStreamIterator(db, s, &rpcdbpb.IteratorStreamRequest{
+			Start:      start,
+			Prefix:     prefix,
+			Reverse:    reverse,
+			BatchSize:  uint32(db.config.IteratorBatchSize),
+			SnapshotId: snapshotID,
+			HasStart:   start != nil,
+		})
+		if status.Code(err) != codes.Unimplemented {
+			if err != nil {
+				return &database.IteratorError{
+					Err: err,
+				}
+			}
+			return it
+		}
+		db.streamingUnsupported.Set(true)
+	}
+
 	resp, err := db.client.NewIteratorWithStartAndPrefix(context.Background(), &rpcdbpb.NewIteratorWithStartAndPrefixRequest{
-		Start:  start,
-		Prefix: prefix,
+		Start:      start,
+		Prefix:     prefix,
+		Reverse:    reverse,
+		SnapshotId: snapshotID,
+		HasStart:   start != nil,
 	})
 	if err != nil {
 		return &database.IteratorError{
 			Err: err,
 		}
 	}
-	return newIterator(db, resp.Id)
+	return newIterator(db, s, resp.Id)
+}
+
+// Snapshot returns a read-only view of the current state of the remote
+// database
+func (db *DatabaseClient) Snapshot() (database.Snapshot, error) {
+	resp, err := db.client.NewSnapshot(context.Background(), &rpcdbpb.NewSnapshotRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if err := errEnumToError[resp.Err]; err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		db: db,
+		id: resp.Id,
+	}, nil
 }
 
 // Compact attempts to optimize the space utilization in the provided range
@@ -153,12 +340,29 @@ func (b *batch) Write() error {
 	keySet := set.NewSet[string](len(b.Ops))
 	for i := len(b.Ops) - 1; i >= 0; i-- {
 		op := b.Ops[i]
+		if op.DeleteRange {
+			if !isEmptyRange(op.Key, op.Limit) {
+				request.DeleteRanges = append(request.DeleteRanges, &rpcdbpb.DeleteRangeRequest{
+					Start: op.Key,
+					Limit: op.Limit,
+				})
+			}
+			continue
+		}
+
 		key := string(op.Key)
 		if keySet.Contains(key) {
 			continue
 		}
 		keySet.Add(key)
 
+		// Range deletions are applied before the puts and deletes, so
+		// operations that were overwritten by a later range deletion must be
+		// dropped.
+		if isDeletedByRange(request.DeleteRanges, op.Key) {
+			continue
+		}
+
 		if op.Delete {
 			request.Deletes = append(request.Deletes, &rpcdbpb.DeleteRequest{
 				Key: op.Key,
@@ -182,9 +386,25 @@ func (b *batch) Inner() database.Batch {
 	return b
 }
 
+// isEmptyRange returns true if there are no keys in the range [start, limit).
+func isEmptyRange(start, limit []byte) bool {
+	return limit != nil && bytes.Compare(start, limit) >= 0
+}
+
+// isDeletedByRange returns true if [key] is in any of the provided ranges.
+func isDeletedByRange(rangeDeletes []*rpcdbpb.DeleteRangeRequest, key []byte) bool {
+	for _, rangeDelete := range rangeDeletes {
+		if database.InRange(key, rangeDelete.Start, rangeDelete.Limit) {
+			return true
+		}
+	}
+	return false
+}
+
 type iterator struct {
-	db *DatabaseClient
-	id uint64
+	db       *DatabaseClient
+	snapshot *snapshot
+	id       uint64
 
 	data        []*rpcdbpb.PutRequest
 	fetchedData chan []*rpcdbpb.PutRequest
@@ -199,9 +419,10 @@ type iterator struct {
 	onClosed chan struct{}
 }
 
-func newIterator(db *DatabaseClient, id uint64) *iterator {
+func newIterator(db *DatabaseClient, s *snapshot, id uint64) *iterator {
 	it := &iterator{
 		db:             db,
+		snapshot:       s,
 		id:             id,
 		fetchedData:    make(chan []*rpcdbpb.PutRequest),
 		reqUpdateError: make(chan chan struct{}),
@@ -262,7 +483,7 @@ func (it *iterator) fetch() {
 // Next attempts to move the iterator to the next element and returns if this
 // succeeded
 func (it *iterator) Next() bool {
-	if it.db.closed.Get() {
+	if it.db.closed.Get() || it.snapshot.isReleased() {
 		it.data = nil
 		it.setError(database.ErrClosed)
 		return false
@@ -347,3 +568,243 @@ func (it *iterator) getError() error {
 
 	return it.err
 }
+
+// streamIterator receives the key-value pairs of an iterator that is streamed
+// by the server.
+type streamIterator struct {
+	db       *DatabaseClient
+	snapshot *snapshot
+
+	data []*rpcdbpb.PutRequest
+	// fetchedData buffers the batches that have been received but not yet
+	// consumed. While it is full, the stream isn't read from, which causes the
+	// server to block.
+	fetchedData chan []*rpcdbpb.PutRequest
+
+	errLock sync.RWMutex
+	err     error
+
+	cancel   context.CancelFunc
+	once     sync.Once
+	onClosed chan struct{}
+}
+
+// newStreamIterator opens the stream and waits for its first response so that
+// a server that doesn't support streaming is detected immediately.
+func newStreamIterator(db *DatabaseClient, s *snapshot, req *rpcdbpb.IteratorStreamRequest) (*streamIterator, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := db.client.IteratorStream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		cancel()
+		return nil, err
+	}
+
+	it := &streamIterator{
+		db:          db,
+		snapshot:    s,
+		fetchedData: make(chan []*rpcdbpb.PutRequest, math.Max(db.config.IteratorPrefetch, 1)),
+		cancel:      cancel,
+		onClosed:    make(chan struct{}),
+	}
+	go it.fetch(ctx, stream, resp)
+	return it, nil
+}
+
+// fetch forwards the batches from [stream], starting with [resp], to the
+// consumer. If [resp] is nil, the stream has already ended.
+func (it *streamIterator) fetch(
+	ctx context.Context,
+	stream rpcdbpb.Database_IteratorStreamClient,
+	resp *rpcdbpb.IteratorStreamResponse,
+) {
+	defer func() {
+		close(it.fetchedData)
+		close(it.onClosed)
+	}()
+
+	for resp != nil {
+		it.setError(errEnumToError[resp.Err])
+		if len(resp.Data) > 0 {
+			select {
+			case it.fetchedData <- resp.Data:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		var err error
+		resp, err = stream.Recv()
+		if err != nil {
+			// Errors caused by releasing the iterator aren't reported.
+			if err != io.EOF && ctx.Err() == nil {
+				it.setError(err)
+			}
+			return
+		}
+	}
+}
+
+// Next attempts to move the iterator to the next element and returns if this
+// succeeded
+func (it *streamIterator) Next() bool {
+	if it.db.closed.Get() || it.snapshot.isReleased() {
+		it.data = nil
+		it.setError(database.ErrClosed)
+		return false
+	}
+	if len(it.data) > 1 {
+		it.data[0] = nil
+		it.data = it.data[1:]
+		return true
+	}
+
+	it.data = <-it.fetchedData
+	return len(it.data) > 0
+}
+
+// Error returns any that occurred while iterating
+func (it *streamIterator) Error() error {
+	it.errLock.RLock()
+	defer it.errLock.RUnlock()
+
+	return it.err
+}
+
+// Key returns the key of the current element
+func (it *streamIterator) Key() []byte {
+	if len(it.data) == 0 {
+		return nil
+	}
+	return it.data[0].Key
+}
+
+// Value returns the value of the current element
+func (it *streamIterator) Value() []byte {
+	if len(it.data) == 0 {
+		return nil
+	}
+	return it.data[0].Value
+}
+
+// Release frees any resources held by the iterator
+func (it *streamIterator) Release() {
+	it.once.Do(func() {
+		it.cancel()
+		<-it.onClosed
+	})
+}
+
+func (it *streamIterator) setError(err error) {
+	if err == nil {
+		return
+	}
+
+	it.errLock.Lock()
+	defer it.errLock.Unlock()
+
+	if it.err == nil {
+		it.err = err
+	}
+}
+
+// snapshot is a read-only view of the remote database that is held by the
+// server until it is released.
+type snapshot struct {
+	db       *DatabaseClient
+	id       uint64
+	released utils.Atomic[bool]
+}
+
+// Has attempts to return if the snapshot has a key with the provided value.
+func (s *snapshot) Has(key []byte) (bool, error) {
+	if s.released.Get() {
+		return false, database.ErrClosed
+	}
+	resp, err := s.db.client.SnapshotHas(context.Background(), &rpcdbpb.SnapshotHasRequest{
+		Id:  s.id,
+		Key: key,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Has, errEnumToError[resp.Err]
+}
+
+// Get attempts to return the value that was mapped to the key that was provided
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	if s.released.Get() {
+		return nil, database.ErrClosed
+	}
+	resp, err := s.db.client.SnapshotGet(context.Background(), &rpcdbpb.SnapshotGetRequest{
+		Id:  s.id,
+		Key: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, errEnumToError[resp.Err]
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	if s.released.Get() {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return s.db.newIterator(s, start, prefix, false)
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	if s.released.Get() {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return s.db.newIterator(s, start, prefix, true)
+}
+
+// Release frees the snapshot held by the server. Failures to release the
+// snapshot can't be reported, so they are ignored.
+func (s *snapshot) Release() {
+	if s.released.Get() {
+		return
+	}
+	s.released.Set(true)
+	_, _ = s.db.client.SnapshotRelease(context.Background(), &rpcdbpb.SnapshotReleaseRequest{
+		Id: s.id,
+	})
+}
+
+// isReleased returns true if [s] is non-nil and has been released.
+func (s *snapshot) isReleased() bool {
+	return s != nil && s.released.Get()
+}
@@ -12,12 +12,19 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/math"
 	"github.com/memeticofficial/pepecoingo/utils/units"
 
 	rpcdbpb "github.com/memeticofficial/pepecoingo/proto/pb/rpcdb"
 )
 
-const iterationBatchSize = 128 * units.KiB
+const (
+	iterationBatchSize = 128 * units.KiB
+
+	// maxIterationBatchSize is the largest batch size that a client may
+	// request when streaming an iterator.
+	maxIterationBatchSize = 4 * units.MiB
+)
 
 var errUnknownIterator = errors.New("unknown iterator")
 
@@ -35,13 +42,21 @@ type DatabaseServer struct {
 	iteratorLock   sync.RWMutex
 	nextIteratorID uint64
 	iterators      map[uint64]database.Iterator
+
+	// snapshotLock protects [nextSnapshotID] and [snapshots]. Snapshot IDs
+	// start at 1 so that 0 can be used to refer to the database itself.
+	snapshotLock   sync.RWMutex
+	nextSnapshotID uint64
+	snapshots      map[uint64]database.Snapshot
 }
 
 // NewServer returns a database instance that is managed remotely
 func NewServer(db database.Database) *DatabaseServer {
 	return &DatabaseServer{
-		db:        db,
-		iterators: make(map[uint64]database.Iterator),
+		db:             db,
+		iterators:      make(map[uint64]database.Iterator),
+		nextSnapshotID: 1,
+		snapshots:      make(map[uint64]database.Snapshot),
 	}
 }
 
@@ -63,6 +78,23 @@ func (db *DatabaseServer) Get(_ context.Context, req *rpcdbpb.GetRequest) (*rpcd
 	}, errorToRPCError(err)
 }
 
+// GetValues delegates a Get call to the managed database for each of the
+// requested keys and returns the results
+func (db *DatabaseServer) GetValues(_ context.Context, req *rpcdbpb.GetValuesRequest) (*rpcdbpb.GetValuesResponse, error) {
+	values := make([]*rpcdbpb.GetResponse, len(req.Keys))
+	for i, key := range req.Keys {
+		value, err := db.db.Get(key)
+		if err := errorToRPCError(err); err != nil {
+			return nil, err
+		}
+		values[i] = &rpcdbpb.GetResponse{
+			Value: value,
+			Err:   errorToErrEnum[err],
+		}
+	}
+	return &rpcdbpb.GetValuesResponse{Values: values}, nil
+}
+
 // Put delegates the Put call to the managed database and returns the result
 func (db *DatabaseServer) Put(_ context.Context, req *rpcdbpb.PutRequest) (*rpcdbpb.PutResponse, error) {
 	err := db.db.Put(req.Key, req.Value)
@@ -76,6 +108,13 @@ func (db *DatabaseServer) Delete(_ context.Context, req *rpcdbpb.DeleteRequest)
 	return &rpcdbpb.DeleteResponse{Err: errorToErrEnum[err]}, errorToRPCError(err)
 }
 
+// DeleteRange delegates the DeleteRange call to the managed database and
+// returns the result
+func (db *DatabaseServer) DeleteRange(_ context.Context, req *rpcdbpb.DeleteRangeRequest) (*rpcdbpb.DeleteRangeResponse, error) {
+	err := db.db.DeleteRange(req.Start, rangeLimit(req))
+	return &rpcdbpb.DeleteRangeResponse{Err: errorToErrEnum[err]}, errorToRPCError(err)
+}
+
 // Compact delegates the Compact call to the managed database and returns the
 // result
 func (db *DatabaseServer) Compact(_ context.Context, req *rpcdbpb.CompactRequest) (*rpcdbpb.CompactResponse, error) {
@@ -83,8 +122,16 @@ func (db *DatabaseServer) Compact(_ context.Context, req *rpcdbpb.CompactRequest
 	return &rpcdbpb.CompactResponse{Err: errorToErrEnum[err]}, errorToRPCError(err)
 }
 
-// Close delegates the Close call to the managed database and returns the result
+// Close releases all of the outstanding snapshots, then delegates the Close
+// call to the managed database and returns the result
 func (db *DatabaseServer) Close(context.Context, *rpcdbpb.CloseRequest) (*rpcdbpb.CloseResponse, error) {
+	db.snapshotLock.Lock()
+	for id, snapshot := range db.snapshots {
+		snapshot.Release()
+		delete(db.snapshots, id)
+	}
+	db.snapshotLock.Unlock()
+
 	err := db.db.Close()
 	return &rpcdbpb.CloseResponse{Err: errorToErrEnum[err]}, errorToRPCError(err)
 }
@@ -106,6 +153,13 @@ func (db *DatabaseServer) HealthCheck(ctx context.Context, _ *emptypb.Empty) (*r
 // the internal database
 func (db *DatabaseServer) WriteBatch(_ context.Context, req *rpcdbpb.WriteBatchRequest) (*rpcdbpb.WriteBatchResponse, error) {
 	batch := db.db.NewBatch()
+	for _, deleteRange := range req.DeleteRanges {
+		if err := batch.DeleteRange(deleteRange.Start, rangeLimit(deleteRange)); err != nil {
+			return &rpcdbpb.WriteBatchResponse{
+				Err: errorToErrEnum[err],
+			}, errorToRPCError(err)
+		}
+	}
 	for _, put := range req.Puts {
 		if err := batch.Put(put.Key, put.Value); err != nil {
 			return &rpcdbpb.WriteBatchResponse{
@@ -127,10 +181,19 @@ func (db *DatabaseServer) WriteBatch(_ context.Context, req *rpcdbpb.WriteBatchR
 	}, errorToRPCError(err)
 }
 
+// rangeLimit returns the limit of [req]. An empty limit is sent for a range
+// without a limit, so it is converted to nil.
+func rangeLimit(req *rpcdbpb.DeleteRangeRequest) []byte {
+	if len(req.Limit) == 0 {
+		return nil
+	}
+	return req.Limit
+}
+
 // NewIteratorWithStartAndPrefix allocates an iterator and returns the iterator
 // ID
 func (db *DatabaseServer) NewIteratorWithStartAndPrefix(_ context.Context, req *rpcdbpb.NewIteratorWithStartAndPrefixRequest) (*rpcdbpb.NewIteratorWithStartAndPrefixResponse, error) {
-	it := db.db.NewIteratorWithStartAndPrefix(req.Start, req.Prefix)
+	it := db.newIterator(req.SnapshotId, requestStart(req.Start, req.HasStart), req.Prefix, req.Reverse)
 
 	db.iteratorLock.Lock()
 	defer db.iteratorLock.Unlock()
@@ -141,6 +204,36 @@ func (db *DatabaseServer) NewIteratorWithStartAndPrefix(_ context.Context, req *
 	return &rpcdbpb.NewIteratorWithStartAndPrefixResponse{Id: id}, nil
 }
 
+// newIterator returns an iterator over the snapshot with [snapshotID], or over
+// the managed database if [snapshotID] is 0. If the snapshot doesn't exist, it
+// is assumed to have been released.
+func (db *DatabaseServer) newIterator(snapshotID uint64, start, prefix []byte, reverse bool) database.Iterator {
+	var iteratee database.Iteratee = db.db
+	if snapshotID != 0 {
+		snapshot, exists := db.getSnapshot(snapshotID)
+		if !exists {
+			return &database.IteratorError{
+				Err: database.ErrClosed,
+			}
+		}
+		iteratee = snapshot
+	}
+
+	if reverse {
+		return iteratee.NewReverseIteratorWithStartAndPrefix(start, prefix)
+	}
+	return iteratee.NewIteratorWithStartAndPrefix(start, prefix)
+}
+
+// requestStart returns the start key of an iterator request. An empty start
+// key is received as nil, so it is restored if the client set [hasStart].
+func requestStart(start []byte, hasStart bool) []byte {
+	if hasStart && start == nil {
+		return []byte{}
+	}
+	return start
+}
+
 // IteratorNext attempts to call next on the requested iterator
 func (db *DatabaseServer) IteratorNext(_ context.Context, req *rpcdbpb.IteratorNextRequest) (*rpcdbpb.IteratorNextResponse, error) {
 	db.iteratorLock.RLock()
@@ -195,3 +288,127 @@ func (db *DatabaseServer) IteratorRelease(_ context.Context, req *rpcdbpb.Iterat
 	it.Release()
 	return &rpcdbpb.IteratorReleaseResponse{Err: errorToErrEnum[err]}, errorToRPCError(err)
 }
+
+// IteratorStream iterates over the managed database and streams the results
+// in batches. Sending blocks while the client isn't consuming the stream, so
+// the client controls how far ahead of it the iteration runs.
+func (db *DatabaseServer) IteratorStream(req *rpcdbpb.IteratorStreamRequest, stream rpcdbpb.Database_IteratorStreamServer) error {
+	batchSize := int(req.BatchSize)
+	if batchSize == 0 {
+		batchSize = iterationBatchSize
+	}
+	batchSize = math.Min(batchSize, maxIterationBatchSize)
+
+	it := db.newIterator(req.SnapshotId, requestStart(req.Start, req.HasStart), req.Prefix, req.Reverse)
+	defer it.Release()
+
+	var (
+		size int
+		data []*rpcdbpb.PutRequest
+	)
+	for it.Next() {
+		key := it.Key()
+		value := it.Value()
+		size += len(key) + len(value)
+
+		data = append(data, &rpcdbpb.PutRequest{
+			Key:   key,
+			Value: value,
+		})
+		if size < batchSize {
+			continue
+		}
+
+		if err := stream.Send(&rpcdbpb.IteratorStreamResponse{Data: data}); err != nil {
+			return err
+		}
+		size = 0
+		data = nil
+	}
+
+	err := it.Error()
+	if err := errorToRPCError(err); err != nil {
+		return err
+	}
+	if len(data) == 0 && err == nil {
+		return nil
+	}
+	return stream.Send(&rpcdbpb.IteratorStreamResponse{
+		Data: data,
+		Err:  errorToErrEnum[err],
+	})
+}
+
+// NewSnapshot allocates a snapshot of the managed database and returns the
+// snapshot ID
+func (db *DatabaseServer) NewSnapshot(context.Context, *rpcdbpb.NewSnapshotRequest) (*rpcdbpb.NewSnapshotResponse, error) {
+	snapshot, err := db.db.Snapshot()
+	if err != nil {
+		return &rpcdbpb.NewSnapshotResponse{
+			Err: errorToErrEnum[err],
+		}, errorToRPCError(err)
+	}
+
+	db.snapshotLock.Lock()
+	defer db.snapshotLock.Unlock()
+
+	id := db.nextSnapshotID
+	db.snapshots[id] = snapshot
+	db.nextSnapshotID++
+	return &rpcdbpb.NewSnapshotResponse{Id: id}, nil
+}
+
+// SnapshotHas delegates the Has call to the requested snapshot and returns the
+// result
+func (db *DatabaseServer) SnapshotHas(_ context.Context, req *rpcdbpb.SnapshotHasRequest) (*rpcdbpb.HasResponse, error) {
+	snapshot, exists := db.getSnapshot(req.Id)
+	if !exists {
+		return &rpcdbpb.HasResponse{
+			Err: rpcdbpb.Error_ERROR_CLOSED,
+		}, nil
+	}
+
+	has, err := snapshot.Has(req.Key)
+	return &rpcdbpb.HasResponse{
+		Has: has,
+		Err: errorToErrEnum[err],
+	}, errorToRPCError(err)
+}
+
+// SnapshotGet delegates the Get call to the requested snapshot and returns the
+// result
+func (db *DatabaseServer) SnapshotGet(_ context.Context, req *rpcdbpb.SnapshotGetRequest) (*rpcdbpb.GetResponse, error) {
+	snapshot, exists := db.getSnapshot(req.Id)
+	if !exists {
+		return &rpcdbpb.GetResponse{
+			Err: rpcdbpb.Error_ERROR_CLOSED,
+		}, nil
+	}
+
+	value, err := snapshot.Get(req.Key)
+	return &rpcdbpb.GetResponse{
+		Value: value,
+		Err:   errorToErrEnum[err],
+	}, errorToRPCError(err)
+}
+
+// SnapshotRelease releases the resources allocated to a snapshot
+func (db *DatabaseServer) SnapshotRelease(_ context.Context, req *rpcdbpb.SnapshotReleaseRequest) (*rpcdbpb.SnapshotReleaseResponse, error) {
+	db.snapshotLock.Lock()
+	snapshot, exists := db.snapshots[req.Id]
+	delete(db.snapshots, req.Id)
+	db.snapshotLock.Unlock()
+
+	if exists {
+		snapshot.Release()
+	}
+	return &rpcdbpb.SnapshotReleaseResponse{}, nil
+}
+
+func (db *DatabaseServer) getSnapshot(id uint64) (database.Snapshot, bool) {
+	db.snapshotLock.RLock()
+	defer db.snapshotLock.RUnlock()
+
+	snapshot, exists := db.snapshots[id]
+	return snapshot, exists
+}
@@ -5,10 +5,14 @@ package rpcdb
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/corruptabledb"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
@@ -23,7 +27,25 @@ type testDatabase struct {
 	closeFn func()
 }
 
+// legacyServer only supports the RPCs that predate streaming iteration and
+// batched gets.
+type legacyServer struct {
+	*DatabaseServer
+}
+
+func (*legacyServer) GetValues(context.Context, *rpcdbpb.GetValuesRequest) (*rpcdbpb.GetValuesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetValues not implemented")
+}
+
+func (*legacyServer) IteratorStream(*rpcdbpb.IteratorStreamRequest, rpcdbpb.Database_IteratorStreamServer) error {
+	return status.Error(codes.Unimplemented, "method IteratorStream not implemented")
+}
+
 func setupDB(t testing.TB) *testDatabase {
+	return setupDBWithConfig(t, DefaultClientConfig(), false)
+}
+
+func setupDBWithConfig(t testing.TB, config ClientConfig, legacy bool) *testDatabase {
 	db := &testDatabase{
 		server: memdb.New(),
 	}
@@ -35,7 +57,11 @@ func setupDB(t testing.TB) *testDatabase {
 	serverCloser := grpcutils.ServerCloser{}
 
 	server := grpcutils.NewServer()
-	rpcdbpb.RegisterDatabaseServer(server, NewServer(db.server))
+	var dbServer rpcdbpb.DatabaseServer = NewServer(db.server)
+	if legacy {
+		dbServer = &legacyServer{DatabaseServer: NewServer(db.server)}
+	}
+	rpcdbpb.RegisterDatabaseServer(server, dbServer)
 	serverCloser.Add(server)
 
 	go grpcutils.Serve(listener, server)
@@ -45,7 +71,7 @@ func setupDB(t testing.TB) *testDatabase {
 		t.Fatalf("Failed to dial: %s", err)
 	}
 
-	db.client = NewClient(rpcdbpb.NewDatabaseClient(conn))
+	db.client = NewClientWithConfig(rpcdbpb.NewDatabaseClient(conn), config)
 	db.closeFn = func() {
 		serverCloser.Stop()
 		_ = conn.Close()
@@ -63,6 +89,28 @@ func TestInterface(t *testing.T) {
 	}
 }
 
+func TestInterfaceLegacyServer(t *testing.T) {
+	for _, test := range database.Tests {
+		db := setupDBWithConfig(t, DefaultClientConfig(), true)
+		test(t, db.client)
+
+		db.closeFn()
+	}
+}
+
+func TestInterfaceSmallBatches(t *testing.T) {
+	config := ClientConfig{
+		IteratorBatchSize: 1,
+		IteratorPrefetch:  1,
+	}
+	for _, test := range database.Tests {
+		db := setupDBWithConfig(t, config, false)
+		test(t, db.client)
+
+		db.closeFn()
+	}
+}
+
 func FuzzInterface(f *testing.F) {
 	for _, test := range database.FuzzTests {
 		db := setupDB(f)
@@ -143,3 +191,121 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestGetValues(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("legacy=%t", legacy), func(t *testing.T) {
+			require := require.New(t)
+
+			db := setupDBWithConfig(t, DefaultClientConfig(), legacy)
+			defer db.closeFn()
+
+			require.NoError(db.server.Put([]byte("key1"), []byte("value1")))
+			require.NoError(db.server.Put([]byte("key3"), []byte("value3")))
+
+			values, errs := db.client.GetValues([][]byte{
+				[]byte("key1"),
+				[]byte("key2"),
+				[]byte("key3"),
+			})
+			require.Equal([][]byte{[]byte("value1"), nil, []byte("value3")}, values)
+			require.Equal([]error{nil, database.ErrNotFound, nil}, errs)
+
+			require.NoError(db.client.Close())
+
+			_, errs = db.client.GetValues([][]byte{[]byte("key1")})
+			require.Equal([]error{database.ErrClosed}, errs)
+		})
+	}
+}
+
+func TestStreamIteratorReleaseEarly(t *testing.T) {
+	require := require.New(t)
+
+	// Only one small batch is buffered, so the server must block until the
+	// iterator is released.
+	db := setupDBWithConfig(t, ClientConfig{
+		IteratorBatchSize: 1,
+		IteratorPrefetch:  1,
+	}, false)
+	defer db.closeFn()
+
+	for i := uint64(0); i < 100; i++ {
+		key := database.PackUInt64(i)
+		require.NoError(db.server.Put(key, key))
+	}
+
+	it := db.client.NewIterator()
+	require.IsType(&streamIterator{}, it)
+	require.True(it.Next())
+	require.Equal(database.PackUInt64(0), it.Key())
+	it.Release()
+
+	require.NoError(it.Error())
+}
+
+// releaseCountingDB counts the number of its snapshots that are released.
+type releaseCountingDB struct {
+	database.Database
+	released int
+}
+
+func (db *releaseCountingDB) Snapshot() (database.Snapshot, error) {
+	snapshot, err := db.Database.Snapshot()
+	return &releaseCountingSnapshot{
+		Snapshot: snapshot,
+		db:       db,
+	}, err
+}
+
+type releaseCountingSnapshot struct {
+	database.Snapshot
+	db *releaseCountingDB
+}
+
+func (s *releaseCountingSnapshot) Release() {
+	s.db.released++
+	s.Snapshot.Release()
+}
+
+func TestCloseReleasesSnapshots(t *testing.T) {
+	require := require.New(t)
+
+	db := &releaseCountingDB{Database: memdb.New()}
+	server := NewServer(db)
+
+	var ids []uint64
+	for i := 0; i < 3; i++ {
+		resp, err := server.NewSnapshot(context.Background(), &rpcdbpb.NewSnapshotRequest{})
+		require.NoError(err)
+		ids = append(ids, resp.Id)
+	}
+
+	_, err := server.SnapshotRelease(context.Background(), &rpcdbpb.SnapshotReleaseRequest{
+		Id: ids[0],
+	})
+	require.NoError(err)
+	require.Equal(1, db.released)
+
+	// The snapshots that the client never released are released on close.
+	_, err = server.Close(context.Background(), &rpcdbpb.CloseRequest{})
+	require.NoError(err)
+	require.Equal(3, db.released)
+	require.Empty(server.snapshots)
+}
+
+func TestNewClientWithConfigDefaults(t *testing.T) {
+	require := require.New(t)
+
+	db := NewClientWithConfig(nil, ClientConfig{
+		IteratorBatchSize: -1,
+	})
+	require.Equal(DefaultClientConfig(), db.config)
+
+	config := ClientConfig{
+		IteratorBatchSize: 1024,
+		IteratorPrefetch:  1,
+	}
+	db = NewClientWithConfig(nil, config)
+	require.Equal(config, db.config)
+}
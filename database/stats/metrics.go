@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stats
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+var (
+	databaseLabels = []string{"chain", "alias", "database"}
+	prefixLabels   = []string{"chain", "alias", "prefix"}
+)
+
+type metrics struct {
+	totalKeys, totalSize, totalDiskSize prometheus.Gauge
+
+	databaseKeys, databaseSize, databaseDiskSize *prometheus.GaugeVec
+
+	prefixKeys, prefixSize, prefixDiskSize *prometheus.GaugeVec
+}
+
+func newMetrics(namespace string, reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		totalKeys: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "total_keys",
+			Help:      "approximate number of keys in the database",
+		}),
+		totalSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "total_size",
+			Help:      "approximate number of bytes of the keys and values in the database",
+		}),
+		totalDiskSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "total_disk_size",
+			Help:      "estimated number of bytes used on disk by the database",
+		}),
+
+		databaseKeys: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "keys",
+				Help:      "approximate number of keys in a chain's database",
+			},
+			databaseLabels,
+		),
+		databaseSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "size",
+				Help:      "approximate number of bytes of the keys and values in a chain's database",
+			},
+			databaseLabels,
+		),
+		databaseDiskSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "disk_size",
+				Help:      "estimated number of bytes used on disk by a chain's database",
+			},
+			databaseLabels,
+		),
+
+		prefixKeys: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "vm_prefix_keys",
+				Help:      "approximate number of keys under a prefix of a chain's VM database",
+			},
+			prefixLabels,
+		),
+		prefixSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "vm_prefix_size",
+				Help:      "approximate number of bytes of the keys and values under a prefix of a chain's VM database",
+			},
+			prefixLabels,
+		),
+		prefixDiskSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "vm_prefix_disk_size",
+				Help:      "estimated number of bytes used on disk under a prefix of a chain's VM database",
+			},
+			prefixLabels,
+		),
+	}
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		reg.Register(m.totalKeys),
+		reg.Register(m.totalSize),
+		reg.Register(m.totalDiskSize),
+
+		reg.Register(m.databaseKeys),
+		reg.Register(m.databaseSize),
+		reg.Register(m.databaseDiskSize),
+
+		reg.Register(m.prefixKeys),
+		reg.Register(m.prefixSize),
+		reg.Register(m.prefixDiskSize),
+	)
+	return m, errs.Err
+}
+
+func (m *metrics) update(stats *Stats) {
+	m.totalKeys.Set(float64(stats.Total.Keys))
+	m.totalSize.Set(float64(stats.Total.Size))
+	m.totalDiskSize.Set(float64(stats.Total.DiskSize))
+
+	for _, chain := range stats.Chains {
+		chainID := chain.ChainID.String()
+		for name, usage := range chain.Databases {
+			labels := prometheus.Labels{
+				"chain":    chainID,
+				"alias":    chain.Alias,
+				"database": name,
+			}
+			m.databaseKeys.With(labels).Set(float64(usage.Keys))
+			m.databaseSize.With(labels).Set(float64(usage.Size))
+			m.databaseDiskSize.With(labels).Set(float64(usage.DiskSize))
+		}
+		for name, usage := range chain.VMPrefixes {
+			labels := prometheus.Labels{
+				"chain":  chainID,
+				"alias":  chain.Alias,
+				"prefix": name,
+			}
+			m.prefixKeys.With(labels).Set(float64(usage.Keys))
+			m.prefixSize.With(labels).Set(float64(usage.Size))
+			m.prefixDiskSize.With(labels).Set(float64(usage.DiskSize))
+		}
+	}
+}
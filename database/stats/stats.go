@@ -0,0 +1,259 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.uber.org/zap"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+)
+
+const (
+	// VMDatabase is the name of the chain database that is provided to the
+	// chain's VM.
+	VMDatabase = "vm"
+
+	// OtherPrefix is the name that the usage of a chain's VM database that
+	// isn't under any of the VM's registered prefixes is reported as.
+	OtherPrefix = "other"
+
+	// DefaultSampleSize is the default maximum number of keys that are
+	// iterated over per prefix before the usage of the prefix is
+	// extrapolated.
+	DefaultSampleSize = 10_000
+)
+
+var _ Collector = (*collector)(nil)
+
+// ChainRegisterer is notified of the databases that each chain stores its data
+// in.
+type ChainRegisterer interface {
+	// RegisterChain records that [chainID], which is running [vmID], stores its
+	// data under [databases]. [databases] maps the name of each of the chain's
+	// databases to the prefix that all of its keys are stored under.
+	RegisterChain(chainID ids.ID, alias string, vmID ids.ID, databases map[string][]byte)
+}
+
+// Collector reports how much storage each chain, and each prefix of a chain's
+// VM, is using.
+type Collector interface {
+	ChainRegisterer
+
+	// RegisterVM records that [vmID] stores its data under [prefixes] of the
+	// database provided to it. [prefixes] maps the name of each prefix to the
+	// prefix that its keys are stored under in the VM's database.
+	RegisterVM(vmID ids.ID, prefixes map[string][]byte)
+
+	// Stats returns the most recently collected stats. If no stats have been
+	// collected yet, or [refresh] is true, the stats are collected first.
+	Stats(refresh bool) (*Stats, error)
+
+	// Dispatch collects the stats every [frequency] until Stop is called.
+	Dispatch(frequency time.Duration)
+
+	// Stop causes Dispatch to return.
+	Stop()
+}
+
+// Stats describes the storage used by the database.
+type Stats struct {
+	// Time that the stats were collected at
+	Timestamp time.Time `json:"timestamp"`
+	// Usage of the entire database
+	Total Usage `json:"total"`
+	// Usage of each registered chain
+	Chains []ChainStats `json:"chains"`
+}
+
+// ChainStats describes the storage used by a chain.
+type ChainStats struct {
+	ChainID ids.ID `json:"chainID"`
+	Alias   string `json:"alias"`
+	VMID    ids.ID `json:"vmID"`
+	// Usage of all of the chain's databases
+	Total Usage `json:"total"`
+	// Usage of each of the chain's databases, keyed by name
+	Databases map[string]Usage `json:"databases"`
+	// Usage of each of the prefixes registered by the chain's VM, keyed by
+	// name. Usage of the VM's database that isn't under a registered prefix is
+	// reported under [OtherPrefix]. If the VM didn't register any prefixes,
+	// this is nil.
+	VMPrefixes map[string]Usage `json:"vmPrefixes,omitempty"`
+}
+
+type chain struct {
+	chainID   ids.ID
+	alias     string
+	vmID      ids.ID
+	databases map[string][]byte
+}
+
+type collector struct {
+	log        logging.Logger
+	db         database.Database
+	sampleSize int
+	metrics    *metrics
+
+	// lock protects [chains] and [vms]
+	lock   sync.Mutex
+	chains []chain
+	vms    map[ids.ID]map[string][]byte
+
+	// statsLock ensures that only one collection happens at a time and
+	// protects [stats]
+	statsLock sync.Mutex
+	stats     *Stats
+
+	stopOnce sync.Once
+	onStop   chan struct{}
+}
+
+// New returns a new Collector that reports the storage used in [db].
+//
+// If [sampleSize] is positive, at most [sampleSize] keys are iterated over per
+// prefix before its usage is extrapolated, so collecting the stats fails if a
+// prefix has more keys and [db] doesn't support size estimation. Otherwise,
+// every key in [db] is iterated over.
+func New(
+	log logging.Logger,
+	db database.Database,
+	sampleSize int,
+	namespace string,
+	reg prometheus.Registerer,
+) (Collector, error) {
+	m, err := newMetrics(namespace, reg)
+	return &collector{
+		log:        log,
+		db:         db,
+		sampleSize: sampleSize,
+		metrics:    m,
+		vms:        make(map[ids.ID]map[string][]byte),
+		onStop:     make(chan struct{}),
+	}, err
+}
+
+func (c *collector) RegisterChain(chainID ids.ID, alias string, vmID ids.ID, databases map[string][]byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.chains = append(c.chains, chain{
+		chainID:   chainID,
+		alias:     alias,
+		vmID:      vmID,
+		databases: databases,
+	})
+}
+
+func (c *collector) RegisterVM(vmID ids.ID, prefixes map[string][]byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.vms[vmID] = prefixes
+}
+
+func (c *collector) Stats(refresh bool) (*Stats, error) {
+	c.statsLock.Lock()
+	defer c.statsLock.Unlock()
+
+	if refresh || c.stats == nil {
+		stats, err := c.collect()
+		if err != nil {
+			return nil, err
+		}
+		c.metrics.update(stats)
+		c.stats = stats
+	}
+	return c.stats, nil
+}
+
+func (c *collector) Dispatch(frequency time.Duration) {
+	t := time.NewTicker(frequency)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+		case <-c.onStop:
+			return
+		}
+
+		if _, err := c.Stats(true); err != nil {
+			c.log.Warn("failed to collect database stats",
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (c *collector) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.onStop)
+	})
+}
+
+func (c *collector) collect() (*Stats, error) {
+	c.lock.Lock()
+	chains := slices.Clone(c.chains)
+	vms := maps.Clone(c.vms)
+	c.lock.Unlock()
+
+	total, err := measure(c.db, nil, c.sampleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Timestamp: time.Now(),
+		Total:     total,
+		Chains:    make([]ChainStats, len(chains)),
+	}
+	for i, chain := range chains {
+		chainStats := ChainStats{
+			ChainID:   chain.chainID,
+			Alias:     chain.alias,
+			VMID:      chain.vmID,
+			Databases: make(map[string]Usage, len(chain.databases)),
+		}
+		for name, prefix := range chain.databases {
+			usage, err := measure(c.db, prefix, c.sampleSize)
+			if err != nil {
+				return nil, err
+			}
+			chainStats.Databases[name] = usage
+			chainStats.Total.add(usage)
+		}
+
+		vmDBPrefix, hasVMDB := chain.databases[VMDatabase]
+		vmPrefixes, hasVMPrefixes := vms[chain.vmID]
+		if hasVMDB && hasVMPrefixes {
+			chainStats.VMPrefixes = make(map[string]Usage, len(vmPrefixes)+1)
+			other := chainStats.Databases[VMDatabase]
+			for name, vmPrefix := range vmPrefixes {
+				prefix := make([]byte, len(vmDBPrefix)+len(vmPrefix))
+				copy(prefix, vmDBPrefix)
+				copy(prefix[len(vmDBPrefix):], vmPrefix)
+
+				usage, err := measure(c.db, prefix, c.sampleSize)
+				if err != nil {
+					return nil, err
+				}
+				chainStats.VMPrefixes[name] = usage
+				other.sub(usage)
+			}
+			chainStats.VMPrefixes[OtherPrefix] = other
+		}
+		stats.Chains[i] = chainStats
+	}
+	return stats, nil
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/database/versiondb"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/json"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+)
+
+var _ database.SizeEstimator = (*sizedDB)(nil)
+
+// sizedDB estimates the disk size of a range as the size of the keys and
+// values in the range.
+type sizedDB struct {
+	database.Database
+}
+
+func (db *sizedDB) EstimateSize(start, limit []byte) (uint64, error) {
+	it := db.NewIteratorWithStart(start)
+	defer it.Release()
+
+	var size uint64
+	for it.Next() {
+		key := it.Key()
+		if !database.InRange(key, start, limit) {
+			break
+		}
+		size += uint64(len(key) + len(it.Value()))
+	}
+	return size, it.Error()
+}
+
+func TestMeasure(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	prefixedDB := prefixdb.New([]byte("prefix"), db)
+	for i := uint64(0); i < 100; i++ {
+		require.NoError(prefixedDB.Put(database.PackUInt64(i), database.PackUInt64(i)))
+	}
+	require.NoError(db.Put([]byte("other"), []byte("other")))
+
+	// memdb doesn't support size estimation, so the usage can't be
+	// extrapolated from a sample.
+	_, err := measure(db, prefixedDB.Prefix(), 10)
+	require.ErrorIs(err, database.ErrSizeEstimateNotSupported)
+
+	// If there are fewer keys than the sample size, they are counted.
+	usage, err := measure(db, prefixedDB.Prefix(), 100)
+	require.NoError(err)
+	require.Equal(Usage{
+		Keys: 100,
+		Size: 100 * (32 + 8 + 8),
+	}, usage)
+
+	// Without a sample size, every key is counted.
+	usage, err = measure(db, nil, 0)
+	require.NoError(err)
+	require.Equal(Usage{
+		Keys: 101,
+		Size: 100*(32+8+8) + 10,
+	}, usage)
+}
+
+func TestMeasureExtrapolated(t *testing.T) {
+	require := require.New(t)
+
+	db := &sizedDB{Database: memdb.New()}
+	prefixedDB := prefixdb.New([]byte("prefix"), db)
+	for i := uint64(0); i < 100; i++ {
+		require.NoError(prefixedDB.Put(database.PackUInt64(i), database.PackUInt64(i)))
+	}
+
+	// All of the keys are the same size, so the extrapolation is exact.
+	usage, err := measure(db, prefixedDB.Prefix(), 10)
+	require.NoError(err)
+	require.Equal(Usage{
+		Keys:        100,
+		Size:        100 * (32 + 8 + 8),
+		DiskSize:    100 * (32 + 8 + 8),
+		Approximate: true,
+	}, usage)
+
+	// If there are fewer keys than the sample size, they are counted.
+	usage, err = measure(db, prefixedDB.Prefix(), 1000)
+	require.NoError(err)
+	require.Equal(Usage{
+		Keys:     100,
+		Size:     100 * (32 + 8 + 8),
+		DiskSize: 100 * (32 + 8 + 8),
+	}, usage)
+}
+
+func TestCollectorStats(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	c, err := New(logging.NoLog{}, db, DefaultSampleSize, "", prometheus.NewRegistry())
+	require.NoError(err)
+
+	var (
+		chainID = ids.GenerateTestID()
+		vmID    = ids.GenerateTestID()
+
+		chainDB = prefixdb.New(chainID[:], db)
+		vmDB    = prefixdb.New([]byte(VMDatabase), chainDB)
+		otherDB = prefixdb.New([]byte("bs"), chainDB)
+
+		// VMs commonly wrap their database in a versiondb, which prevents the
+		// prefixes from being compressed.
+		vmStateDB   = versiondb.New(vmDB)
+		vmUTXODB    = prefixdb.New([]byte("utxo"), vmStateDB)
+		vmUnknownDB = prefixdb.New([]byte("unknown"), vmStateDB)
+	)
+	c.RegisterVM(vmID, map[string][]byte{
+		"utxo": prefixdb.MakePrefix([]byte("utxo")),
+	})
+	c.RegisterChain(chainID, "alias", vmID, map[string][]byte{
+		VMDatabase: vmDB.Prefix(),
+		"bs":       otherDB.Prefix(),
+	})
+
+	key := []byte("key")
+	require.NoError(vmUTXODB.Put(key, key))
+	require.NoError(vmUTXODB.Put([]byte("key2"), key))
+	require.NoError(vmUnknownDB.Put(key, key))
+	require.NoError(vmStateDB.Commit())
+	require.NoError(otherDB.Put(key, key))
+	require.NoError(db.Put(key, key))
+
+	stats, err := c.Stats(false)
+	require.NoError(err)
+	require.Equal(json.Uint64(5), stats.Total.Keys)
+	require.Len(stats.Chains, 1)
+
+	chainStats := stats.Chains[0]
+	require.Equal(chainID, chainStats.ChainID)
+	require.Equal("alias", chainStats.Alias)
+	require.Equal(vmID, chainStats.VMID)
+	require.Equal(json.Uint64(4), chainStats.Total.Keys)
+	require.Equal(json.Uint64(3), chainStats.Databases[VMDatabase].Keys)
+	require.Equal(json.Uint64(1), chainStats.Databases["bs"].Keys)
+	require.Equal(
+		Usage{
+			Keys: 2,
+			Size: 2*(32+32+3) + 3 + 4,
+		},
+		chainStats.VMPrefixes["utxo"],
+	)
+	require.Equal(json.Uint64(1), chainStats.VMPrefixes[OtherPrefix].Keys)
+
+	// The stats are cached until they are refreshed.
+	require.NoError(otherDB.Put([]byte("key2"), key))
+	cachedStats, err := c.Stats(false)
+	require.NoError(err)
+	require.Equal(stats, cachedStats)
+
+	refreshedStats, err := c.Stats(true)
+	require.NoError(err)
+	require.Equal(json.Uint64(2), refreshedStats.Chains[0].Databases["bs"].Keys)
+}
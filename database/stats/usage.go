@@ -0,0 +1,110 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stats
+
+import (
+	"fmt"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/utils/json"
+)
+
+// Usage describes the storage used by a set of keys.
+type Usage struct {
+	// Number of keys
+	Keys json.Uint64 `json:"keys"`
+	// Number of bytes of the keys and values, excluding any storage overhead
+	Size json.Uint64 `json:"size"`
+	// Estimated number of bytes used on disk, or 0 if the database doesn't
+	// support size estimation
+	DiskSize json.Uint64 `json:"diskSize"`
+	// True if [Keys] and [Size] were extrapolated from a sample of the keys
+	// rather than counted
+	Approximate bool `json:"approximate"`
+}
+
+func (u *Usage) add(other Usage) {
+	u.Keys += other.Keys
+	u.Size += other.Size
+	u.DiskSize += other.DiskSize
+	u.Approximate = u.Approximate || other.Approximate
+}
+
+// sub removes [other] from [u]. Because the usages may be approximate, the
+// result is floored at 0 rather than underflowing.
+func (u *Usage) sub(other Usage) {
+	u.Keys = saturatingSub(u.Keys, other.Keys)
+	u.Size = saturatingSub(u.Size, other.Size)
+	u.DiskSize = saturatingSub(u.DiskSize, other.DiskSize)
+	u.Approximate = u.Approximate || other.Approximate
+}
+
+func saturatingSub(a, b json.Uint64) json.Uint64 {
+	if a < b {
+		return 0
+	}
+	return a - b
+}
+
+// measure returns the usage of the keys in [db] that start with [prefix].
+//
+// If [sampleSize] is positive, the number of keys and their size are
+// extrapolated from the portion of the estimated disk size that the first
+// [sampleSize] keys occupy. If there are more than [sampleSize] keys and [db]
+// doesn't support size estimation, [database.ErrSizeEstimateNotSupported] is
+// returned rather than iterating over every key.
+//
+// If [sampleSize] isn't positive, every key is counted.
+func measure(db database.Database, prefix []byte, sampleSize int) (Usage, error) {
+	limit := prefixdb.PrefixToLimit(prefix)
+	diskSize, err := database.EstimateSize(db, prefix, limit)
+	canEstimate := err == nil
+	if err != nil && err != database.ErrSizeEstimateNotSupported {
+		return Usage{}, err
+	}
+
+	usage := Usage{
+		DiskSize: json.Uint64(diskSize),
+	}
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	var lastKey []byte
+	for it.Next() {
+		if sampleSize > 0 && usage.Keys > 0 && int(usage.Keys)%sampleSize == 0 {
+			if !canEstimate {
+				return Usage{}, fmt.Errorf("%w: prefix 0x%x has more than %d keys",
+					database.ErrSizeEstimateNotSupported,
+					prefix,
+					sampleSize,
+				)
+			}
+
+			sampleLimit := append(lastKey, 0)
+			sampleDiskSize, err := database.EstimateSize(db, prefix, sampleLimit)
+			if err != nil {
+				return Usage{}, err
+			}
+			// If the sampled keys haven't been flushed to disk yet, there is
+			// nothing to extrapolate from, so the sample is extended. Unflushed
+			// keys are held in memory, which bounds how far it is extended.
+			if sampleDiskSize > 0 {
+				ratio := float64(diskSize) / float64(sampleDiskSize)
+				if ratio > 1 {
+					usage.Keys = json.Uint64(float64(usage.Keys) * ratio)
+					usage.Size = json.Uint64(float64(usage.Size) * ratio)
+				}
+				usage.Approximate = true
+				return usage, it.Error()
+			}
+		}
+
+		key := it.Key()
+		usage.Keys++
+		usage.Size += json.Uint64(len(key) + len(it.Value()))
+		lastKey = append(lastKey[:0], key...)
+	}
+	return usage, it.Error()
+}
@@ -34,6 +34,7 @@ var Tests = []func(t *testing.T, db Database){
 	TestBatchRewrite,
 	TestBatchReplay,
 	TestBatchReplayPropagateError,
+	TestBatchReplayDeleteRange,
 	TestBatchInner,
 	TestBatchLargeSize,
 	TestIteratorSnapshot,
@@ -42,7 +43,12 @@ var Tests = []func(t *testing.T, db Database){
 	TestIteratorPrefix,
 	TestIteratorStartPrefix,
 	TestIteratorMemorySafety,
+	TestReverseIterator,
+	TestReverseIteratorStart,
+	TestReverseIteratorPrefix,
+	TestReverseIteratorStartPrefix,
 	TestIteratorClosed,
+	TestReverseIteratorClosed,
 	TestIteratorError,
 	TestIteratorErrorAfterRelease,
 	TestCompactNoPanic,
@@ -50,6 +56,16 @@ var Tests = []func(t *testing.T, db Database){
 	TestMemorySafetyBatch,
 	TestClear,
 	TestClearPrefix,
+	TestDeleteRange,
+	TestDeleteRangeNilBounds,
+	TestDeleteRangeEmpty,
+	TestDeleteRangeClosed,
+	TestBatchDeleteRange,
+	TestBatchDeleteRangeNilLimit,
+	TestSnapshot,
+	TestSnapshotIterator,
+	TestSnapshotRelease,
+	TestSnapshotClosed,
 	TestModifyValueAfterPut,
 	TestModifyValueAfterBatchPut,
 	TestModifyValueAfterBatchPutReplay,
@@ -62,6 +78,13 @@ var FuzzTests = []func(*testing.F, Database){
 	FuzzKeyValue,
 }
 
+// SizeEstimatorTests is a list of tests for databases that implement
+// SizeEstimator
+var SizeEstimatorTests = []func(t *testing.T, db Database){
+	TestEstimateSize,
+	TestEstimateSizeClosed,
+}
+
 // TestSimpleKeyValue tests to make sure that simple Put + Get + Delete + Has
 // calls return the expected values.
 func TestSimpleKeyValue(t *testing.T, db Database) {
@@ -498,6 +521,49 @@ func TestBatchReplayPropagateError(t *testing.T, db Database) {
 	require.Equal(io.ErrClosedPipe, batch.Replay(mockBatch))
 }
 
+// TestBatchReplayDeleteRange tests to make sure that batches will correctly
+// replay range deletions in order with the rest of their contents.
+func TestBatchReplayDeleteRange(t *testing.T, db Database) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	batch := db.NewBatch()
+	require.NotNil(batch)
+
+	require.NoError(batch.Put(key1, value1))
+	require.NoError(batch.DeleteRange(key1, key2))
+	require.NoError(batch.Put(key2, value2))
+
+	mockBatch := NewMockBatch(ctrl)
+	gomock.InOrder(
+		mockBatch.EXPECT().Put(key1, value1).Times(1),
+		mockBatch.EXPECT().DeleteRange(key1, key2).Times(1),
+		mockBatch.EXPECT().Put(key2, value2).Times(1),
+	)
+	require.NoError(batch.Replay(mockBatch))
+
+	// Replaying range deletions requires the writer to support them.
+	mockWriter := &writerDeleter{
+		KeyValueWriterDeleter: NewMockBatch(ctrl),
+	}
+	mockWriter.KeyValueWriterDeleter.(*MockBatch).EXPECT().Put(key1, value1).Times(1)
+	require.ErrorIs(batch.Replay(mockWriter), ErrRangeDeleteNotSupported)
+}
+
+// writerDeleter hides every method of the embedded KeyValueWriterDeleter other
+// than Put and Delete.
+type writerDeleter struct {
+	KeyValueWriterDeleter
+}
+
 // TestBatchInner tests to make sure that inner can be used to write to the
 // database.
 func TestBatchInner(t *testing.T, db Database) {
@@ -733,6 +799,203 @@ func TestIteratorStartPrefix(t *testing.T, db Database) {
 	require.NoError(iterator.Error())
 }
 
+// TestReverseIterator tests to make sure the database iterates over the
+// database contents in reverse lexicographical order.
+func TestReverseIterator(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+
+	iterator := db.NewReverseIterator()
+	require.NotNil(iterator)
+
+	defer iterator.Release()
+
+	require.True(iterator.Next())
+	require.Equal(key2, iterator.Key())
+	require.Equal(value2, iterator.Value())
+
+	require.True(iterator.Next())
+	require.Equal(key1, iterator.Key())
+	require.Equal(value1, iterator.Value())
+
+	require.False(iterator.Next())
+	require.Nil(iterator.Key())
+	require.Nil(iterator.Value())
+	require.NoError(iterator.Error())
+}
+
+// TestReverseIteratorStart tests to make sure the the reverse iterator can be
+// configured to start mid way through the database, including the start key.
+func TestReverseIteratorStart(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	key3 := []byte("hello3")
+	value3 := []byte("world3")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+	require.NoError(db.Put(key3, value3))
+
+	{
+		iterator := db.NewReverseIteratorWithStart(key2)
+		require.NotNil(iterator)
+
+		defer iterator.Release()
+
+		require.True(iterator.Next())
+		require.Equal(key2, iterator.Key())
+		require.Equal(value2, iterator.Value())
+
+		require.True(iterator.Next())
+		require.Equal(key1, iterator.Key())
+		require.Equal(value1, iterator.Value())
+
+		require.False(iterator.Next())
+		require.Nil(iterator.Key())
+		require.Nil(iterator.Value())
+		require.NoError(iterator.Error())
+	}
+
+	// A start key that isn't in the database begins at the largest key that
+	// is smaller than it.
+	{
+		iterator := db.NewReverseIteratorWithStart([]byte("hello1a"))
+		require.NotNil(iterator)
+
+		defer iterator.Release()
+
+		require.True(iterator.Next())
+		require.Equal(key1, iterator.Key())
+		require.Equal(value1, iterator.Value())
+
+		require.False(iterator.Next())
+		require.NoError(iterator.Error())
+	}
+
+	// An empty start key is different from a nil start key: only keys <= the
+	// empty key are returned.
+	{
+		iterator := db.NewReverseIteratorWithStart([]byte{})
+		require.NotNil(iterator)
+
+		defer iterator.Release()
+
+		require.False(iterator.Next())
+		require.NoError(iterator.Error())
+	}
+
+	require.NoError(db.Put([]byte{}, value3))
+	{
+		iterator := db.NewReverseIteratorWithStart([]byte{})
+		require.NotNil(iterator)
+
+		defer iterator.Release()
+
+		require.True(iterator.Next())
+		require.Empty(iterator.Key())
+		require.Equal(value3, iterator.Value())
+
+		require.False(iterator.Next())
+		require.NoError(iterator.Error())
+	}
+}
+
+// TestReverseIteratorPrefix tests to make sure the reverse iterator can be
+// configured to skip keys missing the provided prefix.
+func TestReverseIteratorPrefix(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("goodbye")
+	value2 := []byte("world2")
+
+	key3 := []byte("joy")
+	value3 := []byte("world3")
+
+	key4 := []byte("hello2")
+	value4 := []byte("world4")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+	require.NoError(db.Put(key3, value3))
+	require.NoError(db.Put(key4, value4))
+
+	iterator := db.NewReverseIteratorWithPrefix([]byte("h"))
+	require.NotNil(iterator)
+
+	defer iterator.Release()
+
+	require.True(iterator.Next())
+	require.Equal(key4, iterator.Key())
+	require.Equal(value4, iterator.Value())
+
+	require.True(iterator.Next())
+	require.Equal(key1, iterator.Key())
+	require.Equal(value1, iterator.Value())
+
+	require.False(iterator.Next())
+	require.Nil(iterator.Key())
+	require.Nil(iterator.Value())
+	require.NoError(iterator.Error())
+}
+
+// TestReverseIteratorStartPrefix tests to make sure that the reverse iterator
+// can start mid way through the database while skipping a prefix.
+func TestReverseIteratorStartPrefix(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("a")
+	value2 := []byte("world2")
+
+	key3 := []byte("hello3")
+	value3 := []byte("world3")
+
+	key4 := []byte("hello4")
+	value4 := []byte("world4")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+	require.NoError(db.Put(key3, value3))
+	require.NoError(db.Put(key4, value4))
+
+	iterator := db.NewReverseIteratorWithStartAndPrefix(key3, []byte("h"))
+	require.NotNil(iterator)
+
+	defer iterator.Release()
+
+	require.True(iterator.Next())
+	require.Equal(key3, iterator.Key())
+	require.Equal(value3, iterator.Value())
+
+	require.True(iterator.Next())
+	require.Equal(key1, iterator.Key())
+	require.Equal(value1, iterator.Value())
+
+	require.False(iterator.Next())
+	require.Nil(iterator.Key())
+	require.Nil(iterator.Value())
+	require.NoError(iterator.Error())
+}
+
 // TestIteratorMemorySafety tests to make sure that keys can values are able to
 // be modified from the returned iterator.
 func TestIteratorMemorySafety(t *testing.T, db Database) {
@@ -844,6 +1107,32 @@ func TestIteratorClosed(t *testing.T, db Database) {
 	}
 }
 
+func TestReverseIteratorClosed(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Close())
+
+	for _, iterator := range []Iterator{
+		db.NewReverseIterator(),
+		db.NewReverseIteratorWithPrefix(nil),
+		db.NewReverseIteratorWithStart(nil),
+		db.NewReverseIteratorWithStartAndPrefix(nil, nil),
+	} {
+		require.NotNil(iterator)
+
+		require.False(iterator.Next())
+		require.Nil(iterator.Key())
+		require.Nil(iterator.Value())
+		require.Equal(ErrClosed, iterator.Error())
+
+		iterator.Release()
+	}
+}
+
 // TestIteratorError tests to make sure that an iterator on a database will report
 // itself as being exhausted and return [ErrClosed] to indicate that the iteration
 // was not successful.
@@ -996,6 +1285,393 @@ func TestClearPrefix(t *testing.T, db Database) {
 	require.NoError(db.Close())
 }
 
+// TestDeleteRange tests to make sure that every key in the range, and only
+// those keys, are deleted.
+func TestDeleteRange(t *testing.T, db Database) {
+	require := require.New(t)
+
+	keys := [][]byte{
+		[]byte("a"),
+		[]byte("hello1"),
+		[]byte("hello2"),
+		[]byte("hello3"),
+		[]byte("z"),
+	}
+	for _, key := range keys {
+		require.NoError(db.Put(key, key))
+	}
+
+	require.NoError(db.DeleteRange([]byte("hello1"), []byte("hello3")))
+
+	for i, key := range keys {
+		has, err := db.Has(key)
+		require.NoError(err)
+		require.Equal(i != 1 && i != 2, has)
+	}
+
+	count, err := Count(db)
+	require.NoError(err)
+	require.Equal(3, count)
+}
+
+// TestDeleteRangeNilBounds tests to make sure that nil bounds are treated as
+// the start and end of the database.
+func TestDeleteRangeNilBounds(t *testing.T, db Database) {
+	require := require.New(t)
+
+	keys := [][]byte{
+		{},
+		[]byte("hello1"),
+		[]byte("hello2"),
+		[]byte("z"),
+	}
+	for _, key := range keys {
+		require.NoError(db.Put(key, key))
+	}
+
+	require.NoError(db.DeleteRange(nil, []byte("hello2")))
+
+	count, err := Count(db)
+	require.NoError(err)
+	require.Equal(2, count)
+
+	require.NoError(db.DeleteRange([]byte("hello2"), nil))
+
+	count, err = Count(db)
+	require.NoError(err)
+	require.Zero(count)
+
+	for _, key := range keys {
+		require.NoError(db.Put(key, key))
+	}
+
+	require.NoError(db.DeleteRange(nil, nil))
+
+	count, err = Count(db)
+	require.NoError(err)
+	require.Zero(count)
+}
+
+// TestDeleteRangeEmpty tests to make sure that deleting an empty range doesn't
+// delete any keys.
+func TestDeleteRangeEmpty(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key := []byte("hello")
+	require.NoError(db.Put(key, key))
+
+	require.NoError(db.DeleteRange(key, key))
+	require.NoError(db.DeleteRange([]byte("z"), []byte("a")))
+	require.NoError(db.DeleteRange(key, []byte{}))
+
+	has, err := db.Has(key)
+	require.NoError(err)
+	require.True(has)
+}
+
+// TestDeleteRangeClosed tests to make sure that DeleteRange fails after the
+// database has been closed.
+func TestDeleteRangeClosed(t *testing.T, db Database) {
+	require := require.New(t)
+
+	require.NoError(db.Put([]byte("hello"), []byte("world")))
+	require.NoError(db.Close())
+
+	require.Equal(ErrClosed, db.DeleteRange(nil, nil))
+}
+
+// TestBatchDeleteRange tests to make sure that range deletions in a batch are
+// applied in order with the rest of the batch.
+func TestBatchDeleteRange(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	key3 := []byte("hello3")
+	value3 := []byte("world3")
+
+	require.NoError(db.Put(key1, value1))
+
+	batch := db.NewBatch()
+	require.NotNil(batch)
+
+	require.NoError(batch.Put(key2, value2))
+	require.NoError(batch.DeleteRange(key1, key3))
+	require.NoError(batch.Put(key3, value3))
+	require.Positive(batch.Size())
+
+	// Nothing is deleted until the batch is written.
+	has, err := db.Has(key1)
+	require.NoError(err)
+	require.True(has)
+
+	require.NoError(batch.Write())
+
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.False(has)
+
+	has, err = db.Has(key2)
+	require.NoError(err)
+	require.False(has)
+
+	v, err := db.Get(key3)
+	require.NoError(err)
+	require.Equal(value3, v)
+
+	// Writing the batch again must produce the same result.
+	require.NoError(db.Put(key1, value1))
+	require.NoError(batch.Write())
+
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.False(has)
+
+	batch.Reset()
+	require.Zero(batch.Size())
+	require.NoError(db.Put(key1, value1))
+	require.NoError(batch.Write())
+
+	has, err = db.Has(key1)
+	require.NoError(err)
+	require.True(has)
+}
+
+// TestBatchDeleteRangeNilLimit tests to make sure that a range deletion without
+// a limit in a batch deletes keys previously put into the batch.
+func TestBatchDeleteRangeNilLimit(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("z")
+	value2 := []byte("world2")
+
+	require.NoError(db.Put(key1, value1))
+
+	batch := db.NewBatch()
+	require.NotNil(batch)
+
+	require.NoError(batch.Put(key2, value2))
+	require.NoError(batch.DeleteRange(nil, nil))
+	require.NoError(batch.Write())
+
+	count, err := Count(db)
+	require.NoError(err)
+	require.Zero(count)
+}
+
+// TestSnapshot tests to make sure that a snapshot doesn't observe
+// modifications made after it was taken.
+func TestSnapshot(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	key3 := []byte("hello3")
+	value3 := []byte("world3")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(err)
+	defer snapshot.Release()
+
+	require.NoError(db.Put(key1, value2))
+	require.NoError(db.Delete(key2))
+	require.NoError(db.Put(key3, value3))
+
+	v, err := snapshot.Get(key1)
+	require.NoError(err)
+	require.Equal(value1, v)
+
+	v, err = snapshot.Get(key2)
+	require.NoError(err)
+	require.Equal(value2, v)
+
+	has, err := snapshot.Has(key3)
+	require.NoError(err)
+	require.False(has)
+
+	_, err = snapshot.Get(key3)
+	require.Equal(ErrNotFound, err)
+
+	// The database observes the modifications.
+	v, err = db.Get(key1)
+	require.NoError(err)
+	require.Equal(value2, v)
+
+	has, err = db.Has(key2)
+	require.NoError(err)
+	require.False(has)
+}
+
+// TestSnapshotIterator tests to make sure that iterators created from a
+// snapshot iterate over the state of the database when the snapshot was taken.
+func TestSnapshotIterator(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key1 := []byte("hello1")
+	value1 := []byte("world1")
+
+	key2 := []byte("hello2")
+	value2 := []byte("world2")
+
+	key3 := []byte("z")
+	value3 := []byte("world3")
+
+	require.NoError(db.Put(key1, value1))
+	require.NoError(db.Put(key2, value2))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(err)
+	defer snapshot.Release()
+
+	require.NoError(db.Delete(key1))
+	require.NoError(db.Put(key2, value3))
+	require.NoError(db.Put(key3, value3))
+
+	iterator := snapshot.NewIterator()
+	defer iterator.Release()
+
+	require.True(iterator.Next())
+	require.Equal(key1, iterator.Key())
+	require.Equal(value1, iterator.Value())
+
+	require.True(iterator.Next())
+	require.Equal(key2, iterator.Key())
+	require.Equal(value2, iterator.Value())
+
+	require.False(iterator.Next())
+	require.NoError(iterator.Error())
+
+	reverseIterator := snapshot.NewReverseIteratorWithPrefix([]byte("hello"))
+	defer reverseIterator.Release()
+
+	require.True(reverseIterator.Next())
+	require.Equal(key2, reverseIterator.Key())
+	require.Equal(value2, reverseIterator.Value())
+
+	require.True(reverseIterator.Next())
+	require.Equal(key1, reverseIterator.Key())
+	require.Equal(value1, reverseIterator.Value())
+
+	require.False(reverseIterator.Next())
+	require.NoError(reverseIterator.Error())
+
+	startIterator := snapshot.NewIteratorWithStartAndPrefix(key2, []byte("hello"))
+	defer startIterator.Release()
+
+	require.True(startIterator.Next())
+	require.Equal(key2, startIterator.Key())
+	require.Equal(value2, startIterator.Value())
+
+	require.False(startIterator.Next())
+	require.NoError(startIterator.Error())
+}
+
+// TestSnapshotRelease tests to make sure that reads from a released snapshot
+// fail.
+func TestSnapshotRelease(t *testing.T, db Database) {
+	require := require.New(t)
+
+	key := []byte("hello")
+	value := []byte("world")
+
+	require.NoError(db.Put(key, value))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(err)
+
+	iterator := snapshot.NewIterator()
+	defer iterator.Release()
+
+	snapshot.Release()
+	snapshot.Release()
+
+	_, err = snapshot.Has(key)
+	require.Equal(ErrClosed, err)
+
+	_, err = snapshot.Get(key)
+	require.Equal(ErrClosed, err)
+
+	require.False(iterator.Next())
+	require.Equal(ErrClosed, iterator.Error())
+
+	// The database is unaffected.
+	v, err := db.Get(key)
+	require.NoError(err)
+	require.Equal(value, v)
+}
+
+// TestSnapshotClosed tests to make sure that a snapshot can't be taken after
+// the database has been closed.
+func TestSnapshotClosed(t *testing.T, db Database) {
+	require := require.New(t)
+
+	require.NoError(db.Close())
+
+	_, err := db.Snapshot()
+	require.Equal(ErrClosed, err)
+}
+
+// TestEstimateSize tests to make sure that the estimated size of a range grows
+// with the data stored in it.
+func TestEstimateSize(t *testing.T, db Database) {
+	require := require.New(t)
+
+	estimator, ok := db.(SizeEstimator)
+	require.True(ok)
+
+	size, err := estimator.EstimateSize(nil, nil)
+	require.NoError(err)
+	require.Zero(size)
+
+	value := make([]byte, units.KiB)
+	for i := 0; i < 1024; i++ {
+		require.NoError(db.Put(PackUInt32(uint32(i)), value))
+	}
+	require.NoError(db.Compact(nil, nil))
+
+	total, err := estimator.EstimateSize(nil, nil)
+	require.NoError(err)
+	require.Positive(total)
+
+	half, err := estimator.EstimateSize(nil, PackUInt32(512))
+	require.NoError(err)
+	require.Positive(half)
+	require.LessOrEqual(half, total)
+
+	size, err = estimator.EstimateSize(PackUInt32(512), PackUInt32(512))
+	require.NoError(err)
+	require.Zero(size)
+}
+
+// TestEstimateSizeClosed tests to make sure that EstimateSize fails after the
+// database has been closed.
+func TestEstimateSizeClosed(t *testing.T, db Database) {
+	require := require.New(t)
+
+	estimator, ok := db.(SizeEstimator)
+	require.True(ok)
+
+	require.NoError(db.Put([]byte("hello"), []byte("world")))
+	require.NoError(db.Close())
+
+	_, err := estimator.EstimateSize(nil, nil)
+	require.Equal(ErrClosed, err)
+}
+
 func TestModifyValueAfterPut(t *testing.T, db Database) {
 	require := require.New(t)
 
@@ -20,6 +20,7 @@ var (
 	_ Commitable        = (*Database)(nil)
 	_ database.Batch    = (*batch)(nil)
 	_ database.Iterator = (*iterator)(nil)
+	_ database.Snapshot = (*snapshot)(nil)
 )
 
 // Commitable defines the interface that specifies that something may be
@@ -104,6 +105,50 @@ func (db *Database) Delete(key []byte) error {
 	return nil
 }
 
+// DeleteRange buffers the deletion of every key in the range [start, limit).
+// Every key currently in the range is marked as deleted, so keys added to the
+// underlying database afterwards will not be removed by Commit.
+func (db *Database) DeleteRange(start, limit []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.mem == nil {
+		return database.ErrClosed
+	}
+	return db.deleteRange(start, limit)
+}
+
+// Assumes [db.lock] is held.
+func (db *Database) deleteRange(start, limit []byte) error {
+	// The keys are collected before any are marked as deleted so that the
+	// buffered state isn't modified if iterating the underlying database
+	// fails.
+	var keys []string
+	it := db.db.NewIteratorWithStart(start)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if !database.InRange(key, start, limit) {
+			break
+		}
+		keys = append(keys, string(key))
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		db.mem[key] = valueDelete{delete: true}
+	}
+	for key := range db.mem {
+		if database.InRange([]byte(key), start, limit) {
+			db.mem[key] = valueDelete{delete: true}
+		}
+	}
+	return nil
+}
+
 func (db *Database) NewBatch() database.Batch {
 	return &batch{db: db}
 }
@@ -130,26 +175,122 @@ func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database
 		}
 	}
 
+	return newIterator(
+		db.db.NewIteratorWithStartAndPrefix(start, prefix),
+		db.mem,
+		sortedKeys(db.mem, start, prefix),
+		false,
+		db.isClosed,
+	)
+}
+
+func (db *Database) NewReverseIterator() database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.mem == nil {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+
+	return newIterator(
+		db.db.NewReverseIteratorWithStartAndPrefix(start, prefix),
+		db.mem,
+		reverseSortedKeys(db.mem, start, prefix),
+		true,
+		db.isClosed,
+	)
+}
+
+// sortedKeys returns the keys in [mem] that are at least [start] and begin
+// with [prefix], in ascending order.
+func sortedKeys(mem map[string]valueDelete, start, prefix []byte) []string {
 	startString := string(start)
 	prefixString := string(prefix)
-	keys := make([]string, 0, len(db.mem))
-	for key := range db.mem {
+	keys := make([]string, 0, len(mem))
+	for key := range mem {
 		if strings.HasPrefix(key, prefixString) && key >= startString {
 			keys = append(keys, key)
 		}
 	}
 	slices.Sort(keys) // Keys need to be in sorted order
+	return keys
+}
+
+// reverseSortedKeys returns the keys in [mem] that are at most [start] and
+// begin with [prefix], in descending order. If [start] is nil, it is treated
+// as the end of the key space.
+func reverseSortedKeys(mem map[string]valueDelete, start, prefix []byte) []string {
+	startString := string(start)
+	prefixString := string(prefix)
+	keys := make([]string, 0, len(mem))
+	for key := range mem {
+		if strings.HasPrefix(key, prefixString) && (start == nil || key <= startString) {
+			keys = append(keys, key)
+		}
+	}
+	// Keys need to be in reverse sorted order
+	slices.SortFunc(keys, func(a, b string) bool {
+		return a > b
+	})
+	return keys
+}
+
+// newIterator merges the [keys] of [mem] with [it]. [isClosed] is checked
+// before every step of the iteration.
+func newIterator(
+	it database.Iterator,
+	mem map[string]valueDelete,
+	keys []string,
+	reverse bool,
+	isClosed func() bool,
+) database.Iterator {
 	values := make([]valueDelete, len(keys))
 	for i, key := range keys {
-		values[i] = db.mem[key]
+		values[i] = mem[key]
 	}
 
 	return &iterator{
-		db:       db,
-		Iterator: db.db.NewIteratorWithStartAndPrefix(start, prefix),
+		isClosed: isClosed,
+		Iterator: it,
 		keys:     keys,
 		values:   values,
+		reverse:  reverse,
+	}
+}
+
+// Snapshot returns a read-only view of the current state of the database,
+// including any changes that haven't been committed yet.
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.mem == nil {
+		return nil, database.ErrClosed
 	}
+	s, err := db.db.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	// Values are never modified in place, so they can be shared with the
+	// snapshot.
+	return &snapshot{
+		mem:      maps.Clone(db.mem),
+		snapshot: s,
+	}, nil
 }
 
 func (db *Database) Compact(start, limit []byte) error {
@@ -290,6 +431,12 @@ func (b *batch) Write() error {
 	}
 
 	for _, op := range b.Ops {
+		if op.DeleteRange {
+			if err := b.db.deleteRange(op.Key, op.Limit); err != nil {
+				return err
+			}
+			continue
+		}
 		b.db.mem[string(op.Key)] = valueDelete{
 			value:  op.Value,
 			delete: op.Delete,
@@ -305,7 +452,7 @@ func (b *batch) Inner() database.Batch {
 // iterator walks over both the in memory database and the underlying database
 // at the same time.
 type iterator struct {
-	db *Database
+	isClosed func() bool
 	database.Iterator
 
 	key, value []byte
@@ -314,15 +461,25 @@ type iterator struct {
 	keys   []string
 	values []valueDelete
 
+	// reverse is true if the iterator moves in descending key order
+	reverse                bool
 	initialized, exhausted bool
 }
 
+// before returns true if [a] should be returned by the iterator before [b].
+func (it *iterator) before(a, b string) bool {
+	if it.reverse {
+		return a > b
+	}
+	return a < b
+}
+
 // Next moves the iterator to the next key/value pair. It returns whether the
 // iterator is exhausted. We must pay careful attention to set the proper values
 // based on if the in memory db or the underlying db should be read next
 func (it *iterator) Next() bool {
 	// Short-circuit and set an error if the underlying database has been closed.
-	if it.db.isClosed() {
+	if it.isClosed() {
 		it.key = nil
 		it.value = nil
 		it.err = database.ErrClosed
@@ -367,7 +524,7 @@ func (it *iterator) Next() bool {
 
 			dbStringKey := string(dbKey)
 			switch {
-			case memKey < dbStringKey:
+			case it.before(memKey, dbStringKey):
 				it.keys[0] = ""
 				it.keys = it.keys[1:]
 				it.values[0].value = nil
@@ -378,7 +535,7 @@ func (it *iterator) Next() bool {
 					it.value = memValue.value
 					return true
 				}
-			case dbStringKey < memKey:
+			case it.before(dbStringKey, memKey):
 				it.key = dbKey
 				it.value = it.Iterator.Value()
 				it.exhausted = !it.Iterator.Next()
@@ -422,3 +579,118 @@ func (it *iterator) Release() {
 	it.values = nil
 	it.Iterator.Release()
 }
+
+// snapshot is a read-only view of the database, and its uncommitted changes,
+// at the time that it was taken.
+type snapshot struct {
+	lock     sync.RWMutex
+	mem      map[string]valueDelete
+	snapshot database.Snapshot
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.mem == nil {
+		return false, database.ErrClosed
+	}
+	if val, has := s.mem[string(key)]; has {
+		return !val.delete, nil
+	}
+	return s.snapshot.Has(key)
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.mem == nil {
+		return nil, database.ErrClosed
+	}
+	if val, has := s.mem[string(key)]; has {
+		if val.delete {
+			return nil, database.ErrNotFound
+		}
+		return slices.Clone(val.value), nil
+	}
+	return s.snapshot.Get(key)
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.mem == nil {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return newIterator(
+		s.snapshot.NewIteratorWithStartAndPrefix(start, prefix),
+		s.mem,
+		sortedKeys(s.mem, start, prefix),
+		false,
+		s.isReleased,
+	)
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.mem == nil {
+		return &database.IteratorError{
+			Err: database.ErrClosed,
+		}
+	}
+	return newIterator(
+		s.snapshot.NewReverseIteratorWithStartAndPrefix(start, prefix),
+		s.mem,
+		reverseSortedKeys(s.mem, start, prefix),
+		true,
+		s.isReleased,
+	)
+}
+
+func (s *snapshot) Release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.mem == nil {
+		return
+	}
+	s.mem = nil
+	s.snapshot.Release()
+}
+
+func (s *snapshot) isReleased() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.mem == nil
+}
@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 )
@@ -186,6 +188,121 @@ func TestIterate(t *testing.T) {
 	}
 }
 
+func TestReverseIterate(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db := New(baseDB)
+
+	require.NoError(db.Put([]byte("a"), []byte("1")))
+	require.NoError(db.Put([]byte("c"), []byte("3")))
+	require.NoError(db.Put([]byte("e"), []byte("5")))
+	require.NoError(db.Commit())
+
+	// Mix uncommitted puts, overwrites, and deletes with the committed keys.
+	require.NoError(db.Put([]byte("b"), []byte("2")))
+	require.NoError(db.Put([]byte("c"), []byte("33")))
+	require.NoError(db.Delete([]byte("e")))
+	require.NoError(db.Put([]byte("f"), []byte("6")))
+
+	iterator := db.NewReverseIterator()
+	defer iterator.Release()
+
+	expected := [][2]string{
+		{"f", "6"},
+		{"c", "33"},
+		{"b", "2"},
+		{"a", "1"},
+	}
+	for _, kv := range expected {
+		require.True(iterator.Next())
+		require.Equal([]byte(kv[0]), iterator.Key())
+		require.Equal([]byte(kv[1]), iterator.Value())
+	}
+	require.False(iterator.Next())
+	require.NoError(iterator.Error())
+
+	iterator = db.NewReverseIteratorWithStart([]byte("e"))
+	defer iterator.Release()
+
+	for _, kv := range expected[1:] {
+		require.True(iterator.Next())
+		require.Equal([]byte(kv[0]), iterator.Key())
+		require.Equal([]byte(kv[1]), iterator.Value())
+	}
+	require.False(iterator.Next())
+	require.NoError(iterator.Error())
+}
+
+func TestDeleteRangeCommit(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db := New(baseDB)
+
+	require.NoError(db.Put([]byte("a"), []byte("1")))
+	require.NoError(db.Put([]byte("b"), []byte("2")))
+	require.NoError(db.Put([]byte("c"), []byte("3")))
+	require.NoError(db.Commit())
+
+	require.NoError(db.Put([]byte("bb"), []byte("22")))
+	require.NoError(db.DeleteRange([]byte("b"), []byte("c")))
+
+	// The deletions are buffered until the database is committed.
+	has, err := baseDB.Has([]byte("b"))
+	require.NoError(err)
+	require.True(has)
+
+	count, err := database.Count(db)
+	require.NoError(err)
+	require.Equal(2, count)
+
+	require.NoError(db.Commit())
+
+	count, err = database.Count(baseDB)
+	require.NoError(err)
+	require.Equal(2, count)
+
+	has, err = baseDB.Has([]byte("b"))
+	require.NoError(err)
+	require.False(has)
+}
+
+func TestSnapshotUncommitted(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db := New(baseDB)
+
+	require.NoError(db.Put([]byte("a"), []byte("1")))
+	require.NoError(db.Commit())
+
+	require.NoError(db.Put([]byte("b"), []byte("2")))
+	require.NoError(db.Delete([]byte("a")))
+
+	snapshot, err := db.Snapshot()
+	require.NoError(err)
+	defer snapshot.Release()
+
+	// Committing and modifying the database doesn't modify the snapshot.
+	require.NoError(db.Commit())
+	require.NoError(db.Put([]byte("a"), []byte("3")))
+	require.NoError(db.Commit())
+
+	has, err := snapshot.Has([]byte("a"))
+	require.NoError(err)
+	require.False(has)
+
+	it := snapshot.NewIterator()
+	defer it.Release()
+
+	require.True(it.Next())
+	require.Equal([]byte("b"), it.Key())
+	require.Equal([]byte("2"), it.Value())
+	require.False(it.Next())
+	require.NoError(it.Error())
+}
+
 func TestCommit(t *testing.T) {
 	baseDB := memdb.New()
 	db := New(baseDB)
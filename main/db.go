@@ -0,0 +1,548 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/memeticofficial/pepecoingo/config"
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/backup"
+	"github.com/memeticofficial/pepecoingo/database/leveldb"
+	"github.com/memeticofficial/pepecoingo/database/migrate"
+	"github.com/memeticofficial/pepecoingo/database/pebbledb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/trace"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+	"github.com/memeticofficial/pepecoingo/utils/perms"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+	"github.com/memeticofficial/pepecoingo/version"
+	"github.com/memeticofficial/pepecoingo/x/merkledb"
+)
+
+const (
+	dbCommand        = "db"
+	dbRestoreCommand = "restore"
+	dbMigrateCommand = "migrate"
+	dbExportCommand  = "merkle-export"
+	dbImportCommand  = "merkle-import"
+
+	backupDirKey        = "backup-dir"
+	fromDBTypeKey       = "from"
+	toDBTypeKey         = "to"
+	toDBPathKey         = "to-db-dir"
+	toDBConfigFileKey   = "to-db-config-file"
+	dbVersionKey        = "db-version"
+	migrateBatchSizeKey = "migrate-batch-size"
+	pruneOldVersionsKey = "prune-old-versions"
+	pruneConfirmKey     = "prune-confirm"
+	merkleDBPrefixesKey = "merkledb-prefixes"
+	merkleRootKey       = "merkledb-root"
+	merkleFileKey       = "merkledb-file"
+	merkleChunkSizeKey  = "merkledb-chunk-size"
+
+	merkleDBNodeCacheSize = 100_000
+
+	checkpointFileSuffix = ".migrate-checkpoint"
+	restoreDirSuffix     = ".restore-*"
+)
+
+var (
+	errUnknownDBCommand   = errors.New("unknown db command")
+	errNoBackupDir        = errors.New("missing backup directory")
+	errUnsupportedDBType  = errors.New("unsupported db-type")
+	errDatabaseDirExists  = errors.New("database directory already exists")
+	errNoDestination      = errors.New("missing destination")
+	errSameDatabase       = errors.New("source and destination databases are the same")
+	errNoMerkleFile       = errors.New("missing merkledb file")
+	errMerkleFileExists   = errors.New("merkledb file already exists")
+	dbCommandUsageMessage = fmt.Sprintf(
+		"usage: %s %s <%s|%s|%s|%s> [flags]",
+		filepath.Base(os.Args[0]),
+		dbCommand,
+		dbRestoreCommand,
+		dbMigrateCommand,
+		dbExportCommand,
+		dbImportCommand,
+	)
+)
+
+// runDBCommand runs an offline database command and returns the exit code.
+func runDBCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Println(dbCommandUsageMessage)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var err error
+	switch args[0] {
+	case dbRestoreCommand:
+		err = restoreDB(ctx, args[1:])
+	case dbMigrateCommand:
+		err = migrateDB(ctx, args[1:])
+	case dbExportCommand:
+		err = exportMerkleDB(ctx, args[1:])
+	case dbImportCommand:
+		err = importMerkleDB(ctx, args[1:])
+	default:
+		err = fmt.Errorf("%w: %q\n%s", errUnknownDBCommand, args[0], dbCommandUsageMessage)
+	}
+	if errors.Is(err, pflag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Printf("%s %s failed: %s\n", dbCommand, args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// restoreDB seeds the node's database from a backup created by
+// admin.createBackup. The node must not be running.
+func restoreDB(ctx context.Context, args []string) error {
+	fs := config.BuildFlagSet()
+	fs.String(backupDirKey, "", "Path to the backup directory to restore from")
+	v, err := config.BuildViper(fs, args)
+	if err != nil {
+		return err
+	}
+
+	backupDir := v.GetString(backupDirKey)
+	if len(backupDir) == 0 {
+		return errNoBackupDir
+	}
+
+	manifest, err := backup.ReadManifest(backupDir)
+	if err != nil {
+		return err
+	}
+	dbVersion, err := version.Parse(manifest.DatabaseVersion)
+	if err != nil {
+		return err
+	}
+
+	dbConfig, err := config.GetDatabaseConfig(v)
+	if err != nil {
+		return err
+	}
+
+	// The backup is restored into the directory of the version it was taken
+	// from, so the node will treat it exactly like the original database.
+	dbPath := filepath.Join(dbConfig.Path, dbVersion.String())
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("%w: %s", errDatabaseDirExists, dbPath)
+	}
+
+	// The backup is restored into a temporary directory that is only renamed
+	// to [dbPath] once the restore succeeds, so a failed restore never leaves
+	// a partial database for the node to start on. The node ignores
+	// directories that aren't named after a version.
+	if err := os.MkdirAll(dbConfig.Path, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	tmpPath, err := os.MkdirTemp(dbConfig.Path, dbVersion.String()+restoreDirSuffix)
+	if err != nil {
+		return err
+	}
+	if err := restoreDBInto(ctx, backupDir, manifest, dbConfig.Name, tmpPath, dbConfig.Config); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return err
+	}
+	fmt.Printf("restored %s\n", dbPath)
+	return nil
+}
+
+// restoreDBInto restores the backup in [backupDir] into a new database of
+// type [dbType] at [dbPath].
+func restoreDBInto(
+	ctx context.Context,
+	backupDir string,
+	manifest *backup.Manifest,
+	dbType string,
+	dbPath string,
+	dbConfig []byte,
+) error {
+	db, err := openDB(dbType, dbPath, dbConfig)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("restoring %d keys from %s into %s\n", manifest.NumKeys, backupDir, dbPath)
+	_, err = backup.Restore(ctx, backupDir, db, func(progress backup.Progress) {
+		fmt.Printf("restored %d/%d keys (%d bytes)\n", progress.NumKeys, manifest.NumKeys, progress.NumBytes)
+	})
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// migrateDB copies the node's database into a new directory, possibly using a
+// different database type, and verifies the copy. If interrupted, running the
+// same command again resumes the migration. Once the copy is verified, the
+// source's directories of older database versions are optionally removed. The
+// node must not be running.
+func migrateDB(ctx context.Context, args []string) error {
+	fs := config.BuildFlagSet()
+	fs.String(fromDBTypeKey, "", fmt.Sprintf("Database type to migrate from. Defaults to the value of --%s", config.DBTypeKey))
+	fs.String(toDBTypeKey, "", fmt.Sprintf("Database type to migrate to. Should be one of {%s, %s}", leveldb.Name, pebbledb.Name))
+	fs.String(toDBPathKey, "", "Path to the database directory to migrate to")
+	fs.String(toDBConfigFileKey, "", "Path to the config file of the database to migrate to")
+	fs.String(dbVersionKey, version.CurrentDatabase.String(), "Version of the database to migrate")
+	fs.Int(migrateBatchSizeKey, migrate.DefaultBatchSize, "Number of bytes to write to the destination database per batch")
+	fs.Bool(pruneOldVersionsKey, false, "Remove the directories of database versions older than the migrated version from the source database directory once the migration is verified")
+	fs.Bool(pruneConfirmKey, false, fmt.Sprintf("Don't prompt for confirmation before removing the directories of old database versions. Only used if --%s is set", pruneOldVersionsKey))
+	v, err := config.BuildViper(fs, args)
+	if err != nil {
+		return err
+	}
+
+	srcConfig, err := config.GetDatabaseConfig(v)
+	if err != nil {
+		return err
+	}
+	if fromDBType := v.GetString(fromDBTypeKey); len(fromDBType) > 0 {
+		srcConfig.Name = fromDBType
+	}
+
+	dstDBType := v.GetString(toDBTypeKey)
+	dstDBDir := v.GetString(toDBPathKey)
+	if len(dstDBType) == 0 || len(dstDBDir) == 0 {
+		return fmt.Errorf("%w: --%s and --%s must be provided", errNoDestination, toDBTypeKey, toDBPathKey)
+	}
+	var dstDBConfig []byte
+	if dstConfigFile := v.GetString(toDBConfigFileKey); len(dstConfigFile) > 0 {
+		dstDBConfig, err = os.ReadFile(dstConfigFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	dbVersion, err := version.Parse(v.GetString(dbVersionKey))
+	if err != nil {
+		return err
+	}
+
+	// The destination uses the same network and version layout as the source
+	// so that a node can be started directly on it.
+	_, networkName := filepath.Split(srcConfig.Path)
+	srcPath := filepath.Join(srcConfig.Path, dbVersion.String())
+	dstPath := filepath.Join(dstDBDir, networkName, dbVersion.String())
+	if filepath.Clean(srcPath) == filepath.Clean(dstPath) {
+		return fmt.Errorf("%w: %s", errSameDatabase, srcPath)
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return err
+	}
+
+	src, err := openDB(srcConfig.Name, srcPath, srcConfig.Config)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := openDB(dstDBType, dstPath, dstDBConfig)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	checkpointFile := dstPath + checkpointFileSuffix
+	fmt.Printf("migrating %s database at %s to %s database at %s\n", srcConfig.Name, srcPath, dstDBType, dstPath)
+	_, err = migrate.Copy(ctx, src, dst, migrate.Config{
+		BatchSize:      v.GetInt(migrateBatchSizeKey),
+		CheckpointFile: checkpointFile,
+		OnProgress:     printMigrateProgress,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("verifying migration")
+	summary, err := migrate.Verify(ctx, src, dst, migrate.DefaultPrefixLen)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("verified %d keys across %d prefixes\n", summary.NumKeys(), len(summary))
+	// The checkpoint isn't written if the migration finishes before its
+	// first checkpoint.
+	if err := os.Remove(checkpointFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !v.GetBool(pruneOldVersionsKey) {
+		return nil
+	}
+	return pruneOldDBVersions(srcConfig.Path, dbVersion, v.GetBool(pruneConfirmKey), os.Stdin)
+}
+
+// pruneOldDBVersions removes the directories of database versions older than
+// [keep] from [dbDir]. Unless [confirmed], the user must confirm the removal
+// by entering "yes" on [in].
+func pruneOldDBVersions(dbDir string, keep *version.Semantic, confirmed bool, in io.Reader) error {
+	paths, err := migrate.OldVersions(dbDir, keep)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Println("no old database versions to prune")
+		return nil
+	}
+
+	fmt.Println("the following database directories will be permanently removed:")
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+	if !confirmed {
+		fmt.Print("type 'yes' to continue: ")
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if strings.TrimSpace(answer) != "yes" {
+			fmt.Println("not pruning old database versions")
+			return nil
+		}
+	}
+
+	pruned, err := migrate.PruneVersions(dbDir, keep)
+	for _, path := range pruned {
+		fmt.Printf("removed %s\n", path)
+	}
+	return err
+}
+
+func printMigrateProgress(progress migrate.Progress) {
+	fmt.Printf(
+		"copied %d keys (%.2f MiB) in %s at %.0f keys/s (%.2f MiB/s)\n",
+		progress.NumKeys,
+		float64(progress.NumBytes)/units.MiB,
+		progress.Duration.Round(time.Second),
+		progress.KeysPerSecond(),
+		progress.BytesPerSecond()/units.MiB,
+	)
+}
+
+// exportMerkleDB writes a merkledb trie stored in the node's database to a
+// file that can be verified and imported by importMerkleDB. The node must not
+// be running.
+func exportMerkleDB(ctx context.Context, args []string) error {
+	fs := buildMerkleDBFlagSet()
+	fs.String(merkleRootKey, "", "Root of the trie to export. Defaults to the current root")
+	fs.Int(merkleChunkSizeKey, merkledb.DefaultExportChunkSize, "Maximum number of key/value pairs in each chunk of the export")
+	v, err := config.BuildViper(fs, args)
+	if err != nil {
+		return err
+	}
+
+	file := v.GetString(merkleFileKey)
+	if len(file) == 0 {
+		return errNoMerkleFile
+	}
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("%w: %s", errMerkleFileExists, file)
+	}
+	var rootID ids.ID
+	if rootStr := v.GetString(merkleRootKey); len(rootStr) > 0 {
+		rootID, err = ids.FromString(rootStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	db, closeFn, err := openMerkleDB(ctx, v, true)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	rootID, err = db.Export(ctx, w, merkledb.ExportConfig{
+		RootID:     rootID,
+		ChunkSize:  v.GetInt(merkleChunkSizeKey),
+		OnProgress: printMerkleProgress("exported"),
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file)
+		return err
+	}
+	fmt.Printf("exported root %s to %s\n", rootID, file)
+	return nil
+}
+
+// importMerkleDB replaces a merkledb trie stored in the node's database with
+// the trie in a file written by exportMerkleDB. If interrupted, running the
+// same command again resumes the import. The node must not be running.
+func importMerkleDB(ctx context.Context, args []string) error {
+	fs := buildMerkleDBFlagSet()
+	fs.String(merkleRootKey, "", "Expected root of the imported trie. If empty, the root in the file is trusted")
+	v, err := config.BuildViper(fs, args)
+	if err != nil {
+		return err
+	}
+
+	file := v.GetString(merkleFileKey)
+	if len(file) == 0 {
+		return errNoMerkleFile
+	}
+	var expectedRootID ids.ID
+	if rootStr := v.GetString(merkleRootKey); len(rootStr) > 0 {
+		expectedRootID, err = ids.FromString(rootStr)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("--%s wasn't provided, so the root in %s is trusted\n", merkleRootKey, file)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, closeFn, err := openMerkleDB(ctx, v, false)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	rootID, err := db.Import(ctx, bufio.NewReader(f), merkledb.ImportConfig{
+		ExpectedRootID: expectedRootID,
+		OnProgress:     printMerkleProgress("imported"),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported root %s from %s\n", rootID, file)
+	return nil
+}
+
+func buildMerkleDBFlagSet() *pflag.FlagSet {
+	fs := config.BuildFlagSet()
+	fs.String(dbVersionKey, version.CurrentDatabase.String(), "Version of the database containing the trie")
+	fs.StringSlice(merkleDBPrefixesKey, nil, "Hex encoded prefixes of the trie within the database, outermost first")
+	fs.String(merkleFileKey, "", "Path to the exported trie")
+	return fs
+}
+
+// openMerkleDB opens the merkledb trie in the node's database. The returned
+// function closes the trie and the database.
+//
+// If [forExport], the database must already exist and the trie's history is
+// persisted, so that roots other than the current root can be exported if the
+// node persisted them. Otherwise, the database is created if needed and the
+// imported chunks aren't recorded in the persisted history.
+func openMerkleDB(ctx context.Context, v *viper.Viper, forExport bool) (*merkledb.Database, func(), error) {
+	dbConfig, err := config.GetDatabaseConfig(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbVersion, err := version.Parse(v.GetString(dbVersionKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	dbPath := filepath.Join(dbConfig.Path, dbVersion.String())
+	if forExport {
+		if _, err := os.Stat(dbPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var prefixes [][]byte
+	for _, prefixStr := range v.GetStringSlice(merkleDBPrefixesKey) {
+		prefix, err := hex.DecodeString(prefixStr)
+		if err != nil {
+			return nil, nil, err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	baseDB, err := openDB(dbConfig.Name, dbPath, dbConfig.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	var db database.Database = baseDB
+	for _, prefix := range prefixes {
+		db = prefixdb.New(prefix, db)
+	}
+
+	tracer, err := trace.New(trace.Config{Enabled: false})
+	if err != nil {
+		_ = baseDB.Close()
+		return nil, nil, err
+	}
+	merkleDB, err := merkledb.New(ctx, db, merkledb.Config{
+		Tracer:         tracer,
+		NodeCacheSize:  merkleDBNodeCacheSize,
+		PersistHistory: forExport,
+	})
+	if err != nil {
+		_ = baseDB.Close()
+		return nil, nil, err
+	}
+	return merkleDB, func() {
+		_ = merkleDB.Close()
+		_ = baseDB.Close()
+	}, nil
+}
+
+func printMerkleProgress(verb string) func(merkledb.ExportProgress) {
+	return func(progress merkledb.ExportProgress) {
+		fmt.Printf(
+			"%s %d keys in %d chunks (%d skipped) in %s\n",
+			verb,
+			progress.NumKeys,
+			progress.NumChunks,
+			progress.NumSkippedChunks,
+			progress.Duration.Round(time.Second),
+		)
+	}
+}
+
+// openDB opens the on-disk database of type [dbType] at [path].
+func openDB(dbType string, path string, dbConfig []byte) (database.Database, error) {
+	switch dbType {
+	case leveldb.Name:
+		return leveldb.New(path, dbConfig, logging.NoLog{}, "", prometheus.NewRegistry())
+	case pebbledb.Name:
+		return pebbledb.New(path, dbConfig, logging.NoLog{}, "", prometheus.NewRegistry())
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedDBType, dbType)
+	}
+}
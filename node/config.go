@@ -132,6 +132,10 @@ type DatabaseConfig struct {
 
 	// Path to config file
 	Config []byte `json:"-"`
+
+	// Frequency that the storage usage of each chain is collected at. If 0,
+	// the usage is only collected when requested.
+	StatsFrequency time.Duration `json:"statsFrequency"`
 }
 
 // Config contains all of the configurations of an Pepecoin node.
@@ -36,7 +36,9 @@ import (
 	"github.com/memeticofficial/pepecoingo/database/leveldb"
 	"github.com/memeticofficial/pepecoingo/database/manager"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/pebbledb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/database/stats"
 	"github.com/memeticofficial/pepecoingo/genesis"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/indexer"
@@ -82,7 +84,9 @@ import (
 
 	ipcsapi "github.com/memeticofficial/pepecoingo/api/ipcs"
 	avmconfig "github.com/memeticofficial/pepecoingo/vms/avm/config"
+	avmstates "github.com/memeticofficial/pepecoingo/vms/avm/states"
 	platformconfig "github.com/memeticofficial/pepecoingo/vms/platformvm/config"
+	platformstate "github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 )
 
 var (
@@ -107,6 +111,9 @@ type Node struct {
 	DBManager manager.Manager
 	DB        database.Database
 
+	// Reports the storage used by each chain
+	dbStats stats.Collector
+
 	// Profiles the process. Nil if continuous profiling is disabled.
 	profiler profiler.ContinuousProfiler
 
@@ -429,12 +436,15 @@ func (n *Node) initDatabase() error {
 		dbManager, err = manager.NewLevelDB(n.Config.DatabaseConfig.Path, n.Config.DatabaseConfig.Config, n.Log, version.CurrentDatabase, "db_internal", n.MetricsRegisterer)
 	case memdb.Name:
 		dbManager = manager.NewMemDB(version.CurrentDatabase)
+	case pebbledb.Name:
+		dbManager, err = manager.NewPebbleDB(n.Config.DatabaseConfig.Path, n.Config.DatabaseConfig.Config, n.Log, version.CurrentDatabase, "db_internal", n.MetricsRegisterer)
 	default:
 		err = fmt.Errorf(
-			"db-type was %q but should have been one of {%s, %s}",
+			"db-type was %q but should have been one of {%s, %s, %s}",
 			n.Config.DatabaseConfig.Name,
 			leveldb.Name,
 			memdb.Name,
+			pebbledb.Name,
 		)
 	}
 	if err != nil {
@@ -480,6 +490,33 @@ func (n *Node) initDatabase() error {
 	return nil
 }
 
+// initDatabaseStats initializes the reporting of the storage used by each
+// chain.
+// Assumes n.DB and n.MetricsRegisterer are initialized.
+func (n *Node) initDatabaseStats() error {
+	dbStats, err := stats.New(
+		n.Log,
+		n.DB,
+		stats.DefaultSampleSize,
+		"db_stats",
+		n.MetricsRegisterer,
+	)
+	if err != nil {
+		return err
+	}
+	n.dbStats = dbStats
+
+	n.dbStats.RegisterVM(constants.PlatformVMID, platformstate.DatabasePrefixes())
+	n.dbStats.RegisterVM(constants.AVMID, avmstates.DatabasePrefixes())
+
+	if frequency := n.Config.DatabaseConfig.StatsFrequency; frequency > 0 {
+		go n.Log.RecoverAndPanic(func() {
+			n.dbStats.Dispatch(frequency)
+		})
+	}
+	return nil
+}
+
 // Set the node IDs of the peers this node should first connect to
 func (n *Node) initBeacons() error {
 	n.beacons = validators.NewSet()
@@ -717,6 +754,7 @@ func (n *Node) initChainManager(avaxAssetID ids.ID) error {
 		TxAcceptorGroup:                         n.TxAcceptorGroup,
 		VertexAcceptorGroup:                     n.VertexAcceptorGroup,
 		DBManager:                               n.DBManager,
+		DatabaseStats:                           n.dbStats,
 		MsgCreator:                              n.msgCreator,
 		Router:                                  n.Config.ConsensusRouter,
 		Net:                                     n.Net,
@@ -811,6 +849,7 @@ func (n *Node) initVMs() error {
 				ApricotPhase5Time:               version.GetApricotPhase5Time(n.Config.NetworkID),
 				BanffTime:                       version.GetBanffTime(n.Config.NetworkID),
 				CortinaTime:                     version.GetCortinaTime(n.Config.NetworkID),
+				DurangoTime:                     version.GetDurangoTime(n.Config.NetworkID),
 				MinPercentConnectedStakeHealthy: n.Config.MinPercentConnectedStakeHealthy,
 				UseCurrentHeight:                n.Config.UseCurrentHeight,
 			},
@@ -943,6 +982,8 @@ func (n *Node) initAdminAPI() error {
 			NodeConfig:   n.Config,
 			VMManager:    n.VMManager,
 			VMRegistry:   n.VMRegistry,
+			DBManager:    n.DBManager,
+			DBStats:      n.dbStats,
 		},
 	)
 	if err != nil {
@@ -1292,6 +1333,10 @@ func (n *Node) Initialize(
 		return fmt.Errorf("problem initializing database: %w", err)
 	}
 
+	if err := n.initDatabaseStats(); err != nil { // Report the database's storage usage
+		return fmt.Errorf("problem initializing database stats: %w", err)
+	}
+
 	if err := n.initKeystoreAPI(); err != nil { // Start the Keystore API
 		return fmt.Errorf("couldn't initialize keystore API: %w", err)
 	}
@@ -1440,6 +1485,9 @@ func (n *Node) shutdown() {
 	n.Log.Info("cleaning up plugin runtimes")
 	n.runtimeManager.Stop(context.TODO())
 
+	if n.dbStats != nil {
+		n.dbStats.Stop()
+	}
 	if n.DBManager != nil {
 		if err := n.DBManager.Close(); err != nil {
 			n.Log.Warn("error during DB shutdown",
@@ -4,6 +4,7 @@
 package bloom
 
 import (
+	"encoding/binary"
 	"errors"
 	"sync"
 
@@ -12,7 +13,19 @@ import (
 	streakKnife "github.com/holiman/bloomfilter/v2"
 )
 
-var errMaxBytes = errors.New("too large")
+const (
+	// headerLen is the length of the magic bytes followed by k, n and m in the
+	// binary representation of a steakKnifeFilter
+	headerLen = 12 + 3*8
+	kOffset   = 12
+	mOffset   = 12 + 2*8
+	hashLen   = 48
+)
+
+var (
+	errMaxBytes      = errors.New("too large")
+	errInvalidFilter = errors.New("invalid filter")
+)
 
 type Filter interface {
 	// Add adds to filter, assumed thread safe
@@ -22,7 +35,19 @@ type Filter interface {
 	Check([]byte) bool
 }
 
+// MarshallableFilter is a Filter that can be sent to other nodes
+type MarshallableFilter interface {
+	Filter
+
+	// Marshal returns the binary representation of the filter
+	Marshal() ([]byte, error)
+}
+
 func New(maxN uint64, p float64, maxBytes uint64) (Filter, error) {
+	return NewMarshallable(maxN, p, maxBytes)
+}
+
+func NewMarshallable(maxN uint64, p float64, maxBytes uint64) (MarshallableFilter, error) {
 	neededBytes := bytesSteakKnifeFilter(maxN, p)
 	if neededBytes > maxBytes {
 		return nil, errMaxBytes
@@ -30,6 +55,40 @@ func New(maxN uint64, p float64, maxBytes uint64) (Filter, error) {
 	return newSteakKnifeFilter(maxN, p)
 }
 
+// Parse returns the filter that was marshalled into [b]. The size of the
+// filter described by [b] is verified before it is allocated, so [b] may be
+// provided by an untrusted peer.
+func Parse(b []byte, maxBytes uint64) (MarshallableFilter, error) {
+	size := uint64(len(b))
+	if size > maxBytes {
+		return nil, errMaxBytes
+	}
+	if size < headerLen+hashLen {
+		return nil, errInvalidFilter
+	}
+
+	var (
+		k = binary.LittleEndian.Uint64(b[kOffset:])
+		m = binary.LittleEndian.Uint64(b[mOffset:])
+		// Bound k and m by the size of [b] before calculating the expected
+		// size to avoid overflows.
+		maxWords = size / 8
+	)
+	if k > maxWords || m/64 > maxWords {
+		return nil, errInvalidFilter
+	}
+	expectedSize := headerLen + 8*k + 8*((m+63)/64) + hashLen
+	if expectedSize != size {
+		return nil, errInvalidFilter
+	}
+
+	filter := &streakKnife.Filter{}
+	if err := filter.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return &steakKnifeFilter{filter: filter}, nil
+}
+
 type steakKnifeFilter struct {
 	lock   sync.RWMutex
 	filter *streakKnife.Filter
@@ -48,12 +107,15 @@ func bytesSteakKnifeFilter(maxN uint64, p float64) uint64 {
 	return totalSize * 8 // 8 == sizeof(uint64))
 }
 
-func newSteakKnifeFilter(maxN uint64, p float64) (Filter, error) {
+func newSteakKnifeFilter(maxN uint64, p float64) (*steakKnifeFilter, error) {
 	m := streakKnife.OptimalM(maxN, p)
 	k := streakKnife.OptimalK(m, maxN)
 
 	filter, err := streakKnife.New(m, k)
-	return &steakKnifeFilter{filter: filter}, err
+	if err != nil {
+		return nil, err
+	}
+	return &steakKnifeFilter{filter: filter}, nil
 }
 
 func (f *steakKnifeFilter) Add(bl ...[]byte) {
@@ -75,3 +137,10 @@ func (f *steakKnifeFilter) Check(b []byte) bool {
 	_, _ = h.Write(b)
 	return f.filter.Contains(h)
 }
+
+func (f *steakKnifeFilter) Marshal() ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.filter.MarshalBinary()
+}
@@ -4,6 +4,8 @@
 package bloom
 
 import (
+	"encoding/binary"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -30,3 +32,60 @@ func TestNew(t *testing.T) {
 	checked = f.Check([]byte("bye"))
 	require.False(checked, "shouldn't have contained the key")
 }
+
+func TestMarshalParse(t *testing.T) {
+	var (
+		require         = require.New(t)
+		maxN     uint64 = 10000
+		p               = 0.1
+		maxBytes uint64 = 1 * units.MiB // 1 MiB
+	)
+	f, err := NewMarshallable(maxN, p, maxBytes)
+	require.NoError(err)
+
+	f.Add([]byte("hello"))
+
+	b, err := f.Marshal()
+	require.NoError(err)
+
+	parsed, err := Parse(b, maxBytes)
+	require.NoError(err)
+	require.True(parsed.Check([]byte("hello")), "should have contained the key")
+	require.False(parsed.Check([]byte("bye")), "shouldn't have contained the key")
+
+	_, err = Parse(b, uint64(len(b))-1)
+	require.ErrorIs(err, errMaxBytes)
+}
+
+func TestParseInvalid(t *testing.T) {
+	f, err := NewMarshallable(10000, 0.1, units.MiB)
+	require.NoError(t, err)
+	b, err := f.Marshal()
+	require.NoError(t, err)
+
+	tests := map[string][]byte{
+		"empty":     nil,
+		"truncated": b[:len(b)-1],
+		"huge k": func() []byte {
+			b := append([]byte{}, b...)
+			binary.LittleEndian.PutUint64(b[kOffset:], math.MaxUint64)
+			return b
+		}(),
+		"huge m": func() []byte {
+			b := append([]byte{}, b...)
+			binary.LittleEndian.PutUint64(b[mOffset:], math.MaxUint64)
+			return b
+		}(),
+		"corrupted": func() []byte {
+			b := append([]byte{}, b...)
+			b[headerLen]++
+			return b
+		}(),
+	}
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(b, units.MiB)
+			require.Error(t, err)
+		})
+	}
+}
@@ -14,14 +14,14 @@ import (
 
 // RPCChainVMProtocol should be bumped anytime changes are made which require
 // the plugin vm to upgrade to latest pepecoingo release to be compatible.
-const RPCChainVMProtocol uint = 26
+const RPCChainVMProtocol uint = 27
 
 // These are globals that describe network upgrades and node versions
 var (
 	Current = &Semantic{
 		Major: 1,
 		Minor: 10,
-		Patch: 1,
+		Patch: 2,
 	}
 	CurrentApp = &Application{
 		Major: Current.Major,
@@ -100,6 +100,14 @@ var (
 		constants.FujiID:    time.Date(2023, time.April, 6, 15, 0, 0, 0, time.UTC),
 	}
 	CortinaDefaultTime = time.Date(2020, time.December, 5, 5, 0, 0, 0, time.UTC)
+
+	// Durango isn't scheduled, so it is inactive on every network, including
+	// local networks, until its activation times are set.
+	DurangoTimes = map[uint32]time.Time{
+		constants.MainnetID: time.Date(10000, time.December, 1, 0, 0, 0, 0, time.UTC),
+		constants.FujiID:    time.Date(10000, time.December, 1, 0, 0, 0, 0, time.UTC),
+	}
+	DurangoDefaultTime = time.Date(10000, time.December, 1, 0, 0, 0, 0, time.UTC)
 )
 
 func init() {
@@ -172,6 +180,13 @@ func GetCortinaTime(networkID uint32) time.Time {
 	return CortinaDefaultTime
 }
 
+func GetDurangoTime(networkID uint32) time.Time {
+	if upgradeTime, exists := DurangoTimes[networkID]; exists {
+		return upgradeTime
+	}
+	return DurangoDefaultTime
+}
+
 func GetCompatibility(networkID uint32) Compatibility {
 	return NewCompatibility(
 		CurrentApp,
@@ -151,6 +151,23 @@ type state struct {
 	singletonDB                         database.Database
 }
 
+// DatabasePrefixes returns the prefixes, keyed by name, that the state stores
+// its data under in the database that is wrapped by the versiondb provided to
+// New.
+func DatabasePrefixes() map[string][]byte {
+	prefixes := map[string][]byte{
+		"status":    prefixdb.MakePrefix(statusPrefix),
+		"tx":        prefixdb.MakePrefix(txPrefix),
+		"blockID":   prefixdb.MakePrefix(blockIDPrefix),
+		"block":     prefixdb.MakePrefix(blockPrefix),
+		"singleton": prefixdb.MakePrefix(singletonPrefix),
+	}
+	for name, prefix := range avax.UTXOStatePrefixes(utxoPrefix) {
+		prefixes["utxo/"+name] = prefix
+	}
+	return prefixes
+}
+
 func New(
 	db *versiondb.Database,
 	parser blocks.Parser,
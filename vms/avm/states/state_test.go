@@ -113,6 +113,34 @@ func TestState(t *testing.T) {
 	ChainBlockTest(t, s)
 }
 
+func TestDatabasePrefixes(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	vdb := versiondb.New(db)
+	s, err := New(vdb, parser, prometheus.NewRegistry())
+	require.NoError(err)
+
+	s.AddUTXO(populatedUTXO)
+	s.AddTx(populatedTx)
+	s.AddBlock(populatedBlk)
+	require.NoError(s.Commit())
+
+	// Every key should be under one of the reported prefixes.
+	numKeys, err := database.Count(db)
+	require.NoError(err)
+	numPrefixedKeys := 0
+	for _, prefix := range DatabasePrefixes() {
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			numPrefixedKeys++
+		}
+		require.NoError(it.Error())
+		it.Release()
+	}
+	require.Equal(numKeys, numPrefixedKeys)
+}
+
 func TestDiff(t *testing.T) {
 	db := memdb.New()
 	vdb := versiondb.New(db)
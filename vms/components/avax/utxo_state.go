@@ -25,6 +25,23 @@ var (
 	indexPrefix = []byte("index")
 )
 
+// UTXOStatePrefixes returns the prefixes, keyed by name, that a UTXOState
+// stores its data under when it is created with a database that was wrapped,
+// in order, with [dbPrefixes] using prefixdb.New.
+func UTXOStatePrefixes(dbPrefixes ...[]byte) map[string][]byte {
+	return map[string][]byte{
+		"utxo":  makePrefix(dbPrefixes, utxoPrefix),
+		"index": makePrefix(dbPrefixes, indexPrefix),
+	}
+}
+
+func makePrefix(dbPrefixes [][]byte, prefix []byte) []byte {
+	prefixes := make([][]byte, 0, len(dbPrefixes)+1)
+	prefixes = append(prefixes, dbPrefixes...)
+	prefixes = append(prefixes, prefix)
+	return prefixdb.MakePrefix(prefixes...)
+}
+
 // UTXOState is a thin wrapper around a database to provide, caching,
 // serialization, and de-serialization for UTXOs.
 type UTXOState interface {
@@ -96,4 +96,18 @@ func TestUTXOState(t *testing.T) {
 	utxoIDs, err = s.UTXOIDs(addr[:], ids.Empty, 5)
 	require.NoError(err)
 	require.Equal([]ids.ID{utxoID}, utxoIDs)
+
+	// Every key should be under one of the reported prefixes.
+	numKeys, err := database.Count(db)
+	require.NoError(err)
+	numPrefixedKeys := 0
+	for _, prefix := range UTXOStatePrefixes() {
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			numPrefixedKeys++
+		}
+		require.NoError(it.Error())
+		it.Release()
+	}
+	require.Equal(numKeys, numPrefixedKeys)
 }
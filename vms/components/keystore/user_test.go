@@ -21,7 +21,9 @@ const testPassword = "ShaggyPassword1Zoinks!"
 func TestUserClosedDB(t *testing.T) {
 	require := require.New(t)
 
-	db, err := encdb.New([]byte(testPassword), memdb.New())
+	config, err := encdb.NewConfig()
+	require.NoError(err)
+	db, err := encdb.NewWithConfig([]byte(testPassword), memdb.New(), config)
 	require.NoError(err)
 
 	err = db.Close()
@@ -49,7 +51,9 @@ func TestUserClosedDB(t *testing.T) {
 func TestUser(t *testing.T) {
 	require := require.New(t)
 
-	db, err := encdb.New([]byte(testPassword), memdb.New())
+	config, err := encdb.NewConfig()
+	require.NoError(err)
+	db, err := encdb.NewWithConfig([]byte(testPassword), memdb.New(), config)
 	require.NoError(err)
 
 	u := NewUserFromDB(db)
@@ -26,6 +26,8 @@ func init() {
 	errs := wrappers.Errs{}
 	errs.Add(
 		lc.RegisterType(&Tx{}),
+		lc.RegisterType(&PullGossipRequest{}),
+		lc.RegisterType(&PullGossipResponse{}),
 		c.RegisterCodec(codecVersion, lc),
 	)
 	if errs.Errored() {
@@ -14,6 +14,8 @@ var _ Handler = NoopHandler{}
 
 type Handler interface {
 	HandleTx(nodeID ids.NodeID, requestID uint32, msg *Tx) error
+	HandlePullGossipRequest(nodeID ids.NodeID, requestID uint32, msg *PullGossipRequest) error
+	HandlePullGossipResponse(nodeID ids.NodeID, requestID uint32, msg *PullGossipResponse) error
 }
 
 type NoopHandler struct {
@@ -27,3 +29,19 @@ func (h NoopHandler) HandleTx(nodeID ids.NodeID, requestID uint32, _ *Tx) error
 	)
 	return nil
 }
+
+func (h NoopHandler) HandlePullGossipRequest(nodeID ids.NodeID, requestID uint32, _ *PullGossipRequest) error {
+	h.Log.Debug("dropping unexpected PullGossipRequest message",
+		zap.Stringer("nodeID", nodeID),
+		zap.Uint32("requestID", requestID),
+	)
+	return nil
+}
+
+func (h NoopHandler) HandlePullGossipResponse(nodeID ids.NodeID, requestID uint32, _ *PullGossipResponse) error {
+	h.Log.Debug("dropping unexpected PullGossipResponse message",
+		zap.Stringer("nodeID", nodeID),
+		zap.Uint32("requestID", requestID),
+	)
+	return nil
+}
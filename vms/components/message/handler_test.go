@@ -13,7 +13,9 @@ import (
 )
 
 type CounterHandler struct {
-	Tx int
+	Tx                 int
+	PullGossipRequest  int
+	PullGossipResponse int
 }
 
 func (h *CounterHandler) HandleTx(ids.NodeID, uint32, *Tx) error {
@@ -21,6 +23,16 @@ func (h *CounterHandler) HandleTx(ids.NodeID, uint32, *Tx) error {
 	return nil
 }
 
+func (h *CounterHandler) HandlePullGossipRequest(ids.NodeID, uint32, *PullGossipRequest) error {
+	h.PullGossipRequest++
+	return nil
+}
+
+func (h *CounterHandler) HandlePullGossipResponse(ids.NodeID, uint32, *PullGossipResponse) error {
+	h.PullGossipResponse++
+	return nil
+}
+
 func TestHandleTx(t *testing.T) {
 	require := require.New(t)
 
@@ -32,6 +44,20 @@ func TestHandleTx(t *testing.T) {
 	require.Equal(1, handler.Tx)
 }
 
+func TestHandlePullGossip(t *testing.T) {
+	require := require.New(t)
+
+	handler := CounterHandler{}
+
+	err := (&PullGossipRequest{}).Handle(&handler, ids.EmptyNodeID, 0)
+	require.NoError(err)
+	require.Equal(1, handler.PullGossipRequest)
+
+	err = (&PullGossipResponse{}).Handle(&handler, ids.EmptyNodeID, 0)
+	require.NoError(err)
+	require.Equal(1, handler.PullGossipResponse)
+}
+
 func TestNoopHandler(t *testing.T) {
 	handler := NoopHandler{
 		Log: logging.NoLog{},
@@ -39,4 +65,10 @@ func TestNoopHandler(t *testing.T) {
 
 	err := handler.HandleTx(ids.EmptyNodeID, 0, nil)
 	require.NoError(t, err)
+
+	err = handler.HandlePullGossipRequest(ids.EmptyNodeID, 0, nil)
+	require.NoError(t, err)
+
+	err = handler.HandlePullGossipResponse(ids.EmptyNodeID, 0, nil)
+	require.NoError(t, err)
 }
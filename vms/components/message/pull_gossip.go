@@ -0,0 +1,38 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+var (
+	_ Message = (*PullGossipRequest)(nil)
+	_ Message = (*PullGossipResponse)(nil)
+)
+
+// PullGossipRequest requests the txs in the recipient's mempool that are not
+// contained in [Filter].
+type PullGossipRequest struct {
+	message
+
+	// Filter is a marshalled bloom filter of the IDs of the txs known by the
+	// sender
+	Filter []byte `serialize:"true"`
+}
+
+func (msg *PullGossipRequest) Handle(handler Handler, nodeID ids.NodeID, requestID uint32) error {
+	return handler.HandlePullGossipRequest(nodeID, requestID, msg)
+}
+
+// PullGossipResponse contains the txs requested by a PullGossipRequest.
+type PullGossipResponse struct {
+	message
+
+	Txs [][]byte `serialize:"true"`
+}
+
+func (msg *PullGossipResponse) Handle(handler Handler, nodeID ids.NodeID, requestID uint32) error {
+	return handler.HandlePullGossipResponse(nodeID, requestID, msg)
+}
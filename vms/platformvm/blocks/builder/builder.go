@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"go.uber.org/zap"
 
 	"github.com/memeticofficial/pepecoingo/ids"
@@ -80,6 +82,11 @@ type builder struct {
 	// the validator set. When it goes off ResetTimer() is called, potentially
 	// triggering creation of a new block.
 	timer *timer.Timer
+
+	// pullGossipCancel stops the pull gossip loop, which closes
+	// pullGossipDone when it returns.
+	pullGossipCancel context.CancelFunc
+	pullGossipDone   chan struct{}
 }
 
 func New(
@@ -89,25 +96,40 @@ func New(
 	blkManager blockexecutor.Manager,
 	toEngine chan<- common.Message,
 	appSender common.AppSender,
-) Builder {
+	registerer prometheus.Registerer,
+) (Builder, error) {
 	builder := &builder{
 		Mempool:           mempool,
 		txBuilder:         txBuilder,
 		txExecutorBackend: txExecutorBackend,
 		blkManager:        blkManager,
 		toEngine:          toEngine,
+		pullGossipDone:    make(chan struct{}),
 	}
 
 	builder.timer = timer.NewTimer(builder.setNextBuildBlockTime)
 
-	builder.Network = NewNetwork(
+	network, err := NewNetwork(
 		txExecutorBackend.Ctx,
 		builder,
 		appSender,
+		registerer,
 	)
+	if err != nil {
+		return nil, err
+	}
+	builder.Network = network
+
+	var pullGossipCtx context.Context
+	pullGossipCtx, builder.pullGossipCancel = context.WithCancel(context.Background())
 
 	go txExecutorBackend.Ctx.Log.RecoverAndPanic(builder.timer.Dispatch)
-	return builder
+	go txExecutorBackend.Ctx.Log.RecoverAndPanic(func() {
+		defer close(builder.pullGossipDone)
+
+		builder.Network.PullGossip(pullGossipCtx)
+	})
+	return builder, nil
 }
 
 func (b *builder) SetPreference(blockID ids.ID) {
@@ -228,9 +250,13 @@ func (b *builder) buildBlock() (blocks.Block, error) {
 func (b *builder) Shutdown() {
 	// There is a potential deadlock if the timer is about to execute a timeout.
 	// So, the lock must be released before stopping the timer.
+	//
+	// Similarly, the pull gossip loop may be waiting for the lock.
 	ctx := b.txExecutorBackend.Ctx
 	ctx.Lock.Unlock()
 	b.timer.Stop()
+	b.pullGossipCancel()
+	<-b.pullGossipDone
 	ctx.Lock.Lock()
 }
 
@@ -18,6 +18,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/manager"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/database/versiondb"
 	"github.com/memeticofficial/pepecoingo/ids"
@@ -43,6 +44,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -169,7 +171,7 @@ func newEnvironment(t *testing.T) *environment {
 		panic(fmt.Errorf("failed to create metrics: %w", err))
 	}
 
-	res.mempool, err = mempool.NewMempool("mempool", registerer, res)
+	res.mempool, err = mempool.NewMempool("mempool", registerer, avaxAssetID, res)
 	if err != nil {
 		panic(fmt.Errorf("failed to create mempool: %w", err))
 	}
@@ -179,16 +181,21 @@ func newEnvironment(t *testing.T) *environment {
 		res.state,
 		&res.backend,
 		window,
+		stakinghistory.NewNoIndexer(memdb.New()),
 	)
 
-	res.Builder = New(
+	res.Builder, err = New(
 		res.mempool,
 		res.txBuilder,
 		&res.backend,
 		res.blkManager,
 		nil, // toEngine,
 		res.sender,
+		registerer,
 	)
+	if err != nil {
+		panic(fmt.Errorf("failed to create builder: %w", err))
+	}
 
 	res.Builder.SetPreference(genesisID)
 	addSubnet(res)
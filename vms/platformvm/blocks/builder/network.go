@@ -8,14 +8,25 @@ package builder
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"go.uber.org/zap"
 
 	"github.com/memeticofficial/pepecoingo/cache"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow"
 	"github.com/memeticofficial/pepecoingo/snow/engine/common"
+	"github.com/memeticofficial/pepecoingo/snow/validators"
+	"github.com/memeticofficial/pepecoingo/utils"
+	"github.com/memeticofficial/pepecoingo/utils/bloom"
+	"github.com/memeticofficial/pepecoingo/utils/sampler"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+	"github.com/memeticofficial/pepecoingo/utils/timer/mockable"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+	"github.com/memeticofficial/pepecoingo/version"
 	"github.com/memeticofficial/pepecoingo/vms/components/message"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 )
@@ -24,37 +35,90 @@ const (
 	// We allow [recentCacheSize] to be fairly large because we only store hashes
 	// in the cache, not entire transactions.
 	recentCacheSize = 512
+
+	// pullGossipFrequency is how often the mempool is compared against the
+	// mempool of a random peer
+	pullGossipFrequency = 10 * time.Second
+
+	// pullGossipThrottlingPeriod is the minimum amount of time between two
+	// pull gossip requests from the same peer that will be answered
+	pullGossipThrottlingPeriod = pullGossipFrequency / 2
+
+	// Parameters of the bloom filter of the txs known by this node that is sent
+	// in pull gossip requests
+	pullGossipBloomMaxItems                 = 8 * 1024
+	pullGossipBloomFalsePositiveProbability = 0.01
+	pullGossipBloomMaxBytes                 = 128 * units.KiB
+
+	// pullGossipMaxResponseSize is the maximum number of tx bytes sent in a
+	// response to a pull gossip request
+	pullGossipMaxResponseSize = 256 * units.KiB
 )
 
 var _ Network = (*network)(nil)
 
 type Network interface {
 	common.AppHandler
+	validators.Connector
 
 	// GossipTx gossips the transaction to some of the connected peers
 	GossipTx(tx *txs.Tx) error
+
+	// PullGossip periodically requests the txs that are missing from the
+	// mempool from a random connected peer until [ctx] is cancelled.
+	PullGossip(ctx context.Context)
 }
 
 type network struct {
 	ctx        *snow.Context
 	blkBuilder Builder
+	// pull gossip is only sent and served once the chain is bootstrapped, as
+	// txs are only added to the mempool once the chain is bootstrapped
+	bootstrapped *utils.Atomic[bool]
 
 	// gossip related attributes
 	appSender common.AppSender
 	recentTxs *cache.LRU[ids.ID, struct{}]
+
+	// pull gossip related attributes
+	metrics *pullGossipMetrics
+	clock   mockable.Clock
+
+	lock sync.Mutex
+	// peers that are currently connected
+	peers set.Set[ids.NodeID]
+	// ID of the next pull gossip request
+	requestID uint32
+	// Key: Request ID
+	// Value: Peer the pull gossip request was sent to
+	outstandingRequests map[uint32]ids.NodeID
+	// peers with an outstanding pull gossip request
+	requestedPeers set.Set[ids.NodeID]
+	// Key: Peer
+	// Value: Time the last pull gossip request from the peer was answered
+	lastRequestTimes map[ids.NodeID]time.Time
 }
 
 func NewNetwork(
 	ctx *snow.Context,
 	blkBuilder *builder,
 	appSender common.AppSender,
-) Network {
-	return &network{
-		ctx:        ctx,
-		blkBuilder: blkBuilder,
-		appSender:  appSender,
-		recentTxs:  &cache.LRU[ids.ID, struct{}]{Size: recentCacheSize},
+	registerer prometheus.Registerer,
+) (Network, error) {
+	metrics, err := newPullGossipMetrics("network", registerer)
+	if err != nil {
+		return nil, err
 	}
+	return &network{
+		ctx:                 ctx,
+		blkBuilder:          blkBuilder,
+		bootstrapped:        blkBuilder.txExecutorBackend.Bootstrapped,
+		appSender:           appSender,
+		recentTxs:           &cache.LRU[ids.ID, struct{}]{Size: recentCacheSize},
+		metrics:             metrics,
+		outstandingRequests: make(map[uint32]ids.NodeID),
+		lastRequestTimes:    make(map[ids.NodeID]time.Time),
+	}, nil
 }
 
 func (*network) CrossChainAppRequestFailed(context.Context, ids.ID, uint32) error {
@@ -75,21 +139,148 @@ func (*network) CrossChainAppResponse(context.Context, ids.ID, uint32, []byte) e
 	return nil
 }
 
-func (*network) AppRequestFailed(context.Context, ids.NodeID, uint32) error {
-	// This VM currently only supports gossiping of txs, so there are no
-	// requests.
+func (n *network) AppRequestFailed(_ context.Context, nodeID ids.NodeID, requestID uint32) error {
+	if !n.removeOutstandingRequest(nodeID, requestID) {
+		n.ctx.Log.Debug("dropping unexpected AppRequestFailed message",
+			zap.Stringer("nodeID", nodeID),
+			zap.Uint32("requestID", requestID),
+		)
+		return nil
+	}
+
+	n.metrics.requestsFailed.Inc()
 	return nil
 }
 
-func (*network) AppRequest(context.Context, ids.NodeID, uint32, time.Time, []byte) error {
-	// This VM currently only supports gossiping of txs, so there are no
-	// requests.
-	return nil
+func (n *network) AppRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, _ time.Time, msgBytes []byte) error {
+	n.ctx.Log.Debug("called AppRequest message handler",
+		zap.Stringer("nodeID", nodeID),
+		zap.Uint32("requestID", requestID),
+		zap.Int("messageLen", len(msgBytes)),
+	)
+
+	if !n.bootstrapped.Get() {
+		n.ctx.Log.Debug("dropping AppRequest message",
+			zap.String("reason", "not bootstrapped"),
+			zap.Stringer("nodeID", nodeID),
+		)
+		return nil
+	}
+
+	msgIntf, err := message.Parse(msgBytes)
+	if err != nil {
+		n.ctx.Log.Debug("dropping AppRequest message",
+			zap.String("reason", "failed to parse message"),
+		)
+		return nil
+	}
+
+	msg, ok := msgIntf.(*message.PullGossipRequest)
+	if !ok {
+		n.ctx.Log.Debug("dropping unexpected message",
+			zap.Stringer("nodeID", nodeID),
+		)
+		return nil
+	}
+
+	n.metrics.requestsReceived.Inc()
+	if !n.allowRequest(nodeID) {
+		n.ctx.Log.Debug("dropping pull gossip request",
+			zap.String("reason", "throttled"),
+			zap.Stringer("nodeID", nodeID),
+		)
+		n.metrics.requestsThrottled.Inc()
+		return nil
+	}
+
+	filter, err := bloom.Parse(msg.Filter, pullGossipBloomMaxBytes)
+	if err != nil {
+		n.ctx.Log.Debug("dropping pull gossip request",
+			zap.String("reason", "failed to parse bloom filter"),
+			zap.Stringer("nodeID", nodeID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	n.ctx.Lock.Lock()
+	mempoolTxs := n.blkBuilder.List()
+	n.ctx.Lock.Unlock()
+
+	var (
+		txsBytes = [][]byte{}
+		size     = 0
+	)
+	for _, info := range mempoolTxs {
+		txID := info.Tx.ID()
+		if filter.Check(txID[:]) {
+			continue
+		}
+
+		txBytes := info.Tx.Bytes()
+		if size+len(txBytes) > pullGossipMaxResponseSize {
+			continue
+		}
+		txsBytes = append(txsBytes, txBytes)
+		size += len(txBytes)
+	}
+
+	responseBytes, err := message.Build(&message.PullGossipResponse{
+		Txs: txsBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build PullGossipResponse message: %w", err)
+	}
+
+	n.metrics.txsSent.Add(float64(len(txsBytes)))
+	return n.appSender.SendAppResponse(ctx, nodeID, requestID, responseBytes)
 }
 
-func (*network) AppResponse(context.Context, ids.NodeID, uint32, []byte) error {
-	// This VM currently only supports gossiping of txs, so there are no
-	// requests.
+func (n *network) AppResponse(_ context.Context, nodeID ids.NodeID, requestID uint32, msgBytes []byte) error {
+	n.ctx.Log.Debug("called AppResponse message handler",
+		zap.Stringer("nodeID", nodeID),
+		zap.Uint32("requestID", requestID),
+		zap.Int("messageLen", len(msgBytes)),
+	)
+
+	if !n.removeOutstandingRequest(nodeID, requestID) {
+		n.ctx.Log.Debug("dropping unexpected AppResponse message",
+			zap.Stringer("nodeID", nodeID),
+			zap.Uint32("requestID", requestID),
+		)
+		return nil
+	}
+
+	msgIntf, err := message.Parse(msgBytes)
+	if err != nil {
+		n.ctx.Log.Debug("dropping AppResponse message",
+			zap.String("reason", "failed to parse message"),
+		)
+		return nil
+	}
+
+	msg, ok := msgIntf.(*message.PullGossipResponse)
+	if !ok {
+		n.ctx.Log.Debug("dropping unexpected message",
+			zap.Stringer("nodeID", nodeID),
+		)
+		return nil
+	}
+
+	n.metrics.txsReceived.Add(float64(len(msg.Txs)))
+	for _, txBytes := range msg.Txs {
+		tx, err := txs.Parse(txs.Codec, txBytes)
+		if err != nil {
+			n.ctx.Log.Verbo("received invalid tx",
+				zap.Stringer("nodeID", nodeID),
+				zap.Binary("tx", txBytes),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		n.issueTx(nodeID, tx)
+	}
 	return nil
 }
 
@@ -125,6 +316,13 @@ func (n *network) AppGossip(_ context.Context, nodeID ids.NodeID, msgBytes []byt
 		return nil
 	}
 
+	n.issueTx(nodeID, tx)
+	return nil
+}
+
+// issueTx attempts to add [tx], which was received from [nodeID], to the
+// mempool.
+func (n *network) issueTx(nodeID ids.NodeID, tx *txs.Tx) {
 	txID := tx.ID()
 
 	// We need to grab the context lock here to avoid racy behavior with
@@ -134,7 +332,7 @@ func (n *network) AppGossip(_ context.Context, nodeID ids.NodeID, msgBytes []byt
 
 	if reason := n.blkBuilder.GetDropReason(txID); reason != nil {
 		// If the tx is being dropped - just ignore it
-		return nil
+		return
 	}
 
 	// add to mempool
@@ -144,7 +342,6 @@ func (n *network) AppGossip(_ context.Context, nodeID ids.NodeID, msgBytes []byt
 			zap.Error(err),
 		)
 	}
-	return nil
 }
 
 func (n *network) GossipTx(tx *txs.Tx) error {
@@ -166,3 +363,148 @@ func (n *network) GossipTx(tx *txs.Tx) error {
 	}
 	return n.appSender.SendAppGossip(context.TODO(), msgBytes)
 }
+
+func (n *network) Connected(_ context.Context, nodeID ids.NodeID, _ *version.Application) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.peers.Add(nodeID)
+	return nil
+}
+
+func (n *network) Disconnected(_ context.Context, nodeID ids.NodeID) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.peers.Remove(nodeID)
+	delete(n.lastRequestTimes, nodeID)
+	return nil
+}
+
+func (n *network) PullGossip(ctx context.Context) {
+	ticker := time.NewTicker(pullGossipFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := n.pullGossip(ctx); err != nil {
+				n.ctx.Log.Warn("failed to send pull gossip request",
+					zap.Error(err),
+				)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pullGossip sends a bloom filter of the txs in the mempool to a random peer
+// that doesn't have an outstanding request, to request the txs that are
+// missing from the mempool. Nothing is requested before the chain is
+// bootstrapped.
+func (n *network) pullGossip(ctx context.Context) error {
+	if !n.bootstrapped.Get() {
+		return nil
+	}
+
+	n.lock.Lock()
+	peers := make([]ids.NodeID, 0, n.peers.Len())
+	for nodeID := range n.peers {
+		if !n.requestedPeers.Contains(nodeID) {
+			peers = append(peers, nodeID)
+		}
+	}
+	n.lock.Unlock()
+
+	if len(peers) == 0 {
+		return nil
+	}
+
+	s := sampler.NewUniform()
+	s.Initialize(uint64(len(peers)))
+	index, err := s.Next()
+	if err != nil {
+		return err
+	}
+	nodeID := peers[index]
+
+	filter, err := bloom.NewMarshallable(
+		pullGossipBloomMaxItems,
+		pullGossipBloomFalsePositiveProbability,
+		pullGossipBloomMaxBytes,
+	)
+	if err != nil {
+		return err
+	}
+
+	n.ctx.Lock.Lock()
+	mempoolTxs := n.blkBuilder.List()
+	n.ctx.Lock.Unlock()
+
+	for _, info := range mempoolTxs {
+		txID := info.Tx.ID()
+		filter.Add(txID[:])
+	}
+
+	filterBytes, err := filter.Marshal()
+	if err != nil {
+		return err
+	}
+	msgBytes, err := message.Build(&message.PullGossipRequest{
+		Filter: filterBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build PullGossipRequest message: %w", err)
+	}
+
+	n.lock.Lock()
+	requestID := n.requestID
+	n.requestID++
+	n.outstandingRequests[requestID] = nodeID
+	n.requestedPeers.Add(nodeID)
+	n.lock.Unlock()
+
+	n.ctx.Log.Debug("sending pull gossip request",
+		zap.Stringer("nodeID", nodeID),
+		zap.Uint32("requestID", requestID),
+		zap.Int("numTxs", len(mempoolTxs)),
+	)
+
+	nodeIDs := set.NewSet[ids.NodeID](1)
+	nodeIDs.Add(nodeID)
+
+	n.metrics.requestsSent.Inc()
+	return n.appSender.SendAppRequest(ctx, nodeIDs, requestID, msgBytes)
+}
+
+// removeOutstandingRequest returns true if [requestID] was an outstanding pull
+// gossip request sent to [nodeID]. If so, the request is no longer considered
+// outstanding.
+func (n *network) removeOutstandingRequest(nodeID ids.NodeID, requestID uint32) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	requestedNodeID, ok := n.outstandingRequests[requestID]
+	if !ok || requestedNodeID != nodeID {
+		return false
+	}
+
+	delete(n.outstandingRequests, requestID)
+	n.requestedPeers.Remove(nodeID)
+	return true
+}
+
+// allowRequest returns true if a pull gossip request from [nodeID] should be
+// answered.
+func (n *network) allowRequest(nodeID ids.NodeID) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	now := n.clock.Time()
+	if lastRequestTime, ok := n.lastRequestTimes[nodeID]; ok && now.Sub(lastRequestTime) < pullGossipThrottlingPeriod {
+		return false
+	}
+	n.lastRequestTimes[nodeID] = now
+	return true
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package builder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+type pullGossipMetrics struct {
+	requestsSent      prometheus.Counter
+	requestsFailed    prometheus.Counter
+	requestsReceived  prometheus.Counter
+	requestsThrottled prometheus.Counter
+	txsSent           prometheus.Counter
+	txsReceived       prometheus.Counter
+}
+
+func newPullGossipMetrics(namespace string, registerer prometheus.Registerer) (*pullGossipMetrics, error) {
+	m := &pullGossipMetrics{
+		requestsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_requests_sent",
+			Help:      "Number of pull gossip requests sent to peers",
+		}),
+		requestsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_requests_failed",
+			Help:      "Number of pull gossip requests sent to peers that failed",
+		}),
+		requestsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_requests_received",
+			Help:      "Number of pull gossip requests received from peers",
+		}),
+		requestsThrottled: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_requests_throttled",
+			Help:      "Number of pull gossip requests received from peers that were dropped due to throttling",
+		}),
+		txsSent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_txs_sent",
+			Help:      "Number of txs sent in response to pull gossip requests",
+		}),
+		txsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pull_gossip_txs_received",
+			Help:      "Number of txs received in response to pull gossip requests",
+		}),
+	}
+
+	errs := wrappers.Errs{}
+	errs.Add(
+		registerer.Register(m.requestsSent),
+		registerer.Register(m.requestsFailed),
+		registerer.Register(m.requestsReceived),
+		registerer.Register(m.requestsThrottled),
+		registerer.Register(m.txsSent),
+		registerer.Register(m.txsReceived),
+	)
+	return m, errs.Err
+}
@@ -6,12 +6,16 @@ package builder
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/bloom"
 	"github.com/memeticofficial/pepecoingo/utils/constants"
 	"github.com/memeticofficial/pepecoingo/utils/crypto/secp256k1"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+	"github.com/memeticofficial/pepecoingo/version"
 	"github.com/memeticofficial/pepecoingo/vms/components/message"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 
@@ -149,3 +153,259 @@ func TestMempoolNewLocaTxIsGossiped(t *testing.T) {
 
 	require.True(gossipedBytes == nil)
 }
+
+// show that a pull gossip request contains the txs in the mempool and that the
+// txs in the response are added to the mempool
+func TestPullGossipRequest(t *testing.T) {
+	require := require.New(t)
+
+	env := newEnvironment(t)
+	env.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(shutdownEnvironment(env))
+	}()
+
+	env.sender.SendAppGossipF = func(context.Context, []byte) error {
+		return nil
+	}
+
+	var (
+		requestedNodeIDs set.Set[ids.NodeID]
+		requestID        uint32
+		requestBytes     []byte
+	)
+	env.sender.SendAppRequestF = func(_ context.Context, nodeIDs set.Set[ids.NodeID], id uint32, b []byte) error {
+		requestedNodeIDs = nodeIDs
+		requestID = id
+		requestBytes = b
+		return nil
+	}
+
+	// add a tx to the mempool
+	knownTx := getValidTx(env.txBuilder, t)
+	require.NoError(env.Builder.AddUnverifiedTx(knownTx))
+
+	n := env.Builder.(*builder).Network.(*network)
+	nodeID := ids.GenerateTestNodeID()
+	env.ctx.Lock.Unlock()
+	defer env.ctx.Lock.Lock()
+
+	// no request is sent if there are no peers
+	require.NoError(n.pullGossip(context.Background()))
+	require.Nil(requestBytes)
+
+	require.NoError(n.Connected(context.Background(), nodeID, version.CurrentApp))
+	require.NoError(n.pullGossip(context.Background()))
+	require.Equal(set.Set[ids.NodeID]{nodeID: struct{}{}}, requestedNodeIDs)
+
+	// show the request contains a bloom filter of the mempool
+	requestIntf, err := message.Parse(requestBytes)
+	require.NoError(err)
+	request, ok := requestIntf.(*message.PullGossipRequest)
+	require.True(ok)
+
+	filter, err := bloom.Parse(request.Filter, pullGossipBloomMaxBytes)
+	require.NoError(err)
+	knownTxID := knownTx.ID()
+	require.True(filter.Check(knownTxID[:]))
+
+	// no new request is sent to a peer with an outstanding request
+	requestBytes = nil
+	require.NoError(n.pullGossip(context.Background()))
+	require.Nil(requestBytes)
+
+	env.ctx.Lock.Lock()
+	env.Builder.Remove([]*txs.Tx{knownTx})
+	missingTx := getValidTx(env.txBuilder, t)
+	env.ctx.Lock.Unlock()
+
+	responseBytes, err := message.Build(&message.PullGossipResponse{
+		Txs: [][]byte{missingTx.Bytes()},
+	})
+	require.NoError(err)
+
+	// responses that weren't requested are dropped
+	require.NoError(n.AppResponse(context.Background(), nodeID, requestID+1, responseBytes))
+	require.NoError(n.AppResponse(context.Background(), ids.GenerateTestNodeID(), requestID, responseBytes))
+	env.ctx.Lock.Lock()
+	require.False(env.Builder.Has(missingTx.ID()))
+	env.ctx.Lock.Unlock()
+
+	// the txs in the response are added to the mempool
+	require.NoError(n.AppResponse(context.Background(), nodeID, requestID, responseBytes))
+	env.ctx.Lock.Lock()
+	require.True(env.Builder.Has(missingTx.ID()))
+	env.ctx.Lock.Unlock()
+
+	// a duplicate response is dropped
+	require.NoError(n.AppResponse(context.Background(), nodeID, requestID, responseBytes))
+
+	// once the response is received, the peer can be requested again
+	requestBytes = nil
+	require.NoError(n.pullGossip(context.Background()))
+	require.NotNil(requestBytes)
+
+	// a failed request allows the peer to be requested again
+	require.NoError(n.AppRequestFailed(context.Background(), nodeID, requestID))
+	requestBytes = nil
+	require.NoError(n.pullGossip(context.Background()))
+	require.NotNil(requestBytes)
+
+	// disconnected peers aren't requested
+	require.NoError(n.AppRequestFailed(context.Background(), nodeID, requestID))
+	require.NoError(n.Disconnected(context.Background(), nodeID))
+	requestBytes = nil
+	require.NoError(n.pullGossip(context.Background()))
+	require.Nil(requestBytes)
+}
+
+// show that a pull gossip request is answered with the txs missing from the
+// requester's mempool and that requests are throttled per peer
+func TestPullGossipResponse(t *testing.T) {
+	require := require.New(t)
+
+	env := newEnvironment(t)
+	env.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(shutdownEnvironment(env))
+	}()
+
+	env.sender.SendAppGossipF = func(context.Context, []byte) error {
+		return nil
+	}
+
+	var responseBytes []byte
+	env.sender.SendAppResponseF = func(_ context.Context, _ ids.NodeID, _ uint32, b []byte) error {
+		responseBytes = b
+		return nil
+	}
+
+	// add a tx to the mempool
+	tx := getValidTx(env.txBuilder, t)
+	txID := tx.ID()
+	require.NoError(env.Builder.AddUnverifiedTx(tx))
+
+	n := env.Builder.(*builder).Network.(*network)
+	n.clock.Set(time.Now())
+	nodeID := ids.GenerateTestNodeID()
+	env.ctx.Lock.Unlock()
+	defer env.ctx.Lock.Lock()
+
+	filter, err := bloom.NewMarshallable(
+		pullGossipBloomMaxItems,
+		pullGossipBloomFalsePositiveProbability,
+		pullGossipBloomMaxBytes,
+	)
+	require.NoError(err)
+	emptyFilterBytes, err := filter.Marshal()
+	require.NoError(err)
+	filter.Add(txID[:])
+	filterBytes, err := filter.Marshal()
+	require.NoError(err)
+
+	// the requester is missing the tx
+	requestBytes, err := message.Build(&message.PullGossipRequest{
+		Filter: emptyFilterBytes,
+	})
+	require.NoError(err)
+	require.NoError(n.AppRequest(context.Background(), nodeID, 0, time.Time{}, requestBytes))
+
+	responseIntf, err := message.Parse(responseBytes)
+	require.NoError(err)
+	response, ok := responseIntf.(*message.PullGossipResponse)
+	require.True(ok)
+	require.Equal([][]byte{tx.Bytes()}, response.Txs)
+
+	// the next request from the same peer is throttled
+	requestBytes, err = message.Build(&message.PullGossipRequest{
+		Filter: filterBytes,
+	})
+	require.NoError(err)
+	responseBytes = nil
+	require.NoError(n.AppRequest(context.Background(), nodeID, 1, time.Time{}, requestBytes))
+	require.Nil(responseBytes)
+
+	// the requester already knows the tx
+	n.clock.Set(n.clock.Time().Add(pullGossipThrottlingPeriod))
+	require.NoError(n.AppRequest(context.Background(), nodeID, 2, time.Time{}, requestBytes))
+
+	responseIntf, err = message.Parse(responseBytes)
+	require.NoError(err)
+	response, ok = responseIntf.(*message.PullGossipResponse)
+	require.True(ok)
+	require.Empty(response.Txs)
+
+	// requests with an invalid filter are dropped
+	n.clock.Set(n.clock.Time().Add(pullGossipThrottlingPeriod))
+	requestBytes, err = message.Build(&message.PullGossipRequest{
+		Filter: []byte{1, 2, 3},
+	})
+	require.NoError(err)
+	responseBytes = nil
+	require.NoError(n.AppRequest(context.Background(), nodeID, 3, time.Time{}, requestBytes))
+	require.Nil(responseBytes)
+}
+
+// show that pull gossip requests are neither sent nor answered before the chain
+// is bootstrapped
+func TestPullGossipNotBootstrapped(t *testing.T) {
+	require := require.New(t)
+
+	env := newEnvironment(t)
+	env.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(shutdownEnvironment(env))
+	}()
+
+	env.sender.SendAppGossipF = func(context.Context, []byte) error {
+		return nil
+	}
+
+	var requestBytes, responseBytes []byte
+	env.sender.SendAppRequestF = func(_ context.Context, _ set.Set[ids.NodeID], _ uint32, b []byte) error {
+		requestBytes = b
+		return nil
+	}
+	env.sender.SendAppResponseF = func(_ context.Context, _ ids.NodeID, _ uint32, b []byte) error {
+		responseBytes = b
+		return nil
+	}
+
+	// add a tx to the mempool
+	require.NoError(env.Builder.AddUnverifiedTx(getValidTx(env.txBuilder, t)))
+
+	n := env.Builder.(*builder).Network.(*network)
+	n.clock.Set(time.Now())
+	nodeID := ids.GenerateTestNodeID()
+	env.isBootstrapped.Set(false)
+	env.ctx.Lock.Unlock()
+	defer env.ctx.Lock.Lock()
+
+	require.NoError(n.Connected(context.Background(), nodeID, version.CurrentApp))
+
+	filter, err := bloom.NewMarshallable(
+		pullGossipBloomMaxItems,
+		pullGossipBloomFalsePositiveProbability,
+		pullGossipBloomMaxBytes,
+	)
+	require.NoError(err)
+	filterBytes, err := filter.Marshal()
+	require.NoError(err)
+	msgBytes, err := message.Build(&message.PullGossipRequest{
+		Filter: filterBytes,
+	})
+	require.NoError(err)
+
+	// no request is sent or answered
+	require.NoError(n.pullGossip(context.Background()))
+	require.Nil(requestBytes)
+	require.NoError(n.AppRequest(context.Background(), nodeID, 0, time.Time{}, msgBytes))
+	require.Nil(responseBytes)
+
+	// once bootstrapped, requests are sent and answered
+	env.isBootstrapped.Set(true)
+	require.NoError(n.pullGossip(context.Background()))
+	require.NotNil(requestBytes)
+	require.NoError(n.AppRequest(context.Background(), nodeID, 0, time.Time{}, msgBytes))
+	require.NotNil(responseBytes)
+}
@@ -36,6 +36,7 @@ func init() {
 			RegisterApricotBlockTypes(c),
 			txs.RegisterUnsignedTxsTypes(c),
 			RegisterBanffBlockTypes(c),
+			txs.RegisterDurangoUnsignedTxsTypes(c),
 		)
 	}
 	errs.Add(
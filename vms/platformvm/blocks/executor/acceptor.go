@@ -9,12 +9,14 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/memeticofficial/pepecoingo/chains/atomic"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/choices"
 	"github.com/memeticofficial/pepecoingo/utils"
 	"github.com/memeticofficial/pepecoingo/utils/window"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 )
 
@@ -32,6 +34,7 @@ type acceptor struct {
 	metrics          metrics.Metrics
 	recentlyAccepted window.Window[ids.ID]
 	bootstrapped     *utils.Atomic[bool]
+	stakingHistory   stakinghistory.Indexer
 }
 
 func (a *acceptor) BanffAbortBlock(b *blocks.BanffAbortBlock) error {
@@ -195,7 +198,7 @@ func (a *acceptor) abortBlock(b blocks.Block) error {
 		}
 	}
 
-	return a.optionBlock(b, parentState.statelessBlock)
+	return a.optionBlock(b, parentState, false /*=committed*/)
 }
 
 func (a *acceptor) commitBlock(b blocks.Block) error {
@@ -213,11 +216,12 @@ func (a *acceptor) commitBlock(b blocks.Block) error {
 		}
 	}
 
-	return a.optionBlock(b, parentState.statelessBlock)
+	return a.optionBlock(b, parentState, true /*=committed*/)
 }
 
-func (a *acceptor) optionBlock(b, parent blocks.Block) error {
+func (a *acceptor) optionBlock(b blocks.Block, parentState *blockState, committed bool) error {
 	blkID := b.ID()
+	parent := parentState.statelessBlock
 	parentID := parent.ID()
 
 	defer func() {
@@ -240,10 +244,48 @@ func (a *acceptor) optionBlock(b, parent blocks.Block) error {
 	if !ok {
 		return fmt.Errorf("%w %s", errMissingBlockState, blkID)
 	}
+
 	if err := blkState.onAcceptState.Apply(a.state); err != nil {
 		return err
 	}
-	return a.state.Commit()
+
+	if stakingRecord := parentState.stakingRecord; stakingRecord != nil {
+		record, err := completeStakingRecord(
+			stakingRecord,
+			committed,
+			b.Height(),
+			a.state,
+		)
+		if err != nil {
+			return err
+		}
+		if err := a.stakingHistory.Accept(record); err != nil {
+			return fmt.Errorf("failed to index staking record %s: %w", record.RewardTxID, err)
+		}
+	}
+
+	defer a.state.Abort()
+	defer a.stakingHistory.Abort()
+
+	batch, err := a.state.CommitBatch()
+	if err != nil {
+		return fmt.Errorf(
+			"failed to commit VM's database for block %s: %w",
+			blkID,
+			err,
+		)
+	}
+	stakingHistoryBatch, err := a.stakingHistory.CommitBatch()
+	if err != nil {
+		return fmt.Errorf(
+			"failed to commit staking history for block %s: %w",
+			blkID,
+			err,
+		)
+	}
+
+	// Write the staking history atomically with the VM's state.
+	return atomic.WriteAll(batch, stakingHistoryBatch)
 }
 
 func (a *acceptor) proposalBlock(b blocks.Block) {
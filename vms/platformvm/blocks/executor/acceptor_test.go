@@ -13,6 +13,7 @@ import (
 
 	"github.com/memeticofficial/pepecoingo/chains/atomic"
 	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow"
 	"github.com/memeticofficial/pepecoingo/snow/choices"
@@ -23,6 +24,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/components/verify"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 	"github.com/memeticofficial/pepecoingo/vms/secp256k1fx"
@@ -284,7 +286,8 @@ func TestAcceptorVisitCommitBlock(t *testing.T) {
 			MaxSize: 1,
 			TTL:     time.Hour,
 		}),
-		bootstrapped: &utils.Atomic[bool]{},
+		bootstrapped:   &utils.Atomic[bool]{},
+		stakingHistory: stakinghistory.NewNoIndexer(memdb.New()),
 	}
 
 	blk, err := blocks.NewApricotCommitBlock(parentID, 1 /*height*/)
@@ -328,6 +331,7 @@ func TestAcceptorVisitCommitBlock(t *testing.T) {
 
 	// Set [blk]'s state in the map as though it had been verified.
 	acceptor.backend.blkIDToState[parentID] = parentState
+	batch := database.NewMockBatch(ctrl)
 	onAcceptState := state.NewMockDiff(ctrl)
 	acceptor.backend.blkIDToState[blkID] = &blockState{
 		onAcceptState: onAcceptState,
@@ -347,7 +351,10 @@ func TestAcceptorVisitCommitBlock(t *testing.T) {
 		s.EXPECT().AddStatelessBlock(blk, choices.Accepted).Times(1),
 
 		onAcceptState.EXPECT().Apply(s).Times(1),
-		s.EXPECT().Commit().Return(nil).Times(1),
+		s.EXPECT().CommitBatch().Return(batch, nil).Times(1),
+		batch.EXPECT().Inner().Return(batch).Times(1),
+		batch.EXPECT().Write().Return(nil).Times(1),
+		s.EXPECT().Abort().Times(1),
 	)
 
 	err = acceptor.ApricotCommitBlock(blk)
@@ -380,7 +387,8 @@ func TestAcceptorVisitAbortBlock(t *testing.T) {
 			MaxSize: 1,
 			TTL:     time.Hour,
 		}),
-		bootstrapped: &utils.Atomic[bool]{},
+		bootstrapped:   &utils.Atomic[bool]{},
+		stakingHistory: stakinghistory.NewNoIndexer(memdb.New()),
 	}
 
 	blk, err := blocks.NewApricotAbortBlock(parentID, 1 /*height*/)
@@ -424,6 +432,7 @@ func TestAcceptorVisitAbortBlock(t *testing.T) {
 
 	// Set [blk]'s state in the map as though it had been verified.
 	acceptor.backend.blkIDToState[parentID] = parentState
+	batch := database.NewMockBatch(ctrl)
 
 	onAcceptState := state.NewMockDiff(ctrl)
 	acceptor.backend.blkIDToState[blkID] = &blockState{
@@ -444,7 +453,10 @@ func TestAcceptorVisitAbortBlock(t *testing.T) {
 		s.EXPECT().AddStatelessBlock(blk, choices.Accepted).Times(1),
 
 		onAcceptState.EXPECT().Apply(s).Times(1),
-		s.EXPECT().Commit().Return(nil).Times(1),
+		s.EXPECT().CommitBatch().Return(batch, nil).Times(1),
+		batch.EXPECT().Inner().Return(batch).Times(1),
+		batch.EXPECT().Write().Return(nil).Times(1),
+		s.EXPECT().Abort().Times(1),
 	)
 
 	err = acceptor.ApricotAbortBlock(blk)
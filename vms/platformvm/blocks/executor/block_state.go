@@ -10,6 +10,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 )
 
@@ -22,6 +23,8 @@ type proposalBlockState struct {
 	initiallyPreferCommit bool
 	onCommitState         state.Diff
 	onAbortState          state.Diff
+	// stakingRecord is only set if the proposal is a RewardValidatorTx.
+	stakingRecord *stakinghistory.Record
 }
 
 // The state of a block.
@@ -19,6 +19,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/codec"
 	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
 	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/database/versiondb"
 	"github.com/memeticofficial/pepecoingo/ids"
@@ -44,6 +45,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -202,7 +204,7 @@ func newEnvironment(t *testing.T, ctrl *gomock.Controller) *environment {
 	metrics := metrics.Noop
 
 	var err error
-	res.mempool, err = mempool.NewMempool("mempool", registerer, res)
+	res.mempool, err = mempool.NewMempool("mempool", registerer, avaxAssetID, res)
 	if err != nil {
 		panic(fmt.Errorf("failed to create mempool: %w", err))
 	}
@@ -214,6 +216,7 @@ func newEnvironment(t *testing.T, ctrl *gomock.Controller) *environment {
 			res.state,
 			res.backend,
 			window,
+			stakinghistory.NewNoIndexer(memdb.New()),
 		)
 		addSubnet(res)
 	} else {
@@ -223,6 +226,7 @@ func newEnvironment(t *testing.T, ctrl *gomock.Controller) *environment {
 			res.mockedState,
 			res.backend,
 			window,
+			stakinghistory.NewNoIndexer(memdb.New()),
 		)
 		// we do not add any subnet to state, since we can mock
 		// whatever we need
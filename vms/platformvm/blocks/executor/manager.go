@@ -9,6 +9,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/utils/window"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs/executor"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs/mempool"
@@ -32,6 +33,7 @@ func NewManager(
 	s state.State,
 	txExecutorBackend *executor.Backend,
 	recentlyAccepted window.Window[ids.ID],
+	stakingHistory stakinghistory.Indexer,
 ) Manager {
 	backend := &backend{
 		Mempool:      mempool,
@@ -52,6 +54,7 @@ func NewManager(
 			metrics:          metrics,
 			recentlyAccepted: recentlyAccepted,
 			bootstrapped:     txExecutorBackend.Bootstrapped,
+			stakingHistory:   stakingHistory,
 		},
 		rejector: &rejector{backend: backend},
 	}
@@ -108,7 +108,7 @@ func TestApricotProposalBlockTimeVerification(t *testing.T) {
 		StartTime: utx.StartTime(),
 		EndTime:   chainTime,
 	}, nil)
-	onParentAccept.EXPECT().GetTx(addValTx.ID()).Return(addValTx, status.Committed, nil)
+	onParentAccept.EXPECT().GetTx(addValTx.ID()).Return(addValTx, status.Committed, nil).Times(2)
 	onParentAccept.EXPECT().GetCurrentSupply(constants.PrimaryNetworkID).Return(uint64(1000), nil).AnyTimes()
 	onParentAccept.EXPECT().GetDelegateeReward(constants.PrimaryNetworkID, utx.NodeID()).Return(uint64(0), nil).AnyTimes()
 
@@ -219,7 +219,7 @@ func TestBanffProposalBlockTimeVerification(t *testing.T) {
 		StartTime: unsignedNextStakerTx.StartTime(),
 		EndTime:   chainTime,
 	}, nil)
-	onParentAccept.EXPECT().GetTx(nextStakerTxID).Return(nextStakerTx, status.Processing, nil)
+	onParentAccept.EXPECT().GetTx(nextStakerTxID).Return(nextStakerTx, status.Processing, nil).Times(2)
 
 	currentStakersIt := state.NewMockStakerIterator(ctrl)
 	currentStakersIt.EXPECT().Next().Return(true).AnyTimes()
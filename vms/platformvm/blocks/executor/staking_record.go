@@ -0,0 +1,104 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package executor
+
+import (
+	"fmt"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/math"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+	"github.com/memeticofficial/pepecoingo/vms/components/avax"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
+	"github.com/memeticofficial/pepecoingo/vms/secp256k1fx"
+)
+
+// newStakingRecord returns the record of [rewardTxID] removing [staker].
+// The outcome of the record is populated by [completeStakingRecord] once one
+// of the options of the proposal is accepted.
+func newStakingRecord(
+	rewardTxID ids.ID,
+	staker *state.Staker,
+	uptime float64,
+	chain state.Chain,
+) (*stakinghistory.Record, error) {
+	stakerTx, _, err := chain.GetTx(staker.TxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rewarded staker tx %s: %w", staker.TxID, err)
+	}
+
+	record := &stakinghistory.Record{
+		RewardTxID:      rewardTxID,
+		StakerTxID:      staker.TxID,
+		NodeID:          staker.NodeID,
+		SubnetID:        staker.SubnetID,
+		StartTime:       uint64(staker.StartTime.Unix()),
+		EndTime:         uint64(staker.EndTime.Unix()),
+		Weight:          staker.Weight,
+		PotentialReward: staker.PotentialReward,
+		Uptime:          uint32(uptime * reward.PercentDenominator),
+	}
+
+	var owners []fx.Owner
+	switch uStakerTx := stakerTx.Unsigned.(type) {
+	case txs.ValidatorTx:
+		owners = []fx.Owner{
+			uStakerTx.ValidationRewardsOwner(),
+			uStakerTx.DelegationRewardsOwner(),
+		}
+	case txs.DelegatorTx:
+		record.IsDelegator = true
+		owners = []fx.Owner{
+			uStakerTx.RewardsOwner(),
+		}
+	}
+	addrs := set.Set[ids.ShortID]{}
+	for _, owner := range owners {
+		outputOwners, ok := owner.(*secp256k1fx.OutputOwners)
+		if !ok {
+			continue
+		}
+		for _, addr := range outputOwners.Addrs {
+			if addrs.Contains(addr) {
+				continue
+			}
+			addrs.Add(addr)
+			record.RewardOwners = append(record.RewardOwners, addr)
+		}
+	}
+	return record, nil
+}
+
+// completeStakingRecord returns a copy of [record] with the outcome of the
+// accepted option populated.
+func completeStakingRecord(
+	record *stakinghistory.Record,
+	rewarded bool,
+	height uint64,
+	chain state.Chain,
+) (*stakinghistory.Record, error) {
+	rewardUTXOs, err := chain.GetRewardUTXOs(record.StakerTxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reward UTXOs of %s: %w", record.StakerTxID, err)
+	}
+
+	completed := *record
+	completed.Rewarded = rewarded
+	completed.Height = height
+	for _, utxo := range rewardUTXOs {
+		out, ok := utxo.Out.(avax.Amounter)
+		if !ok {
+			continue
+		}
+		completed.Reward, err = math.Add64(completed.Reward, out.Amount())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &completed, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -370,6 +371,20 @@ func (v *verifier) proposalBlock(
 		return err
 	}
 
+	var stakingRecord *stakinghistory.Record
+	if txExecutor.RewardedStaker != nil {
+		var err error
+		stakingRecord, err = newStakingRecord(
+			b.Tx.ID(),
+			txExecutor.RewardedStaker,
+			txExecutor.Uptime,
+			onCommitState,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	onCommitState.AddTx(b.Tx, status.Committed)
 	onAbortState.AddTx(b.Tx, status.Aborted)
 
@@ -379,6 +394,7 @@ func (v *verifier) proposalBlock(
 			onCommitState:         onCommitState,
 			onAbortState:          onAbortState,
 			initiallyPreferCommit: txExecutor.PrefersCommit,
+			stakingRecord:         stakingRecord,
 		},
 		statelessBlock: b,
 		// It is safe to use [b.onAbortState] here because the timestamp will
@@ -79,6 +79,18 @@ type Client interface {
 	GetPendingValidators(ctx context.Context, subnetID ids.ID, nodeIDs []ids.NodeID, options ...rpc.Option) ([]interface{}, []interface{}, error)
 	// GetCurrentSupply returns an upper bound on the supply of AVAX in the system
 	GetCurrentSupply(ctx context.Context, subnetID ids.ID, options ...rpc.Option) (uint64, error)
+	// EstimateReward returns the reward for staking [amount] on the subnet with ID [subnetID] for [duration],
+	// starting at [startTime], and its split if it's delegated to a validator charging [delegationFeeRate] percent.
+	// A zero [startTime] means the current chain time.
+	EstimateReward(
+		ctx context.Context,
+		subnetID ids.ID,
+		amount uint64,
+		duration time.Duration,
+		startTime time.Time,
+		delegationFeeRate float32,
+		options ...rpc.Option,
+	) (*EstimateRewardReply, error)
 	// SampleValidators returns the nodeIDs of a sample of [sampleSize] validators from the current validator set for subnet with ID [subnetID]
 	SampleValidators(ctx context.Context, subnetID ids.ID, sampleSize uint16, options ...rpc.Option) ([]ids.NodeID, error)
 	// AddValidator issues a transaction to add a validator to the primary network
@@ -248,6 +260,33 @@ type Client interface {
 	//
 	// Deprecated: GetRewardUTXOs should be fetched from a dedicated indexer.
 	GetRewardUTXOs(context.Context, *api.GetTxArgs, ...rpc.Option) ([][]byte, error)
+	// GetStakingHistory returns the outcomes of the staking periods of [nodeID]
+	// starting at [cursor], and the cursor to use to fetch the next page.
+	// A zero [pageSize] returns the maximum page size.
+	GetStakingHistory(
+		ctx context.Context,
+		nodeID ids.NodeID,
+		cursor uint64,
+		pageSize uint64,
+		options ...rpc.Option,
+	) ([]StakingRecord, uint64, error)
+	// GetRewardsByAddress returns the outcomes of the staking periods whose
+	// rewards are owned by [addr] starting at [cursor], and the cursor to use
+	// to fetch the next page.
+	// A zero [pageSize] returns the maximum page size.
+	GetRewardsByAddress(
+		ctx context.Context,
+		addr ids.ShortID,
+		cursor uint64,
+		pageSize uint64,
+		options ...rpc.Option,
+	) ([]StakingRecord, uint64, error)
+	// GetMempool returns the txs in the mempool starting at [cursor], and the
+	// cursor to use to fetch the next page.
+	// A zero [pageSize] returns the maximum page size.
+	GetMempool(ctx context.Context, cursor uint64, pageSize uint64, options ...rpc.Option) ([]MempoolTx, uint64, error)
+	// GetMempoolStats returns the number of txs and bytes in the mempool
+	GetMempoolStats(ctx context.Context, options ...rpc.Option) (*GetMempoolStatsReply, error)
 	// GetTimestamp returns the current chain timestamp
 	GetTimestamp(ctx context.Context, options ...rpc.Option) (time.Time, error)
 	// GetValidatorsAt returns the weights of the validator set of a provided subnet
@@ -455,6 +494,29 @@ func (c *client) GetCurrentSupply(ctx context.Context, subnetID ids.ID, options
 	return uint64(res.Supply), err
 }
 
+func (c *client) EstimateReward(
+	ctx context.Context,
+	subnetID ids.ID,
+	amount uint64,
+	duration time.Duration,
+	startTime time.Time,
+	delegationFeeRate float32,
+	options ...rpc.Option,
+) (*EstimateRewardReply, error) {
+	args := &EstimateRewardArgs{
+		SubnetID:          subnetID,
+		Amount:            json.Uint64(amount),
+		Duration:          json.Uint64(duration / time.Second),
+		DelegationFeeRate: json.Float32(delegationFeeRate),
+	}
+	if !startTime.IsZero() {
+		args.StartTime = json.Uint64(startTime.Unix())
+	}
+	res := &EstimateRewardReply{}
+	err := c.requester.SendRequest(ctx, "platform.estimateReward", args, res, options...)
+	return res, err
+}
+
 func (c *client) SampleValidators(ctx context.Context, subnetID ids.ID, sampleSize uint16, options ...rpc.Option) ([]ids.NodeID, error) {
 	res := &SampleValidatorsReply{}
 	err := c.requester.SendRequest(ctx, "platform.sampleValidators", &SampleValidatorsArgs{
@@ -841,6 +903,53 @@ func (c *client) GetRewardUTXOs(ctx context.Context, args *api.GetTxArgs, option
 	return utxos, err
 }
 
+func (c *client) GetStakingHistory(
+	ctx context.Context,
+	nodeID ids.NodeID,
+	cursor uint64,
+	pageSize uint64,
+	options ...rpc.Option,
+) ([]StakingRecord, uint64, error) {
+	res := &GetStakingHistoryReply{}
+	err := c.requester.SendRequest(ctx, "platform.getStakingHistory", &GetStakingHistoryArgs{
+		NodeID:   nodeID,
+		Cursor:   json.Uint64(cursor),
+		PageSize: json.Uint64(pageSize),
+	}, res, options...)
+	return res.Records, uint64(res.Cursor), err
+}
+
+func (c *client) GetRewardsByAddress(
+	ctx context.Context,
+	addr ids.ShortID,
+	cursor uint64,
+	pageSize uint64,
+	options ...rpc.Option,
+) ([]StakingRecord, uint64, error) {
+	res := &GetStakingHistoryReply{}
+	err := c.requester.SendRequest(ctx, "platform.getRewardsByAddress", &GetRewardsByAddressArgs{
+		JSONAddress: api.JSONAddress{Address: addr.String()},
+		Cursor:      json.Uint64(cursor),
+		PageSize:    json.Uint64(pageSize),
+	}, res, options...)
+	return res.Records, uint64(res.Cursor), err
+}
+
+func (c *client) GetMempool(ctx context.Context, cursor uint64, pageSize uint64, options ...rpc.Option) ([]MempoolTx, uint64, error) {
+	res := &GetMempoolReply{}
+	err := c.requester.SendRequest(ctx, "platform.getMempool", &GetMempoolArgs{
+		Cursor:   json.Uint64(cursor),
+		PageSize: json.Uint64(pageSize),
+	}, res, options...)
+	return res.Txs, uint64(res.Cursor), err
+}
+
+func (c *client) GetMempoolStats(ctx context.Context, options ...rpc.Option) (*GetMempoolStatsReply, error) {
+	res := &GetMempoolStatsReply{}
+	err := c.requester.SendRequest(ctx, "platform.getMempoolStats", struct{}{}, res, options...)
+	return res, err
+}
+
 func (c *client) GetTimestamp(ctx context.Context, options ...rpc.Option) (time.Time, error) {
 	res := &GetTimestampReply{}
 	err := c.requester.SendRequest(ctx, "platform.getTimestamp", struct{}{}, res, options...)
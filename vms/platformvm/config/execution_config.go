@@ -0,0 +1,25 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package config
+
+import "encoding/json"
+
+// ExecutionConfig provides execution parameters of the P-chain that can be
+// set per node through the chain config.
+type ExecutionConfig struct {
+	// IndexStakingHistory enables indexing the outcomes of RewardValidatorTxs
+	// by node ID and by reward address. Only outcomes accepted while indexing
+	// is enabled are indexed.
+	IndexStakingHistory bool `json:"index-staking-history"`
+}
+
+// GetExecutionConfig returns the ExecutionConfig parsed from [b]. If [b] is
+// empty, the default config is returned.
+func GetExecutionConfig(b []byte) (*ExecutionConfig, error) {
+	ec := &ExecutionConfig{}
+	if len(b) == 0 {
+		return ec, nil
+	}
+	return ec, json.Unmarshal(b, ec)
+}
@@ -0,0 +1,25 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetExecutionConfig(t *testing.T) {
+	require := require.New(t)
+
+	ec, err := GetExecutionConfig(nil)
+	require.NoError(err)
+	require.False(ec.IndexStakingHistory)
+
+	ec, err = GetExecutionConfig([]byte(`{"index-staking-history":true}`))
+	require.NoError(err)
+	require.True(ec.IndexStakingHistory)
+
+	_, err = GetExecutionConfig([]byte(`{`))
+	require.Error(err)
+}
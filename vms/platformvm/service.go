@@ -35,6 +35,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/signer"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakeable"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -52,6 +53,13 @@ const (
 	// Max number of addresses that can be passed in as argument to GetStake
 	maxGetStakeAddrs = 256
 
+	// Max number of staking records that can be returned by a single call to
+	// GetStakingHistory or GetRewardsByAddress
+	maxStakingHistoryPageSize = 1024
+
+	// Max number of txs that can be returned by a single call to GetMempool
+	maxMempoolPageSize = 1024
+
 	// Minimum amount of delay to allow a transaction to be issued through the
 	// API
 	minAddStakerDelay = 2 * executor.SyncBound
@@ -78,6 +86,7 @@ var (
 	errMissingPrivateKey        = errors.New("argument 'privateKey' not given")
 	errStartAfterEndTime        = errors.New("start time must be before end time")
 	errStartTimeInThePast       = errors.New("start time in the past")
+	errInvalidStakeDuration     = errors.New("argument 'duration' is outside of the allowed staking durations")
 )
 
 // Service defines the API calls that can be made to the platform chain
@@ -554,8 +563,14 @@ func (s *Service) GetSubnets(_ *http.Request, args *GetSubnetsArgs, response *Ge
 				continue
 			}
 
-			unsignedTx := subnet.Unsigned.(*txs.CreateSubnetTx)
-			owner := unsignedTx.Owner.(*secp256k1fx.OutputOwners)
+			subnetOwner, err := s.vm.state.GetSubnetOwner(subnetID)
+			if err != nil {
+				return err
+			}
+			owner, ok := subnetOwner.(*secp256k1fx.OutputOwners)
+			if !ok {
+				return fmt.Errorf("expected *secp256k1fx.OutputOwners but got %T", subnetOwner)
+			}
 			controlAddrs := []string{}
 			for _, controlKeyID := range owner.Addrs {
 				addr, err := s.addrManager.FormatLocalAddress(controlKeyID)
@@ -606,7 +621,7 @@ func (s *Service) GetSubnets(_ *http.Request, args *GetSubnetsArgs, response *Ge
 			continue
 		}
 
-		subnetTx, _, err := s.vm.state.GetTx(subnetID)
+		subnetOwner, err := s.vm.state.GetSubnetOwner(subnetID)
 		if err == database.ErrNotFound {
 			continue
 		}
@@ -614,13 +629,9 @@ func (s *Service) GetSubnets(_ *http.Request, args *GetSubnetsArgs, response *Ge
 			return err
 		}
 
-		subnet, ok := subnetTx.Unsigned.(*txs.CreateSubnetTx)
-		if !ok {
-			return fmt.Errorf("expected tx type *txs.CreateSubnetTx but got %T", subnetTx.Unsigned)
-		}
-		owner, ok := subnet.Owner.(*secp256k1fx.OutputOwners)
+		owner, ok := subnetOwner.(*secp256k1fx.OutputOwners)
 		if !ok {
-			return fmt.Errorf("expected *secp256k1fx.OutputOwners but got %T", subnet.Owner)
+			return fmt.Errorf("expected *secp256k1fx.OutputOwners but got %T", subnetOwner)
 		}
 
 		controlAddrs := make([]string, len(owner.Addrs))
@@ -1093,6 +1104,170 @@ func (s *Service) GetCurrentSupply(_ *http.Request, args *GetCurrentSupplyArgs,
 	return err
 }
 
+// EstimateRewardArgs are the arguments for calling EstimateReward
+type EstimateRewardArgs struct {
+	// Subnet the stake is on
+	// If omitted, defaults to the primary network
+	SubnetID ids.ID `json:"subnetID"`
+	// Amount staked
+	Amount json.Uint64 `json:"amount"`
+	// Number of seconds the amount is staked for
+	Duration json.Uint64 `json:"duration"`
+	// Unix time the staking starts at
+	// If omitted, defaults to the current chain time
+	StartTime json.Uint64 `json:"startTime"`
+	// Percent fee charged by the validator if the amount is delegated, between
+	// 0 and 100, inclusive
+	DelegationFeeRate json.Float32 `json:"delegationFeeRate"`
+}
+
+// EstimateRewardReply are the results from calling EstimateReward
+type EstimateRewardReply struct {
+	// Supply of the subnet's staking asset now
+	CurrentSupply json.Uint64 `json:"currentSupply"`
+	// Supply of the subnet's staking asset when the staking starts, after the
+	// potential rewards of the stakers scheduled to start before then are
+	// added to it
+	ProjectedSupply json.Uint64 `json:"projectedSupply"`
+	// Reward for staking [Amount] for [Duration] if the staker is rewarded
+	Reward json.Uint64 `json:"reward"`
+	// Part of [Reward] that the delegator receives if the amount is delegated
+	DelegatorReward json.Uint64 `json:"delegatorReward"`
+	// Part of [Reward] that the validator receives if the amount is delegated
+	DelegationFee json.Uint64 `json:"delegationFee"`
+}
+
+// EstimateReward returns the reward for staking an amount for a duration,
+// given the current supply and the stakers scheduled to start before the
+// staking starts.
+func (s *Service) EstimateReward(_ *http.Request, args *EstimateRewardArgs, reply *EstimateRewardReply) error {
+	s.vm.ctx.Log.Debug("API called",
+		zap.String("service", "platform"),
+		zap.String("method", "estimateReward"),
+	)
+
+	switch {
+	case args.Amount == 0:
+		return errNoAmount
+	case args.DelegationFeeRate < 0 || args.DelegationFeeRate > 100:
+		return errInvalidDelegationRate
+	}
+
+	rewards, minStakeDuration, maxStakeDuration, err := s.getStakingRules(args.SubnetID)
+	if err != nil {
+		return err
+	}
+	duration := time.Duration(args.Duration) * time.Second
+	if duration < minStakeDuration || duration > maxStakeDuration {
+		return fmt.Errorf(
+			"%w: must be between %s and %s",
+			errInvalidStakeDuration,
+			minStakeDuration,
+			maxStakeDuration,
+		)
+	}
+
+	startTime := s.vm.state.GetTimestamp()
+	if args.StartTime != 0 {
+		requestedStartTime := time.Unix(int64(args.StartTime), 0)
+		if requestedStartTime.Before(startTime) {
+			return errStartTimeInThePast
+		}
+		startTime = requestedStartTime
+	}
+
+	currentSupply, err := s.vm.state.GetCurrentSupply(args.SubnetID)
+	if err != nil {
+		return err
+	}
+	projectedSupply, err := s.projectSupply(args.SubnetID, currentSupply, startTime, rewards)
+	if err != nil {
+		return err
+	}
+
+	potentialReward := rewards.Calculate(duration, uint64(args.Amount), projectedSupply)
+	delegationFee, delegatorReward := reward.Split(potentialReward, uint32(10000*args.DelegationFeeRate))
+
+	reply.CurrentSupply = json.Uint64(currentSupply)
+	reply.ProjectedSupply = json.Uint64(projectedSupply)
+	reply.Reward = json.Uint64(potentialReward)
+	reply.DelegatorReward = json.Uint64(delegatorReward)
+	reply.DelegationFee = json.Uint64(delegationFee)
+	return nil
+}
+
+// Returns the reward calculator and the minimum and maximum staking durations
+// of [subnetID].
+func (s *Service) getStakingRules(subnetID ids.ID) (reward.Calculator, time.Duration, time.Duration, error) {
+	if subnetID == constants.PrimaryNetworkID {
+		return reward.NewCalculator(s.vm.RewardConfig), s.vm.MinStakeDuration, s.vm.MaxStakeDuration, nil
+	}
+
+	transformSubnetIntf, err := s.vm.state.GetSubnetTransformation(subnetID)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf(
+			"failed fetching subnet transformation for %s: %w",
+			subnetID,
+			err,
+		)
+	}
+	transformSubnet, ok := transformSubnetIntf.Unsigned.(*txs.TransformSubnetTx)
+	if !ok {
+		return nil, 0, 0, fmt.Errorf(
+			"unexpected subnet transformation tx type fetched %T",
+			transformSubnetIntf.Unsigned,
+		)
+	}
+
+	rewards := reward.NewCalculator(reward.Config{
+		MaxConsumptionRate: transformSubnet.MaxConsumptionRate,
+		MinConsumptionRate: transformSubnet.MinConsumptionRate,
+		MintingPeriod:      s.vm.RewardConfig.MintingPeriod,
+		SupplyCap:          transformSubnet.MaximumSupply,
+	})
+	minStakeDuration := time.Duration(transformSubnet.MinStakeDuration) * time.Second
+	maxStakeDuration := time.Duration(transformSubnet.MaxStakeDuration) * time.Second
+	return rewards, minStakeDuration, maxStakeDuration, nil
+}
+
+// Returns the supply of [subnetID] at [startTime], given that it's
+// [currentSupply] now. The potential reward of every pending staker of
+// [subnetID] that starts at or before [startTime] is added to the supply when
+// the staker starts, in the same order as when the chain time advances.
+func (s *Service) projectSupply(
+	subnetID ids.ID,
+	currentSupply uint64,
+	startTime time.Time,
+	rewards reward.Calculator,
+) (uint64, error) {
+	pendingStakerIterator, err := s.vm.state.GetPendingStakerIterator()
+	if err != nil {
+		return 0, err
+	}
+	defer pendingStakerIterator.Release()
+
+	supply := currentSupply
+	for pendingStakerIterator.Next() {
+		staker := pendingStakerIterator.Value()
+		if staker.StartTime.After(startTime) {
+			break
+		}
+		// Permissioned subnet validators aren't rewarded.
+		if staker.SubnetID != subnetID || staker.Priority == txs.SubnetPermissionedValidatorPendingPriority {
+			continue
+		}
+
+		// Invariant: [rewards.Calculate] can never return a [potentialReward]
+		//            such that [supply + potentialReward > maximumSupply].
+		supply += rewards.Calculate(
+			staker.EndTime.Sub(staker.StartTime),
+			staker.Weight,
+			supply,
+		)
+	}
+	return supply, nil
+}
+
 // SampleValidatorsArgs are the arguments for calling SampleValidators
 type SampleValidatorsArgs struct {
 	// Number of validators in the sample
@@ -2513,6 +2688,268 @@ func (s *Service) GetRewardUTXOs(_ *http.Request, args *api.GetTxArgs, reply *Ge
 	return nil
 }
 
+// StakingRecord is the outcome of a staking period that ended while this node
+// was indexing staking history
+type StakingRecord struct {
+	// ID of the RewardValidatorTx that removed the staker
+	RewardTxID ids.ID `json:"rewardTxID"`
+	// ID of the tx that added the staker
+	StakerTxID ids.ID      `json:"stakerTxID"`
+	NodeID     ids.NodeID  `json:"nodeID"`
+	SubnetID   ids.ID      `json:"subnetID"`
+	Delegator  bool        `json:"delegator"`
+	StartTime  json.Uint64 `json:"startTime"`
+	EndTime    json.Uint64 `json:"endTime"`
+	Weight     json.Uint64 `json:"weight"`
+	// Reward the staker would have received if it was rewarded
+	PotentialReward json.Uint64 `json:"potentialReward"`
+	// True if the staker was rewarded
+	Rewarded bool `json:"rewarded"`
+	// Sum of the reward UTXOs created when the staker was removed
+	Reward json.Uint64 `json:"reward"`
+	// Uptime of the validator, as a percentage (0-100), measured by this node
+	Uptime json.Float32 `json:"uptime"`
+	// Addresses that own the rewards of the staker
+	RewardOwners []string `json:"rewardOwners"`
+	// Height of the block that accepted the outcome
+	Height json.Uint64 `json:"height"`
+}
+
+// GetStakingHistoryArgs are the arguments for calling GetStakingHistory
+type GetStakingHistoryArgs struct {
+	NodeID ids.NodeID `json:"nodeID"`
+	// Cursor used as a page index / offset
+	Cursor json.Uint64 `json:"cursor"`
+	// PageSize num of items per page
+	PageSize json.Uint64 `json:"pageSize"`
+}
+
+// GetStakingHistoryReply is the response from calling GetStakingHistory or
+// GetRewardsByAddress
+type GetStakingHistoryReply struct {
+	Records []StakingRecord `json:"records"`
+	// Cursor used as a page index / offset
+	Cursor json.Uint64 `json:"cursor"`
+}
+
+// GetStakingHistory returns the outcomes of the staking periods of a node, in
+// order of acceptance. Requires staking history indexing to be enabled.
+func (s *Service) GetStakingHistory(_ *http.Request, args *GetStakingHistoryArgs, reply *GetStakingHistoryReply) error {
+	cursor := uint64(args.Cursor)
+	pageSize := uint64(args.PageSize)
+	s.vm.ctx.Log.Debug("API called",
+		zap.String("service", "platform"),
+		zap.String("method", "getStakingHistory"),
+		zap.Stringer("nodeID", args.NodeID),
+		zap.Uint64("cursor", cursor),
+		zap.Uint64("pageSize", pageSize),
+	)
+
+	pageSize, err := getStakingHistoryPageSize(pageSize)
+	if err != nil {
+		return err
+	}
+
+	records, err := s.vm.stakingHistory.GetByNodeID(args.NodeID, cursor, pageSize)
+	if err != nil {
+		return fmt.Errorf("couldn't get staking history: %w", err)
+	}
+	return s.setStakingHistoryReply(records, cursor, reply)
+}
+
+// GetRewardsByAddressArgs are the arguments for calling GetRewardsByAddress
+type GetRewardsByAddressArgs struct {
+	api.JSONAddress
+	// Cursor used as a page index / offset
+	Cursor json.Uint64 `json:"cursor"`
+	// PageSize num of items per page
+	PageSize json.Uint64 `json:"pageSize"`
+}
+
+// GetRewardsByAddress returns the outcomes of the staking periods whose
+// rewards are owned by an address, in order of acceptance. Requires staking
+// history indexing to be enabled.
+func (s *Service) GetRewardsByAddress(_ *http.Request, args *GetRewardsByAddressArgs, reply *GetStakingHistoryReply) error {
+	cursor := uint64(args.Cursor)
+	pageSize := uint64(args.PageSize)
+	s.vm.ctx.Log.Debug("API called",
+		zap.String("service", "platform"),
+		zap.String("method", "getRewardsByAddress"),
+		logging.UserString("address", args.Address),
+		zap.Uint64("cursor", cursor),
+		zap.Uint64("pageSize", pageSize),
+	)
+
+	pageSize, err := getStakingHistoryPageSize(pageSize)
+	if err != nil {
+		return err
+	}
+
+	addr, err := avax.ParseServiceAddress(s.addrManager, args.Address)
+	if err != nil {
+		return fmt.Errorf("couldn't parse argument 'address' to address: %w", err)
+	}
+
+	records, err := s.vm.stakingHistory.GetByAddress(addr, cursor, pageSize)
+	if err != nil {
+		return fmt.Errorf("couldn't get staking history: %w", err)
+	}
+	return s.setStakingHistoryReply(records, cursor, reply)
+}
+
+func getStakingHistoryPageSize(pageSize uint64) (uint64, error) {
+	switch {
+	case pageSize > maxStakingHistoryPageSize:
+		return 0, fmt.Errorf("pageSize > maximum allowed (%d)", maxStakingHistoryPageSize)
+	case pageSize == 0:
+		return maxStakingHistoryPageSize, nil
+	default:
+		return pageSize, nil
+	}
+}
+
+func (s *Service) setStakingHistoryReply(
+	records []*stakinghistory.Record,
+	cursor uint64,
+	reply *GetStakingHistoryReply,
+) error {
+	reply.Records = make([]StakingRecord, len(records))
+	for i, record := range records {
+		rewardOwners := make([]string, len(record.RewardOwners))
+		for j, addr := range record.RewardOwners {
+			addrStr, err := s.addrManager.FormatLocalAddress(addr)
+			if err != nil {
+				return err
+			}
+			rewardOwners[j] = addrStr
+		}
+
+		reply.Records[i] = StakingRecord{
+			RewardTxID:      record.RewardTxID,
+			StakerTxID:      record.StakerTxID,
+			NodeID:          record.NodeID,
+			SubnetID:        record.SubnetID,
+			Delegator:       record.IsDelegator,
+			StartTime:       json.Uint64(record.StartTime),
+			EndTime:         json.Uint64(record.EndTime),
+			Weight:          json.Uint64(record.Weight),
+			PotentialReward: json.Uint64(record.PotentialReward),
+			Rewarded:        record.Rewarded,
+			Reward:          json.Uint64(record.Reward),
+			// Transform this to a percentage (0-100) to make it consistent
+			// with the uptimes reported by getCurrentValidators
+			Uptime:       json.Float32(float64(record.Uptime) * 100 / reward.PercentDenominator),
+			RewardOwners: rewardOwners,
+			Height:       json.Uint64(record.Height),
+		}
+	}
+	reply.Cursor = json.Uint64(cursor + uint64(len(records)))
+	return nil
+}
+
+// MempoolTx describes a tx in the mempool
+type MempoolTx struct {
+	TxID ids.ID `json:"txID"`
+	// True if the tx is a staker tx, which is ordered by start time rather
+	// than by fee rate
+	Staker bool `json:"staker"`
+	// Size of the tx in bytes
+	Size json.Uint32 `json:"size"`
+	// Amount of AVAX burned by the tx. Omitted for staker txs.
+	Fee *json.Uint64 `json:"fee,omitempty"`
+	// Amount of AVAX burned per byte of the tx. Omitted for staker txs.
+	FeeRate *json.Uint64 `json:"feeRate,omitempty"`
+}
+
+// GetMempoolArgs are the arguments for calling GetMempool
+type GetMempoolArgs struct {
+	// Cursor used as a page index / offset
+	Cursor json.Uint64 `json:"cursor"`
+	// PageSize num of items per page
+	PageSize json.Uint64 `json:"pageSize"`
+}
+
+// GetMempoolReply is the response from calling GetMempool
+type GetMempoolReply struct {
+	Txs []MempoolTx `json:"txs"`
+	// Cursor used as a page index / offset
+	Cursor json.Uint64 `json:"cursor"`
+}
+
+// GetMempool returns the txs in the mempool. Decision txs are returned first,
+// in the order they will be issued, followed by the staker txs.
+func (s *Service) GetMempool(_ *http.Request, args *GetMempoolArgs, reply *GetMempoolReply) error {
+	cursor := uint64(args.Cursor)
+	pageSize := uint64(args.PageSize)
+	s.vm.ctx.Log.Debug("API called",
+		zap.String("service", "platform"),
+		zap.String("method", "getMempool"),
+		zap.Uint64("cursor", cursor),
+		zap.Uint64("pageSize", pageSize),
+	)
+
+	switch {
+	case pageSize > maxMempoolPageSize:
+		return fmt.Errorf("pageSize > maximum allowed (%d)", maxMempoolPageSize)
+	case pageSize == 0:
+		pageSize = maxMempoolPageSize
+	}
+
+	infos := s.vm.Builder.List()
+	if cursor > uint64(len(infos)) {
+		cursor = uint64(len(infos))
+	}
+	infos = infos[cursor:]
+	if pageSize < uint64(len(infos)) {
+		infos = infos[:pageSize]
+	}
+
+	reply.Txs = make([]MempoolTx, len(infos))
+	for i, info := range infos {
+		mempoolTx := MempoolTx{
+			TxID:   info.Tx.ID(),
+			Staker: !info.IsDecisionTx,
+			Size:   json.Uint32(len(info.Tx.Bytes())),
+		}
+		if info.IsDecisionTx {
+			fee := json.Uint64(info.Fee)
+			feeRate := json.Uint64(info.FeeRate)
+			mempoolTx.Fee = &fee
+			mempoolTx.FeeRate = &feeRate
+		}
+		reply.Txs[i] = mempoolTx
+	}
+	reply.Cursor = json.Uint64(cursor + uint64(len(infos)))
+	return nil
+}
+
+// GetMempoolStatsReply is the response from calling GetMempoolStats
+type GetMempoolStatsReply struct {
+	// Number of txs ordered by fee rate
+	NumDecisionTxs json.Uint32 `json:"numDecisionTxs"`
+	// Number of txs ordered by start time
+	NumStakerTxs json.Uint32 `json:"numStakerTxs"`
+	// Number of bytes used by the txs in the mempool
+	BytesUsed json.Uint64 `json:"bytesUsed"`
+	// Number of bytes that can still be added to the mempool
+	BytesAvailable json.Uint64 `json:"bytesAvailable"`
+}
+
+// GetMempoolStats returns the number of txs and bytes in the mempool
+func (s *Service) GetMempoolStats(_ *http.Request, _ *struct{}, reply *GetMempoolStatsReply) error {
+	s.vm.ctx.Log.Debug("API called",
+		zap.String("service", "platform"),
+		zap.String("method", "getMempoolStats"),
+	)
+
+	stats := s.vm.Builder.Stats()
+	reply.NumDecisionTxs = json.Uint32(stats.NumDecisionTxs)
+	reply.NumStakerTxs = json.Uint32(stats.NumStakerTxs)
+	reply.BytesUsed = json.Uint64(stats.BytesUsed)
+	reply.BytesAvailable = json.Uint64(stats.BytesAvailable)
+	return nil
+}
+
 // GetTimestampReply is the response from GetTimestamp
 type GetTimestampReply struct {
 	// Current timestamp
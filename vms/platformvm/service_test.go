@@ -21,6 +21,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/chains/atomic"
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/manager"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/consensus/snowman"
@@ -32,6 +33,8 @@ import (
 	"github.com/memeticofficial/pepecoingo/version"
 	"github.com/memeticofficial/pepecoingo/vms/components/avax"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -728,6 +731,159 @@ func TestGetTimestamp(t *testing.T) {
 	require.Equal(newTimestamp, reply.Timestamp)
 }
 
+func TestEstimateReward(t *testing.T) {
+	require := require.New(t)
+	service, _ := defaultService(t)
+	service.vm.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(service.vm.Shutdown(context.Background()))
+		service.vm.ctx.Lock.Unlock()
+	}()
+
+	rewards := reward.NewCalculator(service.vm.RewardConfig)
+	currentSupply, err := service.vm.state.GetCurrentSupply(constants.PrimaryNetworkID)
+	require.NoError(err)
+
+	// Add a validator that starts after the current chain time.
+	now := service.vm.state.GetTimestamp()
+	pendingStartTime := now.Add(time.Hour)
+	pendingEndTime := pendingStartTime.Add(defaultMinStakingDuration)
+	pendingStake := service.vm.MinValidatorStake
+	tx, err := service.vm.txBuilder.NewAddValidatorTx(
+		pendingStake,
+		uint64(pendingStartTime.Unix()),
+		uint64(pendingEndTime.Unix()),
+		ids.GenerateTestNodeID(),
+		ids.GenerateTestShortID(),
+		0,
+		[]*secp256k1.PrivateKey{keys[0]},
+		keys[0].PublicKey().Address(), // change addr
+	)
+	require.NoError(err)
+	staker, err := state.NewPendingStaker(
+		tx.ID(),
+		tx.Unsigned.(*txs.AddValidatorTx),
+	)
+	require.NoError(err)
+	service.vm.state.PutPendingValidator(staker)
+	service.vm.state.AddTx(tx, status.Committed)
+	require.NoError(service.vm.state.Commit())
+
+	projectedSupply := currentSupply + rewards.Calculate(defaultMinStakingDuration, pendingStake, currentSupply)
+	require.Greater(projectedSupply, currentSupply)
+
+	amount := currentSupply / 100
+	duration := 2 * defaultMinStakingDuration
+	tests := []struct {
+		name                    string
+		args                    EstimateRewardArgs
+		expectedErr             error
+		expectedProjectedSupply uint64
+		expectedDelegationFee   bool
+	}{
+		{
+			name: "starts now",
+			args: EstimateRewardArgs{
+				Amount:   json.Uint64(amount),
+				Duration: json.Uint64(duration / time.Second),
+			},
+			expectedProjectedSupply: currentSupply,
+		},
+		{
+			name: "starts before pending staker",
+			args: EstimateRewardArgs{
+				Amount:    json.Uint64(amount),
+				Duration:  json.Uint64(duration / time.Second),
+				StartTime: json.Uint64(pendingStartTime.Add(-time.Second).Unix()),
+			},
+			expectedProjectedSupply: currentSupply,
+		},
+		{
+			name: "starts with pending staker",
+			args: EstimateRewardArgs{
+				Amount:    json.Uint64(amount),
+				Duration:  json.Uint64(duration / time.Second),
+				StartTime: json.Uint64(pendingStartTime.Unix()),
+			},
+			expectedProjectedSupply: projectedSupply,
+		},
+		{
+			name: "delegated",
+			args: EstimateRewardArgs{
+				Amount:            json.Uint64(amount),
+				Duration:          json.Uint64(duration / time.Second),
+				StartTime:         json.Uint64(pendingStartTime.Unix()),
+				DelegationFeeRate: 2.5,
+			},
+			expectedProjectedSupply: projectedSupply,
+			expectedDelegationFee:   true,
+		},
+		{
+			name: "no amount",
+			args: EstimateRewardArgs{
+				Duration: json.Uint64(duration / time.Second),
+			},
+			expectedErr: errNoAmount,
+		},
+		{
+			name: "duration too short",
+			args: EstimateRewardArgs{
+				Amount:   json.Uint64(amount),
+				Duration: json.Uint64(defaultMinStakingDuration/time.Second - 1),
+			},
+			expectedErr: errInvalidStakeDuration,
+		},
+		{
+			name: "duration too long",
+			args: EstimateRewardArgs{
+				Amount:   json.Uint64(amount),
+				Duration: json.Uint64(defaultMaxStakingDuration/time.Second + 1),
+			},
+			expectedErr: errInvalidStakeDuration,
+		},
+		{
+			name: "start time in the past",
+			args: EstimateRewardArgs{
+				Amount:    json.Uint64(amount),
+				Duration:  json.Uint64(duration / time.Second),
+				StartTime: json.Uint64(now.Add(-time.Second).Unix()),
+			},
+			expectedErr: errStartTimeInThePast,
+		},
+		{
+			name: "invalid delegation fee rate",
+			args: EstimateRewardArgs{
+				Amount:            json.Uint64(amount),
+				Duration:          json.Uint64(duration / time.Second),
+				DelegationFeeRate: 101,
+			},
+			expectedErr: errInvalidDelegationRate,
+		},
+	}
+	for _, test := range tests {
+		reply := EstimateRewardReply{}
+		err := service.EstimateReward(nil, &test.args, &reply)
+		require.ErrorIs(err, test.expectedErr, test.name)
+		if test.expectedErr != nil {
+			continue
+		}
+
+		expectedReward := rewards.Calculate(duration, amount, test.expectedProjectedSupply)
+		require.Equal(currentSupply, uint64(reply.CurrentSupply), test.name)
+		require.Equal(test.expectedProjectedSupply, uint64(reply.ProjectedSupply), test.name)
+		require.Equal(expectedReward, uint64(reply.Reward), test.name)
+		require.Equal(expectedReward, uint64(reply.DelegatorReward+reply.DelegationFee), test.name)
+		if test.expectedDelegationFee {
+			// A 2.5% fee is 25,000 shares.
+			expectedDelegationFee, _ := reward.Split(expectedReward, 25_000)
+			require.NotZero(expectedDelegationFee, test.name)
+			require.Equal(expectedDelegationFee, uint64(reply.DelegationFee), test.name)
+		} else {
+			require.Zero(reply.DelegationFee, test.name)
+		}
+	}
+}
+
 func TestGetBlock(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -803,3 +959,158 @@ func TestGetBlock(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMempool(t *testing.T) {
+	require := require.New(t)
+	service, _ := defaultService(t)
+	service.vm.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(service.vm.Shutdown(context.Background()))
+		service.vm.ctx.Lock.Unlock()
+	}()
+
+	statsReply := GetMempoolStatsReply{}
+	require.NoError(service.GetMempoolStats(nil, nil, &statsReply))
+	require.Zero(statsReply.NumDecisionTxs)
+	require.Zero(statsReply.NumStakerTxs)
+	require.Zero(statsReply.BytesUsed)
+
+	service.vm.Config.CreateAssetTxFee = 100 * defaultTxFee
+
+	tx, err := service.vm.txBuilder.NewCreateSubnetTx(
+		1,
+		[]ids.ShortID{keys[0].PublicKey().Address()},
+		[]*secp256k1.PrivateKey{keys[0]},
+		keys[0].PublicKey().Address(),
+	)
+	require.NoError(err)
+	require.NoError(service.vm.Builder.AddUnverifiedTx(tx))
+
+	reply := GetMempoolReply{}
+	require.NoError(service.GetMempool(nil, &GetMempoolArgs{}, &reply))
+	require.Len(reply.Txs, 1)
+	require.Equal(json.Uint64(1), reply.Cursor)
+
+	mempoolTx := reply.Txs[0]
+	require.Equal(tx.ID(), mempoolTx.TxID)
+	require.False(mempoolTx.Staker)
+	require.Equal(json.Uint32(len(tx.Bytes())), mempoolTx.Size)
+	require.NotNil(mempoolTx.Fee)
+	require.Equal(json.Uint64(service.vm.Config.CreateAssetTxFee), *mempoolTx.Fee)
+	require.NotNil(mempoolTx.FeeRate)
+	require.Equal(json.Uint64(service.vm.Config.CreateAssetTxFee/uint64(len(tx.Bytes()))), *mempoolTx.FeeRate)
+
+	// The next page is empty
+	reply = GetMempoolReply{}
+	require.NoError(service.GetMempool(nil, &GetMempoolArgs{Cursor: 1}, &reply))
+	require.Empty(reply.Txs)
+	require.Equal(json.Uint64(1), reply.Cursor)
+
+	err = service.GetMempool(nil, &GetMempoolArgs{PageSize: maxMempoolPageSize + 1}, &reply)
+	require.Error(err)
+
+	statsReply = GetMempoolStatsReply{}
+	require.NoError(service.GetMempoolStats(nil, nil, &statsReply))
+	require.Equal(json.Uint32(1), statsReply.NumDecisionTxs)
+	require.Zero(statsReply.NumStakerTxs)
+	require.Equal(json.Uint64(len(tx.Bytes())), statsReply.BytesUsed)
+}
+
+func TestGetStakingHistory(t *testing.T) {
+	require := require.New(t)
+	service, _ := defaultService(t)
+	service.vm.ctx.Lock.Lock()
+	defer func() {
+		require.NoError(service.vm.Shutdown(context.Background()))
+		service.vm.ctx.Lock.Unlock()
+	}()
+
+	// Fast forward clock to time for genesis validators to leave
+	service.vm.clock.Set(defaultValidateEndTime)
+
+	blk, err := service.vm.Builder.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+
+	options, err := blk.(snowman.OracleBlock).Options(context.Background())
+	require.NoError(err)
+	commit := options[0].(*blockexecutor.Block)
+	_, ok := commit.Block.(*blocks.BanffCommitBlock)
+	require.True(ok)
+
+	require.NoError(blk.Accept(context.Background()))
+	require.NoError(commit.Verify(context.Background()))
+	require.NoError(commit.Accept(context.Background()))
+
+	rewardTx := blk.(*blockexecutor.Block).Txs()[0]
+	uRewardTx, ok := rewardTx.Unsigned.(*txs.RewardValidatorTx)
+	require.True(ok)
+	stakerTx, _, err := service.vm.state.GetTx(uRewardTx.TxID)
+	require.NoError(err)
+	uStakerTx, ok := stakerTx.Unsigned.(*txs.AddValidatorTx)
+	require.True(ok)
+	nodeID := uStakerTx.NodeID()
+
+	rewardUTXOs, err := service.vm.state.GetRewardUTXOs(uRewardTx.TxID)
+	require.NoError(err)
+	require.Len(rewardUTXOs, 1)
+	expectedReward := rewardUTXOs[0].Out.(*secp256k1fx.TransferOutput).Amount()
+
+	reply := GetStakingHistoryReply{}
+	require.NoError(service.GetStakingHistory(nil, &GetStakingHistoryArgs{
+		NodeID: nodeID,
+	}, &reply))
+	require.Len(reply.Records, 1)
+	require.Equal(json.Uint64(1), reply.Cursor)
+
+	record := reply.Records[0]
+	require.Equal(rewardTx.ID(), record.RewardTxID)
+	require.Equal(uRewardTx.TxID, record.StakerTxID)
+	require.Equal(nodeID, record.NodeID)
+	require.Equal(constants.PrimaryNetworkID, record.SubnetID)
+	require.False(record.Delegator)
+	require.Equal(json.Uint64(defaultValidateStartTime.Unix()), record.StartTime)
+	require.Equal(json.Uint64(defaultValidateEndTime.Unix()), record.EndTime)
+	require.Equal(json.Uint64(defaultWeight), record.Weight)
+	require.True(record.Rewarded)
+	require.Equal(json.Uint64(expectedReward), record.Reward)
+	require.Equal(record.PotentialReward, record.Reward)
+	require.Equal(json.Uint64(commit.Height()), record.Height)
+
+	// The genesis validators' rewards are owned by the address of their node.
+	rewardOwner, err := service.addrManager.FormatLocalAddress(ids.ShortID(nodeID))
+	require.NoError(err)
+	require.Equal([]string{rewardOwner}, record.RewardOwners)
+
+	addrReply := GetStakingHistoryReply{}
+	require.NoError(service.GetRewardsByAddress(nil, &GetRewardsByAddressArgs{
+		JSONAddress: api.JSONAddress{Address: rewardOwner},
+	}, &addrReply))
+	require.Equal(reply, addrReply)
+
+	// The next page is empty
+	require.NoError(service.GetStakingHistory(nil, &GetStakingHistoryArgs{
+		NodeID: nodeID,
+		Cursor: reply.Cursor,
+	}, &reply))
+	require.Empty(reply.Records)
+	require.Equal(json.Uint64(1), reply.Cursor)
+
+	// Nodes that weren't rewarded have no history
+	require.NoError(service.GetStakingHistory(nil, &GetStakingHistoryArgs{
+		NodeID: ids.GenerateTestNodeID(),
+	}, &reply))
+	require.Empty(reply.Records)
+
+	err = service.GetStakingHistory(nil, &GetStakingHistoryArgs{
+		NodeID:   nodeID,
+		PageSize: maxStakingHistoryPageSize + 1,
+	}, &reply)
+	require.Error(err)
+
+	service.vm.stakingHistory = stakinghistory.NewNoIndexer(memdb.New())
+	err = service.GetStakingHistory(nil, &GetStakingHistoryArgs{
+		NodeID: nodeID,
+	}, &reply)
+	require.ErrorIs(err, stakinghistory.ErrIndexingDisabled)
+}
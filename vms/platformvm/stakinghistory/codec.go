@@ -0,0 +1,24 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stakinghistory
+
+import (
+	"math"
+
+	"github.com/memeticofficial/pepecoingo/codec"
+	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
+)
+
+const codecVersion = 0
+
+// c does serialization and deserialization of staking records.
+var c codec.Manager
+
+func init() {
+	c = codec.NewManager(math.MaxInt32)
+	lc := linearcodec.NewCustomMaxLength(math.MaxInt32)
+	if err := c.RegisterCodec(codecVersion, lc); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stakinghistory
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/database/versiondb"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+var (
+	_ Indexer = (*indexer)(nil)
+	_ Indexer = (*noIndexer)(nil)
+
+	ErrIndexingDisabled = errors.New("staking history indexing is disabled")
+
+	recordPrefix  = []byte("record")
+	nodePrefix    = []byte("node")
+	addressPrefix = []byte("address")
+
+	idxKey = []byte("idx")
+)
+
+// Indexer maintains the outcomes of RewardValidatorTxs keyed by the node that
+// was staked to and by the addresses that own the rewards.
+//
+// Writes are buffered until [CommitBatch] is called so that they can be
+// written atomically with the rest of the chain state.
+type Indexer interface {
+	// Accept indexes [record].
+	Accept(record *Record) error
+
+	// GetByNodeID returns the records of [nodeID], starting at [cursor], in
+	// order of acceptance. At most [pageSize] records are returned.
+	GetByNodeID(nodeID ids.NodeID, cursor, pageSize uint64) ([]*Record, error)
+
+	// GetByAddress returns the records whose rewards are owned by [addr],
+	// starting at [cursor], in order of acceptance. At most [pageSize]
+	// records are returned.
+	GetByAddress(addr ids.ShortID, cursor, pageSize uint64) ([]*Record, error)
+
+	// CommitBatch returns a batch containing all the writes since the last
+	// call to [CommitBatch] or [Abort].
+	CommitBatch() (database.Batch, error)
+
+	// Abort discards all the writes that have not been committed.
+	Abort()
+}
+
+type indexer struct {
+	db *versiondb.Database
+
+	recordDB  database.Database
+	nodeDB    database.Database
+	addressDB database.Database
+}
+
+// NewIndexer returns a new Indexer that stores its records in [db].
+func NewIndexer(db database.Database) Indexer {
+	vdb := versiondb.New(db)
+	return &indexer{
+		db:        vdb,
+		recordDB:  prefixdb.New(recordPrefix, vdb),
+		nodeDB:    prefixdb.New(nodePrefix, vdb),
+		addressDB: prefixdb.New(addressPrefix, vdb),
+	}
+}
+
+// Accept persists [record] and appends it to the node's and the reward
+// owners' histories. [record.RewardOwners] must not contain duplicates.
+// The database structure is:
+// "record"
+// |  [rewardTxID] => record
+// "node"
+// |  [nodeID]
+// |  |  "idx" => 2    Running index key, represents the next index
+// |  |  0     => rewardTxID1
+// |  |  1     => rewardTxID2
+// "address"
+// |  [address]
+// |  |  "idx" => 1
+// |  |  0     => rewardTxID1
+func (i *indexer) Accept(record *Record) error {
+	recordBytes, err := c.Marshal(codecVersion, record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal staking record %s: %w", record.RewardTxID, err)
+	}
+	if err := i.recordDB.Put(record.RewardTxID[:], recordBytes); err != nil {
+		return fmt.Errorf("failed to write staking record %s: %w", record.RewardTxID, err)
+	}
+
+	if err := appendToIndex(prefixdb.New(record.NodeID[:], i.nodeDB), record.RewardTxID); err != nil {
+		return err
+	}
+
+	for _, owner := range record.RewardOwners {
+		if err := appendToIndex(prefixdb.New(owner[:], i.addressDB), record.RewardTxID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *indexer) GetByNodeID(nodeID ids.NodeID, cursor, pageSize uint64) ([]*Record, error) {
+	return i.read(prefixdb.New(nodeID[:], i.nodeDB), cursor, pageSize)
+}
+
+func (i *indexer) GetByAddress(addr ids.ShortID, cursor, pageSize uint64) ([]*Record, error) {
+	return i.read(prefixdb.New(addr[:], i.addressDB), cursor, pageSize)
+}
+
+func (i *indexer) CommitBatch() (database.Batch, error) {
+	return i.db.CommitBatch()
+}
+
+func (i *indexer) Abort() {
+	i.db.Abort()
+}
+
+func (i *indexer) read(db database.Database, cursor, pageSize uint64) ([]*Record, error) {
+	cursorBytes := make([]byte, wrappers.LongLen)
+	binary.BigEndian.PutUint64(cursorBytes, cursor)
+
+	// Numeric keys are big endian, so iteration is in order of acceptance.
+	iter := db.NewIteratorWithStart(cursorBytes)
+	defer iter.Release()
+
+	var records []*Record
+	for uint64(len(records)) < pageSize && iter.Next() {
+		if bytes.Equal(idxKey, iter.Key()) {
+			// This key has the next index to use, not a record
+			continue
+		}
+
+		rewardTxID, err := ids.ToID(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		recordBytes, err := i.recordDB.Get(rewardTxID[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read staking record %s: %w", rewardTxID, err)
+		}
+		record := &Record{}
+		if _, err := c.Unmarshal(recordBytes, record); err != nil {
+			return nil, fmt.Errorf("failed to parse staking record %s: %w", rewardTxID, err)
+		}
+		records = append(records, record)
+	}
+	return records, iter.Error()
+}
+
+func appendToIndex(db database.KeyValueReaderWriter, rewardTxID ids.ID) error {
+	var idx uint64
+	idxBytes, err := db.Get(idxKey)
+	switch err {
+	case nil:
+		idx = binary.BigEndian.Uint64(idxBytes)
+	case database.ErrNotFound:
+		// idx not found; this must be the first entry.
+		idxBytes = make([]byte, wrappers.LongLen)
+	default:
+		return fmt.Errorf("failed to read index while indexing %s: %w", rewardTxID, err)
+	}
+
+	if err := db.Put(idxBytes, rewardTxID[:]); err != nil {
+		return fmt.Errorf("failed to write index entry for %s: %w", rewardTxID, err)
+	}
+
+	idx++
+	idxBytes = make([]byte, wrappers.LongLen)
+	binary.BigEndian.PutUint64(idxBytes, idx)
+	return db.Put(idxKey, idxBytes)
+}
+
+type noIndexer struct {
+	db *versiondb.Database
+}
+
+// NewNoIndexer returns an Indexer that doesn't index anything.
+func NewNoIndexer(db database.Database) Indexer {
+	return &noIndexer{
+		db: versiondb.New(db),
+	}
+}
+
+func (*noIndexer) Accept(*Record) error {
+	return nil
+}
+
+func (*noIndexer) GetByNodeID(ids.NodeID, uint64, uint64) ([]*Record, error) {
+	return nil, ErrIndexingDisabled
+}
+
+func (*noIndexer) GetByAddress(ids.ShortID, uint64, uint64) ([]*Record, error) {
+	return nil, ErrIndexingDisabled
+}
+
+func (n *noIndexer) CommitBatch() (database.Batch, error) {
+	return n.db.CommitBatch()
+}
+
+func (n *noIndexer) Abort() {
+	n.db.Abort()
+}
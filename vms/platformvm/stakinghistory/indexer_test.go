@@ -0,0 +1,156 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stakinghistory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+func TestIndexer(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	indexer := NewIndexer(db)
+
+	nodeID := ids.GenerateTestNodeID()
+	addr0 := ids.GenerateTestShortID()
+	addr1 := ids.GenerateTestShortID()
+
+	records := []*Record{
+		{
+			RewardTxID:      ids.GenerateTestID(),
+			StakerTxID:      ids.GenerateTestID(),
+			NodeID:          nodeID,
+			StartTime:       1,
+			EndTime:         2,
+			Weight:          3,
+			PotentialReward: 4,
+			Rewarded:        true,
+			Reward:          4,
+			Uptime:          900_000,
+			RewardOwners:    []ids.ShortID{addr0},
+			Height:          5,
+		},
+		{
+			RewardTxID:      ids.GenerateTestID(),
+			StakerTxID:      ids.GenerateTestID(),
+			NodeID:          nodeID,
+			IsDelegator:     true,
+			StartTime:       6,
+			EndTime:         7,
+			Weight:          8,
+			PotentialReward: 9,
+			RewardOwners:    []ids.ShortID{addr0, addr1},
+			Height:          10,
+		},
+		{
+			RewardTxID:   ids.GenerateTestID(),
+			StakerTxID:   ids.GenerateTestID(),
+			NodeID:       ids.GenerateTestNodeID(),
+			Rewarded:     true,
+			RewardOwners: []ids.ShortID{addr1},
+			Height:       11,
+		},
+	}
+	for _, record := range records {
+		require.NoError(indexer.Accept(record))
+	}
+
+	// Uncommitted records are readable through the indexer.
+	nodeRecords, err := indexer.GetByNodeID(nodeID, 0, 10)
+	require.NoError(err)
+	require.Equal(records[:2], nodeRecords)
+
+	batch, err := indexer.CommitBatch()
+	require.NoError(err)
+	require.NoError(batch.Write())
+
+	// Reopening the indexer reads the committed records.
+	indexer = NewIndexer(db)
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 0, 10)
+	require.NoError(err)
+	require.Equal(records[:2], nodeRecords)
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 1, 10)
+	require.NoError(err)
+	require.Equal(records[1:2], nodeRecords)
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 0, 1)
+	require.NoError(err)
+	require.Equal(records[:1], nodeRecords)
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 2, 10)
+	require.NoError(err)
+	require.Empty(nodeRecords)
+
+	addrRecords, err := indexer.GetByAddress(addr0, 0, 10)
+	require.NoError(err)
+	require.Equal(records[:2], addrRecords)
+
+	addrRecords, err = indexer.GetByAddress(addr1, 0, 10)
+	require.NoError(err)
+	require.Equal(records[1:], addrRecords)
+
+	addrRecords, err = indexer.GetByAddress(ids.GenerateTestShortID(), 0, 10)
+	require.NoError(err)
+	require.Empty(addrRecords)
+
+	// New records are appended to the existing histories.
+	record := &Record{
+		RewardTxID:   ids.GenerateTestID(),
+		StakerTxID:   ids.GenerateTestID(),
+		NodeID:       nodeID,
+		RewardOwners: []ids.ShortID{addr1},
+		Height:       12,
+	}
+	require.NoError(indexer.Accept(record))
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 2, 10)
+	require.NoError(err)
+	require.Equal([]*Record{record}, nodeRecords)
+
+	addrRecords, err = indexer.GetByAddress(addr1, 2, 10)
+	require.NoError(err)
+	require.Equal([]*Record{record}, addrRecords)
+
+	// Aborted records are dropped.
+	indexer.Abort()
+
+	nodeRecords, err = indexer.GetByNodeID(nodeID, 0, 10)
+	require.NoError(err)
+	require.Equal(records[:2], nodeRecords)
+}
+
+func TestNoIndexer(t *testing.T) {
+	require := require.New(t)
+
+	db := memdb.New()
+	indexer := NewNoIndexer(db)
+
+	record := &Record{
+		RewardTxID: ids.GenerateTestID(),
+		NodeID:     ids.GenerateTestNodeID(),
+	}
+	require.NoError(indexer.Accept(record))
+
+	_, err := indexer.GetByNodeID(record.NodeID, 0, 10)
+	require.ErrorIs(err, ErrIndexingDisabled)
+
+	_, err = indexer.GetByAddress(ids.GenerateTestShortID(), 0, 10)
+	require.ErrorIs(err, ErrIndexingDisabled)
+
+	batch, err := indexer.CommitBatch()
+	require.NoError(err)
+	require.NoError(batch.Write())
+
+	iter := db.NewIterator()
+	defer iter.Release()
+	require.False(iter.Next())
+}
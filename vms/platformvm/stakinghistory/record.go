@@ -0,0 +1,34 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package stakinghistory
+
+import "github.com/memeticofficial/pepecoingo/ids"
+
+// Record is the outcome of a RewardValidatorTx as seen by this node.
+type Record struct {
+	// RewardTxID is the ID of the RewardValidatorTx that removed the staker.
+	RewardTxID ids.ID `serialize:"true"`
+	// StakerTxID is the ID of the tx that added the staker.
+	StakerTxID ids.ID     `serialize:"true"`
+	NodeID     ids.NodeID `serialize:"true"`
+	SubnetID   ids.ID     `serialize:"true"`
+	// IsDelegator is true if the removed staker was a delegator.
+	IsDelegator bool `serialize:"true"`
+	// StartTime and EndTime are the staking period, in unix seconds.
+	StartTime       uint64 `serialize:"true"`
+	EndTime         uint64 `serialize:"true"`
+	Weight          uint64 `serialize:"true"`
+	PotentialReward uint64 `serialize:"true"`
+	// Rewarded is true if the RewardValidatorTx was committed.
+	Rewarded bool `serialize:"true"`
+	// Reward is the sum of the reward UTXOs created by the RewardValidatorTx.
+	Reward uint64 `serialize:"true"`
+	// Uptime is the uptime of the validator measured by this node, denominated
+	// in [reward.PercentDenominator].
+	Uptime uint32 `serialize:"true"`
+	// RewardOwners are the addresses that own the rewards of the staker.
+	RewardOwners []ids.ShortID `serialize:"true"`
+	// Height is the height of the block that accepted the outcome.
+	Height uint64 `serialize:"true"`
+}
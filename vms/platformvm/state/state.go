@@ -32,6 +32,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/components/avax"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/blocks"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/config"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/genesis"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
@@ -52,6 +53,7 @@ var (
 	_ State = (*state)(nil)
 
 	ErrDelegatorSubset              = errors.New("delegator's time range must be a subset of the validator's time range")
+	ErrIsNotSubnet                  = errors.New("is not a subnet")
 	errMissingValidatorSet          = errors.New("missing validator set")
 	errValidatorSetAlreadyPopulated = errors.New("validator set already populated")
 	errDuplicateValidatorSet        = errors.New("duplicate validator set")
@@ -70,6 +72,7 @@ var (
 	rewardUTXOsPrefix             = []byte("rewardUTXOs")
 	utxoPrefix                    = []byte("utxo")
 	subnetPrefix                  = []byte("subnet")
+	subnetOwnerPrefix             = []byte("subnetOwner")
 	transformedSubnetPrefix       = []byte("transformedSubnet")
 	supplyPrefix                  = []byte("supply")
 	chainPrefix                   = []byte("chain")
@@ -101,6 +104,9 @@ type Chain interface {
 	GetSubnets() ([]*txs.Tx, error)
 	AddSubnet(createSubnetTx *txs.Tx)
 
+	GetSubnetOwner(subnetID ids.ID) (fx.Owner, error)
+	SetSubnetOwner(subnetID ids.ID, owner fx.Owner)
+
 	GetSubnetTransformation(subnetID ids.ID) (*txs.Tx, error)
 	AddSubnetTransformation(transformSubnetTx *txs.Tx)
 
@@ -278,6 +284,10 @@ type state struct {
 	subnetBaseDB  database.Database
 	subnetDB      linkeddb.LinkedDB
 
+	subnetOwners     map[ids.ID]fx.Owner            // map of subnetID -> owner
+	subnetOwnerCache cache.Cacher[ids.ID, fx.Owner] // cache of subnetID -> owner
+	subnetOwnerDB    database.Database
+
 	transformedSubnets     map[ids.ID]*txs.Tx            // map of subnetID -> transformSubnetTx
 	transformedSubnetCache cache.Cacher[ids.ID, *txs.Tx] // cache of subnetID -> transformSubnetTx if the entry is nil, it is not in the database
 	transformedSubnetDB    database.Database
@@ -335,6 +345,37 @@ type txAndStatus struct {
 	status status.Status
 }
 
+// DatabasePrefixes returns the prefixes, keyed by name, that the state stores
+// its data under in the database provided to New. Data that is stored under
+// prefixes that depend on the contents of the state, such as reward UTXOs,
+// validator diffs and chains, is not included.
+func DatabasePrefixes() map[string][]byte {
+	prefixes := map[string][]byte{
+		"block":             prefixdb.MakePrefix(blockPrefix),
+		"tx":                prefixdb.MakePrefix(txPrefix),
+		"subnet":            prefixdb.MakePrefix(subnetPrefix),
+		"subnetOwner":       prefixdb.MakePrefix(subnetOwnerPrefix),
+		"transformedSubnet": prefixdb.MakePrefix(transformedSubnetPrefix),
+		"supply":            prefixdb.MakePrefix(supplyPrefix),
+		"singleton":         prefixdb.MakePrefix(singletonPrefix),
+	}
+	for _, stakersPrefix := range [][]byte{currentPrefix, pendingPrefix} {
+		for _, stakerPrefix := range [][]byte{
+			validatorPrefix,
+			delegatorPrefix,
+			subnetValidatorPrefix,
+			subnetDelegatorPrefix,
+		} {
+			name := fmt.Sprintf("%s/%s/%s", validatorsPrefix, stakersPrefix, stakerPrefix)
+			prefixes[name] = prefixdb.MakePrefix(validatorsPrefix, stakersPrefix, stakerPrefix)
+		}
+	}
+	for name, prefix := range avax.UTXOStatePrefixes(utxoPrefix) {
+		prefixes["utxo/"+name] = prefix
+	}
+	return prefixes
+}
+
 func New(
 	db database.Database,
 	genesisBytes []byte,
@@ -449,6 +490,15 @@ func new(
 
 	subnetBaseDB := prefixdb.New(subnetPrefix, baseDB)
 
+	subnetOwnerCache, err := metercacher.New[ids.ID, fx.Owner](
+		"subnet_owner_cache",
+		metricsReg,
+		&cache.LRU[ids.ID, fx.Owner]{Size: chainCacheSize},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	transformedSubnetCache, err := metercacher.New[ids.ID, *txs.Tx](
 		"transformed_subnet_cache",
 		metricsReg,
@@ -541,6 +591,10 @@ func new(
 		subnetBaseDB: subnetBaseDB,
 		subnetDB:     linkeddb.NewDefault(subnetBaseDB),
 
+		subnetOwners:     make(map[ids.ID]fx.Owner),
+		subnetOwnerCache: subnetOwnerCache,
+		subnetOwnerDB:    prefixdb.New(subnetOwnerPrefix, baseDB),
+
 		transformedSubnets:     make(map[ids.ID]*txs.Tx),
 		transformedSubnetCache: transformedSubnetCache,
 		transformedSubnetDB:    prefixdb.New(transformedSubnetPrefix, baseDB),
@@ -659,6 +713,48 @@ func (s *state) AddSubnet(createSubnetTx *txs.Tx) {
 	}
 }
 
+func (s *state) GetSubnetOwner(subnetID ids.ID) (fx.Owner, error) {
+	if owner, exists := s.subnetOwners[subnetID]; exists {
+		return owner, nil
+	}
+
+	if owner, cached := s.subnetOwnerCache.Get(subnetID); cached {
+		return owner, nil
+	}
+
+	ownerBytes, err := s.subnetOwnerDB.Get(subnetID[:])
+	if err == nil {
+		var owner fx.Owner
+		if _, err := txs.GenesisCodec.Unmarshal(ownerBytes, &owner); err != nil {
+			return nil, err
+		}
+		s.subnetOwnerCache.Put(subnetID, owner)
+		return owner, nil
+	}
+	if err != database.ErrNotFound {
+		return nil, err
+	}
+
+	// The owner was never explicitly set, so it is the owner specified in the
+	// tx that created the subnet.
+	subnetIntf, _, err := s.GetTx(subnetID)
+	if err != nil {
+		return nil, err
+	}
+
+	subnet, ok := subnetIntf.Unsigned.(*txs.CreateSubnetTx)
+	if !ok {
+		return nil, fmt.Errorf("%q %w", subnetID, ErrIsNotSubnet)
+	}
+
+	s.subnetOwnerCache.Put(subnetID, subnet.Owner)
+	return subnet.Owner, nil
+}
+
+func (s *state) SetSubnetOwner(subnetID ids.ID, owner fx.Owner) {
+	s.subnetOwners[subnetID] = owner
+}
+
 func (s *state) GetSubnetTransformation(subnetID ids.ID) (*txs.Tx, error) {
 	if tx, exists := s.transformedSubnets[subnetID]; exists {
 		return tx, nil
@@ -1373,6 +1469,7 @@ func (s *state) write(updateValidators bool, height uint64) error {
 		s.writeRewardUTXOs(),
 		s.writeUTXOs(),
 		s.writeSubnets(),
+		s.writeSubnetOwners(),
 		s.writeTransformedSubnets(),
 		s.writeSubnetSupplies(),
 		s.writeChains(),
@@ -1399,6 +1496,7 @@ func (s *state) Close() error {
 		s.rewardUTXODB.Close(),
 		s.utxoDB.Close(),
 		s.subnetBaseDB.Close(),
+		s.subnetOwnerDB.Close(),
 		s.transformedSubnetDB.Close(),
 		s.supplyDB.Close(),
 		s.chainDB.Close(),
@@ -1883,6 +1981,26 @@ func (s *state) writeSubnets() error {
 	return nil
 }
 
+func (s *state) writeSubnetOwners() error {
+	for subnetID, owner := range s.subnetOwners {
+		subnetID := subnetID
+		owner := owner
+		delete(s.subnetOwners, subnetID)
+
+		ownerBytes, err := txs.GenesisCodec.Marshal(txs.Version, &owner)
+		if err != nil {
+			return fmt.Errorf("failed to marshal subnet owner: %w", err)
+		}
+
+		s.subnetOwnerCache.Put(subnetID, owner)
+
+		if err := s.subnetOwnerDB.Put(subnetID[:], ownerBytes); err != nil {
+			return fmt.Errorf("failed to write subnet owner: %w", err)
+		}
+	}
+	return nil
+}
+
 func (s *state) writeTransformedSubnets() error {
 	for subnetID, tx := range s.transformedSubnets {
 		txID := tx.ID()
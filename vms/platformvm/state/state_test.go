@@ -30,6 +30,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/genesis"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/status"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 	"github.com/memeticofficial/pepecoingo/vms/secp256k1fx"
 )
@@ -59,6 +60,29 @@ func TestStateInitialization(t *testing.T) {
 	require.False(shouldInit)
 }
 
+func TestDatabasePrefixes(t *testing.T) {
+	require := require.New(t)
+	s, db := newInitializedState(require)
+	require.NoError(s.Commit())
+
+	prefixes := DatabasePrefixes()
+	for _, name := range []string{
+		"block",
+		"tx",
+		"singleton",
+		"validators/current/validator",
+		"utxo/utxo",
+	} {
+		prefix, ok := prefixes[name]
+		require.True(ok, name)
+
+		it := db.NewIteratorWithPrefix(prefix)
+		require.True(it.Next(), name)
+		require.NoError(it.Error())
+		it.Release()
+	}
+}
+
 func TestStateSyncGenesis(t *testing.T) {
 	require := require.New(t)
 	state, _ := newInitializedState(require)
@@ -834,3 +858,52 @@ func TestStateAddRemoveValidator(t *testing.T) {
 		require.Equal(diff.expectedPublicKeyDiff, gotPublicKeyDiffs)
 	}
 }
+
+func TestStateSubnetOwner(t *testing.T) {
+	require := require.New(t)
+
+	state, db := newInitializedState(require)
+
+	var (
+		owner1 = &secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs:     []ids.ShortID{ids.GenerateTestShortID()},
+		}
+		owner2 = &secp256k1fx.OutputOwners{
+			Threshold: 1,
+			Addrs:     []ids.ShortID{ids.GenerateTestShortID()},
+		}
+		createSubnetTx = &txs.Tx{
+			Unsigned: &txs.CreateSubnetTx{
+				BaseTx: txs.BaseTx{},
+				Owner:  owner1,
+			},
+		}
+	)
+	require.NoError(createSubnetTx.Initialize(txs.Codec))
+	subnetID := createSubnetTx.ID()
+
+	_, err := state.GetSubnetOwner(subnetID)
+	require.ErrorIs(err, database.ErrNotFound)
+
+	state.AddTx(createSubnetTx, status.Committed)
+	state.AddSubnet(createSubnetTx)
+	require.NoError(state.Commit())
+
+	// The owner defaults to the owner specified when creating the subnet.
+	owner, err := state.GetSubnetOwner(subnetID)
+	require.NoError(err)
+	require.Equal(owner1, owner)
+
+	state.SetSubnetOwner(subnetID, owner2)
+	owner, err = state.GetSubnetOwner(subnetID)
+	require.NoError(err)
+	require.Equal(owner2, owner)
+	require.NoError(state.Commit())
+
+	// The modified owner is persisted.
+	state = newStateFromDB(require, db)
+	owner, err = state.GetSubnetOwner(subnetID)
+	require.NoError(err)
+	require.Equal(owner2, owner)
+}
@@ -41,6 +41,10 @@ func init() {
 		c.SkipRegistrations(5)
 
 		errs.Add(RegisterUnsignedTxsTypes(c))
+
+		c.SkipRegistrations(4)
+
+		errs.Add(RegisterDurangoUnsignedTxsTypes(c))
 	}
 	errs.Add(
 		Codec.RegisterCodec(Version, c),
@@ -94,3 +98,10 @@ func RegisterUnsignedTxsTypes(targetCodec codec.Registry) error {
 	)
 	return errs.Err
 }
+
+// RegisterDurangoUnsignedTxsTypes registers the tx types added in the Durango
+// upgrade. They must be registered after the Banff block types so that the
+// typeIDs of previously registered types are unchanged.
+func RegisterDurangoUnsignedTxsTypes(targetCodec codec.Registry) error {
+	return targetCodec.RegisterType(&TransferSubnetOwnershipTx{})
+}
@@ -63,6 +63,11 @@ type ProposalTxExecutor struct {
 	// [PrefersCommit] is true iff this node initially prefers to
 	// commit this block transaction.
 	PrefersCommit bool
+	// [RewardedStaker] is the staker removed by a RewardValidatorTx.
+	RewardedStaker *state.Staker
+	// [Uptime] is the uptime this node measured for the validator of
+	// [RewardedStaker].
+	Uptime float64
 }
 
 func (*ProposalTxExecutor) CreateChainTx(*txs.CreateChainTx) error {
@@ -89,6 +94,10 @@ func (*ProposalTxExecutor) TransformSubnetTx(*txs.TransformSubnetTx) error {
 	return ErrWrongTxType
 }
 
+func (*ProposalTxExecutor) TransferSubnetOwnershipTx(*txs.TransferSubnetOwnershipTx) error {
+	return ErrWrongTxType
+}
+
 func (*ProposalTxExecutor) AddPermissionlessValidatorTx(*txs.AddPermissionlessValidatorTx) error {
 	return ErrWrongTxType
 }
@@ -514,14 +523,7 @@ func (e *ProposalTxExecutor) RewardValidatorTx(tx *txs.RewardValidatorTx) error
 
 		// Calculate split of reward between delegator/delegatee
 		// The delegator gives stake to the validatee
-		validatorShares := vdrTx.Shares()
-		delegatorShares := reward.PercentDenominator - uint64(validatorShares)                            // parentTx.Shares <= reward.PercentDenominator so no underflow
-		delegatorReward := delegatorShares * (stakerToRemove.PotentialReward / reward.PercentDenominator) // delegatorShares <= reward.PercentDenominator so no overflow
-		// Delay rounding as long as possible for small numbers
-		if optimisticReward, err := math.Mul64(delegatorShares, stakerToRemove.PotentialReward); err == nil {
-			delegatorReward = optimisticReward / reward.PercentDenominator
-		}
-		delegateeReward := stakerToRemove.PotentialReward - delegatorReward // delegatorReward <= reward so no underflow
+		delegateeReward, delegatorReward := reward.Split(stakerToRemove.PotentialReward, vdrTx.Shares())
 
 		offset := 0
 
@@ -648,6 +650,8 @@ func (e *ProposalTxExecutor) RewardValidatorTx(tx *txs.RewardValidatorTx) error
 	}
 
 	e.PrefersCommit = uptime >= expectedUptimePercentage
+	e.RewardedStaker = stakerToRemove
+	e.Uptime = uptime
 	return nil
 }
 
@@ -66,6 +66,10 @@ func (v *MempoolTxVerifier) TransformSubnetTx(tx *txs.TransformSubnetTx) error {
 	return v.standardTx(tx)
 }
 
+func (v *MempoolTxVerifier) TransferSubnetOwnershipTx(tx *txs.TransferSubnetOwnershipTx) error {
+	return v.standardTx(tx)
+}
+
 func (v *MempoolTxVerifier) AddPermissionlessValidatorTx(tx *txs.AddPermissionlessValidatorTx) error {
 	return v.standardTx(tx)
 }
@@ -5,7 +5,11 @@ package mempool
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/math"
+	"github.com/memeticofficial/pepecoingo/vms/components/avax"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 )
 
@@ -30,56 +34,118 @@ func (*issuer) RewardValidatorTx(*txs.RewardValidatorTx) error {
 }
 
 func (i *issuer) AddValidatorTx(*txs.AddValidatorTx) error {
-	i.m.addStakerTx(i.tx)
-	return nil
+	return i.m.addStakerTx(i.tx)
 }
 
 func (i *issuer) AddSubnetValidatorTx(*txs.AddSubnetValidatorTx) error {
-	i.m.addStakerTx(i.tx)
-	return nil
+	return i.m.addStakerTx(i.tx)
 }
 
 func (i *issuer) AddDelegatorTx(*txs.AddDelegatorTx) error {
-	i.m.addStakerTx(i.tx)
-	return nil
+	return i.m.addStakerTx(i.tx)
 }
 
-func (i *issuer) RemoveSubnetValidatorTx(*txs.RemoveSubnetValidatorTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) RemoveSubnetValidatorTx(tx *txs.RemoveSubnetValidatorTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, nil)
 }
 
-func (i *issuer) CreateChainTx(*txs.CreateChainTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) CreateChainTx(tx *txs.CreateChainTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, nil)
 }
 
-func (i *issuer) CreateSubnetTx(*txs.CreateSubnetTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) CreateSubnetTx(tx *txs.CreateSubnetTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, nil)
 }
 
-func (i *issuer) ImportTx(*txs.ImportTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) ImportTx(tx *txs.ImportTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, tx.ImportedInputs, nil)
 }
 
-func (i *issuer) ExportTx(*txs.ExportTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) ExportTx(tx *txs.ExportTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, tx.ExportedOutputs)
 }
 
-func (i *issuer) TransformSubnetTx(*txs.TransformSubnetTx) error {
-	i.m.addDecisionTx(i.tx)
-	return nil
+func (i *issuer) TransformSubnetTx(tx *txs.TransformSubnetTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, nil)
+}
+
+func (i *issuer) TransferSubnetOwnershipTx(tx *txs.TransferSubnetOwnershipTx) error {
+	return i.addDecisionTx(tx.Ins, tx.Outs, nil, nil)
 }
 
 func (i *issuer) AddPermissionlessValidatorTx(*txs.AddPermissionlessValidatorTx) error {
-	i.m.addStakerTx(i.tx)
-	return nil
+	return i.m.addStakerTx(i.tx)
 }
 
 func (i *issuer) AddPermissionlessDelegatorTx(*txs.AddPermissionlessDelegatorTx) error {
-	i.m.addStakerTx(i.tx)
-	return nil
+	return i.m.addStakerTx(i.tx)
+}
+
+// addDecisionTx adds the tx to the mempool with a fee equal to the amount of
+// AVAX consumed by [ins] and [importedIns] but not produced by [outs] and
+// [exportedOuts].
+func (i *issuer) addDecisionTx(
+	ins []*avax.TransferableInput,
+	outs []*avax.TransferableOutput,
+	importedIns []*avax.TransferableInput,
+	exportedOuts []*avax.TransferableOutput,
+) error {
+	consumed, err := avaxConsumed(i.m.avaxAssetID, ins, importedIns)
+	if err != nil {
+		return err
+	}
+	produced, err := avaxProduced(i.m.avaxAssetID, outs, exportedOuts)
+	if err != nil {
+		return err
+	}
+	fee, err := math.Sub(consumed, produced)
+	if err != nil {
+		return fmt.Errorf("%w: tx %s consumes %d and produces %d",
+			errNegativeFee,
+			i.tx.ID(),
+			consumed,
+			produced,
+		)
+	}
+	return i.m.addDecisionTx(i.tx, fee)
+}
+
+// avaxConsumed returns the amount of [avaxAssetID] consumed by [inputSets].
+func avaxConsumed(avaxAssetID ids.ID, inputSets ...[]*avax.TransferableInput) (uint64, error) {
+	var (
+		amount uint64
+		err    error
+	)
+	for _, ins := range inputSets {
+		for _, in := range ins {
+			if in.AssetID() != avaxAssetID {
+				continue
+			}
+			amount, err = math.Add64(amount, in.Input().Amount())
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return amount, nil
+}
+
+// avaxProduced returns the amount of [avaxAssetID] produced by [outputSets].
+func avaxProduced(avaxAssetID ids.ID, outputSets ...[]*avax.TransferableOutput) (uint64, error) {
+	var (
+		amount uint64
+		err    error
+	)
+	for _, outs := range outputSets {
+		for _, out := range outs {
+			if out.AssetID() != avaxAssetID {
+				continue
+			}
+			amount, err = math.Add64(amount, out.Output().Amount())
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return amount, nil
 }
@@ -6,11 +6,15 @@ package mempool
 import (
 	"errors"
 	"fmt"
+	"sort"
+
+	stdmath "math"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/memeticofficial/pepecoingo/cache"
 	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/math"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 	"github.com/memeticofficial/pepecoingo/utils/units"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
@@ -29,12 +33,22 @@ const (
 
 	// maxMempoolSize is the maximum number of bytes allowed in the mempool
 	maxMempoolSize = 64 * units.MiB
+
+	// replacementFeeRateBumpDivisor is the inverse of the minimum fraction by
+	// which the fee rate of a tx must exceed the fee rate of every decision tx
+	// it conflicts with to replace them.
+	replacementFeeRateBumpDivisor = 10
 )
 
 var (
 	_ Mempool = (*mempool)(nil)
 
-	errMempoolFull = errors.New("mempool is full")
+	errMempoolFull                = errors.New("mempool is full")
+	errConflictingTx              = errors.New("tx conflicts with a transaction in the mempool")
+	errInsufficientReplacementFee = errors.New("fee rate is too low to replace conflicting transaction")
+	errNegativeFee                = errors.New("tx produces more than it consumes")
+	errReplaced                   = errors.New("replaced by a transaction paying a higher fee")
+	errEvicted                    = errors.New("evicted by a transaction paying a higher fee")
 )
 
 type BlockTimer interface {
@@ -75,6 +89,39 @@ type Mempool interface {
 	// reissued.
 	MarkDropped(txID ids.ID, reason error)
 	GetDropReason(txID ids.ID) error
+
+	// List returns the txs in the mempool. Decision txs are returned first, in
+	// the order they will be issued, followed by the staker txs.
+	List() []TxInfo
+	// Stats returns the number of txs and bytes in the mempool.
+	Stats() Stats
+}
+
+// TxInfo describes a tx in the mempool.
+type TxInfo struct {
+	Tx *txs.Tx
+	// IsDecisionTx is true if [Tx] is ordered by fee rate. Staker txs are
+	// ordered by start time and have no fee rate.
+	IsDecisionTx bool
+	// Fee is the amount of AVAX burned by a decision tx.
+	Fee uint64
+	// FeeRate is [Fee] per byte of the decision tx, rounded down.
+	FeeRate uint64
+}
+
+// Stats describes the contents of the mempool.
+type Stats struct {
+	NumDecisionTxs int
+	NumStakerTxs   int
+	BytesUsed      int
+	BytesAvailable int
+}
+
+type decisionTxFee struct {
+	feeRate txheap.FeeRate
+	// seq breaks ties between txs with the same fee rate so that the oldest tx
+	// is issued first.
+	seq uint64
 }
 
 // Transactions from clients that have not yet been put into blocks and added to
@@ -86,14 +133,26 @@ type mempool struct {
 	bytesAvailableMetric prometheus.Gauge
 	bytesAvailable       int
 
-	unissuedDecisionTxs txheap.Heap
-	unissuedStakerTxs   txheap.Heap
+	avaxAssetID ids.ID
+
+	// unissuedDecisionTxs is ordered by decreasing fee rate and
+	// lowestFeeDecisionTxs contains the same txs in the reverse order.
+	unissuedDecisionTxs  txheap.Heap
+	lowestFeeDecisionTxs txheap.Heap
+	unissuedStakerTxs    txheap.Heap
+
+	// Key: Tx ID
+	// Value: Fee of the decision tx
+	decisionTxFees map[ids.ID]decisionTxFee
+	nextSeq        uint64
 
 	// Key: Tx ID
 	// Value: Verification error
 	droppedTxIDs *cache.LRU[ids.ID, error]
 
-	consumedUTXOs set.Set[ids.ID]
+	// Key: UTXO ID
+	// Value: ID of the tx consuming the UTXO
+	consumedUTXOs map[ids.ID]ids.ID
 
 	blkTimer BlockTimer
 }
@@ -101,6 +160,7 @@ type mempool struct {
 func NewMempool(
 	namespace string,
 	registerer prometheus.Registerer,
+	avaxAssetID ids.ID,
 	blkTimer BlockTimer,
 ) (Mempool, error) {
 	bytesAvailableMetric := prometheus.NewGauge(prometheus.GaugeOpts{
@@ -112,16 +172,29 @@ func NewMempool(
 		return nil, err
 	}
 
-	unissuedDecisionTxs, err := txheap.NewWithMetrics(
-		txheap.NewByAge(),
+	m := &mempool{
+		bytesAvailableMetric: bytesAvailableMetric,
+		bytesAvailable:       maxMempoolSize,
+		avaxAssetID:          avaxAssetID,
+		decisionTxFees:       make(map[ids.ID]decisionTxFee),
+		droppedTxIDs:         &cache.LRU[ids.ID, error]{Size: droppedTxIDsCacheSize},
+		consumedUTXOs:        make(map[ids.ID]ids.ID, initialConsumedUTXOsSize),
+		dropIncoming:         false, // enable tx adding by default
+		blkTimer:             blkTimer,
+	}
+
+	var err error
+	m.unissuedDecisionTxs, err = txheap.NewWithMetrics(
+		txheap.NewByFeeRate(m.feeRate),
 		fmt.Sprintf("%s_decision_txs", namespace),
 		registerer,
 	)
 	if err != nil {
 		return nil, err
 	}
+	m.lowestFeeDecisionTxs = txheap.NewByLowestFeeRate(m.feeRate)
 
-	unissuedStakerTxs, err := txheap.NewWithMetrics(
+	m.unissuedStakerTxs, err = txheap.NewWithMetrics(
 		txheap.NewByStartTime(),
 		fmt.Sprintf("%s_staker_txs", namespace),
 		registerer,
@@ -131,16 +204,7 @@ func NewMempool(
 	}
 
 	bytesAvailableMetric.Set(maxMempoolSize)
-	return &mempool{
-		bytesAvailableMetric: bytesAvailableMetric,
-		bytesAvailable:       maxMempoolSize,
-		unissuedDecisionTxs:  unissuedDecisionTxs,
-		unissuedStakerTxs:    unissuedStakerTxs,
-		droppedTxIDs:         &cache.LRU[ids.ID, error]{Size: droppedTxIDsCacheSize},
-		consumedUTXOs:        set.NewSet[ids.ID](initialConsumedUTXOsSize),
-		dropIncoming:         false, // enable tx adding by default
-		blkTimer:             blkTimer,
-	}, nil
+	return m, nil
 }
 
 func (m *mempool) EnableAdding() {
@@ -166,19 +230,6 @@ func (m *mempool) Add(tx *txs.Tx) error {
 	if len(txBytes) > targetTxSize {
 		return fmt.Errorf("tx %s size (%d) > target size (%d)", txID, len(txBytes), targetTxSize)
 	}
-	if len(txBytes) > m.bytesAvailable {
-		return fmt.Errorf("%w, tx %s size (%d) exceeds available space (%d)",
-			errMempoolFull,
-			txID,
-			len(txBytes),
-			m.bytesAvailable,
-		)
-	}
-
-	inputs := tx.Unsigned.InputIDs()
-	if m.consumedUTXOs.Overlaps(inputs) {
-		return fmt.Errorf("tx %s conflicts with a transaction in the mempool", txID)
-	}
 
 	if err := tx.Unsigned.Visit(&issuer{
 		m:  m,
@@ -187,9 +238,6 @@ func (m *mempool) Add(tx *txs.Tx) error {
 		return err
 	}
 
-	// Mark these UTXOs as consumed in the mempool
-	m.consumedUTXOs.Union(inputs)
-
 	// An explicitly added tx must not be marked as dropped.
 	m.droppedTxIDs.Evict(txID)
 
@@ -224,7 +272,7 @@ func (m *mempool) HasTxs() bool {
 }
 
 func (m *mempool) PeekTxs(maxTxsBytes int) []*txs.Tx {
-	txs := m.unissuedDecisionTxs.List()
+	txs := m.sortedDecisionTxs()
 	txs = append(txs, m.unissuedStakerTxs.List()...)
 
 	size := 0
@@ -237,14 +285,138 @@ func (m *mempool) PeekTxs(maxTxsBytes int) []*txs.Tx {
 	return txs
 }
 
-func (m *mempool) addDecisionTx(tx *txs.Tx) {
+// addDecisionTx adds [tx], which burns [fee], to the mempool.
+//
+// If [tx] conflicts with other decision txs, they are replaced if the fee rate
+// of [tx] is sufficiently higher than theirs. If the mempool is full, decision
+// txs with a lower fee rate than [tx] are evicted to make room for [tx].
+func (m *mempool) addDecisionTx(tx *txs.Tx, fee uint64) error {
+	txID := tx.ID()
+	size := len(tx.Bytes())
+	feeRate := txheap.FeeRate{
+		Fee:  fee,
+		Size: uint64(size),
+	}
+
+	var (
+		conflicts      []*txs.Tx
+		conflictIDs    = set.Set[ids.ID]{}
+		bytesAvailable = m.bytesAvailable
+	)
+	for inputID := range tx.Unsigned.InputIDs() {
+		conflictID, ok := m.consumedUTXOs[inputID]
+		if !ok || conflictIDs.Contains(conflictID) {
+			continue
+		}
+
+		conflict := m.unissuedDecisionTxs.Get(conflictID)
+		if conflict == nil {
+			// Staker txs are ordered by start time, so they can't be replaced.
+			return fmt.Errorf("%w: tx %s conflicts with staker tx %s",
+				errConflictingTx,
+				txID,
+				conflictID,
+			)
+		}
+
+		conflictFeeRate, _ := m.feeRate(conflict)
+		minFeeRate := minReplacementFeeRate(conflictFeeRate)
+		if feeRate.Compare(minFeeRate) < 0 {
+			return fmt.Errorf("%w: tx %s fee rate (%s) < minimum fee rate (%s) to replace tx %s",
+				errInsufficientReplacementFee,
+				txID,
+				feeRate,
+				minFeeRate,
+				conflictID,
+			)
+		}
+
+		conflicts = append(conflicts, conflict)
+		conflictIDs.Add(conflictID)
+		bytesAvailable += len(conflict.Bytes())
+	}
+
+	// Select the lowest priority txs to evict until there is enough space for
+	// [tx]. Only txs with a lower fee rate than [tx] may be evicted.
+	var (
+		popped  []*txs.Tx
+		evicted []*txs.Tx
+	)
+	for bytesAvailable < size && m.lowestFeeDecisionTxs.Len() > 0 {
+		lowest := m.lowestFeeDecisionTxs.Peek()
+		if lowestFeeRate, _ := m.feeRate(lowest); lowestFeeRate.Compare(feeRate) >= 0 {
+			break
+		}
+
+		m.lowestFeeDecisionTxs.RemoveTop()
+		popped = append(popped, lowest)
+		if conflictIDs.Contains(lowest.ID()) {
+			// The space of conflicting txs has already been accounted for.
+			continue
+		}
+		evicted = append(evicted, lowest)
+		bytesAvailable += len(lowest.Bytes())
+	}
+	if bytesAvailable < size {
+		// Restore the txs that were selected for eviction. The sequence
+		// numbers are unchanged, so their order is preserved.
+		for _, tx := range popped {
+			m.lowestFeeDecisionTxs.Add(tx)
+		}
+		return fmt.Errorf("%w, tx %s size (%d) exceeds available space (%d)",
+			errMempoolFull,
+			txID,
+			size,
+			m.bytesAvailable,
+		)
+	}
+
+	for _, conflict := range conflicts {
+		m.removeDecisionTx(conflict)
+		m.MarkDropped(conflict.ID(), fmt.Errorf("%w: %s", errReplaced, txID))
+	}
+	for _, tx := range evicted {
+		m.removeDecisionTx(tx)
+		m.MarkDropped(tx.ID(), fmt.Errorf("%w: %s", errEvicted, txID))
+	}
+
+	m.decisionTxFees[txID] = decisionTxFee{
+		feeRate: feeRate,
+		seq:     m.nextSeq,
+	}
+	m.nextSeq++
+
 	m.unissuedDecisionTxs.Add(tx)
+	m.lowestFeeDecisionTxs.Add(tx)
 	m.register(tx)
+	return nil
 }
 
-func (m *mempool) addStakerTx(tx *txs.Tx) {
+func (m *mempool) addStakerTx(tx *txs.Tx) error {
+	txID := tx.ID()
+	size := len(tx.Bytes())
+	if size > m.bytesAvailable {
+		return fmt.Errorf("%w, tx %s size (%d) exceeds available space (%d)",
+			errMempoolFull,
+			txID,
+			size,
+			m.bytesAvailable,
+		)
+	}
+
+	for inputID := range tx.Unsigned.InputIDs() {
+		if conflictID, ok := m.consumedUTXOs[inputID]; ok {
+			return fmt.Errorf("%w: tx %s conflicts with tx %s",
+				errConflictingTx,
+				txID,
+				conflictID,
+			)
+		}
+	}
+
 	m.unissuedStakerTxs.Add(tx)
 	m.register(tx)
+	return nil
 }
 
 func (m *mempool) HasStakerTx() bool {
@@ -253,11 +425,18 @@ func (m *mempool) HasStakerTx() bool {
 
 func (m *mempool) removeDecisionTxs(txs []*txs.Tx) {
 	for _, tx := range txs {
-		txID := tx.ID()
-		if m.unissuedDecisionTxs.Remove(txID) != nil {
-			m.deregister(tx)
-		}
+		m.removeDecisionTx(tx)
+	}
+}
+
+func (m *mempool) removeDecisionTx(tx *txs.Tx) {
+	txID := tx.ID()
+	if m.unissuedDecisionTxs.Remove(txID) == nil {
+		return
 	}
+	m.lowestFeeDecisionTxs.Remove(txID)
+	m.deregister(tx)
+	delete(m.decisionTxFees, txID)
 }
 
 func (m *mempool) removeStakerTx(tx *txs.Tx) {
@@ -284,10 +463,66 @@ func (m *mempool) GetDropReason(txID ids.ID) error {
 	return err
 }
 
+func (m *mempool) List() []TxInfo {
+	decisionTxs := m.sortedDecisionTxs()
+	stakerTxs := m.unissuedStakerTxs.List()
+	sort.SliceStable(stakerTxs, func(i, j int) bool {
+		iTime := stakerTxs[i].Unsigned.(txs.Staker).StartTime()
+		jTime := stakerTxs[j].Unsigned.(txs.Staker).StartTime()
+		return iTime.Before(jTime)
+	})
+
+	infos := make([]TxInfo, 0, len(decisionTxs)+len(stakerTxs))
+	for _, tx := range decisionTxs {
+		fee := m.decisionTxFees[tx.ID()]
+		infos = append(infos, TxInfo{
+			Tx:           tx,
+			IsDecisionTx: true,
+			Fee:          fee.feeRate.Fee,
+			FeeRate:      fee.feeRate.Fee / fee.feeRate.Size,
+		})
+	}
+	for _, tx := range stakerTxs {
+		infos = append(infos, TxInfo{
+			Tx: tx,
+		})
+	}
+	return infos
+}
+
+func (m *mempool) Stats() Stats {
+	return Stats{
+		NumDecisionTxs: m.unissuedDecisionTxs.Len(),
+		NumStakerTxs:   m.unissuedStakerTxs.Len(),
+		BytesUsed:      maxMempoolSize - m.bytesAvailable,
+		BytesAvailable: m.bytesAvailable,
+	}
+}
+
+// sortedDecisionTxs returns the decision txs in order of decreasing priority.
+func (m *mempool) sortedDecisionTxs() []*txs.Tx {
+	decisionTxs := m.unissuedDecisionTxs.List()
+	sort.Slice(decisionTxs, func(i, j int) bool {
+		return txheap.HigherFeeRate(m.feeRate, decisionTxs[i], decisionTxs[j])
+	})
+	return decisionTxs
+}
+
+func (m *mempool) feeRate(tx *txs.Tx) (txheap.FeeRate, uint64) {
+	fee := m.decisionTxFees[tx.ID()]
+	return fee.feeRate, fee.seq
+}
+
 func (m *mempool) register(tx *txs.Tx) {
 	txBytes := tx.Bytes()
 	m.bytesAvailable -= len(txBytes)
 	m.bytesAvailableMetric.Set(float64(m.bytesAvailable))
+
+	// Mark these UTXOs as consumed in the mempool
+	txID := tx.ID()
+	for inputID := range tx.Unsigned.InputIDs() {
+		m.consumedUTXOs[inputID] = txID
+	}
 }
 
 func (m *mempool) deregister(tx *txs.Tx) {
@@ -295,6 +530,41 @@ func (m *mempool) deregister(tx *txs.Tx) {
 	m.bytesAvailable += len(txBytes)
 	m.bytesAvailableMetric.Set(float64(m.bytesAvailable))
 
-	inputs := tx.Unsigned.InputIDs()
-	m.consumedUTXOs.Difference(inputs)
+	for inputID := range tx.Unsigned.InputIDs() {
+		delete(m.consumedUTXOs, inputID)
+	}
+}
+
+// minReplacementFeeRate returns the minimum fee rate a tx must pay to replace
+// a tx paying [feeRate], which is [feeRate] plus the greater of
+// 1/[replacementFeeRateBumpDivisor] of [feeRate] and 1 per byte.
+func minReplacementFeeRate(feeRate txheap.FeeRate) txheap.FeeRate {
+	maxFeeRate := txheap.FeeRate{
+		Fee:  stdmath.MaxUint64,
+		Size: 1,
+	}
+
+	bumpedFee, err := math.Mul64(feeRate.Fee, replacementFeeRateBumpDivisor+1)
+	if err != nil {
+		return maxFeeRate
+	}
+	// [feeRate.Size] is at most [targetTxSize], so this can't overflow.
+	bumpedFeeRate := txheap.FeeRate{
+		Fee:  bumpedFee,
+		Size: feeRate.Size * replacementFeeRateBumpDivisor,
+	}
+
+	minFee, err := math.Add64(feeRate.Fee, feeRate.Size)
+	if err != nil {
+		return maxFeeRate
+	}
+	minFeeRate := txheap.FeeRate{
+		Fee:  minFee,
+		Size: feeRate.Size,
+	}
+
+	if bumpedFeeRate.Compare(minFeeRate) > 0 {
+		return bumpedFeeRate
+	}
+	return minFeeRate
 }
@@ -27,7 +27,10 @@ type noopBlkTimer struct{}
 
 func (*noopBlkTimer) ResetBlockTimer() {}
 
-var preFundedKeys = secp256k1.TestKeys()
+var (
+	preFundedKeys = secp256k1.TestKeys()
+	testAssetID   = ids.ID{'a', 's', 's', 'e', 'r', 't'}
+)
 
 // shows that valid tx is not added to mempool if this would exceed its maximum
 // size
@@ -35,7 +38,7 @@ func TestBlockBuilderMaxMempoolSizeHandling(t *testing.T) {
 	require := require.New(t)
 
 	registerer := prometheus.NewRegistry()
-	mpool, err := NewMempool("mempool", registerer, &noopBlkTimer{})
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
 	require.NoError(err)
 
 	decisionTxs, err := createTestDecisionTxs(1)
@@ -59,7 +62,7 @@ func TestDecisionTxsInMempool(t *testing.T) {
 	require := require.New(t)
 
 	registerer := prometheus.NewRegistry()
-	mpool, err := NewMempool("mempool", registerer, &noopBlkTimer{})
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
 	require.NoError(err)
 
 	decisionTxs, err := createTestDecisionTxs(2)
@@ -111,7 +114,7 @@ func TestProposalTxsInMempool(t *testing.T) {
 	require := require.New(t)
 
 	registerer := prometheus.NewRegistry()
-	mpool, err := NewMempool("mempool", registerer, &noopBlkTimer{})
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
 	require.NoError(err)
 
 	// The proposal txs are ordered by decreasing start time. This means after
@@ -172,44 +175,202 @@ func TestProposalTxsInMempool(t *testing.T) {
 	}
 }
 
+func TestDecisionTxsOrderedByFeeRate(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	lowTx, err := createTestDecisionTx(0, 100_000)
+	require.NoError(err)
+	highTx, err := createTestDecisionTx(1, 1_000_000)
+	require.NoError(err)
+	midTx, err := createTestDecisionTx(2, 500_000)
+	require.NoError(err)
+	// Has the same fee rate as [midTx] but was added later.
+	midTx2, err := createTestDecisionTx(3, 500_000)
+	require.NoError(err)
+
+	for _, tx := range []*txs.Tx{lowTx, highTx, midTx, midTx2} {
+		require.NoError(mpool.Add(tx))
+	}
+
+	expected := []*txs.Tx{highTx, midTx, midTx2, lowTx}
+	require.Equal(expected, mpool.PeekTxs(math.MaxInt))
+
+	infos := mpool.List()
+	require.Len(infos, len(expected))
+	for i, info := range infos {
+		require.Equal(expected[i], info.Tx)
+		require.True(info.IsDecisionTx)
+	}
+	require.Equal(uint64(1_000_000-1234), infos[0].Fee)
+	require.Equal(infos[0].Fee/uint64(len(highTx.Bytes())), infos[0].FeeRate)
+}
+
+// Txs whose fee rates only differ by a fraction of a unit per byte are still
+// ordered by fee rate.
+func TestDecisionTxsOrderedByFractionalFeeRate(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	sizeTx, err := createTestDecisionTx(0, 0)
+	require.NoError(err)
+	size := uint64(len(sizeTx.Bytes()))
+
+	// Both fee rates round down to 1 per byte.
+	lowTx, err := createTestDecisionTx(0, 1234+size+size/4)
+	require.NoError(err)
+	highTx, err := createTestDecisionTx(1, 1234+size+size/2)
+	require.NoError(err)
+	require.Len(highTx.Bytes(), int(size))
+	require.Len(lowTx.Bytes(), int(size))
+
+	require.NoError(mpool.Add(lowTx))
+	require.NoError(mpool.Add(highTx))
+	require.Equal([]*txs.Tx{highTx, lowTx}, mpool.PeekTxs(math.MaxInt))
+
+	infos := mpool.List()
+	require.Len(infos, 2)
+	require.Equal(uint64(1), infos[0].FeeRate)
+	require.Equal(uint64(1), infos[1].FeeRate)
+
+	// The tx with the lower fee rate is evicted to make room for a tx with a
+	// fee rate between them.
+	mpool.(*mempool).bytesAvailable = 0
+	midTx, err := createTestDecisionTx(2, 1234+size+size/3)
+	require.NoError(err)
+	require.NoError(mpool.Add(midTx))
+	require.False(mpool.Has(lowTx.ID()))
+	require.ErrorIs(mpool.GetDropReason(lowTx.ID()), errEvicted)
+	require.Equal([]*txs.Tx{highTx, midTx}, mpool.PeekTxs(math.MaxInt))
+}
+
+func TestReplaceByFee(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	tx, err := createTestDecisionTx(0, 1_000_000)
+	require.NoError(err)
+	require.NoError(mpool.Add(tx))
+
+	// A conflicting tx that doesn't pay a sufficiently higher fee rate can't
+	// replace [tx].
+	lowBumpTx, err := createTestDecisionTx(0, 1_050_000)
+	require.NoError(err)
+	err = mpool.Add(lowBumpTx)
+	require.ErrorIs(err, errInsufficientReplacementFee)
+	require.True(mpool.Has(tx.ID()))
+	require.False(mpool.Has(lowBumpTx.ID()))
+
+	replacementTx, err := createTestDecisionTx(0, 2_000_000)
+	require.NoError(err)
+	require.NoError(mpool.Add(replacementTx))
+	require.False(mpool.Has(tx.ID()))
+	require.True(mpool.Has(replacementTx.ID()))
+	require.ErrorIs(mpool.GetDropReason(tx.ID()), errReplaced)
+	require.Equal([]*txs.Tx{replacementTx}, mpool.PeekTxs(math.MaxInt))
+
+	// The space of the replaced tx is released.
+	require.Equal(maxMempoolSize-len(replacementTx.Bytes()), mpool.Stats().BytesAvailable)
+}
+
+func TestReplaceByFeeConflictingStakerTx(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	stakerTxs, err := createTestProposalTxs(1)
+	require.NoError(err)
+	stakerTx := stakerTxs[0]
+	utx := stakerTx.Unsigned.(*txs.AddValidatorTx)
+	utx.Ins = []*avax.TransferableInput{{
+		UTXOID: avax.UTXOID{
+			TxID: ids.ID{'t', 'x', 'I', 'D'},
+		},
+		Asset: avax.Asset{ID: testAssetID},
+		In: &secp256k1fx.TransferInput{
+			Amt: 5678,
+		},
+	}}
+	require.NoError(stakerTx.Initialize(txs.Codec))
+	require.NoError(mpool.Add(stakerTx))
+
+	// Staker txs can't be replaced, regardless of the fee paid.
+	tx, err := createTestDecisionTx(0, 10_000_000)
+	require.NoError(err)
+	err = mpool.Add(tx)
+	require.ErrorIs(err, errConflictingTx)
+	require.True(mpool.Has(stakerTx.ID()))
+}
+
+func TestEvictLowestFeeRateTx(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	lowTx, err := createTestDecisionTx(0, 100_000)
+	require.NoError(err)
+	midTx, err := createTestDecisionTx(1, 500_000)
+	require.NoError(err)
+	highTx, err := createTestDecisionTx(2, 1_000_000)
+	require.NoError(err)
+	lowestTx, err := createTestDecisionTx(3, 10_000)
+	require.NoError(err)
+
+	// shortcut to simulate a mempool that only fits two txs
+	mpool.(*mempool).bytesAvailable = len(lowTx.Bytes()) + len(midTx.Bytes())
+
+	require.NoError(mpool.Add(lowTx))
+	require.NoError(mpool.Add(midTx))
+
+	// A tx that pays less than every tx in the mempool is rejected.
+	err = mpool.Add(lowestTx)
+	require.ErrorIs(err, errMempoolFull)
+	require.True(mpool.Has(lowTx.ID()))
+	require.True(mpool.Has(midTx.ID()))
+
+	// A tx that pays more than the lowest tx evicts it.
+	require.NoError(mpool.Add(highTx))
+	require.False(mpool.Has(lowTx.ID()))
+	require.ErrorIs(mpool.GetDropReason(lowTx.ID()), errEvicted)
+	require.Equal([]*txs.Tx{highTx, midTx}, mpool.PeekTxs(math.MaxInt))
+
+	stats := mpool.Stats()
+	require.Equal(2, stats.NumDecisionTxs)
+	require.Zero(stats.NumStakerTxs)
+	require.Equal(len(lowTx.Bytes())-len(highTx.Bytes()), stats.BytesAvailable)
+}
+
+func TestNegativeFeeTxRejected(t *testing.T) {
+	require := require.New(t)
+
+	registerer := prometheus.NewRegistry()
+	mpool, err := NewMempool("mempool", registerer, testAssetID, &noopBlkTimer{})
+	require.NoError(err)
+
+	tx, err := createTestDecisionTx(0, 1)
+	require.NoError(err)
+	err = mpool.Add(tx)
+	require.ErrorIs(err, errNegativeFee)
+	require.False(mpool.Has(tx.ID()))
+}
+
 func createTestDecisionTxs(count int) ([]*txs.Tx, error) {
 	decisionTxs := make([]*txs.Tx, 0, count)
 	for i := uint32(0); i < uint32(count); i++ {
-		utx := &txs.CreateChainTx{
-			BaseTx: txs.BaseTx{BaseTx: avax.BaseTx{
-				NetworkID:    10,
-				BlockchainID: ids.Empty.Prefix(uint64(i)),
-				Ins: []*avax.TransferableInput{{
-					UTXOID: avax.UTXOID{
-						TxID:        ids.ID{'t', 'x', 'I', 'D'},
-						OutputIndex: i,
-					},
-					Asset: avax.Asset{ID: ids.ID{'a', 's', 's', 'e', 'r', 't'}},
-					In: &secp256k1fx.TransferInput{
-						Amt:   uint64(5678),
-						Input: secp256k1fx.Input{SigIndices: []uint32{i}},
-					},
-				}},
-				Outs: []*avax.TransferableOutput{{
-					Asset: avax.Asset{ID: ids.ID{'a', 's', 's', 'e', 'r', 't'}},
-					Out: &secp256k1fx.TransferOutput{
-						Amt: uint64(1234),
-						OutputOwners: secp256k1fx.OutputOwners{
-							Threshold: 1,
-							Addrs:     []ids.ShortID{preFundedKeys[0].PublicKey().Address()},
-						},
-					},
-				}},
-			}},
-			SubnetID:    ids.GenerateTestID(),
-			ChainName:   "chainName",
-			VMID:        ids.GenerateTestID(),
-			FxIDs:       []ids.ID{ids.GenerateTestID()},
-			GenesisData: []byte{'g', 'e', 'n', 'D', 'a', 't', 'a'},
-			SubnetAuth:  &secp256k1fx.Input{SigIndices: []uint32{1}},
-		}
-
-		tx, err := txs.NewSigned(utx, txs.Codec, nil)
+		tx, err := createTestDecisionTx(i, 5678)
 		if err != nil {
 			return nil, err
 		}
@@ -218,6 +379,45 @@ func createTestDecisionTxs(count int) ([]*txs.Tx, error) {
 	return decisionTxs, nil
 }
 
+// createTestDecisionTx returns a tx that consumes [consumed] from the UTXO at
+// [inputIndex] and produces 1234.
+func createTestDecisionTx(inputIndex uint32, consumed uint64) (*txs.Tx, error) {
+	utx := &txs.CreateChainTx{
+		BaseTx: txs.BaseTx{BaseTx: avax.BaseTx{
+			NetworkID:    10,
+			BlockchainID: ids.Empty.Prefix(uint64(inputIndex)),
+			Ins: []*avax.TransferableInput{{
+				UTXOID: avax.UTXOID{
+					TxID:        ids.ID{'t', 'x', 'I', 'D'},
+					OutputIndex: inputIndex,
+				},
+				Asset: avax.Asset{ID: testAssetID},
+				In: &secp256k1fx.TransferInput{
+					Amt:   consumed,
+					Input: secp256k1fx.Input{SigIndices: []uint32{inputIndex}},
+				},
+			}},
+			Outs: []*avax.TransferableOutput{{
+				Asset: avax.Asset{ID: testAssetID},
+				Out: &secp256k1fx.TransferOutput{
+					Amt: uint64(1234),
+					OutputOwners: secp256k1fx.OutputOwners{
+						Threshold: 1,
+						Addrs:     []ids.ShortID{preFundedKeys[0].PublicKey().Address()},
+					},
+				},
+			}},
+		}},
+		SubnetID:    ids.GenerateTestID(),
+		ChainName:   "chainName",
+		VMID:        ids.GenerateTestID(),
+		FxIDs:       []ids.ID{ids.GenerateTestID()},
+		GenesisData: []byte{'g', 'e', 'n', 'D', 'a', 't', 'a'},
+		SubnetAuth:  &secp256k1fx.Input{SigIndices: []uint32{1}},
+	}
+	return txs.NewSigned(utx, txs.Codec, nil)
+}
+
 // Proposal txs are sorted by decreasing start time
 func createTestProposalTxs(count int) ([]*txs.Tx, error) {
 	var clk mockable.Clock
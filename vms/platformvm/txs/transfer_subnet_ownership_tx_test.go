@@ -0,0 +1,216 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/snow"
+	"github.com/memeticofficial/pepecoingo/utils/constants"
+	"github.com/memeticofficial/pepecoingo/vms/components/avax"
+	"github.com/memeticofficial/pepecoingo/vms/components/verify"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
+	"github.com/memeticofficial/pepecoingo/vms/secp256k1fx"
+)
+
+var errInvalidOwner = errors.New("invalid owner")
+
+func TestTransferSubnetOwnershipTxSyntacticVerify(t *testing.T) {
+	type test struct {
+		name        string
+		txFunc      func(*gomock.Controller) *TransferSubnetOwnershipTx
+		expectedErr error
+	}
+
+	var (
+		networkID = uint32(1337)
+		chainID   = ids.GenerateTestID()
+	)
+
+	ctx := &snow.Context{
+		ChainID:   chainID,
+		NetworkID: networkID,
+	}
+
+	// A BaseTx that already passed syntactic verification.
+	verifiedBaseTx := BaseTx{
+		SyntacticallyVerified: true,
+	}
+	// Sanity check.
+	require.NoError(t, verifiedBaseTx.SyntacticVerify(ctx))
+
+	// A BaseTx that passes syntactic verification.
+	validBaseTx := BaseTx{
+		BaseTx: avax.BaseTx{
+			NetworkID:    networkID,
+			BlockchainID: chainID,
+		},
+	}
+	// Sanity check.
+	require.NoError(t, validBaseTx.SyntacticVerify(ctx))
+	// Make sure we're not caching the verification result.
+	require.False(t, validBaseTx.SyntacticallyVerified)
+
+	// A BaseTx that fails syntactic verification.
+	invalidBaseTx := BaseTx{}
+
+	tests := []test{
+		{
+			name: "nil tx",
+			txFunc: func(*gomock.Controller) *TransferSubnetOwnershipTx {
+				return nil
+			},
+			expectedErr: ErrNilTx,
+		},
+		{
+			name: "already verified",
+			txFunc: func(*gomock.Controller) *TransferSubnetOwnershipTx {
+				return &TransferSubnetOwnershipTx{BaseTx: verifiedBaseTx}
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "invalid BaseTx",
+			txFunc: func(*gomock.Controller) *TransferSubnetOwnershipTx {
+				return &TransferSubnetOwnershipTx{
+					// Set subnetID so we don't error on that check.
+					Subnet: ids.GenerateTestID(),
+					BaseTx: invalidBaseTx,
+				}
+			},
+			expectedErr: avax.ErrWrongNetworkID,
+		},
+		{
+			name: "invalid subnetID",
+			txFunc: func(*gomock.Controller) *TransferSubnetOwnershipTx {
+				return &TransferSubnetOwnershipTx{
+					BaseTx: validBaseTx,
+					Subnet: constants.PrimaryNetworkID,
+				}
+			},
+			expectedErr: ErrTransferPrimaryNetwork,
+		},
+		{
+			name: "invalid subnetAuth",
+			txFunc: func(ctrl *gomock.Controller) *TransferSubnetOwnershipTx {
+				// This SubnetAuth fails verification.
+				invalidSubnetAuth := verify.NewMockVerifiable(ctrl)
+				invalidSubnetAuth.EXPECT().Verify().Return(errInvalidSubnetAuth)
+				return &TransferSubnetOwnershipTx{
+					// Set subnetID so we don't error on that check.
+					Subnet:     ids.GenerateTestID(),
+					BaseTx:     validBaseTx,
+					SubnetAuth: invalidSubnetAuth,
+				}
+			},
+			expectedErr: errInvalidSubnetAuth,
+		},
+		{
+			name: "invalid owner",
+			txFunc: func(ctrl *gomock.Controller) *TransferSubnetOwnershipTx {
+				validSubnetAuth := verify.NewMockVerifiable(ctrl)
+				validSubnetAuth.EXPECT().Verify().Return(nil)
+				// This Owner fails verification.
+				invalidOwner := fx.NewMockOwner(ctrl)
+				invalidOwner.EXPECT().Verify().Return(errInvalidOwner)
+				return &TransferSubnetOwnershipTx{
+					// Set subnetID so we don't error on that check.
+					Subnet:     ids.GenerateTestID(),
+					BaseTx:     validBaseTx,
+					SubnetAuth: validSubnetAuth,
+					Owner:      invalidOwner,
+				}
+			},
+			expectedErr: errInvalidOwner,
+		},
+		{
+			name: "passes verification",
+			txFunc: func(ctrl *gomock.Controller) *TransferSubnetOwnershipTx {
+				// This SubnetAuth passes verification.
+				validSubnetAuth := verify.NewMockVerifiable(ctrl)
+				validSubnetAuth.EXPECT().Verify().Return(nil)
+				// This Owner passes verification.
+				validOwner := fx.NewMockOwner(ctrl)
+				validOwner.EXPECT().Verify().Return(nil)
+				return &TransferSubnetOwnershipTx{
+					// Set subnetID so we don't error on that check.
+					Subnet:     ids.GenerateTestID(),
+					BaseTx:     validBaseTx,
+					SubnetAuth: validSubnetAuth,
+					Owner:      validOwner,
+				}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tx := tt.txFunc(ctrl)
+			err := tx.SyntacticVerify(ctx)
+			require.ErrorIs(err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				require.True(tx.SyntacticallyVerified)
+			}
+		})
+	}
+}
+
+func TestTransferSubnetOwnershipTxSerialization(t *testing.T) {
+	require := require.New(t)
+
+	unsignedTx := &TransferSubnetOwnershipTx{
+		BaseTx: BaseTx{
+			BaseTx: avax.BaseTx{
+				NetworkID:    constants.UnitTestID,
+				BlockchainID: constants.PlatformChainID,
+				Ins:          []*avax.TransferableInput{},
+				Outs:         []*avax.TransferableOutput{},
+				Memo:         []byte{},
+			},
+		},
+		Subnet: ids.ID{'s', 'u', 'b', 'n', 'e', 't'},
+		SubnetAuth: &secp256k1fx.Input{
+			SigIndices: []uint32{3},
+		},
+		Owner: &secp256k1fx.OutputOwners{
+			Locktime:  876543210,
+			Threshold: 1,
+			Addrs: []ids.ShortID{
+				{'o', 'w', 'n', 'e', 'r'},
+			},
+		},
+	}
+
+	var unsignedTxIntf UnsignedTx = unsignedTx
+	unsignedTxBytes, err := Codec.Marshal(Version, &unsignedTxIntf)
+	require.NoError(err)
+
+	// The tx type is registered after the Banff block types, so it must not
+	// reuse any previously assigned type ID.
+	require.Equal(
+		[]byte{
+			// codec version
+			0x00, 0x00,
+			// TransferSubnetOwnershipTx type ID
+			0x00, 0x00, 0x00, 0x21,
+		},
+		unsignedTxBytes[:6],
+	)
+
+	var parsedTxIntf UnsignedTx
+	_, err = Codec.Unmarshal(unsignedTxBytes, &parsedTxIntf)
+	require.NoError(err)
+	require.Equal(unsignedTx, parsedTxIntf)
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txheap
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
+)
+
+var _ Heap = (*byFeeRate)(nil)
+
+// FeeRate is [Fee] per byte of a tx of [Size] bytes.
+//
+// Fee rates are compared without dividing [Fee] by [Size], so fee rates that
+// differ by less than 1 per byte are still ordered correctly.
+type FeeRate struct {
+	Fee  uint64
+	Size uint64
+}
+
+// Compare returns:
+// * 0 if [r] == [other].
+// * -1 if [r] < [other].
+// * 1 if [r] > [other].
+func (r FeeRate) Compare(other FeeRate) int {
+	// r.Fee/r.Size ? other.Fee/other.Size
+	// is equivalent to
+	// r.Fee*other.Size ? other.Fee*r.Size
+	rHi, rLo := bits.Mul64(r.Fee, other.Size)
+	otherHi, otherLo := bits.Mul64(other.Fee, r.Size)
+	switch {
+	case rHi < otherHi || (rHi == otherHi && rLo < otherLo):
+		return -1
+	case rHi > otherHi || (rHi == otherHi && rLo > otherLo):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r FeeRate) String() string {
+	return fmt.Sprintf("%d/%d", r.Fee, r.Size)
+}
+
+// FeeRateFunc returns the fee rate of [tx] and the sequence number of [tx],
+// which is used to break ties between txs with the same fee rate.
+type FeeRateFunc func(tx *txs.Tx) (feeRate FeeRate, seq uint64)
+
+type byFeeRate struct {
+	txHeap
+
+	feeRate     FeeRateFunc
+	lowestFirst bool
+}
+
+// NewByFeeRate returns a heap ordered by decreasing fee rate. Txs with the
+// same fee rate are ordered by increasing sequence number.
+func NewByFeeRate(feeRate FeeRateFunc) Heap {
+	return newByFeeRate(feeRate, false)
+}
+
+// NewByLowestFeeRate returns a heap ordered by increasing fee rate. Txs with
+// the same fee rate are ordered by decreasing sequence number.
+//
+// This is the reverse order of [NewByFeeRate].
+func NewByLowestFeeRate(feeRate FeeRateFunc) Heap {
+	return newByFeeRate(feeRate, true)
+}
+
+func newByFeeRate(feeRate FeeRateFunc, lowestFirst bool) *byFeeRate {
+	h := &byFeeRate{
+		feeRate:     feeRate,
+		lowestFirst: lowestFirst,
+	}
+	h.initialize(h)
+	return h
+}
+
+func (h *byFeeRate) Less(i, j int) bool {
+	iTx := h.txs[i].tx
+	jTx := h.txs[j].tx
+	if h.lowestFirst {
+		return HigherFeeRate(h.feeRate, jTx, iTx)
+	}
+	return HigherFeeRate(h.feeRate, iTx, jTx)
+}
+
+// HigherFeeRate returns true if [iTx] has a higher priority than [jTx] in a
+// heap created by [NewByFeeRate].
+func HigherFeeRate(feeRate FeeRateFunc, iTx, jTx *txs.Tx) bool {
+	iFeeRate, iSeq := feeRate(iTx)
+	jFeeRate, jSeq := feeRate(jTx)
+	if cmp := iFeeRate.Compare(jFeeRate); cmp != 0 {
+		return cmp > 0
+	}
+	return iSeq < jSeq
+}
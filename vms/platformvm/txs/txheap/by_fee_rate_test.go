@@ -0,0 +1,105 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package txheap
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/vms/components/avax"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
+	"github.com/memeticofficial/pepecoingo/vms/secp256k1fx"
+)
+
+type testFeeRate struct {
+	feeRate FeeRate
+	seq     uint64
+}
+
+func TestByFeeRate(t *testing.T) {
+	require := require.New(t)
+
+	// txs are listed in order of decreasing priority
+	feeRates := []testFeeRate{
+		{feeRate: FeeRate{Fee: 3, Size: 1}, seq: 2},
+		{feeRate: FeeRate{Fee: 2, Size: 1}, seq: 0},
+		{feeRate: FeeRate{Fee: 4, Size: 2}, seq: 3},
+		{feeRate: FeeRate{Fee: 3, Size: 2}, seq: 1},
+	}
+	txsByPriority := make([]*txs.Tx, len(feeRates))
+	txFeeRates := make(map[ids.ID]testFeeRate)
+	for i, feeRate := range feeRates {
+		utx := &txs.CreateSubnetTx{
+			BaseTx: txs.BaseTx{BaseTx: avax.BaseTx{
+				Memo: []byte{byte(i)},
+			}},
+			Owner: &secp256k1fx.OutputOwners{},
+		}
+		tx := &txs.Tx{Unsigned: utx}
+		require.NoError(tx.Initialize(txs.Codec))
+
+		txsByPriority[i] = tx
+		txFeeRates[tx.ID()] = feeRate
+	}
+	feeRateFunc := func(tx *txs.Tx) (FeeRate, uint64) {
+		feeRate := txFeeRates[tx.ID()]
+		return feeRate.feeRate, feeRate.seq
+	}
+
+	highestFirst := NewByFeeRate(feeRateFunc)
+	lowestFirst := NewByLowestFeeRate(feeRateFunc)
+	for _, i := range []int{2, 0, 3, 1} {
+		highestFirst.Add(txsByPriority[i])
+		lowestFirst.Add(txsByPriority[i])
+	}
+
+	for i := range txsByPriority {
+		require.Equal(txsByPriority[i], highestFirst.RemoveTop())
+		require.Equal(txsByPriority[len(txsByPriority)-1-i], lowestFirst.RemoveTop())
+	}
+	require.Zero(highestFirst.Len())
+	require.Zero(lowestFirst.Len())
+}
+
+func TestFeeRateCompare(t *testing.T) {
+	tests := []struct {
+		r        FeeRate
+		other    FeeRate
+		expected int
+	}{
+		{
+			r:        FeeRate{Fee: 1, Size: 1},
+			other:    FeeRate{Fee: 2, Size: 2},
+			expected: 0,
+		},
+		{
+			r:        FeeRate{Fee: 1, Size: 3},
+			other:    FeeRate{Fee: 1, Size: 2},
+			expected: -1,
+		},
+		{
+			r:        FeeRate{Fee: 2, Size: 3},
+			other:    FeeRate{Fee: 1, Size: 2},
+			expected: 1,
+		},
+		{
+			// The products overflow 64 bits.
+			r:        FeeRate{Fee: math.MaxUint64, Size: math.MaxUint64 - 1},
+			other:    FeeRate{Fee: math.MaxUint64 - 1, Size: math.MaxUint64},
+			expected: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s", test.r, test.other), func(t *testing.T) {
+			require := require.New(t)
+
+			require.Equal(test.expected, test.r.Compare(test.other))
+			require.Equal(-test.expected, test.other.Compare(test.r))
+		})
+	}
+}
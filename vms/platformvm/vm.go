@@ -20,6 +20,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/manager"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow"
 	"github.com/memeticofficial/pepecoingo/snow/consensus/snowman"
@@ -43,6 +44,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/metrics"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/reward"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/stakinghistory"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/state"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs/mempool"
@@ -69,6 +71,8 @@ var (
 
 	errMissingValidatorSet = errors.New("missing validator set")
 	errMissingValidator    = errors.New("missing validator")
+
+	stakingHistoryPrefix = []byte("stakingHistory")
 )
 
 type VM struct {
@@ -89,6 +93,9 @@ type VM struct {
 
 	state state.State
 
+	// stakingHistory indexes the outcomes of RewardValidatorTxs
+	stakingHistory stakinghistory.Indexer
+
 	fx            fx.Fx
 	codecRegistry codec.Registry
 
@@ -115,20 +122,25 @@ func (vm *VM) Initialize(
 	dbManager manager.Manager,
 	genesisBytes []byte,
 	_ []byte,
-	_ []byte,
+	configBytes []byte,
 	toEngine chan<- common.Message,
 	_ []*common.Fx,
 	appSender common.AppSender,
 ) error {
 	chainCtx.Log.Verbo("initializing platform chain")
 
+	execConfig, err := config.GetExecutionConfig(configBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse chain config: %w", err)
+	}
+	chainCtx.Log.Info("using VM execution config", zap.Reflect("config", execConfig))
+
 	registerer := prometheus.NewRegistry()
 	if err := chainCtx.Metrics.Register(registerer); err != nil {
 		return err
 	}
 
 	// Initialize metrics as soon as possible
-	var err error
 	vm.metrics, err = metrics.New("", registerer, vm.TrackedSubnets)
 	if err != nil {
 		return fmt.Errorf("failed to initialize metrics: %w", err)
@@ -167,6 +179,13 @@ func (vm *VM) Initialize(
 		return err
 	}
 
+	stakingHistoryDB := prefixdb.New(stakingHistoryPrefix, vm.dbManager.Current().Database)
+	if execConfig.IndexStakingHistory {
+		vm.stakingHistory = stakinghistory.NewIndexer(stakingHistoryDB)
+	} else {
+		vm.stakingHistory = stakinghistory.NewNoIndexer(stakingHistoryDB)
+	}
+
 	vm.atomicUtxosManager = avax.NewAtomicUTXOManager(chainCtx.SharedMemory, txs.Codec)
 	utxoHandler := utxo.NewHandler(vm.ctx, &vm.clock, vm.fx)
 	vm.uptimeManager = uptime.NewManager(vm.state)
@@ -195,7 +214,7 @@ func (vm *VM) Initialize(
 
 	// Note: There is a circular dependency between the mempool and block
 	//       builder which is broken by passing in the vm.
-	mempool, err := mempool.NewMempool("mempool", registerer, vm)
+	mempool, err := mempool.NewMempool("mempool", registerer, vm.ctx.AVAXAssetID, vm)
 	if err != nil {
 		return fmt.Errorf("failed to create mempool: %w", err)
 	}
@@ -206,15 +225,20 @@ func (vm *VM) Initialize(
 		vm.state,
 		txExecutorBackend,
 		vm.recentlyAccepted,
+		vm.stakingHistory,
 	)
-	vm.Builder = blockbuilder.New(
+	vm.Builder, err = blockbuilder.New(
 		mempool,
 		vm.txBuilder,
 		txExecutorBackend,
 		vm.manager,
 		toEngine,
 		appSender,
+		registerer,
 	)
+	if err != nil {
+		return fmt.Errorf("failed to create block builder: %w", err)
+	}
 
 	// Create all of the chains that the database says exist
 	if err := vm.initBlockchains(); err != nil {
@@ -460,18 +484,24 @@ func (*VM) CreateStaticHandlers(context.Context) (map[string]*common.HTTPHandler
 	}, nil
 }
 
-func (vm *VM) Connected(_ context.Context, nodeID ids.NodeID, _ *version.Application) error {
-	return vm.uptimeManager.Connect(nodeID, constants.PrimaryNetworkID)
+func (vm *VM) Connected(ctx context.Context, nodeID ids.NodeID, nodeVersion *version.Application) error {
+	if err := vm.uptimeManager.Connect(nodeID, constants.PrimaryNetworkID); err != nil {
+		return err
+	}
+	return vm.Builder.Connected(ctx, nodeID, nodeVersion)
 }
 
 func (vm *VM) ConnectedSubnet(_ context.Context, nodeID ids.NodeID, subnetID ids.ID) error {
 	return vm.uptimeManager.Connect(nodeID, subnetID)
 }
 
-func (vm *VM) Disconnected(_ context.Context, nodeID ids.NodeID) error {
+func (vm *VM) Disconnected(ctx context.Context, nodeID ids.NodeID) error {
 	if err := vm.uptimeManager.Disconnect(nodeID); err != nil {
 		return err
 	}
+	if err := vm.Builder.Disconnected(ctx, nodeID); err != nil {
+		return err
+	}
 	return vm.state.Commit()
 }
 
@@ -370,7 +370,7 @@ func defaultVM() (*VM, database.Database, *mutableSharedMemory) {
 		chainDBManager,
 		genesisBytes,
 		nil,
-		nil,
+		[]byte(`{"index-staking-history":true}`),
 		msgChan,
 		nil,
 		appSender,
@@ -54,7 +54,9 @@ var (
 	_ block.HeightIndexedChainVM = (*VM)(nil)
 	_ block.StateSyncableVM      = (*VM)(nil)
 
-	dbPrefix = []byte("proposervm")
+	// DBPrefix is the prefix that the proposervm stores its state under in the
+	// database it is initialized with.
+	DBPrefix = []byte("proposervm")
 )
 
 type VM struct {
@@ -166,7 +168,7 @@ func (vm *VM) Initialize(
 
 	vm.ctx = chainCtx
 	rawDB := dbManager.Current().Database
-	prefixDB := prefixdb.New(dbPrefix, rawDB)
+	prefixDB := prefixdb.New(DBPrefix, rawDB)
 	vm.db = versiondb.New(prefixDB)
 	vm.State = state.New(vm.db)
 	vm.Windower = proposer.New(chainCtx.ValidatorState, chainCtx.SubnetID, chainCtx.ChainID)
@@ -40,6 +40,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/snow/engine/snowman/block"
 	"github.com/memeticofficial/pepecoingo/snow/validators/gvalidators"
 	"github.com/memeticofficial/pepecoingo/utils/crypto/bls"
+	"github.com/memeticofficial/pepecoingo/utils/math"
 	"github.com/memeticofficial/pepecoingo/utils/resource"
 	"github.com/memeticofficial/pepecoingo/utils/wrappers"
 	"github.com/memeticofficial/pepecoingo/version"
@@ -106,6 +107,9 @@ type VMClient struct {
 	conns        []*grpc.ClientConn
 
 	grpcServerMetrics *grpc_prometheus.ServerMetrics
+
+	// dbClientConfig configures how the VM reads from its databases
+	dbClientConfig rpcdb.ClientConfig
 }
 
 // NewClient returns a VM connected to a remote VM
@@ -123,6 +127,12 @@ func (vm *VMClient) SetProcess(runtime runtime.Stopper, pid int, processTracker
 	processTracker.TrackProcess(vm.pid)
 }
 
+// SetDatabaseClientConfig configures how the VM reads from the databases that
+// it is initialized with. It must be called before Initialize.
+func (vm *VMClient) SetDatabaseClientConfig(config rpcdb.ClientConfig) {
+	vm.dbClientConfig = config
+}
+
 func (vm *VMClient) Initialize(
 	ctx context.Context,
 	chainCtx *snow.Context,
@@ -210,6 +220,9 @@ func (vm *VMClient) Initialize(
 		ConfigBytes:  configBytes,
 		DbServers:    versionedDBServers,
 		ServerAddr:   serverAddr,
+		// Negative values are sent as 0 so that the defaults are used.
+		DbIteratorBatchSize: uint32(math.Max(vm.dbClientConfig.IteratorBatchSize, 0)),
+		DbIteratorPrefetch:  uint32(math.Max(vm.dbClientConfig.IteratorPrefetch, 0)),
 	})
 	if err != nil {
 		return err
@@ -150,6 +150,10 @@ func (vm *VMServer) Initialize(ctx context.Context, req *vmpb.InitializeRequest)
 	vm.processMetrics = registerer
 
 	// Dial each database in the request and construct the database manager
+	dbClientConfig := rpcdb.ClientConfig{
+		IteratorBatchSize: int(req.DbIteratorBatchSize),
+		IteratorPrefetch:  int(req.DbIteratorPrefetch),
+	}
 	versionedDBs := make([]*manager.VersionedDatabase, len(req.DbServers))
 	for i, vDBReq := range req.DbServers {
 		version, err := version.Parse(vDBReq.Version)
@@ -170,7 +174,7 @@ func (vm *VMServer) Initialize(ctx context.Context, req *vmpb.InitializeRequest)
 			return nil, err
 		}
 		vm.connCloser.Add(clientConn)
-		db := rpcdb.NewClient(rpcdbpb.NewDatabaseClient(clientConn))
+		db := rpcdb.NewClientWithConfig(rpcdbpb.NewDatabaseClient(clientConn), dbClientConfig)
 		versionedDBs[i] = &manager.VersionedDatabase{
 			Database: corruptabledb.New(db),
 			Version:  version,
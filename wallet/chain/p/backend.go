@@ -13,6 +13,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/utils/constants"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 	"github.com/memeticofficial/pepecoingo/vms/components/avax"
+	"github.com/memeticofficial/pepecoingo/vms/platformvm/fx"
 	"github.com/memeticofficial/pepecoingo/vms/platformvm/txs"
 )
 
@@ -42,13 +43,17 @@ type backend struct {
 	txsLock sync.RWMutex
 	// txID -> tx
 	txs map[ids.ID]*txs.Tx
+	// subnetID -> owner, populated by accepted txs that modify a subnet's
+	// owner
+	subnetOwner map[ids.ID]fx.Owner
 }
 
 func NewBackend(ctx Context, utxos ChainUTXOs, txs map[ids.ID]*txs.Tx) Backend {
 	return &backend{
-		Context:    ctx,
-		ChainUTXOs: utxos,
-		txs:        txs,
+		Context:     ctx,
+		ChainUTXOs:  utxos,
+		txs:         txs,
+		subnetOwner: make(map[ids.ID]fx.Owner),
 	}
 }
 
@@ -104,3 +109,31 @@ func (b *backend) GetTx(_ stdcontext.Context, txID ids.ID) (*txs.Tx, error) {
 	}
 	return tx, nil
 }
+
+func (b *backend) GetSubnetOwner(_ stdcontext.Context, subnetID ids.ID) (fx.Owner, error) {
+	b.txsLock.RLock()
+	defer b.txsLock.RUnlock()
+
+	if owner, exists := b.subnetOwner[subnetID]; exists {
+		return owner, nil
+	}
+
+	// If the owner hasn't been modified by an accepted tx, the owner is the
+	// one specified when the subnet was created.
+	tx, exists := b.txs[subnetID]
+	if !exists {
+		return nil, database.ErrNotFound
+	}
+	subnet, ok := tx.Unsigned.(*txs.CreateSubnetTx)
+	if !ok {
+		return nil, errWrongTxType
+	}
+	return subnet.Owner, nil
+}
+
+func (b *backend) setSubnetOwner(subnetID ids.ID, owner fx.Owner) {
+	b.txsLock.Lock()
+	defer b.txsLock.Unlock()
+
+	b.subnetOwner[subnetID] = owner
+}
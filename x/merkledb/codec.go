@@ -12,6 +12,9 @@ import (
 	"math"
 	"sync"
 
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/hashing"
 )
@@ -32,12 +35,16 @@ const (
 	minKeyChangeLen      = minByteSliceLen + minMaybeByteSliceLen
 	minProofNodeLen      = minSerializedPathLen + minMaybeByteSliceLen + minVarIntLen
 	minProofLen          = minCodecVersionLen + minProofPathLen + minByteSliceLen
+	minMultiProofLen     = minCodecVersionLen + minProofPathLen + minVarIntLen
 	minChangeProofLen    = minCodecVersionLen + boolLen + 2*minProofPathLen + minVarIntLen
 	minRangeProofLen     = minCodecVersionLen + 2*minProofPathLen + minVarIntLen
 	minDBNodeLen         = minCodecVersionLen + minMaybeByteSliceLen + minVarIntLen
 	minHashValuesLen     = minCodecVersionLen + minVarIntLen + minMaybeByteSliceLen + minSerializedPathLen
 	minProofNodeChildLen = minVarIntLen + idLen
 	minChildLen          = minVarIntLen + minSerializedPathLen + idLen
+	minChangeSummaryLen  = minCodecVersionLen + idLen + 2*minVarIntLen
+	minNodeChangeLen     = minSerializedPathLen + 2*boolLen
+	minValueChangeLen    = minSerializedPathLen + 2*minMaybeByteSliceLen
 )
 
 var (
@@ -51,17 +58,20 @@ var (
 	errDecodeNil              = errors.New("can't decode nil")
 	errNegativeProofPathNodes = errors.New("negative proof path length")
 	errNegativeNumChildren    = errors.New("number of children is negative")
-	errTooManyChildren        = fmt.Errorf("length of children list is larger than branching factor of %d", NodeBranchFactor)
-	errChildIndexTooLarge     = fmt.Errorf("invalid child index. Must be less than branching factor of %d", NodeBranchFactor)
-	errNegativeNibbleLength   = errors.New("nibble length is negative")
+	errTooManyChildren        = errors.New("length of children list is larger than branching factor")
+	errChildIndexTooLarge     = errors.New("invalid child index. Must be less than branching factor")
+	errNegativeTokenLength    = errors.New("token length is negative")
 	errNegativeNumKeyValues   = errors.New("negative number of key values")
 	errIntTooLarge            = errors.New("integer too large to be decoded")
 	errLeadingZeroes          = errors.New("varint has leading zeroes")
 	errInvalidBool            = errors.New("decoded bool is neither true nor false")
-	errNonZeroNibblePadding   = errors.New("nibbles should be padded with 0s")
+	errNonZeroTokenPadding    = errors.New("tokens should be padded with 0s")
 	errExtraSpace             = errors.New("trailing buffer space")
 	errNegativeSliceLength    = errors.New("negative slice length")
 	errInvalidCodecVersion    = errors.New("invalid codec version")
+	errNegativeNumChanges     = errors.New("negative number of changes")
+	errNegativeNumKeys        = errors.New("negative number of keys")
+	errKeysValuesMismatch     = errors.New("number of keys doesn't match the number of values")
 )
 
 // EncoderDecoder defines the interface needed by merkleDB to marshal
@@ -73,33 +83,47 @@ type EncoderDecoder interface {
 
 type Encoder interface {
 	EncodeProof(version uint16, p *Proof) ([]byte, error)
+	EncodeMultiProof(version uint16, p *MultiProof) ([]byte, error)
 	EncodeChangeProof(version uint16, p *ChangeProof) ([]byte, error)
 	EncodeRangeProof(version uint16, p *RangeProof) ([]byte, error)
 
 	encodeDBNode(version uint16, n *dbNode) ([]byte, error)
 	encodeHashValues(version uint16, hv *hashValues) ([]byte, error)
+	encodeChangeSummary(version uint16, changes *changeSummary) ([]byte, error)
 }
 
 type Decoder interface {
 	DecodeProof(bytes []byte, p *Proof) (uint16, error)
+	DecodeMultiProof(bytes []byte, p *MultiProof) (uint16, error)
 	DecodeChangeProof(bytes []byte, p *ChangeProof) (uint16, error)
 	DecodeRangeProof(bytes []byte, p *RangeProof) (uint16, error)
 
 	decodeDBNode(bytes []byte, n *dbNode) (uint16, error)
+	decodeChangeSummary(bytes []byte, changes *changeSummary, hasher Hasher) (uint16, error)
+}
+
+// NewCodec returns a codec for the proofs of tries with [branchFactor].
+// Assumes [branchFactor] is valid.
+func NewCodec(branchFactor BranchFactor) EncoderDecoder {
+	return newCodec(branchFactor)
 }
 
-func newCodec() (EncoderDecoder, uint16) {
+func newCodec(branchFactor BranchFactor) *codecImpl {
 	return &codecImpl{
+		branchFactor: branchFactor,
 		varIntPool: sync.Pool{
 			New: func() interface{} {
 				return make([]byte, binary.MaxVarintLen64)
 			},
 		},
-	}, codecVersion
+	}
 }
 
 type codecImpl struct {
-	varIntPool sync.Pool
+	// The paths of the encoded tries are split into tokens of
+	// log2([branchFactor]) bits.
+	branchFactor BranchFactor
+	varIntPool   sync.Pool
 }
 
 func (c *codecImpl) EncodeProof(version uint16, proof *Proof) ([]byte, error) {
@@ -127,6 +151,39 @@ func (c *codecImpl) EncodeProof(version uint16, proof *Proof) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func (c *codecImpl) EncodeMultiProof(version uint16, proof *MultiProof) ([]byte, error) {
+	if proof == nil {
+		return nil, errEncodeNil
+	}
+
+	if version != codecVersion {
+		return nil, fmt.Errorf("%w: %d", errUnknownVersion, version)
+	}
+	if len(proof.Keys) != len(proof.Values) {
+		return nil, errKeysValuesMismatch
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.encodeInt(buf, int(version)); err != nil {
+		return nil, err
+	}
+	if err := c.encodeProofPath(buf, proof.Nodes); err != nil {
+		return nil, err
+	}
+	if err := c.encodeInt(buf, len(proof.Keys)); err != nil {
+		return nil, err
+	}
+	for i, key := range proof.Keys {
+		if err := c.encodeByteSlice(buf, key); err != nil {
+			return nil, err
+		}
+		if err := c.encodeMaybeByteSlice(buf, proof.Values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 func (c *codecImpl) EncodeChangeProof(version uint16, proof *ChangeProof) ([]byte, error) {
 	if proof == nil {
 		return nil, errEncodeNil
@@ -212,12 +269,12 @@ func (c *codecImpl) encodeDBNode(version uint16, n *dbNode) ([]byte, error) {
 	if err := c.encodeInt(buf, childrenLength); err != nil {
 		return nil, err
 	}
-	for index := byte(0); index < NodeBranchFactor; index++ {
-		if entry, ok := n.children[index]; ok {
-			if err := c.encodeInt(buf, int(index)); err != nil {
+	for i := 0; i < int(c.branchFactor); i++ {
+		if entry, ok := n.children[byte(i)]; ok {
+			if err := c.encodeInt(buf, i); err != nil {
 				return nil, err
 			}
-			path := entry.compressedPath.Serialize()
+			path := entry.compressedPath.Serialize(c.branchFactor)
 			if err := c.encodeSerializedPath(path, buf); err != nil {
 				return nil, err
 			}
@@ -250,9 +307,9 @@ func (c *codecImpl) encodeHashValues(version uint16, hv *hashValues) ([]byte, er
 	}
 
 	// ensure that the order of entries is consistent
-	for index := byte(0); index < NodeBranchFactor; index++ {
-		if entry, ok := hv.Children[index]; ok {
-			if err := c.encodeInt(buf, int(index)); err != nil {
+	for i := 0; i < int(c.branchFactor); i++ {
+		if entry, ok := hv.Children[byte(i)]; ok {
+			if err := c.encodeInt(buf, i); err != nil {
 				return nil, err
 			}
 			if _, err := buf.Write(entry.id[:]); err != nil {
@@ -263,13 +320,69 @@ func (c *codecImpl) encodeHashValues(version uint16, hv *hashValues) ([]byte, er
 	if err := c.encodeMaybeByteSlice(buf, hv.Value); err != nil {
 		return nil, err
 	}
-	if err := c.encodeSerializedPath(hv.Key, buf); err != nil {
+	if err := c.encodeSerializedPath(hv.Key.Serialize(c.branchFactor), buf); err != nil {
 		return nil, err
 	}
 
 	return buf.Bytes(), nil
 }
 
+func (c *codecImpl) encodeChangeSummary(version uint16, changes *changeSummary) ([]byte, error) {
+	if changes == nil {
+		return nil, errEncodeNil
+	}
+
+	if version != codecVersion {
+		return nil, fmt.Errorf("%w: %d", errUnknownVersion, version)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.encodeInt(buf, int(version)); err != nil {
+		return nil, err
+	}
+	if _, err := buf.Write(changes.rootID[:]); err != nil {
+		return nil, err
+	}
+
+	// ensure that the order of entries is consistent
+	nodeKeys := maps.Keys(changes.nodes)
+	slices.Sort(nodeKeys)
+	if err := c.encodeInt(buf, len(nodeKeys)); err != nil {
+		return nil, err
+	}
+	for _, key := range nodeKeys {
+		nodeChange := changes.nodes[key]
+		if err := c.encodeSerializedPath(key.Serialize(c.branchFactor), buf); err != nil {
+			return nil, err
+		}
+		if err := c.encodeMaybeNode(buf, nodeChange.before); err != nil {
+			return nil, err
+		}
+		if err := c.encodeMaybeNode(buf, nodeChange.after); err != nil {
+			return nil, err
+		}
+	}
+
+	valueKeys := maps.Keys(changes.values)
+	slices.Sort(valueKeys)
+	if err := c.encodeInt(buf, len(valueKeys)); err != nil {
+		return nil, err
+	}
+	for _, key := range valueKeys {
+		valueChange := changes.values[key]
+		if err := c.encodeSerializedPath(key.Serialize(c.branchFactor), buf); err != nil {
+			return nil, err
+		}
+		if err := c.encodeMaybeByteSlice(buf, valueChange.before); err != nil {
+			return nil, err
+		}
+		if err := c.encodeMaybeByteSlice(buf, valueChange.after); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 func (c *codecImpl) DecodeProof(b []byte, proof *Proof) (uint16, error) {
 	if proof == nil {
 		return 0, errDecodeNil
@@ -304,6 +417,55 @@ func (c *codecImpl) DecodeProof(b []byte, proof *Proof) (uint16, error) {
 	return codecVersion, nil
 }
 
+func (c *codecImpl) DecodeMultiProof(b []byte, proof *MultiProof) (uint16, error) {
+	if proof == nil {
+		return 0, errDecodeNil
+	}
+	if minMultiProofLen > len(b) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	var (
+		err error
+		src = bytes.NewReader(b)
+	)
+	gotCodecVersion, err := c.decodeInt(src)
+	if err != nil {
+		return 0, err
+	}
+	if codecVersion != gotCodecVersion {
+		return 0, fmt.Errorf("%w: %d", errInvalidCodecVersion, gotCodecVersion)
+	}
+	if proof.Nodes, err = c.decodeProofPath(src); err != nil {
+		return 0, err
+	}
+
+	numKeys, err := c.decodeInt(src)
+	if err != nil {
+		return 0, err
+	}
+	if numKeys < 0 {
+		return 0, errNegativeNumKeys
+	}
+	if numKeys > src.Len()/minKeyChangeLen {
+		return 0, io.ErrUnexpectedEOF
+	}
+	proof.Keys = make([][]byte, numKeys)
+	proof.Values = make([]Maybe[[]byte], numKeys)
+	for i := 0; i < numKeys; i++ {
+		if proof.Keys[i], err = c.decodeByteSlice(src); err != nil {
+			return 0, err
+		}
+		if proof.Values[i], err = c.decodeMaybeByteSlice(src); err != nil {
+			return 0, err
+		}
+	}
+	if src.Len() != 0 {
+		return 0, errExtraSpace
+	}
+	return codecVersion, nil
+}
+
 func (c *codecImpl) DecodeChangeProof(b []byte, proof *ChangeProof) (uint16, error) {
 	if proof == nil {
 		return 0, errDecodeNil
@@ -436,20 +598,20 @@ func (c *codecImpl) decodeDBNode(b []byte, n *dbNode) (uint16, error) {
 	switch {
 	case numChildren < 0:
 		return 0, errNegativeNumChildren
-	case numChildren > NodeBranchFactor:
+	case numChildren > int(c.branchFactor):
 		return 0, errTooManyChildren
 	case numChildren > src.Len()/minChildLen:
 		return 0, io.ErrUnexpectedEOF
 	}
 
-	n.children = make(map[byte]child, NodeBranchFactor)
+	n.children = make(map[byte]child, numChildren)
 	previousChild := -1
 	for i := 0; i < numChildren; i++ {
 		var index int
 		if index, err = c.decodeInt(src); err != nil {
 			return 0, err
 		}
-		if index <= previousChild || index > NodeBranchFactor-1 {
+		if index <= previousChild || index >= int(c.branchFactor) {
 			return 0, errChildIndexTooLarge
 		}
 		previousChild = index
@@ -463,7 +625,7 @@ func (c *codecImpl) decodeDBNode(b []byte, n *dbNode) (uint16, error) {
 			return 0, err
 		}
 		n.children[byte(index)] = child{
-			compressedPath: compressedPath.deserialize(),
+			compressedPath: compressedPath.deserialize(c.branchFactor),
 			id:             childID,
 		}
 	}
@@ -473,6 +635,126 @@ func (c *codecImpl) decodeDBNode(b []byte, n *dbNode) (uint16, error) {
 	return codecVersion, err
 }
 
+func (c *codecImpl) decodeChangeSummary(b []byte, changes *changeSummary, hasher Hasher) (uint16, error) {
+	if changes == nil {
+		return 0, errDecodeNil
+	}
+	if minChangeSummaryLen > len(b) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	var (
+		src = bytes.NewReader(b)
+		err error
+	)
+
+	gotCodecVersion, err := c.decodeInt(src)
+	if err != nil {
+		return 0, err
+	}
+	if codecVersion != gotCodecVersion {
+		return 0, fmt.Errorf("%w: %d", errInvalidCodecVersion, gotCodecVersion)
+	}
+	if changes.rootID, err = c.decodeID(src); err != nil {
+		return 0, err
+	}
+
+	numNodes, err := c.decodeInt(src)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case numNodes < 0:
+		return 0, errNegativeNumChanges
+	case numNodes > src.Len()/minNodeChangeLen:
+		return 0, io.ErrUnexpectedEOF
+	}
+	changes.nodes = make(map[path]*change[*node], numNodes)
+	for i := 0; i < numNodes; i++ {
+		serializedKey, err := c.decodeSerializedPath(src)
+		if err != nil {
+			return 0, err
+		}
+		key := serializedKey.deserialize(c.branchFactor)
+		before, err := c.decodeMaybeNode(src, key, hasher)
+		if err != nil {
+			return 0, err
+		}
+		after, err := c.decodeMaybeNode(src, key, hasher)
+		if err != nil {
+			return 0, err
+		}
+		changes.nodes[key] = &change[*node]{
+			before: before,
+			after:  after,
+		}
+	}
+
+	numValues, err := c.decodeInt(src)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case numValues < 0:
+		return 0, errNegativeNumChanges
+	case numValues > src.Len()/minValueChangeLen:
+		return 0, io.ErrUnexpectedEOF
+	}
+	changes.values = make(map[path]*change[Maybe[[]byte]], numValues)
+	for i := 0; i < numValues; i++ {
+		serializedKey, err := c.decodeSerializedPath(src)
+		if err != nil {
+			return 0, err
+		}
+		before, err := c.decodeMaybeByteSlice(src)
+		if err != nil {
+			return 0, err
+		}
+		after, err := c.decodeMaybeByteSlice(src)
+		if err != nil {
+			return 0, err
+		}
+		changes.values[serializedKey.deserialize(c.branchFactor)] = &change[Maybe[[]byte]]{
+			before: before,
+			after:  after,
+		}
+	}
+	if src.Len() != 0 {
+		return 0, errExtraSpace
+	}
+	return codecVersion, nil
+}
+
+// encodeMaybeNode writes whether [n] is nil, followed by the byte
+// representation of [n] if it isn't.
+func (c *codecImpl) encodeMaybeNode(dst io.Writer, n *node) error {
+	if err := c.encodeBool(dst, n != nil); err != nil {
+		return err
+	}
+	if n == nil {
+		return nil
+	}
+	nodeBytes, err := n.marshal(c)
+	if err != nil {
+		return err
+	}
+	return c.encodeByteSlice(dst, nodeBytes)
+}
+
+// decodeMaybeNode reads a node written by encodeMaybeNode and sets its key to
+// [key]. The digest of its value is calculated using [hasher].
+func (c *codecImpl) decodeMaybeNode(src *bytes.Reader, key path, hasher Hasher) (*node, error) {
+	hasNode, err := c.decodeBool(src)
+	if err != nil || !hasNode {
+		return nil, err
+	}
+	nodeBytes, err := c.decodeByteSlice(src)
+	if err != nil {
+		return nil, err
+	}
+	return parseNode(c, hasher, key, nodeBytes)
+}
+
 func (c *codecImpl) decodeKeyChange(src *bytes.Reader) (KeyChange, error) {
 	if minKeyChangeLen > src.Len() {
 		return KeyChange{}, io.ErrUnexpectedEOF
@@ -753,7 +1035,7 @@ func (c *codecImpl) decodeProofNode(src *bytes.Reader) (ProofNode, error) {
 	switch {
 	case numChildren < 0:
 		return result, errNegativeNumChildren
-	case numChildren > NodeBranchFactor:
+	case numChildren > int(c.branchFactor):
 		return result, errTooManyChildren
 	case numChildren > src.Len()/minProofNodeChildLen:
 		return result, io.ErrUnexpectedEOF
@@ -766,7 +1048,7 @@ func (c *codecImpl) decodeProofNode(src *bytes.Reader) (ProofNode, error) {
 		if err != nil {
 			return result, err
 		}
-		if index <= previousChild || index >= NodeBranchFactor {
+		if index <= previousChild || index >= int(c.branchFactor) {
 			return result, errChildIndexTooLarge
 		}
 		previousChild = index
@@ -792,20 +1074,20 @@ func (c *codecImpl) encodeProofNode(pn ProofNode, dst io.Writer) error {
 	}
 	// ensure this is in order
 	childrenCount := 0
-	for index := byte(0); index < NodeBranchFactor; index++ {
-		childID, ok := pn.Children[index]
+	for i := 0; i < int(c.branchFactor); i++ {
+		childID, ok := pn.Children[byte(i)]
 		if !ok {
 			continue
 		}
 		childrenCount++
-		if err := c.encodeInt(dst, int(index)); err != nil {
+		if err := c.encodeInt(dst, i); err != nil {
 			return err
 		}
 		if _, err := dst.Write(childID[:]); err != nil {
 			return err
 		}
 	}
-	// there are children present with index >= NodeBranchFactor
+	// there are children present with index >= [c.branchFactor]
 	if childrenCount != len(pn.Children) {
 		return errChildIndexTooLarge
 	}
@@ -833,16 +1115,12 @@ func (c *codecImpl) decodeSerializedPath(src *bytes.Reader) (SerializedPath, err
 		return result, err
 	}
 	if result.NibbleLength < 0 {
-		return result, errNegativeNibbleLength
-	}
-	pathBytesLen := result.NibbleLength >> 1
-	hasOddLen := result.hasOddLength()
-	if hasOddLen {
-		pathBytesLen++
+		return result, errNegativeTokenLength
 	}
-	if pathBytesLen > src.Len() {
+	if result.NibbleLength > src.Len()*c.branchFactor.TokensPerByte() {
 		return result, io.ErrUnexpectedEOF
 	}
+	pathBytesLen := c.branchFactor.bytesNeeded(result.NibbleLength)
 	result.Value = make([]byte, pathBytesLen)
 	if _, err := io.ReadFull(src, result.Value); err != nil {
 		if err == io.EOF {
@@ -850,10 +1128,11 @@ func (c *codecImpl) decodeSerializedPath(src *bytes.Reader) (SerializedPath, err
 		}
 		return result, err
 	}
-	if hasOddLen {
-		paddedNibble := result.Value[pathBytesLen-1] & 0x0F
-		if paddedNibble != 0 {
-			return result, errNonZeroNibblePadding
+	if result.hasPartialByte(c.branchFactor) {
+		usedBits := result.NibbleLength % c.branchFactor.TokensPerByte() * c.branchFactor.bitsPerToken()
+		paddingBits := result.Value[pathBytesLen-1] & (0xFF >> usedBits)
+		if paddingBits != 0 {
+			return result, errNonZeroTokenPadding
 		}
 	}
 	return result, nil
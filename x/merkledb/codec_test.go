@@ -25,7 +25,7 @@ func newRandomProofNode(r *rand.Rand) ProofNode {
 	_, _ = r.Read(val)              // #nosec G404
 
 	children := map[byte]ids.ID{}
-	for j := 0; j < NodeBranchFactor; j++ {
+	for j := 0; j < int(BranchFactor16); j++ {
 		if r.Float64() < 0.5 {
 			var childID ids.ID
 			_, _ = r.Read(childID[:]) // #nosec G404
@@ -46,7 +46,7 @@ func newRandomProofNode(r *rand.Rand) ProofNode {
 	}
 
 	return ProofNode{
-		KeyPath:     newPath(key).Serialize(),
+		KeyPath:     newPath(key, BranchFactor16).Serialize(BranchFactor16),
 		ValueOrHash: Some(val),
 		Children:    children,
 	}
@@ -184,26 +184,28 @@ func FuzzCodecSerializedPath(f *testing.F) {
 		) {
 			require := require.New(t)
 
-			codec := Codec.(*codecImpl)
-			reader := bytes.NewReader(b)
-			startLen := reader.Len()
-			got, err := codec.decodeSerializedPath(reader)
-			if err != nil {
-				return
+			for _, branchFactor := range branchFactors {
+				codec := newCodec(branchFactor)
+				reader := bytes.NewReader(b)
+				startLen := reader.Len()
+				got, err := codec.decodeSerializedPath(reader)
+				if err != nil {
+					continue
+				}
+				endLen := reader.Len()
+				numRead := startLen - endLen
+
+				// Encoding [got] should be the same as [b].
+				var buf bytes.Buffer
+				err = codec.encodeSerializedPath(got, &buf)
+				require.NoError(err)
+				bufBytes := buf.Bytes()
+				require.Len(bufBytes, numRead)
+				require.Equal(b[:numRead], bufBytes)
+
+				clonedGot := got.deserialize(branchFactor).Serialize(branchFactor)
+				require.Equal(got, clonedGot)
 			}
-			endLen := reader.Len()
-			numRead := startLen - endLen
-
-			// Encoding [got] should be the same as [b].
-			var buf bytes.Buffer
-			err = codec.encodeSerializedPath(got, &buf)
-			require.NoError(err)
-			bufBytes := buf.Bytes()
-			require.Len(bufBytes, numRead)
-			require.Equal(b[:numRead], bufBytes)
-
-			clonedGot := got.deserialize().Serialize()
-			require.Equal(got, clonedGot)
 		},
 	)
 }
@@ -270,6 +272,29 @@ func FuzzCodecProofCanonical(f *testing.F) {
 	)
 }
 
+func FuzzCodecMultiProofCanonical(f *testing.F) {
+	f.Fuzz(
+		func(
+			t *testing.T,
+			b []byte,
+		) {
+			require := require.New(t)
+
+			codec := Codec.(*codecImpl)
+			proof := &MultiProof{}
+			got, err := codec.DecodeMultiProof(b, proof)
+			if err != nil {
+				return
+			}
+
+			// Encoding [proof] should be the same as [b].
+			buf, err := codec.EncodeMultiProof(got, proof)
+			require.NoError(err)
+			require.Equal(b, buf)
+		},
+	)
+}
+
 func FuzzCodecChangeProofCanonical(f *testing.F) {
 	f.Fuzz(
 		func(
@@ -520,41 +545,132 @@ func FuzzCodecDBNodeDeterministic(f *testing.F) {
 				value = Some(valueBytes)
 			}
 
-			numChildren := r.Intn(NodeBranchFactor) // #nosec G404
+			for _, branchFactor := range branchFactors {
+				codec := newCodec(branchFactor)
+				numChildren := r.Intn(int(branchFactor)) // #nosec G404
+
+				children := map[byte]child{}
+				for i := 0; i < numChildren; i++ {
+					var childID ids.ID
+					_, _ = r.Read(childID[:]) // #nosec G404
+
+					childPathBytes := make([]byte, r.Intn(32)) // #nosec G404
+					_, _ = r.Read(childPathBytes)              // #nosec G404
+
+					// The compressed path may end in the middle of a byte.
+					childPath := newPath(childPathBytes, branchFactor)
+					childPath = childPath[:r.Intn(len(childPath)+1)] // #nosec G404
+
+					children[byte(r.Intn(int(branchFactor)))] = child{ // #nosec G404
+						compressedPath: childPath,
+						id:             childID,
+					}
+				}
+				node := dbNode{
+					value:    value,
+					children: children,
+				}
+
+				nodeBytes, err := codec.encodeDBNode(Version, &node)
+				require.NoError(err)
+
+				var gotNode dbNode
+				gotVersion, err := codec.decodeDBNode(nodeBytes, &gotNode)
+				require.NoError(err)
+				require.Equal(Version, gotVersion)
+
+				nilEmptySlices(&node)
+				nilEmptySlices(&gotNode)
+				require.Equal(node, gotNode)
 
-			children := map[byte]child{}
-			for i := 0; i < numChildren; i++ {
-				var childID ids.ID
-				_, _ = r.Read(childID[:]) // #nosec G404
+				nodeBytes2, err := codec.encodeDBNode(Version, &gotNode)
+				require.NoError(err)
+				require.Equal(nodeBytes, nodeBytes2)
+			}
+		},
+	)
+}
+
+func FuzzCodecChangeSummaryDeterministic(f *testing.F) {
+	f.Fuzz(
+		func(
+			t *testing.T,
+			randSeed int,
+			numNodes uint,
+			numValues uint,
+		) {
+			require := require.New(t)
+
+			const maxChanges = 32
+
+			r := rand.New(rand.NewSource(int64(randSeed))) // #nosec G404
 
-				childPathBytes := make([]byte, r.Intn(32)) // #nosec G404
-				_, _ = r.Read(childPathBytes)              // #nosec G404
+			newRandomNode := func(key path) *node {
+				if r.Intn(4) == 0 { // #nosec G404
+					return nil
+				}
+				n := newNode(nil, key)
+				if r.Intn(2) == 0 { // #nosec G404
+					value := make([]byte, r.Intn(32)) // #nosec G404
+					_, _ = r.Read(value)              // #nosec G404
+					n.setValue(SHA256Hasher, Some(value))
+				}
+				numChildren := r.Intn(int(BranchFactor16)) // #nosec G404
+				for i := 0; i < numChildren; i++ {
+					var childID ids.ID
+					_, _ = r.Read(childID[:]) // #nosec G404
+					n.children[byte(i)] = child{
+						compressedPath: newPath([]byte{byte(i)}, BranchFactor16),
+						id:             childID,
+					}
+				}
+				nodeBytes, err := n.marshal(Codec)
+				require.NoError(err)
+				n, err = parseNode(Codec, SHA256Hasher, key, nodeBytes)
+				require.NoError(err)
+				return n
+			}
+			newRandomValue := func() Maybe[[]byte] {
+				if r.Intn(2) == 0 { // #nosec G404
+					return Nothing[[]byte]()
+				}
+				value := make([]byte, r.Intn(32)+1) // #nosec G404
+				_, _ = r.Read(value)                // #nosec G404
+				return Some(value)
+			}
 
-				children[byte(i)] = child{
-					compressedPath: newPath(childPathBytes),
-					id:             childID,
+			changes := newChangeSummary(int(numValues))
+			_, _ = r.Read(changes.rootID[:]) // #nosec G404
+			for i := uint(0); i < numNodes%maxChanges; i++ {
+				keyBytes := make([]byte, r.Intn(32)) // #nosec G404
+				_, _ = r.Read(keyBytes)              // #nosec G404
+				key := newPath(keyBytes, BranchFactor16)
+				changes.nodes[key] = &change[*node]{
+					before: newRandomNode(key),
+					after:  newRandomNode(key),
 				}
 			}
-			node := dbNode{
-				value:    value,
-				children: children,
+			for i := uint(0); i < numValues%maxChanges; i++ {
+				keyBytes := make([]byte, r.Intn(32)) // #nosec G404
+				_, _ = r.Read(keyBytes)              // #nosec G404
+				changes.values[newPath(keyBytes, BranchFactor16)] = &change[Maybe[[]byte]]{
+					before: newRandomValue(),
+					after:  newRandomValue(),
+				}
 			}
 
-			nodeBytes, err := Codec.encodeDBNode(Version, &node)
+			changesBytes, err := Codec.encodeChangeSummary(Version, changes)
 			require.NoError(err)
 
-			var gotNode dbNode
-			gotVersion, err := Codec.decodeDBNode(nodeBytes, &gotNode)
+			var gotChanges changeSummary
+			gotVersion, err := Codec.decodeChangeSummary(changesBytes, &gotChanges, SHA256Hasher)
 			require.NoError(err)
 			require.Equal(Version, gotVersion)
+			require.Equal(changes, &gotChanges)
 
-			nilEmptySlices(&node)
-			nilEmptySlices(&gotNode)
-			require.Equal(node, gotNode)
-
-			nodeBytes2, err := Codec.encodeDBNode(Version, &gotNode)
+			changesBytes2, err := Codec.encodeChangeSummary(Version, &gotChanges)
 			require.NoError(err)
-			require.Equal(nodeBytes, nodeBytes2)
+			require.Equal(changesBytes, changesBytes2)
 		},
 	)
 }
@@ -573,6 +689,37 @@ func TestCodec_DecodeProof(t *testing.T) {
 	require.ErrorIs(err, io.ErrUnexpectedEOF)
 }
 
+func TestCodec_DecodeMultiProof(t *testing.T) {
+	require := require.New(t)
+
+	_, err := Codec.DecodeMultiProof([]byte{1}, nil)
+	require.ErrorIs(err, errDecodeNil)
+
+	var (
+		proof         MultiProof
+		tooShortBytes = make([]byte, minMultiProofLen-1)
+	)
+	_, err = Codec.DecodeMultiProof(tooShortBytes, &proof)
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+
+	proofBytes, err := Codec.EncodeMultiProof(Version, &MultiProof{})
+	require.NoError(err)
+
+	// Remove num keys (0) from end
+	proofBytes = proofBytes[:len(proofBytes)-minVarIntLen]
+	proofBytesBuf := bytes.NewBuffer(proofBytes)
+	// Put num keys -1 at end
+	require.NoError(Codec.(*codecImpl).encodeInt(proofBytesBuf, -1))
+
+	_, err = Codec.DecodeMultiProof(proofBytesBuf.Bytes(), &proof)
+	require.ErrorIs(err, errNegativeNumKeys)
+
+	_, err = Codec.EncodeMultiProof(Version, &MultiProof{
+		Keys: [][]byte{{1}},
+	})
+	require.ErrorIs(err, errKeysValuesMismatch)
+}
+
 func TestCodec_DecodeChangeProof(t *testing.T) {
 	require := require.New(t)
 
@@ -676,10 +823,111 @@ func TestCodec_DecodeDBNode(t *testing.T) {
 	nodeBytes = proofBytesBuf.Bytes()
 	nodeBytes = nodeBytes[:len(nodeBytes)-minVarIntLen]
 	proofBytesBuf = bytes.NewBuffer(nodeBytes)
-	// Put num children NodeBranchFactor+1 at end
-	err = Codec.(*codecImpl).encodeInt(proofBytesBuf, NodeBranchFactor+1)
+	// Put num children branch factor+1 at end
+	err = Codec.(*codecImpl).encodeInt(proofBytesBuf, int(BranchFactor16)+1)
 	require.NoError(err)
 
 	_, err = Codec.decodeDBNode(proofBytesBuf.Bytes(), &parsedDBNode)
 	require.ErrorIs(err, errTooManyChildren)
 }
+
+func TestCodec_DecodeChangeSummary(t *testing.T) {
+	require := require.New(t)
+
+	_, err := Codec.decodeChangeSummary([]byte{1}, nil, SHA256Hasher)
+	require.ErrorIs(err, errDecodeNil)
+
+	var (
+		parsedChanges changeSummary
+		tooShortBytes = make([]byte, minChangeSummaryLen-1)
+	)
+	_, err = Codec.decodeChangeSummary(tooShortBytes, &parsedChanges, SHA256Hasher)
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+
+	changes := newChangeSummary(0)
+	changesBytes, err := Codec.encodeChangeSummary(Version, changes)
+	require.NoError(err)
+
+	// Remove num values (0) from end
+	changesBytes = changesBytes[:len(changesBytes)-minVarIntLen]
+	changesBytesBuf := bytes.NewBuffer(changesBytes)
+	// Put num values -1 at end
+	err = Codec.(*codecImpl).encodeInt(changesBytesBuf, -1)
+	require.NoError(err)
+
+	_, err = Codec.decodeChangeSummary(changesBytesBuf.Bytes(), &parsedChanges, SHA256Hasher)
+	require.ErrorIs(err, errNegativeNumChanges)
+
+	// Remove num values from end
+	changesBytes = changesBytesBuf.Bytes()
+	changesBytes = changesBytes[:len(changesBytes)-minVarIntLen]
+	changesBytesBuf = bytes.NewBuffer(changesBytes)
+	// Put num values 1 at end, without any values following it
+	err = Codec.(*codecImpl).encodeInt(changesBytesBuf, 1)
+	require.NoError(err)
+
+	_, err = Codec.decodeChangeSummary(changesBytesBuf.Bytes(), &parsedChanges, SHA256Hasher)
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+}
+
+func TestCodec_DecodeSerializedPath_BranchFactors(t *testing.T) {
+	require := require.New(t)
+
+	for _, branchFactor := range branchFactors {
+		codec := newCodec(branchFactor)
+
+		// A path that fills its last byte.
+		var buf bytes.Buffer
+		require.NoError(codec.encodeSerializedPath(SerializedPath{
+			NibbleLength: branchFactor.TokensPerByte(),
+			Value:        []byte{0xFF},
+		}, &buf))
+		got, err := codec.decodeSerializedPath(bytes.NewReader(buf.Bytes()))
+		require.NoError(err)
+		require.Equal([]byte{0xFF}, got.Value)
+
+		// A path with more tokens than there are bytes.
+		buf.Reset()
+		require.NoError(codec.encodeInt(&buf, branchFactor.TokensPerByte()+1))
+		buf.WriteByte(0)
+		_, err = codec.decodeSerializedPath(bytes.NewReader(buf.Bytes()))
+		require.ErrorIs(err, io.ErrUnexpectedEOF)
+
+		if branchFactor == BranchFactor256 {
+			continue
+		}
+
+		// The unused bits of the last byte must be 0.
+		buf.Reset()
+		require.NoError(codec.encodeSerializedPath(SerializedPath{
+			NibbleLength: 1,
+			Value:        []byte{0xFF},
+		}, &buf))
+		_, err = codec.decodeSerializedPath(bytes.NewReader(buf.Bytes()))
+		require.ErrorIs(err, errNonZeroTokenPadding)
+	}
+}
+
+func TestCodec_DecodeDBNode_BranchFactors(t *testing.T) {
+	require := require.New(t)
+
+	for _, branchFactor := range branchFactors {
+		if branchFactor == BranchFactor256 {
+			// Every child index is valid.
+			continue
+		}
+
+		// A child index that is too large for [branchFactor] is rejected.
+		node := dbNode{
+			children: map[byte]child{
+				byte(branchFactor): {},
+			},
+		}
+		nodeBytes, err := newCodec(BranchFactor256).encodeDBNode(Version, &node)
+		require.NoError(err)
+
+		var parsedNode dbNode
+		_, err = newCodec(branchFactor).decodeDBNode(nodeBytes, &parsedNode)
+		require.ErrorIs(err, errChildIndexTooLarge)
+	}
+}
@@ -6,14 +6,18 @@ package merkledb
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"go.opentelemetry.io/otel/attribute"
 
+	"go.uber.org/zap"
+
 	oteltrace "go.opentelemetry.io/otel/trace"
 
 	"golang.org/x/exp/maps"
@@ -25,6 +29,7 @@ import (
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/trace"
 	"github.com/memeticofficial/pepecoingo/utils"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
 	"github.com/memeticofficial/pepecoingo/utils/math"
 	"github.com/memeticofficial/pepecoingo/utils/set"
 )
@@ -35,34 +40,72 @@ const (
 	// TODO: name better
 	rebuildViewSizeFractionOfCacheSize = 50
 	minRebuildViewSizePerCommit        = 1000
+
+	// The number of keys, or intermediate nodes, processed between progress
+	// logs when regenerating intermediate nodes.
+	rebuildLogFrequency = 100_000
+
+	// The persisted trie config is the branch factor as a uint16 followed by
+	// the hasher.
+	trieConfigLen = 3
 )
 
 var (
 	_ TrieView          = &Database{}
 	_ database.Database = &Database{}
 
-	Codec, Version = newCodec()
+	// Codec encodes and decodes the proofs of tries with the default branch
+	// factor.
+	Codec   = NewCodec(DefaultBranchFactor)
+	Version = uint16(codecVersion)
 
 	rootKey                 = []byte{}
 	nodePrefix              = []byte("node")
 	metadataPrefix          = []byte("metadata")
+	historyPrefix           = []byte("history")
+	dirtyNodesPrefix        = []byte("dirtyNodes")
 	cleanShutdownKey        = []byte("cleanShutdown")
+	trackingDirtyNodesKey   = []byte("trackingDirtyNodes")
+	trieConfigKey           = []byte("trieConfig")
 	hadCleanShutdown        = []byte{1}
 	didNotHaveCleanShutdown = []byte{0}
 
-	errSameRoot = errors.New("start and end root are the same")
+	ErrConfigMismatch = errors.New("config doesn't match the database")
+
+	errSameRoot          = errors.New("start and end root are the same")
+	errInvalidTrieConfig = errors.New("invalid persisted trie config")
 )
 
 type Config struct {
 	// The number of changes to the database that we store in memory in order to
 	// serve change proofs.
 	HistoryLength int
-	NodeCacheSize int
+	// If true, every change to the database is also stored on disk so that
+	// change proofs can be served for roots that are no longer in the
+	// in-memory history, including after a restart. Enabling or disabling
+	// this doesn't require a migration. If disabled, any previously stored
+	// changes are deleted.
+	PersistHistory bool
+	// The maximum number of changes stored on disk if [PersistHistory] is
+	// true. 0 means unbounded.
+	PersistedHistoryLength int
+	// The maximum total size, in bytes, of the changes stored on disk if
+	// [PersistHistory] is true. 0 means unbounded.
+	PersistedHistorySize int
+	NodeCacheSize        int
+	// The number of children of each node. 0 means [DefaultBranchFactor].
+	// Must match the branch factor the database was created with.
+	BranchFactor BranchFactor
+	// The hash function used to compute node IDs. Must match the hasher the
+	// database was created with.
+	Hasher Hasher
 	// If [Reg] is nil, metrics are collected locally but not exported through
 	// Prometheus.
 	// This may be useful for testing.
 	Reg    prometheus.Registerer
 	Tracer trace.Tracer
+	// If [Log] is nil, nothing is logged.
+	Log logging.Logger
 }
 
 // Can only be edited by committing changes from a trieView.
@@ -83,6 +126,13 @@ type Database struct {
 	// Stores data about the database's current state.
 	metadataDB database.Database
 
+	// The number of children of each node.
+	branchFactor BranchFactor
+	// The hash function used to compute node IDs.
+	hasher Hasher
+	// Encodes and decodes nodes and proofs of tries with [branchFactor].
+	codec EncoderDecoder
+
 	// If a value is nil, the corresponding key isn't in the trie.
 	nodeCache     onEvictCache[path, *node]
 	onEvictionErr utils.Atomic[error]
@@ -91,6 +141,14 @@ type Database struct {
 	// historical views of the trie.
 	history *trieHistory
 
+	// Stores change lists on disk. Used when [history] doesn't contain the
+	// requested roots. Nil if [Config.PersistHistory] is false.
+	diskHistory *diskHistory
+
+	// Tracks the intermediate nodes that may be stale on disk so that only
+	// they need to be regenerated after an unclean shutdown.
+	dirtyNodes *dirtyNodes
+
 	// True iff the db has been closed.
 	closed bool
 
@@ -98,6 +156,8 @@ type Database struct {
 
 	tracer trace.Tracer
 
+	log logging.Logger
+
 	// The root of this trie.
 	root *node
 
@@ -111,19 +171,70 @@ func newDatabase(
 	config Config,
 	metrics merkleMetrics,
 ) (*Database, error) {
+	if config.BranchFactor == 0 {
+		config.BranchFactor = DefaultBranchFactor
+	}
+	if err := config.BranchFactor.Valid(); err != nil {
+		return nil, err
+	}
+	if err := config.Hasher.Valid(); err != nil {
+		return nil, err
+	}
+
 	trieDB := &Database{
-		metrics:    metrics,
-		nodeDB:     versiondb.New(prefixdb.New(nodePrefix, db)),
-		metadataDB: prefixdb.New(metadataPrefix, db),
-		history:    newTrieHistory(config.HistoryLength),
-		tracer:     config.Tracer,
-		childViews: make([]*trieView, 0, defaultPreallocationSize),
+		metrics:      metrics,
+		nodeDB:       versiondb.New(prefixdb.New(nodePrefix, db)),
+		metadataDB:   prefixdb.New(metadataPrefix, db),
+		branchFactor: config.BranchFactor,
+		hasher:       config.Hasher,
+		codec:        NewCodec(config.BranchFactor),
+		history:      newTrieHistory(config.HistoryLength, config.BranchFactor),
+		dirtyNodes:   newDirtyNodes(prefixdb.New(dirtyNodesPrefix, db)),
+		tracer:       config.Tracer,
+		log:          config.Log,
+		childViews:   make([]*trieView, 0, defaultPreallocationSize),
+	}
+	if trieDB.log == nil {
+		trieDB.log = logging.NoLog{}
+	}
+
+	// The nodes on disk can only be read with the config they were written
+	// with, so this must be checked before anything is read.
+	if err := trieDB.checkTrieConfig(); err != nil {
+		return nil, err
 	}
 
 	// Note: trieDB.OnEviction is responsible for writing intermediary nodes to
 	// disk as they are evicted from the cache.
 	trieDB.nodeCache = newOnEvictCache[path](config.NodeCacheSize, trieDB.onEviction)
 
+	shutdownType, err := trieDB.metadataDB.Get(cleanShutdownKey)
+	needsRebuild := false
+	switch err {
+	case nil:
+		needsRebuild = bytes.Equal(shutdownType, didNotHaveCleanShutdown)
+	case database.ErrNotFound:
+		// If the marker wasn't found then the DB is being created for the first
+		// time and there is nothing to do.
+	default:
+		return nil, err
+	}
+
+	if needsRebuild {
+		rebuilt, err := trieDB.rebuildDirtyNodes()
+		if err != nil {
+			return nil, err
+		}
+		needsRebuild = !rebuilt
+	}
+
+	// The markers have either been handled above, are from before a clean
+	// shutdown, or are unnecessary because every intermediate node will be
+	// rebuilt.
+	if err := trieDB.dirtyNodes.clear(); err != nil {
+		return nil, err
+	}
+
 	root, err := trieDB.initializeRootIfNeeded()
 	if err != nil {
 		return nil, err
@@ -136,18 +247,21 @@ func newDatabase(
 		nodes:  map[path]*change[*node]{},
 	})
 
-	shutdownType, err := trieDB.metadataDB.Get(cleanShutdownKey)
-	switch err {
-	case nil:
-		if bytes.Equal(shutdownType, didNotHaveCleanShutdown) {
-			if err := trieDB.rebuild(ctx); err != nil {
-				return nil, err
-			}
+	if needsRebuild {
+		if err := trieDB.rebuild(ctx); err != nil {
+			return nil, err
 		}
-	case database.ErrNotFound:
-		// If the marker wasn't found then the DB is being created for the first
-		// time and there is nothing to do.
-	default:
+	}
+
+	// Note: the disk history is initialized after any rebuild so that the
+	// intermediate roots of the rebuild aren't persisted.
+	if err := trieDB.initializeDiskHistory(prefixdb.New(historyPrefix, db), config); err != nil {
+		return nil, err
+	}
+
+	// mark that the dirty nodes are tracked, so that only they need to be
+	// regenerated after an unclean shutdown
+	if err := trieDB.metadataDB.Put(trackingDirtyNodesKey, nil); err != nil {
 		return nil, err
 	}
 
@@ -156,9 +270,96 @@ func newDatabase(
 	return trieDB, err
 }
 
+// checkTrieConfig returns [ErrConfigMismatch] if the database was created
+// with a different branch factor or hasher. Otherwise, the config is persisted
+// so that it can be checked when the database is reopened.
+func (db *Database) checkTrieConfig() error {
+	expected := make([]byte, trieConfigLen)
+	binary.BigEndian.PutUint16(expected, uint16(db.branchFactor))
+	expected[trieConfigLen-1] = byte(db.hasher)
+
+	configBytes, err := db.metadataDB.Get(trieConfigKey)
+	switch err {
+	case nil:
+		if len(configBytes) != trieConfigLen {
+			return fmt.Errorf("%w: length %d", errInvalidTrieConfig, len(configBytes))
+		}
+	case database.ErrNotFound:
+		// The database is either new or was created before the config was
+		// persisted, in which case the defaults were used.
+		hasRoot, err := db.nodeDB.Has(rootKey)
+		if err != nil {
+			return err
+		}
+		if !hasRoot {
+			return db.metadataDB.Put(trieConfigKey, expected)
+		}
+		configBytes = make([]byte, trieConfigLen)
+		binary.BigEndian.PutUint16(configBytes, uint16(DefaultBranchFactor))
+		configBytes[trieConfigLen-1] = byte(SHA256Hasher)
+	default:
+		return err
+	}
+
+	if !bytes.Equal(configBytes, expected) {
+		return fmt.Errorf(
+			"%w: created with branch factor %d and hasher %s but opened with branch factor %d and hasher %s",
+			ErrConfigMismatch,
+			binary.BigEndian.Uint16(configBytes),
+			Hasher(configBytes[trieConfigLen-1]),
+			db.branchFactor,
+			db.hasher,
+		)
+	}
+	return db.metadataDB.Put(trieConfigKey, expected)
+}
+
+// initializeDiskHistory sets [db.diskHistory] if [config.PersistHistory] is
+// true. Otherwise, any previously persisted history is deleted.
+func (db *Database) initializeDiskHistory(historyDB database.Database, config Config) error {
+	if !config.PersistHistory {
+		return historyDB.DeleteRange(nil, nil)
+	}
+
+	diskHistory, err := newDiskHistory(historyDB, db.branchFactor, db.hasher, config.PersistedHistoryLength, config.PersistedHistorySize)
+	if err != nil {
+		return err
+	}
+
+	// If the persisted history doesn't end at the current root, it can't be
+	// used to recreate previous tries.
+	latestRoot, ok, err := diskHistory.latestRoot()
+	if err != nil {
+		return err
+	}
+	if ok && latestRoot != db.getMerkleRoot() {
+		if err := diskHistory.clear(); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if !ok {
+		// add current root to history (has no changes)
+		err := diskHistory.record(&changeSummary{
+			rootID: db.getMerkleRoot(),
+			values: map[path]*change[Maybe[[]byte]]{},
+			nodes:  map[path]*change[*node]{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	db.diskHistory = diskHistory
+	return nil
+}
+
 // Deletes every intermediate node and rebuilds them by re-adding every key/value.
-// TODO: make this more efficient by only clearing out the stale portions of the trie.
+// Only used if the dirty nodes weren't tracked before an unclean shutdown,
+// for example because the database was last opened by an older version.
 func (db *Database) rebuild(ctx context.Context) error {
+	db.log.Info("rebuilding every intermediate node")
+	startTime := time.Now()
+
 	db.root = newNode(nil, RootPath)
 	if err := db.nodeDB.Delete(rootKey); err != nil {
 		return err
@@ -177,6 +378,7 @@ func (db *Database) rebuild(ctx context.Context) error {
 		return err
 	}
 
+	numKeys := 0
 	for it.Next() {
 		if currentViewSize >= viewSizeLimit {
 			if err := currentView.commitToDB(ctx); err != nil {
@@ -192,16 +394,26 @@ func (db *Database) rebuild(ctx context.Context) error {
 		key := it.Key()
 		path := path(key)
 		value := it.Value()
-		n, err := parseNode(path, value)
+		n, err := parseNode(db.codec, db.hasher, path, value)
 		if err != nil {
 			return err
 		}
 		if n.hasValue() {
-			serializedPath := path.Serialize()
+			serializedPath := path.Serialize(db.branchFactor)
 			if err := currentView.Insert(ctx, serializedPath.Value, n.value.value); err != nil {
 				return err
 			}
 			currentViewSize++
+			numKeys++
+
+			if numKeys%rebuildLogFrequency == 0 {
+				db.log.Info("rebuilding every intermediate node",
+					zap.Int("numKeys", numKeys),
+					zap.Duration("duration", time.Since(startTime)),
+				)
+			}
+		} else {
+			db.metrics.RebuiltNode()
 		}
 		if err := db.nodeDB.Delete(key); err != nil {
 			return err
@@ -213,7 +425,144 @@ func (db *Database) rebuild(ctx context.Context) error {
 	if err := currentView.commitToDB(ctx); err != nil {
 		return err
 	}
-	return db.nodeDB.Compact(nil, nil)
+	if err := db.nodeDB.Compact(nil, nil); err != nil {
+		return err
+	}
+
+	db.log.Info("finished rebuilding every intermediate node",
+		zap.Int("numKeys", numKeys),
+		zap.Duration("duration", time.Since(startTime)),
+	)
+	return nil
+}
+
+// rebuildDirtyNodes regenerates the intermediate nodes that were marked as
+// dirty before an unclean shutdown. The other nodes on disk are up to date.
+// Returns false, without modifying the database, if the dirty nodes weren't
+// tracked, in which case [rebuild] must be used instead.
+func (db *Database) rebuildDirtyNodes() (bool, error) {
+	if _, err := db.metadataDB.Get(trackingDirtyNodesKey); err == database.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	dirtyPaths, err := db.dirtyNodes.paths()
+	if err != nil {
+		return false, err
+	}
+
+	db.log.Info("regenerating dirty intermediate nodes",
+		zap.Int("numNodes", len(dirtyPaths)),
+	)
+	startTime := time.Now()
+
+	// [dirtyPaths] is sorted, which is used to find the dirty children of a
+	// node. The nodes are regenerated deepest first so that the IDs of a
+	// node's children are known when it's regenerated.
+	regenerationOrder := slices.Clone(dirtyPaths)
+	slices.SortFunc(regenerationOrder, func(a, b path) bool {
+		return len(a) > len(b)
+	})
+
+	regenerated := make(map[path]*node, len(dirtyPaths))
+	for i, key := range regenerationOrder {
+		n, err := db.regenerateNode(key, dirtyPaths, regenerated)
+		if err != nil {
+			return false, err
+		}
+		nodeBytes, err := n.marshal(db.codec)
+		if err != nil {
+			return false, err
+		}
+		if err := db.nodeDB.Put(key.Bytes(), nodeBytes); err != nil {
+			return false, err
+		}
+		regenerated[key] = n
+		db.metrics.RebuiltNode()
+
+		if numRegenerated := i + 1; numRegenerated%rebuildLogFrequency == 0 {
+			db.log.Info("regenerating dirty intermediate nodes",
+				zap.Int("numRegenerated", numRegenerated),
+				zap.Int("numNodes", len(dirtyPaths)),
+				zap.Duration("duration", time.Since(startTime)),
+			)
+		}
+	}
+	if err := db.nodeDB.Commit(); err != nil {
+		return false, err
+	}
+
+	db.log.Info("finished regenerating dirty intermediate nodes",
+		zap.Int("numNodes", len(dirtyPaths)),
+		zap.Duration("duration", time.Since(startTime)),
+	)
+	return true, nil
+}
+
+// regenerateNode returns the intermediate node at [key], with children read
+// from [regenerated] or, if they aren't dirty, from disk.
+// [dirtyPaths] must be sorted and every dirty path longer than [key] must be
+// in [regenerated].
+func (db *Database) regenerateNode(key path, dirtyPaths []path, regenerated map[path]*node) (*node, error) {
+	n := newNode(nil, key)
+	for i := 0; i < int(db.branchFactor); i++ {
+		index := byte(i)
+		childPath, ok, err := db.firstNodeWithPrefix(key.Append(index), dirtyPaths)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		child, ok := regenerated[childPath]
+		if !ok {
+			childBytes, err := db.nodeDB.Get(childPath.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			child, err = parseNode(db.codec, db.hasher, childPath, childBytes)
+			if err != nil {
+				return nil, err
+			}
+			if err := child.calculateID(db.codec, db.hasher, db.metrics); err != nil {
+				return nil, err
+			}
+		}
+		n.addChildWithoutNode(index, childPath[len(key)+1:], child.id)
+	}
+	return n, n.calculateID(db.codec, db.hasher, db.metrics)
+}
+
+// firstNodeWithPrefix returns the shortest path with [prefix] of a node that
+// is either on disk or in [dirtyPaths], which must be sorted. Because the
+// trie is compressed, every other node with [prefix] is a descendant of it.
+// Returns false if there is no such node.
+func (db *Database) firstNodeWithPrefix(prefix path, dirtyPaths []path) (path, bool, error) {
+	it := db.nodeDB.NewIteratorWithPrefix(prefix.Bytes())
+	defer it.Release()
+
+	var (
+		first path
+		found = it.Next()
+	)
+	if found {
+		first = path(it.Key())
+	}
+	if err := it.Error(); err != nil {
+		return "", false, err
+	}
+
+	// Dirty nodes may not be on disk.
+	index, _ := slices.BinarySearch(dirtyPaths, prefix)
+	if index < len(dirtyPaths) {
+		dirtyPath := dirtyPaths[index]
+		if dirtyPath.HasPrefix(prefix) && (!found || dirtyPath < first) {
+			return dirtyPath, true, nil
+		}
+	}
+	return first, found, nil
 }
 
 // New returns a new merkle database.
@@ -225,6 +574,16 @@ func New(ctx context.Context, db database.Database, config Config) (*Database, e
 	return newDatabase(ctx, db, config, metrics)
 }
 
+// BranchFactor returns the number of children of each node in the trie.
+func (db *Database) BranchFactor() BranchFactor {
+	return db.branchFactor
+}
+
+// Hasher returns the hash function used to compute node IDs.
+func (db *Database) Hasher() Hasher {
+	return db.hasher
+}
+
 // Commits the key/value pairs within the [proof] to the db.
 func (db *Database) CommitChangeProof(ctx context.Context, proof *ChangeProof) error {
 	db.commitLock.Lock()
@@ -278,6 +637,9 @@ func (db *Database) Close() error {
 	defer func() {
 		_ = db.metadataDB.Close()
 		_ = db.nodeDB.Close()
+		if db.diskHistory != nil {
+			_ = db.diskHistory.db.Close()
+		}
 	}()
 
 	if err := db.onEvictionErr.Get(); err != nil {
@@ -299,7 +661,14 @@ func (db *Database) Close() error {
 	}
 
 	// Successfully wrote intermediate nodes.
-	return db.metadataDB.Put(cleanShutdownKey, hadCleanShutdown)
+	if err := db.metadataDB.Put(cleanShutdownKey, hadCleanShutdown); err != nil {
+		return err
+	}
+
+	// The dirty nodes are no longer tracked once the db is closed. If the db
+	// is next opened by a version that doesn't track them, this ensures that
+	// the untracked changes aren't missed by a later rebuild.
+	return db.metadataDB.Delete(trackingDirtyNodesKey)
 }
 
 func (db *Database) Delete(key []byte) error {
@@ -308,6 +677,14 @@ func (db *Database) Delete(key []byte) error {
 	return db.Remove(context.Background(), key)
 }
 
+func (db *Database) DeleteRange(start, limit []byte) error {
+	return db.commitBatch([]database.BatchOp{{
+		Key:         start,
+		DeleteRange: true,
+		Limit:       limit,
+	}})
+}
+
 func (db *Database) Get(key []byte) ([]byte, error) {
 	// this is a duplicate because the database interface doesn't support
 	// contexts, which are used for tracing
@@ -327,7 +704,7 @@ func (db *Database) GetValues(ctx context.Context, keys [][]byte) ([][]byte, []e
 	values := make([][]byte, len(keys))
 	errors := make([]error, len(keys))
 	for i, key := range keys {
-		values[i], errors[i] = db.getValueCopy(newPath(key), false /*lock*/)
+		values[i], errors[i] = db.getValueCopy(newPath(key, db.branchFactor), false /*lock*/)
 	}
 	return values, errors
 }
@@ -338,7 +715,7 @@ func (db *Database) GetValue(ctx context.Context, key []byte) ([]byte, error) {
 	_, span := db.tracer.Start(ctx, "MerkleDB.GetValue")
 	defer span.End()
 
-	return db.getValueCopy(newPath(key), true /*lock*/)
+	return db.getValueCopy(newPath(key, db.branchFactor), true /*lock*/)
 }
 
 // getValueCopy returns a copy of the value for the given [key].
@@ -419,6 +796,24 @@ func (db *Database) getProof(ctx context.Context, key []byte) (*Proof, error) {
 	return view.getProof(ctx, key)
 }
 
+// Returns a proof of the existence/non-existence of each key in [keys] in
+// this trie. Proof nodes shared between keys are only included once.
+func (db *Database) GetMultiProof(ctx context.Context, keys [][]byte) (*MultiProof, error) {
+	db.commitLock.RLock()
+	defer db.commitLock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+
+	view, err := db.newUntrackedView(defaultPreallocationSize)
+	if err != nil {
+		return nil, err
+	}
+	// Don't need to lock [view] because nobody else has a reference to it.
+	return view.getMultiProof(ctx, keys)
+}
+
 // Returns a proof for the key/value pairs in this trie within the range
 // [start, end].
 func (db *Database) GetRangeProof(
@@ -498,7 +893,7 @@ func (db *Database) GetChangeProof(
 	result := &ChangeProof{
 		HadRootsInHistory: true,
 	}
-	changes, err := db.history.getValueChanges(startRootID, endRootID, start, end, maxLength)
+	changes, err := db.getValueChanges(startRootID, endRootID, start, end, maxLength)
 	if err == ErrRootIDNotPresent {
 		result.HadRootsInHistory = false
 		return result, nil
@@ -520,7 +915,7 @@ func (db *Database) GetChangeProof(
 
 	for _, key := range changedKeys {
 		change := changes.values[key]
-		serializedKey := key.Serialize().Value
+		serializedKey := key.Serialize(db.branchFactor).Value
 
 		result.KeyChanges = append(result.KeyChanges, KeyChange{
 			Key: serializedKey,
@@ -619,7 +1014,7 @@ func (db *Database) Has(k []byte) (bool, error) {
 		return false, database.ErrClosed
 	}
 
-	_, err := db.getValue(newPath(k), false /*lock*/)
+	_, err := db.getValue(newPath(k, db.branchFactor), false /*lock*/)
 	if err == database.ErrNotFound {
 		return false, nil
 	}
@@ -670,27 +1065,81 @@ func (db *Database) NewIterator() database.Iterator {
 
 func (db *Database) NewIteratorWithStart(start []byte) database.Iterator {
 	return &iterator{
-		nodeIter: db.nodeDB.NewIteratorWithStart(newPath(start).Bytes()),
+		nodeIter: db.nodeDB.NewIteratorWithStart(newPath(start, db.branchFactor).Bytes()),
 		db:       db,
 	}
 }
 
 func (db *Database) NewIteratorWithPrefix(prefix []byte) database.Iterator {
 	return &iterator{
-		nodeIter: db.nodeDB.NewIteratorWithPrefix(newPath(prefix).Bytes()),
+		nodeIter: db.nodeDB.NewIteratorWithPrefix(newPath(prefix, db.branchFactor).Bytes()),
 		db:       db,
 	}
 }
 
 func (db *Database) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
-	startBytes := newPath(start).Bytes()
-	prefixBytes := newPath(prefix).Bytes()
+	startBytes := newPath(start, db.branchFactor).Bytes()
+	prefixBytes := newPath(prefix, db.branchFactor).Bytes()
 	return &iterator{
 		nodeIter: db.nodeDB.NewIteratorWithStartAndPrefix(startBytes, prefixBytes),
 		db:       db,
 	}
 }
 
+func (db *Database) NewReverseIterator() database.Iterator {
+	return &iterator{
+		nodeIter: db.nodeDB.NewReverseIterator(),
+		db:       db,
+	}
+}
+
+func (db *Database) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return db.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (db *Database) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return &iterator{
+		nodeIter: db.nodeDB.NewReverseIteratorWithPrefix(newPath(prefix, db.branchFactor).Bytes()),
+		db:       db,
+	}
+}
+
+func (db *Database) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	// A nil [start] must be passed through as nil, because the empty path
+	// would only include the root.
+	var startBytes []byte
+	if start != nil {
+		startBytes = newPath(start, db.branchFactor).Bytes()
+	}
+	prefixBytes := newPath(prefix, db.branchFactor).Bytes()
+	return &iterator{
+		nodeIter: db.nodeDB.NewReverseIteratorWithStartAndPrefix(startBytes, prefixBytes),
+		db:       db,
+	}
+}
+
+// Snapshot returns a read-only view of the key-value pairs in the database at
+// the time that it was taken. The snapshot doesn't support proofs.
+func (db *Database) Snapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+	// Every node that has a value is written to [db.nodeDB] when it is
+	// committed, so the key-value pairs can be read directly from a snapshot
+	// of [db.nodeDB].
+	nodeSnapshot, err := db.nodeDB.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{
+		db:           db,
+		nodeSnapshot: nodeSnapshot,
+	}, nil
+}
+
 // If [node] is an intermediary node, puts it in [nodeDB].
 // Note this is called by [db.nodeCache] with its lock held, so
 // the movement of [node] from [db.nodeCache] to [db.nodeDB] is atomic.
@@ -702,7 +1151,7 @@ func (db *Database) onEviction(node *node) error {
 		return nil
 	}
 
-	nodeBytes, err := node.marshal()
+	nodeBytes, err := node.marshal(db.codec)
 	if err != nil {
 		db.onEvictionErr.Set(err)
 		// Prevent reads/writes from/to [db.nodeDB] to avoid inconsistent state.
@@ -718,6 +1167,7 @@ func (db *Database) onEviction(node *node) error {
 		go db.Close()
 		return err
 	}
+	db.dirtyNodes.onEviction(node.key)
 	return nil
 }
 
@@ -830,7 +1280,7 @@ func (db *Database) commitChanges(ctx context.Context, trieToCommit *trieView) e
 			// Otherwise, intermediary nodes are persisted on cache eviction or
 			// shutdown.
 			db.metrics.IOKeyWrite()
-			nodeBytes, err := nodeChange.after.marshal()
+			nodeBytes, err := nodeChange.after.marshal(db.codec)
 			if err != nil {
 				db.nodeDB.Abort()
 				nodesSpan.End()
@@ -847,7 +1297,7 @@ func (db *Database) commitChanges(ctx context.Context, trieToCommit *trieView) e
 	nodesSpan.End()
 
 	_, commitSpan := db.tracer.Start(ctx, "MerkleDB.commitChanges.dbCommit")
-	err := db.nodeDB.Commit()
+	diskHistoryState, dirtyNodesUpdate, err := db.commitNodesAndMetadata(changes)
 	commitSpan.End()
 	if err != nil {
 		db.nodeDB.Abort()
@@ -858,16 +1308,79 @@ func (db *Database) commitChanges(ctx context.Context, trieToCommit *trieView) e
 	// so that we don't need to clean up on error.
 	db.root = rootChange.after
 
+	// Note: the dirty nodes are updated before the changed nodes are cached
+	// so that any node evicted below is recorded.
+	db.dirtyNodes.apply(dirtyNodesUpdate)
+
+	// Update the nodes that are already cached before caching the others.
+	// Otherwise, caching a node could evict the stale version of a changed
+	// node, which would then be written to disk over the committed version.
+	uncachedKeys := make([]path, 0, len(changes.nodes))
 	for key, nodeChange := range changes.nodes {
+		if _, isCached := db.getNodeInCache(key); !isCached {
+			uncachedKeys = append(uncachedKeys, key)
+			continue
+		}
 		if err := db.putNodeInCache(key, nodeChange.after); err != nil {
 			return err
 		}
 	}
+	for _, key := range uncachedKeys {
+		if err := db.putNodeInCache(key, changes.nodes[key].after); err != nil {
+			return err
+		}
+	}
 
 	db.history.record(changes)
+	if db.diskHistory != nil {
+		db.diskHistory.state = diskHistoryState
+	}
 	return nil
 }
 
+// commitNodesAndMetadata atomically writes the pending changes in
+// [db.nodeDB], the dirty node markers for [changes] and, if the history is
+// persisted, [changes] to disk.
+// Returns the state of the disk history and the dirty nodes update after the
+// write, which should be applied once the in-memory state has been updated.
+// Assumes [db.lock] is held.
+func (db *Database) commitNodesAndMetadata(changes *changeSummary) (diskHistoryState, dirtyNodesUpdate, error) {
+	dirtyNodesBatch, dirtyNodesUpdate, err := db.dirtyNodes.prepareCommit(changes)
+	if err != nil {
+		return diskHistoryState{}, dirtyNodesUpdate, err
+	}
+	metadataBatches := []database.Batch{dirtyNodesBatch}
+
+	var diskHistoryState diskHistoryState
+	if db.diskHistory != nil {
+		var historyBatch database.Batch
+		historyBatch, diskHistoryState, err = db.diskHistory.prepareRecord(changes)
+		if err != nil {
+			return diskHistoryState, dirtyNodesUpdate, err
+		}
+		metadataBatches = append(metadataBatches, historyBatch)
+	}
+
+	nodeBatch, err := db.nodeDB.CommitBatch()
+	if err != nil {
+		return diskHistoryState, dirtyNodesUpdate, err
+	}
+
+	// The batches are written to the same underlying database, so the
+	// metadata can be written in the same batch as the nodes.
+	batch := nodeBatch.Inner()
+	for _, metadataBatch := range metadataBatches {
+		if err := metadataBatch.Inner().Replay(batch); err != nil {
+			return diskHistoryState, dirtyNodesUpdate, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return diskHistoryState, dirtyNodesUpdate, err
+	}
+	db.nodeDB.Abort()
+	return diskHistoryState, dirtyNodesUpdate, nil
+}
+
 // moveChildViewsToDB removes any child views from the trieToCommit and moves them to the db
 // assumes [db.lock] is held
 func (db *Database) moveChildViewsToDB(trieToCommit *trieView) {
@@ -910,11 +1423,11 @@ func (db *Database) initializeRootIfNeeded() (ids.ID, error) {
 	nodeBytes, err := db.nodeDB.Get(rootKey)
 	if err == nil {
 		// Root already exists, so parse it and set the in-mem copy
-		db.root, err = parseNode(RootPath, nodeBytes)
+		db.root, err = parseNode(db.codec, db.hasher, RootPath, nodeBytes)
 		if err != nil {
 			return ids.Empty, err
 		}
-		if err := db.root.calculateID(db.metrics); err != nil {
+		if err := db.root.calculateID(db.codec, db.hasher, db.metrics); err != nil {
 			return ids.Empty, err
 		}
 		return db.root.id, nil
@@ -927,12 +1440,12 @@ func (db *Database) initializeRootIfNeeded() (ids.ID, error) {
 	db.root = newNode(nil, RootPath)
 
 	// update its ID
-	if err := db.root.calculateID(db.metrics); err != nil {
+	if err := db.root.calculateID(db.codec, db.hasher, db.metrics); err != nil {
 		return ids.Empty, err
 	}
 
 	// write the newly constructed root to the DB
-	rootBytes, err := db.root.marshal()
+	rootBytes, err := db.root.marshal(db.codec)
 	if err != nil {
 		return ids.Empty, err
 	}
@@ -957,13 +1470,72 @@ func (db *Database) getHistoricalViewForRange(
 		return newTrieView(db, db, db.root.clone(), 100)
 	}
 
-	changeHistory, err := db.history.getChangesToGetToRoot(rootID, start, end)
+	changeHistory, err := db.getChangesToGetToRoot(rootID, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return newTrieViewWithChanges(db, db, changeHistory, len(changeHistory.nodes))
 }
 
+// Returns up to [maxLength] key-value pair changes with keys in [start, end]
+// that occurred between [startRoot] and [endRoot].
+// The in-memory history is checked before the disk history.
+// Assumes [db.commitLock] is read locked.
+func (db *Database) getValueChanges(startRoot, endRoot ids.ID, start, end []byte, maxLength int) (*changeSummary, error) {
+	changes, err := db.history.getValueChanges(startRoot, endRoot, start, end, maxLength)
+	if !isHistoryMiss(err) {
+		if err == nil {
+			db.metrics.HistoryMemoryHit()
+		}
+		return changes, err
+	}
+	if db.diskHistory == nil {
+		db.metrics.HistoryMiss()
+		return nil, err
+	}
+
+	changes, err = db.diskHistory.getValueChanges(startRoot, endRoot, start, end, maxLength)
+	db.recordDiskHistoryResult(err)
+	return changes, err
+}
+
+// Returns the changes to go from the current trie state back to the requested
+// [rootID] for the keys in [start, end].
+// The in-memory history is checked before the disk history.
+// Assumes [db.commitLock] is read locked.
+func (db *Database) getChangesToGetToRoot(rootID ids.ID, start, end []byte) (*changeSummary, error) {
+	changes, err := db.history.getChangesToGetToRoot(rootID, start, end)
+	if !isHistoryMiss(err) {
+		if err == nil {
+			db.metrics.HistoryMemoryHit()
+		}
+		return changes, err
+	}
+	if db.diskHistory == nil {
+		db.metrics.HistoryMiss()
+		return nil, err
+	}
+
+	changes, err = db.diskHistory.getChangesToGetToRoot(rootID, start, end)
+	db.recordDiskHistoryResult(err)
+	return changes, err
+}
+
+func (db *Database) recordDiskHistoryResult(err error) {
+	switch {
+	case err == nil:
+		db.metrics.HistoryDiskHit()
+	case isHistoryMiss(err):
+		db.metrics.HistoryMiss()
+	}
+}
+
+// Returns true iff [err] indicates that the requested roots aren't in a
+// history.
+func isHistoryMiss(err error) bool {
+	return err == ErrRootIDNotPresent || err == ErrStartRootNotFound
+}
+
 // Returns all of the keys in range [start, end] that aren't in [keySet].
 // If [start] is nil, then the range has no lower bound.
 // If [end] is nil, then the range has no upper bound.
@@ -1035,7 +1607,7 @@ func (db *Database) getNode(key path) (*node, error) {
 		return nil, err
 	}
 
-	node, err := parseNode(key, rawBytes)
+	node, err := parseNode(db.codec, db.hasher, key, rawBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -1103,19 +1675,59 @@ func (db *Database) prepareBatchView(ops []database.BatchOp) (*trieView, error)
 	// Don't need to lock [view] because nobody else has a reference to it.
 
 	// write into the trie
-	for _, op := range ops {
-		if op.Delete {
+	for i, op := range ops {
+		switch {
+		case op.DeleteRange:
+			keys, err := db.getKeysInRange(ops[:i], op.Key, op.Limit)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				if err := view.remove(key); err != nil {
+					return nil, err
+				}
+			}
+		case op.Delete:
 			if err := view.remove(op.Key); err != nil {
 				return nil, err
 			}
-		} else if err := view.insert(op.Key, op.Value); err != nil {
-			return nil, err
+		default:
+			if err := view.insert(op.Key, op.Value); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return view, nil
 }
 
+// Returns the keys in the range [start, limit) that are either in [db] or are
+// inserted by [prevOps].
+// Assumes [db.commitLock] is held.
+func (db *Database) getKeysInRange(prevOps []database.BatchOp, start, limit []byte) ([][]byte, error) {
+	var keys [][]byte
+	it := db.NewIteratorWithStart(start)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if !database.InRange(key, start, limit) {
+			break
+		}
+		keys = append(keys, key)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	for _, op := range prevOps {
+		if !op.Delete && !op.DeleteRange && database.InRange(op.Key, start, limit) {
+			keys = append(keys, op.Key)
+		}
+	}
+	return keys, nil
+}
+
 // Returns a new view atop [db] with the key/value pairs in [proof.KeyValues]
 // inserted and the key/value pairs in [proof.DeletedKeys] removed.
 // Assumes [db.commitLock] is locked.
@@ -6,6 +6,7 @@ package merkledb
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"math/rand"
 	"strconv"
 	"testing"
@@ -14,9 +15,11 @@ import (
 
 	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/trace"
 	"github.com/memeticofficial/pepecoingo/utils/hashing"
+	"github.com/memeticofficial/pepecoingo/utils/set"
 )
 
 const minCacheSize = 1000
@@ -33,7 +36,7 @@ func Test_MerkleDB_Get_Safety(t *testing.T) {
 
 	val, err := db.Get([]byte{0})
 	require.NoError(t, err)
-	n, err := db.getNode(newPath([]byte{0}))
+	n, err := db.getNode(newPath([]byte{0}, BranchFactor16))
 	require.NoError(t, err)
 	val[0] = 1
 
@@ -162,6 +165,172 @@ func Test_MerkleDB_DB_Rebuild(t *testing.T) {
 	require.Equal(root, rebuiltRoot)
 }
 
+func Test_MerkleDB_Small_Cache_Reopen(t *testing.T) {
+	require := require.New(t)
+
+	rdb := memdb.New()
+	config := Config{
+		Tracer:        newNoopTracer(),
+		HistoryLength: 100,
+		// Nodes are evicted while the changes of a commit are cached.
+		NodeCacheSize: 10,
+	}
+	db, err := New(context.Background(), rdb, config)
+	require.NoError(err)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	values := map[string][]byte{}
+	for i := 0; i < 20; i++ {
+		batch := db.NewBatch()
+		for j := 0; j < 25; j++ {
+			key := make([]byte, r.Intn(3)+1)
+			_, _ = r.Read(key)
+			value := make([]byte, r.Intn(40)+1)
+			_, _ = r.Read(value)
+			require.NoError(batch.Put(key, value))
+			values[string(key)] = value
+		}
+		require.NoError(batch.Write())
+	}
+	require.NoError(db.Close())
+
+	db, err = New(context.Background(), rdb, config)
+	require.NoError(err)
+	for key, value := range values {
+		gotValue, err := db.Get([]byte(key))
+		require.NoError(err)
+		require.Equal(value, gotValue)
+	}
+}
+
+func Test_MerkleDB_TrieConfig_Reopen(t *testing.T) {
+	require := require.New(t)
+
+	roots := set.Set[ids.ID]{}
+	for _, branchFactor := range branchFactors {
+		for _, hasher := range hashers {
+			rdb := memdb.New()
+			config := Config{
+				Tracer:        newNoopTracer(),
+				HistoryLength: 100,
+				NodeCacheSize: 100,
+				BranchFactor:  branchFactor,
+				Hasher:        hasher,
+			}
+			db, err := New(context.Background(), rdb, config)
+			require.NoError(err)
+
+			r := rand.New(rand.NewSource(0)) // #nosec G404
+			values := map[string][]byte{}
+			for i := 0; i < 10; i++ {
+				require.NoError(writeRandomBatch(r, db, values))
+			}
+			root, err := db.GetMerkleRoot(context.Background())
+			require.NoError(err)
+			roots.Add(root)
+
+			require.NoError(db.rebuild(context.Background()))
+			rebuiltRoot, err := db.GetMerkleRoot(context.Background())
+			require.NoError(err)
+			require.Equal(root, rebuiltRoot)
+			require.NoError(db.Close())
+
+			// Opening the database with a different config fails.
+			otherConfig := config
+			otherConfig.BranchFactor = BranchFactor2
+			if branchFactor == BranchFactor2 {
+				otherConfig.BranchFactor = BranchFactor4
+			}
+			_, err = New(context.Background(), rdb, otherConfig)
+			require.ErrorIs(err, ErrConfigMismatch)
+
+			otherConfig = config
+			otherConfig.Hasher = BLAKE2bHasher
+			if hasher == BLAKE2bHasher {
+				otherConfig.Hasher = SHA256Hasher
+			}
+			_, err = New(context.Background(), rdb, otherConfig)
+			require.ErrorIs(err, ErrConfigMismatch)
+
+			db, err = New(context.Background(), rdb, config)
+			require.NoError(err)
+			reopenedRoot, err := db.GetMerkleRoot(context.Background())
+			require.NoError(err)
+			require.Equal(root, reopenedRoot)
+			for key, value := range values {
+				gotValue, err := db.Get([]byte(key))
+				require.NoError(err)
+				require.Equal(value, gotValue)
+			}
+		}
+	}
+
+	// Every config results in a different root.
+	require.Len(roots, len(branchFactors)*len(hashers))
+}
+
+func Test_MerkleDB_TrieConfig_NotPersisted(t *testing.T) {
+	require := require.New(t)
+
+	rdb := memdb.New()
+	config := Config{
+		Tracer:        newNoopTracer(),
+		HistoryLength: 100,
+		NodeCacheSize: 100,
+	}
+	db, err := New(context.Background(), rdb, config)
+	require.NoError(err)
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	require.NoError(db.Close())
+
+	// Databases created before the config was persisted use the defaults.
+	require.NoError(prefixdb.New(metadataPrefix, rdb).Delete(trieConfigKey))
+
+	otherConfig := config
+	otherConfig.BranchFactor = BranchFactor4
+	_, err = New(context.Background(), rdb, otherConfig)
+	require.ErrorIs(err, ErrConfigMismatch)
+
+	otherConfig = config
+	otherConfig.Hasher = BLAKE2bHasher
+	_, err = New(context.Background(), rdb, otherConfig)
+	require.ErrorIs(err, ErrConfigMismatch)
+
+	db, err = New(context.Background(), rdb, config)
+	require.NoError(err)
+	value, err := db.Get([]byte{1})
+	require.NoError(err)
+	require.Equal([]byte{1}, value)
+
+	has, err := prefixdb.New(metadataPrefix, rdb).Has(trieConfigKey)
+	require.NoError(err)
+	require.True(has)
+}
+
+func Test_MerkleDB_Invalid_TrieConfig(t *testing.T) {
+	require := require.New(t)
+
+	_, err := New(
+		context.Background(),
+		memdb.New(),
+		Config{
+			Tracer:       newNoopTracer(),
+			BranchFactor: 3,
+		},
+	)
+	require.ErrorIs(err, ErrInvalidBranchFactor)
+
+	_, err = New(
+		context.Background(),
+		memdb.New(),
+		Config{
+			Tracer: newNoopTracer(),
+			Hasher: BLAKE2bHasher + 1,
+		},
+	)
+	require.ErrorIs(err, ErrInvalidHasher)
+}
+
 func Test_MerkleDB_Failed_Batch_Commit(t *testing.T) {
 	memDB := memdb.New()
 	db, err := New(
@@ -728,6 +897,21 @@ func Test_MerkleDB_RandomCases(t *testing.T) {
 	}
 }
 
+func Test_MerkleDB_RandomCases_TrieConfigs(t *testing.T) {
+	for _, branchFactor := range branchFactors {
+		for _, hasher := range hashers {
+			t.Run(fmt.Sprintf("%d_%s", branchFactor, hasher), func(t *testing.T) {
+				require := require.New(t)
+
+				for i := 150; i < 300; i += 50 {
+					r := rand.New(rand.NewSource(int64(i))) // #nosec G404
+					runRandDBTestWithTrieConfig(require, r, generate(require, r, i, .01), branchFactor, hasher)
+				}
+			})
+		}
+	}
+}
+
 func Test_MerkleDB_RandomCases_InitialValues(t *testing.T) {
 	require := require.New(t)
 
@@ -757,7 +941,17 @@ const (
 )
 
 func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
-	db, err := getBasicDB()
+	runRandDBTestWithTrieConfig(require, r, rt, DefaultBranchFactor, SHA256Hasher)
+}
+
+func runRandDBTestWithTrieConfig(
+	require *require.Assertions,
+	r *rand.Rand,
+	rt randTest,
+	branchFactor BranchFactor,
+	hasher Hasher,
+) {
+	db, err := getBasicDBWithTrieConfig(branchFactor, hasher)
 	require.NoError(err)
 
 	startRoot, err := db.GetMerkleRoot(context.Background())
@@ -775,13 +969,13 @@ func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
 		case opUpdate:
 			err := currentBatch.Put(step.key, step.value)
 			require.NoError(err)
-			currentValues[newPath(step.key)] = step.value
-			delete(deleteValues, newPath(step.key))
+			currentValues[newPath(step.key, branchFactor)] = step.value
+			delete(deleteValues, newPath(step.key, branchFactor))
 		case opDelete:
 			err := currentBatch.Delete(step.key)
 			require.NoError(err)
-			deleteValues[newPath(step.key)] = struct{}{}
-			delete(currentValues, newPath(step.key))
+			deleteValues[newPath(step.key, branchFactor)] = struct{}{}
+			delete(currentValues, newPath(step.key, branchFactor))
 		case opGenerateRangeProof:
 			root, err := db.GetMerkleRoot(context.Background())
 			require.NoError(err)
@@ -795,6 +989,8 @@ func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
 				step.key,
 				step.value,
 				root,
+				branchFactor,
+				hasher,
 			)
 			require.NoError(err)
 			require.LessOrEqual(len(rangeProof.KeyValues), 100)
@@ -810,7 +1006,7 @@ func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
 				continue
 			}
 			require.NoError(err)
-			changeProofDB, err := getBasicDB()
+			changeProofDB, err := getBasicDBWithTrieConfig(branchFactor, hasher)
 			require.NoError(err)
 			err = changeProof.Verify(
 				context.Background(),
@@ -852,7 +1048,7 @@ func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
 			if err != nil {
 				require.ErrorIs(err, database.ErrNotFound)
 			}
-			want := values[newPath(step.key)]
+			want := values[newPath(step.key, branchFactor)]
 			require.True(bytes.Equal(want, v)) // Use bytes.Equal so nil treated equal to []byte{}
 			trieValue, err := getNodeValue(db, string(step.key))
 			if err != nil {
@@ -867,13 +1063,15 @@ func runRandDBTest(require *require.Assertions, r *rand.Rand, rt randTest) {
 					Tracer:        newNoopTracer(),
 					HistoryLength: 0,
 					NodeCacheSize: minCacheSize,
+					BranchFactor:  branchFactor,
+					Hasher:        hasher,
 				},
 				&mockMetrics{},
 			)
 			require.NoError(err)
 			localTrie := Trie(dbTrie)
 			for key, value := range values {
-				err := localTrie.Insert(context.Background(), key.Serialize().Value, value)
+				err := localTrie.Insert(context.Background(), key.Serialize(branchFactor).Value, value)
 				require.NoError(err)
 			}
 			calculatedRoot, err := localTrie.GetMerkleRoot(context.Background())
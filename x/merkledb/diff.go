@@ -0,0 +1,199 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"bytes"
+	"context"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+// The maximum number of changed keys read from the history at a time by a
+// DiffIterator.
+const diffPageSize = 1024
+
+var _ DiffIterator = (*diffIterator)(nil)
+
+type DiffKind byte
+
+const (
+	KeyAdded DiffKind = iota
+	KeyRemoved
+	KeyModified
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case KeyAdded:
+		return "added"
+	case KeyRemoved:
+		return "removed"
+	case KeyModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyDiff is the change to a key between two roots.
+type KeyDiff struct {
+	Key []byte
+	// The value of [Key] at the start root. Nothing if [Key] wasn't present.
+	Before Maybe[[]byte]
+	// The value of [Key] at the end root. Nothing if [Key] isn't present.
+	After Maybe[[]byte]
+}
+
+func (d KeyDiff) Kind() DiffKind {
+	switch {
+	case d.Before.IsNothing():
+		return KeyAdded
+	case d.After.IsNothing():
+		return KeyRemoved
+	default:
+		return KeyModified
+	}
+}
+
+// DiffIterator iterates over the keys that changed between two roots, in
+// increasing key order.
+//
+// The changes are read from the history in pages. If a root is evicted from
+// the history before the iteration finishes, Next returns false and Error
+// returns ErrRootIDNotPresent or ErrStartRootNotFound.
+type DiffIterator interface {
+	// Next moves the iterator to the next changed key and returns true, or
+	// returns false if there are no more changes or an error occurred.
+	Next() bool
+
+	// Error returns the error that stopped the iteration, if any.
+	Error() error
+
+	// Diff returns the change at the current position of the iterator.
+	// The returned value must not be modified.
+	Diff() KeyDiff
+
+	// Release releases the resources held by the iterator.
+	Release()
+}
+
+type diffIterator struct {
+	ctx       context.Context
+	db        *Database
+	startRoot ids.ID
+	endRoot   ids.ID
+	// The start of the next page to read from the history.
+	nextStart []byte
+	end       []byte
+	// False once every page has been read.
+	hasMore bool
+
+	page    []KeyDiff
+	current KeyDiff
+	err     error
+}
+
+// Diff returns an iterator over the keys in [start, end] that were added,
+// removed or modified between [startRoot] and [endRoot]. Keys that changed but
+// have the same value at both roots aren't included. A nil [start] or [end]
+// means the range is unbounded in that direction.
+//
+// Both roots must be in the history, and [startRoot] must come before
+// [endRoot] in the history.
+func (db *Database) Diff(ctx context.Context, startRoot, endRoot ids.ID, start, end []byte) (DiffIterator, error) {
+	if len(end) > 0 && bytes.Compare(start, end) == 1 {
+		return nil, ErrStartAfterEnd
+	}
+	return &diffIterator{
+		ctx:       ctx,
+		db:        db,
+		startRoot: startRoot,
+		endRoot:   endRoot,
+		nextStart: slices.Clone(start),
+		end:       slices.Clone(end),
+		hasMore:   true,
+	}, nil
+}
+
+func (it *diffIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	// A page may be empty if every change in it was skipped.
+	for len(it.page) == 0 && it.hasMore && it.err == nil {
+		it.err = it.readPage()
+	}
+	if it.err != nil || len(it.page) == 0 {
+		it.current = KeyDiff{}
+		return false
+	}
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *diffIterator) readPage() error {
+	if err := it.ctx.Err(); err != nil {
+		return err
+	}
+
+	it.db.commitLock.RLock()
+	defer it.db.commitLock.RUnlock()
+
+	if it.db.closed {
+		return database.ErrClosed
+	}
+
+	changes, err := it.db.getValueChanges(it.startRoot, it.endRoot, it.nextStart, it.end, diffPageSize)
+	if err != nil {
+		return err
+	}
+
+	changedKeys := maps.Keys(changes.values)
+	slices.SortFunc(changedKeys, func(i, j path) bool {
+		return i.Compare(j) < 0
+	})
+
+	it.page = make([]KeyDiff, 0, len(changedKeys))
+	for _, key := range changedKeys {
+		change := changes.values[key]
+		if change.before.IsNothing() && change.after.IsNothing() {
+			// A key that was deleted without being present isn't a change.
+			continue
+		}
+		it.page = append(it.page, KeyDiff{
+			Key: key.Serialize(it.db.branchFactor).Value,
+			// create copies so edits of the []byte don't affect the db
+			Before: Clone(change.before),
+			After:  Clone(change.after),
+		})
+	}
+
+	// Fewer than [diffPageSize] changes means that every remaining change has
+	// been read.
+	it.hasMore = len(changedKeys) == diffPageSize
+	if it.hasMore {
+		it.nextStart = nextKey(changedKeys[len(changedKeys)-1].Serialize(it.db.branchFactor).Value)
+	}
+	return nil
+}
+
+func (it *diffIterator) Error() error {
+	return it.err
+}
+
+func (it *diffIterator) Diff() KeyDiff {
+	return it.current
+}
+
+func (it *diffIterator) Release() {
+	it.page = nil
+	it.current = KeyDiff{}
+	it.hasMore = false
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+// Returns the diffs between [before] and [after] for keys in [start, end],
+// sorted by key.
+func expectedDiffs(before, after map[string][]byte, start, end []byte) []KeyDiff {
+	keys := maps.Keys(before)
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var diffs []KeyDiff
+	for _, key := range keys {
+		if key < string(start) || (len(end) > 0 && key > string(end)) {
+			continue
+		}
+		beforeValue, inBefore := before[key]
+		afterValue, inAfter := after[key]
+		if inBefore == inAfter && bytes.Equal(beforeValue, afterValue) {
+			continue
+		}
+		diff := KeyDiff{
+			Key:    []byte(key),
+			Before: Nothing[[]byte](),
+			After:  Nothing[[]byte](),
+		}
+		if inBefore {
+			diff.Before = Some(beforeValue)
+		}
+		if inAfter {
+			diff.After = Some(afterValue)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func collectDiffs(t *testing.T, it DiffIterator) []KeyDiff {
+	defer it.Release()
+
+	var diffs []KeyDiff
+	for it.Next() {
+		diffs = append(diffs, it.Diff())
+	}
+	require.NoError(t, it.Error())
+	return diffs
+}
+
+func TestDiff(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	values := map[string][]byte{}
+	require.NoError(writeRandomBatch(r, db, values))
+	startRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	startValues := maps.Clone(values)
+
+	// Change enough keys that the diff is read in multiple pages.
+	for i := 0; i < 100; i++ {
+		require.NoError(writeRandomBatch(r, db, values))
+	}
+	endRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	tests := []struct {
+		name  string
+		start []byte
+		end   []byte
+	}{
+		{
+			name: "unbounded",
+		},
+		{
+			name:  "start",
+			start: []byte{0x80},
+		},
+		{
+			name: "end",
+			end:  []byte{0x40},
+		},
+		{
+			name:  "start and end",
+			start: []byte{0x40},
+			end:   []byte{0x80},
+		},
+	}
+	for _, tt := range tests {
+		it, err := db.Diff(context.Background(), startRoot, endRoot, tt.start, tt.end)
+		require.NoError(err)
+		diffs := collectDiffs(t, it)
+		require.Equal(expectedDiffs(startValues, values, tt.start, tt.end), diffs, tt.name)
+	}
+
+	it, err := db.Diff(context.Background(), startRoot, endRoot, nil, nil)
+	require.NoError(err)
+	require.Greater(len(collectDiffs(t, it)), diffPageSize)
+}
+
+func TestDiffKind(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	require.NoError(db.Put([]byte{2}, []byte{2}))
+	startRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	batch := db.NewBatch()
+	require.NoError(batch.Delete([]byte{1}))
+	require.NoError(batch.Put([]byte{2}, []byte{3}))
+	require.NoError(batch.Put([]byte{3}, []byte{3}))
+	// Changed and then restored, so it isn't in the diff.
+	require.NoError(batch.Put([]byte{4}, []byte{4}))
+	require.NoError(batch.Write())
+	require.NoError(db.Delete([]byte{4}))
+	endRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	it, err := db.Diff(context.Background(), startRoot, endRoot, nil, nil)
+	require.NoError(err)
+	diffs := collectDiffs(t, it)
+	require.Len(diffs, 3)
+	require.Equal(KeyRemoved, diffs[0].Kind())
+	require.Equal(KeyModified, diffs[1].Kind())
+	require.Equal(KeyAdded, diffs[2].Kind())
+}
+
+func TestDiffSameRoot(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	it, err := db.Diff(context.Background(), root, root, nil, nil)
+	require.NoError(err)
+	require.Empty(collectDiffs(t, it))
+}
+
+func TestDiffErrors(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	_, err = db.Diff(context.Background(), root, root, []byte{1}, []byte{0})
+	require.ErrorIs(err, ErrStartAfterEnd)
+
+	it, err := db.Diff(context.Background(), ids.GenerateTestID(), root, nil, nil)
+	require.NoError(err)
+	require.False(it.Next())
+	require.ErrorIs(it.Error(), ErrStartRootNotFound)
+
+	it, err = db.Diff(context.Background(), root, ids.GenerateTestID(), nil, nil)
+	require.NoError(err)
+	require.False(it.Next())
+	require.ErrorIs(it.Error(), ErrRootIDNotPresent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	it, err = db.Diff(ctx, root, root, nil, nil)
+	require.NoError(err)
+	require.False(it.Next())
+	require.ErrorIs(it.Error(), context.Canceled)
+}
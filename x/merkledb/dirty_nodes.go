@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+)
+
+// dirtyNodes tracks the intermediate nodes that may be stale on disk.
+//
+// Intermediate nodes are only written to disk when they are evicted from the
+// node cache or when the database is closed, so after an unclean shutdown the
+// intermediate nodes on disk may not match the key/value pairs on disk.
+// To avoid regenerating every intermediate node after an unclean shutdown, a
+// marker is written for each intermediate node that is changed by a commit but
+// isn't written to disk by it. The markers are written in the same batch as
+// the commit, so they are always on disk before the changes they describe.
+// Once a marked node has been written to disk by an eviction, and that write
+// has been committed, its marker is removed.
+//
+// After an unclean shutdown, only the marked nodes need to be regenerated.
+type dirtyNodes struct {
+	db database.Database
+
+	// The paths of the nodes that are marked on disk.
+	// Only modified in commitChanges, so reads must hold [Database.lock].
+	marked set.Set[path]
+
+	// The marked paths whose nodes have been written to [Database.nodeDB] by
+	// an eviction since the last commit.
+	// Only modified while [Database.lock] is held, either by commitChanges or
+	// by [Database.onEviction], which is called with [Database.nodeCache]'s
+	// lock held.
+	evicted set.Set[path]
+}
+
+// dirtyNodesUpdate describes the markers written by a commit.
+type dirtyNodesUpdate struct {
+	marked   []path
+	unmarked []path
+}
+
+func newDirtyNodes(db database.Database) *dirtyNodes {
+	return &dirtyNodes{
+		db:      db,
+		marked:  set.Set[path]{},
+		evicted: set.Set[path]{},
+	}
+}
+
+// paths returns the paths of the nodes that are marked on disk.
+func (d *dirtyNodes) paths() ([]path, error) {
+	it := d.db.NewIterator()
+	defer it.Release()
+
+	var paths []path
+	for it.Next() {
+		paths = append(paths, path(it.Key()))
+	}
+	return paths, it.Error()
+}
+
+// clear removes every marker.
+func (d *dirtyNodes) clear() error {
+	if err := d.db.DeleteRange(nil, nil); err != nil {
+		return err
+	}
+	d.marked.Clear()
+	d.evicted.Clear()
+	return nil
+}
+
+// onEviction records that the node at [key] was written to
+// [Database.nodeDB].
+func (d *dirtyNodes) onEviction(key path) {
+	if d.marked.Contains(key) {
+		d.evicted.Add(key)
+	}
+}
+
+// prepareCommit returns a batch that marks the intermediate nodes in
+// [changes] that won't be written to disk by the commit. The batch also
+// unmarks the nodes that will be written by the commit and the nodes that
+// have been written by evictions since the last commit.
+// The returned update must only be applied once the batch has been written.
+func (d *dirtyNodes) prepareCommit(changes *changeSummary) (database.Batch, dirtyNodesUpdate, error) {
+	var (
+		batch  = d.db.NewBatch()
+		update dirtyNodesUpdate
+	)
+	for key := range d.evicted {
+		if _, ok := changes.nodes[key]; ok {
+			// Handled below.
+			continue
+		}
+		if err := batch.Delete(key.Bytes()); err != nil {
+			return nil, dirtyNodesUpdate{}, err
+		}
+		update.unmarked = append(update.unmarked, key)
+	}
+
+	for key, nodeChange := range changes.nodes {
+		isMarked := d.marked.Contains(key)
+		switch {
+		case isWrittenOnCommit(nodeChange):
+			if !isMarked {
+				continue
+			}
+			if err := batch.Delete(key.Bytes()); err != nil {
+				return nil, dirtyNodesUpdate{}, err
+			}
+			update.unmarked = append(update.unmarked, key)
+		case !isMarked:
+			if err := batch.Put(key.Bytes(), nil); err != nil {
+				return nil, dirtyNodesUpdate{}, err
+			}
+			update.marked = append(update.marked, key)
+		}
+	}
+	return batch, update, nil
+}
+
+// apply updates the in-memory state once the batch returned by
+// prepareCommit has been written.
+func (d *dirtyNodes) apply(update dirtyNodesUpdate) {
+	d.marked.Add(update.marked...)
+	d.marked.Remove(update.unmarked...)
+	d.evicted.Clear()
+}
+
+// isWrittenOnCommit returns true iff [nodeChange] is written to disk when it
+// is committed, rather than when the node is evicted from the node cache.
+func isWrittenOnCommit(nodeChange *change[*node]) bool {
+	return nodeChange.after == nil ||
+		nodeChange.after.hasValue() ||
+		(nodeChange.before != nil && nodeChange.before.hasValue())
+}
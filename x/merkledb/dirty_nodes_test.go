@@ -0,0 +1,392 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/corruptabledb"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+var (
+	_ database.Database = (*crashingDB)(nil)
+	_ database.Batch    = (*crashingBatch)(nil)
+
+	errCrashed = errors.New("crashed")
+)
+
+// crashingDB simulates a crash by failing every write after a number of
+// writes. Wrapped in a corruptabledb, no reads or writes succeed after the
+// crash, as if the process had stopped.
+type crashingDB struct {
+	database.Database
+
+	lock sync.Mutex
+	// The number of writes before the crash. Negative means unlimited.
+	writesLeft int
+}
+
+func newCrashingDB(db database.Database) *crashingDB {
+	return &crashingDB{
+		Database:   db,
+		writesLeft: -1,
+	}
+}
+
+// crashAfter causes every write after the next [writes] writes to fail.
+func (db *crashingDB) crashAfter(writes int) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.writesLeft = writes
+}
+
+func (db *crashingDB) write() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	switch {
+	case db.writesLeft == 0:
+		return errCrashed
+	case db.writesLeft > 0:
+		db.writesLeft--
+	}
+	return nil
+}
+
+func (db *crashingDB) Put(key, value []byte) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.Database.Put(key, value)
+}
+
+func (db *crashingDB) Delete(key []byte) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.Database.Delete(key)
+}
+
+func (db *crashingDB) DeleteRange(start, limit []byte) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.Database.DeleteRange(start, limit)
+}
+
+func (db *crashingDB) NewBatch() database.Batch {
+	return &crashingBatch{
+		Batch: db.Database.NewBatch(),
+		db:    db,
+	}
+}
+
+type crashingBatch struct {
+	database.Batch
+	db *crashingDB
+}
+
+func (b *crashingBatch) Write() error {
+	if err := b.db.write(); err != nil {
+		return err
+	}
+	return b.Batch.Write()
+}
+
+// Inner returns [b] so that writes of the inner batch can still crash.
+func (b *crashingBatch) Inner() database.Batch {
+	return b
+}
+
+func newCrashTestDB(db database.Database, metrics merkleMetrics) (*Database, error) {
+	return newDatabase(
+		context.Background(),
+		db,
+		Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 100,
+			// A small cache causes intermediate nodes to be written to disk
+			// by evictions.
+			NodeCacheSize: 10,
+		},
+		metrics,
+	)
+}
+
+// Writes a random batch of changes to [db] and, if it succeeds, applies them
+// to [values].
+func writeRandomBatch(r *rand.Rand, db *Database, values map[string][]byte) error {
+	var (
+		batch   = db.NewBatch()
+		changes = map[string][]byte{}
+	)
+	for i := 0; i < 25; i++ {
+		key := make([]byte, r.Intn(3)+1)
+		_, _ = r.Read(key)
+		if r.Intn(4) == 0 {
+			if err := batch.Delete(key); err != nil {
+				return err
+			}
+			changes[string(key)] = nil
+			continue
+		}
+
+		value := make([]byte, r.Intn(40)+1)
+		_, _ = r.Read(value)
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		changes[string(key)] = value
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	for key, value := range changes {
+		if value == nil {
+			delete(values, key)
+		} else {
+			values[key] = value
+		}
+	}
+	return nil
+}
+
+// Returns the contents of the node prefix of [db].
+func nodesOnDisk(t *testing.T, db database.Database) map[string][]byte {
+	require := require.New(t)
+
+	it := prefixdb.New(nodePrefix, db).NewIterator()
+	defer it.Release()
+
+	nodes := map[string][]byte{}
+	for it.Next() {
+		nodes[string(it.Key())] = it.Value()
+	}
+	require.NoError(it.Error())
+	return nodes
+}
+
+// Returns the contents of the node prefix of a cleanly closed database that
+// contains [values].
+func expectedNodesOnDisk(t *testing.T, values map[string][]byte) map[string][]byte {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newCrashTestDB(baseDB, &mockMetrics{})
+	require.NoError(err)
+
+	batch := db.NewBatch()
+	for key, value := range values {
+		require.NoError(batch.Put([]byte(key), value))
+	}
+	require.NoError(batch.Write())
+	require.NoError(db.Close())
+	return nodesOnDisk(t, baseDB)
+}
+
+// Returns the number of intermediate nodes in [nodes].
+func numIntermediateNodes(t *testing.T, nodes map[string][]byte) int {
+	require := require.New(t)
+
+	numNodes := 0
+	for key, nodeBytes := range nodes {
+		n, err := parseNode(Codec, SHA256Hasher, path(key), nodeBytes)
+		require.NoError(err)
+		if !n.hasValue() {
+			numNodes++
+		}
+	}
+	return numNodes
+}
+
+// Writes random batches to [db] until one fails.
+func writeUntilCrash(t *testing.T, r *rand.Rand, db *Database, values map[string][]byte) {
+	for {
+		if err := writeRandomBatch(r, db, values); err != nil {
+			require.ErrorIs(t, err, errCrashed)
+			return
+		}
+	}
+}
+
+func TestRebuildDirtyNodesAfterCrash(t *testing.T) {
+	require := require.New(t)
+
+	for crashAfter := 0; crashAfter < 25; crashAfter++ {
+		r := rand.New(rand.NewSource(int64(crashAfter))) // #nosec G404
+
+		baseDB := memdb.New()
+		crashDB := newCrashingDB(baseDB)
+		db, err := newCrashTestDB(corruptabledb.New(crashDB), &mockMetrics{})
+		require.NoError(err)
+
+		values := map[string][]byte{}
+		for i := 0; i < 10; i++ {
+			require.NoError(writeRandomBatch(r, db, values))
+		}
+
+		crashDB.crashAfter(crashAfter)
+		writeUntilCrash(t, r, db, values)
+
+		// Reopen the database without closing it.
+		metrics := &mockMetrics{}
+		db, err = newCrashTestDB(baseDB, metrics)
+		require.NoError(err)
+
+		// Only the dirty nodes were regenerated.
+		expectedNodes := expectedNodesOnDisk(t, values)
+		require.Positive(metrics.rebuiltNodes)
+		require.Less(metrics.rebuiltNodes, int64(numIntermediateNodes(t, expectedNodes)))
+
+		for key, value := range values {
+			gotValue, err := db.Get([]byte(key))
+			require.NoError(err)
+			require.Equal(value, gotValue)
+		}
+
+		// Every node written to disk matches the trie containing [values].
+		require.NoError(db.Close())
+		require.Equal(expectedNodes, nodesOnDisk(t, baseDB))
+	}
+}
+
+func TestRebuildDirtyNodesAfterRepeatedCrashes(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+
+	baseDB := memdb.New()
+	values := map[string][]byte{}
+	for i := 0; i < 10; i++ {
+		crashDB := newCrashingDB(baseDB)
+		db, err := newCrashTestDB(corruptabledb.New(crashDB), &mockMetrics{})
+		require.NoError(err)
+
+		root, err := db.GetMerkleRoot(context.Background())
+		require.NoError(err)
+		expectedRoot := newCrashTestRoot(t, values)
+		require.Equal(expectedRoot, root)
+
+		for j := 0; j < 5; j++ {
+			require.NoError(writeRandomBatch(r, db, values))
+		}
+		crashDB.crashAfter(r.Intn(10))
+		writeUntilCrash(t, r, db, values)
+	}
+
+	db, err := newCrashTestDB(baseDB, &mockMetrics{})
+	require.NoError(err)
+	require.NoError(db.Close())
+	require.Equal(expectedNodesOnDisk(t, values), nodesOnDisk(t, baseDB))
+}
+
+func TestRebuildWithoutTrackedDirtyNodes(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+
+	baseDB := memdb.New()
+	db, err := newCrashTestDB(baseDB, &mockMetrics{})
+	require.NoError(err)
+
+	values := map[string][]byte{}
+	for i := 0; i < 10; i++ {
+		require.NoError(writeRandomBatch(r, db, values))
+	}
+
+	// Simulate an unclean shutdown of a version that didn't track the dirty
+	// nodes.
+	require.NoError(db.metadataDB.Delete(trackingDirtyNodesKey))
+
+	metrics := &mockMetrics{}
+	db, err = newCrashTestDB(baseDB, metrics)
+	require.NoError(err)
+
+	// Every intermediate node on disk was regenerated.
+	require.Positive(metrics.rebuiltNodes)
+
+	require.NoError(db.Close())
+	require.Equal(expectedNodesOnDisk(t, values), nodesOnDisk(t, baseDB))
+}
+
+func TestDirtyNodesNotTrackedAfterClose(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newCrashTestDB(baseDB, &mockMetrics{})
+	require.NoError(err)
+
+	has, err := db.metadataDB.Has(trackingDirtyNodesKey)
+	require.NoError(err)
+	require.True(has)
+
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	require.NoError(db.Close())
+
+	has, err = prefixdb.New(metadataPrefix, baseDB).Has(trackingDirtyNodesKey)
+	require.NoError(err)
+	require.False(has)
+
+	// Reopening after a clean shutdown removes any markers.
+	metrics := &mockMetrics{}
+	db, err = newCrashTestDB(baseDB, metrics)
+	require.NoError(err)
+	require.Zero(metrics.rebuiltNodes)
+
+	paths, err := db.dirtyNodes.paths()
+	require.NoError(err)
+	require.Empty(paths)
+}
+
+// Returns the root of a trie containing [values].
+func newCrashTestRoot(t *testing.T, values map[string][]byte) ids.ID {
+	require := require.New(t)
+
+	db, err := newCrashTestDB(memdb.New(), &mockMetrics{})
+	require.NoError(err)
+
+	batch := db.NewBatch()
+	for key, value := range values {
+		require.NoError(batch.Put([]byte(key), value))
+	}
+	require.NoError(batch.Write())
+
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	return root
+}
+
+func TestDirtyNodesUnmarkedAfterEviction(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+
+	db, err := newCrashTestDB(memdb.New(), &mockMetrics{})
+	require.NoError(err)
+
+	values := map[string][]byte{}
+	for i := 0; i < 20; i++ {
+		require.NoError(writeRandomBatch(r, db, values))
+
+		// Only nodes that may be stale on disk are marked, so the number of
+		// marked nodes is bounded by the size of the node cache and the
+		// number of nodes evicted since the last commit.
+		paths, err := db.dirtyNodes.paths()
+		require.NoError(err)
+		require.Equal(db.dirtyNodes.marked.Len(), len(paths))
+		require.LessOrEqual(len(paths), db.nodeCache.maxSize+db.dirtyNodes.evicted.Len())
+	}
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+const (
+	changeKeyPrefix byte = iota
+	rootKeyPrefix
+	historySizeKeyPrefix
+)
+
+var (
+	historySizeKey = []byte{historySizeKeyPrefix}
+
+	errMissingHistoryEntry = errors.New("missing history entry")
+	errInvalidHistoryKey   = errors.New("invalid history key")
+	errInvalidHistorySize  = errors.New("invalid history size")
+)
+
+// diskHistory persists change summaries so that change proofs can be served
+// for roots that are no longer in the in-memory history, including roots from
+// before the database was last opened.
+//
+// Every change summary is stored under its index and the index is also stored
+// under the resulting root ID so the latest change resulting in a root can be
+// found without decoding the change summaries.
+//
+// The fields are only modified in commitChanges, so reads must hold
+// [Database.commitLock] and writes must hold [Database.commitLock] and
+// [Database.lock].
+type diskHistory struct {
+	db database.Database
+
+	// Encodes and decodes the stored change summaries.
+	codec        EncoderDecoder
+	branchFactor BranchFactor
+	hasher       Hasher
+
+	// Maximum number of change summaries to store. 0 means unbounded.
+	maxLength int
+	// Maximum total size, in bytes, of the stored change summaries.
+	// 0 means unbounded.
+	maxSize int
+
+	state diskHistoryState
+}
+
+// diskHistoryState describes which change summaries are on disk.
+type diskHistoryState struct {
+	// Index of the oldest stored change summary.
+	oldestIndex uint64
+	// Index that the next change summary will be stored under.
+	nextIndex uint64
+	// Total size, in bytes, of the stored change summaries.
+	size uint64
+}
+
+func (s *diskHistoryState) length() uint64 {
+	return s.nextIndex - s.oldestIndex
+}
+
+func newDiskHistory(
+	db database.Database,
+	branchFactor BranchFactor,
+	hasher Hasher,
+	maxLength int,
+	maxSize int,
+) (*diskHistory, error) {
+	h := &diskHistory{
+		db:           db,
+		codec:        newCodec(branchFactor),
+		branchFactor: branchFactor,
+		hasher:       hasher,
+		maxLength:    maxLength,
+		maxSize:      maxSize,
+	}
+
+	oldestIndex, ok, err := h.firstIndex(false)
+	if err != nil || !ok {
+		return h, err
+	}
+	latestIndex, _, err := h.firstIndex(true)
+	if err != nil {
+		return nil, err
+	}
+	sizeBytes, err := db.Get(historySizeKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sizeBytes) != wrappers.LongLen {
+		return nil, fmt.Errorf("%w: expected %d bytes but got %d", errInvalidHistorySize, wrappers.LongLen, len(sizeBytes))
+	}
+	h.state = diskHistoryState{
+		oldestIndex: oldestIndex,
+		nextIndex:   latestIndex + 1,
+		size:        binary.BigEndian.Uint64(sizeBytes),
+	}
+	return h, nil
+}
+
+// firstIndex returns the smallest, or largest if [reverse], index of a stored
+// change summary. Returns false if there are no stored change summaries.
+func (h *diskHistory) firstIndex(reverse bool) (uint64, bool, error) {
+	prefix := []byte{changeKeyPrefix}
+	var it database.Iterator
+	if reverse {
+		it = h.db.NewReverseIteratorWithPrefix(prefix)
+	} else {
+		it = h.db.NewIteratorWithPrefix(prefix)
+	}
+	defer it.Release()
+
+	if !it.Next() {
+		return 0, false, it.Error()
+	}
+	index, err := parseHistoryChangeKey(it.Key())
+	return index, true, err
+}
+
+// latestRoot returns the root ID resulting from the most recently stored
+// change summary. Returns false if there are no stored change summaries.
+func (h *diskHistory) latestRoot() (ids.ID, bool, error) {
+	if h.state.length() == 0 {
+		return ids.Empty, false, nil
+	}
+	changes, _, err := h.get(h.state.nextIndex - 1)
+	if err != nil {
+		return ids.Empty, false, err
+	}
+	return changes.rootID, true, nil
+}
+
+// clear removes all of the stored change summaries.
+func (h *diskHistory) clear() error {
+	if err := h.db.DeleteRange(nil, nil); err != nil {
+		return err
+	}
+	h.state = diskHistoryState{}
+	return nil
+}
+
+// record stores [changes] as the most recent change summary.
+func (h *diskHistory) record(changes *changeSummary) error {
+	batch, state, err := h.prepareRecord(changes)
+	if err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	h.state = state
+	return nil
+}
+
+// prepareRecord returns a batch that stores [changes] as the most recent
+// change summary and evicts any change summaries that no longer fit, along with
+// the state of the history after the batch has been written.
+// The returned state must only be applied once the batch has been written.
+func (h *diskHistory) prepareRecord(changes *changeSummary) (database.Batch, diskHistoryState, error) {
+	changesBytes, err := h.codec.encodeChangeSummary(Version, changes)
+	if err != nil {
+		return nil, diskHistoryState{}, err
+	}
+
+	var (
+		batch = h.db.NewBatch()
+		state = h.state
+		index = state.nextIndex
+	)
+	if err := batch.Put(historyChangeKey(index), changesBytes); err != nil {
+		return nil, diskHistoryState{}, err
+	}
+	if err := batch.Put(historyRootKey(changes.rootID, index), nil); err != nil {
+		return nil, diskHistoryState{}, err
+	}
+	state.nextIndex++
+	state.size += uint64(len(changesBytes))
+
+	// Always keep the change summary that was just added so that the current
+	// root can be found.
+	for state.length() > 1 &&
+		((h.maxLength > 0 && state.length() > uint64(h.maxLength)) ||
+			(h.maxSize > 0 && state.size > uint64(h.maxSize))) {
+		oldest, oldestSize, err := h.get(state.oldestIndex)
+		if err != nil {
+			return nil, diskHistoryState{}, err
+		}
+		if err := batch.Delete(historyChangeKey(state.oldestIndex)); err != nil {
+			return nil, diskHistoryState{}, err
+		}
+		if err := batch.Delete(historyRootKey(oldest.rootID, state.oldestIndex)); err != nil {
+			return nil, diskHistoryState{}, err
+		}
+		state.oldestIndex++
+		state.size -= uint64(oldestSize)
+	}
+
+	sizeBytes := make([]byte, wrappers.LongLen)
+	binary.BigEndian.PutUint64(sizeBytes, state.size)
+	if err := batch.Put(historySizeKey, sizeBytes); err != nil {
+		return nil, diskHistoryState{}, err
+	}
+	return batch, state, nil
+}
+
+// get returns the change summary stored under [index] and its encoded size.
+func (h *diskHistory) get(index uint64) (*changeSummary, int, error) {
+	changesBytes, err := h.db.Get(historyChangeKey(index))
+	if err == database.ErrNotFound {
+		return nil, 0, fmt.Errorf("%w: %d", errMissingHistoryEntry, index)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	changes := &changeSummary{}
+	if _, err := h.codec.decodeChangeSummary(changesBytes, changes, h.hasher); err != nil {
+		return nil, 0, err
+	}
+	return changes, len(changesBytes), nil
+}
+
+// latestIndex returns the largest index, that is at most [maxIndex], of a
+// change summary resulting in [rootID]. Returns false if there is no such
+// change summary.
+func (h *diskHistory) latestIndex(rootID ids.ID, maxIndex uint64) (uint64, bool, error) {
+	it := h.db.NewReverseIteratorWithStartAndPrefix(
+		historyRootKey(rootID, maxIndex),
+		historyRootPrefix(rootID),
+	)
+	defer it.Release()
+
+	if !it.Next() {
+		return 0, false, it.Error()
+	}
+	key := it.Key()
+	return binary.BigEndian.Uint64(key[len(key)-wrappers.LongLen:]), true, nil
+}
+
+// Returns up to [maxLength] key-value pair changes with keys in [start, end]
+// that occurred between [startRoot] and [endRoot].
+// See trieHistory.getValueChanges.
+func (h *diskHistory) getValueChanges(startRoot, endRoot ids.ID, start, end []byte, maxLength int) (*changeSummary, error) {
+	if maxLength <= 0 {
+		return nil, fmt.Errorf("%w but was %d", ErrInvalidMaxLength, maxLength)
+	}
+
+	if startRoot == endRoot {
+		return newChangeSummary(maxLength), nil
+	}
+
+	if h.state.length() == 0 {
+		return nil, ErrRootIDNotPresent
+	}
+
+	// [endIndex] is the last change resulting in [endRoot].
+	endIndex, ok, err := h.latestIndex(endRoot, h.state.nextIndex-1)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRootIDNotPresent
+	}
+
+	// [startIndex] is the last change resulting in [startRoot] before
+	// [endIndex].
+	startIndex, ok, err := h.latestIndex(startRoot, endIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrStartRootNotFound
+	}
+
+	combinedChanges := newValueChangeCombiner(start, end, maxLength, h.branchFactor)
+	for index := startIndex + 1; index <= endIndex; index++ {
+		changes, _, err := h.get(index)
+		if err != nil {
+			return nil, err
+		}
+		combinedChanges.add(changes)
+	}
+	return combinedChanges.result(), nil
+}
+
+// Returns the changes to go from the most recently stored trie state back to
+// the requested [rootID] for the keys in [start, end].
+// See trieHistory.getChangesToGetToRoot.
+func (h *diskHistory) getChangesToGetToRoot(rootID ids.ID, start, end []byte) (*changeSummary, error) {
+	if h.state.length() == 0 {
+		return nil, ErrRootIDNotPresent
+	}
+
+	rootIndex, ok, err := h.latestIndex(rootID, h.state.nextIndex-1)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRootIDNotPresent
+	}
+
+	var (
+		startPath       = newPath(start, h.branchFactor)
+		endPath         = newPath(end, h.branchFactor)
+		combinedChanges = newChangeSummary(defaultPreallocationSize)
+	)
+	for index := h.state.nextIndex - 1; index > rootIndex; index-- {
+		changes, _, err := h.get(index)
+		if err != nil {
+			return nil, err
+		}
+		addReversedChanges(combinedChanges, changes, startPath, endPath)
+	}
+	return combinedChanges, nil
+}
+
+// historyChangeKey returns the key that the change summary with [index] is stored
+// under. Keys are ordered by index.
+func historyChangeKey(index uint64) []byte {
+	key := make([]byte, 1+wrappers.LongLen)
+	key[0] = changeKeyPrefix
+	binary.BigEndian.PutUint64(key[1:], index)
+	return key
+}
+
+func parseHistoryChangeKey(key []byte) (uint64, error) {
+	if len(key) != 1+wrappers.LongLen {
+		return 0, fmt.Errorf("%w: expected %d bytes but got %d", errInvalidHistoryKey, 1+wrappers.LongLen, len(key))
+	}
+	return binary.BigEndian.Uint64(key[1:]), nil
+}
+
+// historyRootKey returns the key that marks that the change summary with [index]
+// resulted in [rootID].
+func historyRootKey(rootID ids.ID, index uint64) []byte {
+	key := make([]byte, 1+idLen+wrappers.LongLen)
+	copy(key, historyRootPrefix(rootID))
+	binary.BigEndian.PutUint64(key[1+idLen:], index)
+	return key
+}
+
+func historyRootPrefix(rootID ids.ID) []byte {
+	prefix := make([]byte, 1+idLen)
+	prefix[0] = rootKeyPrefix
+	copy(prefix[1:], rootID[:])
+	return prefix
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/database/prefixdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+)
+
+func newPersistedHistoryDB(db database.Database, length int, size int) (*Database, error) {
+	return newDatabase(
+		context.Background(),
+		db,
+		Config{
+			Tracer:                 newNoopTracer(),
+			HistoryLength:          0,
+			PersistHistory:         true,
+			PersistedHistoryLength: length,
+			PersistedHistorySize:   size,
+			NodeCacheSize:          1000,
+		},
+		&mockMetrics{},
+	)
+}
+
+// Writes [key] => [value] to [db] and returns the resulting root.
+func putAndGetRoot(t *testing.T, db *Database, key, value []byte) ids.ID {
+	require := require.New(t)
+
+	require.NoError(db.Put(key, value))
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	return root
+}
+
+func TestDiskHistoryChangeProofAfterRestart(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	startRoot := putAndGetRoot(t, db, []byte{1}, []byte{1})
+	_ = putAndGetRoot(t, db, []byte{2}, []byte{2})
+	require.NoError(db.Delete([]byte{1}))
+	endRoot := putAndGetRoot(t, db, []byte{3}, []byte{3})
+	require.NoError(db.Close())
+
+	db, err = newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	proof, err := db.GetChangeProof(context.Background(), startRoot, endRoot, nil, nil, 10)
+	require.NoError(err)
+	require.True(proof.HadRootsInHistory)
+	require.Len(proof.KeyChanges, 3)
+
+	// Verify the proof against a database at [startRoot].
+	startDB, err := getBasicDB()
+	require.NoError(err)
+	require.NoError(startDB.Put([]byte{1}, []byte{1}))
+	require.NoError(proof.Verify(context.Background(), startDB, nil, nil, endRoot))
+
+	rangeProof, err := db.GetRangeProofAtRoot(context.Background(), startRoot, nil, nil, 10)
+	require.NoError(err)
+	require.NoError(rangeProof.Verify(context.Background(), nil, nil, startRoot, BranchFactor16, SHA256Hasher))
+
+	metrics := db.metrics.(*mockMetrics)
+	require.Equal(int64(2), metrics.historyDiskHit)
+	require.Zero(metrics.historyMemoryHit)
+	require.Zero(metrics.historyMiss)
+}
+
+func TestDiskHistoryAfterUncleanShutdown(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	startRoot := putAndGetRoot(t, db, []byte{1}, []byte{1})
+	endRoot := putAndGetRoot(t, db, []byte{2}, []byte{2})
+
+	// Reopen the database without closing it, which triggers a rebuild.
+	db, err = newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(endRoot, root)
+
+	proof, err := db.GetChangeProof(context.Background(), startRoot, endRoot, nil, nil, 10)
+	require.NoError(err)
+	require.True(proof.HadRootsInHistory)
+	require.Len(proof.KeyChanges, 1)
+}
+
+func TestDiskHistoryMaxLength(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newPersistedHistoryDB(baseDB, 3, 0)
+	require.NoError(err)
+
+	roots := make([]ids.ID, 0, 5)
+	for i := byte(0); i < 5; i++ {
+		roots = append(roots, putAndGetRoot(t, db, []byte{i}, []byte{i}))
+	}
+	require.Equal(uint64(3), db.diskHistory.state.length())
+
+	_, err = db.GetChangeProof(context.Background(), roots[0], roots[4], nil, nil, 10)
+	require.ErrorIs(err, ErrStartRootNotFound)
+
+	proof, err := db.GetChangeProof(context.Background(), roots[2], roots[4], nil, nil, 10)
+	require.NoError(err)
+	require.True(proof.HadRootsInHistory)
+	require.Len(proof.KeyChanges, 2)
+
+	// The bound is respected after a restart.
+	require.NoError(db.Close())
+	db, err = newPersistedHistoryDB(baseDB, 3, 0)
+	require.NoError(err)
+	require.Equal(uint64(3), db.diskHistory.state.length())
+}
+
+func TestDiskHistoryMaxSize(t *testing.T) {
+	require := require.New(t)
+
+	const maxSize = 16 * units.KiB
+	db, err := newPersistedHistoryDB(memdb.New(), 0, maxSize)
+	require.NoError(err)
+
+	for i := byte(0); i < 100; i++ {
+		_ = putAndGetRoot(t, db, []byte{i}, []byte{i})
+		require.LessOrEqual(db.diskHistory.state.size, uint64(maxSize))
+	}
+	require.Less(db.diskHistory.state.length(), uint64(100))
+
+	// The most recent change is always kept, even if it exceeds [maxSize].
+	_ = putAndGetRoot(t, db, []byte{0}, make([]byte, 2*maxSize))
+	require.Equal(uint64(1), db.diskHistory.state.length())
+	require.Greater(db.diskHistory.state.size, uint64(maxSize))
+}
+
+func TestDiskHistoryDisabled(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	startRoot := putAndGetRoot(t, db, []byte{1}, []byte{1})
+	endRoot := putAndGetRoot(t, db, []byte{2}, []byte{2})
+	require.NoError(db.Close())
+
+	db, err = getBasicDBWith(baseDB)
+	require.NoError(err)
+	require.Nil(db.diskHistory)
+
+	_, err = db.GetChangeProof(context.Background(), startRoot, endRoot, nil, nil, 10)
+	require.ErrorIs(err, ErrStartRootNotFound)
+
+	// The previously persisted history was deleted.
+	it := prefixdb.New(historyPrefix, baseDB).NewIterator()
+	defer it.Release()
+	require.False(it.Next())
+	require.NoError(it.Error())
+}
+
+func TestDiskHistoryClearedOnRootMismatch(t *testing.T) {
+	require := require.New(t)
+
+	baseDB := memdb.New()
+	db, err := newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+
+	startRoot := putAndGetRoot(t, db, []byte{1}, []byte{1})
+	require.NoError(db.Close())
+
+	// Modify the database without persisting the history.
+	db, err = getBasicDBWith(baseDB)
+	require.NoError(err)
+	endRoot := putAndGetRoot(t, db, []byte{2}, []byte{2})
+	require.NoError(db.Close())
+
+	db, err = newPersistedHistoryDB(baseDB, 0, 0)
+	require.NoError(err)
+	require.Equal(uint64(1), db.diskHistory.state.length())
+
+	latestRoot, ok, err := db.diskHistory.latestRoot()
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(endRoot, latestRoot)
+
+	_, err = db.GetChangeProof(context.Background(), startRoot, endRoot, nil, nil, 10)
+	require.ErrorIs(err, ErrStartRootNotFound)
+}
+
+func getBasicDBWith(db database.Database) (*Database, error) {
+	return newDatabase(
+		context.Background(),
+		db,
+		Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 1000,
+			NodeCacheSize: 1000,
+		},
+		&mockMetrics{},
+	)
+}
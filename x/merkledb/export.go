@@ -0,0 +1,354 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/units"
+	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+)
+
+// An export is a header followed by a sequence of chunks.
+//
+// The header is [exportMagic], the export format version, the branch factor
+// and hasher of the exporting database, and the root ID of the exported trie.
+// An export can only be imported into a database with the same branch factor
+// and hasher, as they determine the root ID.
+//
+// Each chunk is the length of an encoded range proof followed by the range
+// proof. The first chunk proves the key/value pairs at the start of the trie.
+// Each following chunk proves the key/value pairs that immediately follow the
+// last key of the previous chunk. The last chunk is the only chunk without any
+// key/value pairs, and proves that there are no more keys in the trie.
+//
+// Because every chunk is proven against the root ID in the header, an importer
+// can verify an export chunk by chunk without trusting its source.
+const (
+	exportMagic          = "mdbx"
+	exportVersion        = 0
+	exportHeaderLen      = len(exportMagic) + wrappers.ShortLen + wrappers.ShortLen + wrappers.ByteLen + idLen
+	exportChunkLenLen    = wrappers.IntLen
+	maxExportChunkLen    = 256 * units.MiB
+	exportProgressPeriod = 100_000
+
+	// DefaultExportChunkSize is the default maximum number of key/value pairs
+	// in each chunk of an export.
+	DefaultExportChunkSize = 2048
+)
+
+var (
+	ErrInvalidExportHeader  = errors.New("invalid export header")
+	ErrUnexpectedExportRoot = errors.New("export has an unexpected root")
+	ErrImportRootMismatch   = errors.New("root after import doesn't match the export")
+	ErrExportConfigMismatch = errors.New("export was created by a database with a different config")
+
+	errUnknownExportVersion = errors.New("unknown export version")
+	errExportChunkTooLarge  = errors.New("export chunk too large")
+	errMissingLastChunk     = errors.New("export ended before the last chunk")
+	errTrailingExportData   = errors.New("unexpected data after the last chunk")
+	errInvalidChunkSize     = errors.New("chunk size must be positive")
+)
+
+type ExportConfig struct {
+	// The root of the trie to export. If empty, the current root is exported.
+	// Roots other than the current root must be in the database's history.
+	RootID ids.ID
+	// The maximum number of key/value pairs in each chunk.
+	// If 0, [DefaultExportChunkSize] is used.
+	ChunkSize int
+	// If non-nil, called periodically and once the export is done.
+	OnProgress func(ExportProgress)
+}
+
+type ImportConfig struct {
+	// If non-empty, the import fails unless the export has this root.
+	// If empty, the root in the export is trusted.
+	ExpectedRootID ids.ID
+	// If non-nil, called periodically and once the import is done.
+	OnProgress func(ExportProgress)
+}
+
+// ExportProgress describes the progress of an export or import.
+type ExportProgress struct {
+	NumChunks int
+	NumKeys   int
+	// The number of chunks that weren't committed during an import because
+	// they were already in the database.
+	NumSkippedChunks int
+	Duration         time.Duration
+}
+
+// progressReporter calls a progress callback each time
+// [exportProgressPeriod] more keys have been processed.
+type progressReporter struct {
+	onProgress   func(ExportProgress)
+	startTime    time.Time
+	progress     ExportProgress
+	lastReported int
+}
+
+func newProgressReporter(onProgress func(ExportProgress)) *progressReporter {
+	return &progressReporter{
+		onProgress: onProgress,
+		startTime:  time.Now(),
+	}
+}
+
+func (p *progressReporter) addChunk(numKeys int, skipped bool) {
+	p.progress.NumChunks++
+	p.progress.NumKeys += numKeys
+	if skipped {
+		p.progress.NumSkippedChunks++
+	}
+	if p.progress.NumKeys-p.lastReported >= exportProgressPeriod {
+		p.report()
+	}
+}
+
+func (p *progressReporter) report() {
+	p.lastReported = p.progress.NumKeys
+	if p.onProgress != nil {
+		p.progress.Duration = time.Since(p.startTime)
+		p.onProgress(p.progress)
+	}
+}
+
+// Export writes the key/value pairs of the trie at [config.RootID] to [w] with
+// the range proofs needed to verify them. Returns the exported root.
+func (db *Database) Export(ctx context.Context, w io.Writer, config ExportConfig) (ids.ID, error) {
+	chunkSize := config.ChunkSize
+	switch {
+	case chunkSize == 0:
+		chunkSize = DefaultExportChunkSize
+	case chunkSize < 0:
+		return ids.Empty, fmt.Errorf("%w but was %d", errInvalidChunkSize, chunkSize)
+	}
+
+	rootID := config.RootID
+	if rootID == ids.Empty {
+		var err error
+		rootID, err = db.GetMerkleRoot(ctx)
+		if err != nil {
+			return ids.Empty, err
+		}
+	}
+
+	header := exportHeader{
+		branchFactor: db.branchFactor,
+		hasher:       db.hasher,
+		rootID:       rootID,
+	}
+	if err := writeExportHeader(w, header); err != nil {
+		return ids.Empty, err
+	}
+
+	var (
+		progress = newProgressReporter(config.OnProgress)
+		start    []byte
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return ids.Empty, err
+		}
+
+		proof, err := db.GetRangeProofAtRoot(ctx, rootID, start, nil, chunkSize)
+		if err != nil {
+			return ids.Empty, err
+		}
+		proofBytes, err := db.codec.EncodeRangeProof(Version, proof)
+		if err != nil {
+			return ids.Empty, err
+		}
+		if err := writeExportChunk(w, proofBytes); err != nil {
+			return ids.Empty, err
+		}
+		progress.addChunk(len(proof.KeyValues), false)
+
+		if len(proof.KeyValues) == 0 {
+			progress.report()
+			return rootID, nil
+		}
+		start = nextKey(proof.KeyValues[len(proof.KeyValues)-1].Key)
+	}
+}
+
+// Import replaces the contents of [db] with the trie exported to [r], and
+// returns its root. Each chunk is verified before it's committed.
+//
+// If a previous import of the same export into [db] was interrupted, the
+// chunks that were committed by it are skipped. If [db] is modified while
+// importing, the import fails.
+func (db *Database) Import(ctx context.Context, r io.Reader, config ImportConfig) (ids.ID, error) {
+	header, err := readExportHeader(r)
+	if err != nil {
+		return ids.Empty, err
+	}
+	if header.branchFactor != db.branchFactor {
+		return ids.Empty, fmt.Errorf("%w: export has branch factor %d but the database has %d", ErrExportConfigMismatch, header.branchFactor, db.branchFactor)
+	}
+	if header.hasher != db.hasher {
+		return ids.Empty, fmt.Errorf("%w: export has hasher %s but the database has %s", ErrExportConfigMismatch, header.hasher, db.hasher)
+	}
+	rootID := header.rootID
+	if config.ExpectedRootID != ids.Empty && rootID != config.ExpectedRootID {
+		return ids.Empty, fmt.Errorf("%w: expected %s but got %s", ErrUnexpectedExportRoot, config.ExpectedRootID, rootID)
+	}
+
+	var (
+		progress = newProgressReporter(config.OnProgress)
+		start    []byte
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return ids.Empty, err
+		}
+
+		proofBytes, err := readExportChunk(r)
+		if err != nil {
+			return ids.Empty, err
+		}
+		proof := &RangeProof{}
+		if _, err := db.codec.DecodeRangeProof(proofBytes, proof); err != nil {
+			return ids.Empty, fmt.Errorf("chunk %d: %w", progress.progress.NumChunks, err)
+		}
+		if err := proof.Verify(ctx, start, nil, rootID, db.branchFactor, db.hasher); err != nil {
+			return ids.Empty, fmt.Errorf("chunk %d: %w", progress.progress.NumChunks, err)
+		}
+
+		imported, err := db.hasKeyValues(start, proof.KeyValues)
+		if err != nil {
+			return ids.Empty, err
+		}
+		if !imported {
+			if err := db.CommitRangeProof(ctx, start, proof); err != nil {
+				return ids.Empty, err
+			}
+		}
+		// The last chunk has nothing to skip, so it isn't reported as skipped.
+		progress.addChunk(len(proof.KeyValues), imported && len(proof.KeyValues) > 0)
+
+		if len(proof.KeyValues) == 0 {
+			break
+		}
+		start = nextKey(proof.KeyValues[len(proof.KeyValues)-1].Key)
+	}
+
+	if _, err := io.ReadFull(r, make([]byte, 1)); err != io.EOF {
+		if err == nil {
+			err = errTrailingExportData
+		}
+		return ids.Empty, err
+	}
+
+	importedRootID, err := db.GetMerkleRoot(ctx)
+	if err != nil {
+		return ids.Empty, err
+	}
+	if importedRootID != rootID {
+		return ids.Empty, fmt.Errorf("%w: expected %s but got %s", ErrImportRootMismatch, rootID, importedRootID)
+	}
+	progress.report()
+	return rootID, nil
+}
+
+// hasKeyValues returns true iff the first key/value pairs in [db] with keys
+// >= [start] are [keyValues]. If [keyValues] is empty, returns true iff there
+// are no keys >= [start].
+func (db *Database) hasKeyValues(start []byte, keyValues []KeyValue) (bool, error) {
+	it := db.NewIteratorWithStart(start)
+	defer it.Release()
+
+	for _, kv := range keyValues {
+		if !it.Next() || !bytes.Equal(it.Key(), kv.Key) || !bytes.Equal(it.Value(), kv.Value) {
+			return false, it.Error()
+		}
+	}
+	if len(keyValues) == 0 && it.Next() {
+		return false, it.Error()
+	}
+	return true, it.Error()
+}
+
+// nextKey returns the smallest key that is greater than [key].
+func nextKey(key []byte) []byte {
+	next := make([]byte, len(key)+1)
+	copy(next, key)
+	return next
+}
+
+type exportHeader struct {
+	branchFactor BranchFactor
+	hasher       Hasher
+	rootID       ids.ID
+}
+
+func writeExportHeader(w io.Writer, header exportHeader) error {
+	headerBytes := make([]byte, 0, exportHeaderLen)
+	headerBytes = append(headerBytes, exportMagic...)
+	headerBytes = binary.BigEndian.AppendUint16(headerBytes, exportVersion)
+	headerBytes = binary.BigEndian.AppendUint16(headerBytes, uint16(header.branchFactor))
+	headerBytes = append(headerBytes, byte(header.hasher))
+	headerBytes = append(headerBytes, header.rootID[:]...)
+	_, err := w.Write(headerBytes)
+	return err
+}
+
+func readExportHeader(r io.Reader) (exportHeader, error) {
+	headerBytes := make([]byte, exportHeaderLen)
+	if _, err := io.ReadFull(r, headerBytes); err != nil {
+		return exportHeader{}, fmt.Errorf("%w: %s", ErrInvalidExportHeader, err)
+	}
+	if string(headerBytes[:len(exportMagic)]) != exportMagic {
+		return exportHeader{}, ErrInvalidExportHeader
+	}
+	headerBytes = headerBytes[len(exportMagic):]
+	if version := binary.BigEndian.Uint16(headerBytes); version != exportVersion {
+		return exportHeader{}, fmt.Errorf("%w: %d", errUnknownExportVersion, version)
+	}
+	headerBytes = headerBytes[wrappers.ShortLen:]
+	header := exportHeader{
+		branchFactor: BranchFactor(binary.BigEndian.Uint16(headerBytes)),
+		hasher:       Hasher(headerBytes[wrappers.ShortLen]),
+	}
+	rootID, err := ids.ToID(headerBytes[wrappers.ShortLen+wrappers.ByteLen:])
+	header.rootID = rootID
+	return header, err
+}
+
+func writeExportChunk(w io.Writer, proofBytes []byte) error {
+	chunkLen := make([]byte, exportChunkLenLen)
+	binary.BigEndian.PutUint32(chunkLen, uint32(len(proofBytes)))
+	if _, err := w.Write(chunkLen); err != nil {
+		return err
+	}
+	_, err := w.Write(proofBytes)
+	return err
+}
+
+func readExportChunk(r io.Reader) ([]byte, error) {
+	chunkLenBytes := make([]byte, exportChunkLenLen)
+	if _, err := io.ReadFull(r, chunkLenBytes); err != nil {
+		if err == io.EOF {
+			err = errMissingLastChunk
+		}
+		return nil, err
+	}
+	chunkLen := binary.BigEndian.Uint32(chunkLenBytes)
+	if chunkLen > maxExportChunkLen {
+		return nil, fmt.Errorf("%w: %d bytes", errExportChunkTooLarge, chunkLen)
+	}
+	proofBytes := make([]byte, chunkLen)
+	if _, err := io.ReadFull(r, proofBytes); err != nil {
+		return nil, err
+	}
+	return proofBytes, nil
+}
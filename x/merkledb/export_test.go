@@ -0,0 +1,335 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+// Returns a database containing random key/value pairs, and its contents.
+func newRandomExportDB(t *testing.T, r *rand.Rand) (*Database, map[string][]byte) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	values := map[string][]byte{}
+	for i := 0; i < 10; i++ {
+		require.NoError(writeRandomBatch(r, db, values))
+	}
+	return db, values
+}
+
+func requireContains(t *testing.T, db *Database, values map[string][]byte) {
+	require := require.New(t)
+
+	it := db.NewIterator()
+	defer it.Release()
+
+	numKeys := 0
+	for it.Next() {
+		require.Equal(values[string(it.Key())], it.Value())
+		numKeys++
+	}
+	require.NoError(it.Error())
+	require.Len(values, numKeys)
+}
+
+func TestExportImport(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, values := newRandomExportDB(t, r)
+
+	var (
+		export         bytes.Buffer
+		exportProgress ExportProgress
+	)
+	rootID, err := db.Export(context.Background(), &export, ExportConfig{
+		ChunkSize: 10,
+		OnProgress: func(progress ExportProgress) {
+			exportProgress = progress
+		},
+	})
+	require.NoError(err)
+
+	expectedRootID, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(expectedRootID, rootID)
+	require.Len(values, exportProgress.NumKeys)
+	require.Equal((len(values)+9)/10+1, exportProgress.NumChunks)
+
+	importDB, err := getBasicDB()
+	require.NoError(err)
+
+	var importProgress ExportProgress
+	rootID, err = importDB.Import(context.Background(), &export, ImportConfig{
+		ExpectedRootID: expectedRootID,
+		OnProgress: func(progress ExportProgress) {
+			importProgress = progress
+		},
+	})
+	require.NoError(err)
+	require.Equal(expectedRootID, rootID)
+	require.Equal(exportProgress.NumChunks, importProgress.NumChunks)
+	require.Equal(exportProgress.NumKeys, importProgress.NumKeys)
+	require.Zero(importProgress.NumSkippedChunks)
+	requireContains(t, importDB, values)
+}
+
+func TestExportHistoricalRoot(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, values := newRandomExportDB(t, r)
+
+	rootID, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	newValues := map[string][]byte{}
+	for i := 0; i < 5; i++ {
+		require.NoError(writeRandomBatch(r, db, newValues))
+	}
+
+	var export bytes.Buffer
+	exportedRootID, err := db.Export(context.Background(), &export, ExportConfig{
+		RootID:    rootID,
+		ChunkSize: 10,
+	})
+	require.NoError(err)
+	require.Equal(rootID, exportedRootID)
+
+	importDB, err := getBasicDB()
+	require.NoError(err)
+	_, err = importDB.Import(context.Background(), &export, ImportConfig{
+		ExpectedRootID: rootID,
+	})
+	require.NoError(err)
+	requireContains(t, importDB, values)
+}
+
+func TestExportImportEmpty(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	var export bytes.Buffer
+	rootID, err := db.Export(context.Background(), &export, ExportConfig{})
+	require.NoError(err)
+
+	// Importing an empty trie removes every key.
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	importDB, _ := newRandomExportDB(t, r)
+
+	importedRootID, err := importDB.Import(context.Background(), &export, ImportConfig{})
+	require.NoError(err)
+	require.Equal(rootID, importedRootID)
+	requireContains(t, importDB, map[string][]byte{})
+}
+
+func TestImportReplacesExistingKeys(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, values := newRandomExportDB(t, r)
+
+	var export bytes.Buffer
+	rootID, err := db.Export(context.Background(), &export, ExportConfig{
+		ChunkSize: 10,
+	})
+	require.NoError(err)
+
+	importDB, _ := newRandomExportDB(t, r)
+	importedRootID, err := importDB.Import(context.Background(), &export, ImportConfig{})
+	require.NoError(err)
+	require.Equal(rootID, importedRootID)
+	requireContains(t, importDB, values)
+}
+
+func TestImportResume(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, values := newRandomExportDB(t, r)
+
+	var export bytes.Buffer
+	rootID, err := db.Export(context.Background(), &export, ExportConfig{
+		ChunkSize: 10,
+	})
+	require.NoError(err)
+	exportBytes := export.Bytes()
+
+	// Interrupt the import halfway through the export.
+	importDB, err := getBasicDB()
+	require.NoError(err)
+	_, err = importDB.Import(
+		context.Background(),
+		bytes.NewReader(exportBytes[:len(exportBytes)/2]),
+		ImportConfig{},
+	)
+	require.ErrorIs(err, io.ErrUnexpectedEOF)
+
+	var progress ExportProgress
+	importedRootID, err := importDB.Import(context.Background(), bytes.NewReader(exportBytes), ImportConfig{
+		OnProgress: func(p ExportProgress) {
+			progress = p
+		},
+	})
+	require.NoError(err)
+	require.Equal(rootID, importedRootID)
+	require.Positive(progress.NumSkippedChunks)
+	require.Less(progress.NumSkippedChunks, progress.NumChunks)
+	requireContains(t, importDB, values)
+}
+
+func TestImportMissingLastChunk(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, _ := newRandomExportDB(t, r)
+
+	var export bytes.Buffer
+	require.NoError(writeExportHeader(&export, exportHeader{
+		branchFactor: db.branchFactor,
+		hasher:       db.hasher,
+		rootID:       db.getMerkleRoot(),
+	}))
+	proof, err := db.GetRangeProof(context.Background(), nil, nil, 10)
+	require.NoError(err)
+	proofBytes, err := Codec.EncodeRangeProof(Version, proof)
+	require.NoError(err)
+	require.NoError(writeExportChunk(&export, proofBytes))
+
+	importDB, err := getBasicDB()
+	require.NoError(err)
+	_, err = importDB.Import(context.Background(), &export, ImportConfig{})
+	require.ErrorIs(err, errMissingLastChunk)
+}
+
+func TestImportTamperedChunk(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, _ := newRandomExportDB(t, r)
+
+	proof, err := db.GetRangeProof(context.Background(), nil, nil, 10)
+	require.NoError(err)
+	proof.KeyValues[0].Value = append(proof.KeyValues[0].Value, 0)
+	proofBytes, err := Codec.EncodeRangeProof(Version, proof)
+	require.NoError(err)
+
+	var export bytes.Buffer
+	require.NoError(writeExportHeader(&export, exportHeader{
+		branchFactor: db.branchFactor,
+		hasher:       db.hasher,
+		rootID:       db.getMerkleRoot(),
+	}))
+	require.NoError(writeExportChunk(&export, proofBytes))
+
+	importDB, err := getBasicDB()
+	require.NoError(err)
+	_, err = importDB.Import(context.Background(), &export, ImportConfig{})
+	require.ErrorIs(err, ErrInvalidProof)
+
+	// Nothing was committed.
+	requireContains(t, importDB, map[string][]byte{})
+}
+
+func TestImportTrailingData(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	var export bytes.Buffer
+	_, err = db.Export(context.Background(), &export, ExportConfig{})
+	require.NoError(err)
+	export.WriteByte(0)
+
+	_, err = db.Import(context.Background(), &export, ImportConfig{})
+	require.ErrorIs(err, errTrailingExportData)
+}
+
+func TestImportUnexpectedRoot(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	var export bytes.Buffer
+	_, err = db.Export(context.Background(), &export, ExportConfig{})
+	require.NoError(err)
+
+	_, err = db.Import(context.Background(), &export, ImportConfig{
+		ExpectedRootID: ids.GenerateTestID(),
+	})
+	require.ErrorIs(err, ErrUnexpectedExportRoot)
+}
+
+func TestImportInvalidHeader(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	var export bytes.Buffer
+	_, err = db.Export(context.Background(), &export, ExportConfig{})
+	require.NoError(err)
+	exportBytes := export.Bytes()
+	exportBytes[0]++
+
+	_, err = db.Import(context.Background(), bytes.NewReader(exportBytes), ImportConfig{})
+	require.ErrorIs(err, ErrInvalidExportHeader)
+
+	_, err = db.Import(context.Background(), bytes.NewReader(exportBytes[:exportHeaderLen-1]), ImportConfig{})
+	require.ErrorIs(err, ErrInvalidExportHeader)
+}
+
+func TestImportConfigMismatch(t *testing.T) {
+	require := require.New(t)
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	db, values := newRandomExportDB(t, r)
+
+	var export bytes.Buffer
+	_, err := db.Export(context.Background(), &export, ExportConfig{})
+	require.NoError(err)
+
+	for _, test := range []struct {
+		branchFactor BranchFactor
+		hasher       Hasher
+	}{
+		{
+			branchFactor: BranchFactor256,
+			hasher:       SHA256Hasher,
+		},
+		{
+			branchFactor: DefaultBranchFactor,
+			hasher:       BLAKE2bHasher,
+		},
+	} {
+		importDB, err := getBasicDBWithTrieConfig(test.branchFactor, test.hasher)
+		require.NoError(err)
+		_, err = importDB.Import(context.Background(), bytes.NewReader(export.Bytes()), ImportConfig{})
+		require.ErrorIs(err, ErrExportConfigMismatch)
+
+		// Nothing was committed.
+		requireContains(t, importDB, map[string][]byte{})
+	}
+
+	importDB, err := getBasicDB()
+	require.NoError(err)
+	_, err = importDB.Import(context.Background(), &export, ImportConfig{})
+	require.NoError(err)
+	requireContains(t, importDB, values)
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+var ErrInvalidHasher = errors.New("invalid hasher")
+
+// Hasher is the hash function used to compute the IDs of nodes and the
+// digests of values.
+type Hasher byte
+
+const (
+	// SHA256Hasher uses SHA-256. It is the default hasher.
+	SHA256Hasher Hasher = iota
+	// BLAKE2bHasher uses BLAKE2b-256, which is faster than SHA-256 on
+	// hardware without SHA extensions.
+	BLAKE2bHasher
+)
+
+func (h Hasher) Valid() error {
+	switch h {
+	case SHA256Hasher, BLAKE2bHasher:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d", ErrInvalidHasher, h)
+	}
+}
+
+func (h Hasher) String() string {
+	switch h {
+	case SHA256Hasher:
+		return "sha256"
+	case BLAKE2bHasher:
+		return "blake2b"
+	default:
+		return "unknown"
+	}
+}
+
+// Returns the hash of [b].
+// Assumes [h] is valid.
+func (h Hasher) hash(b []byte) ids.ID {
+	if h == BLAKE2bHasher {
+		return blake2b.Sum256(b)
+	}
+	return sha256.Sum256(b)
+}
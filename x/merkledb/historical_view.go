@@ -0,0 +1,358 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/set"
+)
+
+// The number of key/value pairs that a historical view iterator reads from
+// the view at a time.
+const historicalIteratorBatchSize = 1024
+
+var (
+	_ HistoricalView    = (*historicalView)(nil)
+	_ database.Iterator = (*historicalIterator)(nil)
+)
+
+// HistoricalView is a read-only view of the trie as it was at a previous root.
+//
+// A HistoricalView remains usable after changes are committed to the database
+// it was created from, as it is rebuilt from the history when needed. Once its
+// root ages out of the history, methods return [ErrRootIDNotPresent] and the
+// view must be re-opened at a more recent root.
+type HistoricalView interface {
+	ReadOnlyTrie
+
+	// NewIterator creates an iterator over the key/value pairs in the view.
+	NewIterator() database.Iterator
+
+	// NewIteratorWithStart creates an iterator over the key/value pairs in
+	// the view, starting at [start].
+	NewIteratorWithStart(start []byte) database.Iterator
+
+	// NewIteratorWithPrefix creates an iterator over the key/value pairs in
+	// the view with keys that start with [prefix].
+	NewIteratorWithPrefix(prefix []byte) database.Iterator
+
+	// NewIteratorWithStartAndPrefix creates an iterator over the key/value
+	// pairs in the view with keys that start with [prefix], starting at
+	// [start].
+	NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator
+}
+
+// NewViewAtRoot returns a read-only view of the trie as it was when it had
+// root [rootID]. The view is reconstructed from the in-memory history and, if
+// [Config.PersistHistory] is set, the history persisted on disk.
+// Returns [ErrRootIDNotPresent] if [rootID] isn't in the history, for example
+// because it has aged out.
+func (db *Database) NewViewAtRoot(rootID ids.ID) (HistoricalView, error) {
+	db.commitLock.RLock()
+	defer db.commitLock.RUnlock()
+
+	view, err := db.newHistoricalView(rootID)
+	if err != nil {
+		return nil, err
+	}
+	return &historicalView{
+		db:     db,
+		rootID: rootID,
+		view:   view,
+	}, nil
+}
+
+// Returns a view of the trie as it was when it had root [rootID].
+// The view is added to [db.childViews].
+// Assumes [db.commitLock] is read locked.
+func (db *Database) newHistoricalView(rootID ids.ID) (*trieView, error) {
+	if db.closed {
+		return nil, database.ErrClosed
+	}
+
+	view, err := db.getHistoricalViewForRange(rootID, nil, nil)
+	if err == ErrRootIDNotPresent {
+		return nil, fmt.Errorf("%w: %s", ErrRootIDNotPresent, rootID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Track the view so it is invalidated when the database changes, as it
+	// reads any node that isn't in the history from the database.
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.childViews = append(db.childViews, view)
+	return view, nil
+}
+
+// historicalView wraps a trieView to prevent it from being modified.
+//
+// The wrapped trieView is invalidated when a change is committed to [db], so
+// it is rebuilt from the history the next time it is read.
+type historicalView struct {
+	db     *Database
+	rootID ids.ID
+
+	// Must be held while reading/writing [view].
+	lock sync.Mutex
+	view *trieView
+}
+
+// Returns the trieView at [v.rootID], rebuilding it if a commit invalidated
+// it. Returns [ErrRootIDNotPresent] if [v.rootID] has aged out of the history,
+// in which case the view must be re-opened at a more recent root.
+// Assumes [v.db.commitLock] is read locked.
+func (v *historicalView) getView() (*trieView, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if !v.view.isInvalid() {
+		return v.view, nil
+	}
+
+	view, err := v.db.newHistoricalView(v.rootID)
+	if errors.Is(err, ErrRootIDNotPresent) {
+		return nil, fmt.Errorf("%w; the historical view must be re-opened at a more recent root", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.view = view
+	return view, nil
+}
+
+func (v *historicalView) GetValue(ctx context.Context, key []byte) ([]byte, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetValue(ctx, key)
+}
+
+func (v *historicalView) GetValues(ctx context.Context, keys [][]byte) ([][]byte, []error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		errs := make([]error, len(keys))
+		for i := range errs {
+			errs[i] = err
+		}
+		return make([][]byte, len(keys)), errs
+	}
+	return view.GetValues(ctx, keys)
+}
+
+func (v *historicalView) getValue(key path, lock bool) ([]byte, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.getValue(key, lock)
+}
+
+func (v *historicalView) GetMerkleRoot(ctx context.Context) (ids.ID, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return ids.Empty, err
+	}
+	return view.GetMerkleRoot(ctx)
+}
+
+func (v *historicalView) getEditableNode(key path) (*node, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.getEditableNode(key)
+}
+
+func (v *historicalView) GetProof(ctx context.Context, key []byte) (*Proof, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetProof(ctx, key)
+}
+
+func (v *historicalView) GetMultiProof(ctx context.Context, keys [][]byte) (*MultiProof, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetMultiProof(ctx, keys)
+}
+
+func (v *historicalView) GetRangeProof(ctx context.Context, start, end []byte, maxLength int) (*RangeProof, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetRangeProof(ctx, start, end, maxLength)
+}
+
+func (v *historicalView) getKeyValues(
+	start []byte,
+	end []byte,
+	maxLength int,
+	keysToIgnore set.Set[string],
+	lock bool,
+) ([]KeyValue, error) {
+	v.db.commitLock.RLock()
+	defer v.db.commitLock.RUnlock()
+
+	view, err := v.getView()
+	if err != nil {
+		return nil, err
+	}
+	return view.getKeyValues(start, end, maxLength, keysToIgnore, lock)
+}
+
+func (v *historicalView) NewIterator() database.Iterator {
+	return v.NewIteratorWithStartAndPrefix(nil, nil)
+}
+
+func (v *historicalView) NewIteratorWithStart(start []byte) database.Iterator {
+	return v.NewIteratorWithStartAndPrefix(start, nil)
+}
+
+func (v *historicalView) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return v.NewIteratorWithStartAndPrefix(nil, prefix)
+}
+
+func (v *historicalView) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+	return &historicalIterator{
+		view:      v,
+		nextStart: slices.Clone(start),
+		prefix:    slices.Clone(prefix),
+	}
+}
+
+// historicalIterator iterates over the key/value pairs in a view by reading
+// them in batches of [historicalIteratorBatchSize].
+type historicalIterator struct {
+	view *historicalView
+
+	// The smallest key that may be in the next batch.
+	nextStart []byte
+	prefix    []byte
+
+	batch []KeyValue
+	// Index of the current key/value pair in [batch].
+	index int
+	// True iff there are no key/value pairs after [batch].
+	exhausted bool
+	err       error
+}
+
+func (it *historicalIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	if it.index >= len(it.batch) {
+		if it.exhausted || !it.readBatch() {
+			it.batch = nil
+			return false
+		}
+	}
+
+	if !bytes.HasPrefix(it.batch[it.index].Key, it.prefix) {
+		// Keys are iterated in order, so no later key has the prefix.
+		it.batch = nil
+		it.exhausted = true
+		return false
+	}
+	return true
+}
+
+// Reads the next batch of key/value pairs into [it.batch].
+// Returns false if there are no more key/value pairs or an error occurred.
+func (it *historicalIterator) readBatch() bool {
+	batch, err := it.view.getKeyValues(
+		it.nextStart,
+		nil,
+		historicalIteratorBatchSize,
+		set.Set[string]{},
+		true, /*lock*/
+	)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.batch = batch
+	it.index = 0
+	it.exhausted = len(batch) < historicalIteratorBatchSize
+	if len(batch) == 0 {
+		return false
+	}
+
+	// The next batch starts at the smallest key after the last key in this
+	// batch.
+	lastKey := batch[len(batch)-1].Key
+	it.nextStart = make([]byte, len(lastKey)+1)
+	copy(it.nextStart, lastKey)
+	return true
+}
+
+func (it *historicalIterator) Error() error {
+	return it.err
+}
+
+func (it *historicalIterator) Key() []byte {
+	if it.index >= len(it.batch) {
+		return nil
+	}
+	return it.batch[it.index].Key
+}
+
+func (it *historicalIterator) Value() []byte {
+	if it.index >= len(it.batch) {
+		return nil
+	}
+	return it.batch[it.index].Value
+}
+
+func (it *historicalIterator) Release() {
+	it.batch = nil
+	it.exhausted = true
+}
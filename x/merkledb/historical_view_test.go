@@ -0,0 +1,229 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+func TestNewViewAtRoot(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	require.NoError(db.Put([]byte("key1"), []byte("value1")))
+	require.NoError(db.Put([]byte("key2"), []byte("value2")))
+	oldRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	batch := db.NewBatch()
+	require.NoError(batch.Put([]byte("key1"), []byte("newValue1")))
+	require.NoError(batch.Delete([]byte("key2")))
+	require.NoError(batch.Put([]byte("key3"), []byte("value3")))
+	require.NoError(batch.Write())
+
+	view, err := db.NewViewAtRoot(oldRoot)
+	require.NoError(err)
+
+	root, err := view.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(oldRoot, root)
+
+	value, err := view.GetValue(context.Background(), []byte("key1"))
+	require.NoError(err)
+	require.Equal([]byte("value1"), value)
+
+	values, errs := view.GetValues(context.Background(), [][]byte{[]byte("key2"), []byte("key3")})
+	require.Equal([][]byte{[]byte("value2"), nil}, values)
+	require.NoError(errs[0])
+	require.ErrorIs(errs[1], database.ErrNotFound)
+
+	proof, err := view.GetProof(context.Background(), []byte("key2"))
+	require.NoError(err)
+	require.NoError(proof.Verify(context.Background(), oldRoot, BranchFactor16, SHA256Hasher))
+
+	proof, err = view.GetProof(context.Background(), []byte("key3"))
+	require.NoError(err)
+	require.Nil(proof.Value.value)
+	require.NoError(proof.Verify(context.Background(), oldRoot, BranchFactor16, SHA256Hasher))
+
+	it := view.NewIterator()
+	defer it.Release()
+
+	require.True(it.Next())
+	require.Equal([]byte("key1"), it.Key())
+	require.Equal([]byte("value1"), it.Value())
+	require.True(it.Next())
+	require.Equal([]byte("key2"), it.Key())
+	require.Equal([]byte("value2"), it.Value())
+	require.False(it.Next())
+	require.NoError(it.Error())
+
+	// The current state of the database is unaffected.
+	value, err = db.Get([]byte("key1"))
+	require.NoError(err)
+	require.Equal([]byte("newValue1"), value)
+}
+
+func TestNewViewAtRootIterator(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	// Write more keys than are read by the iterator at a time.
+	const numKeys = 2*historicalIteratorBatchSize + 1
+	batch := db.NewBatch()
+	for i := 0; i < numKeys; i++ {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, uint32(i))
+		require.NoError(batch.Put(key, key))
+	}
+	require.NoError(batch.Write())
+	oldRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	// Delete every other key and change the rest.
+	batch = db.NewBatch()
+	for i := 0; i < numKeys; i++ {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, uint32(i))
+		if i%2 == 0 {
+			require.NoError(batch.Delete(key))
+		} else {
+			require.NoError(batch.Put(key, []byte{1}))
+		}
+	}
+	require.NoError(batch.Write())
+
+	view, err := db.NewViewAtRoot(oldRoot)
+	require.NoError(err)
+
+	it := view.NewIterator()
+	defer it.Release()
+
+	i := 0
+	for it.Next() {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, uint32(i))
+		require.Equal(key, it.Key())
+		require.Equal(key, it.Value())
+		i++
+	}
+	require.NoError(it.Error())
+	require.Equal(numKeys, i)
+
+	// Keys with the prefix {0, 0, 1} are [256, 511].
+	prefixIt := view.NewIteratorWithStartAndPrefix([]byte{0, 0, 1, 16}, []byte{0, 0, 1})
+	defer prefixIt.Release()
+
+	i = 256 + 16
+	for prefixIt.Next() {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, uint32(i))
+		require.Equal(key, prefixIt.Key())
+		i++
+	}
+	require.NoError(prefixIt.Error())
+	require.Equal(512, i)
+}
+
+func TestNewViewAtRootAfterCommit(t *testing.T) {
+	require := require.New(t)
+
+	db, err := New(
+		context.Background(),
+		memdb.New(),
+		Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 3,
+			NodeCacheSize: minCacheSize,
+		},
+	)
+	require.NoError(err)
+
+	require.NoError(db.Put([]byte("key"), []byte("value")))
+	oldRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	view, err := db.NewViewAtRoot(oldRoot)
+	require.NoError(err)
+
+	// Committing to the database doesn't invalidate the view.
+	require.NoError(db.Put([]byte("key"), []byte("newValue")))
+	require.NoError(db.Put([]byte("otherKey"), []byte("value")))
+
+	value, err := view.GetValue(context.Background(), []byte("key"))
+	require.NoError(err)
+	require.Equal([]byte("value"), value)
+
+	_, err = view.GetValue(context.Background(), []byte("otherKey"))
+	require.ErrorIs(err, database.ErrNotFound)
+
+	root, err := view.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(oldRoot, root)
+
+	it := view.NewIterator()
+	require.True(it.Next())
+	require.Equal([]byte("key"), it.Key())
+	require.Equal([]byte("value"), it.Value())
+	require.False(it.Next())
+	require.NoError(it.Error())
+	it.Release()
+
+	// Once [oldRoot] ages out of the history, the view must be re-opened.
+	require.NoError(db.Put([]byte("key"), []byte("value1")))
+	require.NoError(db.Put([]byte("key"), []byte("value2")))
+
+	_, err = view.GetValue(context.Background(), []byte("key"))
+	require.ErrorIs(err, ErrRootIDNotPresent)
+
+	it = view.NewIterator()
+	defer it.Release()
+	require.False(it.Next())
+	require.ErrorIs(it.Error(), ErrRootIDNotPresent)
+}
+
+func TestNewViewAtRootNotInHistory(t *testing.T) {
+	require := require.New(t)
+
+	db, err := New(
+		context.Background(),
+		memdb.New(),
+		Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 2,
+			NodeCacheSize: minCacheSize,
+		},
+	)
+	require.NoError(err)
+
+	require.NoError(db.Put([]byte("key"), []byte("value")))
+	oldRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	// Age [oldRoot] out of the history.
+	require.NoError(db.Put([]byte("key"), []byte("value1")))
+	require.NoError(db.Put([]byte("key"), []byte("value2")))
+
+	_, err = db.NewViewAtRoot(oldRoot)
+	require.ErrorIs(err, ErrRootIDNotPresent)
+
+	_, err = db.NewViewAtRoot(ids.GenerateTestID())
+	require.ErrorIs(err, ErrRootIDNotPresent)
+
+	require.NoError(db.Close())
+	_, err = db.NewViewAtRoot(oldRoot)
+	require.ErrorIs(err, database.ErrClosed)
+}
@@ -32,6 +32,9 @@ type trieHistory struct {
 	history *btree.BTreeG[*changeSummaryAndIndex]
 
 	nextIndex uint64
+
+	// The branch factor of the trie whose changes are stored.
+	branchFactor BranchFactor
 }
 
 // Tracks the beginning and ending state of a value.
@@ -65,9 +68,10 @@ func newChangeSummary(estimatedSize int) *changeSummary {
 	}
 }
 
-func newTrieHistory(maxHistoryLookback int) *trieHistory {
+func newTrieHistory(maxHistoryLookback int, branchFactor BranchFactor) *trieHistory {
 	return &trieHistory{
 		maxHistoryLen: maxHistoryLookback,
+		branchFactor:  branchFactor,
 		history: btree.NewG(
 			2,
 			func(a, b *changeSummaryAndIndex) bool {
@@ -125,25 +129,9 @@ func (th *trieHistory) getValueChanges(startRoot, endRoot ids.ID, start, end []b
 		}
 	}
 
-	// Keep changes sorted so the largest can be removed in order to stay within the maxLength limit.
-	sortedKeys := btree.NewG(
-		2,
-		func(a, b path) bool {
-			return a.Compare(b) < 0
-		},
-	)
-
-	startPath := newPath(start)
-	endPath := newPath(end)
-
-	// For each element in the history in the range between [startRoot]'s
-	// last appearance (exclusive) and [endRoot]'s last appearance (inclusive),
-	// add the changes to keys in [start, end] to [combinedChanges].
-	// Only the key-value pairs with the greatest [maxLength] keys will be kept.
-	combinedChanges := newChangeSummary(maxLength)
-
 	// For each change after [startRootChanges] up to and including
 	// [lastEndRootChange], record the change in [combinedChanges].
+	combinedChanges := newValueChangeCombiner(start, end, maxLength, th.branchFactor)
 	th.history.AscendGreaterOrEqual(
 		startRootChanges,
 		func(item *changeSummaryAndIndex) bool {
@@ -157,41 +145,111 @@ func (th *trieHistory) getValueChanges(startRoot, endRoot ids.ID, start, end []b
 			}
 
 			// Add the changes from this commit to [combinedChanges].
-			for key, valueChange := range item.values {
-				if (len(startPath) == 0 || key.Compare(startPath) >= 0) &&
-					(len(endPath) == 0 || key.Compare(endPath) <= 0) {
-					// The key is in the range [start, end].
-					if existing, ok := combinedChanges.values[key]; ok {
-						// A change to this key already exists in [combinedChanges].
-						existing.after = valueChange.after
-						if existing.before.hasValue == existing.after.hasValue &&
-							bytes.Equal(existing.before.value, existing.after.value) {
-							// The change to this key is a no-op, so remove it from [combinedChanges].
-							delete(combinedChanges.values, key)
-							sortedKeys.Delete(key)
-						}
-					} else {
-						combinedChanges.values[key] = &change[Maybe[[]byte]]{
-							before: valueChange.before,
-							after:  valueChange.after,
-						}
-						sortedKeys.ReplaceOrInsert(key)
-					}
-				}
-			}
-
+			combinedChanges.add(item.changeSummary)
 			return true
 		},
 	)
+	return combinedChanges.result(), nil
+}
 
-	// Keep only the smallest [maxLength] items in [combinedChanges.values].
-	for sortedKeys.Len() > maxLength {
-		if greatestKey, found := sortedKeys.DeleteMax(); found {
-			delete(combinedChanges.values, greatestKey)
+// valueChangeCombiner merges the value changes of consecutive change summaries
+// into a single change summary.
+type valueChangeCombiner struct {
+	startPath path
+	endPath   path
+	maxLength int
+
+	// Keep changes sorted so the largest can be removed in order to stay
+	// within the maxLength limit.
+	sortedKeys *btree.BTreeG[path]
+	changes    *changeSummary
+}
+
+// newValueChangeCombiner returns a combiner that only keeps the changes to
+// keys in [start, end]. Only the key-value pairs with the smallest
+// [maxLength] keys are returned by result.
+func newValueChangeCombiner(start, end []byte, maxLength int, branchFactor BranchFactor) *valueChangeCombiner {
+	return &valueChangeCombiner{
+		startPath: newPath(start, branchFactor),
+		endPath:   newPath(end, branchFactor),
+		maxLength: maxLength,
+		sortedKeys: btree.NewG(
+			2,
+			func(a, b path) bool {
+				return a.Compare(b) < 0
+			},
+		),
+		changes: newChangeSummary(maxLength),
+	}
+}
+
+// add the value changes in [item], which must have been made after every
+// change previously passed to add.
+func (c *valueChangeCombiner) add(item *changeSummary) {
+	for key, valueChange := range item.values {
+		if !isInRange(key, c.startPath, c.endPath) {
+			continue
+		}
+		if existing, ok := c.changes.values[key]; ok {
+			// A change to this key already exists in [c.changes].
+			existing.after = valueChange.after
+			if existing.before.hasValue == existing.after.hasValue &&
+				bytes.Equal(existing.before.value, existing.after.value) {
+				// The change to this key is a no-op, so remove it from [c.changes].
+				delete(c.changes.values, key)
+				c.sortedKeys.Delete(key)
+			}
+		} else {
+			c.changes.values[key] = &change[Maybe[[]byte]]{
+				before: valueChange.before,
+				after:  valueChange.after,
+			}
+			c.sortedKeys.ReplaceOrInsert(key)
 		}
 	}
+}
 
-	return combinedChanges, nil
+// result returns the combined changes.
+func (c *valueChangeCombiner) result() *changeSummary {
+	// Keep only the smallest [maxLength] items in [c.changes.values].
+	for c.sortedKeys.Len() > c.maxLength {
+		if greatestKey, found := c.sortedKeys.DeleteMax(); found {
+			delete(c.changes.values, greatestKey)
+		}
+	}
+	return c.changes
+}
+
+// Returns true iff [key] is in [startPath, endPath].
+// An empty [startPath] or [endPath] means the range is unbounded on that side.
+func isInRange(key, startPath, endPath path) bool {
+	return (len(startPath) == 0 || key.Compare(startPath) >= 0) &&
+		(len(endPath) == 0 || key.Compare(endPath) <= 0)
+}
+
+// addReversedChanges records in [combinedChanges] the changes that undo
+// [item] for keys in [startPath, endPath]. [item] must have been made before
+// every change previously passed to addReversedChanges with [combinedChanges].
+func addReversedChanges(combinedChanges *changeSummary, item *changeSummary, startPath, endPath path) {
+	for key, changedNode := range item.nodes {
+		combinedChanges.nodes[key] = &change[*node]{
+			after: changedNode.before,
+		}
+	}
+
+	for key, valueChange := range item.values {
+		if !isInRange(key, startPath, endPath) {
+			continue
+		}
+		if existing, ok := combinedChanges.values[key]; ok {
+			existing.after = valueChange.before
+		} else {
+			combinedChanges.values[key] = &change[Maybe[[]byte]]{
+				before: valueChange.after,
+				after:  valueChange.before,
+			}
+		}
+	}
 }
 
 // Returns the changes to go from the current trie state back to the requested [rootID]
@@ -206,8 +264,8 @@ func (th *trieHistory) getChangesToGetToRoot(rootID ids.ID, start, end []byte) (
 	}
 
 	var (
-		startPath       = newPath(start)
-		endPath         = newPath(end)
+		startPath       = newPath(start, th.branchFactor)
+		endPath         = newPath(end, th.branchFactor)
 		combinedChanges = newChangeSummary(defaultPreallocationSize)
 	)
 
@@ -219,25 +277,7 @@ func (th *trieHistory) getChangesToGetToRoot(rootID ids.ID, start, end []byte) (
 			if item == lastRootChange {
 				return false
 			}
-			for key, changedNode := range item.nodes {
-				combinedChanges.nodes[key] = &change[*node]{
-					after: changedNode.before,
-				}
-			}
-
-			for key, valueChange := range item.values {
-				if (len(startPath) == 0 || key.Compare(startPath) >= 0) &&
-					(len(endPath) == 0 || key.Compare(endPath) <= 0) {
-					if existing, ok := combinedChanges.values[key]; ok {
-						existing.after = valueChange.before
-					} else {
-						combinedChanges.values[key] = &change[Maybe[[]byte]]{
-							before: valueChange.after,
-							after:  valueChange.before,
-						}
-					}
-				}
-			}
+			addReversedChanges(combinedChanges, item.changeSummary, startPath, endPath)
 			return true
 		},
 	)
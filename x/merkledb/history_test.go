@@ -41,7 +41,7 @@ func Test_History_Simple(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -52,7 +52,7 @@ func Test_History_Simple(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -65,7 +65,7 @@ func Test_History_Simple(t *testing.T) {
 	newProof, err = db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -77,7 +77,7 @@ func Test_History_Simple(t *testing.T) {
 	newProof, err = db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -104,7 +104,7 @@ func Test_History_Simple(t *testing.T) {
 	newProof, err = db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -162,7 +162,7 @@ func Test_History_Large(t *testing.T) {
 		require.NoError(err)
 		require.NotNil(proof)
 
-		err = proof.Verify(context.Background(), nil, nil, roots[0])
+		err = proof.Verify(context.Background(), nil, nil, roots[0], BranchFactor16, SHA256Hasher)
 		require.NoError(err)
 	}
 }
@@ -273,6 +273,8 @@ func Test_History_Trigger_History_Queue_Looping(t *testing.T) {
 		[]byte("k"),
 		[]byte("key3"),
 		origRootID,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(err)
 
@@ -292,6 +294,8 @@ func Test_History_Trigger_History_Queue_Looping(t *testing.T) {
 		[]byte("k"),
 		[]byte("key3"),
 		origRootID,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(err)
 
@@ -361,10 +365,10 @@ func Test_History_Values_Lookup_Over_Queue_Break(t *testing.T) {
 	// changes should still be collectable even though the history has had to loop due to hitting max size
 	changes, err := db.history.getValueChanges(startRoot, endRoot, nil, nil, 10)
 	require.NoError(err)
-	require.Contains(changes.values, newPath([]byte("key1")))
-	require.Equal([]byte("value1"), changes.values[newPath([]byte("key1"))].after.value)
-	require.Contains(changes.values, newPath([]byte("key2")))
-	require.Equal([]byte("value3"), changes.values[newPath([]byte("key2"))].after.value)
+	require.Contains(changes.values, newPath([]byte("key1"), BranchFactor16))
+	require.Equal([]byte("value1"), changes.values[newPath([]byte("key1"), BranchFactor16)].after.value)
+	require.Contains(changes.values, newPath([]byte("key2"), BranchFactor16))
+	require.Equal([]byte("value3"), changes.values[newPath([]byte("key2"), BranchFactor16)].after.value)
 }
 
 func Test_History_RepeatedRoot(t *testing.T) {
@@ -394,7 +398,7 @@ func Test_History_RepeatedRoot(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -409,7 +413,7 @@ func Test_History_RepeatedRoot(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	// revert state to be the same as in orig proof
@@ -426,7 +430,7 @@ func Test_History_RepeatedRoot(t *testing.T) {
 	newProof, err = db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -453,7 +457,7 @@ func Test_History_ExcessDeletes(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -472,7 +476,7 @@ func Test_History_ExcessDeletes(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -499,7 +503,7 @@ func Test_History_DontIncludeAllNodes(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -510,7 +514,7 @@ func Test_History_DontIncludeAllNodes(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -537,7 +541,7 @@ func Test_History_Branching2Nodes(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -548,7 +552,7 @@ func Test_History_Branching2Nodes(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -575,7 +579,7 @@ func Test_History_Branching3Nodes(t *testing.T) {
 	require.NoError(err)
 	require.NotNil(origProof)
 	origRootID := db.root.id
-	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = origProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 
 	batch = db.NewBatch()
@@ -586,7 +590,7 @@ func Test_History_Branching3Nodes(t *testing.T) {
 	newProof, err := db.GetRangeProofAtRoot(context.Background(), origRootID, []byte("k"), []byte("key3"), 10)
 	require.NoError(err)
 	require.NotNil(newProof)
-	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID)
+	err = newProof.Verify(context.Background(), []byte("k"), []byte("key3"), origRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(err)
 }
 
@@ -699,7 +703,7 @@ func TestHistoryRecord(t *testing.T) {
 	require := require.New(t)
 
 	maxHistoryLen := 3
-	th := newTrieHistory(maxHistoryLen)
+	th := newTrieHistory(maxHistoryLen, BranchFactor16)
 
 	changes := []*changeSummary{}
 	for i := 0; i < maxHistoryLen; i++ { // Fill the history
@@ -764,20 +768,20 @@ func TestHistoryRecord(t *testing.T) {
 
 func TestHistoryGetChangesToRoot(t *testing.T) {
 	maxHistoryLen := 3
-	history := newTrieHistory(maxHistoryLen)
+	history := newTrieHistory(maxHistoryLen, BranchFactor16)
 
 	changes := []*changeSummary{}
 	for i := 0; i < maxHistoryLen; i++ { // Fill the history
 		changes = append(changes, &changeSummary{
 			rootID: ids.GenerateTestID(),
 			nodes: map[path]*change[*node]{
-				newPath([]byte{byte(i)}): {
+				newPath([]byte{byte(i)}, BranchFactor16): {
 					before: &node{id: ids.GenerateTestID()},
 					after:  &node{id: ids.GenerateTestID()},
 				},
 			},
 			values: map[path]*change[Maybe[[]byte]]{
-				newPath([]byte{byte(i)}): {
+				newPath([]byte{byte(i)}, BranchFactor16): {
 					before: Some([]byte{byte(i)}),
 					after:  Some([]byte{byte(i + 1)}),
 				},
@@ -816,7 +820,7 @@ func TestHistoryGetChangesToRoot(t *testing.T) {
 				require.Len(got.nodes, 1)
 				require.Len(got.values, 1)
 				reversedChanges := changes[maxHistoryLen-1]
-				removedKey := newPath([]byte{byte(maxHistoryLen - 1)})
+				removedKey := newPath([]byte{byte(maxHistoryLen - 1)}, BranchFactor16)
 				require.Equal(reversedChanges.nodes[removedKey].before, got.nodes[removedKey].after)
 				require.Equal(reversedChanges.values[removedKey].before, got.values[removedKey].after)
 				require.Equal(reversedChanges.values[removedKey].after, got.values[removedKey].before)
@@ -829,12 +833,12 @@ func TestHistoryGetChangesToRoot(t *testing.T) {
 				require.Len(got.nodes, 2)
 				require.Len(got.values, 2)
 				reversedChanges1 := changes[maxHistoryLen-1]
-				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)})
+				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)}, BranchFactor16)
 				require.Equal(reversedChanges1.nodes[removedKey1].before, got.nodes[removedKey1].after)
 				require.Equal(reversedChanges1.values[removedKey1].before, got.values[removedKey1].after)
 				require.Equal(reversedChanges1.values[removedKey1].after, got.values[removedKey1].before)
 				reversedChanges2 := changes[maxHistoryLen-2]
-				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)})
+				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)}, BranchFactor16)
 				require.Equal(reversedChanges2.nodes[removedKey2].before, got.nodes[removedKey2].after)
 				require.Equal(reversedChanges2.values[removedKey2].before, got.values[removedKey2].after)
 				require.Equal(reversedChanges2.values[removedKey2].after, got.values[removedKey2].before)
@@ -848,12 +852,12 @@ func TestHistoryGetChangesToRoot(t *testing.T) {
 				require.Len(got.nodes, 2)
 				require.Len(got.values, 1)
 				reversedChanges1 := changes[maxHistoryLen-1]
-				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)})
+				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)}, BranchFactor16)
 				require.Equal(reversedChanges1.nodes[removedKey1].before, got.nodes[removedKey1].after)
 				require.Equal(reversedChanges1.values[removedKey1].before, got.values[removedKey1].after)
 				require.Equal(reversedChanges1.values[removedKey1].after, got.values[removedKey1].before)
 				reversedChanges2 := changes[maxHistoryLen-2]
-				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)})
+				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)}, BranchFactor16)
 				require.Equal(reversedChanges2.nodes[removedKey2].before, got.nodes[removedKey2].after)
 			},
 		},
@@ -865,10 +869,10 @@ func TestHistoryGetChangesToRoot(t *testing.T) {
 				require.Len(got.nodes, 2)
 				require.Len(got.values, 1)
 				reversedChanges1 := changes[maxHistoryLen-1]
-				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)})
+				removedKey1 := newPath([]byte{byte(maxHistoryLen - 1)}, BranchFactor16)
 				require.Equal(reversedChanges1.nodes[removedKey1].before, got.nodes[removedKey1].after)
 				reversedChanges2 := changes[maxHistoryLen-2]
-				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)})
+				removedKey2 := newPath([]byte{byte(maxHistoryLen - 2)}, BranchFactor16)
 				require.Equal(reversedChanges2.nodes[removedKey2].before, got.nodes[removedKey2].after)
 				require.Equal(reversedChanges2.values[removedKey2].before, got.values[removedKey2].after)
 				require.Equal(reversedChanges2.values[removedKey2].after, got.values[removedKey2].before)
@@ -26,6 +26,10 @@ type merkleMetrics interface {
 	ViewNodeCacheMiss()
 	ViewValueCacheHit()
 	ViewValueCacheMiss()
+	HistoryMemoryHit()
+	HistoryDiskHit()
+	HistoryMiss()
+	RebuiltNode()
 }
 
 type mockMetrics struct {
@@ -39,6 +43,10 @@ type mockMetrics struct {
 	viewNodeCacheMiss  int64
 	viewValueCacheHit  int64
 	viewValueCacheMiss int64
+	historyMemoryHit   int64
+	historyDiskHit     int64
+	historyMiss        int64
+	rebuiltNodes       int64
 }
 
 func (m *mockMetrics) HashCalculated() {
@@ -104,6 +112,34 @@ func (m *mockMetrics) DBNodeCacheMiss() {
 	m.dbNodeCacheMiss++
 }
 
+func (m *mockMetrics) HistoryMemoryHit() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.historyMemoryHit++
+}
+
+func (m *mockMetrics) HistoryDiskHit() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.historyDiskHit++
+}
+
+func (m *mockMetrics) HistoryMiss() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.historyMiss++
+}
+
+func (m *mockMetrics) RebuiltNode() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.rebuiltNodes++
+}
+
 type metrics struct {
 	ioKeyWrite         prometheus.Counter
 	ioKeyRead          prometheus.Counter
@@ -114,6 +150,10 @@ type metrics struct {
 	viewNodeCacheMiss  prometheus.Counter
 	viewValueCacheHit  prometheus.Counter
 	viewValueCacheMiss prometheus.Counter
+	historyMemoryHit   prometheus.Counter
+	historyDiskHit     prometheus.Counter
+	historyMiss        prometheus.Counter
+	rebuiltNodes       prometheus.Counter
 }
 
 func newMetrics(namespace string, reg prometheus.Registerer) (merkleMetrics, error) {
@@ -166,6 +206,26 @@ func newMetrics(namespace string, reg prometheus.Registerer) (merkleMetrics, err
 			Name:      "view_value_cache_miss",
 			Help:      "cumulative amount of misses on the view value cache",
 		}),
+		historyMemoryHit: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "history_memory_hit",
+			Help:      "cumulative amount of history lookups served from memory",
+		}),
+		historyDiskHit: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "history_disk_hit",
+			Help:      "cumulative amount of history lookups served from disk",
+		}),
+		historyMiss: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "history_miss",
+			Help:      "cumulative amount of history lookups for roots that weren't in the history",
+		}),
+		rebuiltNodes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rebuilt_nodes",
+			Help:      "cumulative amount of intermediate nodes regenerated after an unclean shutdown",
+		}),
 	}
 	errs := wrappers.Errs{}
 	errs.Add(
@@ -178,6 +238,10 @@ func newMetrics(namespace string, reg prometheus.Registerer) (merkleMetrics, err
 		reg.Register(m.viewNodeCacheMiss),
 		reg.Register(m.viewValueCacheHit),
 		reg.Register(m.viewValueCacheMiss),
+		reg.Register(m.historyMemoryHit),
+		reg.Register(m.historyDiskHit),
+		reg.Register(m.historyMiss),
+		reg.Register(m.rebuiltNodes),
 	)
 	return &m, errs.Err
 }
@@ -217,3 +281,19 @@ func (m *metrics) DBNodeCacheHit() {
 func (m *metrics) DBNodeCacheMiss() {
 	m.dbNodeCacheMiss.Inc()
 }
+
+func (m *metrics) HistoryMemoryHit() {
+	m.historyMemoryHit.Inc()
+}
+
+func (m *metrics) HistoryDiskHit() {
+	m.historyDiskHit.Inc()
+}
+
+func (m *metrics) HistoryMiss() {
+	m.historyMiss.Inc()
+}
+
+func (m *metrics) RebuiltNode() {
+	m.rebuiltNodes.Inc()
+}
@@ -7,19 +7,15 @@ import (
 	"golang.org/x/exp/maps"
 
 	"github.com/memeticofficial/pepecoingo/ids"
-	"github.com/memeticofficial/pepecoingo/utils/hashing"
 )
 
-const (
-	NodeBranchFactor = 16
-	HashLength       = 32
-)
+const HashLength = 32
 
 // the values that go into the node's id
 type hashValues struct {
 	Children map[byte]child
 	Value    Maybe[[]byte]
-	Key      SerializedPath
+	Key      path
 }
 
 // Representation of a node stored in the database.
@@ -47,7 +43,7 @@ type node struct {
 func newNode(parent *node, key path) *node {
 	newNode := &node{
 		dbNode: dbNode{
-			children: make(map[byte]child, NodeBranchFactor),
+			children: make(map[byte]child),
 		},
 		key: key,
 	}
@@ -58,9 +54,10 @@ func newNode(parent *node, key path) *node {
 }
 
 // Parse [nodeBytes] to a node and set its key to [key].
-func parseNode(key path, nodeBytes []byte) (*node, error) {
+// The digest of its value is calculated using [hasher].
+func parseNode(codec EncoderDecoder, hasher Hasher, key path, nodeBytes []byte) (*node, error) {
 	n := dbNode{}
-	if _, err := Codec.decodeDBNode(nodeBytes, &n); err != nil {
+	if _, err := codec.decodeDBNode(nodeBytes, &n); err != nil {
 		return nil, err
 	}
 	result := &node{
@@ -69,7 +66,7 @@ func parseNode(key path, nodeBytes []byte) (*node, error) {
 		nodeBytes: nodeBytes,
 	}
 
-	result.setValueDigest()
+	result.setValueDigest(hasher)
 	return result, nil
 }
 
@@ -79,12 +76,12 @@ func (n *node) hasValue() bool {
 }
 
 // Returns the byte representation of this node.
-func (n *node) marshal() ([]byte, error) {
+func (n *node) marshal(codec EncoderDecoder) ([]byte, error) {
 	if n.nodeBytes != nil {
 		return n.nodeBytes, nil
 	}
 
-	nodeBytes, err := Codec.encodeDBNode(Version, &(n.dbNode))
+	nodeBytes, err := codec.encodeDBNode(Version, &(n.dbNode))
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +97,7 @@ func (n *node) onNodeChanged() {
 }
 
 // Returns and caches the ID of this node.
-func (n *node) calculateID(metrics merkleMetrics) error {
+func (n *node) calculateID(codec EncoderDecoder, hasher Hasher, metrics merkleMetrics) error {
 	if n.id != ids.Empty {
 		return nil
 	}
@@ -108,32 +105,29 @@ func (n *node) calculateID(metrics merkleMetrics) error {
 	hv := &hashValues{
 		Children: n.children,
 		Value:    n.valueDigest,
-		Key:      n.key.Serialize(),
+		Key:      n.key,
 	}
 
-	bytes, err := Codec.encodeHashValues(Version, hv)
+	bytes, err := codec.encodeHashValues(Version, hv)
 	if err != nil {
 		return err
 	}
 
 	metrics.HashCalculated()
-	n.id = hashing.ComputeHash256Array(bytes)
+	n.id = hasher.hash(bytes)
 	return nil
 }
 
 // Set [n]'s value to [val].
-func (n *node) setValue(val Maybe[[]byte]) {
+// The digest of [val] is calculated using [hasher].
+func (n *node) setValue(hasher Hasher, val Maybe[[]byte]) {
 	n.onNodeChanged()
 	n.value = val
-	n.setValueDigest()
+	n.setValueDigest(hasher)
 }
 
-func (n *node) setValueDigest() {
-	if n.value.IsNothing() || len(n.value.value) < HashLength {
-		n.valueDigest = n.value
-	} else {
-		n.valueDigest = Some(hashing.ComputeHash256(n.value.value))
-	}
+func (n *node) setValueDigest(hasher Hasher) {
+	n.valueDigest = valueDigest(hasher, n.value)
 }
 
 // Adds [child] as a child of [n].
@@ -160,7 +154,7 @@ func (n *node) addChildWithoutNode(index byte, compressedPath path, childID ids.
 // Assumes this node has exactly one child.
 func (n *node) getSingleChildPath() path {
 	for index, entry := range n.children {
-		return n.key + path(index) + entry.compressedPath
+		return n.key.Append(index) + entry.compressedPath
 	}
 	return ""
 }
@@ -190,9 +184,9 @@ func (n *node) clone() *node {
 }
 
 // Returns the ProofNode representation of this node.
-func (n *node) asProofNode() ProofNode {
+func (n *node) asProofNode(bf BranchFactor) ProofNode {
 	pn := ProofNode{
-		KeyPath:     n.key.Serialize(),
+		KeyPath:     n.key.Serialize(bf),
 		Children:    make(map[byte]ids.ID, len(n.children)),
 		ValueOrHash: Clone(n.valueDigest),
 	}
@@ -201,3 +195,12 @@ func (n *node) asProofNode() ProofNode {
 	}
 	return pn
 }
+
+// Returns [value] if it's shorter than [HashLength] and its hash otherwise.
+func valueDigest(hasher Hasher, value Maybe[[]byte]) Maybe[[]byte] {
+	if value.IsNothing() || len(value.value) < HashLength {
+		return value
+	}
+	digest := hasher.hash(value.value)
+	return Some(digest[:])
+}
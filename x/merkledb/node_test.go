@@ -14,18 +14,18 @@ func Test_Node_Marshal(t *testing.T) {
 	root := newNode(nil, EmptyPath)
 	require.NotNil(t, root)
 
-	fullpath := newPath([]byte("key"))
+	fullpath := newPath([]byte("key"), BranchFactor16)
 	childNode := newNode(root, fullpath)
-	childNode.setValue(Some([]byte("value")))
+	childNode.setValue(SHA256Hasher, Some([]byte("value")))
 	require.NotNil(t, childNode)
 
-	err := childNode.calculateID(&mockMetrics{})
+	err := childNode.calculateID(Codec, SHA256Hasher, &mockMetrics{})
 	require.NoError(t, err)
 	root.addChild(childNode)
 
-	data, err := root.marshal()
+	data, err := root.marshal(Codec)
 	require.NoError(t, err)
-	rootParsed, err := parseNode(newPath([]byte("")), data)
+	rootParsed, err := parseNode(Codec, SHA256Hasher, newPath([]byte(""), BranchFactor16), data)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(rootParsed.children))
 
@@ -40,30 +40,30 @@ func Test_Node_Marshal_Errors(t *testing.T) {
 	root := newNode(nil, EmptyPath)
 	require.NotNil(t, root)
 
-	fullpath := newPath([]byte{255})
+	fullpath := newPath([]byte{255}, BranchFactor16)
 	childNode1 := newNode(root, fullpath)
-	childNode1.setValue(Some([]byte("value1")))
+	childNode1.setValue(SHA256Hasher, Some([]byte("value1")))
 	require.NotNil(t, childNode1)
 
-	err := childNode1.calculateID(&mockMetrics{})
+	err := childNode1.calculateID(Codec, SHA256Hasher, &mockMetrics{})
 	require.NoError(t, err)
 	root.addChild(childNode1)
 
-	fullpath = newPath([]byte{237})
+	fullpath = newPath([]byte{237}, BranchFactor16)
 	childNode2 := newNode(root, fullpath)
-	childNode2.setValue(Some([]byte("value2")))
+	childNode2.setValue(SHA256Hasher, Some([]byte("value2")))
 	require.NotNil(t, childNode2)
 
-	err = childNode2.calculateID(&mockMetrics{})
+	err = childNode2.calculateID(Codec, SHA256Hasher, &mockMetrics{})
 	require.NoError(t, err)
 	root.addChild(childNode2)
 
-	data, err := root.marshal()
+	data, err := root.marshal(Codec)
 	require.NoError(t, err)
 
 	for i := 1; i < len(data); i++ {
 		broken := data[:i]
-		_, err = parseNode(newPath([]byte("")), broken)
+		_, err = parseNode(Codec, SHA256Hasher, newPath([]byte(""), BranchFactor16), broken)
 		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
 	}
 }
@@ -5,6 +5,8 @@ package merkledb
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"unsafe"
@@ -12,36 +14,102 @@ import (
 
 const EmptyPath path = ""
 
+var ErrInvalidBranchFactor = errors.New("invalid branch factor")
+
+// BranchFactor is the maximum number of children of a node.
+// Each key is split into tokens of log2([BranchFactor]) bits, and each node
+// has at most one child per token value.
+type BranchFactor int
+
+const (
+	BranchFactor2   BranchFactor = 2
+	BranchFactor4   BranchFactor = 4
+	BranchFactor16  BranchFactor = 16
+	BranchFactor256 BranchFactor = 256
+
+	// DefaultBranchFactor is the branch factor used if none is specified.
+	DefaultBranchFactor = BranchFactor16
+)
+
+func (b BranchFactor) Valid() error {
+	switch b {
+	case BranchFactor2, BranchFactor4, BranchFactor16, BranchFactor256:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d", ErrInvalidBranchFactor, b)
+	}
+}
+
+// Returns the number of bits in each token.
+// Assumes [b] is valid.
+func (b BranchFactor) bitsPerToken() int {
+	switch b {
+	case BranchFactor2:
+		return 1
+	case BranchFactor4:
+		return 2
+	case BranchFactor16:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// TokensPerByte returns the number of tokens in each byte.
+// Assumes [b] is valid.
+func (b BranchFactor) TokensPerByte() int {
+	return 8 / b.bitsPerToken()
+}
+
+// Returns the number of bytes needed to hold [tokenLength] tokens.
+// Assumes [b] is valid.
+func (b BranchFactor) bytesNeeded(tokenLength int) int {
+	return (tokenLength*b.bitsPerToken() + 7) / 8
+}
+
+// Returns the number of bits to shift a byte right by to get the token at
+// [tokenIndex] within it.
+func (b BranchFactor) tokenShift(tokenIndex int) int {
+	bitsPerToken := b.bitsPerToken()
+	return 8 - bitsPerToken*(tokenIndex%b.TokensPerByte()+1)
+}
+
 // SerializedPath contains a path from the trie.
-// The trie branch factor is 16, so the path may contain an odd number of nibbles.
-// If it did contain an odd number of nibbles, the last 4 bits of the last byte should be discarded.
+// The path is a sequence of [NibbleLength] tokens, each of which is
+// log2(branch factor) bits, packed into [Value]. If the tokens don't fill the
+// last byte of [Value], its remaining bits must be 0.
 type SerializedPath struct {
+	// NibbleLength is the number of tokens in the path. The tokens are only
+	// nibbles if the branch factor is 16, which was the only branch factor
+	// before it became configurable.
 	NibbleLength int
 	Value        []byte
 }
 
-func (s SerializedPath) hasOddLength() bool {
-	return s.NibbleLength&1 == 1
+// Returns true iff the tokens of [s] don't fill the last byte of [s.Value].
+// Since keys are whole bytes, such a path can't be the path of a key.
+func (s SerializedPath) hasPartialByte(bf BranchFactor) bool {
+	return s.NibbleLength%bf.TokensPerByte() != 0
 }
 
 func (s SerializedPath) Equal(other SerializedPath) bool {
 	return s.NibbleLength == other.NibbleLength && bytes.Equal(s.Value, other.Value)
 }
 
-func (s SerializedPath) deserialize() path {
-	result := newPath(s.Value)
-	// trim the last nibble if the path has an odd length
-	return result[:len(result)-s.NibbleLength&1]
+func (s SerializedPath) deserialize(bf BranchFactor) path {
+	result := newPath(s.Value, bf)
+	// trim the tokens that pad the last byte
+	return result[:s.NibbleLength]
 }
 
 // HasPrefix returns true iff [prefix] is a prefix of [s] or equal to it.
-func (s SerializedPath) HasPrefix(prefix SerializedPath) bool {
+func (s SerializedPath) HasPrefix(prefix SerializedPath, bf BranchFactor) bool {
 	prefixValue := prefix.Value
 	prefixLength := len(prefix.Value)
 	if s.NibbleLength < prefix.NibbleLength || len(s.Value) < prefixLength {
 		return false
 	}
-	if !prefix.hasOddLength() {
+	if !prefix.hasPartialByte(bf) {
 		return bytes.HasPrefix(s.Value, prefixValue)
 	}
 	reducedSize := prefixLength - 1
@@ -51,38 +119,51 @@ func (s SerializedPath) HasPrefix(prefix SerializedPath) bool {
 		return false
 	}
 
-	// grab the last nibble in the prefix and serialized path
-	prefixRemainder := prefixValue[reducedSize] >> 4
-	valueRemainder := s.Value[reducedSize] >> 4
-	// s has prefix if the last nibbles are equal and s has every byte but the last of prefix as a prefix
+	// grab the tokens of the last byte of the prefix and the same bits of the
+	// serialized path
+	remainderBits := prefix.NibbleLength % bf.TokensPerByte() * bf.bitsPerToken()
+	mask := byte(0xFF) << (8 - remainderBits)
+	prefixRemainder := prefixValue[reducedSize] & mask
+	valueRemainder := s.Value[reducedSize] & mask
+	// s has prefix if the last tokens are equal and s has every byte but the last of prefix as a prefix
 	return valueRemainder == prefixRemainder && bytes.HasPrefix(s.Value, prefixValue[:reducedSize])
 }
 
 // Returns true iff [prefix] is a prefix of [s] but not equal to it.
-func (s SerializedPath) HasStrictPrefix(prefix SerializedPath) bool {
-	return s.HasPrefix(prefix) && !s.Equal(prefix)
+func (s SerializedPath) HasStrictPrefix(prefix SerializedPath, bf BranchFactor) bool {
+	return s.HasPrefix(prefix, bf) && !s.Equal(prefix)
 }
 
-func (s SerializedPath) NibbleVal(nibbleIndex int) byte {
-	value := s.Value[nibbleIndex>>1]
-	isOdd := byte(nibbleIndex & 1)
-	isEven := (1 - isOdd)
-
-	// return value first(even index) or last 4(odd index) bits of the corresponding byte
-	return isEven*value>>4 + isOdd*(value&0x0F)
+// Token returns the token at [tokenIndex].
+func (s SerializedPath) Token(tokenIndex int, bf BranchFactor) byte {
+	value := s.Value[tokenIndex/bf.TokensPerByte()]
+	return value >> bf.tokenShift(tokenIndex) & byte(bf-1)
 }
 
-func (s SerializedPath) AppendNibble(nibble byte) SerializedPath {
-	// even is 1 if even, 0 if odd
-	even := 1 - s.NibbleLength&1
-	value := make([]byte, len(s.Value)+even)
+// AppendToken returns [s] with [token] appended.
+func (s SerializedPath) AppendToken(token byte, bf BranchFactor) SerializedPath {
+	value := make([]byte, bf.bytesNeeded(s.NibbleLength+1))
 	copy(value, s.Value)
-
-	// shift the nibble 4 left if even, do nothing if odd
-	value[len(value)-1] += nibble << (4 * even)
+	value[len(value)-1] |= token << bf.tokenShift(s.NibbleLength)
 	return SerializedPath{Value: value, NibbleLength: s.NibbleLength + 1}
 }
 
+// NibbleVal returns the nibble at [nibbleIndex] of a path with a branch factor
+// of 16.
+//
+// Deprecated: Use [SerializedPath.Token].
+func (s SerializedPath) NibbleVal(nibbleIndex int) byte {
+	return s.Token(nibbleIndex, BranchFactor16)
+}
+
+// AppendNibble returns [s], which has a branch factor of 16, with [nibble]
+// appended.
+//
+// Deprecated: Use [SerializedPath.AppendToken].
+func (s SerializedPath) AppendNibble(nibble byte) SerializedPath {
+	return s.AppendToken(nibble, BranchFactor16)
+}
+
 type path string
 
 // Returns:
@@ -107,51 +188,52 @@ func (p path) HasPrefix(prefix path) bool {
 	return strings.HasPrefix(string(p), string(prefix))
 }
 
+// Returns true iff [p] isn't a whole number of bytes.
+// Since keys are whole bytes, such a path can't be the path of a key.
+func (p path) hasPartialByte(bf BranchFactor) bool {
+	return len(p)%bf.TokensPerByte() != 0
+}
+
 // Append [val] to [p].
 func (p path) Append(val byte) path {
-	return p + path(val)
+	// Note that path(val) would be the UTF-8 encoding of [val], which is
+	// multiple bytes if [val] >= 0x80.
+	return p + path([]byte{val})
 }
 
 // Returns the serialized representation of [p].
-func (p path) Serialize() SerializedPath {
-	// need half the number of bytes as nibbles
-	// add one so there is a byte for the odd nibble if it exists
-	// the extra nibble gets rounded down if even length
-	byteLength := (len(p) + 1) / 2
-
+func (p path) Serialize(bf BranchFactor) SerializedPath {
 	result := SerializedPath{
 		NibbleLength: len(p),
-		Value:        make([]byte, byteLength),
+		Value:        make([]byte, bf.bytesNeeded(len(p))),
 	}
-
-	// loop over the path's bytes
-	// if the length is odd, subtract 1 so we don't overflow on the p[pathIndex+1]
-	keyIndex := 0
-	lastIndex := len(p) - len(p)&1
-	for pathIndex := 0; pathIndex < lastIndex; pathIndex += 2 {
-		result.Value[keyIndex] = p[pathIndex]<<4 + p[pathIndex+1]
-		keyIndex++
+	if bf == BranchFactor256 {
+		copy(result.Value, p)
+		return result
 	}
 
-	// if there is was a odd number of nibbles, grab the last one
-	if result.hasOddLength() {
-		result.Value[keyIndex] = p[keyIndex<<1] << 4
+	tokensPerByte := bf.TokensPerByte()
+	for tokenIndex := 0; tokenIndex < len(p); tokenIndex++ {
+		result.Value[tokenIndex/tokensPerByte] |= p[tokenIndex] << bf.tokenShift(tokenIndex)
 	}
-
 	return result
 }
 
-func newPath(p []byte) path {
-	// create new buffer with double the length of the input since each byte gets split into two nibbles
-	buffer := make([]byte, 2*len(p))
-
-	// first nibble gets shifted right 4 (divided by 16) to isolate the first nibble
-	// second nibble gets bitwise anded with 0x0F (1111) to isolate the second nibble
-	bufferIndex := 0
-	for _, currentByte := range p {
-		buffer[bufferIndex] = currentByte >> 4
-		buffer[bufferIndex+1] = currentByte & 0x0F
-		bufferIndex += 2
+// Returns the path of [p], split into tokens of log2([bf]) bits.
+func newPath(p []byte, bf BranchFactor) path {
+	tokensPerByte := bf.TokensPerByte()
+	buffer := make([]byte, tokensPerByte*len(p))
+	if bf == BranchFactor256 {
+		copy(buffer, p)
+	} else {
+		mask := byte(bf - 1)
+		bufferIndex := 0
+		for _, currentByte := range p {
+			for i := 0; i < tokensPerByte; i++ {
+				buffer[bufferIndex] = currentByte >> bf.tokenShift(i) & mask
+				bufferIndex++
+			}
+		}
 	}
 
 	// avoid copying during the conversion
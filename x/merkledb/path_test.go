@@ -4,76 +4,92 @@
 package merkledb
 
 import (
+	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
-func Test_SerializedPath_NibbleVal(t *testing.T) {
+// The branch factors and hashers that tests are run with.
+var (
+	branchFactors = []BranchFactor{
+		BranchFactor2,
+		BranchFactor4,
+		BranchFactor16,
+		BranchFactor256,
+	}
+	hashers = []Hasher{
+		SHA256Hasher,
+		BLAKE2bHasher,
+	}
+)
+
+func Test_SerializedPath_Token(t *testing.T) {
 	path := SerializedPath{Value: []byte{240, 237}}
-	require.Equal(t, byte(15), path.NibbleVal(0))
-	require.Equal(t, byte(0), path.NibbleVal(1))
-	require.Equal(t, byte(14), path.NibbleVal(2))
-	require.Equal(t, byte(13), path.NibbleVal(3))
+	require.Equal(t, byte(15), path.Token(0, BranchFactor16))
+	require.Equal(t, byte(0), path.Token(1, BranchFactor16))
+	require.Equal(t, byte(14), path.Token(2, BranchFactor16))
+	require.Equal(t, byte(13), path.Token(3, BranchFactor16))
 }
 
-func Test_SerializedPath_AppendNibble(t *testing.T) {
+func Test_SerializedPath_AppendToken(t *testing.T) {
 	path := SerializedPath{Value: []byte{}}
 	require.Equal(t, 0, path.NibbleLength)
 
-	path = path.AppendNibble(1)
+	path = path.AppendToken(1, BranchFactor16)
 	require.Equal(t, 1, path.NibbleLength)
-	require.Equal(t, byte(1), path.NibbleVal(0))
+	require.Equal(t, byte(1), path.Token(0, BranchFactor16))
 
-	path = path.AppendNibble(2)
+	path = path.AppendToken(2, BranchFactor16)
 	require.Equal(t, 2, path.NibbleLength)
-	require.Equal(t, byte(2), path.NibbleVal(1))
+	require.Equal(t, byte(2), path.Token(1, BranchFactor16))
 }
 
 func Test_SerializedPath_Has_Prefix(t *testing.T) {
 	first := SerializedPath{Value: []byte("FirstKey")}
 	prefix := SerializedPath{Value: []byte("FirstKe")}
-	require.True(t, first.HasPrefix(prefix))
-	require.True(t, first.HasStrictPrefix(prefix))
+	require.True(t, first.HasPrefix(prefix, BranchFactor16))
+	require.True(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	first = SerializedPath{Value: []byte("FirstKey"), NibbleLength: 16}
 	prefix = SerializedPath{Value: []byte("FirstKey"), NibbleLength: 15}
-	require.True(t, first.HasPrefix(prefix))
-	require.True(t, first.HasStrictPrefix(prefix))
+	require.True(t, first.HasPrefix(prefix, BranchFactor16))
+	require.True(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	first = SerializedPath{Value: []byte("FirstKey"), NibbleLength: 15}
 	prefix = SerializedPath{Value: []byte("FirstKey"), NibbleLength: 15}
-	require.True(t, first.HasPrefix(prefix))
-	require.False(t, first.HasStrictPrefix(prefix))
+	require.True(t, first.HasPrefix(prefix, BranchFactor16))
+	require.False(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	first = SerializedPath{Value: []byte{247}, NibbleLength: 2}
 	prefix = SerializedPath{Value: []byte{240}, NibbleLength: 2}
-	require.False(t, first.HasPrefix(prefix))
-	require.False(t, first.HasStrictPrefix(prefix))
+	require.False(t, first.HasPrefix(prefix, BranchFactor16))
+	require.False(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	first = SerializedPath{Value: []byte{247}, NibbleLength: 2}
 	prefix = SerializedPath{Value: []byte{240}, NibbleLength: 1}
-	require.True(t, first.HasPrefix(prefix))
-	require.True(t, first.HasStrictPrefix(prefix))
+	require.True(t, first.HasPrefix(prefix, BranchFactor16))
+	require.True(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	first = SerializedPath{Value: []byte{}, NibbleLength: 0}
 	prefix = SerializedPath{Value: []byte{}, NibbleLength: 0}
-	require.True(t, first.HasPrefix(prefix))
-	require.False(t, first.HasStrictPrefix(prefix))
+	require.True(t, first.HasPrefix(prefix, BranchFactor16))
+	require.False(t, first.HasStrictPrefix(prefix, BranchFactor16))
 
 	a := SerializedPath{Value: []byte{0x10}, NibbleLength: 1}
 	b := SerializedPath{Value: []byte{0x10}, NibbleLength: 2}
-	require.False(t, a.HasPrefix(b))
+	require.False(t, a.HasPrefix(b, BranchFactor16))
 }
 
 func Test_SerializedPath_HasPrefix_BadInput(t *testing.T) {
 	a := SerializedPath{Value: []byte{}}
 	b := SerializedPath{Value: []byte{}, NibbleLength: 1}
-	require.False(t, a.HasPrefix(b))
+	require.False(t, a.HasPrefix(b, BranchFactor16))
 
 	a = SerializedPath{Value: []byte{}, NibbleLength: 10}
 	b = SerializedPath{Value: []byte{0x10}, NibbleLength: 1}
-	require.False(t, a.HasPrefix(b))
+	require.False(t, a.HasPrefix(b, BranchFactor16))
 }
 
 func Test_SerializedPath_Equal(t *testing.T) {
@@ -89,3 +105,57 @@ func Test_SerializedPath_Equal(t *testing.T) {
 	prefix = SerializedPath{Value: []byte("FirstKey"), NibbleLength: 15}
 	require.True(t, first.Equal(prefix))
 }
+
+func Test_Path_Serialize_BranchFactors(t *testing.T) {
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	for _, branchFactor := range branchFactors {
+		t.Run(fmt.Sprintf("%d", branchFactor), func(t *testing.T) {
+			require := require.New(t)
+
+			for i := 0; i < 100; i++ {
+				key := make([]byte, r.Intn(10))
+				_, _ = r.Read(key)
+
+				p := newPath(key, branchFactor)
+				require.Len(p, len(key)*branchFactor.TokensPerByte())
+
+				serialized := p.Serialize(branchFactor)
+				require.Equal(key, serialized.Value)
+				require.Equal(len(p), serialized.NibbleLength)
+				require.False(serialized.hasPartialByte(branchFactor))
+
+				// Each prefix of the path is a valid path.
+				for tokenLength := 0; tokenLength <= len(p); tokenLength++ {
+					prefix := p[:tokenLength]
+					serializedPrefix := prefix.Serialize(branchFactor)
+					require.Equal(prefix, serializedPrefix.deserialize(branchFactor))
+					require.Equal(prefix.hasPartialByte(branchFactor), serializedPrefix.hasPartialByte(branchFactor))
+					require.True(serialized.HasPrefix(serializedPrefix, branchFactor))
+					require.Equal(tokenLength < len(p), serialized.HasStrictPrefix(serializedPrefix, branchFactor))
+
+					for tokenIndex := 0; tokenIndex < tokenLength; tokenIndex++ {
+						require.Less(int(prefix[tokenIndex]), int(branchFactor))
+						require.Equal(prefix[tokenIndex], serializedPrefix.Token(tokenIndex, branchFactor))
+					}
+					if tokenLength < len(p) {
+						require.Equal(
+							p[:tokenLength+1].Serialize(branchFactor),
+							serializedPrefix.AppendToken(p[tokenLength], branchFactor),
+						)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_BranchFactor_Valid(t *testing.T) {
+	require := require.New(t)
+
+	for _, branchFactor := range branchFactors {
+		require.NoError(branchFactor.Valid())
+	}
+	for _, branchFactor := range []BranchFactor{0, 1, 3, 8, 32, 255, 512} {
+		require.ErrorIs(branchFactor.Valid(), ErrInvalidBranchFactor)
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/trace"
-	"github.com/memeticofficial/pepecoingo/utils/hashing"
 )
 
 const verificationCacheSize = 2_000
@@ -34,6 +33,12 @@ var (
 	ErrProofNodeNotForKey          = errors.New("the provided node has a key that is not a prefix of the specified key")
 	ErrProofValueDoesntMatch       = errors.New("the provided value does not match the proof node for the provided key's value")
 	ErrProofNodeHasUnincludedValue = errors.New("the provided proof has a value for a key within the range that is not present in the provided key/values")
+	ErrNoKeys                      = errors.New("no keys to prove")
+	ErrKeysValuesLengthMismatch    = errors.New("number of keys doesn't match the number of values")
+	ErrNoRootProofNode             = errors.New("first proof node isn't the root")
+	ErrUnsortedProofNodes          = errors.New("proof nodes aren't sorted by increasing key")
+	ErrUnlinkedProofNode           = errors.New("proof node isn't a child of the preceding proof nodes")
+	ErrMissingProofNode            = errors.New("proof is missing a node on the path to a key")
 )
 
 type ProofNode struct {
@@ -61,13 +66,25 @@ type Proof struct {
 
 // Returns nil if the trie given in [proof] has root [expectedRootID].
 // That is, this is a valid proof that [proof.Key] exists/doesn't exist
-// in the trie with root [expectedRootID].
-func (proof *Proof) Verify(ctx context.Context, expectedRootID ids.ID) error {
+// in the trie with root [expectedRootID], which has branch factor
+// [branchFactor] and computes node IDs with [hasher].
+func (proof *Proof) Verify(
+	ctx context.Context,
+	expectedRootID ids.ID,
+	branchFactor BranchFactor,
+	hasher Hasher,
+) error {
 	// Make sure the proof is well-formed.
 	if len(proof.Path) == 0 {
 		return ErrNoProof
 	}
-	if err := verifyProofPath(proof.Path, newPath(proof.Key)); err != nil {
+	if err := branchFactor.Valid(); err != nil {
+		return err
+	}
+	if err := hasher.Valid(); err != nil {
+		return err
+	}
+	if err := verifyProofPath(proof.Path, newPath(proof.Key, branchFactor), branchFactor); err != nil {
 		return err
 	}
 
@@ -76,24 +93,22 @@ func (proof *Proof) Verify(ctx context.Context, expectedRootID ids.ID) error {
 
 	// If the last proof node's key is [proof.Key] (i.e. this is an inclusion proof)
 	// then the value of the last proof node must match [proof.Value].
-	// Note odd length keys can never match the [proof.Key] since it's bytes,
-	// and thus an even number of nibbles.
-	if !lastNode.KeyPath.hasOddLength() &&
+	// Note keys with a partial byte can never match the [proof.Key] since
+	// it's bytes.
+	if !lastNode.KeyPath.hasPartialByte(branchFactor) &&
 		bytes.Equal(proof.Key, lastNode.KeyPath.Value) &&
-		!valueOrHashMatches(proof.Value, lastNode.ValueOrHash) {
+		!valueOrHashMatches(hasher, proof.Value, lastNode.ValueOrHash) {
 		return ErrProofValueDoesntMatch
 	}
 
-	// If the last proof node has an odd length or a different key than [proof.Key]
+	// If the last proof node has a partial byte or a different key than [proof.Key]
 	// then this is an exclusion proof and should prove that [proof.Key] isn't in the trie..
-	// Note odd length keys can never match the [proof.Key] since it's bytes,
-	// and thus an even number of nibbles.
-	if (lastNode.KeyPath.hasOddLength() || !bytes.Equal(proof.Key, lastNode.KeyPath.Value)) &&
+	if (lastNode.KeyPath.hasPartialByte(branchFactor) || !bytes.Equal(proof.Key, lastNode.KeyPath.Value)) &&
 		!proof.Value.IsNothing() {
 		return ErrProofValueDoesntMatch
 	}
 
-	view, err := getEmptyTrieView(ctx)
+	view, err := getEmptyTrieView(ctx, branchFactor, hasher)
 	if err != nil {
 		return err
 	}
@@ -101,10 +116,10 @@ func (proof *Proof) Verify(ctx context.Context, expectedRootID ids.ID) error {
 	// Insert all of the proof nodes.
 	// [provenPath] is the path that we are proving exists, or the path
 	// that is where the path we are proving doesn't exist should be.
-	provenPath := proof.Path[len(proof.Path)-1].KeyPath.deserialize()
+	provenPath := proof.Path[len(proof.Path)-1].KeyPath.deserialize(branchFactor)
 
 	// Don't bother locking [db] and [view] -- nobody else has a reference to them.
-	if err = addPathInfo(view, proof.Path, provenPath, provenPath); err != nil {
+	if err = addPathInfo(view, proof.Path, Some(provenPath), Some(provenPath)); err != nil {
 		return err
 	}
 
@@ -118,6 +133,181 @@ func (proof *Proof) Verify(ctx context.Context, expectedRootID ids.ID) error {
 	return nil
 }
 
+// A proof of the existence/non-existence of each of a set of keys.
+type MultiProof struct {
+	// The union of the proof paths of each key in [Keys].
+	// Sorted by increasing key, so the first node is the root.
+	// Each node is only included once, even if it is in the proof path of
+	// multiple keys.
+	Nodes []ProofNode
+	// The keys proven to exist/not exist.
+	// Sorted by increasing key.
+	Keys [][]byte
+	// Values[i] is Nothing if Keys[i] isn't in the trie.
+	// Otherwise it is the value corresponding to Keys[i].
+	Values []Maybe[[]byte]
+}
+
+// Returns nil if the trie given in [proof] has root [expectedRootID].
+// That is, this is a valid proof that each key in [proof.Keys] has the
+// corresponding value in [proof.Values], or doesn't exist if that value is
+// Nothing, in the trie with root [expectedRootID], which has branch factor
+// [branchFactor] and computes node IDs with [hasher].
+func (proof *MultiProof) Verify(
+	_ context.Context,
+	expectedRootID ids.ID,
+	branchFactor BranchFactor,
+	hasher Hasher,
+) error {
+	if err := branchFactor.Valid(); err != nil {
+		return err
+	}
+	if err := hasher.Valid(); err != nil {
+		return err
+	}
+
+	switch {
+	case len(proof.Keys) == 0:
+		return ErrNoKeys
+	case len(proof.Keys) != len(proof.Values):
+		return ErrKeysValuesLengthMismatch
+	case len(proof.Nodes) == 0:
+		return ErrNoProof
+	}
+	for i := 1; i < len(proof.Keys); i++ {
+		if bytes.Compare(proof.Keys[i-1], proof.Keys[i]) >= 0 {
+			return ErrNonIncreasingValues
+		}
+	}
+
+	codec := newCodec(branchFactor)
+	nodePaths := make([]path, len(proof.Nodes))
+	nodeIDs := make([]ids.ID, len(proof.Nodes))
+	for i, proofNode := range proof.Nodes {
+		nodePath := proofNode.KeyPath.deserialize(branchFactor)
+		switch {
+		case i == 0 && len(nodePath) != 0:
+			return ErrNoRootProofNode
+		case i > 0 && nodePaths[i-1].Compare(nodePath) >= 0:
+			return ErrUnsortedProofNodes
+		case nodePath.hasPartialByte(branchFactor) && !proofNode.ValueOrHash.IsNothing():
+			// a value cannot have a partial byte in its key
+			return ErrOddLengthWithValue
+		}
+		nodePaths[i] = nodePath
+
+		var err error
+		nodeIDs[i], err = proofNode.calculateID(nodePath, codec, hasher)
+		if err != nil {
+			return err
+		}
+	}
+	if nodeIDs[0] != expectedRootID {
+		return fmt.Errorf("%w:[%s], expected:[%s]", ErrInvalidProof, nodeIDs[0], expectedRootID)
+	}
+
+	// Since the nodes are sorted, each node's parent is the last preceding
+	// node whose key is a prefix of the node's key. Check that each node is
+	// the child of its parent, which proves that every node is in the trie.
+	// [childIndices] maps the path of a node's child entry to the index of
+	// the child in [proof.Nodes].
+	var (
+		childIndices = make(map[path]int, len(proof.Nodes)-1)
+		// The indices of the ancestors of the current node, root first.
+		ancestors = []int{0}
+	)
+	for i := 1; i < len(proof.Nodes); i++ {
+		// The root is a prefix of every node, so [ancestors] is never empty.
+		for !nodePaths[i].HasPrefix(nodePaths[ancestors[len(ancestors)-1]]) {
+			ancestors = ancestors[:len(ancestors)-1]
+		}
+		parent := ancestors[len(ancestors)-1]
+		parentPath := nodePaths[parent]
+		index := nodePaths[i][len(parentPath)]
+		if childID, ok := proof.Nodes[parent].Children[index]; !ok || childID != nodeIDs[i] {
+			return ErrUnlinkedProofNode
+		}
+		childIndices[parentPath.Append(index)] = i
+		ancestors = append(ancestors, i)
+	}
+
+	for i, key := range proof.Keys {
+		if err := verifyMultiProofKey(hasher, proof, nodePaths, childIndices, newPath(key, branchFactor), proof.Values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns nil iff the nodes in [proof] prove that [keyPath] has [value], or
+// doesn't exist if [value] is Nothing.
+// Assumes the nodes in [proof] have been verified to be in the trie.
+func verifyMultiProofKey(
+	hasher Hasher,
+	proof *MultiProof,
+	nodePaths []path,
+	childIndices map[path]int,
+	keyPath path,
+	value Maybe[[]byte],
+) error {
+	// Follow the path from the root to [keyPath] for as long as it exists.
+	current := 0
+	for {
+		currentPath := nodePaths[current]
+		if currentPath == keyPath {
+			// This is an inclusion proof.
+			if !valueOrHashMatches(hasher, value, proof.Nodes[current].ValueOrHash) {
+				return ErrProofValueDoesntMatch
+			}
+			return nil
+		}
+		if !keyPath.HasPrefix(currentPath) {
+			// The trie has no node at [keyPath] because the node where
+			// it would be has a different key.
+			break
+		}
+		index := keyPath[len(currentPath)]
+		if _, ok := proof.Nodes[current].Children[index]; !ok {
+			// The trie has no node at [keyPath] because the node where
+			// it would be a descendant of has no child in its direction.
+			break
+		}
+		next, ok := childIndices[currentPath.Append(index)]
+		if !ok {
+			return ErrMissingProofNode
+		}
+		current = next
+	}
+
+	// This is an exclusion proof.
+	if !value.IsNothing() {
+		return ErrProofValueDoesntMatch
+	}
+	return nil
+}
+
+// Returns the ID of the node described by [proofNode], which has key
+// [nodePath].
+func (proofNode *ProofNode) calculateID(nodePath path, codec EncoderDecoder, hasher Hasher) (ids.ID, error) {
+	children := make(map[byte]child, len(proofNode.Children))
+	for index, childID := range proofNode.Children {
+		children[index] = child{
+			id: childID,
+		}
+	}
+	hv := &hashValues{
+		Children: children,
+		Value:    proofNode.ValueOrHash,
+		Key:      nodePath,
+	}
+
+	bytes, err := codec.encodeHashValues(Version, hv)
+	if err != nil {
+		return ids.Empty, err
+	}
+	return hasher.hash(bytes), nil
+}
+
 type KeyValue struct {
 	Key   []byte
 	Value []byte
@@ -156,13 +346,23 @@ type RangeProof struct {
 //     [proof.EndProof] is just the root.
 //     [end] is non-empty and [proof.EndProof] is a valid proof of a key <= [end].
 //   - [expectedRootID] is the root of the trie containing the given key-value
-//     pairs and start/end proofs.
+//     pairs and start/end proofs, which has branch factor [branchFactor] and
+//     computes node IDs with [hasher].
 func (proof *RangeProof) Verify(
 	ctx context.Context,
 	start []byte,
 	end []byte,
 	expectedRootID ids.ID,
+	branchFactor BranchFactor,
+	hasher Hasher,
 ) error {
+	if err := branchFactor.Valid(); err != nil {
+		return err
+	}
+	if err := hasher.Valid(); err != nil {
+		return err
+	}
+
 	switch {
 	case len(end) > 0 && bytes.Compare(start, end) > 0:
 		return ErrStartAfterEnd
@@ -190,39 +390,39 @@ func (proof *RangeProof) Verify(
 	// The key-value pairs (allegedly) proven by [proof].
 	keyValues := make(map[path][]byte, len(proof.KeyValues))
 	for _, keyValue := range proof.KeyValues {
-		keyValues[newPath(keyValue.Key)] = keyValue.Value
+		keyValues[newPath(keyValue.Key, branchFactor)] = keyValue.Value
 	}
 
-	smallestPath := newPath(start)
-	largestPath := newPath(largestkey)
+	smallestPath := newPath(start, branchFactor)
+	largestPath := newPath(largestkey, branchFactor)
 
 	// Ensure that the start proof is valid and contains values that
 	// match the key/values that were sent.
-	if err := verifyProofPath(proof.StartProof, smallestPath); err != nil {
+	if err := verifyProofPath(proof.StartProof, smallestPath, branchFactor); err != nil {
 		return err
 	}
-	if err := verifyAllRangeProofKeyValuesPresent(proof.StartProof, smallestPath, largestPath, keyValues); err != nil {
+	if err := verifyAllRangeProofKeyValuesPresent(proof.StartProof, smallestPath, largestPath, keyValues, branchFactor, hasher); err != nil {
 		return err
 	}
 
 	// Ensure that the end proof is valid and contains values that
 	// match the key/values that were sent.
-	if err := verifyProofPath(proof.EndProof, largestPath); err != nil {
+	if err := verifyProofPath(proof.EndProof, largestPath, branchFactor); err != nil {
 		return err
 	}
-	if err := verifyAllRangeProofKeyValuesPresent(proof.EndProof, smallestPath, largestPath, keyValues); err != nil {
+	if err := verifyAllRangeProofKeyValuesPresent(proof.EndProof, smallestPath, largestPath, keyValues, branchFactor, hasher); err != nil {
 		return err
 	}
 
 	// Don't need to lock [view] because nobody else has a reference to it.
-	view, err := getEmptyTrieView(ctx)
+	view, err := getEmptyTrieView(ctx, branchFactor, hasher)
 	if err != nil {
 		return err
 	}
 
 	// Insert all key-value pairs into the trie.
 	for _, kv := range proof.KeyValues {
-		if _, err := view.insertIntoTrie(newPath(kv.Key), Some(kv.Value)); err != nil {
+		if _, err := view.insertIntoTrie(newPath(kv.Key, branchFactor), Some(kv.Value)); err != nil {
 			return err
 		}
 	}
@@ -232,10 +432,10 @@ func (proof *RangeProof) Verify(
 	// By inserting all children < [start], we prove that there are no keys
 	// > [start] but less than the first key given. That is, the peer who
 	// gave us this proof is not omitting nodes.
-	if err := addPathInfo(view, proof.StartProof, smallestPath, largestPath); err != nil {
+	if err := addPathInfo(view, proof.StartProof, rangeBound(smallestPath), rangeBound(largestPath)); err != nil {
 		return err
 	}
-	if err := addPathInfo(view, proof.EndProof, smallestPath, largestPath); err != nil {
+	if err := addPathInfo(view, proof.EndProof, rangeBound(smallestPath), rangeBound(largestPath)); err != nil {
 		return err
 	}
 
@@ -251,22 +451,29 @@ func (proof *RangeProof) Verify(
 
 // Verify that all non-intermediate nodes in [proof] which have keys
 // in [[start], [end]] have the value given for that key in [keysValues].
-func verifyAllRangeProofKeyValuesPresent(proof []ProofNode, start, end path, keysValues map[path][]byte) error {
+func verifyAllRangeProofKeyValuesPresent(
+	proof []ProofNode,
+	start path,
+	end path,
+	keysValues map[path][]byte,
+	branchFactor BranchFactor,
+	hasher Hasher,
+) error {
 	for i := 0; i < len(proof); i++ {
 		var (
 			node     = proof[i]
 			nodeKey  = node.KeyPath
-			nodePath = nodeKey.deserialize()
+			nodePath = nodeKey.deserialize(branchFactor)
 		)
 
-		// Skip odd length keys since they cannot have a value (enforced by [verifyProofPath]).
-		if !nodeKey.hasOddLength() && nodePath.Compare(start) >= 0 && nodePath.Compare(end) <= 0 {
+		// Skip keys with a partial byte since they cannot have a value (enforced by [verifyProofPath]).
+		if !nodeKey.hasPartialByte(branchFactor) && nodePath.Compare(start) >= 0 && nodePath.Compare(end) <= 0 {
 			value, ok := keysValues[nodePath]
 			if !ok && !node.ValueOrHash.IsNothing() {
 				// We didn't get a key-value pair for this key, but the proof node has a value.
 				return ErrProofNodeHasUnincludedValue
 			}
-			if ok && !valueOrHashMatches(Some(value), node.ValueOrHash) {
+			if ok && !valueOrHashMatches(hasher, Some(value), node.ValueOrHash) {
 				// We got a key-value pair for this key, but the value in the proof
 				// node doesn't match the value we got for this key.
 				return ErrProofValueDoesntMatch
@@ -357,10 +564,10 @@ func (proof *ChangeProof) Verify(
 		return err
 	}
 
-	smallestPath := newPath(start)
+	smallestPath := newPath(start, db.branchFactor)
 
 	// Make sure the start proof, if given, is well-formed.
-	if err := verifyProofPath(proof.StartProof, smallestPath); err != nil {
+	if err := verifyProofPath(proof.StartProof, smallestPath, db.branchFactor); err != nil {
 		return err
 	}
 
@@ -374,16 +581,16 @@ func (proof *ChangeProof) Verify(
 		// so that we get the expected root ID.
 		largestKey = proof.KeyChanges[len(proof.KeyChanges)-1].Key
 	}
-	largestPath := newPath(largestKey)
+	largestPath := newPath(largestKey, db.branchFactor)
 
 	// Make sure the end proof, if given, is well-formed.
-	if err := verifyProofPath(proof.EndProof, largestPath); err != nil {
+	if err := verifyProofPath(proof.EndProof, largestPath, db.branchFactor); err != nil {
 		return err
 	}
 
 	keyValues := make(map[path]Maybe[[]byte], len(proof.KeyChanges))
 	for _, keyValue := range proof.KeyChanges {
-		keyValues[newPath(keyValue.Key)] = keyValue.Value
+		keyValues[newPath(keyValue.Key, db.branchFactor)] = keyValue.Value
 	}
 
 	// want to prevent commit writes to DB, but not prevent db reads
@@ -421,20 +628,20 @@ func (proof *ChangeProof) Verify(
 	// Insert the key-value pairs into the trie.
 	for _, kv := range proof.KeyChanges {
 		if kv.Value.IsNothing() {
-			if err := view.removeFromTrie(newPath(kv.Key)); err != nil {
+			if err := view.removeFromTrie(newPath(kv.Key, db.branchFactor)); err != nil {
 				return err
 			}
-		} else if _, err := view.insertIntoTrie(newPath(kv.Key), kv.Value); err != nil {
+		} else if _, err := view.insertIntoTrie(newPath(kv.Key, db.branchFactor), kv.Value); err != nil {
 			return err
 		}
 	}
 
 	// For all the nodes along the edges of the proof, insert children < [start] and > [largestKey]
 	// into the trie so that we get the expected root ID (if this proof is valid).
-	if err := addPathInfo(view, proof.StartProof, smallestPath, largestPath); err != nil {
+	if err := addPathInfo(view, proof.StartProof, rangeBound(smallestPath), rangeBound(largestPath)); err != nil {
 		return err
 	}
-	if err := addPathInfo(view, proof.EndProof, smallestPath, largestPath); err != nil {
+	if err := addPathInfo(view, proof.EndProof, rangeBound(smallestPath), rangeBound(largestPath)); err != nil {
 		return err
 	}
 
@@ -451,7 +658,7 @@ func (proof *ChangeProof) Verify(
 }
 
 // Verifies that all values present in the [proof]:
-// - Are nothing when deleted, not in the db, or the node has an odd path length.
+// - Are nothing when deleted, not in the db, or the node's path has a partial byte.
 // - if the node's path is within the key range, that has a value that matches the value passed in the change list or in the db
 func verifyAllChangeProofKeyValuesPresent(
 	ctx context.Context,
@@ -465,12 +672,12 @@ func verifyAllChangeProofKeyValuesPresent(
 		var (
 			node     = proof[i]
 			nodeKey  = node.KeyPath
-			nodePath = nodeKey.deserialize()
+			nodePath = nodeKey.deserialize(db.branchFactor)
 		)
 
 		// Check the value of any node with a key that is within the range.
-		// Skip odd length keys since they cannot have a value (enforced by [verifyProofPath]).
-		if !nodeKey.hasOddLength() && nodePath.Compare(start) >= 0 && nodePath.Compare(end) <= 0 {
+		// Skip keys with a partial byte since they cannot have a value (enforced by [verifyProofPath]).
+		if !nodeKey.hasPartialByte(db.branchFactor) && nodePath.Compare(start) >= 0 && nodePath.Compare(end) <= 0 {
 			value, ok := keysValues[nodePath]
 			if !ok {
 				// This value isn't in the list of key-value pairs we got.
@@ -486,7 +693,7 @@ func verifyAllChangeProofKeyValuesPresent(
 					value = Some(dbValue)
 				}
 			}
-			if !valueOrHashMatches(value, node.ValueOrHash) {
+			if !valueOrHashMatches(db.hasher, value, node.ValueOrHash) {
 				return ErrProofValueDoesntMatch
 			}
 		}
@@ -548,32 +755,32 @@ func verifyKeyValues(kvs []KeyValue, start, end []byte) error {
 }
 
 // Returns nil iff all the following hold:
-//   - Any node with an odd nibble length, should not have a value associated with it
-//     since all keys with values are written in bytes, so have even nibble length.
+//   - Any node whose key has a partial byte should not have a value associated
+//     with it since all keys with values are written in bytes.
 //   - Each key in [proof] is a strict prefix of the following key.
 //   - Each key in [proof] is a strict prefix of [keyBytes], except possibly the last.
 //   - If the last element in [proof] is [keyBytes], this is an inclusion proof.
 //     Otherwise, this is an exclusion proof and [keyBytes] must not be in [proof].
-func verifyProofPath(proof []ProofNode, keyPath path) error {
-	provenKey := keyPath.Serialize()
+func verifyProofPath(proof []ProofNode, keyPath path, branchFactor BranchFactor) error {
+	provenKey := keyPath.Serialize(branchFactor)
 
 	// loop over all but the last node since it will not have the prefix in exclusion proofs
 	for i := 0; i < len(proof)-1; i++ {
 		nodeKey := proof[i].KeyPath
 
-		// intermediate nodes (nodes with odd nibble length) should never have a value associated with them
-		if nodeKey.hasOddLength() && !proof[i].ValueOrHash.IsNothing() {
+		// intermediate nodes (nodes with a partial byte) should never have a value associated with them
+		if nodeKey.hasPartialByte(branchFactor) && !proof[i].ValueOrHash.IsNothing() {
 			return ErrOddLengthWithValue
 		}
 
 		// each node should have a key that has the proven key as a prefix
-		if !provenKey.HasStrictPrefix(nodeKey) {
+		if !provenKey.HasStrictPrefix(nodeKey, branchFactor) {
 			return ErrProofNodeNotForKey
 		}
 
 		// each node should have a key that is a prefix of the next node's key
 		nextKey := proof[i+1].KeyPath
-		if !nextKey.HasStrictPrefix(nodeKey) {
+		if !nextKey.HasStrictPrefix(nodeKey, branchFactor) {
 			return ErrNonIncreasingProofNodes
 		}
 	}
@@ -581,7 +788,7 @@ func verifyProofPath(proof []ProofNode, keyPath path) error {
 	// check the last node for a value since the above loop doesn't check the last node
 	if len(proof) > 0 {
 		lastNode := proof[len(proof)-1]
-		if lastNode.KeyPath.hasOddLength() && !lastNode.ValueOrHash.IsNothing() {
+		if lastNode.KeyPath.hasPartialByte(branchFactor) && !lastNode.ValueOrHash.IsNothing() {
 			return ErrOddLengthWithValue
 		}
 	}
@@ -590,8 +797,9 @@ func verifyProofPath(proof []ProofNode, keyPath path) error {
 }
 
 // Returns true if [value] and [valueDigest] match.
-// [valueOrHash] should be the [ValueOrHash] field of a [ProofNode].
-func valueOrHashMatches(value Maybe[[]byte], valueOrHash Maybe[[]byte]) bool {
+// [valueOrHash] should be the [ValueOrHash] field of a [ProofNode] of a trie
+// that uses [hasher].
+func valueOrHashMatches(hasher Hasher, value Maybe[[]byte], valueOrHash Maybe[[]byte]) bool {
 	var (
 		valueIsNothing  = value.IsNothing()
 		digestIsNothing = valueOrHash.IsNothing()
@@ -607,33 +815,42 @@ func valueOrHashMatches(value Maybe[[]byte], valueOrHash Maybe[[]byte]) bool {
 	case len(value.value) < HashLength:
 		return bytes.Equal(value.value, valueOrHash.value)
 	default:
-		valueHash := hashing.ComputeHash256(value.value)
-		return bytes.Equal(valueHash, valueOrHash.value)
+		valueHash := hasher.hash(value.value)
+		return bytes.Equal(valueHash[:], valueOrHash.value)
+	}
+}
+
+// Returns Nothing if [p] is empty, since an empty range bound means the range
+// is unbounded on that side. Otherwise returns Some([p]).
+func rangeBound(p path) Maybe[path] {
+	if len(p) == 0 {
+		return Nothing[path]()
 	}
+	return Some(p)
 }
 
 // Adds each key/value pair in [proofPath] to [t].
 // For each proof node, adds the children that are < [start] or > [end].
-// If [start] is empty, no children are < [start].
-// If [end] is empty, no children are > [end].
+// If [start] is Nothing, no children are < [start].
+// If [end] is Nothing, no children are > [end].
 // Assumes [t.lock] is held.
 func addPathInfo(
 	t *trieView,
 	proofPath []ProofNode,
-	startPath path,
-	endPath path,
+	startPath Maybe[path],
+	endPath Maybe[path],
 ) error {
 	var (
-		hasLowerBound = len(startPath) > 0
-		hasUpperBound = len(endPath) > 0
+		hasLowerBound = !startPath.IsNothing()
+		hasUpperBound = !endPath.IsNothing()
 	)
 
 	for i := len(proofPath) - 1; i >= 0; i-- {
 		proofNode := proofPath[i]
-		keyPath := proofNode.KeyPath.deserialize()
+		keyPath := proofNode.KeyPath.deserialize(t.db.branchFactor)
 
-		if len(keyPath)&1 == 1 && !proofNode.ValueOrHash.IsNothing() {
-			// a value cannot have an odd number of nibbles in its key
+		if keyPath.hasPartialByte(t.db.branchFactor) && !proofNode.ValueOrHash.IsNothing() {
+			// a value cannot have a partial byte in its key
 			return ErrOddLengthWithValue
 		}
 
@@ -660,8 +877,8 @@ func addPathInfo(
 				compressedPath = existingChild.compressedPath
 			}
 			childPath := keyPath.Append(index) + compressedPath
-			if (hasLowerBound && childPath.Compare(startPath) < 0) ||
-				(hasUpperBound && childPath.Compare(endPath) > 0) {
+			if (hasLowerBound && childPath.Compare(startPath.value) < 0) ||
+				(hasUpperBound && childPath.Compare(endPath.value) > 0) {
 				n.addChildWithoutNode(index, compressedPath, childID)
 			}
 		}
@@ -670,7 +887,7 @@ func addPathInfo(
 	return nil
 }
 
-func getEmptyTrieView(ctx context.Context) (*trieView, error) {
+func getEmptyTrieView(ctx context.Context, branchFactor BranchFactor, hasher Hasher) (*trieView, error) {
 	tracer, err := trace.New(trace.Config{Enabled: false})
 	if err != nil {
 		return nil, err
@@ -681,6 +898,8 @@ func getEmptyTrieView(ctx context.Context) (*trieView, error) {
 		Config{
 			Tracer:        tracer,
 			NodeCacheSize: verificationCacheSize,
+			BranchFactor:  branchFactor,
+			Hasher:        hasher,
 		},
 		&mockMetrics{},
 	)
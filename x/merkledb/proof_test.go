@@ -6,7 +6,9 @@ package merkledb
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -17,6 +19,10 @@ import (
 )
 
 func getBasicDB() (*Database, error) {
+	return getBasicDBWithTrieConfig(DefaultBranchFactor, SHA256Hasher)
+}
+
+func getBasicDBWithTrieConfig(branchFactor BranchFactor, hasher Hasher) (*Database, error) {
 	return newDatabase(
 		context.Background(),
 		memdb.New(),
@@ -24,6 +30,8 @@ func getBasicDB() (*Database, error) {
 			Tracer:        newNoopTracer(),
 			HistoryLength: 1000,
 			NodeCacheSize: 1000,
+			BranchFactor:  branchFactor,
+			Hasher:        hasher,
 		},
 		&mockMetrics{},
 	)
@@ -63,7 +71,7 @@ func Test_Proof_Marshal(t *testing.T) {
 
 func Test_Proof_Empty(t *testing.T) {
 	proof := &Proof{}
-	err := proof.Verify(context.Background(), ids.Empty)
+	err := proof.Verify(context.Background(), ids.Empty, BranchFactor16, SHA256Hasher)
 	require.ErrorIs(t, err, ErrNoProof)
 }
 
@@ -94,6 +102,29 @@ func Test_Proof_MissingValue(t *testing.T) {
 	verifyPath(t, proof.Path, parsedProof.Path)
 }
 
+func Test_Proof_RootOnly(t *testing.T) {
+	require := require.New(t)
+
+	trie, err := getBasicDB()
+	require.NoError(err)
+
+	require.NoError(trie.Insert(context.Background(), []byte{0x10}, []byte{1}))
+	require.NoError(trie.Insert(context.Background(), []byte{0x20}, []byte{2}))
+
+	root, err := trie.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	// Both proofs contain only the root node, so the root's children must
+	// be used to verify them.
+	for _, key := range [][]byte{{}, {0x30}} {
+		proof, err := trie.GetProof(context.Background(), key)
+		require.NoError(err)
+		require.Len(proof.Path, 1)
+		require.True(proof.Value.IsNothing())
+		require.NoError(proof.Verify(context.Background(), root, BranchFactor16, SHA256Hasher))
+	}
+}
+
 func Test_Proof_Marshal_Errors(t *testing.T) {
 	trie, err := getBasicDB()
 	require.NoError(t, err)
@@ -125,7 +156,7 @@ func verifyPath(t *testing.T, path1, path2 []ProofNode) {
 	require.Equal(t, len(path1), len(path2))
 	for i := range path1 {
 		require.True(t, bytes.Equal(path1[i].KeyPath.Value, path2[i].KeyPath.Value))
-		require.Equal(t, path1[i].KeyPath.hasOddLength(), path2[i].KeyPath.hasOddLength())
+		require.Equal(t, path1[i].KeyPath.hasPartialByte(BranchFactor16), path2[i].KeyPath.hasPartialByte(BranchFactor16))
 		require.True(t, bytes.Equal(path1[i].ValueOrHash.value, path2[i].ValueOrHash.value))
 		for childIndex := range path1[i].Children {
 			require.Equal(t, path1[i].Children[childIndex], path2[i].Children[childIndex])
@@ -197,21 +228,21 @@ func Test_Proof_Verify_Bad_Data(t *testing.T) {
 
 			tt.malform(proof)
 
-			err = proof.Verify(context.Background(), db.getMerkleRoot())
+			err = proof.Verify(context.Background(), db.getMerkleRoot(), BranchFactor16, SHA256Hasher)
 			require.ErrorIs(t, err, tt.expectedErr)
 		})
 	}
 }
 
 func Test_Proof_ValueOrHashMatches(t *testing.T) {
-	require.True(t, valueOrHashMatches(Some([]byte{0}), Some([]byte{0})))
-	require.False(t, valueOrHashMatches(Nothing[[]byte](), Some(hashing.ComputeHash256([]byte{0}))))
-	require.True(t, valueOrHashMatches(Nothing[[]byte](), Nothing[[]byte]()))
-
-	require.False(t, valueOrHashMatches(Some([]byte{0}), Nothing[[]byte]()))
-	require.False(t, valueOrHashMatches(Nothing[[]byte](), Some([]byte{0})))
-	require.False(t, valueOrHashMatches(Nothing[[]byte](), Some(hashing.ComputeHash256([]byte{1}))))
-	require.False(t, valueOrHashMatches(Some(hashing.ComputeHash256([]byte{0})), Nothing[[]byte]()))
+	require.True(t, valueOrHashMatches(SHA256Hasher, Some([]byte{0}), Some([]byte{0})))
+	require.False(t, valueOrHashMatches(SHA256Hasher, Nothing[[]byte](), Some(hashing.ComputeHash256([]byte{0}))))
+	require.True(t, valueOrHashMatches(SHA256Hasher, Nothing[[]byte](), Nothing[[]byte]()))
+
+	require.False(t, valueOrHashMatches(SHA256Hasher, Some([]byte{0}), Nothing[[]byte]()))
+	require.False(t, valueOrHashMatches(SHA256Hasher, Nothing[[]byte](), Some([]byte{0})))
+	require.False(t, valueOrHashMatches(SHA256Hasher, Nothing[[]byte](), Some(hashing.ComputeHash256([]byte{1}))))
+	require.False(t, valueOrHashMatches(SHA256Hasher, Some(hashing.ComputeHash256([]byte{0})), Nothing[[]byte]()))
 }
 
 func Test_RangeProof_Extra_Value(t *testing.T) {
@@ -232,6 +263,8 @@ func Test_RangeProof_Extra_Value(t *testing.T) {
 		[]byte{1},
 		[]byte{5, 5},
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(t, err)
 
@@ -242,6 +275,8 @@ func Test_RangeProof_Extra_Value(t *testing.T) {
 		[]byte{1},
 		[]byte{5, 5},
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.ErrorIs(t, err, ErrInvalidProof)
 }
@@ -301,7 +336,7 @@ func Test_RangeProof_Verify_Bad_Data(t *testing.T) {
 
 			tt.malform(proof)
 
-			err = proof.Verify(context.Background(), []byte{2}, []byte{3, 0}, db.getMerkleRoot())
+			err = proof.Verify(context.Background(), []byte{2}, []byte{3, 0}, db.getMerkleRoot(), BranchFactor16, SHA256Hasher)
 			require.ErrorIs(t, err, tt.expectedErr)
 		})
 	}
@@ -347,20 +382,20 @@ func Test_Proof(t *testing.T) {
 
 	require.Len(t, proof.Path, 3)
 
-	require.Equal(t, newPath([]byte("key1")).Serialize(), proof.Path[2].KeyPath)
+	require.Equal(t, newPath([]byte("key1"), BranchFactor16).Serialize(BranchFactor16), proof.Path[2].KeyPath)
 	require.Equal(t, Some([]byte("value1")), proof.Path[2].ValueOrHash)
 
-	require.Equal(t, newPath([]byte{}).Serialize(), proof.Path[0].KeyPath)
+	require.Equal(t, newPath([]byte{}, BranchFactor16).Serialize(BranchFactor16), proof.Path[0].KeyPath)
 	require.True(t, proof.Path[0].ValueOrHash.IsNothing())
 
 	expectedRootID, err := trie.GetMerkleRoot(context.Background())
 	require.NoError(t, err)
-	err = proof.Verify(context.Background(), expectedRootID)
+	err = proof.Verify(context.Background(), expectedRootID, BranchFactor16, SHA256Hasher)
 	require.NoError(t, err)
 
 	proof.Path[0].ValueOrHash = Some([]byte("value2"))
 
-	err = proof.Verify(context.Background(), expectedRootID)
+	err = proof.Verify(context.Background(), expectedRootID, BranchFactor16, SHA256Hasher)
 	require.ErrorIs(t, err, ErrInvalidProof)
 }
 
@@ -451,10 +486,10 @@ func Test_RangeProof_Syntactic_Verify(t *testing.T) {
 				},
 				StartProof: []ProofNode{
 					{
-						KeyPath: newPath([]byte{2}).Serialize(),
+						KeyPath: newPath([]byte{2}, BranchFactor16).Serialize(BranchFactor16),
 					},
 					{
-						KeyPath: newPath([]byte{1}).Serialize(),
+						KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16),
 					},
 				},
 			},
@@ -470,13 +505,13 @@ func Test_RangeProof_Syntactic_Verify(t *testing.T) {
 				},
 				StartProof: []ProofNode{
 					{
-						KeyPath: newPath([]byte{1}).Serialize(),
+						KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16),
 					},
 					{
-						KeyPath: newPath([]byte{1, 2, 3}).Serialize(), // Not a prefix of [1, 2]
+						KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16), // Not a prefix of [1, 2]
 					},
 					{
-						KeyPath: newPath([]byte{1, 2, 3, 4}).Serialize(),
+						KeyPath: newPath([]byte{1, 2, 3, 4}, BranchFactor16).Serialize(BranchFactor16),
 					},
 				},
 			},
@@ -492,10 +527,10 @@ func Test_RangeProof_Syntactic_Verify(t *testing.T) {
 				},
 				EndProof: []ProofNode{
 					{
-						KeyPath: newPath([]byte{2}).Serialize(),
+						KeyPath: newPath([]byte{2}, BranchFactor16).Serialize(BranchFactor16),
 					},
 					{
-						KeyPath: newPath([]byte{1}).Serialize(),
+						KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16),
 					},
 				},
 			},
@@ -511,13 +546,13 @@ func Test_RangeProof_Syntactic_Verify(t *testing.T) {
 				},
 				EndProof: []ProofNode{
 					{
-						KeyPath: newPath([]byte{1}).Serialize(),
+						KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16),
 					},
 					{
-						KeyPath: newPath([]byte{1, 2, 3}).Serialize(), // Not a prefix of [1, 2]
+						KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16), // Not a prefix of [1, 2]
 					},
 					{
-						KeyPath: newPath([]byte{1, 2, 3, 4}).Serialize(),
+						KeyPath: newPath([]byte{1, 2, 3, 4}, BranchFactor16).Serialize(BranchFactor16),
 					},
 				},
 			},
@@ -529,7 +564,7 @@ func Test_RangeProof_Syntactic_Verify(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			require := require.New(t)
 
-			err := tt.proof.Verify(context.Background(), tt.start, tt.end, ids.Empty)
+			err := tt.proof.Verify(context.Background(), tt.start, tt.end, ids.Empty, BranchFactor16, SHA256Hasher)
 			require.ErrorIs(err, tt.expectedErr)
 		})
 	}
@@ -567,6 +602,8 @@ func Test_RangeProof(t *testing.T) {
 		[]byte{1},
 		[]byte{3, 5},
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(err)
 }
@@ -612,15 +649,17 @@ func Test_RangeProof_NilStart(t *testing.T) {
 	require.Equal(t, []byte("value1"), proof.KeyValues[0].Value)
 	require.Equal(t, []byte("value2"), proof.KeyValues[1].Value)
 
-	require.Equal(t, newPath([]byte("key2")).Serialize(), proof.EndProof[2].KeyPath)
+	require.Equal(t, newPath([]byte("key2"), BranchFactor16).Serialize(BranchFactor16), proof.EndProof[2].KeyPath)
 	require.Equal(t, SerializedPath{Value: []uint8{0x6b, 0x65, 0x79, 0x30}, NibbleLength: 7}, proof.EndProof[1].KeyPath)
-	require.Equal(t, newPath([]byte("")).Serialize(), proof.EndProof[0].KeyPath)
+	require.Equal(t, newPath([]byte(""), BranchFactor16).Serialize(BranchFactor16), proof.EndProof[0].KeyPath)
 
 	err = proof.Verify(
 		context.Background(),
 		nil,
 		[]byte("key35"),
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(t, err)
 }
@@ -654,6 +693,8 @@ func Test_RangeProof_NilEnd(t *testing.T) {
 		[]byte{1},
 		nil,
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(t, err)
 }
@@ -688,17 +729,19 @@ func Test_RangeProof_EmptyValues(t *testing.T) {
 	require.Empty(t, proof.KeyValues[2].Value)
 
 	require.Len(t, proof.StartProof, 1)
-	require.Equal(t, newPath([]byte("key1")).Serialize(), proof.StartProof[0].KeyPath)
+	require.Equal(t, newPath([]byte("key1"), BranchFactor16).Serialize(BranchFactor16), proof.StartProof[0].KeyPath)
 
 	require.Len(t, proof.EndProof, 3)
-	require.Equal(t, newPath([]byte("key2")).Serialize(), proof.EndProof[2].KeyPath)
-	require.Equal(t, newPath([]byte{}).Serialize(), proof.EndProof[0].KeyPath)
+	require.Equal(t, newPath([]byte("key2"), BranchFactor16).Serialize(BranchFactor16), proof.EndProof[2].KeyPath)
+	require.Equal(t, newPath([]byte{}, BranchFactor16).Serialize(BranchFactor16), proof.EndProof[0].KeyPath)
 
 	err = proof.Verify(
 		context.Background(),
 		[]byte("key1"),
 		[]byte("key2"),
 		db.root.id,
+		BranchFactor16,
+		SHA256Hasher,
 	)
 	require.NoError(t, err)
 }
@@ -1256,8 +1299,8 @@ func Test_ChangeProof_Syntactic_Verify(t *testing.T) {
 			proof: &ChangeProof{
 				HadRootsInHistory: true,
 				StartProof: []ProofNode{
-					{KeyPath: newPath([]byte{2}).Serialize()},
-					{KeyPath: newPath([]byte{2, 3}).Serialize()},
+					{KeyPath: newPath([]byte{2}, BranchFactor16).Serialize(BranchFactor16)},
+					{KeyPath: newPath([]byte{2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 				},
 			},
 			start:       []byte{1, 2, 3},
@@ -1269,8 +1312,8 @@ func Test_ChangeProof_Syntactic_Verify(t *testing.T) {
 			proof: &ChangeProof{
 				HadRootsInHistory: true,
 				StartProof: []ProofNode{
-					{KeyPath: newPath([]byte{1}).Serialize()},
-					{KeyPath: newPath([]byte{2, 3}).Serialize()},
+					{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+					{KeyPath: newPath([]byte{2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 				},
 			},
 			start:       []byte{1, 2, 3},
@@ -1285,8 +1328,8 @@ func Test_ChangeProof_Syntactic_Verify(t *testing.T) {
 					{Key: []byte{1, 2}, Value: Some([]byte{0})},
 				},
 				EndProof: []ProofNode{
-					{KeyPath: newPath([]byte{2}).Serialize()},
-					{KeyPath: newPath([]byte{2, 3}).Serialize()},
+					{KeyPath: newPath([]byte{2}, BranchFactor16).Serialize(BranchFactor16)},
+					{KeyPath: newPath([]byte{2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 				},
 			},
 			start:       nil,
@@ -1301,8 +1344,8 @@ func Test_ChangeProof_Syntactic_Verify(t *testing.T) {
 					{Key: []byte{1, 2, 3}},
 				},
 				EndProof: []ProofNode{
-					{KeyPath: newPath([]byte{1}).Serialize()},
-					{KeyPath: newPath([]byte{2, 3}).Serialize()},
+					{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+					{KeyPath: newPath([]byte{2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 				},
 			},
 			start:       nil,
@@ -1422,9 +1465,9 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "non-increasing keys",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: ErrNonIncreasingProofNodes,
@@ -1432,10 +1475,10 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "invalid key",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 4}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 4}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: ErrProofNodeNotForKey,
@@ -1443,9 +1486,9 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "extra node inclusion proof",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2},
 			expectedErr: ErrProofNodeNotForKey,
@@ -1453,9 +1496,9 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "extra node exclusion proof",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 3}).Serialize()},
-				{KeyPath: newPath([]byte{1, 3, 4}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 3}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 3, 4}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2},
 			expectedErr: ErrProofNodeNotForKey,
@@ -1463,9 +1506,9 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "happy path exclusion proof",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 4}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 4}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: nil,
@@ -1473,9 +1516,9 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "happy path inclusion proof",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: nil,
@@ -1483,10 +1526,10 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "repeat nodes",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: ErrNonIncreasingProofNodes,
@@ -1494,10 +1537,10 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "repeat nodes 2",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: ErrNonIncreasingProofNodes,
@@ -1505,10 +1548,10 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "repeat nodes 3",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2, 3}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2, 3}, BranchFactor16).Serialize(BranchFactor16)},
 			},
 			proofKey:    []byte{1, 2, 3},
 			expectedErr: ErrProofNodeNotForKey,
@@ -1516,8 +1559,8 @@ func TestVerifyProofPath(t *testing.T) {
 		{
 			name: "oddLength key with value",
 			path: []ProofNode{
-				{KeyPath: newPath([]byte{1}).Serialize()},
-				{KeyPath: newPath([]byte{1, 2}).Serialize()},
+				{KeyPath: newPath([]byte{1}, BranchFactor16).Serialize(BranchFactor16)},
+				{KeyPath: newPath([]byte{1, 2}, BranchFactor16).Serialize(BranchFactor16)},
 				{KeyPath: SerializedPath{Value: []byte{1, 2, 240}, NibbleLength: 5}, ValueOrHash: Some([]byte{1})},
 			},
 			proofKey:    []byte{1, 2, 3},
@@ -1527,8 +1570,388 @@ func TestVerifyProofPath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := verifyProofPath(tt.path, newPath(tt.proofKey))
+			err := verifyProofPath(tt.path, newPath(tt.proofKey, BranchFactor16), BranchFactor16)
 			require.ErrorIs(t, err, tt.expectedErr)
 		})
 	}
 }
+
+func Test_MultiProof(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	batch := db.NewBatch()
+	require.NoError(batch.Put([]byte("key0"), []byte("value0")))
+	require.NoError(batch.Put([]byte("key1"), []byte("value1")))
+	require.NoError(batch.Put([]byte("key2"), make([]byte, 2*HashLength)))
+	require.NoError(batch.Put([]byte("other"), []byte("value")))
+	require.NoError(batch.Write())
+
+	keys := [][]byte{
+		[]byte("key2"),
+		[]byte("key0"),
+		[]byte("key"),  // not in the trie
+		[]byte("key5"), // not in the trie
+		[]byte("key0"), // duplicate
+	}
+	proof, err := db.GetMultiProof(context.Background(), keys)
+	require.NoError(err)
+
+	require.Equal(
+		[][]byte{[]byte("key"), []byte("key0"), []byte("key2"), []byte("key5")},
+		proof.Keys,
+	)
+	require.Equal(
+		[]Maybe[[]byte]{Nothing[[]byte](), Some([]byte("value0")), Some(make([]byte, 2*HashLength)), Nothing[[]byte]()},
+		proof.Values,
+	)
+	require.NoError(proof.Verify(context.Background(), db.getMerkleRoot(), BranchFactor16, SHA256Hasher))
+
+	// Shared nodes are only included once.
+	numSingleProofNodes := 0
+	for _, key := range proof.Keys {
+		singleProof, err := db.GetProof(context.Background(), key)
+		require.NoError(err)
+		numSingleProofNodes += len(singleProof.Path)
+	}
+	require.Less(len(proof.Nodes), numSingleProofNodes)
+
+	_, err = db.GetMultiProof(context.Background(), nil)
+	require.ErrorIs(err, ErrNoKeys)
+}
+
+func Test_MultiProof_Verify_Bad_Data(t *testing.T) {
+	type test struct {
+		name        string
+		malform     func(proof *MultiProof)
+		expectedErr error
+	}
+
+	tests := []test{
+		{
+			name:        "happyPath",
+			malform:     func(proof *MultiProof) {},
+			expectedErr: nil,
+		},
+		{
+			name: "no keys",
+			malform: func(proof *MultiProof) {
+				proof.Keys = nil
+				proof.Values = nil
+			},
+			expectedErr: ErrNoKeys,
+		},
+		{
+			name: "missing value",
+			malform: func(proof *MultiProof) {
+				proof.Values = proof.Values[1:]
+			},
+			expectedErr: ErrKeysValuesLengthMismatch,
+		},
+		{
+			name: "no nodes",
+			malform: func(proof *MultiProof) {
+				proof.Nodes = nil
+			},
+			expectedErr: ErrNoProof,
+		},
+		{
+			name: "unsorted keys",
+			malform: func(proof *MultiProof) {
+				proof.Keys[0], proof.Keys[1] = proof.Keys[1], proof.Keys[0]
+				proof.Values[0], proof.Values[1] = proof.Values[1], proof.Values[0]
+			},
+			expectedErr: ErrNonIncreasingValues,
+		},
+		{
+			name: "missing root",
+			malform: func(proof *MultiProof) {
+				proof.Nodes = proof.Nodes[1:]
+			},
+			expectedErr: ErrNoRootProofNode,
+		},
+		{
+			name: "unsorted nodes",
+			malform: func(proof *MultiProof) {
+				last := len(proof.Nodes) - 1
+				proof.Nodes[last-1], proof.Nodes[last] = proof.Nodes[last], proof.Nodes[last-1]
+			},
+			expectedErr: ErrUnsortedProofNodes,
+		},
+		{
+			name: "modified root",
+			malform: func(proof *MultiProof) {
+				proof.Nodes[0].ValueOrHash = Some([]byte{1})
+			},
+			expectedErr: ErrInvalidProof,
+		},
+		{
+			name: "modified node",
+			malform: func(proof *MultiProof) {
+				proof.Nodes[len(proof.Nodes)-1].ValueOrHash = Some([]byte{10})
+			},
+			expectedErr: ErrUnlinkedProofNode,
+		},
+		{
+			name: "missing node",
+			malform: func(proof *MultiProof) {
+				proof.Nodes = proof.Nodes[:len(proof.Nodes)-1]
+			},
+			expectedErr: ErrMissingProofNode,
+		},
+		{
+			name: "mismatched value",
+			malform: func(proof *MultiProof) {
+				proof.Values[1] = Some([]byte{10})
+			},
+			expectedErr: ErrProofValueDoesntMatch,
+		},
+		{
+			name: "value for excluded key",
+			malform: func(proof *MultiProof) {
+				proof.Values[0] = Some([]byte{10})
+			},
+			expectedErr: ErrProofValueDoesntMatch,
+		},
+		{
+			name: "missing value for included key",
+			malform: func(proof *MultiProof) {
+				proof.Values[1] = Nothing[[]byte]()
+			},
+			expectedErr: ErrProofValueDoesntMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := getBasicDB()
+			require.NoError(t, err)
+
+			writeBasicBatch(t, db)
+
+			// Prove that {0, 1} doesn't exist and that {2} and {3} do.
+			proof, err := db.GetMultiProof(context.Background(), [][]byte{{0, 1}, {2}, {3}})
+			require.NoError(t, err)
+
+			tt.malform(proof)
+
+			err = proof.Verify(context.Background(), db.getMerkleRoot(), BranchFactor16, SHA256Hasher)
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func Test_MultiProof_Marshal(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	writeBasicBatch(t, db)
+
+	proof, err := db.GetMultiProof(context.Background(), [][]byte{{0, 1}, {2}, {3}})
+	require.NoError(err)
+
+	proofBytes, err := Codec.EncodeMultiProof(Version, proof)
+	require.NoError(err)
+
+	parsedProof := &MultiProof{}
+	_, err = Codec.DecodeMultiProof(proofBytes, parsedProof)
+	require.NoError(err)
+
+	require.Equal(proof, parsedProof)
+	require.NoError(parsedProof.Verify(context.Background(), db.getMerkleRoot(), BranchFactor16, SHA256Hasher))
+}
+
+// Checks that a multi-proof of a set of keys is valid iff single-key proofs of
+// those keys are valid, and that they prove the same values.
+func FuzzMultiProof(f *testing.F) {
+	f.Fuzz(
+		func(
+			t *testing.T,
+			randSeed int64,
+			numKeyValues uint,
+			numProofKeys uint,
+		) {
+			require := require.New(t)
+
+			const (
+				maxKeyValues = 512
+				maxProofKeys = 64
+				maxKeyLen    = 4
+			)
+
+			r := rand.New(rand.NewSource(randSeed)) // #nosec G404
+
+			branchFactor := branchFactors[r.Intn(len(branchFactors))] // #nosec G404
+			hasher := hashers[r.Intn(len(hashers))]                   // #nosec G404
+			db, err := getBasicDBWithTrieConfig(branchFactor, hasher)
+			require.NoError(err)
+
+			batch := db.NewBatch()
+			for i := uint(0); i < numKeyValues%maxKeyValues; i++ {
+				key := make([]byte, r.Intn(maxKeyLen)+1)    // #nosec G404
+				_, _ = r.Read(key)                          // #nosec G404
+				value := make([]byte, r.Intn(2*HashLength)) // #nosec G404
+				_, _ = r.Read(value)                        // #nosec G404
+				require.NoError(batch.Put(key, value))
+			}
+			require.NoError(batch.Write())
+
+			keys := make([][]byte, numProofKeys%maxProofKeys+1)
+			for i := range keys {
+				keys[i] = make([]byte, r.Intn(maxKeyLen+1)) // #nosec G404
+				_, _ = r.Read(keys[i])                      // #nosec G404
+			}
+
+			rootID := db.getMerkleRoot()
+			proof, err := db.GetMultiProof(context.Background(), keys)
+			require.NoError(err)
+			require.NoError(proof.Verify(context.Background(), rootID, branchFactor, hasher))
+
+			for i, key := range proof.Keys {
+				singleProof, err := db.GetProof(context.Background(), key)
+				require.NoError(err)
+				require.NoError(singleProof.Verify(context.Background(), rootID, branchFactor, hasher))
+				require.Equal(singleProof.Value, proof.Values[i])
+			}
+
+			// Claiming a different value for a key must fail verification,
+			// as it does for a single-key proof.
+			index := r.Intn(len(proof.Keys)) // #nosec G404
+			singleProof, err := db.GetProof(context.Background(), proof.Keys[index])
+			require.NoError(err)
+			if proof.Values[index].IsNothing() {
+				proof.Values[index] = Some([]byte{0})
+				singleProof.Value = Some([]byte{0})
+			} else {
+				proof.Values[index] = Nothing[[]byte]()
+				singleProof.Value = Nothing[[]byte]()
+			}
+			require.ErrorIs(proof.Verify(context.Background(), rootID, branchFactor, hasher), ErrProofValueDoesntMatch)
+			require.ErrorIs(singleProof.Verify(context.Background(), rootID, branchFactor, hasher), ErrProofValueDoesntMatch)
+		},
+	)
+}
+
+func Test_Proofs_TrieConfigs(t *testing.T) {
+	for _, branchFactor := range branchFactors {
+		for _, hasher := range hashers {
+			t.Run(fmt.Sprintf("%d_%s", branchFactor, hasher), func(t *testing.T) {
+				require := require.New(t)
+
+				r := rand.New(rand.NewSource(0)) // #nosec G404
+				db, err := getBasicDBWithTrieConfig(branchFactor, hasher)
+				require.NoError(err)
+				values := map[string][]byte{}
+				require.NoError(writeRandomBatch(r, db, values))
+				startRoot := db.getMerkleRoot()
+				require.NoError(writeRandomBatch(r, db, values))
+				root := db.getMerkleRoot()
+
+				otherBranchFactor := BranchFactor2
+				if branchFactor == BranchFactor2 {
+					otherBranchFactor = BranchFactor4
+				}
+				otherHasher := BLAKE2bHasher
+				if hasher == BLAKE2bHasher {
+					otherHasher = SHA256Hasher
+				}
+				codec := NewCodec(branchFactor)
+
+				for key := range values {
+					proof, err := db.GetProof(context.Background(), []byte(key))
+					require.NoError(err)
+
+					proofBytes, err := codec.EncodeProof(Version, proof)
+					require.NoError(err)
+					var parsedProof Proof
+					_, err = codec.DecodeProof(proofBytes, &parsedProof)
+					require.NoError(err)
+
+					require.NoError(parsedProof.Verify(context.Background(), root, branchFactor, hasher))
+					require.Error(parsedProof.Verify(context.Background(), root, otherBranchFactor, hasher))
+					require.Error(parsedProof.Verify(context.Background(), root, branchFactor, otherHasher))
+				}
+
+				keys := [][]byte{{}, {0}, {0x80, 0x01}, {0xFF, 0xFF}}
+				multiProof, err := db.GetMultiProof(context.Background(), keys)
+				require.NoError(err)
+				require.NoError(multiProof.Verify(context.Background(), root, branchFactor, hasher))
+				require.Error(multiProof.Verify(context.Background(), root, branchFactor, otherHasher))
+
+				start, end := []byte{0x40}, []byte{0xC0}
+				rangeProof, err := db.GetRangeProof(context.Background(), start, end, 10)
+				require.NoError(err)
+				rangeProofBytes, err := codec.EncodeRangeProof(Version, rangeProof)
+				require.NoError(err)
+				var parsedRangeProof RangeProof
+				_, err = codec.DecodeRangeProof(rangeProofBytes, &parsedRangeProof)
+				require.NoError(err)
+				require.NoError(parsedRangeProof.Verify(context.Background(), start, end, root, branchFactor, hasher))
+				require.Error(parsedRangeProof.Verify(context.Background(), start, end, root, branchFactor, otherHasher))
+
+				changeProof, err := db.GetChangeProof(context.Background(), startRoot, root, start, end, 10)
+				require.NoError(err)
+				changeProofBytes, err := codec.EncodeChangeProof(Version, changeProof)
+				require.NoError(err)
+				var parsedChangeProof ChangeProof
+				_, err = codec.DecodeChangeProof(changeProofBytes, &parsedChangeProof)
+				require.NoError(err)
+
+				// The change proof is verified against a database with the
+				// key/values at [startRoot].
+				startDB, err := getBasicDBWithTrieConfig(branchFactor, hasher)
+				require.NoError(err)
+				startView, err := db.NewViewAtRoot(startRoot)
+				require.NoError(err)
+				it := startView.NewIterator()
+				for it.Next() {
+					require.NoError(startDB.Put(it.Key(), it.Value()))
+				}
+				require.NoError(it.Error())
+				it.Release()
+				require.Equal(startRoot, startDB.getMerkleRoot())
+				require.NoError(parsedChangeProof.Verify(context.Background(), startDB, start, end, root))
+			})
+		}
+	}
+}
+
+func Test_Proof_EmptyKey(t *testing.T) {
+	require := require.New(t)
+
+	db, err := getBasicDB()
+	require.NoError(err)
+
+	// The root has a child in the direction of each non-empty key, so the
+	// proof of the empty key has only the root.
+	require.NoError(db.Put([]byte{}, []byte("value")))
+	require.NoError(db.Put([]byte("key0"), []byte("value0")))
+	require.NoError(db.Put([]byte("key1"), []byte("value1")))
+
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	proof, err := db.GetProof(context.Background(), []byte{})
+	require.NoError(err)
+	require.Len(proof.Path, 1)
+	require.Equal(Some([]byte("value")), proof.Value)
+	require.NoError(proof.Verify(context.Background(), root, BranchFactor16, SHA256Hasher))
+
+	proof.Value = Some([]byte("otherValue"))
+	require.ErrorIs(proof.Verify(context.Background(), root, BranchFactor16, SHA256Hasher), ErrProofValueDoesntMatch)
+
+	// An exclusion proof of the empty key also has only the root.
+	require.NoError(db.Delete([]byte{}))
+	root, err = db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	proof, err = db.GetProof(context.Background(), []byte{})
+	require.NoError(err)
+	require.Len(proof.Path, 1)
+	require.True(proof.Value.IsNothing())
+	require.NoError(proof.Verify(context.Background(), root, BranchFactor16, SHA256Hasher))
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package merkledb
+
+import (
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/database"
+)
+
+var _ database.Snapshot = (*snapshot)(nil)
+
+// snapshot reads the key-value pairs of the database from a snapshot of the
+// database's nodes.
+type snapshot struct {
+	db           *Database
+	nodeSnapshot database.Snapshot
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	_, err := s.getValue(key)
+	if err == database.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	val, err := s.getValue(key)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(val), nil
+}
+
+func (s *snapshot) getValue(key []byte) ([]byte, error) {
+	s.db.metrics.IOKeyRead()
+	nodePath := newPath(key, s.db.branchFactor)
+	nodeBytes, err := s.nodeSnapshot.Get(nodePath.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	n, err := parseNode(s.db.codec, s.db.hasher, nodePath, nodeBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !n.hasValue() {
+		return nil, database.ErrNotFound
+	}
+	return n.value.value, nil
+}
+
+func (s *snapshot) NewIterator() database.Iterator {
+	return s.newIterator(s.nodeSnapshot.NewIterator())
+}
+
+func (s *snapshot) NewIteratorWithStart(start []byte) database.Iterator {
+	return s.newIterator(s.nodeSnapshot.NewIteratorWithStart(newPath(start, s.db.branchFactor).Bytes()))
+}
+
+func (s *snapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.newIterator(s.nodeSnapshot.NewIteratorWithPrefix(newPath(prefix, s.db.branchFactor).Bytes()))
+}
+
+func (s *snapshot) NewIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	startBytes := newPath(start, s.db.branchFactor).Bytes()
+	prefixBytes := newPath(prefix, s.db.branchFactor).Bytes()
+	return s.newIterator(s.nodeSnapshot.NewIteratorWithStartAndPrefix(startBytes, prefixBytes))
+}
+
+func (s *snapshot) NewReverseIterator() database.Iterator {
+	return s.newIterator(s.nodeSnapshot.NewReverseIterator())
+}
+
+func (s *snapshot) NewReverseIteratorWithStart(start []byte) database.Iterator {
+	return s.NewReverseIteratorWithStartAndPrefix(start, nil)
+}
+
+func (s *snapshot) NewReverseIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.newIterator(s.nodeSnapshot.NewReverseIteratorWithPrefix(newPath(prefix, s.db.branchFactor).Bytes()))
+}
+
+func (s *snapshot) NewReverseIteratorWithStartAndPrefix(start, prefix []byte) database.Iterator {
+	// A nil [start] must be passed through as nil, because the empty path
+	// would only include the root.
+	var startBytes []byte
+	if start != nil {
+		startBytes = newPath(start, s.db.branchFactor).Bytes()
+	}
+	prefixBytes := newPath(prefix, s.db.branchFactor).Bytes()
+	return s.newIterator(s.nodeSnapshot.NewReverseIteratorWithStartAndPrefix(startBytes, prefixBytes))
+}
+
+func (s *snapshot) newIterator(nodeIter database.Iterator) database.Iterator {
+	return &iterator{
+		nodeIter: nodeIter,
+		db:       s.db,
+	}
+}
+
+func (s *snapshot) Release() {
+	s.nodeSnapshot.Release()
+}
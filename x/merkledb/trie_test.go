@@ -24,7 +24,7 @@ func getNodeValue(t ReadOnlyTrie, key string) ([]byte, error) {
 		if err := asTrieView.calculateNodeIDs(context.Background()); err != nil {
 			return nil, err
 		}
-		path := newPath([]byte(key))
+		path := newPath([]byte(key), asTrieView.db.branchFactor)
 		nodePath, err := asTrieView.getPathTo(path)
 		if err != nil {
 			return nil, err
@@ -41,7 +41,7 @@ func getNodeValue(t ReadOnlyTrie, key string) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		path := newPath([]byte(key))
+		path := newPath([]byte(key), asDatabases.branchFactor)
 		nodePath, err := view.(*trieView).getPathTo(path)
 		if err != nil {
 			return nil, err
@@ -112,7 +112,7 @@ func TestTrieViewGetPathTo(t *testing.T) {
 	trie, ok := trieIntf.(*trieView)
 	require.True(ok)
 
-	path, err := trie.getPathTo(newPath(nil))
+	path, err := trie.getPathTo(newPath(nil, BranchFactor16))
 	require.NoError(err)
 
 	// Just the root
@@ -126,13 +126,13 @@ func TestTrieViewGetPathTo(t *testing.T) {
 	err = trie.calculateNodeIDs(context.Background())
 	require.NoError(err)
 
-	path, err = trie.getPathTo(newPath(key1))
+	path, err = trie.getPathTo(newPath(key1, BranchFactor16))
 	require.NoError(err)
 
 	// Root and 1 value
 	require.Len(path, 2)
 	require.Equal(trie.root, path[0])
-	require.Equal(newPath(key1), path[1].key)
+	require.Equal(newPath(key1, BranchFactor16), path[1].key)
 
 	// Insert another key which is a child of the first
 	key2 := []byte{0, 1}
@@ -141,12 +141,12 @@ func TestTrieViewGetPathTo(t *testing.T) {
 	err = trie.calculateNodeIDs(context.Background())
 	require.NoError(err)
 
-	path, err = trie.getPathTo(newPath(key2))
+	path, err = trie.getPathTo(newPath(key2, BranchFactor16))
 	require.NoError(err)
 	require.Len(path, 3)
 	require.Equal(trie.root, path[0])
-	require.Equal(newPath(key1), path[1].key)
-	require.Equal(newPath(key2), path[2].key)
+	require.Equal(newPath(key1, BranchFactor16), path[1].key)
+	require.Equal(newPath(key2, BranchFactor16), path[2].key)
 
 	// Insert a key which shares no prefix with the others
 	key3 := []byte{255}
@@ -155,32 +155,32 @@ func TestTrieViewGetPathTo(t *testing.T) {
 	err = trie.calculateNodeIDs(context.Background())
 	require.NoError(err)
 
-	path, err = trie.getPathTo(newPath(key3))
+	path, err = trie.getPathTo(newPath(key3, BranchFactor16))
 	require.NoError(err)
 	require.Len(path, 2)
 	require.Equal(trie.root, path[0])
-	require.Equal(newPath(key3), path[1].key)
+	require.Equal(newPath(key3, BranchFactor16), path[1].key)
 
 	// Other key paths not affected
-	path, err = trie.getPathTo(newPath(key2))
+	path, err = trie.getPathTo(newPath(key2, BranchFactor16))
 	require.NoError(err)
 	require.Len(path, 3)
 	require.Equal(trie.root, path[0])
-	require.Equal(newPath(key1), path[1].key)
-	require.Equal(newPath(key2), path[2].key)
+	require.Equal(newPath(key1, BranchFactor16), path[1].key)
+	require.Equal(newPath(key2, BranchFactor16), path[2].key)
 
 	// Gets closest node when key doesn't exist
 	key4 := []byte{0, 1, 2}
-	path, err = trie.getPathTo(newPath(key4))
+	path, err = trie.getPathTo(newPath(key4, BranchFactor16))
 	require.NoError(err)
 	require.Len(path, 3)
 	require.Equal(trie.root, path[0])
-	require.Equal(newPath(key1), path[1].key)
-	require.Equal(newPath(key2), path[2].key)
+	require.Equal(newPath(key1, BranchFactor16), path[1].key)
+	require.Equal(newPath(key2, BranchFactor16), path[2].key)
 
 	// Gets just root when key doesn't exist and no key shares a prefix
 	key5 := []byte{128}
-	path, err = trie.getPathTo(newPath(key5))
+	path, err = trie.getPathTo(newPath(key5, BranchFactor16))
 	require.NoError(err)
 	require.Len(path, 1)
 	require.Equal(trie.root, path[0])
@@ -275,11 +275,11 @@ func Test_Trie_WriteToDB(t *testing.T) {
 	err = trie.CommitToDB(context.Background())
 	require.NoError(err)
 
-	p := newPath([]byte("key"))
+	p := newPath([]byte("key"), BranchFactor16)
 	rawBytes, err := dbTrie.nodeDB.Get(p.Bytes())
 	require.NoError(err)
 
-	node, err := parseNode(p, rawBytes)
+	node, err := parseNode(Codec, SHA256Hasher, p, rawBytes)
 	require.NoError(err)
 	require.Equal([]byte("value"), node.value.value)
 }
@@ -15,6 +15,7 @@ import (
 
 	oteltrace "go.opentelemetry.io/otel/trace"
 
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 
@@ -267,7 +268,7 @@ func (t *trieView) calculateNodeIDsHelper(ctx context.Context, n *node, eg *errg
 	for childIndex, child := range n.children {
 		childIndex, child := childIndex, child
 
-		childPath := n.key + path(childIndex) + child.compressedPath
+		childPath := n.key.Append(childIndex) + child.compressedPath
 		childNodeChange, ok := t.changes.nodes[childPath]
 		if !ok {
 			// This child wasn't changed.
@@ -305,7 +306,7 @@ func (t *trieView) calculateNodeIDsHelper(ctx context.Context, n *node, eg *errg
 	}
 
 	// The IDs [n]'s descendants are up to date so we can calculate [n]'s ID.
-	return n.calculateID(t.db.metrics)
+	return n.calculateID(t.db.codec, t.db.hasher, t.db.metrics)
 }
 
 // GetProof returns a proof that [bytesPath] is in or not in trie [t].
@@ -342,7 +343,7 @@ func (t *trieView) getProof(ctx context.Context, key []byte) (*Proof, error) {
 	}
 
 	// Get the node at the given path, or the node closest to it.
-	keyPath := newPath(key)
+	keyPath := newPath(key, t.db.branchFactor)
 
 	proofPath, err := t.getPathTo(keyPath)
 	if err != nil {
@@ -352,7 +353,7 @@ func (t *trieView) getProof(ctx context.Context, key []byte) (*Proof, error) {
 	// From root --> node from left --> right.
 	proof.Path = make([]ProofNode, len(proofPath), len(proofPath)+1)
 	for i, node := range proofPath {
-		proof.Path[i] = node.asProofNode()
+		proof.Path[i] = node.asProofNode(t.db.branchFactor)
 	}
 
 	closestNode := proofPath[len(proofPath)-1]
@@ -372,18 +373,85 @@ func (t *trieView) getProof(ctx context.Context, key []byte) (*Proof, error) {
 		return proof, nil
 	}
 
-	childPath := closestNode.key + path(nextIndex) + child.compressedPath
+	childPath := closestNode.key.Append(nextIndex) + child.compressedPath
 	childNode, err := t.getNodeFromParent(closestNode, childPath)
 	if err != nil {
 		return nil, err
 	}
-	proof.Path = append(proof.Path, childNode.asProofNode())
+	proof.Path = append(proof.Path, childNode.asProofNode(t.db.branchFactor))
 	if t.isInvalid() {
 		return nil, ErrInvalid
 	}
 	return proof, nil
 }
 
+// GetMultiProof returns a proof that each key in [keys] is in or not in trie [t].
+func (t *trieView) GetMultiProof(ctx context.Context, keys [][]byte) (*MultiProof, error) {
+	_, span := t.db.tracer.Start(ctx, "MerkleDB.trieview.GetMultiProof", oteltrace.WithAttributes(
+		attribute.Int("keyCount", len(keys)),
+	))
+	defer span.End()
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	// only need full lock if nodes ids need to be calculated
+	// looped to ensure that the value didn't change after the lock was released
+	for t.needsRecalculation {
+		t.lock.RUnlock()
+		t.lock.Lock()
+		if err := t.calculateNodeIDs(ctx); err != nil {
+			return nil, err
+		}
+		t.lock.Unlock()
+		t.lock.RLock()
+	}
+
+	return t.getMultiProof(ctx, keys)
+}
+
+// Returns a proof that each key in [keys] is in or not in trie [t].
+// Assumes [t.lock] is held.
+func (t *trieView) getMultiProof(ctx context.Context, keys [][]byte) (*MultiProof, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	// The proof contains each key once, in increasing order.
+	sortedKeys := slices.Clone(keys)
+	slices.SortFunc(sortedKeys, func(a, b []byte) bool {
+		return bytes.Compare(a, b) < 0
+	})
+	sortedKeys = slices.CompactFunc(sortedKeys, bytes.Equal)
+
+	proof := &MultiProof{
+		Keys:   make([][]byte, len(sortedKeys)),
+		Values: make([]Maybe[[]byte], len(sortedKeys)),
+	}
+	proofNodes := make(map[path]ProofNode)
+	for i, key := range sortedKeys {
+		keyProof, err := t.getProof(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, proofNode := range keyProof.Path {
+			proofNodes[proofNode.KeyPath.deserialize(t.db.branchFactor)] = proofNode
+		}
+		proof.Keys[i] = slices.Clone(key)
+		proof.Values[i] = keyProof.Value
+	}
+
+	nodePaths := maps.Keys(proofNodes)
+	slices.SortFunc(nodePaths, func(a, b path) bool {
+		return a.Compare(b) < 0
+	})
+	proof.Nodes = make([]ProofNode, len(nodePaths))
+	for i, nodePath := range nodePaths {
+		proof.Nodes[i] = proofNodes[nodePath]
+	}
+	return proof, nil
+}
+
 // GetRangeProof returns a range proof for (at least part of) the key range [start, end].
 // The returned proof's [KeyValues] has at most [maxLength] values.
 // [maxLength] must be > 0.
@@ -746,13 +814,18 @@ func (t *trieView) getKeyValues(
 
 	// collect all values that have changed or been deleted
 	changes := make([]KeyValue, 0, len(t.changes.values))
+	startPath := newPath(start, t.db.branchFactor)
 	for key, change := range t.changes.values {
+		if key.Compare(startPath) < 0 {
+			// This key is before the requested range
+			continue
+		}
 		if change.after.IsNothing() {
 			// This was deleted
-			keysToIgnore.Add(string(key.Serialize().Value))
+			keysToIgnore.Add(string(key.Serialize(t.db.branchFactor).Value))
 		} else {
 			changes = append(changes, KeyValue{
-				Key:   key.Serialize().Value,
+				Key:   key.Serialize(t.db.branchFactor).Value,
 				Value: change.after.value,
 			})
 		}
@@ -861,7 +934,7 @@ func (t *trieView) GetValues(_ context.Context, keys [][]byte) ([][]byte, []erro
 	valueErrors := make([]error, len(keys))
 
 	for i, key := range keys {
-		results[i], valueErrors[i] = t.getValueCopy(newPath(key), false)
+		results[i], valueErrors[i] = t.getValueCopy(newPath(key, t.db.branchFactor), false)
 	}
 	return results, valueErrors
 }
@@ -869,7 +942,7 @@ func (t *trieView) GetValues(_ context.Context, keys [][]byte) ([][]byte, []erro
 // GetValue returns the value for the given [key].
 // Returns database.ErrNotFound if it doesn't exist.
 func (t *trieView) GetValue(_ context.Context, key []byte) ([]byte, error) {
-	return t.getValueCopy(newPath(key), true)
+	return t.getValueCopy(newPath(key, t.db.branchFactor), true)
 }
 
 // getValueCopy returns a copy of the value for the given [key].
@@ -938,7 +1011,7 @@ func (t *trieView) insert(key []byte, value []byte) error {
 
 	valCopy := slices.Clone(value)
 
-	if err := t.recordValueChange(newPath(key), Some(valCopy)); err != nil {
+	if err := t.recordValueChange(newPath(key, t.db.branchFactor), Some(valCopy)); err != nil {
 		return err
 	}
 
@@ -972,7 +1045,7 @@ func (t *trieView) remove(key []byte) error {
 	// the trie has been changed, so invalidate all children and remove them from tracking
 	t.invalidateChildren()
 
-	if err := t.recordValueChange(newPath(key), Nothing[[]byte]()); err != nil {
+	if err := t.recordValueChange(newPath(key, t.db.branchFactor), Nothing[[]byte]()); err != nil {
 		return err
 	}
 
@@ -1171,7 +1244,7 @@ func (t *trieView) insertIntoTrie(
 
 	// a node with that exact path already exists so update its value
 	if closestNode.key.Compare(key) == 0 {
-		closestNode.setValue(value)
+		closestNode.setValue(t.db.hasher, value)
 		return closestNode, nil
 	}
 
@@ -1189,7 +1262,7 @@ func (t *trieView) insertIntoTrie(
 			closestNode,
 			key,
 		)
-		newNode.setValue(value)
+		newNode.setValue(t.db.hasher, value)
 		return newNode, t.recordNodeChange(newNode)
 	} else if err != nil {
 		return nil, err
@@ -1212,14 +1285,14 @@ func (t *trieView) insertIntoTrie(
 
 	if len(key)-len(branchNode.key) == 0 {
 		// there was no residual path for the inserted key, so the value goes directly into the new branch node
-		branchNode.setValue(value)
+		branchNode.setValue(t.db.hasher, value)
 	} else {
 		// generate a new node and add it as a child of the branch node
 		newNode := newNode(
 			branchNode,
 			key,
 		)
-		newNode.setValue(value)
+		newNode.setValue(t.db.hasher, value)
 		if err := t.recordNodeChange(newNode); err != nil {
 			return nil, err
 		}
@@ -1344,7 +1417,7 @@ func (t *trieView) removeFromTrie(key path) error {
 		}
 	}
 
-	nodeToDelete.setValue(Nothing[[]byte]())
+	nodeToDelete.setValue(t.db.hasher, Nothing[[]byte]())
 	if err := t.recordNodeChange(nodeToDelete); err != nil {
 		return err
 	}
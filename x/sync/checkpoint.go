@@ -0,0 +1,235 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package sync
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/memeticofficial/pepecoingo/codec"
+	"github.com/memeticofficial/pepecoingo/codec/linearcodec"
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+const (
+	checkpointCodecVersion = 0
+
+	defaultCheckpointInterval = 10 * time.Second
+)
+
+var (
+	checkpointKey = []byte("syncCheckpoint")
+
+	checkpointCodec codec.Manager
+)
+
+func init() {
+	lc := linearcodec.NewCustomMaxLength(math.MaxUint32)
+	checkpointCodec = codec.NewManager(math.MaxInt32)
+
+	if err := checkpointCodec.RegisterCodec(checkpointCodecVersion, lc); err != nil {
+		panic(err)
+	}
+}
+
+// The progress of a sync, written to [StateSyncConfig.CheckpointDB].
+//
+// Only ranges whose keys are known to be in the sync database are recorded.
+// Ranges that were being fetched when the checkpoint was written are fetched
+// again when the sync is resumed.
+type syncCheckpoint struct {
+	// The root that was being synced to.
+	TargetRoot ids.ID `serialize:"true"`
+	// The ranges that were synced, sorted by start.
+	// The ranges don't overlap, other than sharing a boundary.
+	SyncedRanges []syncedRange `serialize:"true"`
+}
+
+// The range [Start, End] of the sync database is in sync with [RootID].
+// An empty [End] means there is no upper bound.
+type syncedRange struct {
+	Start  []byte `serialize:"true"`
+	End    []byte `serialize:"true"`
+	RootID ids.ID `serialize:"true"`
+}
+
+// Returns the checkpoint in [m.config.CheckpointDB].
+// Returns database.ErrNotFound if there isn't one.
+func (m *StateSyncManager) getCheckpoint() (*syncCheckpoint, error) {
+	checkpointBytes, err := m.config.CheckpointDB.Get(checkpointKey)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &syncCheckpoint{}
+	if _, err := checkpointCodec.Unmarshal(checkpointBytes, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Populates [m.unprocessedWork] and [m.processedWork] with the work needed to
+// sync to the target root, resuming from the checkpoint if there is one.
+// Assumes [m.workLock] is held.
+func (m *StateSyncManager) initWork() error {
+	if m.config.CheckpointDB == nil {
+		// Add work item to fetch the entire key range.
+		m.unprocessedWork.Insert(newWorkItem(ids.Empty, nil, nil, lowPriority))
+		return nil
+	}
+
+	checkpoint, err := m.getCheckpoint()
+	if err == database.ErrNotFound {
+		m.unprocessedWork.Insert(newWorkItem(ids.Empty, nil, nil, lowPriority))
+		return m.checkpoint(m.getTargetRoot())
+	}
+	if err != nil {
+		return err
+	}
+
+	targetRoot := m.getTargetRoot()
+	if checkpoint.TargetRoot != targetRoot {
+		m.config.Log.Info("sync target changed since checkpoint",
+			zap.Stringer("checkpointTarget", checkpoint.TargetRoot),
+			zap.Stringer("target", targetRoot),
+		)
+	}
+
+	// The start of the range after the last synced range seen so far.
+	var gapStart []byte
+	for _, synced := range checkpoint.SyncedRanges {
+		start, end := nilIfEmpty(synced.Start), nilIfEmpty(synced.End)
+		if !bytes.Equal(gapStart, start) {
+			// The keys between the previous synced range and this one
+			// haven't been fetched.
+			m.unprocessedWork.Insert(newWorkItem(ids.Empty, gapStart, start, lowPriority))
+		}
+		if synced.RootID == targetRoot {
+			m.processedWork.MergeInsert(newWorkItem(synced.RootID, start, end, lowPriority))
+		} else {
+			// The range was synced to a different root, so get the changes
+			// since that root, as in UpdateSyncTarget.
+			m.unprocessedWork.Insert(newWorkItem(synced.RootID, start, end, highPriority))
+		}
+		gapStart = end
+	}
+	if len(checkpoint.SyncedRanges) == 0 || gapStart != nil {
+		m.unprocessedWork.Insert(newWorkItem(ids.Empty, gapStart, nil, lowPriority))
+	}
+
+	m.config.Log.Info("resuming sync from checkpoint",
+		zap.Int("syncedRanges", len(checkpoint.SyncedRanges)),
+	)
+	return m.checkpoint(targetRoot)
+}
+
+// Writes the progress of the sync to [m.config.CheckpointDB].
+// No-op if [m.config.CheckpointDB] is nil.
+// Assumes [m.workLock] is held.
+func (m *StateSyncManager) checkpoint(targetRoot ids.ID) error {
+	if m.config.CheckpointDB == nil {
+		return nil
+	}
+
+	// Work items that are being processed aren't in either heap, so their
+	// ranges are fetched again on resume.
+	// Unprocessed work items with a local root were synced to that root, but
+	// the target changed since.
+	var syncedItems []*syncWorkItem
+	syncedItems = append(syncedItems, m.processedWork.sortedWorkItems()...)
+	for _, item := range m.unprocessedWork.sortedWorkItems() {
+		if item.LocalRootID != ids.Empty {
+			syncedItems = append(syncedItems, item)
+		}
+	}
+	slices.SortFunc(syncedItems, func(a, b *syncWorkItem) bool {
+		return bytes.Compare(a.start, b.start) < 0
+	})
+
+	checkpoint := syncCheckpoint{
+		TargetRoot:   targetRoot,
+		SyncedRanges: make([]syncedRange, len(syncedItems)),
+	}
+	for i, item := range syncedItems {
+		checkpoint.SyncedRanges[i] = syncedRange{
+			Start:  item.start,
+			End:    item.end,
+			RootID: item.LocalRootID,
+		}
+	}
+
+	checkpointBytes, err := checkpointCodec.Marshal(checkpointCodecVersion, &checkpoint)
+	if err != nil {
+		return err
+	}
+	return m.config.CheckpointDB.Put(checkpointKey, checkpointBytes)
+}
+
+// Checkpoints the progress of the sync every [m.config.CheckpointInterval]
+// until the sync is done.
+func (m *StateSyncManager) checkpointPeriodically() {
+	ticker := time.NewTicker(m.config.CheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.syncDoneChan:
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.checkpointIfSyncing(); err != nil {
+			m.setError(err)
+			return
+		}
+	}
+}
+
+// Checkpoints the progress of the sync unless it's done.
+// Assumes [m.workLock] is not held.
+func (m *StateSyncManager) checkpointIfSyncing() error {
+	m.workLock.Lock()
+	defer m.workLock.Unlock()
+
+	// [m.syncDoneChan] is closed while holding [m.workLock], so the final
+	// checkpoint written by Close is never overwritten.
+	select {
+	case <-m.syncDoneChan:
+		return nil
+	default:
+	}
+	return m.checkpoint(m.getTargetRoot())
+}
+
+// Called when the sync stops.
+// Deletes the checkpoint if the sync completed. Otherwise, checkpoints the
+// progress so that the sync can be resumed.
+// Assumes [m.workLock] is held.
+func (m *StateSyncManager) finalCheckpoint() {
+	if m.config.CheckpointDB == nil || !m.syncing {
+		return
+	}
+
+	var err error
+	if m.Error() == nil && m.unprocessedWork.Len() == 0 && m.processingWorkItems == 0 {
+		err = m.config.CheckpointDB.Delete(checkpointKey)
+	} else {
+		err = m.checkpoint(m.getTargetRoot())
+	}
+	if err != nil {
+		m.config.Log.Error("failed to write sync checkpoint", zap.Error(err))
+	}
+}
+
+func nilIfEmpty(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
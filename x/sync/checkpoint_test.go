@@ -0,0 +1,327 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package sync
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/database"
+	"github.com/memeticofficial/pepecoingo/database/memdb"
+	"github.com/memeticofficial/pepecoingo/ids"
+	"github.com/memeticofficial/pepecoingo/utils/logging"
+	"github.com/memeticofficial/pepecoingo/x/merkledb"
+
+	syncpb "github.com/memeticofficial/pepecoingo/proto/pb/sync"
+)
+
+func newEmptySyncDB(t *testing.T) *merkledb.Database {
+	db, err := merkledb.New(
+		context.Background(),
+		memdb.New(),
+		merkledb.Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 0,
+			NodeCacheSize: 1000,
+		},
+	)
+	require.NoError(t, err)
+	return db
+}
+
+// Syncs [db] to the root of [dbToSync], failing after [numRangeProofs] range
+// proofs are fetched, and returns the checkpoint written by the failed sync.
+func syncUntilError(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	db *merkledb.Database,
+	checkpointDB database.Database,
+	dbToSync *merkledb.Database,
+	numRangeProofs int,
+) *syncCheckpoint {
+	require := require.New(t)
+
+	syncRoot, err := dbToSync.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	client := NewMockClient(ctrl)
+	client.EXPECT().GetRangeProof(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
+			if numRangeProofs == 0 {
+				return nil, errInvalidRangeProof
+			}
+			numRangeProofs--
+			return dbToSync.GetRangeProofAtRoot(ctx, syncRoot, request.Start, request.End, int(request.KeyLimit))
+		},
+	).AnyTimes()
+
+	syncer, err := NewStateSyncManager(StateSyncConfig{
+		SyncDB:                db,
+		Client:                client,
+		TargetRoot:            syncRoot,
+		SimultaneousWorkLimit: 1,
+		Log:                   logging.NoLog{},
+		CheckpointDB:          checkpointDB,
+	})
+	require.NoError(err)
+	require.NoError(syncer.StartSyncing(context.Background()))
+	require.ErrorIs(syncer.Wait(context.Background()), errInvalidRangeProof)
+
+	checkpoint, err := syncer.getCheckpoint()
+	require.NoError(err)
+	require.Equal(syncRoot, checkpoint.TargetRoot)
+	return checkpoint
+}
+
+func Test_Sync_Resume_From_Checkpoint(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	dbToSync, err := generateTrie(t, r, 5000)
+	require.NoError(err)
+	syncRoot, err := dbToSync.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	db := newEmptySyncDB(t)
+	checkpointDB := memdb.New()
+	checkpoint := syncUntilError(t, ctrl, db, checkpointDB, dbToSync, 2)
+	require.NotEmpty(checkpoint.SyncedRanges)
+
+	// The synced ranges shouldn't be fetched again.
+	client := NewMockClient(ctrl)
+	client.EXPECT().GetRangeProof(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
+			for _, synced := range checkpoint.SyncedRanges {
+				inRange := bytes.Compare(synced.Start, request.Start) <= 0 &&
+					(len(synced.End) == 0 || bytes.Compare(request.Start, synced.End) < 0)
+				require.False(inRange)
+			}
+			return dbToSync.GetRangeProofAtRoot(ctx, syncRoot, request.Start, request.End, int(request.KeyLimit))
+		},
+	).AnyTimes()
+
+	syncer, err := NewStateSyncManager(StateSyncConfig{
+		SyncDB:                db,
+		Client:                client,
+		TargetRoot:            syncRoot,
+		SimultaneousWorkLimit: 5,
+		Log:                   logging.NoLog{},
+		CheckpointDB:          checkpointDB,
+	})
+	require.NoError(err)
+	require.NoError(syncer.StartSyncing(context.Background()))
+	require.NoError(syncer.Wait(context.Background()))
+
+	newRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(syncRoot, newRoot)
+
+	// The checkpoint is removed once the sync completes.
+	has, err := checkpointDB.Has(checkpointKey)
+	require.NoError(err)
+	require.False(has)
+}
+
+func Test_Sync_Resume_From_Checkpoint_Changed_Target(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(0)) // #nosec G404
+	dbToSync, err := generateTrie(t, r, 5000)
+	require.NoError(err)
+
+	db := newEmptySyncDB(t)
+	checkpointDB := memdb.New()
+	checkpoint := syncUntilError(t, ctrl, db, checkpointDB, dbToSync, 2)
+	require.NotEmpty(checkpoint.SyncedRanges)
+
+	// Change the trie being synced while the sync is stopped.
+	for i := 0; i < 100; i++ {
+		key := make([]byte, r.Intn(50))
+		_, err = r.Read(key)
+		require.NoError(err)
+		require.NoError(dbToSync.Put(key, key))
+	}
+	newSyncRoot, err := dbToSync.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	// The ranges synced to the previous root are updated with change proofs.
+	numChangeProofs := 0
+	client := NewMockClient(ctrl)
+	client.EXPECT().GetRangeProof(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
+			return dbToSync.GetRangeProofAtRoot(ctx, newSyncRoot, request.Start, request.End, int(request.KeyLimit))
+		},
+	).AnyTimes()
+	client.EXPECT().GetChangeProof(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*ChangeOrRangeProof, error) {
+			numChangeProofs++
+			startRoot, err := ids.ToID(request.StartRoot)
+			require.NoError(err)
+			require.Equal(checkpoint.TargetRoot, startRoot)
+			return getChangeOrRangeProof(ctx, dbToSync, startRoot, newSyncRoot, request.Start, request.End, int(request.KeyLimit))
+		},
+	).AnyTimes()
+
+	syncer, err := NewStateSyncManager(StateSyncConfig{
+		SyncDB:                db,
+		Client:                client,
+		TargetRoot:            newSyncRoot,
+		SimultaneousWorkLimit: 1,
+		Log:                   logging.NoLog{},
+		CheckpointDB:          checkpointDB,
+	})
+	require.NoError(err)
+	require.NoError(syncer.StartSyncing(context.Background()))
+	require.NoError(syncer.Wait(context.Background()))
+	require.Positive(numChangeProofs)
+
+	newRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.Equal(newSyncRoot, newRoot)
+}
+
+func Test_Sync_InitWork_From_Checkpoint(t *testing.T) {
+	targetRoot := ids.GenerateTestID()
+	otherRoot := ids.GenerateTestID()
+
+	tests := []struct {
+		name                string
+		syncedRanges        []syncedRange
+		expectedProcessed   []*syncWorkItem
+		expectedUnprocessed []*syncWorkItem
+	}{
+		{
+			name: "nothing synced",
+			expectedUnprocessed: []*syncWorkItem{
+				newWorkItem(ids.Empty, nil, nil, lowPriority),
+			},
+		},
+		{
+			name: "everything synced",
+			syncedRanges: []syncedRange{
+				{RootID: targetRoot},
+			},
+			expectedProcessed: []*syncWorkItem{
+				newWorkItem(targetRoot, nil, nil, lowPriority),
+			},
+		},
+		{
+			name: "gaps",
+			syncedRanges: []syncedRange{
+				{Start: []byte{1}, End: []byte{2}, RootID: targetRoot},
+				{Start: []byte{2}, End: []byte{3}, RootID: otherRoot},
+				{Start: []byte{4}, End: []byte{5}, RootID: targetRoot},
+			},
+			expectedProcessed: []*syncWorkItem{
+				newWorkItem(targetRoot, []byte{1}, []byte{2}, lowPriority),
+				newWorkItem(targetRoot, []byte{4}, []byte{5}, lowPriority),
+			},
+			expectedUnprocessed: []*syncWorkItem{
+				newWorkItem(ids.Empty, nil, []byte{1}, lowPriority),
+				newWorkItem(otherRoot, []byte{2}, []byte{3}, highPriority),
+				newWorkItem(ids.Empty, []byte{3}, []byte{4}, lowPriority),
+				newWorkItem(ids.Empty, []byte{5}, nil, lowPriority),
+			},
+		},
+		{
+			name: "unbounded end synced",
+			syncedRanges: []syncedRange{
+				{Start: []byte{1}, RootID: otherRoot},
+			},
+			expectedUnprocessed: []*syncWorkItem{
+				newWorkItem(ids.Empty, nil, []byte{1}, lowPriority),
+				newWorkItem(otherRoot, []byte{1}, nil, highPriority),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			checkpointDB := memdb.New()
+			m, err := NewStateSyncManager(StateSyncConfig{
+				SyncDB:                &merkledb.Database{}, // Not used
+				Client:                NewMockClient(ctrl),  // Not used
+				TargetRoot:            targetRoot,
+				SimultaneousWorkLimit: 5,
+				Log:                   logging.NoLog{},
+				CheckpointDB:          checkpointDB,
+			})
+			require.NoError(err)
+
+			checkpointBytes, err := checkpointCodec.Marshal(checkpointCodecVersion, &syncCheckpoint{
+				TargetRoot:   otherRoot,
+				SyncedRanges: tt.syncedRanges,
+			})
+			require.NoError(err)
+			require.NoError(checkpointDB.Put(checkpointKey, checkpointBytes))
+
+			m.workLock.Lock()
+			require.NoError(m.initWork())
+			m.workLock.Unlock()
+
+			require.Equal(tt.expectedProcessed, nilIfNoItems(m.processedWork.sortedWorkItems()))
+			require.Equal(tt.expectedUnprocessed, nilIfNoItems(m.unprocessedWork.sortedWorkItems()))
+
+			// The checkpoint is rewritten with the new target.
+			checkpoint, err := m.getCheckpoint()
+			require.NoError(err)
+			require.Equal(targetRoot, checkpoint.TargetRoot)
+			require.Len(checkpoint.SyncedRanges, len(tt.syncedRanges))
+		})
+	}
+}
+
+func Test_Sync_UpdateSyncTarget_Checkpoint(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	checkpointDB := memdb.New()
+	m, err := NewStateSyncManager(StateSyncConfig{
+		SyncDB:                &merkledb.Database{}, // Not used
+		Client:                NewMockClient(ctrl),  // Not used
+		TargetRoot:            ids.Empty,
+		SimultaneousWorkLimit: 5,
+		Log:                   logging.NoLog{},
+		CheckpointDB:          checkpointDB,
+	})
+	require.NoError(err)
+
+	syncedRoot := ids.GenerateTestID()
+	m.processedWork.Insert(newWorkItem(syncedRoot, []byte{1}, []byte{2}, lowPriority))
+	// Not synced yet, so it isn't in the checkpoint.
+	m.unprocessedWork.Insert(newWorkItem(ids.Empty, []byte{2}, nil, lowPriority))
+
+	newSyncRoot := ids.GenerateTestID()
+	require.NoError(m.UpdateSyncTarget(newSyncRoot))
+
+	checkpoint, err := m.getCheckpoint()
+	require.NoError(err)
+	require.Equal(&syncCheckpoint{
+		TargetRoot: newSyncRoot,
+		SyncedRanges: []syncedRange{
+			{Start: []byte{1}, End: []byte{2}, RootID: syncedRoot},
+		},
+	}, checkpoint)
+}
+
+func nilIfNoItems(items []*syncWorkItem) []*syncWorkItem {
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
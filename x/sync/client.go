@@ -34,6 +34,18 @@ var (
 	errInvalidRangeProof = errors.New("failed to verify range proof")
 	errTooManyKeys       = errors.New("response contains more than requested keys")
 	errTooManyBytes      = errors.New("response contains more than requested bytes")
+	errUnknownErrorCode  = errors.New("unknown error code")
+	errEmptyResponse     = errors.New("response contains neither a proof nor an error")
+	errUnexpectedProof   = errors.New("response contains an unexpected kind of proof")
+
+	// Error code --> error returned when a peer responds with that code.
+	responseErrors = map[syncpb.ErrorCode]error{
+		syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST: ErrInvalidRequest,
+		syncpb.ErrorCode_ERROR_CODE_ROOT_NOT_FOUND:  ErrRootNotFound,
+		syncpb.ErrorCode_ERROR_CODE_THROTTLED:       ErrRequestThrottled,
+		syncpb.ErrorCode_ERROR_CODE_PROOF_TOO_LARGE: ErrMinProofSizeIsTooLarge,
+		syncpb.ErrorCode_ERROR_CODE_INTERNAL:        ErrInternalServerError,
+	}
 )
 
 // Client synchronously fetches data from the network to fulfill state sync requests.
@@ -44,8 +56,19 @@ type Client interface {
 	GetRangeProof(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error)
 	// GetChangeProof synchronously sends the given request, returning a parsed ChangesResponse or error
 	// [verificationDB] is the local db that has all key/values in it for the proof's startroot within the proof's key range
-	// Note: this verifies the response including the change proof.
-	GetChangeProof(ctx context.Context, request *syncpb.ChangeProofRequest, verificationDB *merkledb.Database) (*merkledb.ChangeProof, error)
+	// If the peer doesn't have the start root, the response is a range proof of the key range at the end root.
+	// Note: this verifies the response including the change or range proof.
+	GetChangeProof(ctx context.Context, request *syncpb.ChangeProofRequest, verificationDB *merkledb.Database) (*ChangeOrRangeProof, error)
+}
+
+// ChangeOrRangeProof is the response to a ChangeProofRequest.
+// Exactly one of the fields is non-nil.
+type ChangeOrRangeProof struct {
+	// The changes in the requested range between the start and end roots.
+	ChangeProof *merkledb.ChangeProof
+	// The key/values in the requested range at the end root, sent instead of
+	// [ChangeProof] when the peer doesn't have the start root in its history.
+	RangeProof *merkledb.RangeProof
 }
 
 type client struct {
@@ -55,6 +78,9 @@ type client struct {
 	stateSyncMinVersion *version.Application
 	log                 logging.Logger
 	metrics             SyncMetrics
+	branchFactor        merkledb.BranchFactor
+	hasher              merkledb.Hasher
+	codec               merkledb.EncoderDecoder
 }
 
 type ClientConfig struct {
@@ -63,54 +89,76 @@ type ClientConfig struct {
 	StateSyncMinVersion *version.Application
 	Log                 logging.Logger
 	Metrics             SyncMetrics
+	// The database being synced. Proofs are decoded and verified with its
+	// branch factor and hasher. If nil, the defaults of [merkledb.Config] are
+	// used.
+	DB *merkledb.Database
 }
 
 func NewClient(config *ClientConfig) Client {
+	var (
+		branchFactor = merkledb.DefaultBranchFactor
+		hasher       = merkledb.SHA256Hasher
+	)
+	if config.DB != nil {
+		branchFactor = config.DB.BranchFactor()
+		hasher = config.DB.Hasher()
+	}
 	c := &client{
 		networkClient:       config.NetworkClient,
 		stateSyncNodes:      config.StateSyncNodeIDs,
 		stateSyncMinVersion: config.StateSyncMinVersion,
 		log:                 config.Log,
 		metrics:             config.Metrics,
+		branchFactor:        branchFactor,
+		hasher:              hasher,
+		codec:               merkledb.NewCodec(branchFactor),
 	}
 	return c
 }
 
-// GetChangeProof synchronously retrieves the change proof given by [req].
+// GetChangeProof synchronously retrieves the change proof given by [req], or
+// the range proof at its end root if the peer doesn't have its start root.
 // Upon failure, retries until the context is expired.
-// The returned change proof is verified.
-func (c *client) GetChangeProof(ctx context.Context, req *syncpb.ChangeProofRequest, db *merkledb.Database) (*merkledb.ChangeProof, error) {
-	parseFn := func(ctx context.Context, responseBytes []byte) (*merkledb.ChangeProof, error) {
-		if len(responseBytes) > int(req.BytesLimit) {
-			return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyBytes, len(responseBytes), req.BytesLimit)
-		}
-
-		changeProof := &merkledb.ChangeProof{}
-		if _, err := merkledb.Codec.DecodeChangeProof(responseBytes, changeProof); err != nil {
-			return nil, err
-		}
-
-		// Ensure the response does not contain more than the requested number of leaves
-		// and the start and end roots match the requested roots.
-		if len(changeProof.KeyChanges) > int(req.KeyLimit) {
-			return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyKeys, len(changeProof.KeyChanges), req.KeyLimit)
-		}
-
+// The returned proof is verified.
+func (c *client) GetChangeProof(ctx context.Context, req *syncpb.ChangeProofRequest, db *merkledb.Database) (*ChangeOrRangeProof, error) {
+	parseFn := func(ctx context.Context, response *syncpb.Response) (*ChangeOrRangeProof, error) {
 		endRoot, err := ids.ToID(req.EndRoot)
 		if err != nil {
 			return nil, err
 		}
 
-		if err := changeProof.Verify(ctx, db, req.Start, req.End, endRoot); err != nil {
-			return nil, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
+		switch message := response.GetMessage().(type) {
+		case *syncpb.Response_Proof:
+			changeProof, err := c.parseChangeProof(ctx, message.Proof, req, endRoot, db)
+			if err != nil {
+				return nil, err
+			}
+			return &ChangeOrRangeProof{ChangeProof: changeProof}, nil
+		case *syncpb.Response_RangeProof:
+			rangeProof, err := c.parseRangeProof(
+				ctx,
+				message.RangeProof,
+				req.Start,
+				req.End,
+				endRoot,
+				req.KeyLimit,
+				req.BytesLimit,
+			)
+			if err != nil {
+				return nil, err
+			}
+			return &ChangeOrRangeProof{RangeProof: rangeProof}, nil
+		default:
+			return nil, errUnexpectedProof
 		}
-		return changeProof, nil
 	}
 
 	reqBytes, err := proto.Marshal(&syncpb.Request{
 		Message: &syncpb.Request_ChangeProofRequest{
 			ChangeProofRequest: req,
 		},
+		WantsResponse: true,
 	})
 	if err != nil {
 		return nil, err
@@ -118,45 +166,65 @@ func (c *client) GetChangeProof(ctx context.Context, req *syncpb.ChangeProofRequ
 	return getAndParse(ctx, c, reqBytes, parseFn)
 }
 
+// Returns the change proof in [proofBytes] if it's a valid response to [req].
+func (c *client) parseChangeProof(
+	ctx context.Context,
+	proofBytes []byte,
+	req *syncpb.ChangeProofRequest,
+	endRoot ids.ID,
+	db *merkledb.Database,
+) (*merkledb.ChangeProof, error) {
+	if len(proofBytes) > int(req.BytesLimit) {
+		return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyBytes, len(proofBytes), req.BytesLimit)
+	}
+
+	changeProof := &merkledb.ChangeProof{}
+	if _, err := c.codec.DecodeChangeProof(proofBytes, changeProof); err != nil {
+		return nil, err
+	}
+
+	// Ensure the response does not contain more than the requested number of leaves
+	// and the start and end roots match the requested roots.
+	if len(changeProof.KeyChanges) > int(req.KeyLimit) {
+		return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyKeys, len(changeProof.KeyChanges), req.KeyLimit)
+	}
+
+	if err := changeProof.Verify(ctx, db, req.Start, req.End, endRoot); err != nil {
+		return nil, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
+	}
+	return changeProof, nil
+}
+
 // GetRangeProof synchronously retrieves the range proof given by [req].
 // Upon failure, retries until the context is expired.
 // The returned range proof is verified.
 func (c *client) GetRangeProof(ctx context.Context, req *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
-	parseFn := func(ctx context.Context, responseBytes []byte) (*merkledb.RangeProof, error) {
-		if len(responseBytes) > int(req.BytesLimit) {
-			return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyBytes, len(responseBytes), req.BytesLimit)
-		}
-
-		rangeProof := &merkledb.RangeProof{}
-		if _, err := merkledb.Codec.DecodeRangeProof(responseBytes, rangeProof); err != nil {
-			return nil, err
-		}
-
-		// Ensure the response does not contain more than the maximum requested number of leaves.
-		if len(rangeProof.KeyValues) > int(req.KeyLimit) {
-			return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyKeys, len(rangeProof.KeyValues), req.KeyLimit)
+	parseFn := func(ctx context.Context, response *syncpb.Response) (*merkledb.RangeProof, error) {
+		message, ok := response.GetMessage().(*syncpb.Response_Proof)
+		if !ok {
+			return nil, errUnexpectedProof
 		}
 
 		root, err := ids.ToID(req.Root)
 		if err != nil {
 			return nil, err
 		}
-
-		if err := rangeProof.Verify(
+		return c.parseRangeProof(
 			ctx,
+			message.Proof,
 			req.Start,
 			req.End,
 			root,
-		); err != nil {
-			return nil, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
-		}
-		return rangeProof, nil
+			req.KeyLimit,
+			req.BytesLimit,
+		)
 	}
 
 	reqBytes, err := proto.Marshal(&syncpb.Request{
 		Message: &syncpb.Request_RangeProofRequest{
 			RangeProofRequest: req,
 		},
+		WantsResponse: true,
 	})
 	if err != nil {
 		return nil, err
@@ -165,11 +233,50 @@ func (c *client) GetRangeProof(ctx context.Context, req *syncpb.RangeProofReques
 	return getAndParse(ctx, c, reqBytes, parseFn)
 }
 
+// Returns the range proof in [proofBytes] if it's a valid proof of at most
+// [keyLimit] keys in [start, end] at [root], and it's at most [bytesLimit]
+// bytes.
+func (c *client) parseRangeProof(
+	ctx context.Context,
+	proofBytes []byte,
+	start []byte,
+	end []byte,
+	root ids.ID,
+	keyLimit uint32,
+	bytesLimit uint32,
+) (*merkledb.RangeProof, error) {
+	if len(proofBytes) > int(bytesLimit) {
+		return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyBytes, len(proofBytes), bytesLimit)
+	}
+
+	rangeProof := &merkledb.RangeProof{}
+	if _, err := c.codec.DecodeRangeProof(proofBytes, rangeProof); err != nil {
+		return nil, err
+	}
+
+	// Ensure the response does not contain more than the maximum requested number of leaves.
+	if len(rangeProof.KeyValues) > int(keyLimit) {
+		return nil, fmt.Errorf("%w: (%d) > %d)", errTooManyKeys, len(rangeProof.KeyValues), keyLimit)
+	}
+
+	if err := rangeProof.Verify(
+		ctx,
+		start,
+		end,
+		root,
+		c.branchFactor,
+		c.hasher,
+	); err != nil {
+		return nil, fmt.Errorf("%s due to %w", errInvalidRangeProof, err)
+	}
+	return rangeProof, nil
+}
+
 // getAndParse uses [client] to send [request] to an arbitrary peer. If the peer responds,
 // [parseFn] is called with the raw response. If [parseFn] returns an error or the request
 // times out, this function will retry the request to a different peer until [ctx] expires.
 // If [parseFn] returns a nil error, the result is returned from getAndParse.
-func getAndParse[T any](ctx context.Context, client *client, request []byte, parseFn func(context.Context, []byte) (*T, error)) (*T, error) {
+func getAndParse[T any](ctx context.Context, client *client, request []byte, parseFn func(context.Context, *syncpb.Response) (*T, error)) (*T, error) {
 	var (
 		lastErr  error
 		response *T
@@ -183,9 +290,9 @@ func getAndParse[T any](ctx context.Context, client *client, request []byte, par
 			}
 			return nil, err
 		}
-		responseBytes, nodeID, err := client.get(ctx, request)
+		proofResponse, nodeID, err := client.get(ctx, request)
 		if err == nil {
-			if response, err = parseFn(ctx, responseBytes); err == nil {
+			if response, err = parseFn(ctx, proofResponse); err == nil {
 				return response, nil
 			}
 		}
@@ -204,33 +311,70 @@ func getAndParse[T any](ctx context.Context, client *client, request []byte, par
 }
 
 // get sends [request] to an arbitrary peer and blocks until the node receives a response
-// or [ctx] expires. Returns the raw response from the peer, the peer's NodeID, and an
-// error if the request timed out. Thread safe.
-func (c *client) get(ctx context.Context, requestBytes []byte) ([]byte, ids.NodeID, error) {
+// or [ctx] expires. Returns the response containing a proof from the peer, the peer's NodeID, and an
+// error if the request timed out or the peer responded with an error. Thread safe.
+func (c *client) get(ctx context.Context, requestBytes []byte) (*syncpb.Response, ids.NodeID, error) {
 	c.metrics.RequestMade()
 	var (
-		response  []byte
-		nodeID    ids.NodeID
-		err       error
-		startTime = time.Now()
+		responseBytes []byte
+		response      *syncpb.Response
+		nodeID        ids.NodeID
+		err           error
+		startTime     = time.Now()
 	)
 	if len(c.stateSyncNodes) == 0 {
-		response, nodeID, err = c.networkClient.RequestAny(ctx, c.stateSyncMinVersion, requestBytes)
+		responseBytes, nodeID, err = c.networkClient.RequestAny(ctx, c.stateSyncMinVersion, requestBytes)
 	} else {
 		// get the next nodeID using the nodeIdx offset. If we're out of nodes, loop back to 0
 		// we do this every attempt to ensure we get a different node each time if possible.
 		nodeIdx := atomic.AddUint32(&c.stateSyncNodeIdx, 1)
 		nodeID = c.stateSyncNodes[nodeIdx%uint32(len(c.stateSyncNodes))]
-		response, err = c.networkClient.Request(ctx, nodeID, requestBytes)
+		responseBytes, err = c.networkClient.Request(ctx, nodeID, requestBytes)
+	}
+	if err == nil {
+		response, err = parseResponse(responseBytes)
 	}
 	if err != nil {
 		c.metrics.RequestFailed()
 		c.networkClient.TrackBandwidth(nodeID, 0)
-		return response, nodeID, err
+		return nil, nodeID, err
 	}
 
-	bandwidth := float64(len(response)) / (time.Since(startTime).Seconds() + epsilon)
+	bandwidth := float64(len(responseBytes)) / (time.Since(startTime).Seconds() + epsilon)
 	c.networkClient.TrackBandwidth(nodeID, bandwidth)
 	c.metrics.RequestSucceeded()
 	return response, nodeID, nil
 }
+
+// Returns the response in [responseBytes] if it contains a proof, or the error
+// that the peer responded with.
+func parseResponse(responseBytes []byte) (*syncpb.Response, error) {
+	// Peers that predate Response ignore [WantsResponse] and reply with the
+	// encoded proof. Encoded proofs start with the codec version, which is 0,
+	// while a non-empty Response never starts with a 0 byte.
+	if len(responseBytes) > 0 && responseBytes[0] == 0 {
+		return &syncpb.Response{
+			Message: &syncpb.Response_Proof{
+				Proof: responseBytes,
+			},
+		}, nil
+	}
+
+	response := &syncpb.Response{}
+	if err := proto.Unmarshal(responseBytes, response); err != nil {
+		return nil, err
+	}
+
+	switch message := response.GetMessage().(type) {
+	case *syncpb.Response_Proof, *syncpb.Response_RangeProof:
+		return response, nil
+	case *syncpb.Response_Error:
+		err, ok := responseErrors[message.Error.GetCode()]
+		if !ok {
+			err = errUnknownErrorCode
+		}
+		return nil, fmt.Errorf("%w: %s", err, message.Error.GetMessage())
+	default:
+		return nil, errEmptyResponse
+	}
+}
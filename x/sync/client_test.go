@@ -5,6 +5,7 @@ package sync
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"sync"
 	"testing"
@@ -14,6 +15,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"google.golang.org/protobuf/proto"
+
 	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/engine/common"
@@ -42,7 +45,7 @@ func sendRangeRequest(
 	defer ctrl.Finish()
 
 	sender := common.NewMockSender(ctrl)
-	handler := NewNetworkServer(sender, db, logging.NoLog{})
+	handler := NewNetworkServer(sender, db, logging.NoLog{}, NetworkServerConfig{Metrics: &mockServerMetrics{}})
 	clientNodeID, serverNodeID := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
 	networkClient := NewNetworkClient(sender, clientNodeID, 1, logging.NoLog{})
 	err := networkClient.Connected(context.Background(), serverNodeID, version.CurrentApp)
@@ -51,7 +54,9 @@ func sendRangeRequest(
 		NetworkClient: networkClient,
 		Metrics:       &mockMetrics{},
 		Log:           logging.NoLog{},
+		DB:            db,
 	})
+	codec := merkledb.NewCodec(db.BranchFactor())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	deadline := time.Now().Add(1 * time.Hour) // enough time to complete a request
@@ -89,8 +94,13 @@ func sendRangeRequest(
 	).DoAndReturn(
 		func(_ context.Context, _ ids.NodeID, requestID uint32, responseBytes []byte) error {
 			// deserialize the response so we can modify it if needed.
+			proofResponse, err := parseResponse(responseBytes)
+			if err != nil {
+				// pass error responses to the client unmodified.
+				return networkClient.AppResponse(context.Background(), serverNodeID, requestID, responseBytes)
+			}
 			response := &merkledb.RangeProof{}
-			_, err := merkledb.Codec.DecodeRangeProof(responseBytes, response)
+			_, err = codec.DecodeRangeProof(proofResponse.GetProof(), response)
 			require.NoError(err)
 
 			// modify if needed
@@ -99,7 +109,13 @@ func sendRangeRequest(
 			}
 
 			// reserialize the response and pass it to the client to complete the handling.
-			responseBytes, err = merkledb.Codec.EncodeRangeProof(merkledb.Version, response)
+			proofBytes, err := codec.EncodeRangeProof(merkledb.Version, response)
+			require.NoError(err)
+			responseBytes, err = proto.Marshal(&syncpb.Response{
+				Message: &syncpb.Response_Proof{
+					Proof: proofBytes,
+				},
+			})
 			require.NoError(err)
 			err = networkClient.AppResponse(context.Background(), serverNodeID, requestID, responseBytes)
 			require.NoError(err)
@@ -246,6 +262,15 @@ func TestGetRangeProof(t *testing.T) {
 			},
 			expectedErr: merkledb.ErrInvalidProof,
 		},
+		"root not found": {
+			db: largeTrieDB,
+			request: &syncpb.RangeProofRequest{
+				Root:       ids.Empty[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+			expectedErr: ErrRootNotFound,
+		},
 		"all proof keys removed from response": {
 			db: largeTrieDB,
 			request: &syncpb.RangeProofRequest{
@@ -280,6 +305,45 @@ func TestGetRangeProof(t *testing.T) {
 	}
 }
 
+func TestGetRangeProof_TrieConfigs(t *testing.T) {
+	for _, branchFactor := range []merkledb.BranchFactor{
+		merkledb.BranchFactor2,
+		merkledb.BranchFactor4,
+		merkledb.BranchFactor16,
+		merkledb.BranchFactor256,
+	} {
+		for _, hasher := range []merkledb.Hasher{merkledb.SHA256Hasher, merkledb.BLAKE2bHasher} {
+			t.Run(fmt.Sprintf("%d/%s", branchFactor, hasher), func(t *testing.T) {
+				require := require.New(t)
+
+				r := rand.New(rand.NewSource(int64(branchFactor))) // #nosec G404
+				db, _, err := generateTrieWithTrieConfig(t, r, 1000, 1, branchFactor, hasher)
+				require.NoError(err)
+				root, err := db.GetMerkleRoot(context.Background())
+				require.NoError(err)
+
+				proof, err := sendRangeRequest(t, db, &syncpb.RangeProofRequest{
+					Root:       root[:],
+					KeyLimit:   100,
+					BytesLimit: defaultRequestByteSizeLimit,
+				}, 1, nil)
+				require.NoError(err)
+				require.Len(proof.KeyValues, 100)
+
+				// A tampered response is rejected.
+				_, err = sendRangeRequest(t, db, &syncpb.RangeProofRequest{
+					Root:       root[:],
+					KeyLimit:   100,
+					BytesLimit: defaultRequestByteSizeLimit,
+				}, 1, func(response *merkledb.RangeProof) {
+					response.KeyValues = response.KeyValues[1:]
+				})
+				require.ErrorIs(err, merkledb.ErrInvalidProof)
+			})
+		}
+	}
+}
+
 func sendChangeRequest(
 	t *testing.T,
 	db *merkledb.Database,
@@ -287,7 +351,7 @@ func sendChangeRequest(
 	request *syncpb.ChangeProofRequest,
 	maxAttempts uint32,
 	modifyResponse func(*merkledb.ChangeProof),
-) (*merkledb.ChangeProof, error) {
+) (*ChangeOrRangeProof, error) {
 	t.Helper()
 
 	var wg sync.WaitGroup
@@ -298,7 +362,7 @@ func sendChangeRequest(
 	defer ctrl.Finish()
 
 	sender := common.NewMockSender(ctrl)
-	handler := NewNetworkServer(sender, db, logging.NoLog{})
+	handler := NewNetworkServer(sender, db, logging.NoLog{}, NetworkServerConfig{Metrics: &mockServerMetrics{}})
 	clientNodeID, serverNodeID := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
 	networkClient := NewNetworkClient(sender, clientNodeID, 1, logging.NoLog{})
 	err := networkClient.Connected(context.Background(), serverNodeID, version.CurrentApp)
@@ -307,6 +371,7 @@ func sendChangeRequest(
 		NetworkClient: networkClient,
 		Metrics:       &mockMetrics{},
 		Log:           logging.NoLog{},
+		DB:            verificationDB,
 	})
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -345,8 +410,14 @@ func sendChangeRequest(
 	).DoAndReturn(
 		func(_ context.Context, _ ids.NodeID, requestID uint32, responseBytes []byte) error {
 			// deserialize the response so we can modify it if needed.
+			proofResponse, err := parseResponse(responseBytes)
+			if err != nil || proofResponse.GetRangeProof() != nil {
+				// pass error responses and range proofs sent instead of
+				// change proofs to the client unmodified.
+				return networkClient.AppResponse(context.Background(), serverNodeID, requestID, responseBytes)
+			}
 			response := &merkledb.ChangeProof{}
-			_, err := merkledb.Codec.DecodeChangeProof(responseBytes, response)
+			_, err = merkledb.Codec.DecodeChangeProof(proofResponse.GetProof(), response)
 			require.NoError(err)
 
 			// modify if needed
@@ -355,7 +426,13 @@ func sendChangeRequest(
 			}
 
 			// reserialize the response and pass it to the client to complete the handling.
-			responseBytes, err = merkledb.Codec.EncodeChangeProof(merkledb.Version, response)
+			proofBytes, err := merkledb.Codec.EncodeChangeProof(merkledb.Version, response)
+			require.NoError(err)
+			responseBytes, err = proto.Marshal(&syncpb.Response{
+				Message: &syncpb.Response_Proof{
+					Proof: proofBytes,
+				},
+			})
 			require.NoError(err)
 			err = networkClient.AppResponse(context.Background(), serverNodeID, requestID, responseBytes)
 			require.NoError(err)
@@ -429,6 +506,8 @@ func TestGetChangeProof(t *testing.T) {
 
 	endRoot, err := trieDB.GetMerkleRoot(context.Background())
 	require.NoError(t, err)
+	missingRoot := ids.GenerateTestID()
+	missingEndRoot := ids.GenerateTestID()
 
 	tests := map[string]struct {
 		db                  *merkledb.Database
@@ -436,6 +515,9 @@ func TestGetChangeProof(t *testing.T) {
 		modifyResponse      func(*merkledb.ChangeProof)
 		expectedErr         error
 		expectedResponseLen int
+		// True if the start root isn't in the history, so a range proof at
+		// the end root is expected instead of a change proof.
+		expectedRangeProof bool
 	}{
 		"proof restricted by BytesLimit": {
 			request: &syncpb.ChangeProofRequest{
@@ -524,6 +606,34 @@ func TestGetChangeProof(t *testing.T) {
 			},
 			expectedErr: merkledb.ErrInvalidProof,
 		},
+		"start root not in history": {
+			request: &syncpb.ChangeProofRequest{
+				StartRoot:  missingRoot[:],
+				EndRoot:    endRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+			expectedResponseLen: defaultRequestKeyLimit,
+			expectedRangeProof:  true,
+		},
+		"start root not in history proof restricted by BytesLimit": {
+			request: &syncpb.ChangeProofRequest{
+				StartRoot:  missingRoot[:],
+				EndRoot:    endRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: 10000,
+			},
+			expectedRangeProof: true,
+		},
+		"start and end roots not in history": {
+			request: &syncpb.ChangeProofRequest{
+				StartRoot:  missingRoot[:],
+				EndRoot:    missingEndRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+			expectedErr: ErrRootNotFound,
+		},
 	}
 
 	for name, test := range tests {
@@ -536,10 +646,24 @@ func TestGetChangeProof(t *testing.T) {
 				return
 			}
 			require.NoError(err)
+			if test.expectedRangeProof {
+				require.Nil(proof.ChangeProof)
+				require.NotNil(proof.RangeProof)
+				require.NotEmpty(proof.RangeProof.KeyValues)
+				if test.expectedResponseLen > 0 {
+					require.LessOrEqual(len(proof.RangeProof.KeyValues), test.expectedResponseLen)
+				}
+				bytes, err := merkledb.Codec.EncodeRangeProof(merkledb.Version, proof.RangeProof)
+				require.NoError(err)
+				require.LessOrEqual(len(bytes), int(test.request.BytesLimit))
+				return
+			}
+			require.Nil(proof.RangeProof)
+			require.NotNil(proof.ChangeProof)
 			if test.expectedResponseLen > 0 {
-				require.LessOrEqual(len(proof.KeyChanges), test.expectedResponseLen)
+				require.LessOrEqual(len(proof.ChangeProof.KeyChanges), test.expectedResponseLen)
 			}
-			bytes, err := merkledb.Codec.EncodeChangeProof(merkledb.Version, proof)
+			bytes, err := merkledb.Codec.EncodeChangeProof(merkledb.Version, proof.ChangeProof)
 			require.NoError(err)
 			require.LessOrEqual(len(bytes), int(test.request.BytesLimit))
 		})
@@ -9,11 +9,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/memeticofficial/pepecoingo/utils/wrappers"
+
+	syncpb "github.com/memeticofficial/pepecoingo/proto/pb/sync"
 )
 
 var (
-	_ SyncMetrics = &mockMetrics{}
-	_ SyncMetrics = &metrics{}
+	_ SyncMetrics   = &mockMetrics{}
+	_ SyncMetrics   = &metrics{}
+	_ ServerMetrics = &mockServerMetrics{}
+	_ ServerMetrics = &serverMetrics{}
 )
 
 type SyncMetrics interface {
@@ -94,3 +98,128 @@ func (m *metrics) RequestMade() {
 func (m *metrics) RequestSucceeded() {
 	m.requestsSucceeded.Inc()
 }
+
+type ServerMetrics interface {
+	RequestReceived()
+	RequestThrottled()
+	ErrorResponseSent(code syncpb.ErrorCode)
+	ProofCacheHit()
+	ProofCacheMiss()
+}
+
+type mockServerMetrics struct {
+	lock               sync.Mutex
+	requestsReceived   int
+	requestsThrottled  int
+	errorResponsesSent map[syncpb.ErrorCode]int
+	proofCacheHits     int
+	proofCacheMisses   int
+}
+
+func (m *mockServerMetrics) RequestReceived() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.requestsReceived++
+}
+
+func (m *mockServerMetrics) RequestThrottled() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.requestsThrottled++
+}
+
+func (m *mockServerMetrics) ErrorResponseSent(code syncpb.ErrorCode) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.errorResponsesSent == nil {
+		m.errorResponsesSent = make(map[syncpb.ErrorCode]int)
+	}
+	m.errorResponsesSent[code]++
+}
+
+func (m *mockServerMetrics) ProofCacheHit() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.proofCacheHits++
+}
+
+func (m *mockServerMetrics) ProofCacheMiss() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.proofCacheMisses++
+}
+
+type serverMetrics struct {
+	requestsReceived   prometheus.Counter
+	requestsThrottled  prometheus.Counter
+	errorResponsesSent *prometheus.CounterVec
+	proofCacheHits     prometheus.Counter
+	proofCacheMisses   prometheus.Counter
+}
+
+func NewServerMetrics(namespace string, reg prometheus.Registerer) (ServerMetrics, error) {
+	m := serverMetrics{
+		requestsReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "server_requests_received",
+			Help:      "cumulative amount of proof requests received",
+		}),
+		requestsThrottled: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "server_requests_throttled",
+			Help:      "cumulative amount of proof requests rejected due to throttling",
+		}),
+		errorResponsesSent: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "server_error_responses_sent",
+				Help:      "cumulative amount of error responses sent, by error code",
+			},
+			[]string{"code"},
+		),
+		proofCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "server_proof_cache_hits",
+			Help:      "cumulative amount of proof requests served from the proof cache",
+		}),
+		proofCacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "server_proof_cache_misses",
+			Help:      "cumulative amount of proof requests that weren't in the proof cache",
+		}),
+	}
+	errs := wrappers.Errs{}
+	errs.Add(
+		reg.Register(m.requestsReceived),
+		reg.Register(m.requestsThrottled),
+		reg.Register(m.errorResponsesSent),
+		reg.Register(m.proofCacheHits),
+		reg.Register(m.proofCacheMisses),
+	)
+	return &m, errs.Err
+}
+
+func (m *serverMetrics) RequestReceived() {
+	m.requestsReceived.Inc()
+}
+
+func (m *serverMetrics) RequestThrottled() {
+	m.requestsThrottled.Inc()
+}
+
+func (m *serverMetrics) ErrorResponseSent(code syncpb.ErrorCode) {
+	m.errorResponsesSent.WithLabelValues(code.String()).Inc()
+}
+
+func (m *serverMetrics) ProofCacheHit() {
+	m.proofCacheHits.Inc()
+}
+
+func (m *serverMetrics) ProofCacheMiss() {
+	m.proofCacheMisses.Inc()
+}
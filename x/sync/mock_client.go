@@ -37,10 +37,10 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // GetChangeProof mocks base method.
-func (m *MockClient) GetChangeProof(arg0 context.Context, arg1 *sync.ChangeProofRequest, arg2 *merkledb.Database) (*merkledb.ChangeProof, error) {
+func (m *MockClient) GetChangeProof(arg0 context.Context, arg1 *sync.ChangeProofRequest, arg2 *merkledb.Database) (*ChangeOrRangeProof, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetChangeProof", arg0, arg1, arg2)
-	ret0, _ := ret[0].(*merkledb.ChangeProof)
+	ret0, _ := ret[0].(*ChangeOrRangeProof)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
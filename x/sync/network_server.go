@@ -17,6 +17,7 @@ import (
 
 	"google.golang.org/protobuf/proto"
 
+	"github.com/memeticofficial/pepecoingo/cache"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/engine/common"
 	"github.com/memeticofficial/pepecoingo/utils/constants"
@@ -39,21 +40,92 @@ const (
 	estimatedMessageOverhead = 4 * units.KiB
 	maxByteSizeLimit         = constants.DefaultMaxMessageSize - estimatedMessageOverhead
 	endProofSizeBufferAmount = 2 * units.KiB
+
+	defaultRequestsPerSecondPerPeer     = 20
+	defaultRequestBurstPerPeer          = 40
+	defaultMaxConcurrentRequestsPerPeer = 8
+	defaultMaxConcurrentRequests        = 32
+	defaultProofCacheSize               = 16 * units.MiB
+)
+
+var (
+	ErrMinProofSizeIsTooLarge = errors.New("cannot generate any proof within the requested limit")
+	ErrInvalidRequest         = errors.New("invalid request")
+	ErrRootNotFound           = errors.New("requested root not found")
+	ErrRequestThrottled       = errors.New("request throttled")
+	ErrInternalServerError    = errors.New("internal server error")
 )
 
-var ErrMinProofSizeIsTooLarge = errors.New("cannot generate any proof within the requested limit")
+type NetworkServerConfig struct {
+	Metrics ServerMetrics
+	// The rate, in requests per second, at which requests from a peer are
+	// handled. Requests over the rate are rejected.
+	// 0 means [defaultRequestsPerSecondPerPeer].
+	RequestsPerSecondPerPeer float64
+	// The number of requests from a peer that can be handled in a burst over
+	// [RequestsPerSecondPerPeer].
+	// 0 means [defaultRequestBurstPerPeer].
+	RequestBurstPerPeer int
+	// The maximum number of requests from a peer that are handled at once.
+	// 0 means [defaultMaxConcurrentRequestsPerPeer].
+	MaxConcurrentRequestsPerPeer int
+	// The maximum number of requests that are handled at once.
+	// 0 means [defaultMaxConcurrentRequests].
+	MaxConcurrentRequests int
+	// The maximum total size, in bytes, of the recently generated proofs that
+	// are cached.
+	// 0 means [defaultProofCacheSize].
+	ProofCacheSize int
+}
 
 type NetworkServer struct {
 	appSender common.AppSender // Used to respond to peer requests via AppResponse.
 	db        *merkledb.Database
+	// Encodes the proofs of [db].
+	codec     merkledb.EncoderDecoder
 	log       logging.Logger
+	metrics   ServerMetrics
+	throttler *requestThrottler
+	// Request bytes --> response bytes of recently generated proofs.
+	proofCache cache.Cacher[string, []byte]
 }
 
-func NewNetworkServer(appSender common.AppSender, db *merkledb.Database, log logging.Logger) *NetworkServer {
+func NewNetworkServer(
+	appSender common.AppSender,
+	db *merkledb.Database,
+	log logging.Logger,
+	config NetworkServerConfig,
+) *NetworkServer {
+	if config.RequestsPerSecondPerPeer == 0 {
+		config.RequestsPerSecondPerPeer = defaultRequestsPerSecondPerPeer
+	}
+	if config.RequestBurstPerPeer == 0 {
+		config.RequestBurstPerPeer = defaultRequestBurstPerPeer
+	}
+	if config.MaxConcurrentRequestsPerPeer == 0 {
+		config.MaxConcurrentRequestsPerPeer = defaultMaxConcurrentRequestsPerPeer
+	}
+	if config.MaxConcurrentRequests == 0 {
+		config.MaxConcurrentRequests = defaultMaxConcurrentRequests
+	}
+	if config.ProofCacheSize == 0 {
+		config.ProofCacheSize = defaultProofCacheSize
+	}
 	return &NetworkServer{
 		appSender: appSender,
 		db:        db,
+		codec:     merkledb.NewCodec(db.BranchFactor()),
 		log:       log,
+		metrics:   config.Metrics,
+		throttler: newRequestThrottler(
+			config.RequestsPerSecondPerPeer,
+			config.RequestBurstPerPeer,
+			config.MaxConcurrentRequestsPerPeer,
+			config.MaxConcurrentRequests,
+		),
+		proofCache: cache.NewSizedLRU(config.ProofCacheSize, func(key string, proofBytes []byte) int {
+			return len(key) + len(proofBytes)
+		}),
 	}
 }
 
@@ -67,6 +139,8 @@ func (s *NetworkServer) AppRequest(
 	deadline time.Time,
 	request []byte,
 ) error {
+	s.metrics.RequestReceived()
+
 	var req syncpb.Request
 	if err := proto.Unmarshal(request, &req); err != nil {
 		s.log.Debug(
@@ -76,8 +150,22 @@ func (s *NetworkServer) AppRequest(
 			zap.Int("requestLen", len(request)),
 			zap.Error(err),
 		)
+		s.sendErrorOrLog(ctx, nodeID, requestID, true /*=wantsResponse*/, ErrInvalidRequest)
 		return nil
 	}
+
+	if !s.throttler.acquire(nodeID) {
+		s.log.Debug(
+			"throttling AppRequest from node",
+			zap.Stringer("nodeID", nodeID),
+			zap.Uint32("requestID", requestID),
+		)
+		s.metrics.RequestThrottled()
+		s.sendErrorOrLog(ctx, nodeID, requestID, req.WantsResponse, ErrRequestThrottled)
+		return nil
+	}
+	defer s.throttler.release(nodeID)
+
 	s.log.Debug(
 		"processing AppRequest from node",
 		zap.Stringer("nodeID", nodeID),
@@ -105,19 +193,20 @@ func (s *NetworkServer) AppRequest(
 	defer cancel()
 
 	var err error
-	switch req := req.GetMessage().(type) {
+	switch message := req.GetMessage().(type) {
 	case *syncpb.Request_ChangeProofRequest:
-		err = s.HandleChangeProofRequest(ctx, nodeID, requestID, req.ChangeProofRequest)
+		err = s.handleChangeProofRequest(ctx, nodeID, requestID, &req, message.ChangeProofRequest)
 	case *syncpb.Request_RangeProofRequest:
-		err = s.HandleRangeProofRequest(ctx, nodeID, requestID, req.RangeProofRequest)
+		err = s.handleRangeProofRequest(ctx, nodeID, requestID, &req, message.RangeProofRequest)
 	default:
 		s.log.Debug(
 			"unknown AppRequest type",
 			zap.Stringer("nodeID", nodeID),
 			zap.Uint32("requestID", requestID),
 			zap.Int("requestLen", len(request)),
-			zap.String("requestType", fmt.Sprintf("%T", req)),
+			zap.String("requestType", fmt.Sprintf("%T", message)),
 		)
+		s.sendErrorOrLog(ctx, nodeID, requestID, req.WantsResponse, ErrInvalidRequest)
 		return nil
 	}
 
@@ -146,25 +235,202 @@ func isTimeout(err error) bool {
 	return errors.Is(err, context.DeadlineExceeded)
 }
 
-// Generates a change proof and sends it to [nodeID].
+// Returns the code that [err] is reported to peers with.
+func errorCode(err error) syncpb.ErrorCode {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST
+	case errors.Is(err, merkledb.ErrRootIDNotPresent), errors.Is(err, merkledb.ErrStartRootNotFound):
+		return syncpb.ErrorCode_ERROR_CODE_ROOT_NOT_FOUND
+	case errors.Is(err, ErrRequestThrottled):
+		return syncpb.ErrorCode_ERROR_CODE_THROTTLED
+	case errors.Is(err, ErrMinProofSizeIsTooLarge):
+		return syncpb.ErrorCode_ERROR_CODE_PROOF_TOO_LARGE
+	default:
+		return syncpb.ErrorCode_ERROR_CODE_INTERNAL
+	}
+}
+
+// Notifies [nodeID] that [requestID] failed with [err].
+// If [wantsResponse] is false, the peer predates error responses, so nothing
+// is sent, which the peer handles like a timeout.
+func (s *NetworkServer) sendError(ctx context.Context, nodeID ids.NodeID, requestID uint32, wantsResponse bool, err error) error {
+	if !wantsResponse {
+		return nil
+	}
+
+	code := errorCode(err)
+	message := err.Error()
+	if code == syncpb.ErrorCode_ERROR_CODE_INTERNAL {
+		// Don't expose the details of internal errors to peers.
+		message = ErrInternalServerError.Error()
+	}
+	s.metrics.ErrorResponseSent(code)
+
+	responseBytes, err := proto.Marshal(&syncpb.Response{
+		Message: &syncpb.Response_Error{
+			Error: &syncpb.Error{
+				Code:    code,
+				Message: message,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.appSender.SendAppResponse(ctx, nodeID, requestID, responseBytes)
+}
+
+// Notifies [nodeID] that [requestID] failed with [err], logging any failure to
+// do so instead of returning it, since errors from AppRequest are fatal.
+func (s *NetworkServer) sendErrorOrLog(ctx context.Context, nodeID ids.NodeID, requestID uint32, wantsResponse bool, err error) {
+	if err := s.sendError(ctx, nodeID, requestID, wantsResponse, err); err != nil {
+		s.log.Warn(
+			"failed to send error response",
+			zap.Stringer("nodeID", nodeID),
+			zap.Uint32("requestID", requestID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Returns the key of the proof for [req] in [s.proofCache].
+func proofCacheKey(req *syncpb.Request) (string, error) {
+	keyBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	return string(keyBytes), err
+}
+
+// Sends the response to [req] to [nodeID], or an error response if it can't be
+// generated. The response is generated by [getResponse] unless it's cached.
+// Returns an error if the response couldn't be generated for a reason other
+// than the request being invalid.
+func (s *NetworkServer) handleProofRequest(
+	ctx context.Context,
+	nodeID ids.NodeID,
+	requestID uint32,
+	req *syncpb.Request,
+	getResponse func(context.Context) (*syncpb.Response, error),
+) error {
+	key, err := proofCacheKey(req)
+	if err != nil {
+		return err
+	}
+	if responseBytes, ok := s.proofCache.Get(key); ok {
+		s.metrics.ProofCacheHit()
+		return s.appSender.SendAppResponse(ctx, nodeID, requestID, responseBytes)
+	}
+	s.metrics.ProofCacheMiss()
+
+	response, err := getResponse(ctx)
+	if err != nil {
+		if sendErr := s.sendError(ctx, nodeID, requestID, req.WantsResponse, err); sendErr != nil {
+			return sendErr
+		}
+		// handle expected errors so clients cannot cause servers to spam warning logs.
+		if errorCode(err) == syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST ||
+			errorCode(err) == syncpb.ErrorCode_ERROR_CODE_ROOT_NOT_FOUND {
+			s.log.Debug(
+				"dropping invalid request",
+				zap.Stringer("nodeID", nodeID),
+				zap.Uint32("requestID", requestID),
+				zap.Stringer("req", req),
+				zap.Error(err),
+			)
+			return nil
+		}
+		return err
+	}
+
+	responseBytes, err := encodeResponse(req, response)
+	if err != nil {
+		return err
+	}
+	if responseBytes == nil {
+		s.log.Debug(
+			"dropping request that can't be answered in the legacy format",
+			zap.Stringer("nodeID", nodeID),
+			zap.Uint32("requestID", requestID),
+			zap.Stringer("req", req),
+		)
+		return nil
+	}
+	s.proofCache.Put(key, responseBytes)
+	return s.appSender.SendAppResponse(ctx, nodeID, requestID, responseBytes)
+}
+
+// Returns the bytes of [response] to send in reply to [req], or nil if
+// [response] can't be sent.
+//
+// Peers that predate Response don't set [req.WantsResponse], and are sent the
+// encoded proof instead. They can't be sent the range proof that replaces a
+// change proof, since they would parse it as a change proof.
+func encodeResponse(req *syncpb.Request, response *syncpb.Response) ([]byte, error) {
+	if req.WantsResponse {
+		return proto.Marshal(response)
+	}
+	if message, ok := response.GetMessage().(*syncpb.Response_Proof); ok {
+		return message.Proof, nil
+	}
+	return nil, nil
+}
+
+// Generates a change proof and sends it to [nodeID] in a Response.
+// If the start root isn't in the history, a range proof at the end root is
+// sent instead.
 func (s *NetworkServer) HandleChangeProofRequest(
 	ctx context.Context,
 	nodeID ids.NodeID,
 	requestID uint32,
 	req *syncpb.ChangeProofRequest,
 ) error {
+	return s.handleChangeProofRequest(
+		ctx,
+		nodeID,
+		requestID,
+		&syncpb.Request{
+			Message: &syncpb.Request_ChangeProofRequest{
+				ChangeProofRequest: req,
+			},
+			WantsResponse: true,
+		},
+		req,
+	)
+}
+
+// Generates a change proof and sends it to [nodeID] in the format that
+// [request] wants.
+func (s *NetworkServer) handleChangeProofRequest(
+	ctx context.Context,
+	nodeID ids.NodeID,
+	requestID uint32,
+	request *syncpb.Request,
+	req *syncpb.ChangeProofRequest,
+) error {
+	return s.handleProofRequest(
+		ctx,
+		nodeID,
+		requestID,
+		request,
+		func(ctx context.Context) (*syncpb.Response, error) {
+			return s.getChangeProof(ctx, req)
+		},
+	)
+}
+
+// Returns the response to [req]. It contains the encoded change proof given by
+// [req], or, if the start root isn't in the history before the end root, the
+// encoded range proof of [req.Start, req.End] at the end root.
+//
+// Either response can be cached, since a range proof at the end root remains a
+// valid response to [req] even if the start root is later added to the history.
+func (s *NetworkServer) getChangeProof(ctx context.Context, req *syncpb.ChangeProofRequest) (*syncpb.Response, error) {
 	if req.BytesLimit == 0 ||
 		req.KeyLimit == 0 ||
 		len(req.StartRoot) != hashing.HashLen ||
 		len(req.EndRoot) != hashing.HashLen ||
+		bytes.Equal(req.StartRoot, req.EndRoot) ||
 		(len(req.End) > 0 && bytes.Compare(req.Start, req.End) > 0) {
-		s.log.Debug(
-			"dropping invalid change proof request",
-			zap.Stringer("nodeID", nodeID),
-			zap.Uint32("requestID", requestID),
-			zap.Stringer("req", req),
-		)
-		return nil // dropping request
+		return nil, ErrInvalidRequest
 	}
 
 	// override limit if it is greater than maxKeyValuesLimit
@@ -177,46 +443,55 @@ func (s *NetworkServer) HandleChangeProofRequest(
 		bytesLimit = maxByteSizeLimit
 	}
 
+	startRoot, err := ids.ToID(req.StartRoot)
+	if err != nil {
+		return nil, err
+	}
+	endRoot, err := ids.ToID(req.EndRoot)
+	if err != nil {
+		return nil, err
+	}
+
 	// attempt to get a proof within the bytes limit
 	for keyLimit > 0 {
-		startRoot, err := ids.ToID(req.StartRoot)
-		if err != nil {
-			return err
-		}
-		endRoot, err := ids.ToID(req.EndRoot)
-		if err != nil {
-			return err
-		}
 		changeProof, err := s.db.GetChangeProof(ctx, startRoot, endRoot, req.Start, req.End, int(keyLimit))
-		if err != nil {
-			// handle expected errors so clients cannot cause servers to spam warning logs.
-			if errors.Is(err, merkledb.ErrRootIDNotPresent) || errors.Is(err, merkledb.ErrStartRootNotFound) {
-				s.log.Debug(
-					"dropping invalid change proof request",
-					zap.Stringer("nodeID", nodeID),
-					zap.Uint32("requestID", requestID),
-					zap.Stringer("req", req),
-					zap.Error(err),
-				)
-				return nil // dropping request
+		if errors.Is(err, merkledb.ErrStartRootNotFound) || (err == nil && !changeProof.HadRootsInHistory) {
+			// The changes since the start root can't be proven, so send the
+			// keys in the range at the end root instead.
+			// If the end root isn't in the history either, this fails with
+			// [merkledb.ErrRootIDNotPresent].
+			proofBytes, err := s.getRangeProofAtRoot(ctx, endRoot, req.Start, req.End, keyLimit, bytesLimit)
+			if err != nil {
+				return nil, err
 			}
-			return err
+			return &syncpb.Response{
+				Message: &syncpb.Response_RangeProof{
+					RangeProof: proofBytes,
+				},
+			}, nil
+		}
+		if err != nil {
+			return nil, err
 		}
 
-		proofBytes, err := merkledb.Codec.EncodeChangeProof(merkledb.Version, changeProof)
+		proofBytes, err := s.codec.EncodeChangeProof(merkledb.Version, changeProof)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(proofBytes) < bytesLimit {
-			return s.appSender.SendAppResponse(ctx, nodeID, requestID, proofBytes)
+			return &syncpb.Response{
+				Message: &syncpb.Response_Proof{
+					Proof: proofBytes,
+				},
+			}, nil
 		}
 		// the proof size was too large, try to shrink it
 		keyLimit = uint32(len(changeProof.KeyChanges)) / 2
 	}
-	return ErrMinProofSizeIsTooLarge
+	return nil, ErrMinProofSizeIsTooLarge
 }
 
-// Generates a range proof and sends it to [nodeID].
+// Generates a range proof and sends it to [nodeID] in a Response.
 // TODO danlaine how should we handle context cancellation?
 func (s *NetworkServer) HandleRangeProofRequest(
 	ctx context.Context,
@@ -224,17 +499,55 @@ func (s *NetworkServer) HandleRangeProofRequest(
 	requestID uint32,
 	req *syncpb.RangeProofRequest,
 ) error {
+	return s.handleRangeProofRequest(
+		ctx,
+		nodeID,
+		requestID,
+		&syncpb.Request{
+			Message: &syncpb.Request_RangeProofRequest{
+				RangeProofRequest: req,
+			},
+			WantsResponse: true,
+		},
+		req,
+	)
+}
+
+// Generates a range proof and sends it to [nodeID] in the format that
+// [request] wants.
+func (s *NetworkServer) handleRangeProofRequest(
+	ctx context.Context,
+	nodeID ids.NodeID,
+	requestID uint32,
+	request *syncpb.Request,
+	req *syncpb.RangeProofRequest,
+) error {
+	return s.handleProofRequest(
+		ctx,
+		nodeID,
+		requestID,
+		request,
+		func(ctx context.Context) (*syncpb.Response, error) {
+			proofBytes, err := s.getRangeProof(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return &syncpb.Response{
+				Message: &syncpb.Response_Proof{
+					Proof: proofBytes,
+				},
+			}, nil
+		},
+	)
+}
+
+// Returns the encoded range proof given by [req].
+func (s *NetworkServer) getRangeProof(ctx context.Context, req *syncpb.RangeProofRequest) ([]byte, error) {
 	if req.BytesLimit == 0 ||
 		req.KeyLimit == 0 ||
 		len(req.Root) != hashing.HashLen ||
 		(len(req.End) > 0 && bytes.Compare(req.Start, req.End) > 0) {
-		s.log.Debug(
-			"dropping invalid range proof request",
-			zap.Stringer("nodeID", nodeID),
-			zap.Uint32("requestID", requestID),
-			zap.Stringer("req", req),
-		)
-		return nil // dropping request
+		return nil, ErrInvalidRequest
 	}
 
 	// override limit if it is greater than maxKeyValuesLimit
@@ -246,36 +559,38 @@ func (s *NetworkServer) HandleRangeProofRequest(
 	if bytesLimit > maxByteSizeLimit {
 		bytesLimit = maxByteSizeLimit
 	}
+	root, err := ids.ToID(req.Root)
+	if err != nil {
+		return nil, err
+	}
+	return s.getRangeProofAtRoot(ctx, root, req.Start, req.End, keyLimit, bytesLimit)
+}
+
+// Returns the encoded range proof of at most [keyLimit] keys in [start, end]
+// at [root], shrinking it until it's smaller than [bytesLimit].
+func (s *NetworkServer) getRangeProofAtRoot(
+	ctx context.Context,
+	root ids.ID,
+	start []byte,
+	end []byte,
+	keyLimit uint32,
+	bytesLimit int,
+) ([]byte, error) {
 	for keyLimit > 0 {
-		root, err := ids.ToID(req.Root)
+		rangeProof, err := s.db.GetRangeProofAtRoot(ctx, root, start, end, int(keyLimit))
 		if err != nil {
-			return err
-		}
-		rangeProof, err := s.db.GetRangeProofAtRoot(ctx, root, req.Start, req.End, int(keyLimit))
-		if err != nil {
-			// handle expected errors so clients cannot cause servers to spam warning logs.
-			if errors.Is(err, merkledb.ErrRootIDNotPresent) {
-				s.log.Debug(
-					"dropping invalid range proof request",
-					zap.Stringer("nodeID", nodeID),
-					zap.Uint32("requestID", requestID),
-					zap.Stringer("req", req),
-					zap.Error(err),
-				)
-				return nil // dropping request
-			}
-			return err
+			return nil, err
 		}
 
-		proofBytes, err := merkledb.Codec.EncodeRangeProof(merkledb.Version, rangeProof)
+		proofBytes, err := s.codec.EncodeRangeProof(merkledb.Version, rangeProof)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(proofBytes) < bytesLimit {
-			return s.appSender.SendAppResponse(ctx, nodeID, requestID, proofBytes)
+			return proofBytes, nil
 		}
 		// the proof size was too large, try to shrink it
 		keyLimit = uint32(len(rangeProof.KeyValues)) / 2
 	}
-	return ErrMinProofSizeIsTooLarge
+	return nil, ErrMinProofSizeIsTooLarge
 }
@@ -7,11 +7,15 @@ import (
 	"context"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 
 	"github.com/stretchr/testify/require"
 
+	"google.golang.org/protobuf/proto"
+
+	"github.com/memeticofficial/pepecoingo/database/memdb"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/snow/engine/common"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
@@ -105,15 +109,16 @@ func Test_Server_GetRangeProof(t *testing.T) {
 				func(_ context.Context, _ ids.NodeID, requestID uint32, responseBytes []byte) error {
 					// grab a copy of the proof so we can inspect it later
 					if !test.proofNil {
-						var err error
+						response, err := parseResponse(responseBytes)
+						require.NoError(err)
 						proofResult = &merkledb.RangeProof{}
-						_, err = merkledb.Codec.DecodeRangeProof(responseBytes, proofResult)
+						_, err = merkledb.Codec.DecodeRangeProof(response.GetProof(), proofResult)
 						require.NoError(err)
 					}
 					return nil
 				},
 			).AnyTimes()
-			handler := NewNetworkServer(sender, smallTrieDB, logging.NoLog{})
+			handler := NewNetworkServer(sender, smallTrieDB, logging.NoLog{}, NetworkServerConfig{Metrics: &mockServerMetrics{}})
 			err := handler.HandleRangeProofRequest(context.Background(), test.nodeID, 0, test.request)
 			if test.expectedErr != nil {
 				require.ErrorIs(err, test.expectedErr)
@@ -254,15 +259,16 @@ func Test_Server_GetChangeProof(t *testing.T) {
 				func(_ context.Context, _ ids.NodeID, requestID uint32, responseBytes []byte) error {
 					// grab a copy of the proof so we can inspect it later
 					if !test.proofNil {
-						var err error
+						response, err := parseResponse(responseBytes)
+						require.NoError(err)
 						proofResult = &merkledb.ChangeProof{}
-						_, err = merkledb.Codec.DecodeChangeProof(responseBytes, proofResult)
+						_, err = merkledb.Codec.DecodeChangeProof(response.GetProof(), proofResult)
 						require.NoError(err)
 					}
 					return nil
 				},
 			).AnyTimes()
-			handler := NewNetworkServer(sender, trieDB, logging.NoLog{})
+			handler := NewNetworkServer(sender, trieDB, logging.NoLog{}, NetworkServerConfig{Metrics: &mockServerMetrics{}})
 			err := handler.HandleChangeProofRequest(context.Background(), test.nodeID, 0, test.request)
 			if test.expectedErr != nil {
 				require.ErrorIs(err, test.expectedErr)
@@ -287,3 +293,407 @@ func Test_Server_GetChangeProof(t *testing.T) {
 		})
 	}
 }
+
+// Sends [requests] from [nodeID] to a server for [db] and returns the errors
+// in the responses.
+func sendAppRequest(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	db *merkledb.Database,
+	config NetworkServerConfig,
+	nodeID ids.NodeID,
+	requests ...[]byte,
+) []error {
+	require := require.New(t)
+
+	var errs []error
+	sender := common.NewMockSender(ctrl)
+	sender.EXPECT().SendAppResponse(
+		gomock.Any(), // ctx
+		nodeID,
+		gomock.Any(), // requestID
+		gomock.Any(), // responseBytes
+	).DoAndReturn(
+		func(_ context.Context, _ ids.NodeID, _ uint32, responseBytes []byte) error {
+			_, err := parseResponse(responseBytes)
+			errs = append(errs, err)
+			return nil
+		},
+	).Times(len(requests))
+
+	server := NewNetworkServer(sender, db, logging.NoLog{}, config)
+	for i, request := range requests {
+		require.NoError(server.AppRequest(context.Background(), nodeID, uint32(i), time.Now().Add(time.Hour), request))
+	}
+	return errs
+}
+
+func marshalRequest(t *testing.T, request *syncpb.Request) []byte {
+	requestBytes, err := proto.Marshal(request)
+	require.NoError(t, err)
+	return requestBytes
+}
+
+func Test_Server_ErrorResponses(t *testing.T) {
+	r := rand.New(rand.NewSource(1)) // #nosec G404
+	db, _, err := generateTrieWithMinKeyLen(t, r, defaultRequestKeyLimit, 1)
+	require.NoError(t, err)
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(t, err)
+	unknownRoot := ids.GenerateTestID()
+
+	tests := map[string]struct {
+		request      []byte
+		expectedErr  error
+		expectedCode syncpb.ErrorCode
+	}{
+		"malformed request": {
+			request:      []byte{0xFF},
+			expectedErr:  ErrInvalidRequest,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST,
+		},
+		"empty request": {
+			request:      marshalRequest(t, &syncpb.Request{WantsResponse: true}),
+			expectedErr:  ErrInvalidRequest,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST,
+		},
+		"invalid range proof request": {
+			request: marshalRequest(t, &syncpb.Request{
+				Message: &syncpb.Request_RangeProofRequest{
+					RangeProofRequest: &syncpb.RangeProofRequest{
+						Root:       root[:],
+						KeyLimit:   defaultRequestKeyLimit,
+						BytesLimit: 0,
+					},
+				},
+				WantsResponse: true,
+			}),
+			expectedErr:  ErrInvalidRequest,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST,
+		},
+		"range proof root not found": {
+			request: marshalRequest(t, &syncpb.Request{
+				Message: &syncpb.Request_RangeProofRequest{
+					RangeProofRequest: &syncpb.RangeProofRequest{
+						Root:       unknownRoot[:],
+						KeyLimit:   defaultRequestKeyLimit,
+						BytesLimit: defaultRequestByteSizeLimit,
+					},
+				},
+				WantsResponse: true,
+			}),
+			expectedErr:  ErrRootNotFound,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_ROOT_NOT_FOUND,
+		},
+		"range proof too large": {
+			request: marshalRequest(t, &syncpb.Request{
+				Message: &syncpb.Request_RangeProofRequest{
+					RangeProofRequest: &syncpb.RangeProofRequest{
+						Root:       root[:],
+						KeyLimit:   defaultRequestKeyLimit,
+						BytesLimit: 1000,
+					},
+				},
+				WantsResponse: true,
+			}),
+			expectedErr:  ErrMinProofSizeIsTooLarge,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_PROOF_TOO_LARGE,
+		},
+		"change proof with the same roots": {
+			request: marshalRequest(t, &syncpb.Request{
+				Message: &syncpb.Request_ChangeProofRequest{
+					ChangeProofRequest: &syncpb.ChangeProofRequest{
+						StartRoot:  root[:],
+						EndRoot:    root[:],
+						KeyLimit:   defaultRequestKeyLimit,
+						BytesLimit: defaultRequestByteSizeLimit,
+					},
+				},
+				WantsResponse: true,
+			}),
+			expectedErr:  ErrInvalidRequest,
+			expectedCode: syncpb.ErrorCode_ERROR_CODE_INVALID_REQUEST,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			metrics := &mockServerMetrics{}
+			errs := sendAppRequest(t, ctrl, db, NetworkServerConfig{Metrics: metrics}, ids.GenerateTestNodeID(), test.request)
+			require.Len(errs, 1)
+			require.ErrorIs(errs[0], test.expectedErr)
+			require.Equal(map[syncpb.ErrorCode]int{test.expectedCode: 1}, metrics.errorResponsesSent)
+		})
+	}
+}
+
+func Test_Server_Throttling(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(1)) // #nosec G404
+	db, _, err := generateTrieWithMinKeyLen(t, r, 100, 1)
+	require.NoError(err)
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	request := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_RangeProofRequest{
+			RangeProofRequest: &syncpb.RangeProofRequest{
+				Root:       root[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+		WantsResponse: true,
+	})
+
+	metrics := &mockServerMetrics{}
+	errs := sendAppRequest(
+		t,
+		ctrl,
+		db,
+		NetworkServerConfig{
+			Metrics:                  metrics,
+			RequestsPerSecondPerPeer: 0.001,
+			RequestBurstPerPeer:      2,
+		},
+		ids.GenerateTestNodeID(),
+		request,
+		request,
+		request,
+	)
+	require.Len(errs, 3)
+	require.NoError(errs[0])
+	require.NoError(errs[1])
+	require.ErrorIs(errs[2], ErrRequestThrottled)
+	require.Equal(3, metrics.requestsReceived)
+	require.Equal(1, metrics.requestsThrottled)
+}
+
+func Test_Server_ProofCache(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(1)) // #nosec G404
+	db, _, err := generateTrieWithMinKeyLen(t, r, 100, 1)
+	require.NoError(err)
+	startRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	require.NoError(db.Put([]byte{1}, []byte{1}))
+	endRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	rangeProofRequest := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_RangeProofRequest{
+			RangeProofRequest: &syncpb.RangeProofRequest{
+				Root:       startRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+		WantsResponse: true,
+	})
+	changeProofRequest := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_ChangeProofRequest{
+			ChangeProofRequest: &syncpb.ChangeProofRequest{
+				StartRoot:  startRoot[:],
+				EndRoot:    endRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+		WantsResponse: true,
+	})
+	unknownRoot := ids.GenerateTestID()
+	missingRootRequest := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_ChangeProofRequest{
+			ChangeProofRequest: &syncpb.ChangeProofRequest{
+				StartRoot:  startRoot[:],
+				EndRoot:    unknownRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+		WantsResponse: true,
+	})
+
+	metrics := &mockServerMetrics{}
+	errs := sendAppRequest(
+		t,
+		ctrl,
+		db,
+		NetworkServerConfig{Metrics: metrics},
+		ids.GenerateTestNodeID(),
+		rangeProofRequest,
+		changeProofRequest,
+		rangeProofRequest,
+		changeProofRequest,
+		// An error response isn't cached.
+		missingRootRequest,
+		missingRootRequest,
+	)
+	require.Len(errs, 6)
+	for _, err := range errs[:4] {
+		require.NoError(err)
+	}
+	for _, err := range errs[4:] {
+		require.ErrorIs(err, ErrRootNotFound)
+	}
+	require.Equal(2, metrics.proofCacheHits)
+	require.Equal(4, metrics.proofCacheMisses)
+}
+
+func Test_Server_GetChangeProof_StartRootEvicted(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(1)) // #nosec G404
+	db, err := merkledb.New(
+		context.Background(),
+		memdb.New(),
+		merkledb.Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 5,
+			NodeCacheSize: 1000,
+		},
+	)
+	require.NoError(err)
+	require.NoError(db.Put([]byte{0}, []byte{0}))
+	startRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	// Evict [startRoot] from the history.
+	for i := 0; i < 10; i++ {
+		key := make([]byte, r.Intn(50)+1)
+		_, err = r.Read(key)
+		require.NoError(err)
+		require.NoError(db.Put(key, key))
+	}
+	endRoot, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+
+	request := &syncpb.ChangeProofRequest{
+		StartRoot:  startRoot[:],
+		EndRoot:    endRoot[:],
+		KeyLimit:   defaultRequestKeyLimit,
+		BytesLimit: defaultRequestByteSizeLimit,
+	}
+
+	var response *syncpb.Response
+	sender := common.NewMockSender(ctrl)
+	sender.EXPECT().SendAppResponse(
+		gomock.Any(), // ctx
+		gomock.Any(), // nodeID
+		gomock.Any(), // requestID
+		gomock.Any(), // responseBytes
+	).DoAndReturn(
+		func(_ context.Context, _ ids.NodeID, _ uint32, responseBytes []byte) error {
+			response, err = parseResponse(responseBytes)
+			return err
+		},
+	)
+	server := NewNetworkServer(sender, db, logging.NoLog{}, NetworkServerConfig{Metrics: &mockServerMetrics{}})
+	require.NoError(server.HandleChangeProofRequest(context.Background(), ids.GenerateTestNodeID(), 0, request))
+
+	// The server sends the keys at [endRoot] instead of the changes since
+	// [startRoot].
+	require.Nil(response.GetProof())
+	rangeProof := &merkledb.RangeProof{}
+	_, err = merkledb.Codec.DecodeRangeProof(response.GetRangeProof(), rangeProof)
+	require.NoError(err)
+	require.Len(rangeProof.KeyValues, 11)
+	require.NoError(rangeProof.Verify(
+		context.Background(),
+		nil,
+		nil,
+		endRoot,
+		merkledb.DefaultBranchFactor,
+		merkledb.SHA256Hasher,
+	))
+}
+
+// Peers that predate Response are sent the encoded proof, and nothing on
+// failure.
+func Test_Server_LegacyResponses(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := rand.New(rand.NewSource(1)) // #nosec G404
+	db, _, err := generateTrieWithMinKeyLen(t, r, 100, 1)
+	require.NoError(err)
+	root, err := db.GetMerkleRoot(context.Background())
+	require.NoError(err)
+	unknownRoot := ids.GenerateTestID()
+
+	rangeProofRequest := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_RangeProofRequest{
+			RangeProofRequest: &syncpb.RangeProofRequest{
+				Root:       root[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+	})
+	missingRootRequest := marshalRequest(t, &syncpb.Request{
+		Message: &syncpb.Request_RangeProofRequest{
+			RangeProofRequest: &syncpb.RangeProofRequest{
+				Root:       unknownRoot[:],
+				KeyLimit:   defaultRequestKeyLimit,
+				BytesLimit: defaultRequestByteSizeLimit,
+			},
+		},
+	})
+
+	nodeID := ids.GenerateTestNodeID()
+	var responses [][]byte
+	sender := common.NewMockSender(ctrl)
+	sender.EXPECT().SendAppResponse(
+		gomock.Any(), // ctx
+		nodeID,
+		gomock.Any(), // requestID
+		gomock.Any(), // responseBytes
+	).DoAndReturn(
+		func(_ context.Context, _ ids.NodeID, _ uint32, responseBytes []byte) error {
+			responses = append(responses, responseBytes)
+			return nil
+		},
+	).Times(2)
+
+	metrics := &mockServerMetrics{}
+	server := NewNetworkServer(sender, db, logging.NoLog{}, NetworkServerConfig{Metrics: metrics})
+	for i, request := range [][]byte{rangeProofRequest, missingRootRequest, rangeProofRequest} {
+		require.NoError(server.AppRequest(context.Background(), nodeID, uint32(i), time.Now().Add(time.Hour), request))
+	}
+
+	// The missing root isn't reported, and the second range proof is served
+	// from the cache in the same format.
+	require.Len(responses, 2)
+	require.Equal(responses[0], responses[1])
+	require.Empty(metrics.errorResponsesSent)
+	require.Equal(1, metrics.proofCacheHits)
+
+	rangeProof := &merkledb.RangeProof{}
+	_, err = merkledb.Codec.DecodeRangeProof(responses[0], rangeProof)
+	require.NoError(err)
+	require.NoError(rangeProof.Verify(
+		context.Background(),
+		nil,
+		nil,
+		root,
+		merkledb.DefaultBranchFactor,
+		merkledb.SHA256Hasher,
+	))
+
+	// The client accepts the legacy format.
+	response, err := parseResponse(responses[0])
+	require.NoError(err)
+	require.Equal(responses[0], response.GetProof())
+}
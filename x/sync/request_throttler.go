@@ -0,0 +1,94 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package sync
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+
+	"github.com/memeticofficial/pepecoingo/cache"
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+// The maximum number of peers whose request rates are tracked at once.
+// When a peer that isn't tracked makes a request, the rate of the peer that
+// made a request least recently is forgotten.
+const maxRateLimitedPeers = 1024
+
+// Limits the rate and concurrency of the requests handled by a NetworkServer.
+type requestThrottler struct {
+	requestsPerSecond            rate.Limit
+	requestBurst                 int
+	maxConcurrentRequestsPerPeer int
+	maxConcurrentRequests        int
+
+	lock sync.Mutex
+	// Node ID --> token bucket based rate limiter where each token is a
+	// request.
+	// [lock] must be held when accessing [limiters].
+	limiters cache.LRU[ids.NodeID, *rate.Limiter]
+	// Node ID --> number of requests from the node being handled.
+	// Nodes with no requests being handled aren't in the map.
+	// [lock] must be held when accessing [processingRequests].
+	processingRequests map[ids.NodeID]int
+	// The number of requests being handled.
+	// [lock] must be held when accessing [numProcessingRequests].
+	numProcessingRequests int
+}
+
+func newRequestThrottler(
+	requestsPerSecond float64,
+	requestBurst int,
+	maxConcurrentRequestsPerPeer int,
+	maxConcurrentRequests int,
+) *requestThrottler {
+	return &requestThrottler{
+		requestsPerSecond:            rate.Limit(requestsPerSecond),
+		requestBurst:                 requestBurst,
+		maxConcurrentRequestsPerPeer: maxConcurrentRequestsPerPeer,
+		maxConcurrentRequests:        maxConcurrentRequests,
+		limiters:                     cache.LRU[ids.NodeID, *rate.Limiter]{Size: maxRateLimitedPeers},
+		processingRequests:           make(map[ids.NodeID]int),
+	}
+}
+
+// Returns true if a request from [nodeID] can be handled now, in which case
+// release must be called once it's handled.
+// Returns false if the request should be rejected.
+func (t *requestThrottler) acquire(nodeID ids.NodeID) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.numProcessingRequests >= t.maxConcurrentRequests ||
+		t.processingRequests[nodeID] >= t.maxConcurrentRequestsPerPeer {
+		return false
+	}
+
+	limiter, ok := t.limiters.Get(nodeID)
+	if !ok {
+		limiter = rate.NewLimiter(t.requestsPerSecond, t.requestBurst)
+		t.limiters.Put(nodeID, limiter)
+	}
+	if !limiter.Allow() {
+		return false
+	}
+
+	t.processingRequests[nodeID]++
+	t.numProcessingRequests++
+	return true
+}
+
+// Marks a request from [nodeID], for which acquire returned true, as handled.
+func (t *requestThrottler) release(nodeID ids.NodeID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.numProcessingRequests--
+	if t.processingRequests[nodeID] <= 1 {
+		delete(t.processingRequests, nodeID)
+		return
+	}
+	t.processingRequests[nodeID]--
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2019-2023, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/memeticofficial/pepecoingo/ids"
+)
+
+func TestRequestThrottlerConcurrency(t *testing.T) {
+	require := require.New(t)
+
+	throttler := newRequestThrottler(1000, 1000, 2, 3)
+	nodeID0 := ids.GenerateTestNodeID()
+	nodeID1 := ids.GenerateTestNodeID()
+
+	require.True(throttler.acquire(nodeID0))
+	require.True(throttler.acquire(nodeID0))
+	// [nodeID0] is at its limit.
+	require.False(throttler.acquire(nodeID0))
+
+	require.True(throttler.acquire(nodeID1))
+	// Every node is at the overall limit.
+	require.False(throttler.acquire(nodeID1))
+
+	throttler.release(nodeID0)
+	require.True(throttler.acquire(nodeID1))
+	require.False(throttler.acquire(nodeID0))
+
+	throttler.release(nodeID0)
+	throttler.release(nodeID1)
+	throttler.release(nodeID1)
+	require.Empty(throttler.processingRequests)
+	require.Zero(throttler.numProcessingRequests)
+}
+
+func TestRequestThrottlerRate(t *testing.T) {
+	require := require.New(t)
+
+	throttler := newRequestThrottler(0.001, 2, 10, 10)
+	nodeID0 := ids.GenerateTestNodeID()
+	nodeID1 := ids.GenerateTestNodeID()
+
+	for i := 0; i < 2; i++ {
+		require.True(throttler.acquire(nodeID0))
+		throttler.release(nodeID0)
+	}
+	// [nodeID0] used its burst.
+	require.False(throttler.acquire(nodeID0))
+
+	// Other nodes have their own rate.
+	require.True(throttler.acquire(nodeID1))
+	throttler.release(nodeID1)
+}
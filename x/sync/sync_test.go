@@ -6,7 +6,10 @@ package sync
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -37,7 +40,7 @@ type mockClient struct {
 	db *merkledb.Database
 }
 
-func (client *mockClient) GetChangeProof(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*merkledb.ChangeProof, error) {
+func (client *mockClient) GetChangeProof(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*ChangeOrRangeProof, error) {
 	startRoot, err := ids.ToID(request.StartRoot)
 	if err != nil {
 		return nil, err
@@ -46,7 +49,33 @@ func (client *mockClient) GetChangeProof(ctx context.Context, request *syncpb.Ch
 	if err != nil {
 		return nil, err
 	}
-	return client.db.GetChangeProof(ctx, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
+	return getChangeOrRangeProof(ctx, client.db, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
+}
+
+// Returns the change proof of [start, end] between [startRoot] and [endRoot]
+// in [db], or the range proof at [endRoot] if [startRoot] isn't in the
+// history before [endRoot], as a NetworkServer would.
+func getChangeOrRangeProof(
+	ctx context.Context,
+	db *merkledb.Database,
+	startRoot ids.ID,
+	endRoot ids.ID,
+	start []byte,
+	end []byte,
+	keyLimit int,
+) (*ChangeOrRangeProof, error) {
+	changeProof, err := db.GetChangeProof(ctx, startRoot, endRoot, start, end, keyLimit)
+	if err == nil && changeProof.HadRootsInHistory {
+		return &ChangeOrRangeProof{ChangeProof: changeProof}, nil
+	}
+	if err != nil && !errors.Is(err, merkledb.ErrStartRootNotFound) {
+		return nil, err
+	}
+	rangeProof, err := db.GetRangeProofAtRoot(ctx, endRoot, start, end, keyLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeOrRangeProof{RangeProof: rangeProof}, nil
 }
 
 func (client *mockClient) GetRangeProof(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
@@ -622,7 +651,7 @@ func TestFindNextKeyRandom(t *testing.T) {
 		for _, node := range remoteProof.EndProof {
 			for childIdx, childID := range node.Children {
 				remoteKeyIDs = append(remoteKeyIDs, keyAndID{
-					key: node.KeyPath.AppendNibble(childIdx),
+					key: node.KeyPath.AppendToken(childIdx, merkledb.BranchFactor16),
 					id:  childID,
 				})
 			}
@@ -633,7 +662,7 @@ func TestFindNextKeyRandom(t *testing.T) {
 		for _, node := range localProof.Path {
 			for childIdx, childID := range node.Children {
 				localKeyIDs = append(localKeyIDs, keyAndID{
-					key: node.KeyPath.AppendNibble(childIdx),
+					key: node.KeyPath.AppendToken(childIdx, merkledb.BranchFactor16),
 					id:  childID,
 				})
 			}
@@ -821,6 +850,55 @@ func Test_Sync_Result_Correct_Root(t *testing.T) {
 	}
 }
 
+func Test_Sync_Result_Correct_Root_TrieConfigs(t *testing.T) {
+	for _, branchFactor := range []merkledb.BranchFactor{
+		merkledb.BranchFactor2,
+		merkledb.BranchFactor4,
+		merkledb.BranchFactor16,
+		merkledb.BranchFactor256,
+	} {
+		for _, hasher := range []merkledb.Hasher{merkledb.SHA256Hasher, merkledb.BLAKE2bHasher} {
+			t.Run(fmt.Sprintf("%d/%s", branchFactor, hasher), func(t *testing.T) {
+				require := require.New(t)
+
+				r := rand.New(rand.NewSource(int64(branchFactor))) // #nosec G404
+				dbToSync, _, err := generateTrieWithTrieConfig(t, r, 1000, 0, branchFactor, hasher)
+				require.NoError(err)
+				syncRoot, err := dbToSync.GetMerkleRoot(context.Background())
+				require.NoError(err)
+
+				db, err := merkledb.New(
+					context.Background(),
+					memdb.New(),
+					merkledb.Config{
+						Tracer:        newNoopTracer(),
+						HistoryLength: 0,
+						NodeCacheSize: 1000,
+						BranchFactor:  branchFactor,
+						Hasher:        hasher,
+					},
+				)
+				require.NoError(err)
+				syncer, err := NewStateSyncManager(StateSyncConfig{
+					SyncDB:                db,
+					Client:                &mockClient{db: dbToSync},
+					TargetRoot:            syncRoot,
+					SimultaneousWorkLimit: 5,
+					Log:                   logging.NoLog{},
+				})
+				require.NoError(err)
+				require.NoError(syncer.StartSyncing(context.Background()))
+				require.NoError(syncer.Wait(context.Background()))
+				require.NoError(syncer.Error())
+
+				newRoot, err := db.GetMerkleRoot(context.Background())
+				require.NoError(err)
+				require.Equal(syncRoot, newRoot)
+			})
+		}
+	}
+}
+
 func Test_Sync_Result_Correct_Root_With_Sync_Restart(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		r := rand.New(rand.NewSource(int64(i))) // #nosec G404
@@ -917,12 +995,12 @@ func Test_Sync_Error_During_Sync(t *testing.T) {
 		},
 	).AnyTimes()
 	client.EXPECT().GetChangeProof(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-		func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*merkledb.ChangeProof, error) {
+		func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*ChangeOrRangeProof, error) {
 			startRoot, err := ids.ToID(request.StartRoot)
 			require.NoError(err)
 			endRoot, err := ids.ToID(request.EndRoot)
 			require.NoError(err)
-			return dbToSync.GetChangeProof(ctx, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
+			return getChangeOrRangeProof(ctx, dbToSync, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
 		},
 	).AnyTimes()
 
@@ -981,13 +1059,13 @@ func Test_Sync_Result_Correct_Root_Update_Root_During(t *testing.T) {
 			},
 		).AnyTimes()
 		client.EXPECT().GetChangeProof(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-			func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*merkledb.ChangeProof, error) {
+			func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*ChangeOrRangeProof, error) {
 				<-updatedRootChan
 				startRoot, err := ids.ToID(request.StartRoot)
 				require.NoError(err)
 				endRoot, err := ids.ToID(request.EndRoot)
 				require.NoError(err)
-				return dbToSync.GetChangeProof(ctx, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
+				return getChangeOrRangeProof(ctx, dbToSync, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
 			},
 		).AnyTimes()
 
@@ -1057,6 +1135,199 @@ func Test_Sync_Result_Correct_Root_Update_Root_During(t *testing.T) {
 	}
 }
 
+// Tests that a sync completes when the root that parts of the sync DB were
+// synced to is evicted from the history of the peers before the changes since
+// it are fetched.
+func Test_Sync_Result_Correct_Root_History_Evicted_During(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for i := 0; i < 3; i++ {
+		r := rand.New(rand.NewSource(int64(i))) // #nosec G404
+
+		// [oldDB] is never changed, so it can always serve range proofs at
+		// [oldRoot].
+		oldDB, err := generateTrie(t, r, 10000)
+		require.NoError(err)
+		oldRoot, err := oldDB.GetMerkleRoot(context.Background())
+		require.NoError(err)
+
+		// [dbToSync] has the same keys as [oldDB] but only a short history.
+		dbToSync, err := merkledb.New(
+			context.Background(),
+			memdb.New(),
+			merkledb.Config{
+				Tracer:        newNoopTracer(),
+				HistoryLength: 5,
+				NodeCacheSize: 1000,
+			},
+		)
+		require.NoError(err)
+		batch := dbToSync.NewBatch()
+		it := oldDB.NewIterator()
+		for it.Next() {
+			require.NoError(batch.Put(it.Key(), it.Value()))
+		}
+		require.NoError(it.Error())
+		it.Release()
+		require.NoError(batch.Write())
+		root, err := dbToSync.GetMerkleRoot(context.Background())
+		require.NoError(err)
+		require.Equal(oldRoot, root)
+
+		db, err := merkledb.New(
+			context.Background(),
+			memdb.New(),
+			merkledb.Config{
+				Tracer:        newNoopTracer(),
+				HistoryLength: 0,
+				NodeCacheSize: 1000,
+			},
+		)
+		require.NoError(err)
+
+		// Only let one response go through until we update the root.
+		updatedRootChan := make(chan struct{}, 1)
+		updatedRootChan <- struct{}{}
+		var numRangeProofFallbacks int32
+		client := NewMockClient(ctrl)
+		client.EXPECT().GetRangeProof(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, request *syncpb.RangeProofRequest) (*merkledb.RangeProof, error) {
+				<-updatedRootChan
+				root, err := ids.ToID(request.Root)
+				require.NoError(err)
+				if root == oldRoot {
+					return oldDB.GetRangeProofAtRoot(ctx, root, request.Start, request.End, int(request.KeyLimit))
+				}
+				return dbToSync.GetRangeProofAtRoot(ctx, root, request.Start, request.End, int(request.KeyLimit))
+			},
+		).AnyTimes()
+		client.EXPECT().GetChangeProof(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, request *syncpb.ChangeProofRequest, _ *merkledb.Database) (*ChangeOrRangeProof, error) {
+				<-updatedRootChan
+				startRoot, err := ids.ToID(request.StartRoot)
+				require.NoError(err)
+				endRoot, err := ids.ToID(request.EndRoot)
+				require.NoError(err)
+				proof, err := getChangeOrRangeProof(ctx, dbToSync, startRoot, endRoot, request.Start, request.End, int(request.KeyLimit))
+				if err == nil && proof.RangeProof != nil {
+					atomic.AddInt32(&numRangeProofFallbacks, 1)
+				}
+				return proof, err
+			},
+		).AnyTimes()
+
+		syncer, err := NewStateSyncManager(StateSyncConfig{
+			SyncDB:                db,
+			Client:                client,
+			TargetRoot:            oldRoot,
+			SimultaneousWorkLimit: 5,
+			Log:                   logging.NoLog{},
+		})
+		require.NoError(err)
+		require.NoError(syncer.StartSyncing(context.Background()))
+
+		// Wait until we've processed some work before changing [dbToSync].
+		require.Eventually(
+			func() bool {
+				syncer.workLock.Lock()
+				defer syncer.workLock.Unlock()
+
+				return syncer.processedWork.Len() > 0
+			},
+			3*time.Second,
+			10*time.Millisecond,
+		)
+
+		// Make more changes than [dbToSync] keeps in its history, so
+		// [oldRoot] is evicted from it.
+		for x := 0; x < 50; x++ {
+			key := make([]byte, r.Intn(50))
+			_, err = r.Read(key)
+			require.NoError(err)
+
+			val := make([]byte, r.Intn(50))
+			_, err = r.Read(val)
+			require.NoError(err)
+
+			require.NoError(dbToSync.Put(key, val))
+
+			deleteKeyStart := make([]byte, r.Intn(50))
+			_, err = r.Read(deleteKeyStart)
+			require.NoError(err)
+
+			it := dbToSync.NewIteratorWithStart(deleteKeyStart)
+			if it.Next() {
+				require.NoError(dbToSync.Delete(it.Key()))
+			}
+			require.NoError(it.Error())
+			it.Release()
+		}
+		syncRoot, err := dbToSync.GetMerkleRoot(context.Background())
+		require.NoError(err)
+		_, err = dbToSync.GetChangeProof(context.Background(), oldRoot, syncRoot, nil, nil, 1)
+		require.ErrorIs(err, merkledb.ErrStartRootNotFound)
+
+		require.NoError(syncer.UpdateSyncTarget(syncRoot))
+		close(updatedRootChan)
+
+		require.NoError(syncer.Wait(context.Background()))
+		require.NoError(syncer.Error())
+		require.Positive(atomic.LoadInt32(&numRangeProofFallbacks))
+
+		newRoot, err := db.GetMerkleRoot(context.Background())
+		require.NoError(err)
+		require.Equal(syncRoot, newRoot)
+	}
+}
+
+func Test_Sync_ApplyEmptyRangeProof(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, err := merkledb.New(
+		context.Background(),
+		memdb.New(),
+		merkledb.Config{
+			Tracer:        newNoopTracer(),
+			HistoryLength: 0,
+			NodeCacheSize: 1000,
+		},
+	)
+	require.NoError(err)
+	// Keys synced to a previous root.
+	for _, key := range [][]byte{{0}, {1}, {2}, {2, 0}, {3}} {
+		require.NoError(db.Put(key, key))
+	}
+
+	targetRoot := ids.GenerateTestID()
+	m, err := NewStateSyncManager(StateSyncConfig{
+		SyncDB:                db,
+		Client:                NewMockClient(ctrl), // Not used
+		TargetRoot:            targetRoot,
+		SimultaneousWorkLimit: 5,
+		Log:                   logging.NoLog{},
+	})
+	require.NoError(err)
+
+	// There are no keys in [1, 2] at [targetRoot].
+	workItem := newWorkItem(ids.GenerateTestID(), []byte{1}, []byte{2}, lowPriority)
+	m.applyRangeProof(context.Background(), workItem, targetRoot, &merkledb.RangeProof{})
+	require.NoError(m.Error())
+
+	for _, key := range [][]byte{{0}, {2, 0}, {3}} {
+		_, err := db.Get(key)
+		require.NoError(err)
+	}
+	for _, key := range [][]byte{{1}, {2}} {
+		_, err := db.Get(key)
+		require.ErrorIs(err, database.ErrNotFound)
+	}
+	require.Equal(1, m.processedWork.Len())
+}
+
 func Test_Sync_UpdateSyncTarget(t *testing.T) {
 	require := require.New(t)
 	ctrl := gomock.NewController(t)
@@ -1110,6 +1381,17 @@ func generateTrie(t *testing.T, r *rand.Rand, count int) (*merkledb.Database, er
 }
 
 func generateTrieWithMinKeyLen(t *testing.T, r *rand.Rand, count int, minKeyLen int) (*merkledb.Database, [][]byte, error) {
+	return generateTrieWithTrieConfig(t, r, count, minKeyLen, merkledb.DefaultBranchFactor, merkledb.SHA256Hasher)
+}
+
+func generateTrieWithTrieConfig(
+	t *testing.T,
+	r *rand.Rand,
+	count int,
+	minKeyLen int,
+	branchFactor merkledb.BranchFactor,
+	hasher merkledb.Hasher,
+) (*merkledb.Database, [][]byte, error) {
 	db, err := merkledb.New(
 		context.Background(),
 		memdb.New(),
@@ -1117,6 +1399,8 @@ func generateTrieWithMinKeyLen(t *testing.T, r *rand.Rand, count int, minKeyLen
 			Tracer:        newNoopTracer(),
 			HistoryLength: 1000,
 			NodeCacheSize: 1000,
+			BranchFactor:  branchFactor,
+			Hasher:        hasher,
 		},
 	)
 	if err != nil {
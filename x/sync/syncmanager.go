@@ -9,9 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/memeticofficial/pepecoingo/database"
 	"github.com/memeticofficial/pepecoingo/ids"
 	"github.com/memeticofficial/pepecoingo/utils/logging"
 	"github.com/memeticofficial/pepecoingo/x/merkledb"
@@ -111,6 +113,13 @@ type StateSyncConfig struct {
 	SimultaneousWorkLimit int
 	Log                   logging.Logger
 	TargetRoot            ids.ID
+	// If non-nil, the progress of the sync is periodically written to
+	// [CheckpointDB], and StartSyncing resumes from it. It should be
+	// persisted along with [SyncDB], and mustn't be shared with other syncs.
+	CheckpointDB database.Database
+	// How often the progress of the sync is written to [CheckpointDB].
+	// 0 means [defaultCheckpointInterval].
+	CheckpointInterval time.Duration
 }
 
 func NewStateSyncManager(config StateSyncConfig) (*StateSyncManager, error) {
@@ -124,6 +133,9 @@ func NewStateSyncManager(config StateSyncConfig) (*StateSyncManager, error) {
 	case config.SimultaneousWorkLimit == 0:
 		return nil, ErrZeroWorkLimit
 	}
+	if config.CheckpointInterval == 0 {
+		config.CheckpointInterval = defaultCheckpointInterval
+	}
 
 	m := &StateSyncManager{
 		config:          config,
@@ -144,14 +156,18 @@ func (m *StateSyncManager) StartSyncing(ctx context.Context) error {
 		return ErrAlreadyStarted
 	}
 
-	// Add work item to fetch the entire key range.
-	// Note that this will be the first work item to be processed.
-	m.unprocessedWork.Insert(newWorkItem(ids.Empty, nil, nil, lowPriority))
+	// Add work items to fetch the key ranges that aren't synced yet.
+	if err := m.initWork(); err != nil {
+		return err
+	}
 
 	m.syncing = true
 	ctx, m.cancelCtx = context.WithCancel(ctx)
 
 	go m.sync(ctx)
+	if m.config.CheckpointDB != nil {
+		go m.checkpointPeriodically()
+	}
 	return nil
 }
 
@@ -215,6 +231,8 @@ func (m *StateSyncManager) Close() {
 			m.cancelCtx()
 		}
 
+		m.finalCheckpoint()
+
 		// ensure any goroutines waiting for work from the heaps gets released
 		m.unprocessedWork.Close()
 		m.unprocessedWorkCond.Signal()
@@ -256,7 +274,7 @@ func (m *StateSyncManager) getAndApplyChangeProof(ctx context.Context, workItem
 		return
 	}
 
-	changeproof, err := m.config.Client.GetChangeProof(
+	proof, err := m.config.Client.GetChangeProof(
 		ctx,
 		&syncpb.ChangeProofRequest{
 			StartRoot:  workItem.LocalRootID[:],
@@ -280,9 +298,18 @@ func (m *StateSyncManager) getAndApplyChangeProof(ctx context.Context, workItem
 	default:
 	}
 
+	if proof.RangeProof != nil {
+		// The start root isn't in the peer's history, so it sent the keys in
+		// the range at [rootID] instead of the changes since the start root.
+		m.applyRangeProof(ctx, workItem, rootID, proof.RangeProof)
+		return
+	}
+
+	changeproof := proof.ChangeProof
 	// The start or end root IDs are not present in other nodes' history.
-	// Add this range as a fresh uncompleted work item to the work heap.
-	// TODO danlaine send range proof instead of failure notification
+	// Peers running an older version respond with an empty change proof
+	// instead of a range proof, so add this range as a fresh uncompleted work
+	// item to the work heap.
 	if !changeproof.HadRootsInHistory {
 		workItem.LocalRootID = ids.Empty
 		m.enqueueWork(workItem)
@@ -327,6 +354,13 @@ func (m *StateSyncManager) getAndApplyRangeProof(ctx context.Context, workItem *
 	default:
 	}
 
+	m.applyRangeProof(ctx, workItem, rootID, proof)
+}
+
+// Apply [proof], a range proof of the key range of [workItem] at [rootID], to
+// the sync DB.
+// Assumes [m.workLock] is not held.
+func (m *StateSyncManager) applyRangeProof(ctx context.Context, workItem *syncWorkItem, rootID ids.ID, proof *merkledb.RangeProof) {
 	largestHandledKey := workItem.end
 	if len(proof.KeyValues) > 0 {
 		// Add all the key-value pairs we got to the database.
@@ -336,11 +370,45 @@ func (m *StateSyncManager) getAndApplyRangeProof(ctx context.Context, workItem *
 		}
 
 		largestHandledKey = proof.KeyValues[len(proof.KeyValues)-1].Key
+	} else {
+		// There are no keys in the range at [rootID], but the sync DB may
+		// have keys in it that were synced to a previous root.
+		if err := m.deleteKeysInRange(workItem.start, workItem.end); err != nil {
+			m.setError(err)
+			return
+		}
 	}
 
 	m.completeWorkItem(ctx, workItem, largestHandledKey, rootID, proof.EndProof)
 }
 
+// Removes the keys in [start, end] from the sync DB.
+// An empty [end] means there is no upper bound.
+func (m *StateSyncManager) deleteKeysInRange(start, end []byte) error {
+	var keysToDelete [][]byte
+	it := m.config.SyncDB.NewIteratorWithStart(start)
+	for it.Next() {
+		key := it.Key()
+		if len(end) > 0 && bytes.Compare(key, end) > 0 {
+			break
+		}
+		keysToDelete = append(keysToDelete, key)
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil || len(keysToDelete) == 0 {
+		return err
+	}
+
+	batch := m.config.SyncDB.NewBatch()
+	for _, key := range keysToDelete {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
 // findNextKey attempts to find the first key larger than the lastReceivedKey that is different in the local merkle vs the merkle that is being synced.
 // Returns the first key with a difference in the range (lastReceivedKey, rangeEnd), or nil if no difference was found in the range
 func (m *StateSyncManager) findNextKey(
@@ -353,11 +421,12 @@ func (m *StateSyncManager) findNextKey(
 	// This is done by taking two proofs for the same key (one that was just received as part of a proof, and one from the local db)
 	// and traversing them from the deepest key to the shortest key.
 	// For each node in these proofs, compare if the children of that node exist or have the same id in the other proof.
-	proofKeyPath := merkledb.SerializedPath{Value: lastReceivedKey, NibbleLength: 2 * len(lastReceivedKey)}
+	branchFactor := m.config.SyncDB.BranchFactor()
+	proofKeyPath := merkledb.SerializedPath{Value: lastReceivedKey, NibbleLength: branchFactor.TokensPerByte() * len(lastReceivedKey)}
 
 	// If the received proof is an exclusion proof, the last node may be for a key that is after the lastReceivedKey.
 	// If the last received node's key is after the lastReceivedKey, it can be removed to obtain a valid proof for a prefix of the lastReceivedKey
-	if !proofKeyPath.HasPrefix(receivedProofNodes[len(receivedProofNodes)-1].KeyPath) {
+	if !proofKeyPath.HasPrefix(receivedProofNodes[len(receivedProofNodes)-1].KeyPath, branchFactor) {
 		receivedProofNodes = receivedProofNodes[:len(receivedProofNodes)-1]
 		// update the proofKeyPath to be for the prefix
 		proofKeyPath = receivedProofNodes[len(receivedProofNodes)-1].KeyPath
@@ -372,7 +441,7 @@ func (m *StateSyncManager) findNextKey(
 
 	// The local proof may also be an exclusion proof with an extra node.
 	// Remove this extra node if it exists to get a proof of the same key as the received proof
-	if !proofKeyPath.HasPrefix(localProofNodes[len(localProofNodes)-1].KeyPath) {
+	if !proofKeyPath.HasPrefix(localProofNodes[len(localProofNodes)-1].KeyPath, branchFactor) {
 		localProofNodes = localProofNodes[:len(localProofNodes)-1]
 	}
 
@@ -422,22 +491,22 @@ func (m *StateSyncManager) findNextKey(
 
 		// We only want to look at the children with keys greater than the proofKey.
 		// The proof key has the deepest node's key as a prefix,
-		// so only the next nibble of the proof key needs to be considered.
+		// so only the next token of the proof key needs to be considered.
 
 		// If the deepest node has the same key as proofKeyPath,
-		// then all of its children have keys greater than the proof key, so we can start at the 0 nibble
-		startingChildNibble := byte(0)
+		// then all of its children have keys greater than the proof key, so we can start at the 0 token
+		startingChildToken := 0
 
 		// If the deepest node has a key shorter than the key being proven,
-		// we can look at the next nibble of the proof key to determine which of that node's children have keys larger than proofKeyPath.
-		// Any child with a nibble greater than the proofKeyPath's nibble at that index will have a larger key
+		// we can look at the next token of the proof key to determine which of that node's children have keys larger than proofKeyPath.
+		// Any child with a token greater than the proofKeyPath's token at that index will have a larger key
 		if deepestNode.KeyPath.NibbleLength < proofKeyPath.NibbleLength {
-			startingChildNibble = proofKeyPath.NibbleVal(deepestNode.KeyPath.NibbleLength) + 1
+			startingChildToken = int(proofKeyPath.Token(deepestNode.KeyPath.NibbleLength, branchFactor)) + 1
 		}
 
 		// determine if there are any differences in the children for the deepest unhandled node of the two proofs
-		if childIndex, hasDifference := findChildDifference(deepestNode, deepestNodeFromOtherProof, startingChildNibble); hasDifference {
-			nextKey = deepestNode.KeyPath.AppendNibble(childIndex).Value
+		if childIndex, hasDifference := findChildDifference(deepestNode, deepestNodeFromOtherProof, startingChildToken, branchFactor); hasDifference {
+			nextKey = deepestNode.KeyPath.AppendToken(childIndex, branchFactor).Value
 			break
 		}
 	}
@@ -461,12 +530,13 @@ func (m *StateSyncManager) findNextKey(
 
 // findChildDifference returns the first child index that is different between node 1 and node 2 if one exists and
 // a bool indicating if any difference was found
-func findChildDifference(node1, node2 *merkledb.ProofNode, startIndex byte) (byte, bool) {
+func findChildDifference(node1, node2 *merkledb.ProofNode, startIndex int, branchFactor merkledb.BranchFactor) (byte, bool) {
 	var (
 		child1, child2 ids.ID
 		ok1, ok2       bool
 	)
-	for childIndex := startIndex; childIndex < merkledb.NodeBranchFactor; childIndex++ {
+	for i := startIndex; i < int(branchFactor); i++ {
+		childIndex := byte(i)
 		if node1 != nil {
 			child1, ok1 = node1.Children[childIndex]
 		}
@@ -555,7 +625,11 @@ func (m *StateSyncManager) UpdateSyncTarget(syncTargetRoot ids.ID) error {
 		// waiting on [m.unprocessedWorkCond].
 		m.unprocessedWorkCond.Signal()
 	}
-	return nil
+
+	// Record the new target before any changes towards it are applied,
+	// so that ranges synced to the previous target are resumed with
+	// change proofs from it.
+	return m.checkpoint(syncTargetRoot)
 }
 
 func (m *StateSyncManager) getTargetRoot() ids.ID {
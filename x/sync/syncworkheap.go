@@ -142,6 +142,16 @@ func (wh *syncWorkHeap) MergeInsert(item *syncWorkItem) {
 	}
 }
 
+// Returns the items in the heap sorted by range start.
+func (wh *syncWorkHeap) sortedWorkItems() []*syncWorkItem {
+	items := make([]*syncWorkItem, 0, wh.Len())
+	wh.sortedItems.Ascend(func(item *heapItem) bool {
+		items = append(items, item.workItem)
+		return true
+	})
+	return items
+}
+
 // Deletes [item] from the heap.
 func (wh *syncWorkHeap) remove(item *heapItem) {
 	oldIndex := item.heapIndex